@@ -0,0 +1,137 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Admin runs typed administrative commands against the admin database.
+type Admin struct {
+	db *Database
+}
+
+// Admin returns a handle for administrative commands.
+func (c *Client) Admin() *Admin {
+	return &Admin{db: c.Database("admin")}
+}
+
+// PingResult is the outcome of a ping command.
+type PingResult struct {
+	// RoundTrip is the time between sending the command and receiving the reply.
+	RoundTrip time.Duration
+}
+
+// Ping runs the ping command and measures its round trip.
+func (a *Admin) Ping(ctx context.Context) (*PingResult, error) {
+	clock := a.db.client.clock
+	start := clock.Now()
+	if err := a.db.RunCommand(ctx, map[string]any{"ping": 1}).Err(); err != nil {
+		return nil, err
+	}
+	return &PingResult{RoundTrip: clock.Now().Sub(start)}, nil
+}
+
+// HelloResult describes the server as reported by the hello command.
+type HelloResult struct {
+	IsWritablePrimary            bool     `json:"isWritablePrimary"`
+	Secondary                    bool     `json:"secondary"`
+	ReadOnly                     bool     `json:"readOnly"`
+	SetName                      string   `json:"setName"`
+	Hosts                        []string `json:"hosts"`
+	Primary                      string   `json:"primary"`
+	Me                           string   `json:"me"`
+	Msg                          string   `json:"msg"`
+	MaxBSONObjectSize            int64    `json:"maxBsonObjectSize"`
+	MaxMessageSizeBytes          int64    `json:"maxMessageSizeBytes"`
+	MaxWriteBatchSize            int64    `json:"maxWriteBatchSize"`
+	LogicalSessionTimeoutMinutes int64    `json:"logicalSessionTimeoutMinutes"`
+	ConnectionID                 int64    `json:"connectionId"`
+	MinWireVersion               int32    `json:"minWireVersion"`
+	MaxWireVersion               int32    `json:"maxWireVersion"`
+	// LocalTime is the server's clock when it replied.
+	LocalTime time.Time `json:"-"`
+}
+
+// Hello runs the hello command.
+func (a *Admin) Hello(ctx context.Context) (*HelloResult, error) {
+	raw, err := a.db.RunCommand(ctx, map[string]any{"hello": 1}).Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	var result HelloResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if lt, ok := doc["localTime"]; ok && lt != nil {
+		if result.LocalTime, err = parseDateTime(lt); err != nil {
+			return nil, fmt.Errorf("localTime: %w", err)
+		}
+	}
+	return &result, nil
+}
+
+// GetParameter returns the value of a server parameter.
+func (a *Admin) GetParameter(ctx context.Context, name string) (any, error) {
+	var result map[string]any
+	if err := a.db.RunCommand(ctx, map[string]any{"getParameter": 1, name: 1}).Decode(&result); err != nil {
+		return nil, err
+	}
+	value, ok := result[name]
+	if !ok {
+		return nil, fmt.Errorf("mongo: server parameter %q not returned", name)
+	}
+	return value, nil
+}
+
+// SetParameter sets a server parameter and returns its previous value.
+func (a *Admin) SetParameter(ctx context.Context, name string, value any) (any, error) {
+	var result map[string]any
+	if err := a.db.RunCommand(ctx, map[string]any{"setParameter": 1, name: value}).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result["was"], nil
+}
+
+// CommandInfo describes a command supported by the server.
+type CommandInfo struct {
+	Name         string
+	Help         string
+	AdminOnly    bool
+	SecondaryOK  bool
+	RequiresAuth bool
+}
+
+// ListCommands returns the commands supported by the server, sorted by name.
+func (a *Admin) ListCommands(ctx context.Context) ([]CommandInfo, error) {
+	var result map[string]any
+	if err := a.db.RunCommand(ctx, map[string]any{"listCommands": 1}).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	commands, ok := result["commands"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected commands type: %T", result["commands"])
+	}
+
+	infos := make([]CommandInfo, 0, len(commands))
+	for name, raw := range commands {
+		doc, _ := raw.(map[string]any)
+		info := CommandInfo{Name: name}
+		info.Help, _ = doc["help"].(string)
+		info.AdminOnly = asBool(doc["adminOnly"])
+		info.SecondaryOK = asBool(doc["secondaryOk"]) || asBool(doc["slaveOk"])
+		info.RequiresAuth = asBool(doc["requiresAuth"])
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
@@ -0,0 +1,129 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAdminPing tests the typed ping command.
+func TestAdminPing(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	result, err := client.Admin().Ping(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.RoundTrip < 0 {
+		t.Errorf("unexpected round trip: %v", result.RoundTrip)
+	}
+	if mock.calls[0].args[0] != "admin" || mock.calls[0].args[1].(map[string]any)["ping"] != 1 {
+		t.Errorf("unexpected command: %v", mock.calls[0].args)
+	}
+}
+
+// TestAdminHello tests parsing the hello command.
+func TestAdminHello(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{
+		"isWritablePrimary": true,
+		"setName":           "rs0",
+		"hosts":             []any{"a:27017", "b:27017"},
+		"maxBsonObjectSize": float64(16777216),
+		"maxWriteBatchSize": float64(100000),
+		"maxWireVersion":    float64(21),
+		"localTime":         map[string]any{"$date": "2024-05-01T12:00:00Z"},
+		"ok":                float64(1),
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	hello, err := client.Admin().Hello(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !hello.IsWritablePrimary || hello.SetName != "rs0" || len(hello.Hosts) != 2 {
+		t.Errorf("unexpected topology: %+v", hello)
+	}
+	if hello.MaxBSONObjectSize != 16777216 || hello.MaxWriteBatchSize != 100000 || hello.MaxWireVersion != 21 {
+		t.Errorf("unexpected limits: %+v", hello)
+	}
+	if !hello.LocalTime.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected local time: %v", hello.LocalTime)
+	}
+}
+
+// TestAdminGetSetParameter tests reading and changing server parameters.
+func TestAdminGetSetParameter(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"logLevel": float64(0), "ok": float64(1)}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"was": float64(0), "ok": float64(1)}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	admin := newClientWithRPC(mock, "mongodb://localhost:27017").Admin()
+	ctx := context.Background()
+
+	value, err := admin.GetParameter(ctx, "logLevel")
+	if err != nil || value != float64(0) {
+		t.Errorf("unexpected parameter: %v, %v", value, err)
+	}
+
+	was, err := admin.SetParameter(ctx, "logLevel", 2)
+	if err != nil || was != float64(0) {
+		t.Errorf("unexpected previous value: %v, %v", was, err)
+	}
+	if cmd := mock.calls[1].args[1].(map[string]any); cmd["setParameter"] != 1 || cmd["logLevel"] != 2 {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+
+	if _, err := admin.GetParameter(ctx, "unknown"); err == nil {
+		t.Error("expected error for missing parameter")
+	}
+}
+
+// TestAdminListCommands tests listing server commands.
+func TestAdminListCommands(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{
+		"commands": map[string]any{
+			"shutdown": map[string]any{"help": "shuts down", "adminOnly": true, "requiresAuth": true},
+			"find":     map[string]any{"help": "query", "secondaryOk": true},
+		},
+		"ok": float64(1),
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	commands, err := client.Admin().ListCommands(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []CommandInfo{
+		{Name: "find", Help: "query", SecondaryOK: true},
+		{Name: "shutdown", Help: "shuts down", AdminOnly: true, RequiresAuth: true},
+	}
+	if len(commands) != 2 || commands[0] != want[0] || commands[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, commands)
+	}
+}
+
+// TestAdminCommandErrors tests command failures.
+func TestAdminCommandErrors(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, errors.New("unauthorized"))
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	admin := newClientWithRPC(mock, "mongodb://localhost:27017").Admin()
+	ctx := context.Background()
+
+	if _, err := admin.Hello(ctx); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := admin.ListCommands(ctx); err == nil {
+		t.Error("expected error for missing commands")
+	}
+}
@@ -0,0 +1,290 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// AggregateOptions configures an Aggregate operation.
+type AggregateOptions struct {
+	// AllowDiskUse lets blocking stages such as $sort and $group spill to
+	// disk on the server instead of failing at the server memory limit.
+	AllowDiskUse *bool
+	// MaxBufferedDocuments caps the number of result documents buffered in
+	// memory, overriding the client-wide setting. Zero means no cap.
+	MaxBufferedDocuments *int64
+	// StrictPipeline rejects the pipeline on any LintPipeline issue, not
+	// just errors.
+	StrictPipeline *bool
+}
+
+// SetAllowDiskUse sets whether the server may spill to disk.
+func (o *AggregateOptions) SetAllowDiskUse(allow bool) *AggregateOptions {
+	o.AllowDiskUse = &allow
+	return o
+}
+
+// SetMaxBufferedDocuments sets the maximum number of buffered result documents.
+func (o *AggregateOptions) SetMaxBufferedDocuments(n int64) *AggregateOptions {
+	o.MaxBufferedDocuments = &n
+	return o
+}
+
+// SetStrictPipeline sets whether lint warnings also reject the pipeline.
+func (o *AggregateOptions) SetStrictPipeline(strict bool) *AggregateOptions {
+	o.StrictPipeline = &strict
+	return o
+}
+
+// aggregateBufferSuggestion is the remediation hint for oversized aggregations.
+const aggregateBufferSuggestion = "narrow the pipeline with $match or $limit, or write the output with $out or $merge instead of returning it"
+
+// withLimit returns pipeline with a trailing $limit stage, or false if the
+// pipeline is not a slice of stages.
+func withLimit(pipeline any, n int64) (any, bool) {
+	stage := map[string]any{"$limit": n}
+	switch p := pipeline.(type) {
+	case Pipeline:
+		return append(append(Pipeline{}, p...), stage), true
+	case []any:
+		return append(append([]any{}, p...), stage), true
+	case []map[string]any:
+		return append(append([]map[string]any{}, p...), stage), true
+	case bson.A:
+		return append(append(bson.A{}, p...), stage), true
+	case []bson.D:
+		return append(append([]bson.D{}, p...), bson.D{{Key: "$limit", Value: n}}), true
+	}
+	return nil, false
+}
+
+// pipelineStages returns the stages of pipeline, or false if it is not a
+// Pipeline, bson.A, []any, []map[string]any or []bson.D.
+func pipelineStages(pipeline any) ([]any, bool) {
+	switch p := pipeline.(type) {
+	case Pipeline:
+		return p, true
+	case bson.A:
+		return p, true
+	case []any:
+		return p, true
+	case []map[string]any:
+		stages := make([]any, len(p))
+		for i, stage := range p {
+			stages[i] = stage
+		}
+		return stages, true
+	case []bson.D:
+		stages := make([]any, len(p))
+		for i, stage := range p {
+			stages[i] = stage
+		}
+		return stages, true
+	}
+	return nil, false
+}
+
+// Sample returns a cursor over n randomly selected documents matching the
+// filter. A nil filter samples from the whole collection.
+func (c *Collection) Sample(ctx context.Context, n int64, filter any) (*Cursor, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("mongo: sample size must be positive, got %d", n)
+	}
+
+	pipeline := make([]any, 0, 2)
+	if filter != nil {
+		pipeline = append(pipeline, map[string]any{"$match": filter})
+	}
+	pipeline = append(pipeline, map[string]any{"$sample": map[string]any{"size": n}})
+
+	return c.Aggregate(ctx, pipeline)
+}
+
+// Pipeline is an ordered list of aggregation stages.
+type Pipeline []any
+
+// FacetResult holds the output of each facet of a Facet query.
+type FacetResult struct {
+	facets map[string][]any
+
+	naming  NamingStrategy
+	numbers NumberDecoding
+	decoder DecoderConfig
+	strict  bool
+}
+
+// Names returns the names of the facets in the result.
+func (r *FacetResult) Names() []string {
+	names := make([]string, 0, len(r.facets))
+	for name := range r.facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode decodes the documents of the named facet into results, which must
+// be a pointer to a slice, with the codec settings of the collection. opts
+// override the strict decoding defaults of the client and collection.
+func (r *FacetResult) Decode(name string, results any, opts ...*DecodeOptions) error {
+	docs, ok := r.facets[name]
+	if !ok {
+		return fmt.Errorf("mongo: unknown facet %q", name)
+	}
+	data, err := json.Marshal(docs)
+	if err != nil {
+		return err
+	}
+	return unmarshalDocument(data, results, r.naming, r.numbers, r.decoder, strictDecoding(r.strict, opts...))
+}
+
+// Facet runs several sub-pipelines over the same input documents in a single
+// $facet stage, e.g. a total count alongside the top-N documents.
+func (c *Collection) Facet(ctx context.Context, facets map[string]Pipeline) (*FacetResult, error) {
+	if len(facets) == 0 {
+		return nil, fmt.Errorf("mongo: facet requires at least one pipeline")
+	}
+
+	stage := make(map[string]any, len(facets))
+	for name, pipeline := range facets {
+		if pipeline == nil {
+			pipeline = Pipeline{}
+		}
+		stage[name] = []any(pipeline)
+	}
+
+	cursor, err := c.Aggregate(ctx, []any{map[string]any{"$facet": stage}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &FacetResult{
+		facets:  make(map[string][]any, len(facets)),
+		naming:  c.naming,
+		numbers: c.numbers,
+		decoder: c.decoder,
+		strict:  c.strict,
+	}
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	var doc map[string][]any
+	if err := cursor.Decode(&doc); err != nil {
+		return nil, err
+	}
+	for name := range facets {
+		docs := doc[name]
+		if docs == nil {
+			docs = []any{}
+		}
+		result.facets[name] = docs
+	}
+
+	return result, nil
+}
+
+// DefaultDebugSampleSize is the number of documents DebugAggregate returns
+// per stage by default.
+const DefaultDebugSampleSize = 10
+
+// DebugAggregateOptions configures a DebugAggregate run.
+type DebugAggregateOptions struct {
+	// SampleSize caps the documents returned per stage.
+	SampleSize *int64
+}
+
+// SetSampleSize sets the number of documents returned per stage.
+func (o *DebugAggregateOptions) SetSampleSize(n int64) *DebugAggregateOptions {
+	o.SampleSize = &n
+	return o
+}
+
+// StageResult holds the output of a pipeline truncated after one stage.
+type StageResult struct {
+	// Stage is the zero-based index of the last stage run.
+	Stage int
+	// Name is the stage operator, such as "$match".
+	Name string
+	// Documents is a sample of the documents the stage outputs.
+	Documents []any
+	// Duration is how long the truncated pipeline took.
+	Duration time.Duration
+	// Err is the error the truncated pipeline failed with, if any.
+	Err error
+}
+
+// DebugAggregate runs pipeline incrementally, first with only its first
+// stage, then the first two, and so on, sampling each run with $limit, and
+// returns the intermediate results per stage. It stops at the first stage
+// that fails, recording the error in that stage's result. $out and $merge
+// stages are never run, since they write to a collection.
+func (c *Collection) DebugAggregate(ctx context.Context, pipeline any, opts ...*DebugAggregateOptions) ([]StageResult, error) {
+	stages, ok := pipelineStages(pipeline)
+	if !ok {
+		return nil, fmt.Errorf("mongo: cannot debug pipeline of type %T", pipeline)
+	}
+
+	sampleSize := int64(DefaultDebugSampleSize)
+	for _, opt := range opts {
+		if opt != nil && opt.SampleSize != nil {
+			sampleSize = *opt.SampleSize
+		}
+	}
+	if sampleSize <= 0 {
+		return nil, fmt.Errorf("mongo: sample size must be positive, got %d", sampleSize)
+	}
+
+	clock := c.database.client.clock
+	results := make([]StageResult, 0, len(stages))
+	for i, stage := range stages {
+		result := StageResult{Stage: i, Name: stageName(stage)}
+		if result.Name == "$out" || result.Name == "$merge" {
+			break
+		}
+
+		prefix := make([]any, 0, i+2)
+		prefix = append(prefix, stages[:i+1]...)
+		prefix = append(prefix, map[string]any{"$limit": sampleSize})
+
+		start := clock.Now()
+		cursor, err := c.Aggregate(ctx, prefix)
+		if err == nil {
+			result.Documents = []any{}
+			err = cursor.All(ctx, &result.Documents)
+		}
+		result.Duration = clock.Now().Sub(start)
+		result.Err = err
+
+		results = append(results, result)
+		if err != nil {
+			break
+		}
+	}
+	return results, nil
+}
+
+// stageName returns the operator of a single-key stage document, or "" if
+// stage is not one.
+func stageName(stage any) string {
+	if d, ok := stage.(bson.D); ok && len(d) == 1 {
+		return d[0].Key
+	}
+	doc, ok := plainDocument(stage).(map[string]any)
+	if !ok || len(doc) != 1 {
+		return ""
+	}
+	for name := range doc {
+		return name
+	}
+	return ""
+}
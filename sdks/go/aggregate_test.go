@@ -0,0 +1,335 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCollectionSample tests sampling documents with a filter.
+func TestCollectionSample(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"_id": "1"},
+		map[string]any{"_id": "2"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	cursor, err := coll.Sample(context.Background(), 2, map[string]any{"active": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cursor.RemainingBatchLength() != 2 {
+		t.Errorf("expected 2 documents, got %d", cursor.RemainingBatchLength())
+	}
+
+	pipeline := mock.calls[0].args[2].([]any)
+	if len(pipeline) != 2 {
+		t.Fatalf("expected 2 stages, got %d", len(pipeline))
+	}
+
+	if _, ok := pipeline[0].(map[string]any)["$match"]; !ok {
+		t.Error("expected $match as first stage")
+	}
+
+	sample := pipeline[1].(map[string]any)["$sample"].(map[string]any)
+	if sample["size"] != int64(2) {
+		t.Errorf("expected size 2, got %v", sample["size"])
+	}
+}
+
+// TestCollectionSampleNoFilter tests sampling without a filter.
+func TestCollectionSampleNoFilter(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Sample(context.Background(), 5, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pipeline := mock.calls[0].args[2].([]any)
+	if len(pipeline) != 1 {
+		t.Errorf("expected 1 stage, got %d", len(pipeline))
+	}
+}
+
+// TestCollectionSampleInvalidSize tests sampling with a non-positive size.
+func TestCollectionSampleInvalidSize(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Sample(context.Background(), 0, nil); err == nil {
+		t.Error("expected error for zero sample size")
+	}
+}
+
+// TestCollectionFacet tests running multiple facets in one query.
+func TestCollectionFacet(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{
+			"total": []any{map[string]any{"count": float64(42)}},
+			"top": []any{
+				map[string]any{"name": "a", "score": float64(10)},
+				map[string]any{"name": "b", "score": float64(9)},
+			},
+		},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("scores")
+
+	result, err := coll.Facet(context.Background(), map[string]Pipeline{
+		"total": {map[string]any{"$count": "count"}},
+		"top":   {map[string]any{"$sort": map[string]any{"score": -1}}, map[string]any{"$limit": 2}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var total []struct {
+		Count int `json:"count"`
+	}
+	if err := result.Decode("total", &total); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(total) != 1 || total[0].Count != 42 {
+		t.Errorf("unexpected total: %v", total)
+	}
+
+	var top []struct {
+		Name string `json:"name"`
+	}
+	if err := result.Decode("top", &top); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(top) != 2 || top[0].Name != "a" {
+		t.Errorf("unexpected top: %v", top)
+	}
+
+	if names := result.Names(); len(names) != 2 || names[0] != "top" {
+		t.Errorf("unexpected names: %v", names)
+	}
+
+	pipeline := mock.calls[0].args[2].([]any)
+	stage := pipeline[0].(map[string]any)["$facet"].(map[string]any)
+	if len(stage) != 2 {
+		t.Errorf("expected 2 facets in stage, got %d", len(stage))
+	}
+}
+
+// TestCollectionFacetCodec tests decoding facets with the collection's
+// naming strategy and strict decoding.
+func TestCollectionFacetCodec(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"users": []any{map[string]any{"user_id": "u1", "login_count": float64(3), "extra": true}}},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	opts := (&CollectionOptions{}).SetNamingStrategy(NamingSnakeCase).SetDisallowUnknownFields(true)
+	coll := client.Database("testdb").Collection("users", opts)
+
+	result, err := coll.Facet(context.Background(), map[string]Pipeline{"users": nil})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type user struct {
+		UserID     string
+		LoginCount int64
+	}
+	var users []user
+	var unknown *UnknownFieldError
+	if err := result.Decode("users", &users); !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownFieldError, got %v", err)
+	}
+	if err := result.Decode("users", &users, (&DecodeOptions{}).SetDisallowUnknownFields(false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0] != (user{UserID: "u1", LoginCount: 3}) {
+		t.Errorf("unexpected users: %+v", users)
+	}
+}
+
+// TestCollectionFacetUnknown tests decoding a facet that was not requested.
+func TestCollectionFacetUnknown(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("scores")
+
+	result, err := coll.Facet(context.Background(), map[string]Pipeline{"total": nil})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var docs []map[string]any
+	if err := result.Decode("missing", &docs); err == nil {
+		t.Error("expected error for unknown facet")
+	}
+}
+
+// TestCollectionFacetEmpty tests that at least one facet is required.
+func TestCollectionFacetEmpty(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("scores")
+
+	if _, err := coll.Facet(context.Background(), nil); err == nil {
+		t.Error("expected error for no facets")
+	}
+}
+
+// TestCollectionAggregateAllowDiskUse tests sending allowDiskUse.
+func TestCollectionAggregateAllowDiskUse(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	pipeline := []any{map[string]any{"$sort": map[string]any{"total": -1}}}
+	if _, err := coll.Aggregate(context.Background(), pipeline, (&AggregateOptions{}).SetAllowDiskUse(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := mock.calls[0].args
+	if args[3].(map[string]any)["allowDiskUse"] != true {
+		t.Errorf("expected allowDiskUse, got %v", args[3])
+	}
+	if len(args[2].([]any)) != 1 {
+		t.Errorf("expected unmodified pipeline, got %v", args[2])
+	}
+}
+
+// TestCollectionAggregateMaxBufferedDocuments tests the client-wide buffer cap.
+func TestCollectionAggregateMaxBufferedDocuments(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"_id": float64(1)},
+		map[string]any{"_id": float64(2)},
+		map[string]any{"_id": float64(3)},
+	}, nil)
+	mock.addCall("mongo.aggregate", []any{map[string]any{"_id": float64(1)}}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetMaxBufferedDocuments(2))
+	coll := client.Database("testdb").Collection("orders")
+	ctx := context.Background()
+
+	pipeline := Pipeline{map[string]any{"$match": map[string]any{"status": "open"}}}
+	_, err := coll.Aggregate(ctx, pipeline, (&AggregateOptions{}).SetAllowDiskUse(true))
+
+	var tooLarge *ResultTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 2 || !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("expected ResultTooLargeError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "$out") {
+		t.Errorf("expected aggregation guidance, got %q", err.Error())
+	}
+
+	sent := mock.calls[0].args[2].(Pipeline)
+	if len(sent) != 2 || sent[1].(map[string]any)["$limit"] != int64(3) {
+		t.Errorf("expected trailing $limit, got %v", sent)
+	}
+	if len(pipeline) != 1 {
+		t.Errorf("caller pipeline was modified: %v", pipeline)
+	}
+
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	if err != nil || cursor.RemainingBatchLength() != 1 {
+		t.Errorf("unexpected result: %v", err)
+	}
+}
+
+// TestCollectionAggregateMaxBufferedDocumentsOverride tests the per-call cap.
+func TestCollectionAggregateMaxBufferedDocumentsOverride(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{map[string]any{"_id": float64(1)}, map[string]any{"_id": float64(2)}}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetMaxBufferedDocuments(1))
+	coll := client.Database("testdb").Collection("orders")
+
+	pipeline := []map[string]any{{"$match": map[string]any{}}}
+	if _, err := coll.Aggregate(context.Background(), pipeline, (&AggregateOptions{}).SetMaxBufferedDocuments(0)); err != nil {
+		t.Fatalf("expected cap to be disabled, got %v", err)
+	}
+	if len(mock.calls[0].args[2].([]map[string]any)) != 1 {
+		t.Errorf("expected unmodified pipeline, got %v", mock.calls[0].args[2])
+	}
+}
+
+// TestCollectionDebugAggregate tests running a pipeline stage by stage.
+func TestCollectionDebugAggregate(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{map[string]any{"_id": "1", "status": "A"}}, nil)
+	mock.addCall("mongo.aggregate", []any{map[string]any{"_id": "A", "n": float64(1)}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	pipeline := Pipeline{
+		map[string]any{"$match": map[string]any{"status": "A"}},
+		map[string]any{"$group": map[string]any{"_id": "$status", "n": map[string]any{"$sum": 1}}},
+		map[string]any{"$out": "summary"},
+	}
+	results, err := coll.DebugAggregate(context.Background(), pipeline, (&DebugAggregateOptions{}).SetSampleSize(5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 stage results ($out skipped), got %d", len(results))
+	}
+	if results[0].Name != "$match" || results[1].Name != "$group" || results[1].Stage != 1 {
+		t.Errorf("unexpected stages: %+v", results)
+	}
+	if len(results[1].Documents) != 1 || results[1].Err != nil {
+		t.Errorf("unexpected group result: %+v", results[1])
+	}
+
+	sent := mock.calls[1].args[2].([]any)
+	if len(sent) != 3 || !reflect.DeepEqual(sent[2], map[string]any{"$limit": int64(5)}) {
+		t.Errorf("unexpected pipeline: %v", sent)
+	}
+}
+
+// TestCollectionDebugAggregateStopsOnError tests that a failing stage ends the run.
+func TestCollectionDebugAggregateStopsOnError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.aggregate", nil, errors.New("bad stage"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	pipeline := []any{
+		map[string]any{"$match": map[string]any{}},
+		map[string]any{"$lookup": map[string]any{}},
+		map[string]any{"$limit": 1},
+	}
+	results, err := coll.DebugAggregate(context.Background(), pipeline)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[1].Err == nil || results[0].Err != nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if sent := mock.calls[0].args[2].([]any); !reflect.DeepEqual(sent[1], map[string]any{"$limit": int64(DefaultDebugSampleSize)}) {
+		t.Errorf("expected default sample size, got %v", sent)
+	}
+
+	if _, err := coll.DebugAggregate(context.Background(), "pipeline"); err == nil {
+		t.Error("expected error for non-slice pipeline")
+	}
+}
@@ -0,0 +1,192 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+)
+
+// DefaultArchiveBatchSize is the number of documents Archive moves at once.
+const DefaultArchiveBatchSize = 500
+
+// ArchiveOptions configures an Archive operation.
+type ArchiveOptions struct {
+	BatchSize *int
+	// Checkpoint stores the _id of the last document moved under
+	// CheckpointKey, which defaults to "archive:" and the source and
+	// archive namespaces, so an interrupted run resumes after it. The
+	// checkpoint is cleared once a run completes.
+	Checkpoint    CheckpointStore
+	CheckpointKey string
+}
+
+// SetBatchSize sets the number of documents moved at once.
+func (o *ArchiveOptions) SetBatchSize(size int) *ArchiveOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetCheckpoint sets the checkpoint store and the key to store progress under.
+func (o *ArchiveOptions) SetCheckpoint(store CheckpointStore, key string) *ArchiveOptions {
+	o.Checkpoint = store
+	o.CheckpointKey = key
+	return o
+}
+
+// ArchiveResult is the result of an Archive operation.
+type ArchiveResult struct {
+	// Archived is the number of documents copied to the archive.
+	Archived int64
+	// Deleted is the number of documents deleted from the collection. It
+	// is less than Archived when documents stopped matching the filter
+	// before they were deleted; their archived copies remain.
+	Deleted int64
+	Batches int
+}
+
+// Archive moves the documents matching filter to archive in batches, in
+// _id order. Each batch is copied with upserts, so copies left by an
+// interrupted run are overwritten rather than duplicated, then read back
+// from the archive and compared by content hash, and only then deleted. A
+// batch with documents missing from the archive or different there fails
+// with ErrArchiveMismatch and is not deleted. Documents changed
+// between being copied and deleted lose the change, so filter should select
+// documents no longer written to, such as those past a retention period.
+func (c *Collection) Archive(ctx context.Context, archive *Collection, filter any, opts ...*ArchiveOptions) (*ArchiveResult, error) {
+	ctx = withBatchPriority(ctx)
+	options := &ArchiveOptions{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.BatchSize != nil {
+			options.BatchSize = opt.BatchSize
+		}
+		if opt.Checkpoint != nil {
+			options.Checkpoint = opt.Checkpoint
+		}
+		if opt.CheckpointKey != "" {
+			options.CheckpointKey = opt.CheckpointKey
+		}
+	}
+
+	batchSize := DefaultArchiveBatchSize
+	if options.BatchSize != nil && *options.BatchSize > 0 {
+		batchSize = *options.BatchSize
+	}
+	key := options.CheckpointKey
+	if key == "" {
+		key = "archive:" + c.namespace() + ":" + archive.namespace()
+	}
+	if filter == nil {
+		filter = map[string]any{}
+	}
+
+	var after any
+	if options.Checkpoint != nil {
+		token, err := options.Checkpoint.Load(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("mongo: loading checkpoint %s: %w", key, err)
+		}
+		after = token
+	}
+
+	result := &ArchiveResult{}
+	for {
+		batchFilter := filter
+		if after != nil {
+			batchFilter = map[string]any{"$and": []any{filter, map[string]any{"_id": map[string]any{"$gt": after}}}}
+		}
+		cursor, err := c.Find(ctx, batchFilter, (&FindOptions{}).SetSort(map[string]any{"_id": 1}).SetLimit(int64(batchSize)))
+		if err != nil {
+			return result, err
+		}
+		var docs []map[string]any
+		if err := cursor.All(ctx, &docs); err != nil {
+			return result, err
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		ids := make([]any, len(docs))
+		models := make([]WriteModel, len(docs))
+		upsert := true
+		for i, doc := range docs {
+			ids[i] = doc["_id"]
+			models[i] = &ReplaceOneModel{Filter: map[string]any{"_id": doc["_id"]}, Replacement: doc, Upsert: &upsert}
+		}
+		if _, err := archive.BulkWrite(ctx, models); err != nil {
+			return result, fmt.Errorf("mongo: copying to %s: %w", archive.namespace(), err)
+		}
+		result.Archived += int64(len(docs))
+
+		inBatch := map[string]any{"_id": map[string]any{"$in": ids}}
+		verified, err := verifyArchived(ctx, archive, inBatch, docs)
+		if err != nil {
+			return result, fmt.Errorf("mongo: verifying %s: %w", archive.namespace(), err)
+		}
+		if verified != len(docs) {
+			return result, fmt.Errorf("%w: %d of %d documents match in %s", ErrArchiveMismatch, verified, len(docs), archive.namespace())
+		}
+
+		// Documents that stopped matching the filter since they were read
+		// are kept.
+		deleted, err := c.DeleteMany(ctx, map[string]any{"$and": []any{filter, inBatch}})
+		if err != nil {
+			return result, err
+		}
+		result.Deleted += deleted.DeletedCount
+		result.Batches++
+
+		after = ids[len(ids)-1]
+		if options.Checkpoint != nil {
+			if err := options.Checkpoint.Save(ctx, key, after); err != nil {
+				return result, fmt.Errorf("mongo: saving checkpoint %s: %w", key, err)
+			}
+		}
+		if len(docs) < batchSize {
+			break
+		}
+	}
+
+	if options.Checkpoint != nil {
+		if err := options.Checkpoint.Save(ctx, key, nil); err != nil {
+			return result, fmt.Errorf("mongo: clearing checkpoint %s: %w", key, err)
+		}
+	}
+	return result, nil
+}
+
+// verifyArchived reads the documents matching inBatch back from archive and
+// returns how many of docs it holds with the same content.
+func verifyArchived(ctx context.Context, archive *Collection, inBatch map[string]any, docs []map[string]any) (int, error) {
+	cursor, err := archive.Find(ctx, inBatch)
+	if err != nil {
+		return 0, err
+	}
+	var copies []map[string]any
+	if err := cursor.All(ctx, &copies); err != nil {
+		return 0, err
+	}
+	hashes := make(map[string][sha256.Size]byte, len(copies))
+	for _, doc := range copies {
+		key, hash, err := documentHash(doc)
+		if err != nil {
+			return 0, err
+		}
+		hashes[key] = hash
+	}
+
+	verified := 0
+	for _, doc := range docs {
+		key, hash, err := documentHash(doc)
+		if err != nil {
+			return 0, err
+		}
+		if copied, ok := hashes[key]; ok && copied == hash {
+			verified++
+		}
+	}
+	return verified, nil
+}
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestArchive tests moving documents in verified batches.
+func TestArchive(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "a"}, map[string]any{"_id": "b"}}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"upsertedCount": float64(2)}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "b"}, map[string]any{"_id": "a"}}, nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(2)}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "c"}}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"upsertedCount": float64(1)}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "c"}}, nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(0)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+	store := newMemoryCheckpointStore()
+	filter := map[string]any{"status": "closed"}
+
+	result, err := db.Collection("orders").Archive(context.Background(), db.Collection("orders_archive"), filter,
+		(&ArchiveOptions{}).SetBatchSize(2).SetCheckpoint(store, "orders"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != (ArchiveResult{Archived: 3, Deleted: 2, Batches: 2}) {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	next := map[string]any{"$and": []any{filter, map[string]any{"_id": map[string]any{"$gt": "b"}}}}
+	if got := mock.calls[4].args[2]; !reflect.DeepEqual(got, next) {
+		t.Errorf("expected the second batch after the first, got %v", got)
+	}
+	want := map[string]any{"$and": []any{filter, map[string]any{"_id": map[string]any{"$in": []any{"a", "b"}}}}}
+	if got := mock.calls[3].args[2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected delete filter %v", got)
+	}
+	if token, ok := store.tokens["orders"]; !ok || token != nil || store.saves != 3 {
+		t.Errorf("expected the checkpoint to be cleared after 3 saves, got %v after %d", token, store.saves)
+	}
+}
+
+// TestArchiveMismatch tests that batches whose copies differ are not
+// deleted, and that a rerun resumes from the checkpoint.
+func TestArchiveMismatch(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "c", "total": float64(5)}, map[string]any{"_id": "d"}}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"upsertedCount": float64(2)}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "c", "total": float64(4)}, map[string]any{"_id": "d"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+	store := newMemoryCheckpointStore()
+	store.tokens["archive:testdb.orders:testdb.orders_archive"] = "b"
+
+	result, err := db.Collection("orders").Archive(context.Background(), db.Collection("orders_archive"), nil,
+		(&ArchiveOptions{}).SetCheckpoint(store, ""))
+	if !errors.Is(err, ErrArchiveMismatch) {
+		t.Fatalf("expected ErrArchiveMismatch, got %v", err)
+	}
+	if result.Deleted != 0 || mock.callIndex != 3 {
+		t.Errorf("expected nothing to be deleted, got %+v after %d calls", result, mock.callIndex)
+	}
+	want := map[string]any{"$and": []any{map[string]any{}, map[string]any{"_id": map[string]any{"$gt": "b"}}}}
+	if got := mock.calls[0].args[2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected to resume after the checkpoint, got %v", got)
+	}
+	if store.saves != 0 {
+		t.Errorf("expected the checkpoint to be kept, got %d saves", store.saves)
+	}
+}
@@ -0,0 +1,233 @@
+package mongo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ArrayFilters builds the arrayFilters of an update, one filter per
+// identifier referenced by a filtered positional operator such as
+// "grades.$[elem].score":
+//
+//	af := mongo.NewArrayFilters()
+//	af.Elem("elem").Gt("score", 5)
+//	opts := (&mongo.UpdateOptions{}).SetArrayFilters(af.Filters())
+type ArrayFilters struct {
+	elems []*ArrayFilter
+}
+
+// NewArrayFilters returns an empty ArrayFilters builder.
+func NewArrayFilters() *ArrayFilters {
+	return &ArrayFilters{}
+}
+
+// Elem returns the filter for identifier, creating it on first use.
+func (af *ArrayFilters) Elem(identifier string) *ArrayFilter {
+	for _, f := range af.elems {
+		if f.identifier == identifier {
+			return f
+		}
+	}
+	f := &ArrayFilter{identifier: identifier, conditions: make(map[string]map[string]any)}
+	af.elems = append(af.elems, f)
+	return f
+}
+
+// Filters returns the filter documents in the order the identifiers were
+// added, ready for UpdateOptions.SetArrayFilters.
+func (af *ArrayFilters) Filters() []any {
+	filters := make([]any, 0, len(af.elems))
+	for _, f := range af.elems {
+		filters = append(filters, f.document())
+	}
+	return filters
+}
+
+// ArrayFilter holds the conditions on the array elements bound to one
+// identifier. Conditions on the same field are combined.
+type ArrayFilter struct {
+	identifier string
+	fields     []string
+	conditions map[string]map[string]any
+}
+
+// Eq matches elements whose field equals value. An empty field refers to the
+// element itself.
+func (f *ArrayFilter) Eq(field string, value any) *ArrayFilter {
+	return f.condition(field, "$eq", value)
+}
+
+// Ne matches elements whose field does not equal value.
+func (f *ArrayFilter) Ne(field string, value any) *ArrayFilter {
+	return f.condition(field, "$ne", value)
+}
+
+// Gt matches elements whose field is greater than value.
+func (f *ArrayFilter) Gt(field string, value any) *ArrayFilter {
+	return f.condition(field, "$gt", value)
+}
+
+// Gte matches elements whose field is greater than or equal to value.
+func (f *ArrayFilter) Gte(field string, value any) *ArrayFilter {
+	return f.condition(field, "$gte", value)
+}
+
+// Lt matches elements whose field is less than value.
+func (f *ArrayFilter) Lt(field string, value any) *ArrayFilter {
+	return f.condition(field, "$lt", value)
+}
+
+// Lte matches elements whose field is less than or equal to value.
+func (f *ArrayFilter) Lte(field string, value any) *ArrayFilter {
+	return f.condition(field, "$lte", value)
+}
+
+// In matches elements whose field equals any of values.
+func (f *ArrayFilter) In(field string, values ...any) *ArrayFilter {
+	return f.condition(field, "$in", values)
+}
+
+// Nin matches elements whose field equals none of values.
+func (f *ArrayFilter) Nin(field string, values ...any) *ArrayFilter {
+	return f.condition(field, "$nin", values)
+}
+
+func (f *ArrayFilter) condition(field, op string, value any) *ArrayFilter {
+	path := f.identifier
+	if field != "" {
+		path += "." + field
+	}
+	cond, ok := f.conditions[path]
+	if !ok {
+		cond = make(map[string]any)
+		f.conditions[path] = cond
+		f.fields = append(f.fields, path)
+	}
+	cond[op] = value
+	return f
+}
+
+// document returns the filter document, matching everything when no
+// condition was added.
+func (f *ArrayFilter) document() map[string]any {
+	doc := make(map[string]any, len(f.fields))
+	for _, path := range f.fields {
+		doc[path] = f.conditions[path]
+	}
+	if len(doc) == 0 {
+		doc[f.identifier] = map[string]any{"$exists": true}
+	}
+	return doc
+}
+
+var (
+	// arrayFilterIdentifier matches a valid identifier: a lower-case letter
+	// followed by letters and digits.
+	arrayFilterIdentifier = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	// filteredPositional matches filtered positional operators in update paths.
+	filteredPositional = regexp.MustCompile(`\$\[([^\]]*)\]`)
+)
+
+// validateArrayFilters checks that every filter targets a single valid
+// identifier, that no identifier is filtered twice, and that every
+// identifier referenced by update's $[identifier] operators has a filter.
+// Updates that are not documents are only checked for the filter side.
+func validateArrayFilters(update any, filters []any) error {
+	filtered := make(map[string]bool, len(filters))
+	for i, filter := range filters {
+		identifier, err := arrayFilterTarget(plainDocument(filter))
+		if err != nil {
+			return fmt.Errorf("%w: filter %d: %v", ErrInvalidArrayFilter, i, err)
+		}
+		if filtered[identifier] {
+			return fmt.Errorf("%w: identifier %q has more than one filter", ErrInvalidArrayFilter, identifier)
+		}
+		filtered[identifier] = true
+	}
+
+	doc, ok := plainDocument(update).(map[string]any)
+	if !ok {
+		return nil
+	}
+	used := updateIdentifiers(doc)
+	for _, identifier := range sortedKeys(used) {
+		if !filtered[identifier] {
+			return fmt.Errorf("%w: no filter for identifier %q used in update", ErrInvalidArrayFilter, identifier)
+		}
+	}
+	return nil
+}
+
+// arrayFilterTarget returns the identifier a filter document applies to.
+func arrayFilterTarget(filter any) (string, error) {
+	doc, ok := filter.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("expected a document, got %T", filter)
+	}
+	var identifier string
+	for key, value := range doc {
+		var id string
+		switch key {
+		case "$and", "$or", "$nor":
+			clauses, ok := value.([]any)
+			if !ok || len(clauses) == 0 {
+				return "", fmt.Errorf("%s requires a non-empty array", key)
+			}
+			for _, clause := range clauses {
+				clauseID, err := arrayFilterTarget(clause)
+				if err != nil {
+					return "", err
+				}
+				if id != "" && clauseID != id {
+					return "", fmt.Errorf("%s mixes identifiers %q and %q", key, id, clauseID)
+				}
+				id = clauseID
+			}
+		default:
+			id, _, _ = strings.Cut(key, ".")
+			if !arrayFilterIdentifier.MatchString(id) {
+				return "", fmt.Errorf("invalid identifier %q: must start with a lower-case letter and contain only letters and digits", id)
+			}
+		}
+		if identifier != "" && id != identifier {
+			return "", fmt.Errorf("mixes identifiers %q and %q", identifier, id)
+		}
+		identifier = id
+	}
+	if identifier == "" {
+		return "", fmt.Errorf("empty filter")
+	}
+	return identifier, nil
+}
+
+// updateIdentifiers returns the identifiers referenced by $[identifier]
+// operators in the field paths of an update document.
+func updateIdentifiers(update map[string]any) map[string]bool {
+	used := make(map[string]bool)
+	for _, fields := range update {
+		doc, ok := fields.(map[string]any)
+		if !ok {
+			continue
+		}
+		for path := range doc {
+			for _, m := range filteredPositional.FindAllStringSubmatch(path, -1) {
+				if m[1] != "" {
+					used[m[1]] = true
+				}
+			}
+		}
+	}
+	return used
+}
+
+// sortedKeys returns the keys of set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestArrayFiltersBuilder tests building filter documents.
+func TestArrayFiltersBuilder(t *testing.T) {
+	af := NewArrayFilters()
+	af.Elem("elem").Gt("score", 5).Lte("score", 10)
+	af.Elem("tag").In("", "a", "b")
+	af.Elem("any")
+
+	want := []any{
+		map[string]any{"elem.score": map[string]any{"$gt": 5, "$lte": 10}},
+		map[string]any{"tag": map[string]any{"$in": []any{"a", "b"}}},
+		map[string]any{"any": map[string]any{"$exists": true}},
+	}
+	if got := af.Filters(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if af.Elem("elem") != af.Elem("elem") {
+		t.Error("expected Elem to return the same filter for an identifier")
+	}
+}
+
+// TestValidateArrayFilters tests matching filters against update identifiers.
+func TestValidateArrayFilters(t *testing.T) {
+	update := map[string]any{
+		"$set": map[string]any{"grades.$[elem].score": 0, "grades.$[].seen": true},
+		"$inc": map[string]any{"items.$[item].qty": 1},
+	}
+	elem := map[string]any{"elem.score": map[string]any{"$gt": 5}}
+	item := map[string]any{"$or": []any{
+		map[string]any{"item.qty": map[string]any{"$lt": 1}},
+		map[string]any{"item.sku": "x"},
+	}}
+
+	tests := []struct {
+		name    string
+		update  any
+		filters []any
+		wantErr bool
+	}{
+		{"matching", update, []any{elem, item}, false},
+		{"missing filter", update, []any{elem}, true},
+		{"unused filter", update, []any{elem, item, map[string]any{"other": 1}}, false},
+		{"duplicate identifier", update, []any{elem, elem, item}, true},
+		{"invalid identifier", update, []any{elem, item, map[string]any{"Bad.x": 1}}, true},
+		{"mixed identifiers", update, []any{map[string]any{"elem.a": 1, "item.b": 2}}, true},
+		{"not a document", update, []any{"elem"}, true},
+		{"pipeline update", []any{map[string]any{"$set": map[string]any{"a": 1}}}, []any{elem}, false},
+	}
+
+	for _, tt := range tests {
+		err := validateArrayFilters(tt.update, tt.filters)
+		if tt.wantErr && !errors.Is(err, ErrInvalidArrayFilter) {
+			t.Errorf("%s: expected ErrInvalidArrayFilter, got %v", tt.name, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+	}
+}
+
+// TestUpdateOneValidatesArrayFilters tests that mismatched filters fail before the RPC call.
+func TestUpdateOneValidatesArrayFilters(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("students")
+	ctx := context.Background()
+
+	update := map[string]any{"$set": map[string]any{"grades.$[elem].score": 100}}
+
+	wrong := NewArrayFilters()
+	wrong.Elem("grade").Gte("score", 90)
+	_, err := coll.UpdateOne(ctx, map[string]any{}, update, (&UpdateOptions{}).SetArrayFilters(wrong.Filters()))
+	if !errors.Is(err, ErrInvalidArrayFilter) {
+		t.Fatalf("expected ErrInvalidArrayFilter, got %v", err)
+	}
+	if len(mock.calls[0].args) != 0 {
+		t.Fatal("expected no RPC call for invalid array filters")
+	}
+
+	af := NewArrayFilters()
+	af.Elem("elem").Gte("score", 90)
+	if _, err := coll.UpdateOne(ctx, map[string]any{}, update, (&UpdateOptions{}).SetArrayFilters(af.Filters())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := mock.calls[0].args[4].(map[string]any)
+	if !reflect.DeepEqual(options["arrayFilters"], af.Filters()) {
+		t.Errorf("unexpected arrayFilters: %v", options["arrayFilters"])
+	}
+}
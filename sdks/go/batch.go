@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultDeleteBatchSize is the number of IDs deleted per request by DeleteByIDs.
+const DefaultDeleteBatchSize = 1000
+
+// BatchProgress reports the progress of a batched operation after each
+// batch.
+type BatchProgress struct {
+	// Batch is the index of the batch just finished.
+	Batch int
+	// Processed is the number of items sent in this and earlier batches.
+	Processed int
+	// Total is the number of items in the operation, or zero when it is not
+	// known in advance.
+	Total int
+	// Latency is how long the batch took.
+	Latency time.Duration
+	// Errors is the number of batches that have failed so far.
+	Errors int
+	// Err is the error the batch failed with, if any.
+	Err error
+}
+
+// ProgressFunc receives BatchProgress reports. It is called synchronously
+// between batches, so it can also pace the operation by blocking.
+type ProgressFunc func(BatchProgress)
+
+// report calls p, if set, with the progress of a finished batch. Batched
+// operations stop at their first failed batch, so a report with an Err is
+// the only one to count an error. A panic in p is returned as a PanicError,
+// which the operation stops with too.
+func (p ProgressFunc) report(client *Client, progress BatchProgress) error {
+	if p == nil {
+		return nil
+	}
+	if progress.Err != nil {
+		progress.Errors = 1
+	}
+	return client.runCallback("ProgressFunc", func() error {
+		p(progress)
+		return nil
+	})
+}
+
+// DeleteByIDsOptions configures a DeleteByIDs operation.
+type DeleteByIDsOptions struct {
+	BatchSize *int
+	// Progress is called after each batch.
+	Progress ProgressFunc
+}
+
+// SetBatchSize sets the maximum number of IDs per delete request.
+func (o *DeleteByIDsOptions) SetBatchSize(size int) *DeleteByIDsOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetProgress sets the function called after each batch.
+func (o *DeleteByIDsOptions) SetProgress(progress ProgressFunc) *DeleteByIDsOptions {
+	o.Progress = progress
+	return o
+}
+
+// DeleteByIDs deletes the documents with the given _id values, splitting
+// large lists into multiple {_id: {$in: [...]}} deletes of bounded size to
+// stay within payload limits. If a batch fails, the returned result holds
+// the documents deleted by earlier batches alongside the error. A Progress
+// function set in the options is told about each batch, including the
+// failed one.
+func (c *Collection) DeleteByIDs(ctx context.Context, ids []any, opts ...*DeleteByIDsOptions) (*DeleteResult, error) {
+	batchSize := DefaultDeleteBatchSize
+	var progress ProgressFunc
+	for _, opt := range opts {
+		if opt != nil && opt.BatchSize != nil {
+			batchSize = *opt.BatchSize
+		}
+		if opt != nil && opt.Progress != nil {
+			progress = opt.Progress
+		}
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("mongo: batch size must be positive, got %d", batchSize)
+	}
+
+	clock := c.database.client.clock
+	total := &DeleteResult{}
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		filter := map[string]any{"_id": map[string]any{"$in": ids[start:end]}}
+		began := clock.Now()
+		result, err := c.DeleteMany(ctx, filter)
+		reportErr := progress.report(c.database.client, BatchProgress{
+			Batch:     start / batchSize,
+			Processed: end,
+			Total:     len(ids),
+			Latency:   clock.Now().Sub(began),
+			Err:       err,
+		})
+		if err != nil {
+			return total, fmt.Errorf("mongo: delete batch starting at %d: %w", start, err)
+		}
+		total.DeletedCount += result.DeletedCount
+		if reportErr != nil {
+			return total, reportErr
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,187 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestCollectionDeleteByIDs tests deleting IDs in bounded batches.
+func TestCollectionDeleteByIDs(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(2)}, nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(2)}, nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	ids := []any{"a", "b", "c", "d", "e"}
+	result, err := coll.DeleteByIDs(context.Background(), ids, (&DeleteByIDsOptions{}).SetBatchSize(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.DeletedCount != 5 {
+		t.Errorf("expected 5 deleted, got %d", result.DeletedCount)
+	}
+
+	if mock.callIndex != 3 {
+		t.Fatalf("expected 3 calls, got %d", mock.callIndex)
+	}
+
+	filter := mock.calls[2].args[2].(map[string]any)
+	in := filter["_id"].(map[string]any)["$in"].([]any)
+	if len(in) != 1 || in[0] != "e" {
+		t.Errorf("unexpected last batch: %v", in)
+	}
+}
+
+// TestCollectionDeleteByIDsPartialFailure tests that counts from earlier batches are kept.
+func TestCollectionDeleteByIDsPartialFailure(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(2)}, nil)
+	mock.addCall("mongo.deleteMany", nil, errors.New("payload too large"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	result, err := coll.DeleteByIDs(context.Background(), []any{1, 2, 3, 4}, (&DeleteByIDsOptions{}).SetBatchSize(2))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if result == nil || result.DeletedCount != 2 {
+		t.Errorf("expected 2 deleted before failure, got %v", result)
+	}
+}
+
+// TestCollectionDeleteByIDsEmpty tests that no requests are sent for an empty list.
+func TestCollectionDeleteByIDsEmpty(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	result, err := coll.DeleteByIDs(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.DeletedCount != 0 || mock.callIndex != 0 {
+		t.Errorf("expected no deletes, got %d with %d calls", result.DeletedCount, mock.callIndex)
+	}
+}
+
+// TestCollectionDeleteByIDsInvalidBatchSize tests rejecting a non-positive batch size.
+func TestCollectionDeleteByIDsInvalidBatchSize(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.DeleteByIDs(context.Background(), []any{1}, (&DeleteByIDsOptions{}).SetBatchSize(0))
+	if err == nil {
+		t.Error("expected error for zero batch size")
+	}
+}
+
+// TestCollectionDeleteByIDsProgress tests the reports made after each batch.
+func TestCollectionDeleteByIDsProgress(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(2)}, nil)
+	mock.addCall("mongo.deleteMany", nil, errors.New("payload too large"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	var reports []BatchProgress
+	opts := (&DeleteByIDsOptions{}).SetBatchSize(2).SetProgress(func(p BatchProgress) {
+		reports = append(reports, p)
+	})
+	if _, err := coll.DeleteByIDs(context.Background(), []any{1, 2, 3, 4, 5}, opts); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if r := reports[0]; r.Batch != 0 || r.Processed != 2 || r.Total != 5 || r.Errors != 0 || r.Err != nil {
+		t.Errorf("unexpected first report: %+v", r)
+	}
+	if r := reports[1]; r.Batch != 1 || r.Processed != 4 || r.Errors != 1 || r.Err == nil {
+		t.Errorf("unexpected second report: %+v", r)
+	}
+}
+
+// TestCollectionInsertManyProgress tests reporting each InsertMany batch.
+func TestCollectionInsertManyProgress(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"a", "b"}}, nil)
+	mock.addCall("mongo.insertMany", nil, errors.New("write failed"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	var reports []BatchProgress
+	opts := (&InsertManyOptions{}).SetBatchSize(2).SetProgress(func(p BatchProgress) {
+		reports = append(reports, p)
+	})
+	docs := []any{map[string]any{"_id": "a"}, map[string]any{"_id": "b"}, map[string]any{"_id": "c"}}
+	if _, err := coll.InsertMany(context.Background(), docs, opts); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(mock.calls[0].args[2].([]any)) != 2 || len(mock.calls[1].args[2].([]any)) != 1 {
+		t.Errorf("expected batches of 2 and 1 documents, got %v and %v", mock.calls[0].args[2], mock.calls[1].args[2])
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if r := reports[0]; r.Batch != 0 || r.Processed != 2 || r.Total != 3 || r.Errors != 0 || r.Err != nil {
+		t.Errorf("unexpected first report: %+v", r)
+	}
+	if r := reports[1]; r.Batch != 1 || r.Processed != 3 || r.Errors != 1 || r.Err == nil {
+		t.Errorf("unexpected second report: %+v", r)
+	}
+}
+
+// TestCollectionBulkWriteProgress tests reporting each BulkWrite batch, and
+// keeping the server's batch limit when the requested size is larger.
+func TestCollectionBulkWriteProgress(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.bulkWrite", map[string]any{"insertedCount": float64(2)}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.capabilities = (&helloReply{MaxWriteBatchSize: 2}).description()
+	coll := client.Database("testdb").Collection("users")
+
+	var reports []BatchProgress
+	opts := (&BulkWriteOptions{}).SetBatchSize(10).SetProgress(func(p BatchProgress) {
+		reports = append(reports, p)
+	})
+	result, err := coll.BulkWrite(context.Background(), []WriteModel{
+		&InsertOneModel{Document: map[string]any{"_id": 1}},
+		&InsertOneModel{Document: map[string]any{"_id": 2}},
+		&DeleteOneModel{Filter: map[string]any{"_id": 3}},
+	}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.InsertedCount != 2 || result.DeletedCount != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if r := reports[0]; r.Batch != 0 || r.Processed != 2 || r.Total != 3 || r.Err != nil {
+		t.Errorf("unexpected first report: %+v", r)
+	}
+	if r := reports[1]; r.Batch != 1 || r.Processed != 3 || r.Total != 3 || r.Err != nil {
+		t.Errorf("unexpected second report: %+v", r)
+	}
+
+	if _, err := coll.BulkWrite(context.Background(), nil, (&BulkWriteOptions{}).SetBatchSize(0)); err == nil {
+		t.Error("expected error for a non-positive batch size")
+	}
+}
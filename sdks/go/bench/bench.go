@@ -0,0 +1,362 @@
+// Package bench generates load against a collection and reports
+// throughput and latency percentiles. It drives the same client code path
+// an application uses, so capacity can be planned with the SDK version,
+// options and transport that will run in production:
+//
+//	opts := bench.DefaultOptions().
+//		SetDuration(30 * time.Second).
+//		SetConcurrency(32).
+//		SetMix(bench.ReadWriteMix(0.9))
+//	result, err := bench.Run(ctx, client.Database("bench").Collection("load"), opts)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Print(result)
+package bench
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+// ErrNoLimit is returned by Run when neither a duration nor an operation
+// count bounds the run.
+var ErrNoLimit = errors.New("bench: no duration or operation limit")
+
+// ErrEmptyMix is returned by Run when the mix has no positive weight.
+var ErrEmptyMix = errors.New("bench: operation mix is empty")
+
+// preloadBatchSize is the number of documents Run inserts per InsertMany
+// call when preloading.
+const preloadBatchSize = 1000
+
+// Target is the collection a benchmark runs against. *mongo.Collection
+// implements it.
+type Target interface {
+	InsertOne(ctx context.Context, document any) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []any, opts ...*mongo.InsertManyOptions) (*mongo.InsertManyResult, error)
+	FindOne(ctx context.Context, filter any, opts ...*mongo.FindOneOptions) *mongo.SingleResult
+	UpdateOne(ctx context.Context, filter any, update any, opts ...*mongo.UpdateOptions) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter any, opts ...*mongo.DeleteOptions) (*mongo.DeleteResult, error)
+}
+
+// Operation is a kind of operation in a benchmark mix.
+type Operation string
+
+const (
+	// OpInsert inserts a new document with InsertOne.
+	OpInsert Operation = "insert"
+	// OpFind reads a document by _id with FindOne.
+	OpFind Operation = "find"
+	// OpUpdate sets a field of a document by _id with UpdateOne.
+	OpUpdate Operation = "update"
+	// OpDelete deletes a document by _id with DeleteOne.
+	OpDelete Operation = "delete"
+)
+
+// operations lists the operations in report order.
+var operations = []Operation{OpInsert, OpFind, OpUpdate, OpDelete}
+
+// Mix weighs the operations a benchmark issues. Each operation is chosen
+// with a probability proportional to its weight.
+type Mix struct {
+	Insert int
+	Find   int
+	Update int
+	Delete int
+}
+
+// ReadWriteMix returns a mix in which readRatio of the operations, from 0
+// to 1, are finds and the rest are split evenly between inserts and
+// updates.
+func ReadWriteMix(readRatio float64) Mix {
+	readRatio = min(max(readRatio, 0), 1)
+	reads := int(readRatio*1000 + 0.5)
+	writes := 1000 - reads
+	return Mix{Find: reads, Insert: writes / 2, Update: writes - writes/2}
+}
+
+// weight returns the weight of op.
+func (m Mix) weight(op Operation) int {
+	switch op {
+	case OpInsert:
+		return m.Insert
+	case OpFind:
+		return m.Find
+	case OpUpdate:
+		return m.Update
+	case OpDelete:
+		return m.Delete
+	}
+	return 0
+}
+
+// Options configures a benchmark run.
+type Options struct {
+	// Duration is how long operations are issued for. Operations in flight
+	// when it passes complete and are counted.
+	Duration time.Duration
+	// Operations caps the number of operations issued. Zero means no cap;
+	// the run then ends after Duration.
+	Operations int64
+	// Concurrency is the number of goroutines issuing operations, each
+	// waiting for one operation before issuing the next.
+	Concurrency int
+	// Mix weighs the operations issued.
+	Mix Mix
+	// DocumentSize is the approximate size in bytes of inserted documents,
+	// padded with a string field.
+	DocumentSize int
+	// KeySpace is the number of _ids finds, updates and deletes pick from.
+	// Preloaded documents use _ids 0 to KeySpace-1; inserts use _ids above
+	// them, so they never collide.
+	KeySpace int64
+	// Preload inserts KeySpace documents before the run starts, so finds
+	// and updates hit existing documents. Preloading is not timed.
+	Preload bool
+	// Seed seeds the choice of operations and keys, so a run can be
+	// repeated. Zero picks a random seed.
+	Seed int64
+}
+
+// DefaultOptions returns the default benchmark options: ten seconds on
+// eight goroutines of a 90% read mix over 10000 preloaded 256-byte
+// documents.
+func DefaultOptions() *Options {
+	return &Options{
+		Duration:     10 * time.Second,
+		Concurrency:  8,
+		Mix:          ReadWriteMix(0.9),
+		DocumentSize: 256,
+		KeySpace:     10000,
+		Preload:      true,
+	}
+}
+
+// SetDuration sets how long operations are issued for.
+func (o *Options) SetDuration(d time.Duration) *Options {
+	o.Duration = d
+	return o
+}
+
+// SetOperations sets the maximum number of operations issued.
+func (o *Options) SetOperations(n int64) *Options {
+	o.Operations = n
+	return o
+}
+
+// SetConcurrency sets the number of goroutines issuing operations.
+func (o *Options) SetConcurrency(n int) *Options {
+	o.Concurrency = n
+	return o
+}
+
+// SetMix sets the operation mix.
+func (o *Options) SetMix(mix Mix) *Options {
+	o.Mix = mix
+	return o
+}
+
+// SetDocumentSize sets the approximate size of inserted documents.
+func (o *Options) SetDocumentSize(size int) *Options {
+	o.DocumentSize = size
+	return o
+}
+
+// SetKeySpace sets the number of _ids operations pick from.
+func (o *Options) SetKeySpace(n int64) *Options {
+	o.KeySpace = n
+	return o
+}
+
+// SetPreload sets whether documents are inserted before the run.
+func (o *Options) SetPreload(preload bool) *Options {
+	o.Preload = preload
+	return o
+}
+
+// SetSeed sets the seed of the choice of operations and keys.
+func (o *Options) SetSeed(seed int64) *Options {
+	o.Seed = seed
+	return o
+}
+
+// Run runs a benchmark against target and returns its result. A nil opts
+// uses DefaultOptions. Finds matching no document are not errors; other
+// operation errors are counted in the result rather than ending the run.
+// If ctx is done before the run ends, Run returns the result so far along
+// with ctx's error.
+func Run(ctx context.Context, target Target, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	o := *opts
+	if o.Duration <= 0 && o.Operations <= 0 {
+		return nil, ErrNoLimit
+	}
+	total := 0
+	for _, op := range operations {
+		total += max(o.Mix.weight(op), 0)
+	}
+	if total == 0 {
+		return nil, ErrEmptyMix
+	}
+	o.Concurrency = max(o.Concurrency, 1)
+	o.KeySpace = max(o.KeySpace, 1)
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+
+	r := &runner{target: target, opts: o, total: total, padding: strings.Repeat("x", max(o.DocumentSize-64, 0))}
+	r.nextID.Store(o.KeySpace)
+	if o.Preload {
+		if err := r.preload(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	if o.Duration > 0 {
+		r.deadline = start.Add(o.Duration)
+	}
+	workers := make([]*worker, o.Concurrency)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = newWorker(o.Seed + int64(i))
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			r.work(ctx, w)
+		}(workers[i])
+	}
+	wg.Wait()
+
+	return newResult(time.Since(start), workers), ctx.Err()
+}
+
+// runner holds the state shared by the workers of a run.
+type runner struct {
+	target   Target
+	opts     Options
+	total    int
+	padding  string
+	deadline time.Time
+	issued   atomic.Int64
+	nextID   atomic.Int64
+}
+
+// preload inserts the documents with _ids 0 to KeySpace-1.
+func (r *runner) preload(ctx context.Context) error {
+	rnd := rand.New(rand.NewSource(r.opts.Seed))
+	for start := int64(0); start < r.opts.KeySpace; start += preloadBatchSize {
+		end := min(start+preloadBatchSize, r.opts.KeySpace)
+		docs := make([]any, 0, end-start)
+		for id := start; id < end; id++ {
+			docs = append(docs, r.document(id, rnd))
+		}
+		if _, err := r.target.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document returns the document with the given _id.
+func (r *runner) document(id int64, rnd *rand.Rand) bson.M {
+	return bson.M{"_id": id, "n": rnd.Int63(), "payload": r.padding}
+}
+
+// work issues operations until the run ends.
+func (r *runner) work(ctx context.Context, w *worker) {
+	for ctx.Err() == nil {
+		if !r.deadline.IsZero() && !time.Now().Before(r.deadline) {
+			return
+		}
+		if r.opts.Operations > 0 && r.issued.Add(1) > r.opts.Operations {
+			return
+		}
+		op := r.pick(w.rand)
+		start := time.Now()
+		err := r.do(ctx, op, w.rand)
+		elapsed := time.Since(start)
+		if err != nil && ctx.Err() != nil {
+			// Cancelled with the run, not failed on its own
+			return
+		}
+		w.record(op, elapsed, err)
+	}
+}
+
+// pick chooses an operation by the weights of the mix.
+func (r *runner) pick(rnd *rand.Rand) Operation {
+	n := rnd.Intn(r.total)
+	for _, op := range operations {
+		weight := max(r.opts.Mix.weight(op), 0)
+		if n < weight {
+			return op
+		}
+		n -= weight
+	}
+	return operations[len(operations)-1]
+}
+
+// do performs one operation.
+func (r *runner) do(ctx context.Context, op Operation, rnd *rand.Rand) error {
+	key := bson.M{"_id": rnd.Int63n(r.opts.KeySpace)}
+	switch op {
+	case OpInsert:
+		_, err := r.target.InsertOne(ctx, r.document(r.nextID.Add(1)-1, rnd))
+		return err
+	case OpFind:
+		var doc bson.M
+		if err := r.target.FindOne(ctx, key).Decode(&doc); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		return nil
+	case OpUpdate:
+		_, err := r.target.UpdateOne(ctx, key, bson.M{"$set": bson.M{"n": rnd.Int63()}})
+		return err
+	case OpDelete:
+		_, err := r.target.DeleteOne(ctx, key)
+		return err
+	}
+	return nil
+}
+
+// worker is a goroutine issuing operations, with the samples it took.
+// Workers record without locking and are merged once the run ends.
+type worker struct {
+	rand     *rand.Rand
+	samples  map[Operation][]time.Duration
+	errors   map[Operation]int64
+	firstErr error
+}
+
+// newWorker returns a worker choosing with the given seed.
+func newWorker(seed int64) *worker {
+	return &worker{
+		rand:    rand.New(rand.NewSource(seed)),
+		samples: make(map[Operation][]time.Duration),
+		errors:  make(map[Operation]int64),
+	}
+}
+
+// record records a completed operation.
+func (w *worker) record(op Operation, elapsed time.Duration, err error) {
+	if err != nil {
+		w.errors[op]++
+		if w.firstErr == nil {
+			w.firstErr = err
+		}
+		return
+	}
+	w.samples[op] = append(w.samples[op], elapsed)
+}
@@ -0,0 +1,204 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+// memoryTarget is an in-memory Target keyed by _id.
+type memoryTarget struct {
+	mu      sync.Mutex
+	docs    map[int64]bson.M
+	calls   map[Operation]int
+	batches int
+	err     error
+}
+
+func newMemoryTarget() *memoryTarget {
+	return &memoryTarget{docs: make(map[int64]bson.M), calls: make(map[Operation]int)}
+}
+
+func (m *memoryTarget) InsertOne(ctx context.Context, document any) (*mongo.InsertOneResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[OpInsert]++
+	doc := document.(bson.M)
+	m.docs[doc["_id"].(int64)] = doc
+	return &mongo.InsertOneResult{InsertedID: doc["_id"]}, m.err
+}
+
+func (m *memoryTarget) InsertMany(ctx context.Context, documents []any, opts ...*mongo.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches++
+	for _, d := range documents {
+		doc := d.(bson.M)
+		m.docs[doc["_id"].(int64)] = doc
+	}
+	return &mongo.InsertManyResult{}, nil
+}
+
+func (m *memoryTarget) FindOne(ctx context.Context, filter any, opts ...*mongo.FindOneOptions) *mongo.SingleResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[OpFind]++
+	doc, ok := m.docs[filter.(bson.M)["_id"].(int64)]
+	if !ok {
+		return mongo.NewSingleResultFromDocument(nil, m.err)
+	}
+	return mongo.NewSingleResultFromDocument(doc, m.err)
+}
+
+func (m *memoryTarget) UpdateOne(ctx context.Context, filter any, update any, opts ...*mongo.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[OpUpdate]++
+	return &mongo.UpdateResult{}, m.err
+}
+
+func (m *memoryTarget) DeleteOne(ctx context.Context, filter any, opts ...*mongo.DeleteOptions) (*mongo.DeleteResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[OpDelete]++
+	delete(m.docs, filter.(bson.M)["_id"].(int64))
+	return &mongo.DeleteResult{}, m.err
+}
+
+var _ Target = (*mongo.Collection)(nil)
+
+// TestRunOperations tests a run capped by operation count.
+func TestRunOperations(t *testing.T) {
+	target := newMemoryTarget()
+	opts := DefaultOptions().
+		SetDuration(0).
+		SetOperations(2000).
+		SetConcurrency(4).
+		SetKeySpace(1500).
+		SetMix(Mix{Insert: 1, Find: 1, Update: 1, Delete: 1}).
+		SetSeed(1)
+
+	res, err := Run(context.Background(), target, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.batches != 2 {
+		t.Errorf("expected 2 preload batches, got %d", target.batches)
+	}
+	if res.Operations != 2000 || res.Errors != 0 {
+		t.Errorf("expected 2000 operations without errors, got %d and %d", res.Operations, res.Errors)
+	}
+	var sum int64
+	for _, op := range operations {
+		s := res.ByOperation[op]
+		if s == nil || s.Operations == 0 {
+			t.Fatalf("expected %s operations, got %+v", op, res.ByOperation)
+		}
+		if int64(target.calls[op]) != s.Operations {
+			t.Errorf("expected %d %s calls, got %d", s.Operations, op, target.calls[op])
+		}
+		sum += s.Operations
+	}
+	if sum != res.Operations {
+		t.Errorf("expected operations to add up to %d, got %d", res.Operations, sum)
+	}
+	if res.Throughput <= 0 || res.Latency.Max < res.Latency.Min {
+		t.Errorf("unexpected throughput or latency: %v %+v", res.Throughput, res.Latency)
+	}
+	if _, ok := target.docs[1500]; !ok {
+		t.Errorf("expected inserts to start after the key space")
+	}
+}
+
+// TestRunDuration tests a run bounded by time.
+func TestRunDuration(t *testing.T) {
+	target := newMemoryTarget()
+	opts := DefaultOptions().
+		SetDuration(50 * time.Millisecond).
+		SetKeySpace(10).
+		SetMix(ReadWriteMix(1)).
+		SetPreload(false)
+
+	start := time.Now()
+	res, err := Run(context.Background(), target, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the run to stop after its duration, took %v", elapsed)
+	}
+	if res.Operations == 0 || res.Errors != 0 {
+		t.Errorf("expected finds of missing documents to succeed, got %d operations and %d errors", res.Operations, res.Errors)
+	}
+	if len(res.ByOperation) != 1 || res.ByOperation[OpFind] == nil {
+		t.Errorf("expected only finds, got %v", res.ByOperation)
+	}
+}
+
+// TestRunErrors tests that failed operations are counted.
+func TestRunErrors(t *testing.T) {
+	target := newMemoryTarget()
+	target.err = errors.New("boom")
+	opts := DefaultOptions().
+		SetOperations(100).
+		SetMix(Mix{Update: 1}).
+		SetPreload(false)
+
+	res, err := Run(context.Background(), target, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Operations != 100 || res.Errors != 100 {
+		t.Errorf("expected 100 failed operations, got %d and %d", res.Operations, res.Errors)
+	}
+	if res.FirstError == nil || res.FirstError.Error() != "boom" {
+		t.Errorf("expected the first error, got %v", res.FirstError)
+	}
+}
+
+// TestRunCancel tests a run whose context is cancelled.
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	res, err := Run(ctx, newMemoryTarget(), DefaultOptions().SetDuration(time.Hour).SetPreload(false))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if res == nil || res.Operations == 0 {
+		t.Errorf("expected the result so far, got %+v", res)
+	}
+}
+
+// TestRunInvalidOptions tests options that cannot run.
+func TestRunInvalidOptions(t *testing.T) {
+	if _, err := Run(context.Background(), newMemoryTarget(), DefaultOptions().SetDuration(0)); !errors.Is(err, ErrNoLimit) {
+		t.Errorf("expected ErrNoLimit, got %v", err)
+	}
+	if _, err := Run(context.Background(), newMemoryTarget(), DefaultOptions().SetMix(Mix{})); !errors.Is(err, ErrEmptyMix) {
+		t.Errorf("expected ErrEmptyMix, got %v", err)
+	}
+}
+
+// TestReadWriteMix tests splitting a mix by read ratio.
+func TestReadWriteMix(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  Mix
+	}{
+		{0.9, Mix{Find: 900, Insert: 50, Update: 50}},
+		{1, Mix{Find: 1000}},
+		{0, Mix{Insert: 500, Update: 500}},
+		{2, Mix{Find: 1000}},
+	}
+	for _, tt := range tests {
+		if got := ReadWriteMix(tt.ratio); got != tt.want {
+			t.Errorf("ReadWriteMix(%v) = %+v, want %+v", tt.ratio, got, tt.want)
+		}
+	}
+}
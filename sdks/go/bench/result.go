@@ -0,0 +1,150 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Latencies summarizes the latencies of successful operations.
+type Latencies struct {
+	Min  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+	Max  time.Duration
+}
+
+// newLatencies summarizes samples, sorting them in place.
+func newLatencies(samples []time.Duration) Latencies {
+	if len(samples) == 0 {
+		return Latencies{}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var sum time.Duration
+	for _, d := range samples {
+		sum += d
+	}
+	return Latencies{
+		Min:  samples[0],
+		Mean: sum / time.Duration(len(samples)),
+		P50:  percentile(samples, 0.5),
+		P90:  percentile(samples, 0.9),
+		P99:  percentile(samples, 0.99),
+		P999: percentile(samples, 0.999),
+		Max:  samples[len(samples)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p, from 0 to 1, of sorted
+// samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// OperationStats is the result of one kind of operation in a run.
+type OperationStats struct {
+	// Operations is the number of operations completed, including failed
+	// ones.
+	Operations int64
+	Errors     int64
+	// Throughput is the number of operations completed per second.
+	Throughput float64
+	Latency    Latencies
+}
+
+// Result is the result of a benchmark run.
+type Result struct {
+	// Duration is the time from the first operation issued to the last
+	// completed, excluding preloading.
+	Duration time.Duration
+	// Operations is the number of operations completed, including failed
+	// ones.
+	Operations int64
+	Errors     int64
+	// Throughput is the number of operations completed per second.
+	Throughput float64
+	// Latency summarizes all successful operations.
+	Latency Latencies
+	// ByOperation holds the results of each operation in the mix that
+	// completed at least once.
+	ByOperation map[Operation]*OperationStats
+	// FirstError is an error of a failed operation, if any, to tell why
+	// operations failed.
+	FirstError error
+}
+
+// newResult merges the samples of workers into a result.
+func newResult(elapsed time.Duration, workers []*worker) *Result {
+	res := &Result{Duration: elapsed, ByOperation: make(map[Operation]*OperationStats)}
+	var all []time.Duration
+	for _, op := range operations {
+		var samples []time.Duration
+		var errs int64
+		for _, w := range workers {
+			samples = append(samples, w.samples[op]...)
+			errs += w.errors[op]
+		}
+		n := int64(len(samples)) + errs
+		if n == 0 {
+			continue
+		}
+		all = append(all, samples...)
+		res.ByOperation[op] = &OperationStats{
+			Operations: n,
+			Errors:     errs,
+			Throughput: throughput(n, elapsed),
+			Latency:    newLatencies(samples),
+		}
+		res.Operations += n
+		res.Errors += errs
+	}
+	for _, w := range workers {
+		if w.firstErr != nil {
+			res.FirstError = w.firstErr
+			break
+		}
+	}
+	res.Throughput = throughput(res.Operations, elapsed)
+	res.Latency = newLatencies(all)
+	return res
+}
+
+// throughput returns n operations over elapsed as operations per second.
+func throughput(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) / elapsed.Seconds()
+}
+
+// String returns a table of the result, one row per operation followed by
+// the total.
+func (r *Result) String() string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\tops\tops/s\terrors\tmin\tmean\tp50\tp90\tp99\tp99.9\tmax\t")
+	row := func(name string, ops, errs int64, rate float64, l Latencies) {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n",
+			name, ops, rate, errs, l.Min, l.Mean, l.P50, l.P90, l.P99, l.P999, l.Max)
+	}
+	for _, op := range operations {
+		if s, ok := r.ByOperation[op]; ok {
+			row(string(op), s.Operations, s.Errors, s.Throughput, s.Latency)
+		}
+	}
+	row("total", r.Operations, r.Errors, r.Throughput, r.Latency)
+	tw.Flush()
+	fmt.Fprintf(&b, "duration %v", r.Duration.Round(time.Millisecond))
+	if r.FirstError != nil {
+		fmt.Fprintf(&b, ", first error: %v", r.FirstError)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLatencies tests latency percentiles.
+func TestLatencies(t *testing.T) {
+	samples := make([]time.Duration, 0, 1000)
+	for i := 1000; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	got := newLatencies(samples)
+	want := Latencies{
+		Min:  time.Millisecond,
+		Mean: 500500 * time.Microsecond,
+		P50:  500 * time.Millisecond,
+		P90:  900 * time.Millisecond,
+		P99:  990 * time.Millisecond,
+		P999: 999 * time.Millisecond,
+		Max:  1000 * time.Millisecond,
+	}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if got := newLatencies(nil); got != (Latencies{}) {
+		t.Errorf("expected zero latencies without samples, got %+v", got)
+	}
+	if got := newLatencies([]time.Duration{time.Second}); got.P50 != time.Second || got.P999 != time.Second {
+		t.Errorf("expected one sample to be every percentile, got %+v", got)
+	}
+}
+
+// TestResult tests merging worker samples into a result.
+func TestResult(t *testing.T) {
+	a, b := newWorker(1), newWorker(2)
+	a.record(OpFind, time.Millisecond, nil)
+	a.record(OpFind, 3*time.Millisecond, nil)
+	b.record(OpInsert, 2*time.Millisecond, nil)
+	b.record(OpInsert, 0, errors.New("duplicate key"))
+
+	res := newResult(2*time.Second, []*worker{a, b})
+	if res.Operations != 4 || res.Errors != 1 || res.Throughput != 2 {
+		t.Errorf("unexpected totals: %+v", res)
+	}
+	if res.Latency.Mean != 2*time.Millisecond || res.Latency.Max != 3*time.Millisecond {
+		t.Errorf("expected latencies of successful operations, got %+v", res.Latency)
+	}
+	if s := res.ByOperation[OpInsert]; s == nil || s.Operations != 2 || s.Errors != 1 || s.Throughput != 1 {
+		t.Errorf("unexpected insert stats: %+v", s)
+	}
+	if _, ok := res.ByOperation[OpUpdate]; ok {
+		t.Errorf("expected no stats for operations that did not run")
+	}
+	if res.FirstError == nil || res.FirstError.Error() != "duplicate key" {
+		t.Errorf("expected the first error, got %v", res.FirstError)
+	}
+
+	report := res.String()
+	for _, want := range []string{"p99.9", "insert", "find", "total", "duration 2s", "first error: duplicate key"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "update") {
+		t.Errorf("expected no update row in report:\n%s", report)
+	}
+}
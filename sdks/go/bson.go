@@ -0,0 +1,689 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// This file holds the minimal BSON codec used by the wire protocol backend
+// and for BSON payloads over the RPC transport (see payload.go).
+// Documents decode to the same shapes the RPC transport produces: numbers
+// are float64 and special types use their extended JSON form ($oid, $uuid,
+// $timestamp, ...), so the rest of the SDK handles both backends alike.
+
+// BSON element types.
+const (
+	bsonDouble    byte = 0x01
+	bsonString    byte = 0x02
+	bsonDocument  byte = 0x03
+	bsonArray     byte = 0x04
+	bsonBinary    byte = 0x05
+	bsonUndefined byte = 0x06
+	bsonObjectID  byte = 0x07
+	bsonBool      byte = 0x08
+	bsonDateTime  byte = 0x09
+	bsonNull      byte = 0x0A
+	bsonRegex     byte = 0x0B
+	bsonInt32     byte = 0x10
+	bsonTimestamp byte = 0x11
+	bsonInt64     byte = 0x12
+	bsonDecimal   byte = 0x13
+	bsonMinKey    byte = 0xFF
+	bsonMaxKey    byte = 0x7F
+)
+
+// maxSafeInteger is the largest integer a float64 holds exactly.
+const maxSafeInteger = 1 << 53
+
+// bsonDoc is a BSON document with ordered elements, used for commands,
+// whose first element names the command.
+type bsonDoc []bsonElem
+
+// bsonElem is an element of a bsonDoc.
+type bsonElem struct {
+	Key   string
+	Value any
+}
+
+// appendOpt appends key when value is non-nil.
+func (d bsonDoc) appendOpt(key string, value any) bsonDoc {
+	if value == nil {
+		return d
+	}
+	return append(d, bsonElem{key, value})
+}
+
+// marshalBSON encodes a document.
+func marshalBSON(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeBSONDocument(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeBSONDocument writes doc, a bsonDoc, a map or a value that encodes
+// to a JSON object, as a BSON document.
+func encodeBSONDocument(buf *bytes.Buffer, doc any) error {
+	elems, err := bsonElements(doc)
+	if err != nil {
+		return err
+	}
+
+	start := buf.Len()
+	buf.Write([]byte{0, 0, 0, 0})
+	for _, e := range elems {
+		if err := encodeBSONElement(buf, e.Key, e.Value); err != nil {
+			return fmt.Errorf("%s: %w", e.Key, err)
+		}
+	}
+	buf.WriteByte(0)
+	binary.LittleEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+	return nil
+}
+
+// bsonElements returns the elements of a document value. Map keys are
+// sorted, with _id first, since Go maps have no order; the keys of a DBRef
+// come before it, in the order the server requires.
+func bsonElements(doc any) (bsonDoc, error) {
+	switch d := doc.(type) {
+	case bsonDoc:
+		return d, nil
+	case bson.D:
+		elems := make(bsonDoc, len(d))
+		for i, e := range d {
+			elems[i] = bsonElem{e.Key, e.Value}
+		}
+		return elems, nil
+	case bson.M:
+		return bsonElements(map[string]any(d))
+	case map[string]any:
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if ri, rj := keyRank(keys[i]), keyRank(keys[j]); ri != rj {
+				return ri < rj
+			}
+			return keys[i] < keys[j]
+		})
+		elems := make(bsonDoc, len(keys))
+		for i, k := range keys {
+			elems[i] = bsonElem{k, d[k]}
+		}
+		return elems, nil
+	case nil:
+		return nil, ErrNilDocument
+	}
+
+	generic, err := toGeneric(doc)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := generic.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T as a BSON document", doc)
+	}
+	return bsonElements(m)
+}
+
+// toGeneric converts v to maps, slices and scalars through its JSON
+// encoding, keeping integers as int64.
+func toGeneric(v any) (any, error) {
+	data, err := json.Marshal(encodeDates(v))
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// encodeBSONElement writes one element.
+func encodeBSONElement(buf *bytes.Buffer, key string, value any) error {
+	if strings.IndexByte(key, 0) >= 0 {
+		return fmt.Errorf("key contains a NUL byte")
+	}
+	header := func(t byte) {
+		buf.WriteByte(t)
+		buf.WriteString(key)
+		buf.WriteByte(0)
+	}
+
+	switch v := value.(type) {
+	case nil:
+		header(bsonNull)
+	case bool:
+		header(bsonBool)
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case string:
+		header(bsonString)
+		writeBSONString(buf, v)
+	case float64:
+		header(bsonDouble)
+		writeUint64(buf, math.Float64bits(v))
+	case float32:
+		header(bsonDouble)
+		writeUint64(buf, math.Float64bits(float64(v)))
+	case int:
+		encodeBSONInt(buf, header, int64(v))
+	case int8:
+		encodeBSONInt(buf, header, int64(v))
+	case int16:
+		encodeBSONInt(buf, header, int64(v))
+	case int32:
+		header(bsonInt32)
+		writeUint32(buf, uint32(v))
+	case int64:
+		header(bsonInt64)
+		writeUint64(buf, uint64(v))
+	case uint8:
+		encodeBSONInt(buf, header, int64(v))
+	case uint16:
+		encodeBSONInt(buf, header, int64(v))
+	case uint32:
+		encodeBSONInt(buf, header, int64(v))
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			encodeBSONInt(buf, header, n)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		header(bsonDouble)
+		writeUint64(buf, math.Float64bits(f))
+	case time.Time:
+		header(bsonDateTime)
+		writeUint64(buf, uint64(v.UnixMilli()))
+	case bson.DateTime:
+		header(bsonDateTime)
+		writeUint64(buf, uint64(v))
+	case bson.ObjectID:
+		header(bsonObjectID)
+		buf.Write(v[:])
+	case bson.Decimal128:
+		header(bsonDecimal)
+		h, l := v.GetBytes()
+		writeUint64(buf, l)
+		writeUint64(buf, h)
+	case []byte:
+		header(bsonBinary)
+		writeUint32(buf, uint32(len(v)))
+		buf.WriteByte(0)
+		buf.Write(v)
+	case bson.Binary:
+		header(bsonBinary)
+		writeUint32(buf, uint32(len(v.Data)))
+		buf.WriteByte(v.Subtype)
+		buf.Write(v.Data)
+	case bson.UUID:
+		header(bsonBinary)
+		writeUint32(buf, uint32(len(v)))
+		buf.WriteByte(bson.BinaryUUID)
+		buf.Write(v[:])
+	case bson.Timestamp:
+		header(bsonTimestamp)
+		writeUint32(buf, v.I)
+		writeUint32(buf, v.T)
+	case bson.Regex:
+		header(bsonRegex)
+		return writeBSONRegex(buf, v)
+	case bson.DBRef:
+		d := bson.D{{Key: "$ref", Value: v.Ref}, {Key: "$id", Value: v.ID}}
+		if v.DB != "" {
+			d = append(d, bson.E{Key: "$db", Value: v.DB})
+		}
+		header(bsonDocument)
+		return encodeBSONDocument(buf, d)
+	case bsonDoc, bson.D:
+		header(bsonDocument)
+		return encodeBSONDocument(buf, v)
+	case bson.M:
+		return encodeBSONElement(buf, key, map[string]any(v))
+	case map[string]any:
+		if t, payload, ok, err := extendedJSON(v); ok {
+			if err != nil {
+				return err
+			}
+			header(t)
+			buf.Write(payload)
+			return nil
+		}
+		header(bsonDocument)
+		return encodeBSONDocument(buf, v)
+	case []any:
+		header(bsonArray)
+		return encodeBSONArray(buf, v)
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Pointer:
+			if rv.IsNil() {
+				header(bsonNull)
+				return nil
+			}
+			return encodeBSONElement(buf, key, rv.Elem().Interface())
+		case reflect.Slice, reflect.Array:
+			items := make([]any, rv.Len())
+			for i := range items {
+				items[i] = rv.Index(i).Interface()
+			}
+			header(bsonArray)
+			return encodeBSONArray(buf, items)
+		}
+		generic, err := toGeneric(value)
+		if err != nil {
+			return err
+		}
+		return encodeBSONElement(buf, key, generic)
+	}
+	return nil
+}
+
+// keyRank orders the keys of a map document: the DBRef keys, then _id,
+// then the rest.
+func keyRank(key string) int {
+	switch key {
+	case "$ref":
+		return 0
+	case "$id":
+		return 1
+	case "$db":
+		return 2
+	case "_id":
+		return 3
+	}
+	return 4
+}
+
+// encodeBSONInt writes n as an int32 when it fits and an int64 otherwise.
+func encodeBSONInt(buf *bytes.Buffer, header func(byte), n int64) {
+	if n >= math.MinInt32 && n <= math.MaxInt32 {
+		header(bsonInt32)
+		writeUint32(buf, uint32(int32(n)))
+		return
+	}
+	header(bsonInt64)
+	writeUint64(buf, uint64(n))
+}
+
+// encodeBSONArray writes items as a document keyed by index.
+func encodeBSONArray(buf *bytes.Buffer, items []any) error {
+	elems := make(bsonDoc, len(items))
+	for i, item := range items {
+		elems[i] = bsonElem{strconv.Itoa(i), item}
+	}
+	return encodeBSONDocument(buf, elems)
+}
+
+// extendedJSON encodes the single-key extended JSON documents the SDK uses
+// for special types. It reports ok false for ordinary documents.
+func extendedJSON(doc map[string]any) (byte, []byte, bool, error) {
+	if len(doc) != 1 {
+		return 0, nil, false, nil
+	}
+	var buf bytes.Buffer
+	for key, value := range doc {
+		switch key {
+		case "$oid":
+			s, _ := value.(string)
+			id, err := hex.DecodeString(s)
+			if err != nil || len(id) != 12 {
+				return 0, nil, true, fmt.Errorf("invalid $oid %v", value)
+			}
+			return bsonObjectID, id, true, nil
+		case "$date":
+			t, err := parseDateTime(doc)
+			if err != nil {
+				return 0, nil, true, err
+			}
+			writeUint64(&buf, uint64(t.UnixMilli()))
+			return bsonDateTime, buf.Bytes(), true, nil
+		case "$numberLong":
+			s, _ := value.(string)
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return 0, nil, true, fmt.Errorf("invalid $numberLong %v", value)
+			}
+			writeUint64(&buf, uint64(n))
+			return bsonInt64, buf.Bytes(), true, nil
+		case "$numberDecimal":
+			s, _ := value.(string)
+			d, err := bson.ParseDecimal128(s)
+			if err != nil {
+				return 0, nil, true, fmt.Errorf("invalid $numberDecimal %v", value)
+			}
+			h, l := d.GetBytes()
+			writeUint64(&buf, l)
+			writeUint64(&buf, h)
+			return bsonDecimal, buf.Bytes(), true, nil
+		case "$uuid":
+			s, _ := value.(string)
+			id, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+			if err != nil || len(id) != 16 {
+				return 0, nil, true, fmt.Errorf("invalid $uuid %v", value)
+			}
+			writeUint32(&buf, 16)
+			buf.WriteByte(bson.BinaryUUID)
+			buf.Write(id)
+			return bsonBinary, buf.Bytes(), true, nil
+		case "$binary":
+			var b bson.Binary
+			data, _ := json.Marshal(doc)
+			if err := b.UnmarshalJSON(data); err != nil {
+				return 0, nil, true, fmt.Errorf("invalid $binary %v", value)
+			}
+			writeUint32(&buf, uint32(len(b.Data)))
+			buf.WriteByte(b.Subtype)
+			buf.Write(b.Data)
+			return bsonBinary, buf.Bytes(), true, nil
+		case "$timestamp":
+			ts, err := parseTimestamp(doc)
+			if err != nil {
+				return 0, nil, true, err
+			}
+			writeUint32(&buf, ts.I)
+			writeUint32(&buf, ts.T)
+			return bsonTimestamp, buf.Bytes(), true, nil
+		case "$regularExpression":
+			var re bson.Regex
+			data, _ := json.Marshal(doc)
+			if err := re.UnmarshalJSON(data); err != nil {
+				return 0, nil, true, fmt.Errorf("invalid $regularExpression %v", value)
+			}
+			if err := writeBSONRegex(&buf, re); err != nil {
+				return 0, nil, true, err
+			}
+			return bsonRegex, buf.Bytes(), true, nil
+		}
+	}
+	return 0, nil, false, nil
+}
+
+func writeBSONString(buf *bytes.Buffer, s string) {
+	writeUint32(buf, uint32(len(s)+1))
+	buf.WriteString(s)
+	buf.WriteByte(0)
+}
+
+// writeBSONRegex writes the pattern and options of re as C strings, which
+// cannot hold a NUL byte.
+func writeBSONRegex(buf *bytes.Buffer, re bson.Regex) error {
+	for _, s := range []string{re.Pattern, re.Options} {
+		if strings.IndexByte(s, 0) >= 0 {
+			return fmt.Errorf("invalid regular expression %q: contains a NUL byte", re.String())
+		}
+	}
+	// The server expects the options in alphabetical order
+	options := []byte(re.Options)
+	sort.Slice(options, func(i, j int) bool { return options[i] < options[j] })
+	buf.WriteString(re.Pattern)
+	buf.WriteByte(0)
+	buf.Write(options)
+	buf.WriteByte(0)
+	return nil
+}
+
+func writeUint32(buf *bytes.Buffer, n uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], n)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, n uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], n)
+	buf.Write(b[:])
+}
+
+// unmarshalBSON decodes a document.
+func unmarshalBSON(data []byte) (map[string]any, error) {
+	return unmarshalBSONOrdered(data, nil)
+}
+
+// unmarshalBSONOrdered decodes a document like unmarshalBSON, but decodes
+// the embedded documents ordered selects as bson.D, keeping their order.
+// ordered is given the keys leading to each embedded document.
+func unmarshalBSONOrdered(data []byte, ordered func(path []string) bool) (map[string]any, error) {
+	r := &bsonReader{data: data, ordered: ordered}
+	doc, err := r.document()
+	if err != nil {
+		return nil, fmt.Errorf("mongo: invalid BSON: %w", err)
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("mongo: invalid BSON: %d trailing bytes", len(data)-r.pos)
+	}
+	return doc, nil
+}
+
+// bsonReader decodes BSON from data.
+type bsonReader struct {
+	data []byte
+	pos  int
+	// ordered selects the embedded documents decoded as bson.D; path holds
+	// the keys leading to the current value.
+	ordered func(path []string) bool
+	path    []string
+}
+
+func (r *bsonReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bsonReader) uint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *bsonReader) uint64() (uint64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *bsonReader) cstring() (string, error) {
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := string(r.data[r.pos : r.pos+end])
+	r.pos += end + 1
+	return s, nil
+}
+
+func (r *bsonReader) string() (string, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.next(int(n))
+	if err != nil || n == 0 || b[n-1] != 0 {
+		return "", fmt.Errorf("invalid string")
+	}
+	return string(b[:n-1]), nil
+}
+
+// elements calls fn for each element of the document at the current
+// position.
+func (r *bsonReader) elements(fn func(key string, value any)) error {
+	start := r.pos
+	size, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	end := start + int(size)
+	if size < 5 || end > len(r.data) {
+		return fmt.Errorf("invalid document size %d", size)
+	}
+	for r.pos < end-1 {
+		t, err := r.next(1)
+		if err != nil {
+			return err
+		}
+		key, err := r.cstring()
+		if err != nil {
+			return err
+		}
+		r.path = append(r.path, key)
+		value, err := r.value(t[0])
+		r.path = r.path[:len(r.path)-1]
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		fn(key, value)
+	}
+	if r.pos != end-1 || r.data[r.pos] != 0 {
+		return fmt.Errorf("invalid document terminator")
+	}
+	r.pos = end
+	return nil
+}
+
+func (r *bsonReader) document() (map[string]any, error) {
+	doc := make(map[string]any)
+	err := r.elements(func(key string, value any) { doc[key] = value })
+	return doc, err
+}
+
+func (r *bsonReader) orderedDocument() (bson.D, error) {
+	doc := bson.D{}
+	err := r.elements(func(key string, value any) { doc = append(doc, bson.E{Key: key, Value: value}) })
+	return doc, err
+}
+
+func (r *bsonReader) array() ([]any, error) {
+	items := []any{}
+	err := r.elements(func(_ string, value any) { items = append(items, value) })
+	return items, err
+}
+
+// value decodes one value of type t.
+func (r *bsonReader) value(t byte) (any, error) {
+	switch t {
+	case bsonDouble:
+		n, err := r.uint64()
+		return math.Float64frombits(n), err
+	case bsonString:
+		return r.string()
+	case bsonDocument:
+		if r.ordered != nil && r.ordered(r.path) {
+			return r.orderedDocument()
+		}
+		return r.document()
+	case bsonArray:
+		return r.array()
+	case bsonBinary:
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		subtype, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		if subtype[0] == bson.BinaryUUID && n == 16 {
+			return map[string]any{"$uuid": formatUUID(b)}, nil
+		}
+		return map[string]any{"$binary": map[string]any{
+			"base64":  base64.StdEncoding.EncodeToString(b),
+			"subType": fmt.Sprintf("%02x", subtype[0]),
+		}}, nil
+	case bsonUndefined, bsonNull:
+		return nil, nil
+	case bsonObjectID:
+		b, err := r.next(12)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"$oid": hex.EncodeToString(b)}, nil
+	case bsonBool:
+		b, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case bsonDateTime:
+		n, err := r.uint64()
+		if t := bson.DateTime(n).Time(); t.Year() < 1970 || t.Year() > 9999 {
+			return map[string]any{"$date": map[string]any{"$numberLong": strconv.FormatInt(int64(n), 10)}}, err
+		}
+		return map[string]any{"$date": bson.DateTime(n).String()}, err
+	case bsonRegex:
+		pattern, err := r.cstring()
+		if err != nil {
+			return nil, err
+		}
+		options, err := r.cstring()
+		return map[string]any{"$regularExpression": map[string]any{"pattern": pattern, "options": options}}, err
+	case bsonInt32:
+		n, err := r.uint32()
+		return float64(int32(n)), err
+	case bsonTimestamp:
+		i, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		ts, err := r.uint32()
+		return map[string]any{"$timestamp": map[string]any{"t": float64(ts), "i": float64(i)}}, err
+	case bsonInt64:
+		n, err := r.uint64()
+		if v := int64(n); v > -maxSafeInteger && v < maxSafeInteger {
+			return float64(v), err
+		}
+		return map[string]any{"$numberLong": strconv.FormatInt(int64(n), 10)}, err
+	case bsonDecimal:
+		l, err := r.uint64()
+		if err != nil {
+			return nil, err
+		}
+		h, err := r.uint64()
+		return map[string]any{"$numberDecimal": bson.NewDecimal128(h, l).String()}, err
+	case bsonMinKey:
+		return map[string]any{"$minKey": float64(1)}, nil
+	case bsonMaxKey:
+		return map[string]any{"$maxKey": float64(1)}, nil
+	}
+	return nil, fmt.Errorf("unsupported BSON type 0x%02x", t)
+}
+
+// formatUUID formats 16 bytes as a UUID string.
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,219 @@
+package bson
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrInvalidBinary is returned when JSON input is not binary data.
+var ErrInvalidBinary = errors.New("bson: invalid binary")
+
+// ErrInvalidUUID is returned when a string or binary value is not a UUID.
+var ErrInvalidUUID = errors.New("bson: invalid UUID")
+
+// Binary subtypes.
+const (
+	BinaryGeneric     byte = 0x00
+	BinaryFunction    byte = 0x01
+	BinaryOld         byte = 0x02
+	BinaryUUIDOld     byte = 0x03
+	BinaryUUID        byte = 0x04
+	BinaryMD5         byte = 0x05
+	BinaryEncrypted   byte = 0x06
+	BinaryUserDefined byte = 0x80
+)
+
+// Binary is BSON binary data with its subtype. It encodes to JSON as
+// {"$binary": {"base64": ..., "subType": ...}}. A plain []byte is stored
+// with the generic subtype.
+type Binary struct {
+	Subtype byte
+	Data    []byte
+}
+
+// IsZero reports whether b holds no data.
+func (b Binary) IsZero() bool {
+	return len(b.Data) == 0 && b.Subtype == BinaryGeneric
+}
+
+// UUID returns b as a UUID. It fails unless b has a UUID subtype and holds
+// 16 bytes.
+func (b Binary) UUID() (UUID, error) {
+	var u UUID
+	if (b.Subtype != BinaryUUID && b.Subtype != BinaryUUIDOld) || len(b.Data) != len(u) {
+		return u, fmt.Errorf("%w: subtype %02x with %d bytes", ErrInvalidUUID, b.Subtype, len(b.Data))
+	}
+	copy(u[:], b.Data)
+	return u, nil
+}
+
+// MarshalJSON encodes b as {"$binary": {"base64": ..., "subType": ...}}.
+func (b Binary) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$binary":{"base64":"` + base64.StdEncoding.EncodeToString(b.Data) +
+		`","subType":"` + fmt.Sprintf("%02x", b.Subtype) + `"}}`), nil
+}
+
+// UnmarshalJSON decodes b from a $binary document, in the current or the
+// legacy {"$binary": ..., "$type": ...} form, a $uuid document or a base64
+// string. JSON null leaves b unchanged.
+func (b *Binary) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := parseBinaryValue(v)
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// parseBinaryValue parses a generically decoded binary value.
+func parseBinaryValue(v any) (Binary, error) {
+	invalid := fmt.Errorf("%w: %v", ErrInvalidBinary, v)
+	switch v := v.(type) {
+	case string:
+		data, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return Binary{}, invalid
+		}
+		return Binary{Data: data}, nil
+	case map[string]any:
+		if s, ok := v["$uuid"].(string); ok && len(v) == 1 {
+			u, err := ParseUUID(s)
+			if err != nil {
+				return Binary{}, err
+			}
+			return u.Binary(), nil
+		}
+		var encoded, subtype string
+		switch inner := v["$binary"].(type) {
+		case map[string]any:
+			if len(v) != 1 {
+				return Binary{}, invalid
+			}
+			encoded, _ = inner["base64"].(string)
+			subtype, _ = inner["subType"].(string)
+		case string:
+			if len(v) != 2 {
+				return Binary{}, invalid
+			}
+			encoded = inner
+			subtype, _ = v["$type"].(string)
+		default:
+			return Binary{}, invalid
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return Binary{}, invalid
+		}
+		n, err := strconv.ParseUint(subtype, 16, 8)
+		if err != nil {
+			return Binary{}, invalid
+		}
+		return Binary{Subtype: byte(n), Data: data}, nil
+	}
+	return Binary{}, invalid
+}
+
+// UUID is a 16-byte UUID. It is stored as binary subtype 4 and encodes to
+// JSON as {"$uuid": "<hyphenated hex>"}. Other UUID types with the same
+// [16]byte layout, such as github.com/google/uuid.UUID, convert to and from
+// UUID directly.
+type UUID [16]byte
+
+// NilUUID is the zero UUID.
+var NilUUID UUID
+
+// NewUUID returns a random version 4 UUID.
+func NewUUID() (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		return NilUUID, err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// ParseUUID parses a UUID in hyphenated or plain hex form.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	plain := s
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return NilUUID, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+		}
+		plain = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(plain) != 2*len(u) {
+		return NilUUID, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+	if _, err := hex.Decode(u[:], []byte(plain)); err != nil {
+		return NilUUID, fmt.Errorf("%w: %q", ErrInvalidUUID, s)
+	}
+	return u, nil
+}
+
+// String returns u in hyphenated form.
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// IsZero reports whether u is NilUUID.
+func (u UUID) IsZero() bool {
+	return u == NilUUID
+}
+
+// Binary returns u as binary subtype 4.
+func (u UUID) Binary() Binary {
+	return Binary{Subtype: BinaryUUID, Data: append([]byte(nil), u[:]...)}
+}
+
+// MarshalJSON encodes u as {"$uuid": "<hyphenated hex>"}.
+func (u UUID) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$uuid":"` + u.String() + `"}`), nil
+}
+
+// UnmarshalJSON decodes u from a $uuid document, a $binary document with
+// a UUID subtype or a UUID string. JSON null leaves u unchanged.
+func (u *UUID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if s, ok := v.(string); ok {
+		parsed, err := ParseUUID(s)
+		if err != nil {
+			return err
+		}
+		*u = parsed
+		return nil
+	}
+	b, err := parseBinaryValue(v)
+	if err != nil {
+		return err
+	}
+	parsed, err := b.UUID()
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
@@ -0,0 +1,102 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestBinaryJSON tests encoding binary data and decoding every accepted
+// form.
+func TestBinaryJSON(t *testing.T) {
+	b := Binary{Subtype: BinaryMD5, Data: []byte{1, 2}}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"$binary":{"base64":"AQI=","subType":"05"}}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	tests := []struct {
+		input string
+		want  Binary
+	}{
+		{`{"$binary":{"base64":"AQI=","subType":"05"}}`, b},
+		{`{"$binary":"AQI=","$type":"80"}`, Binary{Subtype: BinaryUserDefined, Data: []byte{1, 2}}},
+		{`"AQI="`, Binary{Data: []byte{1, 2}}},
+		{`{"$uuid":"01234567-89ab-cdef-0123-456789abcdef"}`, Binary{Subtype: BinaryUUID, Data: []byte{
+			0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef,
+		}}},
+	}
+	for _, tt := range tests {
+		var got Binary
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.input, err)
+		}
+		if got.Subtype != tt.want.Subtype || !bytes.Equal(got.Data, tt.want.Data) {
+			t.Errorf("%s: expected %v, got %v", tt.input, tt.want, got)
+		}
+	}
+
+	var got Binary
+	if err := json.Unmarshal([]byte(`{"$binary":{"base64":"!!","subType":"00"}}`), &got); !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("expected ErrInvalidBinary, got %v", err)
+	}
+}
+
+// TestUUID tests generating, parsing and encoding UUIDs.
+func TestUUID(t *testing.T) {
+	u, err := NewUUID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.IsZero() || u[6]>>4 != 4 || u[8]>>6 != 2 {
+		t.Errorf("expected a version 4 UUID, got %v", u)
+	}
+
+	const s = "01234567-89ab-cdef-0123-456789abcdef"
+	parsed, err := ParseUUID(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.String() != s {
+		t.Errorf("expected %s, got %s", s, parsed)
+	}
+	if plain, err := ParseUUID("0123456789abcdef0123456789abcdef"); err != nil || plain != parsed {
+		t.Errorf("expected %v, got %v (%v)", parsed, plain, err)
+	}
+	for _, bad := range []string{"", "0123456789ab-cdef-0123-456789abcdef0", "01234567-89ab-cdef-0123-456789abcdeg"} {
+		if _, err := ParseUUID(bad); !errors.Is(err, ErrInvalidUUID) {
+			t.Errorf("%q: expected ErrInvalidUUID, got %v", bad, err)
+		}
+	}
+
+	data, _ := json.Marshal(parsed)
+	if string(data) != `{"$uuid":"`+s+`"}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+	for _, input := range []string{
+		`{"$uuid":"` + s + `"}`,
+		`{"$binary":{"base64":"ASNFZ4mrze8BI0VniavN7w==","subType":"04"}}`,
+		`{"$binary":{"base64":"ASNFZ4mrze8BI0VniavN7w==","subType":"03"}}`,
+		`"` + s + `"`,
+	} {
+		var got UUID
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", input, err)
+		}
+		if got != parsed {
+			t.Errorf("%s: expected %v, got %v", input, parsed, got)
+		}
+	}
+
+	var got UUID
+	if err := json.Unmarshal([]byte(`{"$binary":{"base64":"AQI=","subType":"04"}}`), &got); !errors.Is(err, ErrInvalidUUID) {
+		t.Errorf("expected ErrInvalidUUID, got %v", err)
+	}
+	if b := parsed.Binary(); b.Subtype != BinaryUUID || !bytes.Equal(b.Data, parsed[:]) {
+		t.Errorf("unexpected binary %v", b)
+	}
+}
@@ -0,0 +1,173 @@
+// Package bson provides document types for building filters, updates,
+// pipelines and commands.
+//
+// Go maps have no key order, which is fine for most filters but not for
+// documents whose order is significant, such as commands, sort
+// specifications and $group keys. D keeps its elements in the order given:
+//
+//	sort := bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}}
+//	pipeline := bson.A{
+//		bson.D{{Key: "$match", Value: bson.M{"status": "A"}}},
+//		bson.D{{Key: "$sort", Value: sort}},
+//	}
+//
+// All the types encode to JSON, so they can be passed anywhere a
+// map[string]any document is accepted.
+//
+// ObjectID is the 12-byte identifier MongoDB assigns to documents; generate
+// one with NewObjectID to set _id before inserting.
+// Decimal128 holds exact decimal values, such as prices, that float64 would
+// round. DateTime is a date as MongoDB stores it; time.Time values are
+// converted to it when documents are sent. Binary is binary data with its
+// subtype, and UUID a UUID stored as binary subtype 4. Timestamp is the
+// server's logical time, as in cluster times and the oplog. Regex is a
+// regular expression with options, matched by the server. DBRef is a
+// reference to a document in another collection.
+//
+// Raw holds a result document as received; Lookup reads a value by path
+// without decoding the whole document.
+//
+// MarshalExtJSON and UnmarshalExtJSON convert documents to and from MongoDB
+// Extended JSON v2, the format of mongoexport and the Atlas Data API.
+//
+// Types that implement Marshaler and Unmarshaler control their own document
+// representation.
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidDocument is returned when JSON input is not a document.
+var ErrInvalidDocument = errors.New("bson: invalid document")
+
+// E is a single element of a D.
+type E struct {
+	Key   string
+	Value any
+}
+
+// D is an ordered document. Its elements are encoded in order.
+type D []E
+
+// M is an unordered document.
+type M map[string]any
+
+// A is an array.
+type A []any
+
+// Map returns the elements of d as an M. When a key repeats, the last
+// element wins.
+func (d D) Map() M {
+	m := make(M, len(d))
+	for _, e := range d {
+		m[e.Key] = e.Value
+	}
+	return m
+}
+
+// MarshalJSON encodes d as a JSON object with its keys in order.
+func (d D) MarshalJSON() ([]byte, error) {
+	if d == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range d {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("bson: key %q: %w", e.Key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object into d, keeping its keys in order.
+// Nested objects are decoded as D and arrays as A.
+func (d *D) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeValue(dec, false)
+	if err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case D:
+		*d = v
+	case nil:
+		*d = nil
+	default:
+		return fmt.Errorf("%w: expected an object, got %T", ErrInvalidDocument, value)
+	}
+	return nil
+}
+
+// decodeValue decodes the next JSON value from dec, building objects as D.
+// Numbers are decoded as float64, as encoding/json does, unless keepNumbers
+// is set, in which case they stay json.Number.
+func decodeValue(dec *json.Decoder, keepNumbers bool) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			d := D{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: unexpected token %v", ErrInvalidDocument, keyTok)
+				}
+				value, err := decodeValue(dec, keepNumbers)
+				if err != nil {
+					return nil, err
+				}
+				d = append(d, E{Key: key, Value: value})
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return d, nil
+		case '[':
+			a := A{}
+			for dec.More() {
+				value, err := decodeValue(dec, keepNumbers)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return a, nil
+		}
+		return nil, fmt.Errorf("%w: unexpected token %v", ErrInvalidDocument, t)
+	case json.Number:
+		if keepNumbers {
+			return t, nil
+		}
+		return t.Float64()
+	}
+	return tok, nil
+}
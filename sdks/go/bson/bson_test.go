@@ -0,0 +1,61 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestDMarshalJSON tests that documents encode their keys in order.
+func TestDMarshalJSON(t *testing.T) {
+	doc := D{
+		{Key: "z", Value: 1},
+		{Key: "a", Value: D{{Key: "y", Value: "x"}, {Key: "b", Value: A{1, M{"k": true}}}}},
+		{Key: "m", Value: nil},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"z":1,"a":{"y":"x","b":[1,{"k":true}]},"m":null}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	if data, _ := json.Marshal(D(nil)); string(data) != "null" {
+		t.Errorf("expected null, got %s", data)
+	}
+	if data, _ := json.Marshal(D{}); string(data) != "{}" {
+		t.Errorf("expected {}, got %s", data)
+	}
+}
+
+// TestDUnmarshalJSON tests that decoding keeps keys in order.
+func TestDUnmarshalJSON(t *testing.T) {
+	var doc D
+	if err := json.Unmarshal([]byte(`{"z":1,"a":{"y":"x"},"l":[2.5,{"k":true}],"n":null}`), &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := D{
+		{Key: "z", Value: float64(1)},
+		{Key: "a", Value: D{{Key: "y", Value: "x"}}},
+		{Key: "l", Value: A{2.5, D{{Key: "k", Value: true}}}},
+		{Key: "n", Value: nil},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("expected %v, got %v", want, doc)
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), &doc); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("expected ErrInvalidDocument, got %v", err)
+	}
+}
+
+// TestDMap tests converting a document to a map.
+func TestDMap(t *testing.T) {
+	got := D{{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "a", Value: 3}}.Map()
+	if !reflect.DeepEqual(got, M{"a": 3, "b": 2}) {
+		t.Errorf("unexpected map: %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidDateTime is returned when JSON input is not a date.
+var ErrInvalidDateTime = errors.New("bson: invalid date")
+
+// dateLayout formats dates the way MongoDB's relaxed Extended JSON does.
+const dateLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// DateTime is a BSON date: milliseconds since the Unix epoch, in UTC. It
+// encodes to JSON as {"$date": ...}, so dates reach the server as dates
+// rather than strings. The SDK converts time.Time values to DateTime when
+// sending documents.
+type DateTime int64
+
+// NewDateTimeFromTime returns the DateTime of t, truncated to milliseconds.
+func NewDateTimeFromTime(t time.Time) DateTime {
+	return DateTime(t.UnixMilli())
+}
+
+// Time returns d as a UTC time.
+func (d DateTime) Time() time.Time {
+	return time.UnixMilli(int64(d)).UTC()
+}
+
+// String returns d in ISO-8601 form.
+func (d DateTime) String() string {
+	return d.Time().Format(dateLayout)
+}
+
+// MarshalJSON encodes d as {"$date": "<ISO-8601>"}, or as
+// {"$date": {"$numberLong": "<ms>"}} for years outside 1970 to 9999.
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	if t := d.Time(); t.Year() >= 1970 && t.Year() <= 9999 {
+		return []byte(`{"$date":"` + t.Format(dateLayout) + `"}`), nil
+	}
+	return []byte(`{"$date":{"$numberLong":"` + strconv.FormatInt(int64(d), 10) + `"}}`), nil
+}
+
+// UnmarshalJSON decodes d from any $date form, an RFC 3339 string or a
+// number of milliseconds. JSON null leaves d unchanged.
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	t, err := parseDateValue(v)
+	if err != nil {
+		return err
+	}
+	*d = NewDateTimeFromTime(t)
+	return nil
+}
+
+// parseDateValue parses a generically decoded date: a $date document, an
+// RFC 3339 string or a number of milliseconds.
+func parseDateValue(v any) (time.Time, error) {
+	if doc, ok := v.(map[string]any); ok {
+		inner, ok := doc["$date"]
+		if !ok || len(doc) != 1 {
+			return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDateTime, v)
+		}
+		if long, ok := inner.(map[string]any); ok {
+			inner = long["$numberLong"]
+		}
+		v = inner
+	}
+	switch v := v.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC(), nil
+		}
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.UnixMilli(ms).UTC(), nil
+		}
+	case json.Number:
+		if ms, err := v.Int64(); err == nil {
+			return time.UnixMilli(ms).UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidDateTime, v)
+}
@@ -0,0 +1,50 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDateTimeJSON tests encoding dates and decoding every accepted form.
+func TestDateTimeJSON(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	d := NewDateTimeFromTime(when)
+	if !d.Time().Equal(when) || d.String() != "2024-01-02T03:04:05.006Z" {
+		t.Errorf("unexpected date %v", d)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"$date":"2024-01-02T03:04:05.006Z"}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+	old, _ := json.Marshal(NewDateTimeFromTime(time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)))
+	if string(old) != `{"$date":{"$numberLong":"-315619200000"}}` {
+		t.Errorf("unexpected encoding %s", old)
+	}
+
+	for _, input := range []string{
+		`{"$date":"2024-01-02T03:04:05.006Z"}`,
+		`{"$date":{"$numberLong":"1704164645006"}}`,
+		`{"$date":1704164645006}`,
+		`"2024-01-02T04:04:05.006+01:00"`,
+		`1704164645006`,
+	} {
+		var got DateTime
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", input, err)
+		}
+		if got != d {
+			t.Errorf("%s: expected %v, got %v", input, d, got)
+		}
+	}
+
+	var got DateTime
+	if err := json.Unmarshal([]byte(`{"$oid":"x"}`), &got); !errors.Is(err, ErrInvalidDateTime) {
+		t.Errorf("expected ErrInvalidDateTime, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package bson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidDBRef is returned when JSON input is not a DBRef.
+var ErrInvalidDBRef = errors.New("bson: invalid DBRef")
+
+// DBRef is a reference to a document in another collection, as stored by
+// older applications and ODMs: the collection name, the referenced _id and
+// optionally the database. Collection.ResolveRef fetches the document it
+// points to. It encodes to JSON as {"$ref": ..., "$id": ..., "$db": ...},
+// in that order, which the server requires.
+type DBRef struct {
+	Ref string
+	ID  any
+	DB  string
+}
+
+// String returns ref as "DBRef(collection, id)", or
+// "DBRef(collection, id, database)" with a database, as the shell prints it.
+func (ref DBRef) String() string {
+	if ref.DB != "" {
+		return fmt.Sprintf("DBRef(%q, %v, %q)", ref.Ref, ref.ID, ref.DB)
+	}
+	return fmt.Sprintf("DBRef(%q, %v)", ref.Ref, ref.ID)
+}
+
+// MarshalJSON encodes ref as {"$ref": ..., "$id": ...}, followed by "$db"
+// when ref names a database.
+func (ref DBRef) MarshalJSON() ([]byte, error) {
+	d := D{{Key: "$ref", Value: ref.Ref}, {Key: "$id", Value: ref.ID}}
+	if ref.DB != "" {
+		d = append(d, E{Key: "$db", Value: ref.DB})
+	}
+	return d.MarshalJSON()
+}
+
+// UnmarshalJSON decodes ref from a {"$ref": ..., "$id": ...} document with
+// an optional "$db". The _id is decoded as relaxed Extended JSON, so an
+// {"$oid": ...} becomes an ObjectID. Other fields, which some ODMs add, are
+// dropped. JSON null leaves ref unchanged.
+func (ref *DBRef) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var d D
+	if err := UnmarshalExtJSON(data, false, &d); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDBRef, err)
+	}
+	parsed, ok := parseDBRef(d)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidDBRef, data)
+	}
+	*ref = parsed
+	return nil
+}
+
+// parseDBRef reads a DBRef from a decoded document, reporting false if d
+// lacks a string $ref or an $id, or has a $db that is not a string.
+func parseDBRef(d D) (DBRef, bool) {
+	var ref DBRef
+	var hasRef, hasID bool
+	for _, e := range d {
+		switch e.Key {
+		case "$ref":
+			ref.Ref, hasRef = e.Value.(string)
+		case "$id":
+			ref.ID, hasID = e.Value, true
+		case "$db":
+			db, ok := e.Value.(string)
+			if !ok {
+				return DBRef{}, false
+			}
+			ref.DB = db
+		}
+	}
+	return ref, hasRef && hasID
+}
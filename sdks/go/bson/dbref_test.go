@@ -0,0 +1,62 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestDBRefJSON tests encoding and decoding DBRefs.
+func TestDBRefJSON(t *testing.T) {
+	id, err := ObjectIDFromHex("65f1a2b3c4d5e6f708192a3b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(DBRef{Ref: "users", ID: id, DB: "app"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"$ref":"users","$id":{"$oid":"65f1a2b3c4d5e6f708192a3b"},"$db":"app"}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	if data, _ := json.Marshal(DBRef{Ref: "users", ID: 7}); string(data) != `{"$ref":"users","$id":7}` {
+		t.Errorf("expected no $db, got %s", data)
+	}
+
+	var doc struct{ Owner DBRef }
+	if err := json.Unmarshal([]byte(`{"Owner":{"$id":{"$oid":"65f1a2b3c4d5e6f708192a3b"},"$ref":"users","$db":"app","_class":"User"}}`), &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Owner.Ref != "users" || doc.Owner.ID != id || doc.Owner.DB != "app" {
+		t.Errorf("unexpected DBRef %v", doc.Owner)
+	}
+
+	for _, input := range []string{`{"$ref":"users"}`, `{"$id":1}`, `{"$ref":1,"$id":1}`, `{"$ref":"users","$id":1,"$db":2}`, `[1]`} {
+		var ref DBRef
+		if err := json.Unmarshal([]byte(input), &ref); !errors.Is(err, ErrInvalidDBRef) {
+			t.Errorf("%s: expected ErrInvalidDBRef, got %v", input, err)
+		}
+	}
+}
+
+// TestDBRefString tests printing a DBRef.
+func TestDBRefString(t *testing.T) {
+	if s := (DBRef{Ref: "users", ID: 7}).String(); s != `DBRef("users", 7)` {
+		t.Errorf("unexpected string %s", s)
+	}
+	if s := (DBRef{Ref: "users", ID: "ada", DB: "app"}).String(); s != `DBRef("users", ada, "app")` {
+		t.Errorf("unexpected string %s", s)
+	}
+}
+
+// TestRawDBRef tests reading a DBRef from a raw document.
+func TestRawDBRef(t *testing.T) {
+	raw := Raw(`{"owner":{"$ref":"users","$id":7},"n":{"a":1}}`)
+	if ref, ok := raw.Lookup("owner").DBRefOK(); !ok || ref.Ref != "users" || ref.ID != int32(7) {
+		t.Errorf("unexpected DBRef %v", ref)
+	}
+	if _, ok := raw.Lookup("n").DBRefOK(); ok {
+		t.Error("expected a plain document not to read as a DBRef")
+	}
+}
@@ -0,0 +1,270 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a value cannot be represented exactly
+// as a Decimal128.
+var ErrInvalidDecimal = errors.New("bson: invalid Decimal128")
+
+// Decimal128 limits, from IEEE 754-2008 decimal128.
+const (
+	decimalMaxDigits   = 34
+	decimalExpBias     = 6176
+	decimalMinExponent = -6176
+	decimalMaxExponent = 6111
+)
+
+var (
+	decimalMaxCoefficient = new(big.Int).Sub(new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalMaxDigits), nil), big.NewInt(1))
+	bigTen                = big.NewInt(10)
+)
+
+// Decimal128 is a 128-bit IEEE 754-2008 decimal floating point number, the
+// BSON type for exact decimal values such as money. It encodes to JSON in
+// extended JSON form, {"$numberDecimal": "<value>"}, so its digits survive
+// the round trip that float64 would round.
+//
+// Decimal128 does no arithmetic itself; use BigInt to hand the exact value
+// to a big number or decimal library.
+type Decimal128 struct {
+	h, l uint64
+}
+
+// NewDecimal128 returns the Decimal128 with the given high and low 64 bits.
+func NewDecimal128(h, l uint64) Decimal128 {
+	return Decimal128{h: h, l: l}
+}
+
+// GetBytes returns the high and low 64 bits of d.
+func (d Decimal128) GetBytes() (uint64, uint64) {
+	return d.h, d.l
+}
+
+// IsNaN reports whether d is not a number.
+func (d Decimal128) IsNaN() bool {
+	return d.h>>58&0x1F == 0x1F
+}
+
+// IsInf returns 1 for positive infinity, -1 for negative infinity and 0
+// otherwise.
+func (d Decimal128) IsInf() int {
+	if d.h>>58&0x1F != 0x1E {
+		return 0
+	}
+	if d.h>>63 == 1 {
+		return -1
+	}
+	return 1
+}
+
+// BigInt returns the coefficient and exponent of d, whose value is
+// coefficient × 10^exponent. It fails for NaN and infinities.
+func (d Decimal128) BigInt() (*big.Int, int, error) {
+	if d.IsNaN() || d.IsInf() != 0 {
+		return nil, 0, fmt.Errorf("%w: %s has no coefficient", ErrInvalidDecimal, d)
+	}
+
+	var exp int
+	coef := new(big.Int)
+	if d.h>>61&3 == 3 {
+		// The coefficient would exceed 2^113, which is not canonical and
+		// reads as zero.
+		exp = int(d.h>>47&0x3FFF) - decimalExpBias
+	} else {
+		exp = int(d.h>>49&0x3FFF) - decimalExpBias
+		coef.SetUint64(d.h & (1<<49 - 1))
+		coef.Lsh(coef, 64)
+		coef.Or(coef, new(big.Int).SetUint64(d.l))
+	}
+	if d.h>>63 == 1 {
+		coef.Neg(coef)
+	}
+	return coef, exp, nil
+}
+
+// ParseDecimal128FromBigInt returns coefficient × 10^exponent as a
+// Decimal128. It fails if the value cannot be represented exactly.
+func ParseDecimal128FromBigInt(coefficient *big.Int, exponent int) (Decimal128, error) {
+	coef := new(big.Int).Abs(coefficient)
+	negative := coefficient.Sign() < 0
+	q, r := new(big.Int), new(big.Int)
+
+	// Drop trailing zeros while there are too many digits or the exponent
+	// is too small; both keep the value exact.
+	for coef.Cmp(decimalMaxCoefficient) > 0 || exponent < decimalMinExponent {
+		q.QuoRem(coef, bigTen, r)
+		if r.Sign() != 0 {
+			return Decimal128{}, fmt.Errorf("%w: %se%d is not exact in 34 digits", ErrInvalidDecimal, coefficient, exponent)
+		}
+		coef.Set(q)
+		exponent++
+	}
+	// Pad the coefficient while the exponent is too large.
+	for exponent > decimalMaxExponent {
+		if coef.Sign() == 0 {
+			exponent = decimalMaxExponent
+			break
+		}
+		coef.Mul(coef, bigTen)
+		if coef.Cmp(decimalMaxCoefficient) > 0 {
+			return Decimal128{}, fmt.Errorf("%w: %se%d overflows", ErrInvalidDecimal, coefficient, exponent)
+		}
+		exponent--
+	}
+
+	lo := new(big.Int).And(coef, new(big.Int).SetUint64(^uint64(0))).Uint64()
+	hi := new(big.Int).Rsh(coef, 64).Uint64()
+	hi |= uint64(exponent+decimalExpBias) << 49
+	if negative {
+		hi |= 1 << 63
+	}
+	return Decimal128{h: hi, l: lo}, nil
+}
+
+// ParseDecimal128 parses a decimal string such as "-12.50", "1E+3",
+// "Infinity" or "NaN". It fails rather than round if the value has more
+// than 34 significant digits.
+func ParseDecimal128(s string) (Decimal128, error) {
+	text := s
+	negative := false
+	if text != "" && (text[0] == '-' || text[0] == '+') {
+		negative = text[0] == '-'
+		text = text[1:]
+	}
+
+	switch strings.ToLower(text) {
+	case "nan":
+		return Decimal128{h: 0x1F << 58}, nil
+	case "inf", "infinity":
+		if negative {
+			return Decimal128{h: 0x1E<<58 | 1<<63}, nil
+		}
+		return Decimal128{h: 0x1E << 58}, nil
+	}
+
+	mantissa, exponent := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		mantissa = text[:i]
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil {
+			return Decimal128{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+		}
+		exponent = e
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal128{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	d, err := ParseDecimal128FromBigInt(coef, exponent-len(frac))
+	if err != nil {
+		return Decimal128{}, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+	}
+	if negative && coef.Sign() == 0 {
+		d.h |= 1 << 63
+	}
+	return d, nil
+}
+
+// String returns d in the canonical string form MongoDB uses.
+func (d Decimal128) String() string {
+	if d.IsNaN() {
+		return "NaN"
+	}
+	switch d.IsInf() {
+	case 1:
+		return "Infinity"
+	case -1:
+		return "-Infinity"
+	}
+
+	coef, exp, _ := d.BigInt()
+	var b strings.Builder
+	if d.h>>63 == 1 {
+		b.WriteByte('-')
+	}
+	digits := new(big.Int).Abs(coef).String()
+	adjusted := exp + len(digits) - 1
+
+	switch {
+	case exp > 0 || adjusted < -6:
+		b.WriteString(digits[:1])
+		if len(digits) > 1 {
+			b.WriteByte('.')
+			b.WriteString(digits[1:])
+		}
+		b.WriteByte('E')
+		if adjusted >= 0 {
+			b.WriteByte('+')
+		}
+		b.WriteString(strconv.Itoa(adjusted))
+	case exp == 0:
+		b.WriteString(digits)
+	default:
+		point := len(digits) + exp
+		if point <= 0 {
+			b.WriteString("0.")
+			b.WriteString(strings.Repeat("0", -point))
+			b.WriteString(digits)
+		} else {
+			b.WriteString(digits[:point])
+			b.WriteByte('.')
+			b.WriteString(digits[point:])
+		}
+	}
+	return b.String()
+}
+
+// MarshalJSON encodes d as {"$numberDecimal": "<value>"}.
+func (d Decimal128) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$numberDecimal":"` + d.String() + `"}`), nil
+}
+
+// UnmarshalJSON decodes d from {"$numberDecimal": "<value>"}, a string or
+// a JSON number, keeping every digit of the input. JSON null leaves d
+// unchanged.
+func (d *Decimal128) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var s string
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		var wrapper struct {
+			Decimal *string `json:"$numberDecimal"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return err
+		}
+		if wrapper.Decimal == nil {
+			return fmt.Errorf("%w: %s", ErrInvalidDecimal, data)
+		}
+		s = *wrapper.Decimal
+	case len(data) > 0 && data[0] == '"':
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	default:
+		s = string(data)
+	}
+	parsed, err := ParseDecimal128(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
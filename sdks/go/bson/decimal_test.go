@@ -0,0 +1,112 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestParseDecimal128 tests parsing and canonical formatting.
+func TestParseDecimal128(t *testing.T) {
+	tests := []struct {
+		in   string
+		h, l uint64
+		want string
+	}{
+		{"0", 0x3040000000000000, 0, "0"},
+		{"1", 0x3040000000000000, 1, "1"},
+		{"-1", 0xB040000000000000, 1, "-1"},
+		{"-0", 0xB040000000000000, 0, "-0"},
+		{"1.23", 0x303C000000000000, 123, "1.23"},
+		{"-12.50", 0xB03C000000000000, 1250, "-12.50"},
+		{"0.000001234", 0x302E000000000000, 1234, "0.000001234"},
+		{"0.0000001234", 0x302C000000000000, 1234, "1.234E-7"},
+		{"1E+3", 0x3046000000000000, 1, "1E+3"},
+		{"+1e3", 0x3046000000000000, 1, "1E+3"},
+		{"NaN", 0x7C00000000000000, 0, "NaN"},
+		{"Infinity", 0x7800000000000000, 0, "Infinity"},
+		{"-inf", 0xF800000000000000, 0, "-Infinity"},
+		{"9999999999999999999999999999999999", 0x3041ED09BEAD87C0, 0x378D8E63FFFFFFFF, "9999999999999999999999999999999999"},
+		{"1E6112", 0x5FFE000000000000, 10, "1.0E+6112"},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDecimal128(tt.in)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.in, err)
+		}
+		if h, l := d.GetBytes(); h != tt.h || l != tt.l {
+			t.Errorf("%s: expected %016x%016x, got %016x%016x", tt.in, tt.h, tt.l, h, l)
+		}
+		if d.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.in, tt.want, d.String())
+		}
+	}
+
+	for _, s := range []string{"", "abc", "1.2.3", "1e", "12345678901234567890123456789012345", "1E-6200", "1E6200"} {
+		if _, err := ParseDecimal128(s); !errors.Is(err, ErrInvalidDecimal) {
+			t.Errorf("%q: expected ErrInvalidDecimal, got %v", s, err)
+		}
+	}
+}
+
+// TestDecimal128BigInt tests converting to and from a coefficient and
+// exponent.
+func TestDecimal128BigInt(t *testing.T) {
+	d, _ := ParseDecimal128("-1234.5678")
+	coef, exp, err := d.BigInt()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if coef.String() != "-12345678" || exp != -4 {
+		t.Errorf("unexpected coefficient %s and exponent %d", coef, exp)
+	}
+
+	back, err := ParseDecimal128FromBigInt(coef, exp)
+	if err != nil || back != d {
+		t.Errorf("expected %v, got %v (%v)", d, back, err)
+	}
+
+	// Trailing zeros beyond 34 digits are dropped exactly.
+	big35, _ := new(big.Int).SetString("12345678901234567890123456789012340", 10)
+	if d, err := ParseDecimal128FromBigInt(big35, 0); err != nil || d.String() != "1.234567890123456789012345678901234E+34" {
+		t.Errorf("unexpected result %v (%v)", d, err)
+	}
+
+	nan, _ := ParseDecimal128("NaN")
+	if _, _, err := nan.BigInt(); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal, got %v", err)
+	}
+}
+
+// TestDecimal128JSON tests the extended JSON encoding and exact decoding.
+func TestDecimal128JSON(t *testing.T) {
+	d, _ := ParseDecimal128("1234567890.123456789012345678")
+	data, err := json.Marshal(M{"price": d})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"price":{"$numberDecimal":"1234567890.123456789012345678"}}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	for _, input := range []string{
+		`{"$numberDecimal":"1234567890.123456789012345678"}`,
+		`"1234567890.123456789012345678"`,
+		`1234567890.123456789012345678`,
+	} {
+		var got Decimal128
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", input, err)
+		}
+		if got != d {
+			t.Errorf("%s: expected %v, got %v", input, d, got)
+		}
+	}
+
+	var got Decimal128
+	if err := json.Unmarshal([]byte(`{"value":"1"}`), &got); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("expected ErrInvalidDecimal, got %v", err)
+	}
+}
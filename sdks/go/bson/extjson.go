@@ -0,0 +1,650 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExtJSON is returned when input is not valid Extended JSON.
+var ErrInvalidExtJSON = errors.New("bson: invalid extended JSON")
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	dateTimeType   = reflect.TypeOf(DateTime(0))
+	binaryType     = reflect.TypeOf(Binary{})
+	uuidType       = reflect.TypeOf(UUID{})
+	objectIDType   = reflect.TypeOf(ObjectID{})
+	decimalType    = reflect.TypeOf(Decimal128{})
+	dType          = reflect.TypeOf(D{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// opaqueWrappers are the Extended JSON wrappers without a Go equivalent in
+// this package. They are decoded and encoded as they are.
+var opaqueWrappers = map[string]bool{
+	"$minKey": true, "$maxKey": true, "$symbol": true, "$code": true,
+	"$undefined": true, "$dbPointer": true,
+}
+
+// MarshalExtJSON encodes val as Extended JSON v2.
+//
+// Canonical mode keeps every type: integers become $numberInt or
+// $numberLong, doubles $numberDouble and times $date with a $numberLong of
+// milliseconds. Relaxed mode writes numbers as plain JSON numbers and times
+// from 1970 to 9999 as ISO-8601 strings, which is easier to read but loses
+// the distinction between integer sizes.
+//
+// Structs are encoded field by field, following `bson` tags with a fallback
+// to `json` tags; a Go int is written as an int32 when it fits. escapeHTML
+// escapes <, > and & in strings, as encoding/json does.
+func MarshalExtJSON(val any, canonical, escapeHTML bool) ([]byte, error) {
+	w := &extJSONWriter{canonical: canonical, escapeHTML: escapeHTML}
+	if err := w.write(reflect.ValueOf(val)); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// UnmarshalExtJSON decodes Extended JSON v2 data into val, which must be a
+// non-nil pointer.
+//
+// Decoded into a *D, *M, map or interface, the wrappers become Go values:
+// $oid an ObjectID, $date a time.Time, $numberInt an int32, $numberLong an
+// int64, $numberDouble a float64, $numberDecimal a Decimal128, $binary a
+// []byte, a UUID for subtype 4 or a Binary for other subtypes, $uuid a
+// UUID, $timestamp a Timestamp and $regularExpression a Regex. Other
+// targets, such as structs, are filled with encoding/json after the same
+// conversion, so their fields follow `json` tags. With canonical set,
+// plain JSON numbers are rejected, as canonical Extended JSON wraps every
+// number.
+func UnmarshalExtJSON(data []byte, canonical bool, val any) error {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: cannot decode into %T", ErrInvalidExtJSON, val)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	raw, err := decodeValue(dec, true)
+	if err != nil {
+		return err
+	}
+	value, err := parseExtJSON(raw, canonical)
+	if err != nil {
+		return err
+	}
+
+	switch target := val.(type) {
+	case *D:
+		d, ok := value.(D)
+		if !ok && value != nil {
+			return fmt.Errorf("%w: expected an object, got %T", ErrInvalidDocument, value)
+		}
+		*target = d
+		return nil
+	case *any:
+		*target = value
+		return nil
+	case *M:
+		m, ok := toMap(value).(map[string]any)
+		if !ok && value != nil {
+			return fmt.Errorf("%w: expected an object, got %T", ErrInvalidDocument, value)
+		}
+		*target = m
+		return nil
+	case *map[string]any:
+		m, ok := toMap(value).(map[string]any)
+		if !ok && value != nil {
+			return fmt.Errorf("%w: expected an object, got %T", ErrInvalidDocument, value)
+		}
+		*target = m
+		return nil
+	}
+
+	plain, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, val)
+}
+
+// toMap converts the documents within v from D to map[string]any.
+func toMap(v any) any {
+	switch v := v.(type) {
+	case D:
+		m := make(map[string]any, len(v))
+		for _, e := range v {
+			m[e.Key] = toMap(e.Value)
+		}
+		return m
+	case A:
+		for i, elem := range v {
+			v[i] = toMap(elem)
+		}
+	}
+	return v
+}
+
+// parseExtJSON converts a value decoded with decodeValue, keeping numbers,
+// into Go values, replacing the Extended JSON wrappers it recognizes.
+func parseExtJSON(v any, canonical bool) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if canonical {
+			return nil, fmt.Errorf("%w: plain number %s in canonical mode", ErrInvalidExtJSON, v)
+		}
+		return parseRelaxedNumber(v)
+	case A:
+		for i, elem := range v {
+			parsed, err := parseExtJSON(elem, canonical)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = parsed
+		}
+		return v, nil
+	case D:
+		if value, ok, err := parseWrapper(v, canonical); ok || err != nil {
+			return value, err
+		}
+		for i, e := range v {
+			parsed, err := parseExtJSON(e.Value, canonical)
+			if err != nil {
+				return nil, err
+			}
+			v[i].Value = parsed
+		}
+		return v, nil
+	}
+	return v, nil
+}
+
+// parseRelaxedNumber returns n as an int32 or int64 when it is an integer
+// that fits, and as a float64 otherwise.
+func parseRelaxedNumber(n json.Number) (any, error) {
+	if i, err := strconv.ParseInt(n.String(), 10, 64); err == nil {
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			return int32(i), nil
+		}
+		return i, nil
+	}
+	return n.Float64()
+}
+
+// parseWrapper converts a single-key Extended JSON wrapper document. It
+// reports ok false for other documents.
+func parseWrapper(d D, canonical bool) (any, bool, error) {
+	if len(d) == 0 || !strings.HasPrefix(d[0].Key, "$") {
+		return nil, false, nil
+	}
+	if len(d) == 1 && opaqueWrappers[d[0].Key] {
+		return d, true, nil
+	}
+	invalid := func() (any, bool, error) {
+		return nil, true, fmt.Errorf("%w: %s %v", ErrInvalidExtJSON, d[0].Key, d[0].Value)
+	}
+	s, isString := d[0].Value.(string)
+
+	switch d[0].Key {
+	case "$oid":
+		id, err := ObjectIDFromHex(s)
+		if err != nil || len(d) != 1 {
+			return invalid()
+		}
+		return id, true, nil
+	case "$numberInt":
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || len(d) != 1 {
+			return invalid()
+		}
+		return int32(n), true, nil
+	case "$numberLong":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || len(d) != 1 {
+			return invalid()
+		}
+		return n, true, nil
+	case "$numberDouble":
+		if !isString || len(d) != 1 {
+			return invalid()
+		}
+		switch s {
+		case "Infinity":
+			return math.Inf(1), true, nil
+		case "-Infinity":
+			return math.Inf(-1), true, nil
+		case "NaN":
+			return math.NaN(), true, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return invalid()
+		}
+		return f, true, nil
+	case "$numberDecimal":
+		dec, err := ParseDecimal128(s)
+		if err != nil || len(d) != 1 {
+			return invalid()
+		}
+		return dec, true, nil
+	case "$date":
+		if len(d) != 1 {
+			return invalid()
+		}
+		return parseExtDate(d[0].Value, canonical)
+	case "$uuid":
+		u, err := ParseUUID(s)
+		if err != nil || len(d) != 1 {
+			return invalid()
+		}
+		return u, true, nil
+	case "$binary":
+		inner, ok := d[0].Value.(D)
+		if !ok || len(d) != 1 {
+			return invalid()
+		}
+		b, err := parseBinaryValue(toMap(D{{Key: "$binary", Value: inner}}))
+		if err != nil {
+			return invalid()
+		}
+		switch b.Subtype {
+		case BinaryGeneric:
+			return b.Data, true, nil
+		case BinaryUUID:
+			if u, err := b.UUID(); err == nil {
+				return u, true, nil
+			}
+		}
+		return b, true, nil
+	case "$timestamp":
+		if len(d) != 1 {
+			return invalid()
+		}
+		ts, err := parseTimestampValue(toMap(d))
+		if err != nil {
+			return invalid()
+		}
+		return ts, true, nil
+	case "$regularExpression":
+		if len(d) != 1 {
+			return invalid()
+		}
+		re, err := parseRegexValue(toMap(d))
+		if err != nil {
+			return invalid()
+		}
+		return re, true, nil
+	}
+	return nil, false, nil
+}
+
+// parseExtDate converts the value of a $date wrapper: a $numberLong of
+// milliseconds, an ISO-8601 string or, in relaxed mode, a number.
+func parseExtDate(v any, canonical bool) (any, bool, error) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, true, fmt.Errorf("%w: $date %q", ErrInvalidExtJSON, v)
+		}
+		return t.UTC(), true, nil
+	case json.Number:
+		if ms, err := v.Int64(); err == nil && !canonical {
+			return time.UnixMilli(ms).UTC(), true, nil
+		}
+	case D:
+		if len(v) == 1 && v[0].Key == "$numberLong" {
+			s, _ := v[0].Value.(string)
+			if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return time.UnixMilli(ms).UTC(), true, nil
+			}
+		}
+	}
+	return nil, true, fmt.Errorf("%w: $date %v", ErrInvalidExtJSON, v)
+}
+
+// extJSONWriter writes values as Extended JSON.
+type extJSONWriter struct {
+	buf        bytes.Buffer
+	canonical  bool
+	escapeHTML bool
+}
+
+// write writes v.
+func (w *extJSONWriter) write(v reflect.Value) error {
+	if !v.IsValid() {
+		w.buf.WriteString("null")
+		return nil
+	}
+
+	switch v.Type() {
+	case objectIDType:
+		w.buf.WriteString(`{"$oid":"` + v.Interface().(ObjectID).Hex() + `"}`)
+		return nil
+	case decimalType:
+		w.buf.WriteString(`{"$numberDecimal":"` + v.Interface().(Decimal128).String() + `"}`)
+		return nil
+	case timeType:
+		w.writeDate(v.Interface().(time.Time))
+		return nil
+	case dateTimeType:
+		w.writeDate(v.Interface().(DateTime).Time())
+		return nil
+	case binaryType:
+		w.writeBinary(v.Interface().(Binary))
+		return nil
+	case uuidType:
+		w.writeBinary(v.Interface().(UUID).Binary())
+		return nil
+	case dType:
+		return w.writeD(v.Interface().(D))
+	case jsonNumberType:
+		n, err := parseRelaxedNumber(v.Interface().(json.Number))
+		if err != nil {
+			return fmt.Errorf("%w: number %s", ErrInvalidExtJSON, v)
+		}
+		return w.write(reflect.ValueOf(n))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			w.buf.WriteString("null")
+			return nil
+		}
+		return w.write(v.Elem())
+	}
+
+	if v.Type().Implements(marshalerType) {
+		return w.writeMarshaler(v.Interface().(json.Marshaler))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		w.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.String:
+		return w.writeString(v.String())
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		w.writeInt32(v.Int())
+	case reflect.Int:
+		if n := v.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+			w.writeInt32(n)
+		} else {
+			w.writeInt64(n)
+		}
+	case reflect.Int64:
+		w.writeInt64(v.Int())
+	case reflect.Uint8, reflect.Uint16:
+		w.writeInt32(int64(v.Uint()))
+	case reflect.Uint, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return fmt.Errorf("%w: %d overflows int64", ErrInvalidExtJSON, v.Uint())
+		}
+		w.writeInt64(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		w.writeDouble(v.Float())
+	case reflect.Slice:
+		if v.IsNil() {
+			w.buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.writeBinary(Binary{Data: v.Bytes()})
+			return nil
+		}
+		return w.writeArray(v)
+	case reflect.Array:
+		return w.writeArray(v)
+	case reflect.Map:
+		return w.writeMap(v)
+	case reflect.Struct:
+		return w.writeStruct(v)
+	default:
+		return fmt.Errorf("%w: unsupported type %s", ErrInvalidExtJSON, v.Type())
+	}
+	return nil
+}
+
+// writeString writes s as a JSON string.
+func (w *extJSONWriter) writeString(s string) error {
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(w.escapeHTML)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	w.buf.Write(bytes.TrimRight(out.Bytes(), "\n"))
+	return nil
+}
+
+// writeInt32 writes n as a 32-bit integer.
+func (w *extJSONWriter) writeInt32(n int64) {
+	if w.canonical {
+		w.buf.WriteString(`{"$numberInt":"` + strconv.FormatInt(n, 10) + `"}`)
+		return
+	}
+	w.buf.WriteString(strconv.FormatInt(n, 10))
+}
+
+// writeInt64 writes n as a 64-bit integer.
+func (w *extJSONWriter) writeInt64(n int64) {
+	if w.canonical {
+		w.buf.WriteString(`{"$numberLong":"` + strconv.FormatInt(n, 10) + `"}`)
+		return
+	}
+	w.buf.WriteString(strconv.FormatInt(n, 10))
+}
+
+// writeDouble writes f. Non-finite values are wrapped in both modes, as
+// JSON has no literal for them.
+func (w *extJSONWriter) writeDouble(f float64) {
+	var s string
+	switch {
+	case math.IsNaN(f):
+		s = "NaN"
+	case math.IsInf(f, 1):
+		s = "Infinity"
+	case math.IsInf(f, -1):
+		s = "-Infinity"
+	default:
+		s = strconv.FormatFloat(f, 'G', -1, 64)
+		if !strings.ContainsAny(s, ".E") {
+			s += ".0"
+		}
+		if !w.canonical {
+			w.buf.WriteString(s)
+			return
+		}
+	}
+	w.buf.WriteString(`{"$numberDouble":"` + s + `"}`)
+}
+
+// writeDate writes t as a $date, relaxed as an ISO-8601 string when its
+// year is between 1970 and 9999.
+func (w *extJSONWriter) writeDate(t time.Time) {
+	if !w.canonical && t.Year() >= 1970 && t.Year() <= 9999 {
+		w.buf.WriteString(`{"$date":"` + t.UTC().Format("2006-01-02T15:04:05.000Z07:00") + `"}`)
+		return
+	}
+	w.buf.WriteString(`{"$date":{"$numberLong":"` + strconv.FormatInt(t.UnixMilli(), 10) + `"}}`)
+}
+
+// writeBinary writes b as a $binary document, the form both modes use.
+func (w *extJSONWriter) writeBinary(b Binary) {
+	data, _ := b.MarshalJSON()
+	w.buf.Write(data)
+}
+
+// writeKey writes key and the colon that follows it.
+func (w *extJSONWriter) writeKey(i int, key string) error {
+	if i > 0 {
+		w.buf.WriteByte(',')
+	}
+	if err := w.writeString(key); err != nil {
+		return err
+	}
+	w.buf.WriteByte(':')
+	return nil
+}
+
+// writeD writes an ordered document.
+func (w *extJSONWriter) writeD(d D) error {
+	if d == nil {
+		w.buf.WriteString("null")
+		return nil
+	}
+	if len(d) == 1 && opaqueWrappers[d[0].Key] {
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		w.buf.Write(data)
+		return nil
+	}
+	w.buf.WriteByte('{')
+	for i, e := range d {
+		if err := w.writeKey(i, e.Key); err != nil {
+			return err
+		}
+		if err := w.write(reflect.ValueOf(e.Value)); err != nil {
+			return fmt.Errorf("bson: key %q: %w", e.Key, err)
+		}
+	}
+	w.buf.WriteByte('}')
+	return nil
+}
+
+// writeArray writes a slice or array.
+func (w *extJSONWriter) writeArray(v reflect.Value) error {
+	w.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			w.buf.WriteByte(',')
+		}
+		if err := w.write(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte(']')
+	return nil
+}
+
+// writeMap writes a string-keyed map with its keys sorted.
+func (w *extJSONWriter) writeMap(v reflect.Value) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: unsupported map key type %s", ErrInvalidExtJSON, v.Type().Key())
+	}
+	if v.IsNil() {
+		w.buf.WriteString("null")
+		return nil
+	}
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	d := make(D, len(keys))
+	for i, key := range keys {
+		d[i] = E{Key: key.String(), Value: v.MapIndex(key).Interface()}
+	}
+	return w.writeD(d)
+}
+
+// writeStruct writes the fields of a struct in declaration order.
+func (w *extJSONWriter) writeStruct(v reflect.Value) error {
+	d := D{}
+	appendStructElements(&d, v)
+	return w.writeD(d)
+}
+
+// appendStructElements appends the document fields of struct v to d. Tags
+// follow the rules of the SDK: `bson` before `json`, "-" skips a field,
+// omitempty skips zero values and inline or untagged embedded structs are
+// flattened. An inline map contributes its entries.
+func appendStructElements(d *D, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		tag, hasTag := sf.Tag.Lookup("bson")
+		if !hasTag {
+			tag, hasTag = sf.Tag.Lookup("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		inline := strings.Contains(","+opts+",", ",inline,")
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+
+		if inline || (sf.Anonymous && name == "") {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			switch {
+			case fv.Kind() == reflect.Struct && !fv.Type().Implements(marshalerType):
+				appendStructElements(d, fv)
+				continue
+			case inline && fv.Kind() == reflect.Map && fv.Type().Key().Kind() == reflect.String:
+				keys := fv.MapKeys()
+				sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+				for _, key := range keys {
+					*d = append(*d, E{Key: key.String(), Value: fv.MapIndex(key).Interface()})
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		*d = append(*d, E{Key: name, Value: fv.Interface()})
+	}
+}
+
+// writeMarshaler writes a value that encodes itself as JSON, converting the
+// numbers in its output. Extended JSON wrappers it produces, such as
+// {"$timestamp": ...}, are written as they are.
+func (w *extJSONWriter) writeMarshaler(m json.Marshaler) error {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeValue(dec, true)
+	if err != nil {
+		return err
+	}
+	if d, ok := value.(D); ok && len(d) == 1 && strings.HasPrefix(d[0].Key, "$") {
+		w.buf.Write(compactJSON(data))
+		return nil
+	}
+	return w.write(reflect.ValueOf(value))
+}
+
+// compactJSON returns data without insignificant whitespace.
+func compactJSON(data []byte) []byte {
+	var out bytes.Buffer
+	if err := json.Compact(&out, data); err != nil {
+		return data
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,252 @@
+package bson
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type extJSONUser struct {
+	ID      ObjectID   `bson:"_id"`
+	Name    string     `json:"name"`
+	Age     int        `bson:"age"`
+	Visits  int64      `bson:"visits"`
+	Score   float64    `bson:"score"`
+	Balance Decimal128 `bson:"balance"`
+	Joined  time.Time  `bson:"joined"`
+	Avatar  []byte     `bson:"avatar,omitempty"`
+	Note    string     `bson:"note,omitempty"`
+	Secret  string     `bson:"-"`
+}
+
+func testExtJSONUser(t *testing.T) extJSONUser {
+	t.Helper()
+	id, err := ObjectIDFromHex("507f1f77bcf86cd799439011")
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ParseDecimal128("10.50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extJSONUser{
+		ID:      id,
+		Name:    "Ada <admin>",
+		Age:     36,
+		Visits:  1 << 40,
+		Score:   2,
+		Balance: balance,
+		Joined:  time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		Avatar:  []byte{1, 2},
+		Secret:  "x",
+	}
+}
+
+// TestMarshalExtJSON tests both output modes.
+func TestMarshalExtJSON(t *testing.T) {
+	user := testExtJSONUser(t)
+
+	canonical, err := MarshalExtJSON(user, true, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"name":"Ada <admin>","age":{"$numberInt":"36"},` +
+		`"visits":{"$numberLong":"1099511627776"},"score":{"$numberDouble":"2.0"},"balance":{"$numberDecimal":"10.50"},` +
+		`"joined":{"$date":{"$numberLong":"1704164645006"}},"avatar":{"$binary":{"base64":"AQI=","subType":"00"}}}`
+	if string(canonical) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, canonical)
+	}
+
+	relaxed, err := MarshalExtJSON(user, false, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `{"_id":{"$oid":"507f1f77bcf86cd799439011"},"name":"Ada \u003cadmin\u003e","age":36,` +
+		`"visits":1099511627776,"score":2.0,"balance":{"$numberDecimal":"10.50"},` +
+		`"joined":{"$date":"2024-01-02T03:04:05.006Z"},"avatar":{"$binary":{"base64":"AQI=","subType":"00"}}}`
+	if string(relaxed) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, relaxed)
+	}
+}
+
+// TestMarshalExtJSONSpecialValues tests values JSON has no literal for and
+// values passed through unchanged.
+func TestMarshalExtJSONSpecialValues(t *testing.T) {
+	doc := D{
+		{Key: "nan", Value: math.NaN()},
+		{Key: "inf", Value: math.Inf(-1)},
+		{Key: "old", Value: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Key: "ts", Value: M{"$timestamp": M{"t": 1, "i": 2}}},
+	}
+	got, err := MarshalExtJSON(doc, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"nan":{"$numberDouble":"NaN"},"inf":{"$numberDouble":"-Infinity"},` +
+		`"old":{"$date":{"$numberLong":"-315619200000"}},"ts":{"$timestamp":{"i":2,"t":1}}}`
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+// TestUnmarshalExtJSON tests decoding into generic documents and structs in
+// both modes.
+func TestUnmarshalExtJSON(t *testing.T) {
+	user := testExtJSONUser(t)
+	for _, canonical := range []bool{true, false} {
+		data, err := MarshalExtJSON(user, canonical, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var d D
+		if err := UnmarshalExtJSON(data, canonical, &d); err != nil {
+			t.Fatalf("canonical %v: unexpected error: %v", canonical, err)
+		}
+		m := d.Map()
+		if m["_id"] != user.ID || m["age"] != int32(36) || m["visits"] != int64(1<<40) || m["score"] != 2.0 {
+			t.Errorf("canonical %v: unexpected document %v", canonical, d)
+		}
+		if m["balance"] != user.Balance || !m["joined"].(time.Time).Equal(user.Joined) {
+			t.Errorf("canonical %v: unexpected document %v", canonical, d)
+		}
+		if !reflect.DeepEqual(m["avatar"], []byte{1, 2}) || d[0].Key != "_id" {
+			t.Errorf("canonical %v: unexpected document %v", canonical, d)
+		}
+
+		var got struct {
+			ID      ObjectID   `json:"_id"`
+			Name    string     `json:"name"`
+			Visits  int64      `json:"visits"`
+			Balance Decimal128 `json:"balance"`
+			Joined  time.Time  `json:"joined"`
+		}
+		if err := UnmarshalExtJSON(data, canonical, &got); err != nil {
+			t.Fatalf("canonical %v: unexpected error: %v", canonical, err)
+		}
+		if got.ID != user.ID || got.Name != user.Name || got.Visits != user.Visits || !got.Joined.Equal(user.Joined) {
+			t.Errorf("canonical %v: unexpected struct %+v", canonical, got)
+		}
+	}
+
+	var m M
+	if err := UnmarshalExtJSON([]byte(`{"a":{"b":[1,2.5]}}`), false, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(m, M{"a": map[string]any{"b": A{int32(1), 2.5}}}) {
+		t.Errorf("unexpected document %#v", m)
+	}
+}
+
+// TestUnmarshalExtJSONErrors tests rejecting malformed input.
+func TestUnmarshalExtJSONErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"n":1}`,
+		`{"id":{"$oid":"xyz"}}`,
+		`{"n":{"$numberInt":"3000000000"}}`,
+		`{"d":{"$date":"yesterday"}}`,
+		`{"b":{"$binary":{"base64":"!!"}}}`,
+	} {
+		var d D
+		if err := UnmarshalExtJSON([]byte(input), true, &d); !errors.Is(err, ErrInvalidExtJSON) {
+			t.Errorf("%s: expected ErrInvalidExtJSON, got %v", input, err)
+		}
+	}
+	var d D
+	if err := UnmarshalExtJSON([]byte(`{}`), false, d); !errors.Is(err, ErrInvalidExtJSON) {
+		t.Errorf("expected ErrInvalidExtJSON for a non-pointer, got %v", err)
+	}
+}
+
+// TestExtJSONBinary tests that UUIDs and binary subtypes survive a round
+// trip.
+func TestExtJSONBinary(t *testing.T) {
+	u, err := ParseUUID("01234567-89ab-cdef-0123-456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := D{
+		{Key: "uuid", Value: u},
+		{Key: "md5", Value: Binary{Subtype: BinaryMD5, Data: []byte{1}}},
+		{Key: "raw", Value: []byte{2}},
+	}
+	data, err := MarshalExtJSON(doc, true, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"uuid":{"$binary":{"base64":"ASNFZ4mrze8BI0VniavN7w==","subType":"04"}},` +
+		`"md5":{"$binary":{"base64":"AQ==","subType":"05"}},"raw":{"$binary":{"base64":"Ag==","subType":"00"}}}`
+	if string(data) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, data)
+	}
+
+	var got D
+	if err := UnmarshalExtJSON(data, true, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, doc) {
+		t.Errorf("expected %v, got %v", doc, got)
+	}
+	var m M
+	if err := UnmarshalExtJSON([]byte(`{"id":{"$uuid":"01234567-89ab-cdef-0123-456789abcdef"}}`), false, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m["id"] != u {
+		t.Errorf("expected %v, got %v", u, m["id"])
+	}
+}
+
+// TestExtJSONTimestamp tests that timestamps survive a round trip in both
+// modes.
+func TestExtJSONTimestamp(t *testing.T) {
+	doc := D{{Key: "ts", Value: Timestamp{T: 1700000000, I: 3}}}
+	for _, canonical := range []bool{true, false} {
+		data, err := MarshalExtJSON(doc, canonical, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != `{"ts":{"$timestamp":{"t":1700000000,"i":3}}}` {
+			t.Errorf("canonical %v: unexpected encoding %s", canonical, data)
+		}
+		var got D
+		if err := UnmarshalExtJSON(data, canonical, &got); err != nil {
+			t.Fatalf("canonical %v: unexpected error: %v", canonical, err)
+		}
+		if !reflect.DeepEqual(got, doc) {
+			t.Errorf("canonical %v: expected %v, got %v", canonical, doc, got)
+		}
+	}
+
+	var d D
+	if err := UnmarshalExtJSON([]byte(`{"ts":{"$timestamp":{"t":1}}}`), false, &d); !errors.Is(err, ErrInvalidExtJSON) {
+		t.Errorf("expected ErrInvalidExtJSON, got %v", err)
+	}
+}
+
+// TestExtJSONRegex tests regular expressions in both modes.
+func TestExtJSONRegex(t *testing.T) {
+	doc := D{{Key: "re", Value: Regex{Pattern: "^ada", Options: "i"}}}
+	for _, canonical := range []bool{true, false} {
+		data, err := MarshalExtJSON(doc, canonical, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(data) != `{"re":{"$regularExpression":{"pattern":"^ada","options":"i"}}}` {
+			t.Errorf("canonical %v: unexpected encoding %s", canonical, data)
+		}
+		var got D
+		if err := UnmarshalExtJSON(data, canonical, &got); err != nil {
+			t.Fatalf("canonical %v: unexpected error: %v", canonical, err)
+		}
+		if !reflect.DeepEqual(got, doc) {
+			t.Errorf("canonical %v: expected %v, got %v", canonical, doc, got)
+		}
+	}
+
+	var d D
+	if err := UnmarshalExtJSON([]byte(`{"re":{"$regularExpression":{"pattern":1,"options":""}}}`), false, &d); !errors.Is(err, ErrInvalidExtJSON) {
+		t.Errorf("expected ErrInvalidExtJSON, got %v", err)
+	}
+}
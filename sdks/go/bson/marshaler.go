@@ -0,0 +1,18 @@
+package bson
+
+// Marshaler is implemented by types that build their own document, rather
+// than being encoded field by field. MarshalBSON returns the document as
+// Extended JSON, such as MarshalExtJSON produces, or as binary BSON, so
+// types written for the official driver keep working. Documents being
+// inserted, replaced or used as filters and updates honor it at any depth.
+type Marshaler interface {
+	MarshalBSON() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from a
+// document. UnmarshalBSON receives the document as relaxed Extended JSON,
+// as Raw holds it, so it can read fields with Raw.Lookup or decode them
+// with UnmarshalExtJSON. Result documents honor it at any depth.
+type Unmarshaler interface {
+	UnmarshalBSON([]byte) error
+}
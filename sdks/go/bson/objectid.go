@@ -0,0 +1,127 @@
+package bson
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInvalidHex is returned when a string is not a valid ObjectID.
+var ErrInvalidHex = errors.New("bson: invalid ObjectID hex")
+
+// ObjectID is a 12-byte MongoDB ObjectID: a 4-byte creation time in
+// seconds, 5 bytes unique to the process and a 3-byte counter. It encodes
+// to JSON in extended JSON form, {"$oid": "<hex>"}.
+type ObjectID [12]byte
+
+// NilObjectID is the zero ObjectID.
+var NilObjectID ObjectID
+
+// objectIDCounter and objectIDProcess make generated ObjectIDs unique.
+var (
+	objectIDCounter = func() *atomic.Uint32 {
+		var c atomic.Uint32
+		var b [4]byte
+		rand.Read(b[:])
+		c.Store(binary.BigEndian.Uint32(b[:]))
+		return &c
+	}()
+	objectIDProcess = func() [5]byte {
+		var b [5]byte
+		rand.Read(b[:])
+		return b
+	}()
+)
+
+// NewObjectID returns a new ObjectID for the current time.
+func NewObjectID() ObjectID {
+	return NewObjectIDFromTimestamp(time.Now())
+}
+
+// NewObjectIDFromTimestamp returns a new ObjectID for t.
+func NewObjectIDFromTimestamp(t time.Time) ObjectID {
+	var id ObjectID
+	binary.BigEndian.PutUint32(id[0:], uint32(t.Unix()))
+	copy(id[4:9], objectIDProcess[:])
+	n := objectIDCounter.Add(1)
+	id[9], id[10], id[11] = byte(n>>16), byte(n>>8), byte(n)
+	return id
+}
+
+// ObjectIDFromHex parses a 24-character hex string.
+func ObjectIDFromHex(s string) (ObjectID, error) {
+	var id ObjectID
+	if len(s) != 2*len(id) {
+		return NilObjectID, fmt.Errorf("%w: %q", ErrInvalidHex, s)
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return NilObjectID, fmt.Errorf("%w: %q", ErrInvalidHex, s)
+	}
+	return id, nil
+}
+
+// IsValidObjectID reports whether s is a valid ObjectID hex string.
+func IsValidObjectID(s string) bool {
+	_, err := ObjectIDFromHex(s)
+	return err == nil
+}
+
+// Hex returns the ObjectID as a 24-character hex string.
+func (id ObjectID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns the ObjectID in the form ObjectID("<hex>").
+func (id ObjectID) String() string {
+	return fmt.Sprintf("ObjectID(%q)", id.Hex())
+}
+
+// IsZero reports whether id is NilObjectID.
+func (id ObjectID) IsZero() bool {
+	return id == NilObjectID
+}
+
+// Timestamp returns the creation time encoded in id.
+func (id ObjectID) Timestamp() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(id[0:4])), 0)
+}
+
+// MarshalJSON encodes id as {"$oid": "<hex>"}.
+func (id ObjectID) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$oid":"` + id.Hex() + `"}`), nil
+}
+
+// UnmarshalJSON decodes id from {"$oid": "<hex>"} or a plain hex string.
+// JSON null leaves id unchanged.
+func (id *ObjectID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var s string
+	if len(data) > 0 && data[0] == '{' {
+		var wrapper struct {
+			OID *string `json:"$oid"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return err
+		}
+		if wrapper.OID == nil {
+			return fmt.Errorf("%w: %s", ErrInvalidHex, data)
+		}
+		s = *wrapper.OID
+	} else if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ObjectIDFromHex(s)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
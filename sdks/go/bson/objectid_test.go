@@ -0,0 +1,74 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestNewObjectID tests that generated IDs are unique and carry their time.
+func TestNewObjectID(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := NewObjectIDFromTimestamp(created)
+	b := NewObjectIDFromTimestamp(created)
+	if a == b {
+		t.Error("expected distinct IDs")
+	}
+	if !a.Timestamp().Equal(created) {
+		t.Errorf("expected timestamp %v, got %v", created, a.Timestamp())
+	}
+	if NewObjectID().IsZero() || !NilObjectID.IsZero() {
+		t.Error("unexpected IsZero result")
+	}
+}
+
+// TestObjectIDFromHex tests parsing hex strings.
+func TestObjectIDFromHex(t *testing.T) {
+	id, err := ObjectIDFromHex("507f1f77bcf86cd799439011")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Hex() != "507f1f77bcf86cd799439011" {
+		t.Errorf("unexpected hex %s", id.Hex())
+	}
+	if id.String() != `ObjectID("507f1f77bcf86cd799439011")` {
+		t.Errorf("unexpected string %s", id)
+	}
+
+	for _, s := range []string{"", "507f1f77bcf86cd79943901", "507f1f77bcf86cd79943901z"} {
+		if _, err := ObjectIDFromHex(s); !errors.Is(err, ErrInvalidHex) {
+			t.Errorf("%q: expected ErrInvalidHex, got %v", s, err)
+		}
+		if IsValidObjectID(s) {
+			t.Errorf("%q: expected invalid", s)
+		}
+	}
+}
+
+// TestObjectIDJSON tests the extended JSON encoding.
+func TestObjectIDJSON(t *testing.T) {
+	id, _ := ObjectIDFromHex("507f1f77bcf86cd799439011")
+	data, err := json.Marshal(M{"_id": id})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"_id":{"$oid":"507f1f77bcf86cd799439011"}}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	for _, input := range []string{`{"$oid":"507f1f77bcf86cd799439011"}`, `"507f1f77bcf86cd799439011"`} {
+		var got ObjectID
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Fatalf("%s: unexpected error: %v", input, err)
+		}
+		if got != id {
+			t.Errorf("%s: expected %v, got %v", input, id, got)
+		}
+	}
+
+	var got ObjectID
+	if err := json.Unmarshal([]byte(`{"id":"x"}`), &got); !errors.Is(err, ErrInvalidHex) {
+		t.Errorf("expected ErrInvalidHex, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a struct field that may be absent from a document, telling a
+// missing field apart from one holding the zero value. The SDK leaves
+// absent Optional fields out of the documents it encodes, and sets Present
+// when decoding a document that has the field, even when it is null.
+// Combined with Null, or with a pointer, a field has three states:
+//
+//	type Patch struct {
+//		Nickname bson.Optional[bson.Null[string]] `json:"nickname"`
+//	}
+//
+// Absent, null and set are told apart both when decoding and in updates
+// built with mongo.Patch, which $unset fields that are present but null.
+type Optional[T any] struct {
+	Value   T
+	Present bool
+}
+
+// NewOptional returns a present Optional holding v.
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Get returns the value and whether it is present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present
+}
+
+// MarshalJSON encodes the value, or null when it is absent. Only the SDK's
+// encoder can leave an absent field out altogether.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Present {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON marks o present and decodes the value.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Present = true
+	return nil
+}
+
+// Null is a value that may be null, like sql.Null: Valid is false for
+// null. Unlike a pointer, it keeps non-null values inline.
+type Null[T any] struct {
+	Value T
+	Valid bool
+}
+
+// NewNull returns a valid Null holding v.
+func NewNull[T any](v T) Null[T] {
+	return Null[T]{Value: v, Valid: true}
+}
+
+// Get returns the value and whether it is not null.
+func (n Null[T]) Get() (T, bool) {
+	return n.Value, n.Valid
+}
+
+// MarshalJSON encodes the value, or null when it is not valid.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON decodes the value, or makes n null for JSON null.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Value); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -0,0 +1,50 @@
+package bson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type profile struct {
+	Nickname Optional[Null[string]] `json:"nickname"`
+	Age      Optional[int]          `json:"age"`
+}
+
+// TestOptionalJSON tests encoding and decoding Optional and Null values.
+func TestOptionalJSON(t *testing.T) {
+	data, err := json.Marshal(profile{Nickname: NewOptional(NewNull("ada")), Age: NewOptional(36)})
+	if err != nil || string(data) != `{"nickname":"ada","age":36}` {
+		t.Errorf("unexpected encoding %s (%v)", data, err)
+	}
+	data, err = json.Marshal(profile{Nickname: NewOptional(Null[string]{})})
+	if err != nil || string(data) != `{"nickname":null,"age":null}` {
+		t.Errorf("unexpected encoding %s (%v)", data, err)
+	}
+
+	var p profile
+	if err := json.Unmarshal([]byte(`{"nickname":null}`), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nick, ok := p.Nickname.Get(); !ok || nick.Valid {
+		t.Errorf("expected a present null nickname, got %+v", p.Nickname)
+	}
+	if _, ok := p.Age.Get(); ok {
+		t.Errorf("expected an absent age, got %+v", p.Age)
+	}
+
+	p = profile{}
+	if err := json.Unmarshal([]byte(`{"nickname":"ada","age":36}`), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nick, _ := p.Nickname.Get(); nick != NewNull("ada") {
+		t.Errorf("unexpected nickname %+v", p.Nickname)
+	}
+	if age, ok := p.Age.Get(); !ok || age != 36 {
+		t.Errorf("unexpected age %+v", p.Age)
+	}
+
+	var n Null[int]
+	if err := json.Unmarshal([]byte(`"x"`), &n); err == nil {
+		t.Error("expected an error decoding a string into Null[int]")
+	}
+}
@@ -0,0 +1,424 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrElementNotFound is returned when a path does not name an element of a
+// document.
+var ErrElementNotFound = errors.New("bson: element not found")
+
+// Raw is a document in its JSON encoding, as results are received. It can
+// be inspected without decoding it into a struct:
+//
+//	city, ok := raw.Lookup("address.city").StringValueOK()
+//
+// Special values keep their extended JSON form, such as {"$oid": ...}, and
+// the typed accessors of RawValue read them.
+type Raw []byte
+
+// Lookup returns the value at path, a dotted path of keys and, within
+// arrays, zero-based indexes, such as "items.0.sku". It returns a zero
+// RawValue if the path does not exist; use LookupErr to tell why.
+func (r Raw) Lookup(path string) RawValue {
+	v, _ := r.LookupErr(path)
+	return v
+}
+
+// LookupErr returns the value at path, or an error wrapping
+// ErrElementNotFound if the path does not exist, or ErrInvalidDocument if
+// r is not a document.
+func (r Raw) LookupErr(path string) (RawValue, error) {
+	if path == "" {
+		return RawValue{}, fmt.Errorf("%w: empty path", ErrElementNotFound)
+	}
+	current := RawValue{data: r}
+	if current.Type() != "object" {
+		return RawValue{}, fmt.Errorf("%w: not a JSON object", ErrInvalidDocument)
+	}
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		next, ok, err := current.child(part)
+		if err != nil {
+			return RawValue{}, err
+		}
+		if !ok {
+			return RawValue{}, fmt.Errorf("%w: %q", ErrElementNotFound, strings.Join(parts[:i+1], "."))
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// Elements returns the elements of r in document order.
+func (r Raw) Elements() ([]RawElement, error) {
+	var elements []RawElement
+	err := scanRaw(r, '{', func(key string, value json.RawMessage) bool {
+		elements = append(elements, RawElement{Key: key, Value: RawValue{data: value}})
+		return true
+	})
+	return elements, err
+}
+
+// Validate reports whether r is a well-formed document.
+func (r Raw) Validate() error {
+	if !json.Valid(r) || (RawValue{data: r}).Type() != "object" {
+		return fmt.Errorf("%w: not a JSON object", ErrInvalidDocument)
+	}
+	return nil
+}
+
+// String returns r as text.
+func (r Raw) String() string {
+	return string(r)
+}
+
+// MarshalJSON returns r, so a Raw document can be written as it is.
+func (r Raw) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+	return r, nil
+}
+
+// UnmarshalJSON sets r to a copy of data.
+func (r *Raw) UnmarshalJSON(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// RawElement is one element of a Raw document.
+type RawElement struct {
+	Key   string
+	Value RawValue
+}
+
+// RawValue is one value of a Raw document, in its JSON encoding. The zero
+// RawValue stands for a missing value.
+type RawValue struct {
+	data []byte
+}
+
+// IsZero reports whether v is missing.
+func (v RawValue) IsZero() bool {
+	return len(v.data) == 0
+}
+
+// Bytes returns the JSON encoding of v.
+func (v RawValue) Bytes() []byte {
+	return v.data
+}
+
+// String returns the JSON encoding of v as text.
+func (v RawValue) String() string {
+	return string(v.data)
+}
+
+// Type returns the BSON type name of v, as used by $type and bsonType:
+// "double", "string", "object", "array", "binData", "objectId", "bool",
+// "date", "null", "regex", "int", "timestamp", "long", "decimal",
+// "minKey" or "maxKey". Whole JSON numbers are "int" or "long" by size. It
+// returns "" for a zero or malformed value.
+func (v RawValue) Type() string {
+	data := bytes.TrimSpace(v.data)
+	if len(data) == 0 {
+		return ""
+	}
+	switch data[0] {
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	case 'n':
+		return "null"
+	case '[':
+		return "array"
+	case '{':
+		if key, _, ok := v.wrapper(); ok {
+			if t, known := wrapperTypes[key]; known {
+				return t
+			}
+		}
+		return "object"
+	}
+	if n, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return "int"
+		}
+		return "long"
+	}
+	if _, err := strconv.ParseFloat(string(data), 64); err == nil {
+		return "double"
+	}
+	return ""
+}
+
+// wrapperTypes maps extended JSON wrapper keys to BSON type names.
+var wrapperTypes = map[string]string{
+	"$oid": "objectId", "$date": "date", "$numberDecimal": "decimal",
+	"$numberLong": "long", "$numberInt": "int", "$numberDouble": "double",
+	"$binary": "binData", "$uuid": "binData", "$timestamp": "timestamp",
+	"$regularExpression": "regex", "$minKey": "minKey", "$maxKey": "maxKey",
+}
+
+// StringValueOK returns v as a string, reporting false if it is not one.
+func (v RawValue) StringValueOK() (string, bool) {
+	var s string
+	if v.Type() != "string" || json.Unmarshal(v.data, &s) != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// BooleanOK returns v as a bool, reporting false if it is not one.
+func (v RawValue) BooleanOK() (bool, bool) {
+	var b bool
+	if v.Type() != "bool" || json.Unmarshal(v.data, &b) != nil {
+		return false, false
+	}
+	return b, true
+}
+
+// Int64OK returns v as an int64, reporting false unless it is an integer,
+// plain or as $numberInt or $numberLong.
+func (v RawValue) Int64OK() (int64, bool) {
+	switch v.Type() {
+	case "int", "long":
+		text := string(bytes.TrimSpace(v.data))
+		if _, inner, ok := v.wrapper(); ok {
+			if err := json.Unmarshal(inner, &text); err != nil {
+				return 0, false
+			}
+		}
+		n, err := strconv.ParseInt(text, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Int32OK returns v as an int32, reporting false unless it is an integer
+// that fits.
+func (v RawValue) Int32OK() (int32, bool) {
+	n, ok := v.Int64OK()
+	if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// DoubleOK returns v as a float64, reporting false unless it is a number,
+// plain or as $numberInt, $numberLong or $numberDouble.
+func (v RawValue) DoubleOK() (float64, bool) {
+	switch v.Type() {
+	case "int", "long", "double":
+		text := string(bytes.TrimSpace(v.data))
+		if _, inner, ok := v.wrapper(); ok {
+			if err := json.Unmarshal(inner, &text); err != nil {
+				return 0, false
+			}
+		}
+		switch text {
+		case "Infinity":
+			return math.Inf(1), true
+		case "-Infinity":
+			return math.Inf(-1), true
+		case "NaN":
+			return math.NaN(), true
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// ObjectIDOK returns v as an ObjectID, reporting false if it is not one.
+func (v RawValue) ObjectIDOK() (ObjectID, bool) {
+	var id ObjectID
+	if v.Type() != "objectId" || id.UnmarshalJSON(v.data) != nil {
+		return NilObjectID, false
+	}
+	return id, true
+}
+
+// TimeOK returns v as a time, reporting false if it is not a date.
+func (v RawValue) TimeOK() (time.Time, bool) {
+	var d DateTime
+	if v.Type() != "date" || d.UnmarshalJSON(v.data) != nil {
+		return time.Time{}, false
+	}
+	return d.Time(), true
+}
+
+// TimestampOK returns v as a Timestamp, reporting false if it is not one.
+func (v RawValue) TimestampOK() (Timestamp, bool) {
+	var ts Timestamp
+	if v.Type() != "timestamp" || ts.UnmarshalJSON(v.data) != nil {
+		return Timestamp{}, false
+	}
+	return ts, true
+}
+
+// RegexOK returns v as a Regex, reporting false if it is not a regular
+// expression.
+func (v RawValue) RegexOK() (Regex, bool) {
+	var re Regex
+	if v.Type() != "regex" || re.UnmarshalJSON(v.data) != nil {
+		return Regex{}, false
+	}
+	return re, true
+}
+
+// DBRefOK returns v as a DBRef, reporting false if it is not a document
+// with $ref and $id.
+func (v RawValue) DBRefOK() (DBRef, bool) {
+	var ref DBRef
+	if v.Type() != "object" || ref.UnmarshalJSON(v.data) != nil {
+		return DBRef{}, false
+	}
+	return ref, true
+}
+
+// Decimal128OK returns v as a Decimal128, reporting false if it is not a
+// decimal.
+func (v RawValue) Decimal128OK() (Decimal128, bool) {
+	var d Decimal128
+	if v.Type() != "decimal" || json.Unmarshal(v.data, &d) != nil {
+		return Decimal128{}, false
+	}
+	return d, true
+}
+
+// BinaryOK returns v as binary data, reporting false if it is not binary.
+func (v RawValue) BinaryOK() (Binary, bool) {
+	var b Binary
+	if v.Type() != "binData" || b.UnmarshalJSON(v.data) != nil {
+		return Binary{}, false
+	}
+	return b, true
+}
+
+// UUIDOK returns v as a UUID, reporting false unless it is binary data
+// holding a UUID.
+func (v RawValue) UUIDOK() (UUID, bool) {
+	var u UUID
+	if v.Type() != "binData" || u.UnmarshalJSON(v.data) != nil {
+		return NilUUID, false
+	}
+	return u, true
+}
+
+// DocumentOK returns v as a document, reporting false if it is not one.
+func (v RawValue) DocumentOK() (Raw, bool) {
+	if v.Type() != "object" {
+		return nil, false
+	}
+	return Raw(v.data), true
+}
+
+// ArrayOK returns the elements of v, reporting false if it is not an array.
+func (v RawValue) ArrayOK() ([]RawValue, bool) {
+	if v.Type() != "array" {
+		return nil, false
+	}
+	var values []RawValue
+	err := scanRaw(v.data, '[', func(_ string, value json.RawMessage) bool {
+		values = append(values, RawValue{data: value})
+		return true
+	})
+	return values, err == nil
+}
+
+// Unmarshal decodes v into val with encoding/json.
+func (v RawValue) Unmarshal(val any) error {
+	if v.IsZero() {
+		return ErrElementNotFound
+	}
+	return json.Unmarshal(v.data, val)
+}
+
+// child returns the element of a document or array named by part.
+func (v RawValue) child(part string) (RawValue, bool, error) {
+	var found RawValue
+	var ok bool
+	switch v.Type() {
+	case "object":
+		err := scanRaw(v.data, '{', func(key string, value json.RawMessage) bool {
+			if key == part {
+				found, ok = RawValue{data: value}, true
+			}
+			return !ok
+		})
+		return found, ok, err
+	case "array":
+		index, err := strconv.Atoi(part)
+		if err != nil || index < 0 {
+			return RawValue{}, false, nil
+		}
+		i := 0
+		err = scanRaw(v.data, '[', func(_ string, value json.RawMessage) bool {
+			if i == index {
+				found, ok = RawValue{data: value}, true
+			}
+			i++
+			return !ok
+		})
+		return found, ok, err
+	}
+	return RawValue{}, false, nil
+}
+
+// wrapper returns the key and value of v when it is a single-key document
+// whose key starts with "$".
+func (v RawValue) wrapper() (string, json.RawMessage, bool) {
+	var key string
+	var inner json.RawMessage
+	n := 0
+	err := scanRaw(v.data, '{', func(k string, value json.RawMessage) bool {
+		key, inner = k, value
+		n++
+		return n < 2
+	})
+	if err != nil || n != 1 || !strings.HasPrefix(key, "$") {
+		return "", nil, false
+	}
+	return key, inner, true
+}
+
+// scanRaw calls fn for each element of data, a JSON object when open is
+// '{' or an array when it is '[', until fn returns false. Array elements
+// have empty keys.
+func scanRaw(data []byte, open json.Delim, fn func(key string, value json.RawMessage) bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if tok != open {
+		return fmt.Errorf("%w: expected %v, got %v", ErrInvalidDocument, open, tok)
+	}
+	for dec.More() {
+		var key string
+		if open == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+			}
+			key, _ = tok.(string)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+		if !fn(key, value) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package bson
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testRaw = `{
+	"_id": {"$oid": "507f1f77bcf86cd799439011"},
+	"name": "Ada",
+	"age": 36,
+	"visits": {"$numberLong": "1099511627776"},
+	"score": 2.5,
+	"active": true,
+	"joined": {"$date": "2024-01-02T03:04:05.006Z"},
+	"balance": {"$numberDecimal": "10.50"},
+	"session": {"$uuid": "01234567-89ab-cdef-0123-456789abcdef"},
+	"address": {"city": "London", "geo": {"lat": 51.5}},
+	"items": [{"sku": "a1"}, {"sku": "b2", "qty": 3}],
+	"note": null
+}`
+
+// TestRawLookup tests navigating documents and arrays by path.
+func TestRawLookup(t *testing.T) {
+	raw := Raw(testRaw)
+	if err := raw.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if city, ok := raw.Lookup("address.city").StringValueOK(); !ok || city != "London" {
+		t.Errorf("unexpected city %q", city)
+	}
+	if lat, ok := raw.Lookup("address.geo.lat").DoubleOK(); !ok || lat != 51.5 {
+		t.Errorf("unexpected lat %v", lat)
+	}
+	if qty, ok := raw.Lookup("items.1.qty").Int64OK(); !ok || qty != 3 {
+		t.Errorf("unexpected qty %v", qty)
+	}
+	if sku, _ := raw.Lookup("items.0.sku").StringValueOK(); sku != "a1" {
+		t.Errorf("unexpected sku %q", sku)
+	}
+	if v := raw.Lookup("note"); v.IsZero() || v.Type() != "null" {
+		t.Errorf("expected null, got %v", v)
+	}
+
+	for _, path := range []string{"missing", "address.zip", "items.2", "items.x", "name.first", ""} {
+		if v, err := raw.LookupErr(path); !errors.Is(err, ErrElementNotFound) || !v.IsZero() {
+			t.Errorf("%q: expected ErrElementNotFound, got %v %v", path, v, err)
+		}
+	}
+	if _, err := Raw(`[1]`).LookupErr("0"); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("expected ErrInvalidDocument, got %v", err)
+	}
+}
+
+// TestRawValueAccessors tests the typed accessors and BSON type names.
+func TestRawValueAccessors(t *testing.T) {
+	raw := Raw(testRaw)
+
+	types := map[string]string{
+		"_id": "objectId", "name": "string", "age": "int", "visits": "long", "score": "double",
+		"active": "bool", "joined": "date", "balance": "decimal", "session": "binData",
+		"address": "object", "items": "array", "note": "null", "missing": "",
+	}
+	for path, want := range types {
+		if got := raw.Lookup(path).Type(); got != want {
+			t.Errorf("%s: expected type %q, got %q", path, want, got)
+		}
+	}
+
+	if id, ok := raw.Lookup("_id").ObjectIDOK(); !ok || id.Hex() != "507f1f77bcf86cd799439011" {
+		t.Errorf("unexpected _id %v", id)
+	}
+	if n, ok := raw.Lookup("visits").Int64OK(); !ok || n != 1<<40 {
+		t.Errorf("unexpected visits %v", n)
+	}
+	if _, ok := raw.Lookup("visits").Int32OK(); ok {
+		t.Error("expected visits to overflow int32")
+	}
+	if joined, ok := raw.Lookup("joined").TimeOK(); !ok || !joined.Equal(time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)) {
+		t.Errorf("unexpected joined %v", joined)
+	}
+	if d, ok := raw.Lookup("balance").Decimal128OK(); !ok || d.String() != "10.50" {
+		t.Errorf("unexpected balance %v", d)
+	}
+	if u, ok := raw.Lookup("session").UUIDOK(); !ok || u.String() != "01234567-89ab-cdef-0123-456789abcdef" {
+		t.Errorf("unexpected session %v", u)
+	}
+	if b, ok := raw.Lookup("active").BooleanOK(); !ok || !b {
+		t.Error("expected active")
+	}
+	if _, ok := raw.Lookup("name").Int64OK(); ok {
+		t.Error("expected a string not to read as an integer")
+	}
+	if _, ok := raw.Lookup("score").Int64OK(); ok {
+		t.Error("expected a double not to read as an integer")
+	}
+
+	address, ok := raw.Lookup("address").DocumentOK()
+	if !ok || address.Lookup("geo.lat").String() != "51.5" {
+		t.Errorf("unexpected address %s", address)
+	}
+	items, ok := raw.Lookup("items").ArrayOK()
+	if !ok || len(items) != 2 || items[1].Type() != "object" {
+		t.Errorf("unexpected items %v", items)
+	}
+	var item struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+	if err := items[1].Unmarshal(&item); err != nil || item.SKU != "b2" || item.Qty != 3 {
+		t.Errorf("unexpected item %+v (%v)", item, err)
+	}
+}
+
+// TestRawElements tests listing elements in document order.
+func TestRawElements(t *testing.T) {
+	elements, err := Raw(`{"z": 1, "a": {"b": 2}, "m": "x"}`).Elements()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var keys []string
+	for _, e := range elements {
+		keys = append(keys, e.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"z", "a", "m"}) || elements[1].Value.String() != `{"b": 2}` {
+		t.Errorf("unexpected elements %v", elements)
+	}
+
+	if _, err := Raw(`{"a": `).Elements(); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("expected ErrInvalidDocument, got %v", err)
+	}
+	if err := Raw(`"x"`).Validate(); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("expected ErrInvalidDocument, got %v", err)
+	}
+}
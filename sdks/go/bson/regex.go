@@ -0,0 +1,110 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidRegex is returned when JSON input is not a regular expression.
+var ErrInvalidRegex = errors.New("bson: invalid regular expression")
+
+// Regex is a BSON regular expression, matched by the server. Options are
+// the server's flags, such as "i" for case-insensitive, "m" for multiline,
+// "s" to let . match newlines and "x" for extended syntax. A Regex can be
+// used as a filter value, or as the value of $regex or $in:
+//
+//	filter := bson.M{"name": bson.Regex{Pattern: "^ada", Options: "i"}}
+//
+// It encodes to JSON as {"$regularExpression": {"pattern": ...,
+// "options": ...}}, with the options in alphabetical order as the server
+// stores them.
+type Regex struct {
+	Pattern string
+	Options string
+}
+
+// String returns re as "/pattern/options", as the shell prints it.
+func (re Regex) String() string {
+	return "/" + re.Pattern + "/" + sortOptions(re.Options)
+}
+
+// MarshalJSON encodes re as {"$regularExpression": {"pattern": ...,
+// "options": ...}}.
+func (re Regex) MarshalJSON() ([]byte, error) {
+	inner, err := json.Marshal(struct {
+		Pattern string `json:"pattern"`
+		Options string `json:"options"`
+	}{re.Pattern, sortOptions(re.Options)})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(`{"$regularExpression":` + string(inner) + `}`), nil
+}
+
+// UnmarshalJSON decodes re from a $regularExpression document or a legacy
+// {"$regex": ..., "$options": ...} document. JSON null leaves re unchanged.
+func (re *Regex) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := parseRegexValue(v)
+	if err != nil {
+		return err
+	}
+	*re = parsed
+	return nil
+}
+
+// parseRegexValue parses a generically decoded regular expression.
+func parseRegexValue(v any) (Regex, error) {
+	invalid := fmt.Errorf("%w: %v", ErrInvalidRegex, v)
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return Regex{}, invalid
+	}
+	var pattern, options any
+	if inner, ok := doc["$regularExpression"]; ok {
+		fields, ok := inner.(map[string]any)
+		if !ok || len(doc) != 1 || len(fields) != 2 {
+			return Regex{}, invalid
+		}
+		pattern, options = fields["pattern"], fields["options"]
+	} else {
+		pattern, ok = doc["$regex"]
+		if !ok || len(doc) > 2 {
+			return Regex{}, invalid
+		}
+		options, ok = doc["$options"]
+		if !ok {
+			if len(doc) != 1 {
+				return Regex{}, invalid
+			}
+			options = ""
+		}
+	}
+	p, pok := pattern.(string)
+	o, ook := options.(string)
+	if !pok || !ook {
+		return Regex{}, invalid
+	}
+	return Regex{Pattern: p, Options: sortOptions(o)}, nil
+}
+
+// sortOptions returns options in alphabetical order.
+func sortOptions(options string) string {
+	if len(options) < 2 {
+		return options
+	}
+	flags := strings.Split(options, "")
+	sort.Strings(flags)
+	return strings.Join(flags, "")
+}
@@ -0,0 +1,63 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestRegexJSON tests encoding and decoding regular expressions.
+func TestRegexJSON(t *testing.T) {
+	re := Regex{Pattern: `^a.b\d`, Options: "mi"}
+	data, err := json.Marshal(M{"name": re})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"name":{"$regularExpression":{"pattern":"^a.b\\d","options":"im"}}}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	var got struct{ Name Regex }
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != (Regex{Pattern: `^a.b\d`, Options: "im"}) {
+		t.Errorf("unexpected regex %v", got.Name)
+	}
+
+	var legacy Regex
+	if err := json.Unmarshal([]byte(`{"$regex":"^ada","$options":"si"}`), &legacy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if legacy != (Regex{Pattern: "^ada", Options: "is"}) {
+		t.Errorf("unexpected legacy regex %v", legacy)
+	}
+	if err := json.Unmarshal([]byte(`{"$regex":"^ada"}`), &legacy); err != nil || legacy.Options != "" {
+		t.Errorf("expected a regex without options, got %v %v", legacy, err)
+	}
+
+	for _, input := range []string{`"^ada"`, `{"$regularExpression":{"pattern":"a"}}`, `{"$regex":"a","x":1}`, `{"$regex":1}`} {
+		var re Regex
+		if err := json.Unmarshal([]byte(input), &re); !errors.Is(err, ErrInvalidRegex) {
+			t.Errorf("%s: expected ErrInvalidRegex, got %v", input, err)
+		}
+	}
+}
+
+// TestRegexString tests printing a regular expression.
+func TestRegexString(t *testing.T) {
+	if s := (Regex{Pattern: "^ada$", Options: "xi"}).String(); s != "/^ada$/ix" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
+
+// TestRawRegex tests reading a regular expression from a raw document.
+func TestRawRegex(t *testing.T) {
+	raw := Raw(`{"re":{"$regularExpression":{"pattern":"^a","options":"i"}},"s":"^a"}`)
+	if re, ok := raw.Lookup("re").RegexOK(); !ok || re != (Regex{Pattern: "^a", Options: "i"}) {
+		t.Errorf("unexpected regex %v", re)
+	}
+	if _, ok := raw.Lookup("s").RegexOK(); ok {
+		t.Error("expected a string not to read as a regex")
+	}
+}
@@ -0,0 +1,127 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidTimestamp is returned when JSON input is not a timestamp.
+var ErrInvalidTimestamp = errors.New("bson: invalid timestamp")
+
+// Timestamp is a BSON timestamp: seconds since the Unix epoch and an
+// ordinal that orders operations within the second. The server uses it for
+// cluster and operation times, resume points and the oplog; it is not a
+// date. It encodes to JSON as {"$timestamp": {"t": ..., "i": ...}}.
+type Timestamp struct {
+	T uint32
+	I uint32
+}
+
+// IsZero reports whether ts is unset.
+func (ts Timestamp) IsZero() bool {
+	return ts.T == 0 && ts.I == 0
+}
+
+// Compare returns -1, 0 or 1 as ts orders before, with or after other.
+func (ts Timestamp) Compare(other Timestamp) int {
+	switch {
+	case ts.T < other.T || (ts.T == other.T && ts.I < other.I):
+		return -1
+	case ts == other:
+		return 0
+	}
+	return 1
+}
+
+// Before reports whether ts orders before other.
+func (ts Timestamp) Before(other Timestamp) bool {
+	return ts.Compare(other) < 0
+}
+
+// After reports whether ts orders after other.
+func (ts Timestamp) After(other Timestamp) bool {
+	return ts.Compare(other) > 0
+}
+
+// Time returns the seconds of ts as a UTC time.
+func (ts Timestamp) Time() time.Time {
+	return time.Unix(int64(ts.T), 0).UTC()
+}
+
+// String returns ts as "Timestamp(t, i)", as the shell prints it.
+func (ts Timestamp) String() string {
+	return fmt.Sprintf("Timestamp(%d, %d)", ts.T, ts.I)
+}
+
+// MarshalJSON encodes ts as {"$timestamp": {"t": ..., "i": ...}}.
+func (ts Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(`{"$timestamp":{"t":` + strconv.FormatUint(uint64(ts.T), 10) +
+		`,"i":` + strconv.FormatUint(uint64(ts.I), 10) + `}}`), nil
+}
+
+// UnmarshalJSON decodes ts from a $timestamp document or a plain
+// {"t": ..., "i": ...} document. JSON null leaves ts unchanged.
+func (ts *Timestamp) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	parsed, err := parseTimestampValue(v)
+	if err != nil {
+		return err
+	}
+	*ts = parsed
+	return nil
+}
+
+// parseTimestampValue parses a generically decoded timestamp.
+func parseTimestampValue(v any) (Timestamp, error) {
+	invalid := fmt.Errorf("%w: %v", ErrInvalidTimestamp, v)
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return Timestamp{}, invalid
+	}
+	if inner, ok := doc["$timestamp"]; ok {
+		if len(doc) != 1 {
+			return Timestamp{}, invalid
+		}
+		if doc, ok = inner.(map[string]any); !ok {
+			return Timestamp{}, invalid
+		}
+	}
+	t, tok := timestampPart(doc["t"])
+	i, iok := timestampPart(doc["i"])
+	if !tok || !iok || len(doc) != 2 {
+		return Timestamp{}, invalid
+	}
+	return Timestamp{T: t, I: i}, nil
+}
+
+// timestampPart converts one field of a timestamp to a uint32.
+func timestampPart(v any) (uint32, bool) {
+	var n uint64
+	var err error
+	switch v := v.(type) {
+	case json.Number:
+		n, err = strconv.ParseUint(string(v), 10, 32)
+	case float64:
+		if v < 0 || v > math.MaxUint32 || v != math.Trunc(v) {
+			return 0, false
+		}
+		n = uint64(v)
+	default:
+		return 0, false
+	}
+	return uint32(n), err == nil
+}
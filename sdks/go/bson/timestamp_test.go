@@ -0,0 +1,67 @@
+package bson
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTimestampJSON tests encoding and decoding timestamps.
+func TestTimestampJSON(t *testing.T) {
+	ts := Timestamp{T: 1700000000, I: 3}
+	data, err := json.Marshal(ts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"$timestamp":{"t":1700000000,"i":3}}` {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+
+	for _, input := range []string{
+		`{"$timestamp":{"t":1700000000,"i":3}}`,
+		`{"t":1700000000,"i":3}`,
+	} {
+		var got Timestamp
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Errorf("%s: unexpected error: %v", input, err)
+		} else if got != ts {
+			t.Errorf("%s: expected %v, got %v", input, ts, got)
+		}
+	}
+
+	for _, input := range []string{`"x"`, `{"t":-1,"i":0}`, `{"t":1}`, `{"$timestamp":{"t":1,"i":1},"x":1}`, `{"t":4294967296,"i":0}`} {
+		var got Timestamp
+		if err := json.Unmarshal([]byte(input), &got); !errors.Is(err, ErrInvalidTimestamp) {
+			t.Errorf("%s: expected ErrInvalidTimestamp, got %v", input, err)
+		}
+	}
+}
+
+// TestTimestampOrder tests comparing timestamps.
+func TestTimestampOrder(t *testing.T) {
+	a := Timestamp{T: 10, I: 1}
+	b := Timestamp{T: 10, I: 2}
+	c := Timestamp{T: 11, I: 0}
+
+	if a.Compare(b) != -1 || c.Compare(b) != 1 || b.Compare(b) != 0 {
+		t.Error("unexpected Compare")
+	}
+	if !a.Before(c) || !c.After(a) || a.After(a) {
+		t.Error("unexpected Before or After")
+	}
+	if !c.Time().Equal(time.Unix(11, 0)) || c.String() != "Timestamp(11, 0)" {
+		t.Errorf("unexpected time %v or string %s", c.Time(), c)
+	}
+}
+
+// TestRawTimestamp tests reading a timestamp from a raw document.
+func TestRawTimestamp(t *testing.T) {
+	raw := Raw(`{"ts":{"$timestamp":{"t":5,"i":1}},"n":5}`)
+	if ts, ok := raw.Lookup("ts").TimestampOK(); !ok || ts != (Timestamp{T: 5, I: 1}) {
+		t.Errorf("unexpected timestamp %v", ts)
+	}
+	if _, ok := raw.Lookup("n").TimestampOK(); ok {
+		t.Error("expected a number not to read as a timestamp")
+	}
+}
@@ -0,0 +1,245 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// TestBSONRoundTrip tests encoding documents and decoding them to RPC shapes.
+func TestBSONRoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := map[string]any{
+		"_id":     map[string]any{"$oid": "65a1b2c3d4e5f60718293a4b"},
+		"name":    "Ada",
+		"age":     36,
+		"score":   9.5,
+		"big":     int64(1) << 60,
+		"active":  true,
+		"missing": nil,
+		"created": created,
+		"tags":    []string{"a", "b"},
+		"nested":  map[string]any{"x": int32(1)},
+		"session": map[string]any{"$uuid": "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		"ts":      map[string]any{"$timestamp": map[string]any{"t": float64(10), "i": float64(2)}},
+		"long":    map[string]any{"$numberLong": "42"},
+		"price":   map[string]any{"$numberDecimal": "12.50"},
+		"total":   mustDecimal(t, "-0.000000001"),
+	}
+
+	data, err := marshalBSON(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"_id":     map[string]any{"$oid": "65a1b2c3d4e5f60718293a4b"},
+		"name":    "Ada",
+		"age":     float64(36),
+		"score":   9.5,
+		"big":     map[string]any{"$numberLong": "1152921504606846976"},
+		"active":  true,
+		"missing": nil,
+		"created": map[string]any{"$date": "2024-01-02T03:04:05.000Z"},
+		"tags":    []any{"a", "b"},
+		"nested":  map[string]any{"x": float64(1)},
+		"session": map[string]any{"$uuid": "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		"ts":      map[string]any{"$timestamp": map[string]any{"t": float64(10), "i": float64(2)}},
+		"long":    float64(42),
+		"price":   map[string]any{"$numberDecimal": "12.50"},
+		"total":   map[string]any{"$numberDecimal": "-1E-9"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestBSONOrderedDocument tests that bsonDoc keeps element order and maps
+// put _id first.
+func TestBSONOrderedDocument(t *testing.T) {
+	data, err := marshalBSON(bsonDoc{{"find", "users"}, {"filter", map[string]any{"b": 1, "_id": 2, "a": 3}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := string(data)
+	if strings.Index(s, "find") > strings.Index(s, "filter") {
+		t.Error("expected find before filter")
+	}
+	if !(strings.Index(s, "_id") < strings.Index(s, "a\x00") && strings.Index(s, "a\x00") < strings.Index(s, "b\x00")) {
+		t.Errorf("expected _id, a, b order in %q", s)
+	}
+}
+
+// TestBSONStruct tests encoding structs through their JSON form.
+func TestBSONStruct(t *testing.T) {
+	type user struct {
+		ID   string `json:"_id"`
+		Name string `json:"name"`
+	}
+	data, err := marshalBSON(user{ID: "u1", Name: "Ada"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, map[string]any{"_id": "u1", "name": "Ada"}) {
+		t.Errorf("unexpected document: %v", got)
+	}
+
+	if _, err := marshalBSON([]any{1}); err == nil {
+		t.Error("expected an error encoding an array as a document")
+	}
+}
+
+// TestUnmarshalBSONInvalid tests rejecting malformed documents.
+func TestUnmarshalBSONInvalid(t *testing.T) {
+	valid, err := marshalBSON(map[string]any{"a": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := [][]byte{
+		nil,
+		valid[:len(valid)-1],
+		append(append([]byte{}, valid...), 0),
+		{5, 0, 0, 0, 1},
+	}
+	for _, data := range tests {
+		if _, err := unmarshalBSON(data); err == nil {
+			t.Errorf("expected an error decoding %v", data)
+		}
+	}
+}
+
+// TestBSONOrderedTypes tests encoding the bson package's document types.
+func TestBSONOrderedTypes(t *testing.T) {
+	doc := bson.D{{Key: "z", Value: bson.A{1, "x"}}, {Key: "a", Value: bson.M{"k": true}}}
+	data, err := marshalBSON(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"z": []any{float64(1), "x"}, "a": map[string]any{"k": true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if s := string(data); strings.Index(s, "z\x00") > strings.Index(s, "a\x00") {
+		t.Errorf("expected z before a in %q", s)
+	}
+}
+
+// TestBSONObjectID tests that ObjectIDs encode as the BSON ObjectID type.
+func TestBSONObjectID(t *testing.T) {
+	id := bson.NewObjectID()
+	data, err := marshalBSON(bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got["_id"], map[string]any{"$oid": id.Hex()}) {
+		t.Errorf("unexpected _id %v", got["_id"])
+	}
+}
+
+// TestBSONBinary tests that binary data keeps its subtype, whether given
+// as a value or as a $binary document.
+func TestBSONBinary(t *testing.T) {
+	id, err := bson.ParseUUID("0f8fad5b-d9cb-469f-a165-70867728950e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := bson.D{
+		{Key: "id", Value: id},
+		{Key: "hash", Value: bson.Binary{Subtype: bson.BinaryMD5, Data: []byte{1, 2}}},
+		{Key: "nested", Value: map[string]any{"user": bson.Binary{Subtype: bson.BinaryUserDefined, Data: []byte{3}}}},
+	}
+	data, err := marshalBSON(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"id":     map[string]any{"$uuid": "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		"hash":   map[string]any{"$binary": map[string]any{"base64": "AQI=", "subType": "05"}},
+		"nested": map[string]any{"user": map[string]any{"$binary": map[string]any{"base64": "Aw==", "subType": "80"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestBSONTimestamp tests that timestamps encode as BSON timestamps.
+func TestBSONTimestamp(t *testing.T) {
+	data, err := marshalBSON(bson.D{{Key: "ts", Value: bson.Timestamp{T: 1700000000, I: 3}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data[4] != bsonTimestamp {
+		t.Errorf("expected element type %#x, got %#x", bsonTimestamp, data[4])
+	}
+	got, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"ts": map[string]any{"$timestamp": map[string]any{"t": float64(1700000000), "i": float64(3)}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestBSONRegex tests encoding regular expressions, as values and as
+// extended JSON.
+func TestBSONRegex(t *testing.T) {
+	want := map[string]any{"$regularExpression": map[string]any{"pattern": "^ada", "options": "im"}}
+	for _, value := range []any{
+		bson.Regex{Pattern: "^ada", Options: "mi"},
+		map[string]any{"$regularExpression": map[string]any{"pattern": "^ada", "options": "mi"}},
+	} {
+		data, err := marshalBSON(bson.D{{Key: "re", Value: value}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if data[4] != bsonRegex {
+			t.Errorf("expected element type %#x, got %#x", bsonRegex, data[4])
+		}
+		got, err := unmarshalBSON(data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got["re"], want) {
+			t.Errorf("expected %v, got %v", want, got["re"])
+		}
+	}
+
+	if _, err := marshalBSON(bson.D{{Key: "re", Value: bson.Regex{Pattern: "a\x00b"}}}); err == nil {
+		t.Error("expected an error for a NUL byte")
+	}
+}
+
+// mustDecimal parses s or fails the test.
+func mustDecimal(t *testing.T, s string) bson.Decimal128 {
+	t.Helper()
+	d, err := bson.ParseDecimal128(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return d
+}
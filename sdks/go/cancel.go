@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+)
+
+// requestIDKey is the context key for request IDs.
+type requestIDKey struct{}
+
+// WithRequestID returns a context that tags every operation issued with it
+// with id, so the operations can later be aborted with CancelOperation. The
+// ID is sent in the operation's comment as {requestId: id}.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID attached to ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// tagRequest adds the request ID from ctx to the comment option, keeping any
+// existing comment under the "comment" key.
+func tagRequest(ctx context.Context, options map[string]any) {
+	id, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return
+	}
+	comment := map[string]any{"requestId": id}
+	if existing, ok := options["comment"]; ok {
+		comment["comment"] = existing
+	}
+	options["comment"] = comment
+}
+
+// CancelOperation aborts the running operations tagged with requestID via
+// WithRequestID. It finds them in currentOp and issues killOp for each,
+// returning ErrOperationNotFound if none are running.
+func (c *Client) CancelOperation(ctx context.Context, requestID string) error {
+	pipeline := []any{
+		map[string]any{"$currentOp": map[string]any{"allUsers": true}},
+		map[string]any{"$match": map[string]any{"$or": []any{
+			map[string]any{"command.comment.requestId": requestID},
+			map[string]any{"originatingCommand.comment.requestId": requestID},
+		}}},
+	}
+
+	cursor, err := c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	var ops []map[string]any
+	if err := cursor.All(ctx, &ops); err != nil {
+		return err
+	}
+
+	killed := 0
+	admin := c.Database("admin")
+	for _, op := range ops {
+		opid, ok := op["opid"]
+		if !ok {
+			continue
+		}
+		if err := admin.RunCommand(ctx, map[string]any{"killOp": 1, "op": normalizeID(opid)}).Err(); err != nil {
+			return fmt.Errorf("killing operation %v: %w", opid, err)
+		}
+		killed++
+	}
+
+	if killed == 0 {
+		return ErrOperationNotFound
+	}
+	return nil
+}
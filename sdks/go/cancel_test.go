@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestWithRequestID tests tagging operations with a request ID.
+func TestWithRequestID(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	mock.addCall("mongo.deleteOne", map[string]any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.SetNamespaceDefaults("testdb.users", NamespaceDefaults{Comment: "team-a"})
+	coll := client.Database("testdb").Collection("users")
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	if id, ok := RequestIDFromContext(ctx); !ok || id != "req-42" {
+		t.Fatalf("unexpected request ID: %q", id)
+	}
+
+	if _, err := coll.Find(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.DeleteOne(ctx, map[string]any{"_id": "1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, call := range mock.calls {
+		options := call.args[len(call.args)-1].(map[string]any)
+		comment, ok := options["comment"].(map[string]any)
+		if !ok || comment["requestId"] != "req-42" || comment["comment"] != "team-a" {
+			t.Errorf("call %d: unexpected comment %v", i, options["comment"])
+		}
+	}
+}
+
+// TestRequestIDFromContextMissing tests contexts without a request ID.
+func TestRequestIDFromContextMissing(t *testing.T) {
+	if _, ok := RequestIDFromContext(context.Background()); ok {
+		t.Error("expected no request ID")
+	}
+	if _, ok := RequestIDFromContext(WithRequestID(context.Background(), "")); ok {
+		t.Error("expected empty request ID to be ignored")
+	}
+}
+
+// TestClientCancelOperation tests killing operations by request ID.
+func TestClientCancelOperation(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"opid": float64(101), "command": map[string]any{"comment": map[string]any{"requestId": "req-42"}}},
+		map[string]any{"opid": "shard1:202"},
+	}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.CancelOperation(context.Background(), "req-42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	match := mock.calls[0].args[2].([]any)[1].(map[string]any)["$match"].(map[string]any)
+	if len(match["$or"].([]any)) != 2 {
+		t.Errorf("unexpected currentOp filter: %v", match)
+	}
+
+	first := mock.calls[1].args[1].(map[string]any)
+	if mock.calls[1].args[0] != "admin" || first["killOp"] != 1 || first["op"] != int64(101) {
+		t.Errorf("unexpected killOp: %v %v", mock.calls[1].args[0], first)
+	}
+	if second := mock.calls[2].args[1].(map[string]any); second["op"] != "shard1:202" {
+		t.Errorf("unexpected killOp: %v", second)
+	}
+}
+
+// TestClientCancelOperationNotFound tests cancelling an operation that is not running.
+func TestClientCancelOperationNotFound(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.CancelOperation(context.Background(), "req-42"); !errors.Is(err, ErrOperationNotFound) {
+		t.Errorf("expected ErrOperationNotFound, got %v", err)
+	}
+}
+
+// TestClientCancelOperationKillError tests a failing killOp.
+func TestClientCancelOperationKillError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{map[string]any{"opid": float64(7)}}, nil)
+	mock.addCall("mongo.runCommand", nil, errors.New("unauthorized"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.CancelOperation(context.Background(), "req-42"); err == nil || errors.Is(err, ErrOperationNotFound) {
+		t.Errorf("expected kill error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+)
+
+// CreateCollectionOptions configures a CreateCollection operation.
+type CreateCollectionOptions struct {
+	// Capped creates a fixed-size collection that overwrites its oldest
+	// documents once SizeInBytes or MaxDocuments is reached.
+	Capped       *bool
+	SizeInBytes  *int64
+	MaxDocuments *int64
+}
+
+// SetCapped sets whether the collection is capped.
+func (o *CreateCollectionOptions) SetCapped(capped bool) *CreateCollectionOptions {
+	o.Capped = &capped
+	return o
+}
+
+// SetSizeInBytes sets the maximum size of a capped collection.
+func (o *CreateCollectionOptions) SetSizeInBytes(size int64) *CreateCollectionOptions {
+	o.SizeInBytes = &size
+	return o
+}
+
+// SetMaxDocuments sets the maximum number of documents in a capped collection.
+func (o *CreateCollectionOptions) SetMaxDocuments(max int64) *CreateCollectionOptions {
+	o.MaxDocuments = &max
+	return o
+}
+
+// createCollectionOptions merges create collection options into an options map.
+func createCollectionOptions(opts ...*CreateCollectionOptions) map[string]any {
+	options := make(map[string]any)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Capped != nil {
+			options["capped"] = *opt.Capped
+		}
+		if opt.SizeInBytes != nil {
+			options["size"] = *opt.SizeInBytes
+		}
+		if opt.MaxDocuments != nil {
+			options["max"] = *opt.MaxDocuments
+		}
+	}
+	return options
+}
+
+// CappedInfo describes whether a collection is capped and its limits.
+type CappedInfo struct {
+	Capped bool
+	// MaxSize and MaxDocuments are the configured limits of a capped
+	// collection; MaxDocuments is zero when only the size is limited.
+	MaxSize      int64
+	MaxDocuments int64
+	// Size and Count are the current data size and document count.
+	Size  int64
+	Count int64
+}
+
+// CappedInfo returns the capped status and size limits of the collection.
+func (c *Collection) CappedInfo(ctx context.Context) (*CappedInfo, error) {
+	var stats map[string]any
+	if err := c.database.RunCommand(ctx, map[string]any{"collStats": c.name}).Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	info := &CappedInfo{Capped: asBool(stats["capped"])}
+	info.MaxSize, _ = asInt64(stats["maxSize"])
+	info.MaxDocuments, _ = asInt64(stats["max"])
+	info.Size, _ = asInt64(stats["size"])
+	info.Count, _ = asInt64(stats["count"])
+	return info, nil
+}
+
+// IsCapped reports whether the collection is capped.
+func (c *Collection) IsCapped(ctx context.Context) (bool, error) {
+	info, err := c.CappedInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return info.Capped, nil
+}
+
+// ConvertToCapped converts the collection to a capped collection of at most
+// size bytes. The conversion holds an exclusive lock on the database while
+// the data is copied.
+func (c *Collection) ConvertToCapped(ctx context.Context, size int64) error {
+	if size <= 0 {
+		return fmt.Errorf("mongo: capped size must be positive, got %d", size)
+	}
+	return c.database.RunCommand(ctx, map[string]any{"convertToCapped": c.name, "size": size}).Err()
+}
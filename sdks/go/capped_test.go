@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestDatabaseCreateCappedCollection tests the capped creation options.
+func TestDatabaseCreateCappedCollection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.createCollection", true, nil)
+	mock.addCall("mongo.createCollection", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+	ctx := context.Background()
+
+	opts := (&CreateCollectionOptions{}).SetCapped(true).SetSizeInBytes(1 << 20).SetMaxDocuments(1000)
+	if err := db.CreateCollection(ctx, "events", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[2].(map[string]any)
+	if options["capped"] != true || options["size"] != int64(1<<20) || options["max"] != int64(1000) {
+		t.Errorf("unexpected options: %v", options)
+	}
+
+	if err := db.CreateCollection(ctx, "plain"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.calls[1].args) != 2 {
+		t.Errorf("expected no options, got %v", mock.calls[1].args)
+	}
+}
+
+// TestCollectionCappedInfo tests reading capped metadata from collStats.
+func TestCollectionCappedInfo(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{
+		"capped":  true,
+		"maxSize": float64(1048576),
+		"max":     float64(1000),
+		"size":    float64(2048),
+		"count":   float64(12),
+	}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"size": float64(10), "count": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("events")
+	ctx := context.Background()
+
+	info, err := coll.CappedInfo(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := CappedInfo{Capped: true, MaxSize: 1048576, MaxDocuments: 1000, Size: 2048, Count: 12}
+	if *info != want {
+		t.Errorf("expected %+v, got %+v", want, *info)
+	}
+	if cmd := mock.calls[0].args[1].(map[string]any); cmd["collStats"] != "events" {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+
+	capped, err := coll.IsCapped(ctx)
+	if err != nil || capped {
+		t.Errorf("expected uncapped, got %v, %v", capped, err)
+	}
+}
+
+// TestCollectionIsCappedError tests a failing collStats command.
+func TestCollectionIsCappedError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, errors.New("ns not found"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if _, err := client.Database("testdb").Collection("events").IsCapped(context.Background()); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestCollectionConvertToCapped tests the convertToCapped wrapper.
+func TestCollectionConvertToCapped(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("events")
+	ctx := context.Background()
+
+	if err := coll.ConvertToCapped(ctx, 0); err == nil {
+		t.Error("expected error for non-positive size")
+	}
+
+	if err := coll.ConvertToCapped(ctx, 4096); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd := mock.calls[0].args[1].(map[string]any)
+	if cmd["convertToCapped"] != "events" || cmd["size"] != int64(4096) {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+}
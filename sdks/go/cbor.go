@@ -0,0 +1,345 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// This file holds the minimal CBOR (RFC 8949) codec used for CBOR payloads
+// over the RPC transport (see payload.go).
+
+// CBOR major types.
+const (
+	cborUint   byte = 0 << 5
+	cborNegInt byte = 1 << 5
+	cborBytes  byte = 2 << 5
+	cborText   byte = 3 << 5
+	cborArray  byte = 4 << 5
+	cborMap    byte = 5 << 5
+	cborTag    byte = 6 << 5
+	cborSimple byte = 7 << 5
+)
+
+// cborIndefinite is the additional information of indefinite-length items,
+// and cborBreak the byte ending them.
+const (
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// marshalCBOR encodes a document as CBOR.
+func marshalCBOR(doc any) ([]byte, error) {
+	e := &cborEncoder{}
+	if err := encodeCompact(e, doc); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// cborEncoder writes CBOR values, with definite lengths and arguments in
+// their smallest form.
+type cborEncoder struct {
+	buf bytes.Buffer
+}
+
+// writeHead writes the initial byte of an item of major type with argument n.
+func (e *cborEncoder) writeHead(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(major | 25)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(major | 26)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(major | 27)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func (e *cborEncoder) writeNil() {
+	e.buf.WriteByte(cborSimple | 22)
+}
+
+func (e *cborEncoder) writeBool(b bool) {
+	if b {
+		e.buf.WriteByte(cborSimple | 21)
+	} else {
+		e.buf.WriteByte(cborSimple | 20)
+	}
+}
+
+func (e *cborEncoder) writeInt(n int64) {
+	if n >= 0 {
+		e.writeHead(cborUint, uint64(n))
+	} else {
+		e.writeHead(cborNegInt, uint64(-1-n))
+	}
+}
+
+func (e *cborEncoder) writeUint(n uint64) {
+	e.writeHead(cborUint, n)
+}
+
+func (e *cborEncoder) writeFloat(f float64) {
+	e.buf.WriteByte(cborSimple | 27)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func (e *cborEncoder) writeString(s string) {
+	e.writeHead(cborText, uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *cborEncoder) writeBytes(b []byte) {
+	e.writeHead(cborBytes, uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *cborEncoder) writeArrayHeader(n int) {
+	e.writeHead(cborArray, uint64(n))
+}
+
+func (e *cborEncoder) writeMapHeader(n int) {
+	e.writeHead(cborMap, uint64(n))
+}
+
+// unmarshalCBOR decodes a CBOR document.
+func unmarshalCBOR(data []byte) (map[string]any, error) {
+	r := &cborReader{data: data}
+	v, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("%d bytes after document", len(data)-r.pos)
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a map, got %T", v)
+	}
+	return doc, nil
+}
+
+// cborReader decodes CBOR values.
+type cborReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *cborReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// head reads the initial byte of an item and its argument. For
+// indefinite-length items, indefinite is true and n is zero.
+func (r *cborReader) head() (major, info byte, n uint64, indefinite bool, err error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info = b[0]&0xe0, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info == cborIndefinite:
+		return major, info, 0, true, nil
+	case info > 27:
+		return 0, 0, 0, false, fmt.Errorf("invalid CBOR additional information %d", info)
+	}
+	arg, err := r.next(1 << (info - 24))
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	for _, c := range arg {
+		n = n<<8 | uint64(c)
+	}
+	return major, info, n, false, nil
+}
+
+// atBreak consumes the break ending an indefinite-length item, if it is next.
+func (r *cborReader) atBreak() bool {
+	if r.pos < len(r.data) && r.data[r.pos] == cborBreak {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *cborReader) value() (any, error) {
+	major, info, n, indefinite, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return compactUint(n), nil
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			return -float64(n) - 1, nil
+		}
+		return compactInt(-1 - int64(n)), nil
+	case cborBytes, cborText:
+		b, err := r.str(major, n, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return compactBytes(b), nil
+	case cborArray:
+		if !indefinite {
+			if _, err := compactLength(n, len(r.data)-r.pos); err != nil {
+				return nil, err
+			}
+		}
+		items := []any{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && r.atBreak() {
+				break
+			}
+			item, err := r.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		if !indefinite {
+			if _, err := compactLength(n, len(r.data)-r.pos); err != nil {
+				return nil, err
+			}
+		}
+		doc := make(map[string]any)
+		for i := uint64(0); indefinite || i < n; i++ {
+			if indefinite && r.atBreak() {
+				break
+			}
+			k, err := r.value()
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.value()
+			if err != nil {
+				return nil, err
+			}
+			doc[compactKey(k)] = v
+		}
+		return doc, nil
+	case cborTag:
+		return r.tagged(n)
+	}
+	return r.simple(info, n)
+}
+
+// str reads the contents of a byte or text string, joining the chunks of
+// an indefinite-length one.
+func (r *cborReader) str(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		size, err := compactLength(n, len(r.data)-r.pos)
+		if err != nil {
+			return nil, err
+		}
+		return r.next(size)
+	}
+	var joined []byte
+	for !r.atBreak() {
+		chunkMajor, _, n, chunkIndefinite, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, fmt.Errorf("invalid CBOR string chunk")
+		}
+		chunk, err := r.str(major, n, false)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, chunk...)
+	}
+	return joined, nil
+}
+
+// tagged decodes the content of a tagged item. Standard and epoch dates
+// (tags 0 and 1) become {$date: ...}; other tags are ignored.
+func (r *cborReader) tagged(tag uint64) (any, error) {
+	v, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case 0:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid CBOR date %v", v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return compactDate(t), nil
+	case 1:
+		seconds, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid CBOR epoch date %v", v)
+		}
+		return compactDate(time.UnixMilli(int64(math.Round(seconds * 1000)))), nil
+	}
+	return v, nil
+}
+
+// simple decodes major type 7: booleans, null, undefined and floats.
+func (r *cborReader) simple(info byte, n uint64) (any, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		return halfFloat(uint16(n)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(n))), nil
+	case 27:
+		return math.Float64frombits(n), nil
+	}
+	return nil, fmt.Errorf("unsupported CBOR simple value %d", n)
+}
+
+// halfFloat converts an IEEE 754 half-precision float.
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
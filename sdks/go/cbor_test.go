@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestCBOREncoding tests encoding values with their smallest CBOR heads.
+func TestCBOREncoding(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{bsonDoc{{"a", 1}}, "a1616101"},
+		{bsonDoc{{"n", 100}}, "a1616e1864"},
+		{bsonDoc{{"n", -1000}}, "a1616e3903e7"},
+		{bsonDoc{{"b", []byte{1, 2}}}, "a16162420102"},
+		{bsonDoc{{"x", []any{true, nil, []any{false}}}}, "a1617883f5f681f4"},
+		{bsonDoc{{"f", 1.5}}, "a16166fb3ff8000000000000"},
+	}
+	for _, tt := range tests {
+		data, err := marshalCBOR(tt.value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := hex.EncodeToString(data); got != tt.want {
+			t.Errorf("encoding %v: got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestCBORDecoding tests decoding CBOR, including the forms the encoder
+// does not produce.
+func TestCBORDecoding(t *testing.T) {
+	id := bson.NewObjectID()
+	data, err := marshalCBOR(bsonDoc{{"big", int64(-1) << 60}, {"id", id}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := unmarshalCBOR(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"big": map[string]any{"$numberLong": "-1152921504606846976"},
+		"id":  map[string]any{"$oid": id.Hex()},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("unexpected document %v", doc)
+	}
+
+	tests := []struct {
+		data string
+		want any
+	}{
+		// Half-precision float.
+		{"a16168f93c00", 1.0},
+		// Indefinite-length array and text string.
+		{"a161689f0102ff", []any{float64(1), float64(2)}},
+		{"a161687f626162626364ff", "abcd"},
+		// Epoch and standard dates.
+		{"a16168c11a514b67b0", map[string]any{"$date": "2013-03-21T20:04:00.000Z"}},
+		{"a16168c074323031332d30332d32315432303a30343a30305a", map[string]any{"$date": "2013-03-21T20:04:00.000Z"}},
+		// Other tags are ignored.
+		{"a16168d82001", float64(1)},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.data)
+		doc, err := unmarshalCBOR(data)
+		if err != nil {
+			t.Errorf("decoding %s: %v", tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(doc["h"], tt.want) {
+			t.Errorf("decoding %s: got %v, want %v", tt.data, doc["h"], tt.want)
+		}
+	}
+
+	if f := halfFloat(0x7c00); !math.IsInf(f, 1) {
+		t.Errorf("expected +Inf, got %v", f)
+	}
+	for _, bad := range []string{"a161", "9b00000000ffffffff", "a1616bfc", "f8", "0102"} {
+		data, _ := hex.DecodeString(bad)
+		if _, err := unmarshalCBOR(data); err == nil {
+			t.Errorf("expected an error decoding %s", bad)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package mongo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// BatchCompression is the compression of change event batches sent by the
+// server when a change stream is opened with ChangeStreamOptions.BatchCompression.
+type BatchCompression string
+
+// Batch compressions. CompressionGzip is the supported codec. Other codecs
+// the server offers, such as "zstd", need a decompressor registered with
+// RegisterDecompressor first, as the driver ships none; opening a stream
+// with one that has none fails with ErrInvalidOption.
+const (
+	CompressionNone BatchCompression = "none"
+	CompressionGzip BatchCompression = "gzip"
+)
+
+// DefaultMaxEventBatchBytes caps the decompressed size of a change event
+// batch when ClientOptions.MaxResponseBytes is not set.
+const DefaultMaxEventBatchBytes = 64 << 20
+
+// Decompressor returns a reader of the decompressed contents of r.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[BatchCompression]Decompressor{
+		CompressionNone: func(r io.Reader) (io.Reader, error) { return r, nil },
+		CompressionGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+)
+
+// RegisterDecompressor makes a decompressor available for compression,
+// replacing any registered before.
+func RegisterDecompressor(compression BatchCompression, d Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	decompressors[compression] = d
+}
+
+// decompressor returns the decompressor registered for compression.
+func decompressor(compression BatchCompression) (Decompressor, bool) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	d, ok := decompressors[compression]
+	return d, ok
+}
+
+// validate reports an error for compressions without a registered
+// decompressor.
+func (c BatchCompression) validate() error {
+	if _, ok := decompressor(c); !ok {
+		return fmt.Errorf("%w: batchCompression %q has no registered decompressor", ErrInvalidOption, string(c))
+	}
+	return nil
+}
+
+// decodeEventBatch decodes a mongo.changeStreamNextBatch result on ns:
+// base64 data holding a JSON array of events, compressed as named by
+// compression. Batches decompressing to more than maxBytes fail with a
+// ResultTooLargeError, without reading past the limit.
+func decodeEventBatch(batch map[string]any, ns string, maxBytes int64) ([]map[string]any, error) {
+	compression := CompressionNone
+	if name, ok := batch["compression"].(string); ok && name != "" {
+		compression = BatchCompression(name)
+	}
+	d, ok := decompressor(compression)
+	if !ok {
+		return nil, fmt.Errorf("mongo: change event batch uses unsupported compression %q", compression)
+	}
+
+	encoded, _ := batch["data"].(string)
+	if encoded == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: change event batch: %w", err)
+	}
+	r, err := d(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("mongo: change event batch: %w", err)
+	}
+	decompressed, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("mongo: change event batch: %w", err)
+	}
+	if int64(len(decompressed)) > maxBytes {
+		return nil, &ResultTooLargeError{Namespace: ns, Limit: maxBytes, Unit: "bytes", Suggestion: "lower the change stream batch size"}
+	}
+	var events []map[string]any
+	if err := json.Unmarshal(decompressed, &events); err != nil {
+		return nil, fmt.Errorf("mongo: change event batch: %w", err)
+	}
+	return events, nil
+}
+
+// advanceBatch handles a mongo.changeStreamNextBatch result, buffering its
+// events and making the first one current.
+func (cs *ChangeStream) advanceBatch(result any) bool {
+	batch, ok := result.(map[string]any)
+	if !ok {
+		cs.err = unexpectedResponse("mongo.changeStreamNextBatch", result)
+		return false
+	}
+	events, err := decodeEventBatch(batch, cs.namespace, cs.maxBatchBytes)
+	if err != nil {
+		cs.err = err
+		return false
+	}
+	if cs.liveness != nil {
+		cs.liveness.lastSeen = cs.liveness.clock.Now()
+	}
+	cs.pending = events
+	cs.pendingToken = batch["postBatchResumeToken"]
+	return cs.nextPending()
+}
+
+// nextPending makes the next buffered event current. Once the batch is
+// drained, the resume token moves to the batch's postBatchResumeToken.
+func (cs *ChangeStream) nextPending() bool {
+	if len(cs.pending) == 0 {
+		cs.finishBatch()
+		return false
+	}
+	event := cs.pending[0]
+	cs.pending = cs.pending[1:]
+
+	current, err := cs.parseEvent(event)
+	if err != nil {
+		cs.err = err
+		return false
+	}
+	cs.current = current
+	cs.resumeToken = current.ID
+	if len(cs.pending) == 0 {
+		cs.finishBatch()
+	}
+	return true
+}
+
+// finishBatch records the resume token of a drained batch.
+func (cs *ChangeStream) finishBatch() {
+	cs.pending = nil
+	if cs.pendingToken != nil {
+		cs.resumeToken = cs.pendingToken
+		cs.pendingToken = nil
+	}
+}
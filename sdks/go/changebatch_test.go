@@ -0,0 +1,138 @@
+package mongo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gzipBatch encodes events as a gzip-compressed batch payload.
+func gzipBatch(t *testing.T, events []any) string {
+	t.Helper()
+	data, err := json.Marshal(events)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// TestChangeStreamCompressedBatches tests iterating events from compressed batches.
+func TestChangeStreamCompressedBatches(t *testing.T) {
+	events := []any{
+		map[string]any{"_id": map[string]any{"_data": "t1"}, "operationType": "insert", "documentKey": map[string]any{"_id": "a"}},
+		map[string]any{"_id": map[string]any{"_data": "t2"}, "operationType": "delete", "documentKey": map[string]any{"_id": "b"}},
+	}
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNextBatch", map[string]any{
+		"compression":          "gzip",
+		"data":                 gzipBatch(t, events),
+		"postBatchResumeToken": map[string]any{"_data": "t3"},
+	}, nil)
+	mock.addCall("mongo.changeStreamNextBatch", nil, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	cs, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetBatchCompression(CompressionGzip))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["batchCompression"] != "gzip" {
+		t.Errorf("unexpected options: %v", options)
+	}
+
+	var ids []any
+	for cs.Next(ctx) {
+		ids = append(ids, cs.Current().DocumentID())
+	}
+	if err := cs.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []any{"a", "b"}) {
+		t.Errorf("unexpected events: %v", ids)
+	}
+	if mock.callIndex != 3 {
+		t.Errorf("expected one RPC per batch, got %d calls", mock.callIndex)
+	}
+	if !reflect.DeepEqual(cs.resumeToken, map[string]any{"_data": "t3"}) {
+		t.Errorf("expected the postBatchResumeToken, got %v", cs.resumeToken)
+	}
+}
+
+// TestChangeStreamBatchCompressionRegistry tests validating and registering decompressors.
+func TestChangeStreamBatchCompressionRegistry(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.Watch(context.Background(), []any{}, (&ChangeStreamOptions{}).SetBatchCompression("lz4"))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption, got %v", err)
+	}
+	_, err = coll.Watch(context.Background(), []any{}, (&ChangeStreamOptions{}).SetBatchCompression("zstd"))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption for zstd without a decompressor, got %v", err)
+	}
+
+	RegisterDecompressor("identity", func(r io.Reader) (io.Reader, error) { return r, nil })
+	defer func() {
+		decompressorsMu.Lock()
+		delete(decompressors, "identity")
+		decompressorsMu.Unlock()
+	}()
+
+	data := base64.StdEncoding.EncodeToString([]byte(`[{"_id":{"_data":"t1"},"operationType":"insert"}]`))
+	events, err := decodeEventBatch(map[string]any{"compression": "identity", "data": data}, "testdb.users", DefaultMaxEventBatchBytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0]["operationType"] != "insert" {
+		t.Errorf("unexpected events: %v", events)
+	}
+
+	if _, err := decodeEventBatch(map[string]any{"compression": "lz4", "data": data}, "testdb.users", DefaultMaxEventBatchBytes); err == nil {
+		t.Error("expected error for an unsupported compression")
+	}
+	if _, err := decodeEventBatch(map[string]any{"compression": "gzip", "data": data}, "testdb.users", DefaultMaxEventBatchBytes); err == nil {
+		t.Error("expected error for corrupt data")
+	}
+}
+
+// TestChangeStreamBatchTooLarge tests that batches decompressing past
+// MaxResponseBytes are rejected.
+func TestChangeStreamBatchTooLarge(t *testing.T) {
+	events := []any{map[string]any{"_id": map[string]any{"_data": "t1"}, "operationType": "insert", "padding": strings.Repeat("x", 4096)}}
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNextBatch", map[string]any{"compression": "gzip", "data": gzipBatch(t, events)}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017",
+		DefaultClientOptions().SetMaxResponseBytes(1024))
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	cs, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetBatchCompression(CompressionGzip))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs.Next(ctx) {
+		t.Fatal("expected no event from an oversized batch")
+	}
+	var tooLarge *ResultTooLargeError
+	if err := cs.Err(); !errors.As(err, &tooLarge) || tooLarge.Limit != 1024 || tooLarge.Namespace != "testdb.users" {
+		t.Errorf("expected a ResultTooLargeError at 1024 bytes, got %v", err)
+	}
+}
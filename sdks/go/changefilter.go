@@ -0,0 +1,83 @@
+package mongo
+
+// OperationType is the kind of change a change event describes.
+type OperationType string
+
+// Operation types of change events.
+const (
+	OperationInsert       OperationType = "insert"
+	OperationUpdate       OperationType = "update"
+	OperationReplace      OperationType = "replace"
+	OperationDelete       OperationType = "delete"
+	OperationDrop         OperationType = "drop"
+	OperationRename       OperationType = "rename"
+	OperationDropDatabase OperationType = "dropDatabase"
+	OperationInvalidate   OperationType = "invalidate"
+)
+
+// ChangeFilter is a condition on change events, written against the change
+// event schema. WatchPipeline combines filters into a $match stage:
+//
+//	pipeline := mongo.WatchPipeline(
+//		mongo.OnlyOperations(mongo.OperationInsert, mongo.OperationUpdate),
+//		mongo.FieldChanged("status"),
+//	)
+//	stream, err := coll.Watch(ctx, pipeline)
+type ChangeFilter map[string]any
+
+// OnlyOperations matches events of the given operation types.
+func OnlyOperations(ops ...OperationType) ChangeFilter {
+	if len(ops) == 1 {
+		return ChangeFilter{"operationType": string(ops[0])}
+	}
+	names := make([]any, len(ops))
+	for i, op := range ops {
+		names[i] = string(op)
+	}
+	return ChangeFilter{"operationType": map[string]any{"$in": names}}
+}
+
+// FieldChanged matches update events that set or remove any of fields as a
+// whole. Updates of a nested path, such as "status.code", are reported under
+// a dotted key that query paths cannot address, so they do not match.
+// Inserts, replaces and deletes never match, as their events do not say
+// which fields changed.
+func FieldChanged(fields ...string) ChangeFilter {
+	conditions := make([]any, 0, 2*len(fields))
+	for _, field := range fields {
+		conditions = append(conditions,
+			map[string]any{"updateDescription.updatedFields." + field: map[string]any{"$exists": true}},
+			map[string]any{"updateDescription.removedFields": field},
+		)
+	}
+	return ChangeFilter{
+		"operationType": string(OperationUpdate),
+		"$or":           conditions,
+	}
+}
+
+// MatchDocumentKey matches events for the documents with the given _id
+// values.
+func MatchDocumentKey(ids ...any) ChangeFilter {
+	if len(ids) == 1 {
+		return ChangeFilter{"documentKey._id": ids[0]}
+	}
+	return ChangeFilter{"documentKey._id": map[string]any{"$in": append([]any{}, ids...)}}
+}
+
+// WatchPipeline returns a pipeline for Watch with a single $match stage
+// requiring every filter. Without filters it is empty and matches every
+// event; append further stages, such as $project, as needed.
+func WatchPipeline(filters ...ChangeFilter) Pipeline {
+	switch len(filters) {
+	case 0:
+		return Pipeline{}
+	case 1:
+		return Pipeline{map[string]any{"$match": map[string]any(filters[0])}}
+	}
+	and := make([]any, len(filters))
+	for i, f := range filters {
+		and[i] = map[string]any(f)
+	}
+	return Pipeline{map[string]any{"$match": map[string]any{"$and": and}}}
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestWatchPipeline tests the $match stages built from change filters.
+func TestWatchPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		want     Pipeline
+	}{
+		{"empty", WatchPipeline(), Pipeline{}},
+		{
+			"one operation",
+			WatchPipeline(OnlyOperations(OperationDelete)),
+			Pipeline{map[string]any{"$match": map[string]any{"operationType": "delete"}}},
+		},
+		{
+			"operations and key",
+			WatchPipeline(OnlyOperations(OperationInsert, OperationUpdate), MatchDocumentKey("a", "b")),
+			Pipeline{map[string]any{"$match": map[string]any{"$and": []any{
+				map[string]any{"operationType": map[string]any{"$in": []any{"insert", "update"}}},
+				map[string]any{"documentKey._id": map[string]any{"$in": []any{"a", "b"}}},
+			}}}},
+		},
+		{
+			"field changed",
+			WatchPipeline(FieldChanged("status")),
+			Pipeline{map[string]any{"$match": map[string]any{
+				"operationType": "update",
+				"$or": []any{
+					map[string]any{"updateDescription.updatedFields.status": map[string]any{"$exists": true}},
+					map[string]any{"updateDescription.removedFields": "status"},
+				},
+			}}},
+		},
+		{
+			"one key",
+			WatchPipeline(MatchDocumentKey(7)),
+			Pipeline{map[string]any{"$match": map[string]any{"documentKey._id": 7}}},
+		},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.pipeline, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.pipeline)
+		}
+	}
+}
+
+// TestWatchWithPipeline tests that Watch sends the built pipeline.
+func TestWatchWithPipeline(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	pipeline := WatchPipeline(OnlyOperations(OperationReplace))
+	if _, err := client.Database("testdb").Collection("users").Watch(context.Background(), pipeline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mock.calls[0].args[2]; !reflect.DeepEqual(got, pipeline) {
+		t.Errorf("expected %v, got %v", pipeline, got)
+	}
+}
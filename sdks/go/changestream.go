@@ -0,0 +1,845 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// ChangeStream represents a change stream for watching database changes.
+type ChangeStream struct {
+	rpcClient RPCClient
+	streamID  string
+	closed    bool
+	mu        sync.Mutex
+	current   *ChangeEvent
+	err       error
+	// resumeToken is the token of the last event or heartbeat seen.
+	resumeToken any
+	// reopen opens a new server-side stream resuming after token. It is
+	// nil for streams that cannot resume.
+	reopen   func(ctx context.Context, token any) (RPCClient, string, error)
+	onResume func(ChangeStreamResumeEvent) error
+	liveness *changeStreamLiveness
+	// compression is set for streams receiving compressed event batches,
+	// whose undelivered events are buffered in pending. Batches on
+	// namespace decompressing to more than maxBatchBytes are rejected.
+	compression   BatchCompression
+	pending       []map[string]any
+	pendingToken  any
+	namespace     string
+	maxBatchBytes int64
+	// leaks tracks the stream under leakID while it is open, with leak
+	// detection on.
+	leaks  *leakTracker
+	leakID uint64
+	// pollInterval, when positive, makes Next wait on clock between empty
+	// answers rather than return.
+	pollInterval time.Duration
+	clock        Clock
+	// strict rejects event fields the target struct does not have.
+	strict bool
+	// naming, numbers and decoder are the codec settings events are
+	// decoded with, as for a Cursor.
+	naming  NamingStrategy
+	numbers NumberDecoding
+	decoder DecoderConfig
+}
+
+// ChangeStreamOptions configures a Watch operation.
+type ChangeStreamOptions struct {
+	// ResumeAfter resumes the stream after the event with this resume token.
+	ResumeAfter any
+	// StartAfter is like ResumeAfter but can resume after an invalidate event.
+	StartAfter any
+	// StartAtOperationTime starts the stream at the first event at or after
+	// this cluster time, such as the operation time of an earlier write.
+	StartAtOperationTime *Timestamp
+	FullDocument         *FullDocumentMode
+	// FullDocumentBeforeChange requests pre-images. The collection must have
+	// changeStreamPreAndPostImages enabled for pre-images to be recorded.
+	FullDocumentBeforeChange *FullDocumentMode
+	BatchSize                *int32
+	// LivenessTimeout closes and resumes the stream when no event or server
+	// heartbeat arrives within this window. Zero disables liveness checks.
+	LivenessTimeout *time.Duration
+	// OnResume is called after the stream is resumed because of a liveness
+	// timeout or a resumable error. A panic in it fails the stream with a
+	// PanicError.
+	OnResume func(ChangeStreamResumeEvent)
+	// BatchCompression asks the server to send events in compressed batches
+	// that are decompressed and iterated locally, saving a round trip per
+	// event on busy streams. CompressionGzip is the supported codec; others
+	// need a decompressor registered with RegisterDecompressor, and Watch
+	// fails with ErrInvalidOption otherwise. A batch decompressing to more
+	// than ClientOptions.MaxResponseBytes, or DefaultMaxEventBatchBytes
+	// without it, fails with ErrResultTooLarge.
+	BatchCompression *BatchCompression
+	// MaxAwaitTime is how long the server waits for a new event before
+	// answering a request for the next one with none. Longer waits mean
+	// fewer empty round trips on quiet streams.
+	MaxAwaitTime *time.Duration
+	// PollInterval makes Next keep asking for the next event, pausing this
+	// long after each empty answer, until one arrives or its context is
+	// done. Zero makes Next return false after a single empty answer.
+	PollInterval *time.Duration
+}
+
+// SetResumeAfter sets the resume token to resume after.
+func (o *ChangeStreamOptions) SetResumeAfter(token any) *ChangeStreamOptions {
+	o.ResumeAfter = token
+	return o
+}
+
+// SetStartAfter sets the resume token to start after.
+func (o *ChangeStreamOptions) SetStartAfter(token any) *ChangeStreamOptions {
+	o.StartAfter = token
+	return o
+}
+
+// SetStartAtOperationTime sets the cluster time to start at.
+func (o *ChangeStreamOptions) SetStartAtOperationTime(ts Timestamp) *ChangeStreamOptions {
+	o.StartAtOperationTime = &ts
+	return o
+}
+
+// SetFullDocument sets the fullDocument mode.
+func (o *ChangeStreamOptions) SetFullDocument(mode FullDocumentMode) *ChangeStreamOptions {
+	o.FullDocument = &mode
+	return o
+}
+
+// SetFullDocumentBeforeChange sets the fullDocumentBeforeChange mode.
+func (o *ChangeStreamOptions) SetFullDocumentBeforeChange(mode FullDocumentMode) *ChangeStreamOptions {
+	o.FullDocumentBeforeChange = &mode
+	return o
+}
+
+// SetBatchSize sets the number of events fetched per batch.
+func (o *ChangeStreamOptions) SetBatchSize(size int32) *ChangeStreamOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetLivenessTimeout sets the window after which a silent stream is resumed.
+func (o *ChangeStreamOptions) SetLivenessTimeout(d time.Duration) *ChangeStreamOptions {
+	o.LivenessTimeout = &d
+	return o
+}
+
+// SetOnResume sets the callback notified when the stream is resumed.
+func (o *ChangeStreamOptions) SetOnResume(fn func(ChangeStreamResumeEvent)) *ChangeStreamOptions {
+	o.OnResume = fn
+	return o
+}
+
+// SetBatchCompression sets the compression of event batches. Codecs other
+// than CompressionGzip need a decompressor registered with
+// RegisterDecompressor.
+func (o *ChangeStreamOptions) SetBatchCompression(c BatchCompression) *ChangeStreamOptions {
+	o.BatchCompression = &c
+	return o
+}
+
+// SetMaxAwaitTime sets how long the server waits for a new event.
+func (o *ChangeStreamOptions) SetMaxAwaitTime(d time.Duration) *ChangeStreamOptions {
+	o.MaxAwaitTime = &d
+	return o
+}
+
+// SetPollInterval sets the pause between empty answers while Next waits
+// for an event.
+func (o *ChangeStreamOptions) SetPollInterval(d time.Duration) *ChangeStreamOptions {
+	o.PollInterval = &d
+	return o
+}
+
+// changeStreamOptions merges and validates change stream options into an
+// options map.
+func changeStreamOptions(opts ...*ChangeStreamOptions) (map[string]any, error) {
+	options := make(map[string]any)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.ResumeAfter != nil {
+			options["resumeAfter"] = opt.ResumeAfter
+		}
+		if opt.StartAfter != nil {
+			options["startAfter"] = opt.StartAfter
+		}
+		if opt.StartAtOperationTime != nil {
+			options["startAtOperationTime"] = *opt.StartAtOperationTime
+		}
+		if opt.FullDocument != nil {
+			if err := opt.FullDocument.validateFullDocument(); err != nil {
+				return nil, err
+			}
+			options["fullDocument"] = string(*opt.FullDocument)
+		}
+		if opt.FullDocumentBeforeChange != nil {
+			if err := opt.FullDocumentBeforeChange.validateBeforeChange(); err != nil {
+				return nil, err
+			}
+			options["fullDocumentBeforeChange"] = string(*opt.FullDocumentBeforeChange)
+		}
+		if opt.BatchSize != nil {
+			options["batchSize"] = *opt.BatchSize
+		}
+		if opt.BatchCompression != nil {
+			if err := opt.BatchCompression.validate(); err != nil {
+				return nil, err
+			}
+			options["batchCompression"] = string(*opt.BatchCompression)
+		}
+		if opt.MaxAwaitTime != nil {
+			if *opt.MaxAwaitTime < 0 {
+				return nil, fmt.Errorf("%w: maxAwaitTime %v is negative", ErrInvalidOption, *opt.MaxAwaitTime)
+			}
+			options["maxAwaitTimeMS"] = opt.MaxAwaitTime.Milliseconds()
+		}
+	}
+	return options, nil
+}
+
+// ChangeNamespace identifies the database and collection of a change event.
+type ChangeNamespace struct {
+	DB   string `json:"db"`
+	Coll string `json:"coll"`
+}
+
+// Timestamp is a server logical timestamp, as used for cluster and
+// operation times. It is bson.Timestamp, so it encodes as a BSON timestamp.
+type Timestamp = bson.Timestamp
+
+// TruncatedArray records an array field that was shortened by an update.
+type TruncatedArray struct {
+	Field   string `json:"field"`
+	NewSize int32  `json:"newSize"`
+}
+
+// UpdateDescription describes the fields changed by an update operation.
+type UpdateDescription struct {
+	UpdatedFields   map[string]any   `json:"updatedFields"`
+	RemovedFields   []string         `json:"removedFields"`
+	TruncatedArrays []TruncatedArray `json:"truncatedArrays"`
+	// DisambiguatedPaths maps ambiguous dotted paths in UpdatedFields and
+	// RemovedFields to their path components, where numeric components that
+	// are array indexes are given as int and field names as string.
+	DisambiguatedPaths map[string][]any `json:"disambiguatedPaths"`
+}
+
+// IsEmpty reports whether the description contains no changes.
+func (u *UpdateDescription) IsEmpty() bool {
+	return len(u.UpdatedFields) == 0 && len(u.RemovedFields) == 0 && len(u.TruncatedArrays) == 0
+}
+
+// Apply applies the described update to doc in place: arrays are truncated,
+// removed fields are deleted, and updated fields are set. Paths are split on
+// dots unless DisambiguatedPaths gives their components.
+func (u *UpdateDescription) Apply(doc map[string]any) error {
+	for _, ta := range u.TruncatedArrays {
+		parent, last, err := u.resolve(doc, ta.Field, false)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			continue
+		}
+		arr, ok := getPath(parent, last).([]any)
+		if !ok || int(ta.NewSize) > len(arr) {
+			return fmt.Errorf("cannot truncate %s to %d", ta.Field, ta.NewSize)
+		}
+		setPath(parent, last, arr[:ta.NewSize])
+	}
+
+	for _, field := range u.RemovedFields {
+		parent, last, err := u.resolve(doc, field, false)
+		if err != nil {
+			return err
+		}
+		if m, ok := parent.(map[string]any); ok {
+			if key, ok := last.(string); ok {
+				delete(m, key)
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(u.UpdatedFields))
+	for field := range u.UpdatedFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		parent, last, err := u.resolve(doc, field, true)
+		if err != nil {
+			return err
+		}
+		if err := setPath(parent, last, u.UpdatedFields[field]); err != nil {
+			return fmt.Errorf("cannot set %s: %w", field, err)
+		}
+	}
+
+	return nil
+}
+
+// components splits a path into its components.
+func (u *UpdateDescription) components(path string) []any {
+	if parts, ok := u.DisambiguatedPaths[path]; ok {
+		return parts
+	}
+	split := strings.Split(path, ".")
+	parts := make([]any, len(split))
+	for i, p := range split {
+		parts[i] = p
+	}
+	return parts
+}
+
+// resolve walks doc to the container holding the last component of path.
+// With create set, missing intermediate documents are created; otherwise a
+// missing intermediate yields a nil parent.
+func (u *UpdateDescription) resolve(doc map[string]any, path string, create bool) (any, any, error) {
+	parts := u.components(path)
+	if len(parts) == 0 {
+		return nil, nil, fmt.Errorf("empty path")
+	}
+
+	var current any = doc
+	for _, part := range parts[:len(parts)-1] {
+		next := getPath(current, part)
+		if next == nil {
+			if !create {
+				return nil, nil, nil
+			}
+			next = map[string]any{}
+			if err := setPath(current, part, next); err != nil {
+				return nil, nil, fmt.Errorf("cannot set %s: %w", path, err)
+			}
+		}
+		current = next
+	}
+	return current, parts[len(parts)-1], nil
+}
+
+// getPath returns the value of a single path component within container.
+// String components that are numeric index into arrays.
+func getPath(container any, part any) any {
+	switch c := container.(type) {
+	case map[string]any:
+		key, ok := part.(string)
+		if !ok {
+			key = fmt.Sprint(part)
+		}
+		return c[key]
+	case []any:
+		i, ok := arrayIndex(part)
+		if !ok || i < 0 || i >= len(c) {
+			return nil
+		}
+		return c[i]
+	}
+	return nil
+}
+
+// setPath sets a single path component within container. Arrays are only
+// written in place, so the index must be within bounds.
+func setPath(container any, part any, value any) error {
+	switch c := container.(type) {
+	case map[string]any:
+		key, ok := part.(string)
+		if !ok {
+			key = fmt.Sprint(part)
+		}
+		c[key] = value
+		return nil
+	case []any:
+		i, ok := arrayIndex(part)
+		if !ok || i < 0 || i >= len(c) {
+			return fmt.Errorf("array index %v out of range", part)
+		}
+		c[i] = value
+		return nil
+	}
+	return fmt.Errorf("cannot traverse %T", container)
+}
+
+// arrayIndex converts a path component to an array index.
+func arrayIndex(part any) (int, bool) {
+	if s, ok := part.(string); ok {
+		i, err := strconv.Atoi(s)
+		return i, err == nil
+	}
+	n, ok := asInt64(part)
+	return int(n), ok
+}
+
+// ChangeEvent represents a change event from a change stream.
+type ChangeEvent struct {
+	ID                any    `json:"_id"`
+	OperationType     string `json:"operationType"`
+	FullDocument      any    `json:"fullDocument"`
+	// FullDocumentBeforeChange is the pre-image of the document, present
+	// when the stream was opened with FullDocumentBeforeChange.
+	FullDocumentBeforeChange any               `json:"fullDocumentBeforeChange,omitempty"`
+	Ns                       ChangeNamespace   `json:"ns"`
+	DocumentKey              any               `json:"documentKey"`
+	UpdateDescription        UpdateDescription `json:"updateDescription"`
+	// To is the new namespace of a rename event.
+	To          *ChangeNamespace `json:"to,omitempty"`
+	ClusterTime Timestamp        `json:"clusterTime"`
+	WallTime    time.Time        `json:"wallTime"`
+	// TxnNumber and LSID are set for events produced inside a transaction.
+	TxnNumber *int64         `json:"txnNumber,omitempty"`
+	LSID      map[string]any `json:"lsid,omitempty"`
+	// Raw is the event document as received.
+	Raw map[string]any `json:"-"`
+
+	// naming, numbers, decoder and strict are the codec settings of the
+	// stream the event came from, which DecodeDocument applies.
+	naming  NamingStrategy
+	numbers NumberDecoding
+	decoder DecoderConfig
+	strict  bool
+}
+
+// ResumeToken returns the token to resume the stream after this event.
+func (e *ChangeEvent) ResumeToken() any {
+	return e.ID
+}
+
+// DecodeDocument decodes the full document of the event into val, honoring
+// `bson` and `json` struct tags and the codec settings of the client or
+// collection the stream was opened on. It returns ErrNoDocuments when the
+// event carries no full document.
+func (e *ChangeEvent) DecodeDocument(val any, opts ...*DecodeOptions) error {
+	if e.FullDocument == nil {
+		return ErrNoDocuments
+	}
+	data, err := json.Marshal(e.FullDocument)
+	if err != nil {
+		return err
+	}
+	return unmarshalDocument(data, val, e.naming, e.numbers, e.decoder, strictDecoding(e.strict, opts...))
+}
+
+// DocumentID returns the _id of the changed document, or nil if the event
+// does not refer to a single document.
+func (e *ChangeEvent) DocumentID() any {
+	if key, ok := e.DocumentKey.(map[string]any); ok {
+		return key["_id"]
+	}
+	return nil
+}
+
+// IsUpdate reports whether the event carries an update description.
+func (e *ChangeEvent) IsUpdate() bool {
+	return e.OperationType == "update"
+}
+
+// Document returns the full document as a map, or nil if it was not included.
+func (e *ChangeEvent) Document() map[string]any {
+	doc, _ := e.FullDocument.(map[string]any)
+	return doc
+}
+
+// DocumentBeforeChange returns the pre-image as a map, or nil if it was not
+// included.
+func (e *ChangeEvent) DocumentBeforeChange() map[string]any {
+	doc, _ := e.FullDocumentBeforeChange.(map[string]any)
+	return doc
+}
+
+// parseEvent converts a raw change event document into a ChangeEvent that
+// decodes with the stream's codec settings.
+func (cs *ChangeStream) parseEvent(event map[string]any) (*ChangeEvent, error) {
+	ce, err := parseChangeEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	ce.naming = cs.naming
+	ce.numbers = cs.numbers
+	ce.decoder = cs.decoder
+	ce.strict = cs.strict
+	return ce, nil
+}
+
+// parseChangeEvent converts a raw change event document into a ChangeEvent.
+func parseChangeEvent(event map[string]any) (*ChangeEvent, error) {
+	operationType, ok := event["operationType"].(string)
+	if !ok {
+		return nil, fmt.Errorf("change event missing operationType")
+	}
+
+	ce := &ChangeEvent{
+		ID:            event["_id"],
+		OperationType: operationType,
+		FullDocument:  event["fullDocument"],
+		DocumentKey:   event["documentKey"],
+		LSID:          asDocument(event["lsid"]),
+		Raw:           event,
+	}
+	ce.FullDocumentBeforeChange = event["fullDocumentBeforeChange"]
+
+	if ns, ok := event["ns"].(map[string]any); ok {
+		ce.Ns = parseChangeNamespace(ns)
+	}
+	if to, ok := event["to"].(map[string]any); ok {
+		ns := parseChangeNamespace(to)
+		ce.To = &ns
+	}
+	if ud, ok := event["updateDescription"].(map[string]any); ok {
+		desc, err := parseUpdateDescription(ud)
+		if err != nil {
+			return nil, err
+		}
+		ce.UpdateDescription = desc
+	}
+	if raw, ok := event["clusterTime"]; ok && raw != nil {
+		ts, err := parseTimestamp(raw)
+		if err != nil {
+			return nil, fmt.Errorf("clusterTime: %w", err)
+		}
+		ce.ClusterTime = ts
+	}
+	if raw, ok := event["wallTime"]; ok && raw != nil {
+		wt, err := parseDateTime(raw)
+		if err != nil {
+			return nil, fmt.Errorf("wallTime: %w", err)
+		}
+		ce.WallTime = wt
+	}
+	if n, ok := asInt64(normalizeID(event["txnNumber"])); ok {
+		ce.TxnNumber = &n
+	}
+
+	return ce, nil
+}
+
+// parseChangeNamespace converts an ns or to document.
+func parseChangeNamespace(doc map[string]any) ChangeNamespace {
+	var ns ChangeNamespace
+	ns.DB, _ = doc["db"].(string)
+	ns.Coll, _ = doc["coll"].(string)
+	return ns
+}
+
+// parseUpdateDescription converts an updateDescription document.
+func parseUpdateDescription(doc map[string]any) (UpdateDescription, error) {
+	var desc UpdateDescription
+
+	desc.UpdatedFields = asDocument(doc["updatedFields"])
+
+	if removed, ok := doc["removedFields"].([]any); ok {
+		desc.RemovedFields = make([]string, 0, len(removed))
+		for _, f := range removed {
+			field, ok := f.(string)
+			if !ok {
+				return desc, fmt.Errorf("invalid removed field type: %T", f)
+			}
+			desc.RemovedFields = append(desc.RemovedFields, field)
+		}
+	}
+
+	if truncated, ok := doc["truncatedArrays"].([]any); ok {
+		desc.TruncatedArrays = make([]TruncatedArray, 0, len(truncated))
+		for _, t := range truncated {
+			entry, ok := t.(map[string]any)
+			if !ok {
+				return desc, fmt.Errorf("invalid truncated array type: %T", t)
+			}
+			field, _ := entry["field"].(string)
+			size, ok := asInt64(normalizeID(entry["newSize"]))
+			if field == "" || !ok {
+				return desc, fmt.Errorf("invalid truncated array entry: %v", entry)
+			}
+			desc.TruncatedArrays = append(desc.TruncatedArrays, TruncatedArray{Field: field, NewSize: int32(size)})
+		}
+	}
+
+	if paths, ok := doc["disambiguatedPaths"].(map[string]any); ok {
+		desc.DisambiguatedPaths = make(map[string][]any, len(paths))
+		for path, raw := range paths {
+			parts, ok := raw.([]any)
+			if !ok {
+				return desc, fmt.Errorf("invalid disambiguated path for %s: %T", path, raw)
+			}
+			components := make([]any, len(parts))
+			for i, p := range parts {
+				if n, ok := asInt64(normalizeID(p)); ok {
+					components[i] = int(n)
+				} else {
+					components[i] = p
+				}
+			}
+			desc.DisambiguatedPaths[path] = components
+		}
+	}
+
+	return desc, nil
+}
+
+// parseTimestamp converts {$timestamp: {t, i}} or {t, i} into a Timestamp.
+func parseTimestamp(raw any) (Timestamp, error) {
+	doc, ok := raw.(map[string]any)
+	if !ok {
+		return Timestamp{}, fmt.Errorf("unexpected timestamp type: %T", raw)
+	}
+	if inner, ok := doc["$timestamp"].(map[string]any); ok {
+		doc = inner
+	}
+	t, tok := asInt64(doc["t"])
+	i, iok := asInt64(doc["i"])
+	if !tok || !iok {
+		return Timestamp{}, fmt.Errorf("invalid timestamp: %v", raw)
+	}
+	return Timestamp{T: uint32(t), I: uint32(i)}, nil
+}
+
+// parseDateTime converts an RFC 3339 string, milliseconds since the epoch,
+// or an extended JSON {$date: ...} value into a time.
+func parseDateTime(raw any) (time.Time, error) {
+	if doc, ok := raw.(map[string]any); ok {
+		inner, ok := doc["$date"]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid date: %v", raw)
+		}
+		raw = normalizeID(inner)
+	}
+	switch v := raw.(type) {
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	case time.Time:
+		return v, nil
+	case bson.DateTime:
+		return v.Time(), nil
+	}
+	if ms, ok := asInt64(raw); ok {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unexpected date type: %T", raw)
+}
+
+// asDocument returns v as a document, or nil if it is not one.
+func asDocument(v any) map[string]any {
+	doc, _ := v.(map[string]any)
+	return doc
+}
+
+// newChangeStream creates a new change stream.
+func newChangeStream(rpcClient RPCClient, streamID string) *ChangeStream {
+	return &ChangeStream{
+		rpcClient: rpcClient,
+		streamID:  streamID,
+	}
+}
+
+// Next advances to the next change event. It returns false when no event
+// is available, unless the stream has a PollInterval, in which case it waits
+// for an event until ctx is done or the stream is closed. The stream is not
+// locked between polls, so Close can be called from another goroutine.
+func (cs *ChangeStream) Next(ctx context.Context) bool {
+	if cs.pollInterval <= 0 {
+		return cs.tryNext(ctx)
+	}
+	// Polling stops at the first error, so Err reports the latest call's.
+	cs.mu.Lock()
+	cs.err = nil
+	cs.mu.Unlock()
+	for {
+		cs.mu.Lock()
+		ok, failed := cs.nextOnce(ctx), cs.err != nil
+		cs.mu.Unlock()
+		if ok || failed {
+			return ok
+		}
+		select {
+		case <-cs.clock.After(cs.pollInterval):
+		case <-ctx.Done():
+			cs.mu.Lock()
+			cs.err = ctx.Err()
+			cs.mu.Unlock()
+			return false
+		}
+	}
+}
+
+// tryNext makes a single attempt to advance to the next event, whatever the
+// stream's PollInterval.
+func (cs *ChangeStream) tryNext(ctx context.Context) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.nextOnce(ctx)
+}
+
+// nextOnce makes a single attempt to advance to the next event. The caller
+// holds cs.mu.
+func (cs *ChangeStream) nextOnce(ctx context.Context) bool {
+	if cs.closed {
+		cs.err = ErrCursorClosed
+		return false
+	}
+
+	// Check context
+	select {
+	case <-ctx.Done():
+		cs.err = ctx.Err()
+		return false
+	default:
+	}
+
+	if len(cs.pending) > 0 {
+		return cs.nextPending()
+	}
+
+	// Resumable errors, such as a network error or a primary stepping
+	// down, reopen the stream after the last resume token; only fatal
+	// errors reach the caller.
+	for resumes := 0; ; resumes++ {
+		ok, err := cs.fetchNext(ctx)
+		if err == nil {
+			return ok
+		}
+		if resumes == maxChangeStreamResumes || cs.reopen == nil || !IsResumableChangeStreamError(err) {
+			cs.err = err
+			return false
+		}
+		if !cs.resume(ctx, err) {
+			return false
+		}
+	}
+}
+
+// fetchNext requests the next event or batch of events, returning the
+// error of a failed request.
+func (cs *ChangeStream) fetchNext(ctx context.Context) (bool, error) {
+	if cs.liveness != nil {
+		return cs.nextWithLiveness(ctx)
+	}
+	result, err := awaitContext(ctx, callContext(ctx, cs.rpcClient, cs.nextMethod(), cs.streamID))
+	if err == nil {
+		err = serverError(result)
+	}
+	if err != nil {
+		return false, err
+	}
+	return cs.advance(result), nil
+}
+
+// nextMethod returns the RPC method fetching the stream's next event or
+// batch of events.
+func (cs *ChangeStream) nextMethod() string {
+	if cs.compression != "" {
+		return "mongo.changeStreamNextBatch"
+	}
+	return "mongo.changeStreamNext"
+}
+
+// advance handles a mongo.changeStreamNext result, making an event current
+// and recording resume tokens from events and heartbeats.
+func (cs *ChangeStream) advance(result any) bool {
+	if result == nil {
+		return false
+	}
+	if cs.compression != "" {
+		return cs.advanceBatch(result)
+	}
+
+	event, ok := result.(map[string]any)
+	if !ok {
+		cs.err = unexpectedResponse("mongo.changeStreamNext", result)
+		return false
+	}
+
+	if token, ok := heartbeatToken(event); ok {
+		if token != nil {
+			cs.resumeToken = token
+		}
+		if cs.liveness != nil {
+			cs.liveness.lastSeen = cs.liveness.clock.Now()
+		}
+		return false
+	}
+
+	current, err := cs.parseEvent(event)
+	if err != nil {
+		cs.err = err
+		return false
+	}
+	cs.current = current
+	cs.resumeToken = current.ID
+	if cs.liveness != nil {
+		cs.liveness.lastSeen = cs.liveness.clock.Now()
+	}
+	return true
+}
+
+// Decode decodes the current change event. val may be a *ChangeEvent or a
+// pointer to a struct, which receives the raw event document following its
+// `bson` or `json` tags. opts override the strict decoding defaults of the
+// client and collection.
+func (cs *ChangeStream) Decode(val any, opts ...*DecodeOptions) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.current == nil {
+		return ErrNoDocuments
+	}
+
+	// Type assert to *ChangeEvent
+	if ce, ok := val.(*ChangeEvent); ok {
+		*ce = *cs.current
+		return nil
+	}
+
+	// Structs receive the raw event document, following their tags.
+	if t := reflect.TypeOf(val); t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		data, err := json.Marshal(cs.current.Raw)
+		if err != nil {
+			return err
+		}
+		return unmarshalDocument(data, val, cs.naming, cs.numbers, cs.decoder, strictDecoding(cs.strict, opts...))
+	}
+
+	return fmt.Errorf("cannot decode into %T", val)
+}
+
+// Current returns the current change event.
+func (cs *ChangeStream) Current() *ChangeEvent {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.current
+}
+
+// Err returns any error from the change stream.
+func (cs *ChangeStream) Err() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.err
+}
+
+// Close closes the change stream.
+func (cs *ChangeStream) Close(ctx context.Context) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.closed {
+		return nil
+	}
+
+	cs.closed = true
+	cs.untrack()
+
+	// Notify server to close the stream
+	promise := cs.rpcClient.Call("mongo.changeStreamClose", cs.streamID)
+	_, err := promise.Await()
+	return err
+}
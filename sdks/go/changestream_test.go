@@ -0,0 +1,560 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestChangeStreamNextFullEvent tests parsing every field of an update event.
+func TestChangeStreamNextFullEvent(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           map[string]any{"_data": "826A"},
+		"operationType": "update",
+		"ns":            map[string]any{"db": "testdb", "coll": "users"},
+		"documentKey":   map[string]any{"_id": "u1"},
+		"updateDescription": map[string]any{
+			"updatedFields":      map[string]any{"name": "Jane", "tags.0": "a"},
+			"removedFields":      []any{"age"},
+			"truncatedArrays":    []any{map[string]any{"field": "scores", "newSize": float64(2)}},
+			"disambiguatedPaths": map[string]any{"tags.0": []any{"tags", float64(0)}},
+		},
+		"clusterTime": map[string]any{"$timestamp": map[string]any{"t": float64(1700000000), "i": float64(3)}},
+		"wallTime":    map[string]any{"$date": "2024-01-02T03:04:05.006Z"},
+		"txnNumber":   map[string]any{"$numberLong": "7"},
+		"lsid":        map[string]any{"id": "session"},
+	}, nil)
+
+	stream := newChangeStream(mock, "stream-123")
+	if !stream.Next(context.Background()) {
+		t.Fatalf("expected event, got error %v", stream.Err())
+	}
+
+	event := stream.Current()
+	if !event.IsUpdate() || event.DocumentID() != "u1" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.ResumeToken().(map[string]any)["_data"] != "826A" {
+		t.Errorf("unexpected resume token: %v", event.ResumeToken())
+	}
+
+	desc := event.UpdateDescription
+	if desc.UpdatedFields["name"] != "Jane" || !reflect.DeepEqual(desc.RemovedFields, []string{"age"}) {
+		t.Errorf("unexpected update description: %+v", desc)
+	}
+	if len(desc.TruncatedArrays) != 1 || desc.TruncatedArrays[0] != (TruncatedArray{Field: "scores", NewSize: 2}) {
+		t.Errorf("unexpected truncated arrays: %v", desc.TruncatedArrays)
+	}
+	if !reflect.DeepEqual(desc.DisambiguatedPaths["tags.0"], []any{"tags", 0}) {
+		t.Errorf("unexpected disambiguated paths: %v", desc.DisambiguatedPaths)
+	}
+
+	if event.ClusterTime != (Timestamp{T: 1700000000, I: 3}) {
+		t.Errorf("unexpected cluster time: %v", event.ClusterTime)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	if !event.WallTime.Equal(want) {
+		t.Errorf("expected wall time %v, got %v", want, event.WallTime)
+	}
+	if event.TxnNumber == nil || *event.TxnNumber != 7 || event.LSID["id"] != "session" {
+		t.Errorf("unexpected transaction info: %v %v", event.TxnNumber, event.LSID)
+	}
+	if event.Raw["operationType"] != "update" {
+		t.Errorf("expected raw event, got %v", event.Raw)
+	}
+}
+
+// TestChangeStreamNextRename tests parsing the target namespace of a rename.
+func TestChangeStreamNextRename(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           "change-1",
+		"operationType": "rename",
+		"ns":            map[string]any{"db": "testdb", "coll": "old"},
+		"to":            map[string]any{"db": "testdb", "coll": "new"},
+		"wallTime":      float64(1700000000000),
+	}, nil)
+
+	stream := newChangeStream(mock, "stream-123")
+	if !stream.Next(context.Background()) {
+		t.Fatalf("expected event, got error %v", stream.Err())
+	}
+
+	event := stream.Current()
+	if event.To == nil || event.To.Coll != "new" || event.Ns.Coll != "old" {
+		t.Errorf("unexpected namespaces: %v %v", event.Ns, event.To)
+	}
+	if event.WallTime.UnixMilli() != 1700000000000 {
+		t.Errorf("unexpected wall time: %v", event.WallTime)
+	}
+	if event.DocumentID() != nil || !event.UpdateDescription.IsEmpty() {
+		t.Errorf("unexpected document fields: %+v", event)
+	}
+}
+
+// TestChangeStreamNextInvalidEvent tests malformed change events.
+func TestChangeStreamNextInvalidEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event any
+	}{
+		{"not a document", "event"},
+		{"missing operationType", map[string]any{"_id": "x"}},
+		{"bad clusterTime", map[string]any{"operationType": "insert", "clusterTime": "now"}},
+		{"bad wallTime", map[string]any{"operationType": "insert", "wallTime": true}},
+		{"bad removedFields", map[string]any{"operationType": "update", "updateDescription": map[string]any{"removedFields": []any{1}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockRPCClient()
+			mock.addCall("mongo.changeStreamNext", tt.event, nil)
+
+			stream := newChangeStream(mock, "stream-123")
+			if stream.Next(context.Background()) {
+				t.Fatal("expected Next to return false")
+			}
+			if stream.Err() == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestUpdateDescriptionApply tests applying an update description to a document.
+func TestUpdateDescriptionApply(t *testing.T) {
+	doc := map[string]any{
+		"name":   "John",
+		"age":    30,
+		"scores": []any{1, 2, 3, 4},
+		"tags":   []any{"x", "y"},
+		"a.b":    "literal",
+	}
+
+	desc := UpdateDescription{
+		UpdatedFields: map[string]any{
+			"name":         "Jane",
+			"tags.1":       "z",
+			"address.city": "Paris",
+			"a.b":          "changed",
+			"scores.1":     20,
+		},
+		RemovedFields:      []string{"age"},
+		TruncatedArrays:    []TruncatedArray{{Field: "scores", NewSize: 2}},
+		DisambiguatedPaths: map[string][]any{"a.b": {"a.b"}},
+	}
+
+	if err := desc.Apply(doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"name":    "Jane",
+		"scores":  []any{1, 20},
+		"tags":    []any{"x", "z"},
+		"address": map[string]any{"city": "Paris"},
+		"a.b":     "changed",
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("expected %v, got %v", want, doc)
+	}
+}
+
+// TestUpdateDescriptionApplyErrors tests updates that do not fit the document.
+func TestUpdateDescriptionApplyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		desc UpdateDescription
+	}{
+		{"index out of range", UpdateDescription{UpdatedFields: map[string]any{"tags.5": "a"}}},
+		{"truncate grows", UpdateDescription{TruncatedArrays: []TruncatedArray{{Field: "tags", NewSize: 5}}}},
+		{"traverse scalar", UpdateDescription{UpdatedFields: map[string]any{"name.first": "a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := map[string]any{"name": "John", "tags": []any{"a"}}
+			if err := tt.desc.Apply(doc); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestTimestampBefore tests timestamp ordering.
+func TestTimestampBefore(t *testing.T) {
+	a := Timestamp{T: 10, I: 1}
+	b := Timestamp{T: 10, I: 2}
+	c := Timestamp{T: 11, I: 0}
+
+	if !a.Before(b) || !b.Before(c) || c.Before(a) || a.Before(a) {
+		t.Error("unexpected ordering")
+	}
+	if a.IsZero() || !(Timestamp{}).IsZero() {
+		t.Error("unexpected IsZero")
+	}
+}
+
+// TestWatchStartAtOperationTime tests starting a stream at a cluster time.
+func TestWatchStartAtOperationTime(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	start := Timestamp{T: 1700000000, I: 3}
+	if _, err := coll.Watch(context.Background(), []any{}, (&ChangeStreamOptions{}).SetStartAtOperationTime(start)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["startAtOperationTime"] != start {
+		t.Errorf("unexpected watch options: %v", options)
+	}
+	data, err := json.Marshal(options)
+	if err != nil || string(data) != `{"startAtOperationTime":{"$timestamp":{"t":1700000000,"i":3}}}` {
+		t.Errorf("unexpected encoding %s (%v)", data, err)
+	}
+}
+
+// TestWatchFullDocumentBeforeChange tests requesting and reading pre-images.
+func TestWatchFullDocumentBeforeChange(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":                      "change-1",
+		"operationType":            "update",
+		"documentKey":              map[string]any{"_id": "u1"},
+		"fullDocument":             map[string]any{"_id": "u1", "status": "active"},
+		"fullDocumentBeforeChange": map[string]any{"_id": "u1", "status": "pending"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	opts := (&ChangeStreamOptions{}).SetFullDocument("whenAvailable").SetFullDocumentBeforeChange("required")
+	stream, err := coll.Watch(ctx, []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["fullDocumentBeforeChange"] != "required" || options["fullDocument"] != "whenAvailable" {
+		t.Errorf("unexpected watch options: %v", options)
+	}
+
+	if !stream.Next(ctx) {
+		t.Fatalf("expected event, got error %v", stream.Err())
+	}
+	event := stream.Current()
+	if event.DocumentBeforeChange()["status"] != "pending" || event.Document()["status"] != "active" {
+		t.Errorf("unexpected images: before %v after %v", event.FullDocumentBeforeChange, event.FullDocument)
+	}
+}
+
+// TestWatchWithoutPreImage tests events without a pre-image.
+func TestWatchWithoutPreImage(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"_id": "change-1", "operationType": "insert"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	ctx := context.Background()
+
+	stream, err := client.Database("testdb").Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.calls[0].args) != 3 {
+		t.Errorf("expected no watch options, got %v", mock.calls[0].args)
+	}
+
+	stream.Next(ctx)
+	if stream.Current().DocumentBeforeChange() != nil {
+		t.Error("expected no pre-image")
+	}
+}
+
+// TestChangeStreamResumesOnResumableError tests that Next resumes after a
+// resumable error without surfacing it.
+func TestChangeStreamResumesOnResumableError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"_id": "token-1", "operationType": "insert"}, nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"ok": float64(0), "code": float64(91), "codeName": "ShutdownInProgress",
+		"errorLabels": []any{"ResumableChangeStreamError"},
+	}, nil)
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+	mock.addCall("mongo.watch", "stream-2", nil)
+	mock.addCall("mongo.changeStreamNext", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+	mock.addCall("mongo.watch", "stream-3", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"_id": "token-2", "operationType": "update"}, nil)
+
+	var resumes []ChangeStreamResumeEvent
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	opts := (&ChangeStreamOptions{}).SetOnResume(func(e ChangeStreamResumeEvent) { resumes = append(resumes, e) })
+	stream, err := client.Database("testdb").Collection("users").Watch(context.Background(), []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	if !stream.Next(ctx) || !stream.Next(ctx) {
+		t.Fatalf("expected two events, got error %v", stream.Err())
+	}
+	if stream.Current().ID != "token-2" || stream.Err() != nil {
+		t.Errorf("unexpected event %v, error %v", stream.Current(), stream.Err())
+	}
+	if mock.callIndex != 9 {
+		t.Errorf("expected 9 calls, got %d", mock.callIndex)
+	}
+	for _, i := range []int{4, 7} {
+		options := mock.calls[i].args[3].(map[string]any)
+		if options["resumeAfter"] != "token-1" {
+			t.Errorf("call %d: expected to resume after token-1, got %v", i, options)
+		}
+	}
+	if len(resumes) != 2 || resumes[0].OldStreamID != "stream-1" || resumes[1].NewStreamID != "stream-3" {
+		t.Fatalf("unexpected resume events: %+v", resumes)
+	}
+	if !IsResumableChangeStreamError(resumes[0].Cause) || !IsNetworkError(resumes[1].Cause) {
+		t.Errorf("unexpected causes: %v, %v", resumes[0].Cause, resumes[1].Cause)
+	}
+}
+
+// TestChangeStreamFatalError tests that fatal errors end the stream and
+// that repeated resumable errors give up.
+func TestChangeStreamFatalError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"ok": float64(0), "code": float64(280), "codeName": "ChangeStreamFatalError",
+		"errorLabels": []any{"NonResumableChangeStreamError"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	ctx := context.Background()
+	stream, err := client.Database("testdb").Collection("users").Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stream.Next(ctx) {
+		t.Fatal("expected no event")
+	}
+	var cmdErr *CommandError
+	if !errors.As(stream.Err(), &cmdErr) || cmdErr.Code != 280 || mock.callIndex != 2 {
+		t.Errorf("expected the fatal error without resuming, got %v after %d calls", stream.Err(), mock.callIndex)
+	}
+
+	mock = newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	for i := 0; i <= maxChangeStreamResumes; i++ {
+		mock.addCall("mongo.changeStreamNext", nil, &ConnectionError{Address: "localhost"})
+		if i < maxChangeStreamResumes {
+			mock.addCall("mongo.changeStreamClose", nil, nil)
+			mock.addCall("mongo.watch", "stream-n", nil)
+		}
+	}
+	client = newClientWithRPC(mock, "mongodb://localhost:27017")
+	stream, err = client.Database("testdb").Collection("users").Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stream.Next(ctx) || !IsNetworkError(stream.Err()) {
+		t.Errorf("expected the network error after %d resumes, got %v", maxChangeStreamResumes, stream.Err())
+	}
+	if mock.callIndex != len(mock.calls) {
+		t.Errorf("expected %d calls, got %d", len(mock.calls), mock.callIndex)
+	}
+}
+
+// TestWatchMaxAwaitTime tests passing the server wait time.
+func TestWatchMaxAwaitTime(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	if _, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetMaxAwaitTime(1500*time.Millisecond)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options := mock.calls[0].args[3].(map[string]any); options["maxAwaitTimeMS"] != int64(1500) {
+		t.Errorf("unexpected watch options: %v", options)
+	}
+
+	if _, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetMaxAwaitTime(-time.Second)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+}
+
+// TestWatchPollInterval tests Next waiting for an event across empty
+// answers and heartbeats.
+func TestWatchPollInterval(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"postBatchResumeToken": "t1"}, nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"_id": "change-1", "operationType": "insert"}, nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	coll := client.Database("testdb").Collection("users")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cs, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetPollInterval(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan bool)
+	go func() { done <- cs.Next(ctx) }()
+	for polls := 0; polls < 2; polls++ {
+		waitTimers(t, clock, 1)
+		clock.Advance(time.Second)
+	}
+	if !<-done || cs.Current().ID != "change-1" {
+		t.Fatalf("expected the event after two polls, got %v", cs.Err())
+	}
+	if mock.callIndex != 4 {
+		t.Errorf("expected 3 changeStreamNext calls, got %d", mock.callIndex-1)
+	}
+
+	go func() { done <- cs.Next(ctx) }()
+	waitTimers(t, clock, 1)
+	cancel()
+	if <-done || !errors.Is(cs.Err(), context.Canceled) {
+		t.Errorf("expected polling to stop with the context, got %v", cs.Err())
+	}
+}
+
+// TestWatchPollIntervalClose tests closing a stream from another goroutine
+// while Next waits between polls.
+func TestWatchPollIntervalClose(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	cs, err := coll.Watch(ctx, []any{}, (&ChangeStreamOptions{}).SetPollInterval(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := make(chan bool)
+	go func() { done <- cs.Next(ctx) }()
+	waitTimers(t, clock, 1)
+
+	closed := make(chan error)
+	go func() { closed <- cs.Close(ctx) }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Close not to wait for Next")
+	}
+
+	clock.Advance(time.Second)
+	if <-done || !errors.Is(cs.Err(), ErrCursorClosed) {
+		t.Errorf("expected Next to stop on the closed stream, got %v", cs.Err())
+	}
+}
+
+// TestChangeStreamNextContext tests giving up on a request when ctx ends.
+func TestChangeStreamNextContext(t *testing.T) {
+	rpc := &hangingRPCClient{release: make(chan struct{})}
+	defer close(rpc.release)
+	cs := newChangeStream(rpc, "stream-1")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan bool)
+	go func() { done <- cs.Next(ctx) }()
+	// Give Next time to send the request that never completes.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case ok := <-done:
+		if ok || !errors.Is(cs.Err(), context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", cs.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Next to return when ctx is canceled")
+	}
+}
+
+// TestChangeStreamCodecSettings tests decoding events with the codec
+// settings of the collection the stream was opened on.
+func TestChangeStreamCodecSettings(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           "t1",
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"_id": "a", "first_name": "Ada", "login_count": float64(3)},
+		"documentKey":   map[string]any{"_id": "a"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll, err := client.Database("testdb").Collection("users").Clone((&CollectionOptions{}).
+		SetNamingStrategy(NamingSnakeCase).
+		SetNumberDecoding(NumberDecodingInt64WhenExact))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	cs, err := coll.Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cs.Next(ctx) {
+		t.Fatalf("expected an event, got %v", cs.Err())
+	}
+
+	type user struct {
+		ID        string `json:"_id"`
+		FirstName string
+	}
+	var u user
+	if err := cs.Current().DecodeDocument(&u); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.FirstName != "Ada" {
+		t.Errorf("expected the snake_case field decoded, got %+v", u)
+	}
+
+	var doc map[string]any
+	if err := cs.Current().DecodeDocument(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := doc["login_count"].(int64); !ok {
+		t.Errorf("expected an int64 count, got %T", doc["login_count"])
+	}
+
+	var event struct {
+		OperationType string `json:"operationType"`
+		FullDocument  user   `json:"fullDocument"`
+	}
+	if err := cs.Decode(&event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.OperationType != "insert" || event.FullDocument.FirstName != "Ada" {
+		t.Errorf("expected the raw event decoded by strategy, got %+v", event)
+	}
+}
@@ -0,0 +1,53 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+)
+
+// CheckpointStore persists change stream resume tokens so a subscriber can
+// resume where it left off after a restart.
+type CheckpointStore interface {
+	// Load returns the last saved resume token for key, or nil if none has
+	// been saved.
+	Load(ctx context.Context, key string) (any, error)
+	// Save records token as the resume point for key.
+	Save(ctx context.Context, key string, token any) error
+}
+
+// CollectionCheckpointStore is a CheckpointStore backed by a collection,
+// storing one document per key: {_id: key, token: <resume token>}.
+type CollectionCheckpointStore struct {
+	coll *Collection
+}
+
+// NewCollectionCheckpointStore creates a checkpoint store that keeps resume
+// tokens in coll.
+func NewCollectionCheckpointStore(coll *Collection) *CollectionCheckpointStore {
+	return &CollectionCheckpointStore{coll: coll}
+}
+
+// Load returns the saved resume token for key.
+func (s *CollectionCheckpointStore) Load(ctx context.Context, key string) (any, error) {
+	var doc map[string]any
+	err := s.coll.FindOne(ctx, map[string]any{"_id": key}).Decode(&doc)
+	if errors.Is(err, ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc["token"], nil
+}
+
+// Save upserts the resume token for key.
+func (s *CollectionCheckpointStore) Save(ctx context.Context, key string, token any) error {
+	update := map[string]any{
+		"$set": map[string]any{
+			"token":     token,
+			"updatedAt": s.coll.database.client.clock.Now(),
+		},
+	}
+	_, err := s.coll.UpdateOne(ctx, map[string]any{"_id": key}, update, (&UpdateOptions{}).SetUpsert(true))
+	return err
+}
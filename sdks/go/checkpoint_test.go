@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCollectionCheckpointStoreLoad tests loading a saved resume token.
+func TestCollectionCheckpointStoreLoad(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{"_id": "orders", "token": map[string]any{"_data": "826A"}}, nil)
+	mock.addCall("mongo.findOne", nil, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	store := NewCollectionCheckpointStore(client.Database("testdb").Collection("checkpoints"))
+	ctx := context.Background()
+
+	token, err := store.Load(ctx, "orders")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.(map[string]any)["_data"] != "826A" {
+		t.Errorf("unexpected token: %v", token)
+	}
+	if filter := mock.calls[0].args[2].(map[string]any); filter["_id"] != "orders" {
+		t.Errorf("unexpected filter: %v", filter)
+	}
+
+	token, err = store.Load(ctx, "missing")
+	if err != nil || token != nil {
+		t.Errorf("expected nil token, got %v, %v", token, err)
+	}
+}
+
+// TestCollectionCheckpointStoreLoadError tests a failing load.
+func TestCollectionCheckpointStoreLoadError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", nil, errors.New("boom"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	store := NewCollectionCheckpointStore(client.Database("testdb").Collection("checkpoints"))
+
+	if _, err := store.Load(context.Background(), "orders"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestCollectionCheckpointStoreSave tests upserting a resume token.
+func TestCollectionCheckpointStoreSave(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(0), "upsertedCount": float64(1)}, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	store := NewCollectionCheckpointStore(client.Database("testdb").Collection("checkpoints"))
+
+	if err := store.Save(context.Background(), "orders", "token-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := mock.calls[0].args
+	set := args[3].(map[string]any)["$set"].(map[string]any)
+	if set["token"] != "token-1" || !set["updatedAt"].(time.Time).Equal(clock.Now()) {
+		t.Errorf("unexpected update: %v", set)
+	}
+	if args[4].(map[string]any)["upsert"] != true {
+		t.Errorf("expected upsert, got %v", args[4])
+	}
+}
@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestCollectionChecksum tests paging through results and hashing them.
+func TestCollectionChecksum(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "a", "n": float64(1)},
+		map[string]any{"_id": "b", "n": float64(2)},
+	}, nil)
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "c", "n": float64(3)},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("items")
+	ctx := context.Background()
+
+	filter := map[string]any{"n": map[string]any{"$gt": 0}}
+	result, err := coll.Checksum(ctx, filter, (&ChecksumOptions{}).SetBatchSize(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Count != 3 || len(result.Sum) != 64 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if !reflect.DeepEqual(options["sort"], map[string]any{"_id": 1}) || options["limit"] != int64(2) {
+		t.Errorf("unexpected options: %v", options)
+	}
+	wantPage := map[string]any{"$and": []any{filter, map[string]any{"_id": map[string]any{"$gt": "b"}}}}
+	if !reflect.DeepEqual(mock.calls[1].args[2], wantPage) {
+		t.Errorf("unexpected second page filter: %v", mock.calls[1].args[2])
+	}
+
+	// The same documents with fields in another order hash identically.
+	other := newMockRPCClient()
+	other.addCall("mongo.find", []any{
+		map[string]any{"n": float64(1), "_id": "a"},
+		map[string]any{"n": float64(2), "_id": "b"},
+		map[string]any{"n": float64(3), "_id": "c"},
+	}, nil)
+	otherColl := newClientWithRPC(other, "mongodb://localhost:27017").Database("testdb").Collection("items")
+	otherResult, err := otherColl.Checksum(ctx, filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Equal(otherResult) {
+		t.Errorf("expected equal checksums, got %+v and %+v", result, otherResult)
+	}
+}
+
+// TestCollectionChecksumDiffers tests that different contents change the sum.
+func TestCollectionChecksumDiffers(t *testing.T) {
+	sums := make([]*ChecksumResult, 0, 2)
+	for _, n := range []float64{1, 2} {
+		mock := newMockRPCClient()
+		mock.addCall("mongo.find", []any{map[string]any{"_id": "a", "n": n}}, nil)
+		coll := newClientWithRPC(mock, "mongodb://localhost:27017").Database("testdb").Collection("items")
+
+		result, err := coll.Checksum(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sums = append(sums, result)
+	}
+	if sums[0].Equal(sums[1]) {
+		t.Errorf("expected different checksums, got %s for both", sums[0].Sum)
+	}
+
+	coll := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017").Database("testdb").Collection("items")
+	if _, err := coll.Checksum(context.Background(), nil, (&ChecksumOptions{}).SetBatchSize(0)); err == nil {
+		t.Error("expected error for non-positive batch size")
+	}
+}
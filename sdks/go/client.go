@@ -3,7 +3,9 @@ package mongo
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,6 +35,39 @@ type Client struct {
 	timeout      time.Duration
 	ctx          context.Context
 	cancel       context.CancelFunc
+
+	clock       Clock
+	idGenerator IDGenerator
+	metrics     *clientMetrics
+	retry       *RetryOptions
+	retryBudget *retryBudget
+	throttle    *adaptiveThrottle
+	limits      resultLimits
+	nsDefaults  map[string]NamespaceDefaults
+	reconnect   *reconnectState
+	naming      NamingStrategy
+	numbers     NumberDecoding
+	decoder     DecoderConfig
+	extJSON     bool
+	strict      bool
+	metadata    ClientMetadata
+	// namespaces tracks drops and renames, to tell stale handles.
+	namespaces namespaceGenerations
+	// capabilities are set by the connection handshake.
+	capabilities *ServerDescription
+	sessions     sessionPool
+	// repairRPC is the connection to the read repair endpoint, if any.
+	repairRPC RPCClient
+	// leaks tracks open cursors and change streams, with leak detection on.
+	leaks *leakTracker
+	// resolver resolves the hosts of the URI for WarmUp.
+	resolver hostResolver
+	// minPoolSize is the number of connections WarmUp must establish.
+	minPoolSize uint64
+	// limiter bounds the operations in flight; nil means no bound.
+	limiter *operationLimiter
+	// onPanic is notified of callbacks that panicked.
+	onPanic func(*PanicError)
 }
 
 // ClientOptions configures the client.
@@ -42,6 +77,91 @@ type ClientOptions struct {
 	MinPoolSize     uint64
 	MaxConnIdleTime time.Duration
 	AppName         string
+	Clock           Clock
+	IDGenerator     IDGenerator
+	Retry           *RetryOptions
+	// MaxFindDocuments caps the number of documents a Find without an
+	// explicit limit may return. Zero means no cap.
+	MaxFindDocuments int64
+	// MaxResponseBytes caps the estimated encoded size of a Find or
+	// Aggregate result. It is checked after the result has been received
+	// and decoded, so it does not bound the memory used to read it; use
+	// MaxFindDocuments or a limit for that. It also caps the decompressed
+	// size of compressed change event batches, as they are read. Zero means
+	// no cap, or DefaultMaxEventBatchBytes for event batches.
+	MaxResponseBytes int64
+	// MaxBufferedDocuments caps the number of documents an Aggregate may
+	// buffer in memory. Zero means no cap.
+	MaxBufferedDocuments int64
+	// ReconnectQueue enables automatic reconnection after the transport is
+	// lost, queuing operations issued meanwhile instead of failing them with
+	// ErrClientDisconnected. Nil disables reconnection.
+	ReconnectQueue *ReconnectQueueOptions
+	// NamingStrategy names struct fields that have no json tag name when
+	// encoding documents and maps them back when decoding. The default,
+	// NamingAsIs, uses the Go field name.
+	NamingStrategy NamingStrategy
+	// NumberDecoding controls how numbers surface in untyped results such
+	// as map[string]any. The default, NumberDecodingFloat64, decodes them
+	// as float64.
+	NumberDecoding NumberDecoding
+	// DecoderConfig tunes how results are assigned to Go values: whether
+	// structs and maps are reset before decoding and what null fields do.
+	// Nil decodes as encoding/json does.
+	DecoderConfig *DecoderConfig
+	// ExtendedJSON decodes results that arrive in canonical or relaxed
+	// Extended JSON v2, as produced by mongoexport or the Atlas Data API:
+	// $numberLong, $numberInt and $numberDouble become numbers, $date a
+	// time, and $binary bytes.
+	ExtendedJSON bool
+	// DisallowUnknownFields makes decoding results into structs fail with
+	// an UnknownFieldError when a document has a field the struct does not,
+	// catching schema drift early. Collections and single Decode calls can
+	// override it.
+	DisallowUnknownFields bool
+	// ReadRepairEndpoint is a second read endpoint, such as another
+	// load-balanced replica, queried alongside the main one by reads that
+	// enable read repair.
+	ReadRepairEndpoint string
+	// WireProtocol selects the MongoDB wire protocol backend, which talks
+	// to a real MongoDB server instead of the RPC service. Nil selects it
+	// for plain mongodb:// URIs. The backend uses a single connection to
+	// the writable primary found when connecting and does not follow
+	// failovers until it reconnects.
+	WireProtocol *bool
+	// PayloadEncoding is how calls to the RPC service are encoded: JSON,
+	// BSON, MessagePack or CBOR. The zero value is PayloadEncodingAuto.
+	// The wire protocol always uses BSON.
+	PayloadEncoding PayloadEncoding
+	// WrapTransport wraps every connection the client dials, including
+	// reconnections and the read repair endpoint, such as to observe RPC
+	// calls or inject faults into them in tests.
+	WrapTransport func(RPCClient) RPCClient
+	// LeakDetection tracks open cursors and change streams with the stack
+	// trace that opened them, to find forgotten Close calls. Disconnect
+	// then fails with a LeakError listing those still open, and one that is
+	// garbage collected while open is reported to OnLeak. A cursor read to
+	// the end needs no Close. Capturing stack traces has a cost, so enable
+	// it in tests and while debugging.
+	LeakDetection bool
+	// OnLeak is called, with leak detection on, for a cursor or change
+	// stream garbage collected without being closed. Nil logs a warning
+	// with the log package.
+	OnLeak func(Leak)
+	// MaxOperations is the most operations the client runs at once; others
+	// wait, in order of Priority. Zero, the default, admits every operation
+	// at once, and priorities have no effect.
+	MaxOperations uint64
+	// MaxBatchOperations is the most operations at PriorityBatch the client
+	// runs at once, leaving the other MaxOperations slots to interactive
+	// operations. Zero means half of MaxOperations. It has no effect
+	// without MaxOperations.
+	MaxBatchOperations uint64
+	// OnPanic is called when a callback run by the SDK panics, such as a
+	// WithTransaction function or a Subscribe handler, before the panic is
+	// returned as a PanicError. Use it to log or count the panics. Panics in
+	// OnLeak, which runs without a caller, are only reported here.
+	OnPanic func(*PanicError)
 }
 
 // DefaultClientOptions returns the default client options.
@@ -51,10 +171,12 @@ func DefaultClientOptions() *ClientOptions {
 		MaxPoolSize:     100,
 		MinPoolSize:     0,
 		MaxConnIdleTime: 0,
+		Clock:           systemClock{},
 	}
 }
 
-// SetTimeout sets the operation timeout.
+// SetTimeout sets the default operation timeout, the lowest level of the
+// timeout hierarchy described by TimeoutSource.
 func (o *ClientOptions) SetTimeout(d time.Duration) *ClientOptions {
 	o.Timeout = d
 	return o
@@ -66,7 +188,23 @@ func (o *ClientOptions) SetMaxPoolSize(size uint64) *ClientOptions {
 	return o
 }
 
-// SetMinPoolSize sets the minimum connection pool size.
+// SetMaxOperations sets the maximum number of operations in flight,
+// enabling admission by priority.
+func (o *ClientOptions) SetMaxOperations(n uint64) *ClientOptions {
+	o.MaxOperations = n
+	return o
+}
+
+// SetMaxBatchOperations sets the maximum number of batch priority
+// operations in flight.
+func (o *ClientOptions) SetMaxBatchOperations(n uint64) *ClientOptions {
+	o.MaxBatchOperations = n
+	return o
+}
+
+// SetMinPoolSize sets the minimum connection pool size. Both the RPC and
+// the wire protocol backends use a single connection, so WarmUp fails with
+// ErrMinPoolSizeUnmet for a size above 1.
 func (o *ClientOptions) SetMinPoolSize(size uint64) *ClientOptions {
 	o.MinPoolSize = size
 	return o
@@ -78,14 +216,141 @@ func (o *ClientOptions) SetMaxConnIdleTime(d time.Duration) *ClientOptions {
 	return o
 }
 
-// SetAppName sets the application name.
+// SetAppName sets the application name. It is sent in the connection
+// handshake and attached to operations for server-side attribution.
 func (o *ClientOptions) SetAppName(name string) *ClientOptions {
 	o.AppName = name
 	return o
 }
 
+// SetClock sets the clock used for timestamps, TTL helpers, and retry backoff.
+func (o *ClientOptions) SetClock(clock Clock) *ClientOptions {
+	o.Clock = clock
+	return o
+}
+
+// SetIDGenerator sets the generator used to assign _id values to inserted
+// documents that lack one. Without a generator the server assigns them.
+func (o *ClientOptions) SetIDGenerator(gen IDGenerator) *ClientOptions {
+	o.IDGenerator = gen
+	return o
+}
+
+// SetRetry sets the retry, retry budget, and adaptive throttling policy.
+// Retries are disabled unless a policy is set.
+func (o *ClientOptions) SetRetry(retry *RetryOptions) *ClientOptions {
+	o.Retry = retry
+	return o
+}
+
+// SetMaxFindDocuments sets the maximum number of documents a Find without
+// an explicit limit may return before failing with ErrResultTooLarge.
+func (o *ClientOptions) SetMaxFindDocuments(n int64) *ClientOptions {
+	o.MaxFindDocuments = n
+	return o
+}
+
+// SetMaxBufferedDocuments sets the maximum number of documents an Aggregate
+// may buffer before failing with ErrResultTooLarge.
+func (o *ClientOptions) SetMaxBufferedDocuments(n int64) *ClientOptions {
+	o.MaxBufferedDocuments = n
+	return o
+}
+
+// SetReconnectQueue enables reconnection with operation queuing.
+func (o *ClientOptions) SetReconnectQueue(opts *ReconnectQueueOptions) *ClientOptions {
+	o.ReconnectQueue = opts
+	return o
+}
+
+// SetReadRepairEndpoint sets the second endpoint used by read-repair reads.
+func (o *ClientOptions) SetReadRepairEndpoint(uri string) *ClientOptions {
+	o.ReadRepairEndpoint = uri
+	return o
+}
+
+// SetWireProtocol selects the wire protocol backend, or the RPC service when
+// wire is false, regardless of the URI scheme.
+func (o *ClientOptions) SetWireProtocol(wire bool) *ClientOptions {
+	o.WireProtocol = &wire
+	return o
+}
+
+// SetPayloadEncoding sets how calls to the RPC service are encoded.
+func (o *ClientOptions) SetPayloadEncoding(encoding PayloadEncoding) *ClientOptions {
+	o.PayloadEncoding = encoding
+	return o
+}
+
+// SetWrapTransport sets the function wrapping every dialed connection.
+func (o *ClientOptions) SetWrapTransport(wrap func(RPCClient) RPCClient) *ClientOptions {
+	o.WrapTransport = wrap
+	return o
+}
+
+// SetLeakDetection sets whether open cursors and change streams are
+// tracked to report the ones never closed.
+func (o *ClientOptions) SetLeakDetection(enabled bool) *ClientOptions {
+	o.LeakDetection = enabled
+	return o
+}
+
+// SetOnLeak sets the function called for a cursor or change stream garbage
+// collected without being closed.
+func (o *ClientOptions) SetOnLeak(onLeak func(Leak)) *ClientOptions {
+	o.OnLeak = onLeak
+	return o
+}
+
+// SetOnPanic sets the function notified of callbacks that panicked.
+func (o *ClientOptions) SetOnPanic(onPanic func(*PanicError)) *ClientOptions {
+	o.OnPanic = onPanic
+	return o
+}
+
+// SetNamingStrategy sets how untagged struct fields are named in documents.
+func (o *ClientOptions) SetNamingStrategy(n NamingStrategy) *ClientOptions {
+	o.NamingStrategy = n
+	return o
+}
+
+// SetNumberDecoding sets how numbers are decoded in untyped results.
+func (o *ClientOptions) SetNumberDecoding(n NumberDecoding) *ClientOptions {
+	o.NumberDecoding = n
+	return o
+}
+
+// SetDecoderConfig sets how results are assigned to Go values.
+func (o *ClientOptions) SetDecoderConfig(config *DecoderConfig) *ClientOptions {
+	o.DecoderConfig = config
+	return o
+}
+
+// SetDisallowUnknownFields sets whether decoding fails on fields the target
+// struct does not have.
+func (o *ClientOptions) SetDisallowUnknownFields(disallow bool) *ClientOptions {
+	o.DisallowUnknownFields = disallow
+	return o
+}
+
+// SetExtendedJSON sets whether results are decoded from Extended JSON.
+func (o *ClientOptions) SetExtendedJSON(extJSON bool) *ClientOptions {
+	o.ExtendedJSON = extJSON
+	return o
+}
+
+// SetMaxResponseBytes sets the maximum estimated encoded size of a Find or
+// Aggregate result before failing with ErrResultTooLarge. The check runs
+// once the result has been decoded.
+func (o *ClientOptions) SetMaxResponseBytes(n int64) *ClientOptions {
+	o.MaxResponseBytes = n
+	return o
+}
+
 // NewClient creates a new MongoDB client.
-// The URI should be a mongodb:// or mongodb+srv:// URI.
+// The URI should be a mongodb:// or mongodb+srv:// URI. Connecting performs
+// a handshake that sends the client metadata and records the server
+// description; see Client.ServerDescription.
 //
 // Example:
 //
@@ -132,43 +397,282 @@ func NewClient(ctx context.Context, uri string, opts ...*ClientOptions) (*Client
 			if opt.AppName != "" {
 				options.AppName = opt.AppName
 			}
+			if opt.Clock != nil {
+				options.Clock = opt.Clock
+			}
+			if opt.IDGenerator != nil {
+				options.IDGenerator = opt.IDGenerator
+			}
+			if opt.Retry != nil {
+				options.Retry = opt.Retry
+			}
+			if opt.MaxFindDocuments > 0 {
+				options.MaxFindDocuments = opt.MaxFindDocuments
+			}
+			if opt.MaxResponseBytes > 0 {
+				options.MaxResponseBytes = opt.MaxResponseBytes
+			}
+			if opt.MaxBufferedDocuments > 0 {
+				options.MaxBufferedDocuments = opt.MaxBufferedDocuments
+			}
+			if opt.ReconnectQueue != nil {
+				options.ReconnectQueue = opt.ReconnectQueue
+			}
+			if opt.NamingStrategy != NamingAsIs {
+				options.NamingStrategy = opt.NamingStrategy
+			}
+			if opt.NumberDecoding != NumberDecodingFloat64 {
+				options.NumberDecoding = opt.NumberDecoding
+			}
+			if opt.DecoderConfig != nil {
+				options.DecoderConfig = opt.DecoderConfig
+			}
+			if opt.ExtendedJSON {
+				options.ExtendedJSON = true
+			}
+			if opt.DisallowUnknownFields {
+				options.DisallowUnknownFields = true
+			}
+			if opt.ReadRepairEndpoint != "" {
+				options.ReadRepairEndpoint = opt.ReadRepairEndpoint
+			}
+			if opt.WireProtocol != nil {
+				options.WireProtocol = opt.WireProtocol
+			}
+			if opt.PayloadEncoding != "" {
+				options.PayloadEncoding = opt.PayloadEncoding
+			}
+			if opt.WrapTransport != nil {
+				options.WrapTransport = opt.WrapTransport
+			}
+			if opt.LeakDetection {
+				options.LeakDetection = true
+			}
+			if opt.OnLeak != nil {
+				options.OnLeak = opt.OnLeak
+			}
+			if opt.OnPanic != nil {
+				options.OnPanic = opt.OnPanic
+			}
+			if opt.MaxOperations > 0 {
+				options.MaxOperations = opt.MaxOperations
+			}
+			if opt.MaxBatchOperations > 0 {
+				options.MaxBatchOperations = opt.MaxBatchOperations
+			}
 		}
 	}
 
+	if err := options.PayloadEncoding.validate(); err != nil {
+		return nil, err
+	}
+
 	// Convert URI for RPC client
 	rpcURI := convertToRPCURI(uri)
+	wire := usesWireProtocol(uri, options.WireProtocol)
+	wrap := func(rpcClient RPCClient) RPCClient {
+		if options.WrapTransport != nil {
+			return options.WrapTransport(rpcClient)
+		}
+		return rpcClient
+	}
+	dial := func(ctx context.Context) (RPCClient, error) {
+		if wire {
+			wireClient, err := dialWire(ctx, uri, options.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			return wrap(wireClient), nil
+		}
+		rpcClient, err := rpc.ConnectContext(ctx, rpcURI, rpc.WithTimeout(options.Timeout))
+		if err != nil {
+			return nil, err
+		}
+		return wrap(withPayloadEncoding(&rpcClientWrapper{client: rpcClient}, options.PayloadEncoding)), nil
+	}
 
 	// Create RPC client
-	rpcClient, err := rpc.ConnectContext(ctx, rpcURI, rpc.WithTimeout(options.Timeout))
+	rpcClient, err := dial(ctx)
 	if err != nil {
 		return nil, &ConnectionError{Address: uri, Wrapped: err}
 	}
 
+	c := newClient(ctx, rpcClient, uri, options)
+	if err := c.handshake(ctx, c.rpcClient); err != nil {
+		c.cancel()
+		rpcClient.Close()
+		return nil, &ConnectionError{Address: uri, Wrapped: err}
+	}
+	if options.ReadRepairEndpoint != "" {
+		repairRPC, err := rpc.ConnectContext(ctx, convertToRPCURI(options.ReadRepairEndpoint), rpc.WithTimeout(options.Timeout))
+		if err != nil {
+			c.cancel()
+			rpcClient.Close()
+			return nil, &ConnectionError{Address: options.ReadRepairEndpoint, Wrapped: err}
+		}
+		c.repairRPC = wrap(withPayloadEncoding(&rpcClientWrapper{client: repairRPC}, options.PayloadEncoding))
+	}
+	if c.reconnect != nil {
+		c.reconnect.dial = func(ctx context.Context) (RPCClient, error) {
+			rpcClient, err := dial(ctx)
+			if err != nil {
+				return nil, &ConnectionError{Address: uri, Wrapped: err}
+			}
+			if err := c.handshake(ctx, rpcClient); err != nil {
+				rpcClient.Close()
+				return nil, &ConnectionError{Address: uri, Wrapped: err}
+			}
+			return rpcClient, nil
+		}
+	}
+	return c, nil
+}
+
+// newClient creates a connected client on top of rpcClient.
+func newClient(ctx context.Context, rpcClient RPCClient, uri string, options *ClientOptions) *Client {
 	clientCtx, cancel := context.WithCancel(ctx)
 
-	return &Client{
-		rpcClient: &rpcClientWrapper{client: rpcClient},
-		uri:       uri,
-		connected: true,
-		databases: make(map[string]*Database),
-		timeout:   options.Timeout,
-		ctx:       clientCtx,
-		cancel:    cancel,
-	}, nil
+	c := &Client{
+		rpcClient:   rpcClient,
+		uri:         uri,
+		connected:   true,
+		databases:   make(map[string]*Database),
+		timeout:     options.Timeout,
+		clock:       options.Clock,
+		idGenerator: options.IDGenerator,
+		metrics:     newClientMetrics(),
+		retry:       options.Retry,
+		limits: resultLimits{
+			MaxFindDocuments:     options.MaxFindDocuments,
+			MaxResponseBytes:     options.MaxResponseBytes,
+			MaxBufferedDocuments: options.MaxBufferedDocuments,
+		},
+		naming:      options.NamingStrategy,
+		numbers:     options.NumberDecoding,
+		extJSON:     options.ExtendedJSON,
+		strict:      options.DisallowUnknownFields,
+		metadata:    newClientMetadata(options.AppName),
+		onPanic:     options.OnPanic,
+		resolver:    net.DefaultResolver,
+		minPoolSize: options.MinPoolSize,
+		ctx:         clientCtx,
+		cancel:      cancel,
+	}
+	if r := options.Retry; r != nil {
+		if r.BudgetRatio > 0 {
+			c.retryBudget = newRetryBudget(r.BudgetRatio)
+		}
+		if r.ThrottleMultiplier > 0 {
+			c.throttle = newAdaptiveThrottle(r.ThrottleMultiplier, options.Clock)
+		}
+	}
+	if config := options.DecoderConfig; config != nil {
+		c.decoder = *config
+		if config.UseNumber && c.numbers == NumberDecodingFloat64 {
+			c.numbers = NumberDecodingJSONNumber
+		}
+	}
+	if options.ReconnectQueue != nil {
+		c.reconnect = &reconnectState{options: options.ReconnectQueue.withDefaults()}
+	}
+	if options.LeakDetection {
+		onLeak := options.OnLeak
+		if onLeak != nil {
+			// Leaks are reported from finalizers, which have no caller to
+			// return a PanicError to; OnPanic is notified alone.
+			report := onLeak
+			onLeak = func(leak Leak) {
+				c.runCallback("OnLeak", func() error {
+					report(leak)
+					return nil
+				})
+			}
+		}
+		c.leaks = newLeakTracker(options.Clock, onLeak)
+	}
+	if options.MaxOperations > 0 {
+		maxBatch := options.MaxBatchOperations
+		if maxBatch == 0 {
+			maxBatch = max(options.MaxOperations/2, 1)
+		}
+		c.limiter = newOperationLimiter(int(options.MaxOperations), int(maxBatch))
+	}
+	return c
 }
 
 // newClientWithRPC creates a client with a custom RPC client (for testing).
 func newClientWithRPC(rpcClient RPCClient, uri string) *Client {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &Client{
-		rpcClient: rpcClient,
-		uri:       uri,
-		connected: true,
-		databases: make(map[string]*Database),
-		timeout:   30 * time.Second,
-		ctx:       ctx,
-		cancel:    cancel,
+	return newClient(context.Background(), rpcClient, uri, DefaultClientOptions())
+}
+
+// execute issues an RPC call for an operation on namespace ns. It fails fast
+// when the client is disconnected or ctx is already done, waits while a
+// reconnection is in progress if queuing is enabled, and records
+// per-namespace operation metrics.
+func (c *Client) execute(ctx context.Context, ns string, method string, args ...any) (any, error) {
+	return c.executeWithin(ctx, c.EffectiveTimeout(ctx), ns, method, args...)
+}
+
+// executeWithin is execute bounded by timeout. Metrics describe the
+// resolved deadline, so deadlines derived from timeout defaults are
+// recorded as deadline slack too.
+func (c *Client) executeWithin(ctx context.Context, timeout OperationTimeout, ns string, method string, args ...any) (any, error) {
+	if err := c.checkPayload(method, args); err != nil {
+		return nil, err
+	}
+
+	opCtx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	deadline := c.deadline(timeout)
+
+	rpcClient, err := c.connection(opCtx)
+	if err != nil {
+		c.metrics.record(ns, deadline, c.clock.Now(), err)
+		return nil, err
 	}
+
+	// Check context
+	select {
+	case <-opCtx.Done():
+		c.metrics.record(ns, deadline, c.clock.Now(), opCtx.Err())
+		return nil, opCtx.Err()
+	default:
+	}
+
+	if c.limiter != nil {
+		priority := PriorityFromContext(ctx)
+		if err := c.limiter.acquire(opCtx, priority); err != nil {
+			c.metrics.record(ns, deadline, c.clock.Now(), err)
+			return nil, err
+		}
+		defer c.limiter.release(priority)
+	}
+
+	result, err := c.call(opCtx, rpcClient, method, args...)
+	if err != nil {
+		c.connectionLost(rpcClient)
+		err = notSupported(method, err)
+	} else {
+		err = validateResponse(method, result)
+	}
+	c.metrics.record(ns, deadline, c.clock.Now(), err)
+	return result, err
+}
+
+// transport returns the underlying RPC client.
+func (c *Client) transport() RPCClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rpcClient
+}
+
+// usesWireProtocol reports whether a client for uri uses the wire protocol
+// backend: as wire says, or, when wire is nil, for mongodb:// URIs.
+func usesWireProtocol(uri string, wire *bool) bool {
+	if wire != nil {
+		return *wire
+	}
+	return strings.HasPrefix(uri, "mongodb://")
 }
 
 // convertToRPCURI converts a MongoDB URI to an RPC-compatible URI.
@@ -191,8 +695,14 @@ type rpcClientWrapper struct {
 	client *rpc.Client
 }
 
+// Call sends args with their time.Time values as bson.DateTime, so the
+// service stores and compares them as dates.
 func (w *rpcClientWrapper) Call(method string, args ...any) RPCPromise {
-	return w.client.Call(method, args...)
+	encoded := make([]any, len(args))
+	for i, arg := range args {
+		encoded[i] = encodeDates(arg)
+	}
+	return w.client.Call(method, encoded...)
 }
 
 func (w *rpcClientWrapper) Close() error {
@@ -222,39 +732,58 @@ func (c *Client) Connect(ctx context.Context) error {
 	return ErrClientDisconnected
 }
 
-// Disconnect closes the connection to the server.
+// Disconnect ends the active sessions and closes the connection to the
+// server. The wire protocol backend first kills, best effort, the server
+// cursors it still has open, such as those of change streams.
 func (c *Client) Disconnect(ctx context.Context) error {
+	c.endSessions(ctx)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if !c.connected {
+	reconnecting := c.reconnect != nil && c.reconnect.active
+	if !c.connected && !reconnecting {
 		return nil
 	}
 
 	c.connected = false
 	c.cancel()
 
+	if c.repairRPC != nil {
+		c.repairRPC.Close()
+	}
 	if c.rpcClient != nil {
-		return c.rpcClient.Close()
+		if err := c.rpcClient.Close(); err != nil {
+			return err
+		}
 	}
 
+	if c.leaks != nil {
+		if leaks := c.leaks.drain(); len(leaks) > 0 {
+			return &LeakError{Leaks: leaks}
+		}
+	}
 	return nil
 }
 
 // Database returns a handle for the specified database.
-func (c *Client) Database(name string) *Database {
+// Handles requested without options are cached by name; handles requested
+// with options are always new, since their defaults may differ.
+func (c *Client) Database(name string, opts ...*DatabaseOptions) *Database {
+	for _, opt := range opts {
+		if opt != nil {
+			return newDatabase(c, name, opts...)
+		}
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if db, ok := c.databases[name]; ok {
+	if db, ok := c.databases[name]; ok && !db.Stale() {
 		return db
 	}
 
-	db := &Database{
-		client:      c,
-		name:        name,
-		collections: make(map[string]*Collection),
-	}
+	db := newDatabase(c, name)
 	c.databases[name] = db
 
 	return db
@@ -262,24 +791,7 @@ func (c *Client) Database(name string) *Database {
 
 // ListDatabaseNames returns the names of all databases.
 func (c *Client) ListDatabaseNames(ctx context.Context) ([]string, error) {
-	c.mu.RLock()
-	connected := c.connected
-	rpcClient := c.rpcClient
-	c.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.listDatabases")
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "admin", "mongo.listDatabases")
 	if err != nil {
 		return nil, err
 	}
@@ -295,59 +807,35 @@ func (c *Client) ListDatabaseNames(ctx context.Context) ([]string, error) {
 		return result, nil
 	}
 
-	return nil, fmt.Errorf("unexpected result type: %T", result)
+	return nil, unexpectedResponse("mongo.listDatabases", result)
 }
 
-// Ping verifies the connection to the server.
-func (c *Client) Ping(ctx context.Context) error {
-	c.mu.RLock()
-	connected := c.connected
-	rpcClient := c.rpcClient
-	c.mu.RUnlock()
-
-	if !connected {
-		return ErrClientDisconnected
+// Aggregate runs an admin-level aggregation pipeline that does not target a
+// database namespace, such as $currentOp, $listLocalSessions, or $documents.
+func (c *Client) Aggregate(ctx context.Context, pipeline any) (*Cursor, error) {
+	result, err := c.execute(ctx, "admin", "mongo.aggregate", "admin", "", pipeline)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
+	// Parse result as documents array
+	docs, ok := result.([]any)
+	if !ok {
+		return nil, unexpectedResponse("mongo.aggregate", result)
 	}
 
-	promise := rpcClient.Call("mongo.ping")
-	_, err := promise.Await()
-	return err
+	cursor := newCursor(c.resultDocuments(docs))
+	cursor.numbers = c.numbers
+	cursor.decoder = c.decoder
+	cursor.strict = c.strict
+	c.trackCursor(cursor, "admin")
+	return cursor, nil
 }
 
-// StartSession starts a new session (for future transaction support).
-func (c *Client) StartSession() (*Session, error) {
-	c.mu.RLock()
-	connected := c.connected
-	c.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	return &Session{client: c}, nil
-}
-
-// Session represents a MongoDB session.
-type Session struct {
-	client *Client
-}
-
-// EndSession ends the session.
-func (s *Session) EndSession(ctx context.Context) {
-	// No-op for now
-}
-
-// WithTransaction runs a function within a transaction.
-func (s *Session) WithTransaction(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
-	// For now, just execute without transaction support
-	return fn(ctx)
+// Ping verifies the connection to the server.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.execute(ctx, "admin", "mongo.ping")
+	return err
 }
 
 // NumberLong represents a 64-bit integer.
@@ -52,6 +52,7 @@ func (m *mockRPCClient) Call(method string, args ...any) RPCPromise {
 		return &mockPromise{err: errors.New("unexpected call: " + method)}
 	}
 
+	m.calls[m.callIndex].args = args
 	call := m.calls[m.callIndex]
 	m.callIndex++
 
@@ -280,7 +281,7 @@ func TestClientStartSession(t *testing.T) {
 		t.Error("expected session to have same client")
 	}
 
-	// End session (no-op)
+	// End session
 	session.EndSession(context.Background())
 }
 
@@ -400,3 +401,55 @@ func TestNumberTypes(t *testing.T) {
 		t.Errorf("NumberDouble conversion failed")
 	}
 }
+
+// TestClientAggregate tests running an admin-level aggregation.
+func TestClientAggregate(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"opid": float64(1), "active": true},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	ctx := context.Background()
+
+	cursor, err := client.Aggregate(ctx, []any{map[string]any{"$currentOp": map[string]any{}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cursor.RemainingBatchLength() != 1 {
+		t.Errorf("expected 1 document, got %d", cursor.RemainingBatchLength())
+	}
+
+	args := mock.calls[0].args
+	if args[0] != "admin" || args[1] != "" {
+		t.Errorf("expected admin namespace, got %v.%v", args[0], args[1])
+	}
+}
+
+// TestClientAggregateDisconnected tests admin aggregation when disconnected.
+func TestClientAggregateDisconnected(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	ctx := context.Background()
+	client.Disconnect(ctx)
+
+	_, err := client.Aggregate(ctx, []any{})
+	if !errors.Is(err, ErrClientDisconnected) {
+		t.Errorf("expected ErrClientDisconnected, got %v", err)
+	}
+}
+
+// TestClientAggregateUnexpectedResult tests admin aggregation with unexpected result type.
+func TestClientAggregateUnexpectedResult(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", "not an array", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	_, err := client.Aggregate(context.Background(), []any{})
+	if err == nil {
+		t.Error("expected error for unexpected result type")
+	}
+}
@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// Clock is the source of time used by the SDK for timestamps, TTL helpers,
+// and retry backoff. Tests can inject a fake to make time deterministic.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// IDGenerator produces _id values for documents inserted without one.
+// Tests can inject a fake to make generated IDs deterministic.
+type IDGenerator interface {
+	NewID() any
+}
+
+// IDGeneratorFunc adapts a function to the IDGenerator interface.
+type IDGeneratorFunc func() any
+
+// NewID calls f.
+func (f IDGeneratorFunc) NewID() any {
+	return f()
+}
+
+// ObjectIDGenerator generates a bson.ObjectID for each document, so inserts
+// carry their _id instead of relying on the server to assign one.
+var ObjectIDGenerator IDGenerator = IDGeneratorFunc(func() any { return bson.NewObjectID() })
+
+// withGeneratedID returns document with an _id from gen if it is a document
+// map without one. The caller's map is not modified. Other document types are
+// returned unchanged and get their _id assigned by the server.
+func withGeneratedID(gen IDGenerator, document any) any {
+	if gen == nil {
+		return document
+	}
+	if d, ok := document.(bson.D); ok {
+		for _, e := range d {
+			if e.Key == "_id" {
+				return document
+			}
+		}
+		return append(bson.D{{Key: "_id", Value: gen.NewID()}}, d...)
+	}
+	if m, ok := document.(bson.M); ok {
+		document = map[string]any(m)
+	}
+	doc, ok := document.(map[string]any)
+	if !ok {
+		return document
+	}
+	if _, ok := doc["_id"]; ok {
+		return document
+	}
+	withID := make(map[string]any, len(doc)+1)
+	for k, v := range doc {
+		withID[k] = v
+	}
+	withID["_id"] = gen.NewID()
+	return withID
+}
@@ -0,0 +1,185 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// fakeClock is a manually advanced Clock for tests.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: at, ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward and fires any expired timers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+			continue
+		}
+		pending = append(pending, w)
+	}
+	c.waiters = pending
+}
+
+// TestFakeClock tests the fake clock used by other tests.
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+
+	ch := clock.After(time.Second)
+	clock.Advance(500 * time.Millisecond)
+
+	select {
+	case <-ch:
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(500 * time.Millisecond)
+
+	select {
+	case now := <-ch:
+		if !now.Equal(start.Add(time.Second)) {
+			t.Errorf("unexpected fire time %v", now)
+		}
+	default:
+		t.Fatal("expected timer to fire")
+	}
+}
+
+// TestClientOptionsClockAndIDGenerator tests setting the clock and ID generator.
+func TestClientOptionsClockAndIDGenerator(t *testing.T) {
+	opts := DefaultClientOptions()
+
+	if _, ok := opts.Clock.(systemClock); !ok {
+		t.Errorf("expected system clock by default, got %T", opts.Clock)
+	}
+
+	clock := newFakeClock(time.Unix(0, 0))
+	gen := IDGeneratorFunc(func() any { return "id" })
+
+	opts.SetClock(clock).SetIDGenerator(gen)
+
+	if opts.Clock != clock {
+		t.Error("expected clock to be set")
+	}
+
+	if opts.IDGenerator.NewID() != "id" {
+		t.Error("expected ID generator to be set")
+	}
+}
+
+// TestCollectionInsertOneGeneratedID tests that injected IDs are assigned on insert.
+func TestCollectionInsertOneGeneratedID(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "id-1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.idGenerator = IDGeneratorFunc(func() any { return "id-1" })
+
+	doc := map[string]any{"name": "John"}
+	_, err := client.Database("testdb").Collection("users").InsertOne(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := mock.calls[0].args[2].(map[string]any)
+	if sent["_id"] != "id-1" {
+		t.Errorf("expected generated _id, got %v", sent["_id"])
+	}
+
+	if _, ok := doc["_id"]; ok {
+		t.Error("expected caller's document to be unmodified")
+	}
+}
+
+// TestCollectionInsertManyGeneratedIDs tests that existing _id values are kept.
+func TestCollectionInsertManyGeneratedIDs(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"keep", "gen-1"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	n := 0
+	client.idGenerator = IDGeneratorFunc(func() any {
+		n++
+		return fmt.Sprintf("gen-%d", n)
+	})
+
+	_, err := client.Database("testdb").Collection("users").InsertMany(context.Background(), []any{
+		map[string]any{"_id": "keep"},
+		map[string]any{"name": "Jane"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := mock.calls[0].args[2].([]any)
+	if sent[0].(map[string]any)["_id"] != "keep" {
+		t.Errorf("expected existing _id kept, got %v", sent[0])
+	}
+	if sent[1].(map[string]any)["_id"] != "gen-1" {
+		t.Errorf("expected generated _id, got %v", sent[1])
+	}
+}
+
+// TestObjectIDGenerator tests populating _id client-side with ObjectIDs.
+func TestObjectIDGenerator(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.idGenerator = ObjectIDGenerator
+
+	doc := withGeneratedID(client.idGenerator, map[string]any{"name": "Ada"}).(map[string]any)
+	id, ok := doc["_id"].(bson.ObjectID)
+	if !ok || id.IsZero() {
+		t.Fatalf("expected a generated ObjectID, got %v", doc["_id"])
+	}
+
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": map[string]any{"$oid": id.Hex()}}, nil)
+	result, err := client.Database("testdb").Collection("users").InsertOne(context.Background(), doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.InsertedID != id {
+		t.Errorf("expected inserted ID %v, got %v", id, result.InsertedID)
+	}
+}
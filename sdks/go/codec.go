@@ -0,0 +1,294 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structField describes how a struct field maps to a document field.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	// named reports whether the name comes from a tag rather than the Go
+	// field name.
+	named bool
+	// inlineMap marks a map field tagged `bson:",inline"`, which holds the
+	// document fields no other field claims.
+	inlineMap bool
+}
+
+// structFieldCache caches resolved fields per struct type.
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the document fields of struct type t, following the
+// encoding/json rules: a `json:"-"` tag skips a field, a tag name renames it,
+// unexported fields are ignored, untagged embedded structs are flattened,
+// and of fields flattened to the same name the shallowest wins. A `bson` tag,
+// as used with the official driver, takes precedence over the `json` tag.
+// The inline option of either flattens a struct field or, on a map field,
+// collects the remaining document fields.
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField)
+	}
+	fields := dominantFields(appendStructFields(nil, t, nil))
+	structFieldCache.Store(t, fields)
+	return fields
+}
+
+func appendStructFields(fields []structField, t reflect.Type, index []int) []structField {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("bson")
+		if !hasTag {
+			tag, hasTag = sf.Tag.Lookup("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if hasOption(opts, "inline") && sf.IsExported() {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch {
+			case ft.Kind() == reflect.Struct:
+				fields = appendStructFields(fields, ft, fieldIndex)
+				continue
+			case ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String:
+				fields = append(fields, structField{index: fieldIndex, inlineMap: true})
+				continue
+			}
+		}
+		if sf.Anonymous && (!hasTag || name == "") {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = appendStructFields(fields, ft, fieldIndex)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		named := name != ""
+		if !named {
+			name = sf.Name
+		}
+		fields = append(fields, structField{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: hasOption(opts, "omitempty"),
+			named:     named,
+		})
+	}
+	return fields
+}
+
+// dominantFields drops the fields hidden by others of the same name, as
+// encoding/json does: a field at a shallower depth hides deeper ones, and of
+// those at the same depth a tagged field hides untagged ones. Names still
+// ambiguous are dropped entirely.
+func dominantFields(fields []structField) []structField {
+	best := make(map[string]structField, len(fields))
+	ambiguous := make(map[string]bool)
+	for _, f := range fields {
+		if f.inlineMap {
+			continue
+		}
+		b, ok := best[f.name]
+		switch {
+		case !ok || len(f.index) < len(b.index) || (len(f.index) == len(b.index) && f.named && !b.named):
+			best[f.name] = f
+			ambiguous[f.name] = false
+		case len(f.index) == len(b.index) && f.named == b.named:
+			ambiguous[f.name] = true
+		}
+	}
+
+	kept := fields[:0:0]
+	for _, f := range fields {
+		if f.inlineMap || (!ambiguous[f.name] && reflect.DeepEqual(best[f.name].index, f.index)) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// hasOption reports whether the comma-separated tag options contain opt.
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// bsonTagCache caches usesBSONTags per type.
+var bsonTagCache sync.Map // map[reflect.Type]bool
+
+// usesBSONTags reports whether t, or a type it contains, is a struct with a
+// `bson` field tag or a `json` tag encoding/json would not honor, implements
+// bson.Marshaler or bson.Unmarshaler, or is a bson.Optional or bson.Null.
+// Such types are encoded and decoded by the SDK rather than by encoding/json,
+// which knows none of them.
+func usesBSONTags(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := bsonTagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	uses := containsBSONTags(t, make(map[reflect.Type]bool))
+	bsonTagCache.Store(t, uses)
+	return uses
+}
+
+// containsBSONTags walks t for usesBSONTags, skipping types already seen.
+func containsBSONTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if hasBSONCodec(t) || nullableKind(t) != "" {
+		return true
+	}
+	if seen[t] || marshalsItself(t) {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsBSONTags(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if _, ok := sf.Tag.Lookup("bson"); ok && (sf.IsExported() || sf.Anonymous) {
+				return true
+			}
+			if sf.IsExported() && jsonIgnoresTag(sf) {
+				return true
+			}
+			if (sf.IsExported() || sf.Anonymous) && containsBSONTags(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonIgnoresTag reports whether the `json` tag of sf has an option that
+// encoding/json ignores: inline, or omitempty on a struct, which encoding/json
+// never omits but the SDK omits when zero.
+func jsonIgnoresTag(sf reflect.StructField) bool {
+	tag, ok := sf.Tag.Lookup("json")
+	if !ok {
+		return false
+	}
+	_, opts, _ := strings.Cut(tag, ",")
+	if hasOption(opts, "inline") {
+		return true
+	}
+	return hasOption(opts, "omitempty") && sf.Type.Kind() == reflect.Struct
+}
+
+// dateTypeCache caches holdsDates per type.
+var dateTypeCache sync.Map // map[reflect.Type]bool
+
+// holdsDates reports whether values of t may hold a time.Time or a
+// bson.DateTime: t is or contains one of them or an interface, which can
+// hold anything.
+func holdsDates(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := dateTypeCache.Load(t); ok {
+		return cached.(bool)
+	}
+	holds := containsDates(t, make(map[reflect.Type]bool))
+	dateTypeCache.Store(t, holds)
+	return holds
+}
+
+// containsDates walks t for holdsDates, skipping types already seen.
+func containsDates(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == timeType || t == dateTimeType {
+		return true
+	}
+	if seen[t] || marshalsItself(t) {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsDates(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if (sf.IsExported() || sf.Anonymous) && containsDates(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// binaryTypeCache caches holdsBinary per type.
+var binaryTypeCache sync.Map // map[reflect.Type]bool
+
+// holdsBinary reports whether values of t may hold binary data that
+// encoding/json cannot decode from a $binary or $uuid document: t is or
+// contains a []byte, a UUID-compatible array or an interface.
+func holdsBinary(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := binaryTypeCache.Load(t); ok {
+		return cached.(bool)
+	}
+	holds := containsBinary(t, make(map[reflect.Type]bool))
+	binaryTypeCache.Store(t, holds)
+	return holds
+}
+
+// containsBinary walks t for holdsBinary, skipping types already seen.
+func containsBinary(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return false
+	}
+	if isUUIDArray(t) || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+		return true
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsBinary(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if (sf.IsExported() || sf.Anonymous) && containsBinary(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUUIDArray reports whether t is a [16]byte array, the layout of UUID
+// types such as bson.UUID and github.com/google/uuid.UUID.
+func isUUIDArray(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
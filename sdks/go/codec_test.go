@@ -0,0 +1,130 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type codecBase struct {
+	ID      string `json:"_id"`
+	Created int64  `json:"created,omitempty"`
+}
+
+type codecUser struct {
+	codecBase
+	Name     string `json:"name"`
+	Email    string
+	Password string `json:"-"`
+	internal string
+	Address  struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+// TestStructFields tests resolving document fields of a struct.
+func TestStructFields(t *testing.T) {
+	fields := structFields(reflect.TypeOf(codecUser{}))
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+
+	want := []string{"_id", "created", "name", "Email", "address"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("expected %v, got %v", want, names)
+	}
+
+	if !fields[1].omitEmpty {
+		t.Error("expected created to be omitempty")
+	}
+
+	if !reflect.DeepEqual(fields[0].index, []int{0, 0}) {
+		t.Errorf("expected embedded index path, got %v", fields[0].index)
+	}
+}
+
+type codecAudit struct {
+	Created time.Time `json:"created,omitempty"`
+	By      string    `json:"by,omitempty"`
+}
+
+type codecOrder struct {
+	ID    string     `json:"_id"`
+	Audit codecAudit `json:"audit,inline"`
+	Total int        `json:"total,omitempty"`
+}
+
+type codecShadowed struct {
+	codecBase
+	ID string `json:"_id"`
+}
+
+// TestStructFieldsDominance tests that shallower fields hide inlined fields
+// of the same name.
+func TestStructFieldsDominance(t *testing.T) {
+	fields := structFields(reflect.TypeOf(codecShadowed{}))
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	if want := []string{"created", "_id"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	if !reflect.DeepEqual(fields[1].index, []int{1}) {
+		t.Errorf("expected the outer _id to win, got index %v", fields[1].index)
+	}
+
+	got := encodeNamed(codecShadowed{codecBase: codecBase{ID: "inner"}, ID: "outer"}, NamingCamelCase)
+	if want := map[string]any{"_id": "outer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestEncodeInlineOmitEmpty tests that json inline and omitempty tags are
+// honored when inserting structs.
+func TestEncodeInlineOmitEmpty(t *testing.T) {
+	if !usesBSONTags(reflect.TypeOf(codecOrder{})) {
+		t.Fatal("expected a json inline tag to need the SDK encoder")
+	}
+
+	got := encodeNamed(codecOrder{ID: "o1", Audit: codecAudit{By: "ada"}}, NamingAsIs)
+	if want := map[string]any{"_id": "o1", "by": "ada"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"o1", "o2"}}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	_, err := coll.InsertMany(context.Background(), []any{
+		codecOrder{ID: "o1", Total: 3},
+		&codecOrder{ID: "o2"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := mock.calls[0].args[2].([]any)
+	want := []any{
+		map[string]any{"_id": "o1", "total": 3},
+		map[string]any{"_id": "o2"},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("expected %v, got %v", want, docs)
+	}
+}
+
+// TestHasOption tests parsing tag options.
+func TestHasOption(t *testing.T) {
+	if !hasOption("omitempty,inline", "inline") {
+		t.Error("expected inline option")
+	}
+
+	if hasOption("omitempty", "inline") {
+		t.Error("unexpected inline option")
+	}
+}
@@ -2,13 +2,238 @@ package mongo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.mongo.do/bson"
 )
 
 // Collection represents a MongoDB collection.
 type Collection struct {
 	database *Database
 	name     string
+
+	readPreference   *ReadPreference
+	readConcern      *ReadConcern
+	writeConcern     *WriteConcern
+	maxModified      int64
+	timeout          *time.Duration
+	rejectUnsafeKeys bool
+	schema           *Schema
+	strict           bool
+	versioning       *DocumentVersioning
+	// naming, numbers and decoder are the codec settings of the handle,
+	// the client's unless overridden.
+	naming  NamingStrategy
+	numbers NumberDecoding
+	decoder DecoderConfig
+	// dbGeneration and generation are the generations of the database and
+	// the collection the handle was created in; see Stale.
+	dbGeneration uint64
+	generation   atomic.Uint64
+}
+
+// CollectionOptions configures a Collection handle.
+// Unset fields inherit the database defaults.
+type CollectionOptions struct {
+	ReadPreference *ReadPreference
+	ReadConcern    *ReadConcern
+	WriteConcern   *WriteConcern
+	// MaxModifiedDocuments makes UpdateMany and DeleteMany fail with
+	// ErrModifyLimitExceeded when their filter matches more documents.
+	// Zero means no limit.
+	MaxModifiedDocuments *int64
+	// Timeout is the default operation timeout for the collection,
+	// overriding the database and client defaults. Zero disables them.
+	Timeout *time.Duration
+	// RejectUnsafeKeys makes inserts and replacements fail with
+	// ErrUnsafeKey when a document has a key, at any depth, that starts with
+	// "$" or contains ".". Enable it when storing maps built from user
+	// input, so the input cannot smuggle in operators or dotted paths.
+	// Trusted writes can use a handle cloned with the check disabled.
+	RejectUnsafeKeys *bool
+	// Schema makes inserts, replacements and updates fail with a
+	// SchemaError when the document, or the values an update sets, fail
+	// it. The check runs client-side, before the request is sent.
+	Schema *Schema
+	// DisallowUnknownFields overrides the client's strict decoding of
+	// results; see ClientOptions.DisallowUnknownFields.
+	DisallowUnknownFields *bool
+	// Versioning stamps inserted and replaced documents with a schema
+	// version and upgrades older documents as they are read.
+	Versioning *DocumentVersioning
+	// NamingStrategy, NumberDecoding and DecoderConfig override the
+	// client's codec settings of the same names for documents written and
+	// read through the handle. Together they take the place of the codec
+	// registry of other drivers.
+	NamingStrategy *NamingStrategy
+	NumberDecoding *NumberDecoding
+	DecoderConfig  *DecoderConfig
+}
+
+// SetReadPreference sets the read preference.
+func (o *CollectionOptions) SetReadPreference(rp *ReadPreference) *CollectionOptions {
+	o.ReadPreference = rp
+	return o
+}
+
+// SetReadConcern sets the read concern.
+func (o *CollectionOptions) SetReadConcern(rc *ReadConcern) *CollectionOptions {
+	o.ReadConcern = rc
+	return o
+}
+
+// SetWriteConcern sets the write concern.
+func (o *CollectionOptions) SetWriteConcern(wc *WriteConcern) *CollectionOptions {
+	o.WriteConcern = wc
+	return o
+}
+
+// SetMaxModifiedDocuments sets the maximum number of documents UpdateMany and
+// DeleteMany may affect without an explicit override.
+func (o *CollectionOptions) SetMaxModifiedDocuments(n int64) *CollectionOptions {
+	o.MaxModifiedDocuments = &n
+	return o
+}
+
+// SetTimeout sets the default operation timeout for the collection.
+func (o *CollectionOptions) SetTimeout(d time.Duration) *CollectionOptions {
+	o.Timeout = &d
+	return o
+}
+
+// SetRejectUnsafeKeys sets whether inserts and replacements reject keys that
+// start with "$" or contain ".".
+func (o *CollectionOptions) SetRejectUnsafeKeys(reject bool) *CollectionOptions {
+	o.RejectUnsafeKeys = &reject
+	return o
+}
+
+// SetSchema sets the JSON Schema that writes are validated against.
+func (o *CollectionOptions) SetSchema(schema *Schema) *CollectionOptions {
+	o.Schema = schema
+	return o
+}
+
+// SetDisallowUnknownFields sets whether decoding fails on fields the target
+// struct does not have.
+func (o *CollectionOptions) SetDisallowUnknownFields(disallow bool) *CollectionOptions {
+	o.DisallowUnknownFields = &disallow
+	return o
+}
+
+// SetVersioning sets the schema versioning of documents.
+func (o *CollectionOptions) SetVersioning(versioning *DocumentVersioning) *CollectionOptions {
+	o.Versioning = versioning
+	return o
+}
+
+// SetNamingStrategy sets how untagged struct fields are named.
+func (o *CollectionOptions) SetNamingStrategy(n NamingStrategy) *CollectionOptions {
+	o.NamingStrategy = &n
+	return o
+}
+
+// SetNumberDecoding sets how numbers are decoded in untyped results.
+func (o *CollectionOptions) SetNumberDecoding(n NumberDecoding) *CollectionOptions {
+	o.NumberDecoding = &n
+	return o
+}
+
+// SetDecoderConfig sets how decoded values are assigned.
+func (o *CollectionOptions) SetDecoderConfig(config *DecoderConfig) *CollectionOptions {
+	o.DecoderConfig = config
+	return o
+}
+
+// validate reports an error for option values that cannot apply.
+func (o *CollectionOptions) validate() error {
+	if o.MaxModifiedDocuments != nil && *o.MaxModifiedDocuments < 0 {
+		return fmt.Errorf("%w: maxModifiedDocuments %d is negative", ErrInvalidOption, *o.MaxModifiedDocuments)
+	}
+	if o.Timeout != nil && *o.Timeout < 0 {
+		return fmt.Errorf("%w: timeout %s is negative", ErrInvalidOption, *o.Timeout)
+	}
+	if o.NamingStrategy != nil && (*o.NamingStrategy < NamingAsIs || *o.NamingStrategy > NamingSnakeCase) {
+		return fmt.Errorf("%w: naming strategy %d", ErrInvalidOption, *o.NamingStrategy)
+	}
+	if o.NumberDecoding != nil && (*o.NumberDecoding < NumberDecodingFloat64 || *o.NumberDecoding > NumberDecodingInt64WhenExact) {
+		return fmt.Errorf("%w: number decoding %d", ErrInvalidOption, *o.NumberDecoding)
+	}
+	return nil
+}
+
+// newCollection creates a collection handle inheriting the database defaults
+// and applying the given options on top.
+func newCollection(db *Database, name string, opts ...*CollectionOptions) *Collection {
+	coll := &Collection{
+		database:       db,
+		name:           name,
+		readPreference: db.readPreference,
+		readConcern:    db.readConcern,
+		writeConcern:   db.writeConcern,
+		strict:         db.client.strict,
+		naming:         db.client.naming,
+		numbers:        db.client.numbers,
+		decoder:        db.client.decoder,
+		dbGeneration:   db.generation.Load(),
+	}
+	coll.generation.Store(db.client.namespaces.current(coll.namespace()))
+	applyCollectionOptions(coll, opts)
+	return coll
+}
+
+// applyCollectionOptions sets the fields of coll that opts set, later
+// options taking precedence.
+func applyCollectionOptions(coll *Collection, opts []*CollectionOptions) {
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.ReadPreference != nil {
+				coll.readPreference = opt.ReadPreference
+			}
+			if opt.ReadConcern != nil {
+				coll.readConcern = opt.ReadConcern
+			}
+			if opt.WriteConcern != nil {
+				coll.writeConcern = opt.WriteConcern
+			}
+			if opt.MaxModifiedDocuments != nil {
+				coll.maxModified = *opt.MaxModifiedDocuments
+			}
+			if opt.Timeout != nil {
+				coll.timeout = opt.Timeout
+			}
+			if opt.RejectUnsafeKeys != nil {
+				coll.rejectUnsafeKeys = *opt.RejectUnsafeKeys
+			}
+			if opt.Schema != nil {
+				coll.schema = opt.Schema
+			}
+			if opt.DisallowUnknownFields != nil {
+				coll.strict = *opt.DisallowUnknownFields
+			}
+			if opt.Versioning != nil {
+				coll.versioning = opt.Versioning
+			}
+			if opt.NamingStrategy != nil {
+				coll.naming = *opt.NamingStrategy
+			}
+			if opt.NumberDecoding != nil {
+				coll.numbers = *opt.NumberDecoding
+			}
+			if config := opt.DecoderConfig; config != nil {
+				coll.decoder = *config
+				if config.UseNumber && coll.numbers == NumberDecodingFloat64 {
+					coll.numbers = NumberDecodingJSONNumber
+				}
+			}
+		}
+	}
 }
 
 // Name returns the name of the collection.
@@ -21,14 +246,143 @@ func (c *Collection) Database() *Database {
 	return c.database
 }
 
+// Clone returns a new handle for the same collection with the given options
+// applied on top of this handle's defaults. The original handle is unchanged.
+// It fails with ErrInvalidOption if an option has a value that cannot apply,
+// such as a negative MaxModifiedDocuments.
+func (c *Collection) Clone(opts ...*CollectionOptions) (*Collection, error) {
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	clone := &Collection{
+		database:         c.database,
+		name:             c.name,
+		readPreference:   c.readPreference,
+		readConcern:      c.readConcern,
+		writeConcern:     c.writeConcern,
+		maxModified:      c.maxModified,
+		timeout:          c.timeout,
+		rejectUnsafeKeys: c.rejectUnsafeKeys,
+		schema:           c.schema,
+		strict:           c.strict,
+		versioning:       c.versioning,
+		naming:           c.naming,
+		numbers:          c.numbers,
+		decoder:          c.decoder,
+		dbGeneration:     c.dbGeneration,
+	}
+	clone.generation.Store(c.generation.Load())
+	applyCollectionOptions(clone, opts)
+	return clone, nil
+}
+
+// namespace returns the full "db.collection" namespace.
+func (c *Collection) namespace() string {
+	return c.database.name + "." + c.name
+}
+
+// encode applies the handle's naming strategy to a document, update or
+// replacement before it is sent.
+func (c *Collection) encode(v any) any {
+	return encodeNamed(v, c.naming)
+}
+
+// cursor returns a cursor over docs that decodes with the handle's codec
+// settings.
+func (c *Collection) cursor(docs []any) *Cursor {
+	cur := newCursor(c.database.client.resultDocuments(docs))
+	cur.naming = c.naming
+	cur.numbers = c.numbers
+	cur.decoder = c.decoder
+	cur.strict = c.strict
+	c.database.client.trackCursor(cur, c.namespace())
+	return cur
+}
+
+// singleResult returns a SingleResult for doc that decodes with the
+// handle's codec settings.
+func (c *Collection) singleResult(doc any) *SingleResult {
+	doc, err := c.upgradeVersion(doc)
+	if err != nil {
+		return newSingleResultError(err)
+	}
+	return c.decodedResult(c.database.client.resultDocument(doc))
+}
+
+// decodedResult returns a SingleResult for doc, whose Extended JSON has
+// already been converted.
+func (c *Collection) decodedResult(doc any) *SingleResult {
+	sr := newSingleResult(doc)
+	sr.naming = c.naming
+	sr.numbers = c.numbers
+	sr.decoder = c.decoder
+	sr.strict = c.strict
+	return sr
+}
+
+// ReadPreference returns the read preference used by this collection handle.
+func (c *Collection) ReadPreference() *ReadPreference {
+	return c.readPreference
+}
+
+// ReadConcern returns the read concern used by this collection handle.
+func (c *Collection) ReadConcern() *ReadConcern {
+	return c.readConcern
+}
+
+// WriteConcern returns the write concern used by this collection handle.
+func (c *Collection) WriteConcern() *WriteConcern {
+	return c.writeConcern
+}
+
+// readOptions adds the collection's read preference and read concern, the
+// namespace defaults, the write token and request ID from ctx and the client
+// attribution to options.
+func (c *Collection) readOptions(ctx context.Context, options map[string]any) map[string]any {
+	defaults := c.database.client.namespaceDefaults(c.database.name, c.name)
+	rp := c.readPreference
+	if rp == nil {
+		rp = defaults.ReadPreference
+	}
+	if rp != nil {
+		options["readPreference"] = rp.document()
+	}
+	if c.readConcern != nil {
+		options["readConcern"] = c.readConcern.document()
+	}
+	defaults.apply(options)
+	applyWriteToken(ctx, options)
+	c.database.client.tagOperation(ctx, options)
+	return options
+}
+
+// writeOptions adds the collection's write concern, the namespace defaults,
+// the request ID from ctx and the client attribution to options.
+func (c *Collection) writeOptions(ctx context.Context, options map[string]any) map[string]any {
+	if c.writeConcern != nil {
+		options["writeConcern"] = c.writeConcern.document()
+	}
+	c.database.client.namespaceDefaults(c.database.name, c.name).apply(options)
+	c.database.client.tagOperation(ctx, options)
+	return options
+}
+
 // InsertOneResult represents the result of an InsertOne operation.
 type InsertOneResult struct {
 	InsertedID any
+	// Token identifies the write for read-after-write reads; see
+	// WithWriteToken. It is nil when the server does not report one.
+	Token *WriteToken
 }
 
 // InsertManyResult represents the result of an InsertMany operation.
 type InsertManyResult struct {
 	InsertedIDs []any
+	Token       *WriteToken
 }
 
 // UpdateResult represents the result of an Update operation.
@@ -37,11 +391,13 @@ type UpdateResult struct {
 	ModifiedCount int64
 	UpsertedCount int64
 	UpsertedID    any
+	Token         *WriteToken
 }
 
 // DeleteResult represents the result of a Delete operation.
 type DeleteResult struct {
 	DeletedCount int64
+	Token        *WriteToken
 }
 
 // CountResult represents the result of a Count operation.
@@ -57,6 +413,7 @@ type BulkWriteResult struct {
 	DeletedCount  int64
 	UpsertedCount int64
 	UpsertedIDs   map[int64]any
+	Token         *WriteToken
 }
 
 // IndexModel represents an index to be created.
@@ -80,24 +437,18 @@ func (c *Collection) InsertOne(ctx context.Context, document any) (*InsertOneRes
 		return nil, ErrNilDocument
 	}
 
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
+	document, err := c.stampVersion(c.encode(withGeneratedID(c.database.client.idGenerator, document)))
+	if err != nil {
+		return nil, err
 	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if err := c.checkKeys(document); err != nil {
+		return nil, err
+	}
+	if err := c.checkSchema(document); err != nil {
+		return nil, err
 	}
 
-	promise := rpcClient.Call("mongo.insertOne", c.database.name, c.name, document)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.insertOne", withOptions([]any{c.database.name, c.name, document}, c.writeOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return nil, err
 	}
@@ -105,72 +456,263 @@ func (c *Collection) InsertOne(ctx context.Context, document any) (*InsertOneRes
 	// Parse result
 	if r, ok := result.(map[string]any); ok {
 		return &InsertOneResult{
-			InsertedID: r["insertedId"],
+			InsertedID: normalizeID(r["insertedId"]),
+			Token:      parseWriteToken(r),
 		}, nil
 	}
 
-	return &InsertOneResult{InsertedID: result}, nil
+	return &InsertOneResult{InsertedID: normalizeID(result)}, nil
 }
 
-// InsertMany inserts multiple documents into the collection.
-func (c *Collection) InsertMany(ctx context.Context, documents []any) (*InsertManyResult, error) {
+// InsertManyOptions configures an InsertMany operation.
+type InsertManyOptions struct {
+	// BatchSize caps the number of documents per request below the
+	// server's MaxWriteBatchSize.
+	BatchSize *int
+	// Progress is called after each batch.
+	Progress ProgressFunc
+}
+
+// SetBatchSize sets the maximum number of documents per request.
+func (o *InsertManyOptions) SetBatchSize(size int) *InsertManyOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetProgress sets the function called after each batch.
+func (o *InsertManyOptions) SetProgress(progress ProgressFunc) *InsertManyOptions {
+	o.Progress = progress
+	return o
+}
+
+// InsertMany inserts multiple documents into the collection. A Progress
+// function set in the options is told about each batch, including a
+// failed one.
+func (c *Collection) InsertMany(ctx context.Context, documents []any, opts ...*InsertManyOptions) (*InsertManyResult, error) {
 	if documents == nil || len(documents) == 0 {
 		return nil, ErrNilDocument
 	}
+	var batchSize *int
+	var progress ProgressFunc
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.BatchSize != nil {
+				batchSize = opt.BatchSize
+			}
+			if opt.Progress != nil {
+				progress = opt.Progress
+			}
+		}
+	}
+	size, err := c.writeBatchSize(batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if gen := c.database.client.idGenerator; gen != nil {
+		withIDs := make([]any, len(documents))
+		for i, doc := range documents {
+			withIDs[i] = withGeneratedID(gen, doc)
+		}
+		documents = withIDs
+	}
+	encoded := make([]any, len(documents))
+	for i, doc := range documents {
+		stamped, err := c.stampVersion(c.encode(doc))
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = stamped
+	}
+	documents = encoded
+	for _, doc := range documents {
+		if err := c.checkKeys(doc); err != nil {
+			return nil, err
+		}
+		if err := c.checkSchema(doc); err != nil {
+			return nil, err
+		}
+	}
 
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
+	// Writes larger than the server's batch limit are sent in batches, so
+	// a failed batch leaves the earlier ones inserted.
+	clock := c.database.client.clock
+	inserted := &InsertManyResult{}
+	for start := 0; start < len(documents); start += size {
+		batch := documents[start:min(start+size, len(documents))]
+		began := clock.Now()
+		result, err := c.execute(ctx, "mongo.insertMany", withOptions([]any{c.database.name, c.name, batch}, c.writeOptions(ctx, make(map[string]any)))...)
+		if reportErr := progress.report(c.database.client, BatchProgress{
+			Batch:     start / size,
+			Processed: start + len(batch),
+			Total:     len(documents),
+			Latency:   clock.Now().Sub(began),
+			Err:       err,
+		}); err == nil {
+			err = reportErr
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	if !connected {
-		return nil, ErrClientDisconnected
+		// Parse result
+		r, ok := result.(map[string]any)
+		if !ok {
+			continue
+		}
+		ids := parseInsertedIDs(r["insertedIds"], len(batch))
+		if start+len(batch) < len(documents) && len(ids) < len(batch) {
+			// Keep the IDs of later batches at their document's index.
+			ids = append(ids, make([]any, len(batch)-len(ids))...)
+		}
+		inserted.InsertedIDs = append(inserted.InsertedIDs, ids...)
+		if token := parseWriteToken(r); token != nil {
+			inserted.Token = token
+		}
 	}
+	return inserted, nil
+}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// InsertAndGet inserts document and returns it as stored, including its _id
+// and any fields filled in by the server. When the document has an _id, or
+// the client generates one, the insert and the read happen in a single
+// findOneAndUpdate upsert; otherwise the document is inserted and then read
+// back by its inserted _id.
+func (c *Collection) InsertAndGet(ctx context.Context, document any) *SingleResult {
+	if document == nil {
+		return newSingleResultError(ErrNilDocument)
 	}
 
-	promise := rpcClient.Call("mongo.insertMany", c.database.name, c.name, documents)
-	result, err := promise.Await()
+	doc, ok := encodeNamedValue(reflect.ValueOf(document), c.naming).(map[string]any)
+	if !ok {
+		return c.insertThenGet(ctx, document)
+	}
+	doc = withGeneratedID(c.database.client.idGenerator, doc).(map[string]any)
+	if err := c.checkKeys(doc); err != nil {
+		return newSingleResultError(err)
+	}
+	if err := c.checkSchema(doc); err != nil {
+		return newSingleResultError(err)
+	}
+	id, ok := doc["_id"]
+	if !ok || len(doc) == 1 {
+		return c.insertThenGet(ctx, doc)
+	}
+	stamped, err := c.stampVersion(doc)
 	if err != nil {
-		return nil, err
+		return newSingleResultError(err)
 	}
+	doc = stamped.(map[string]any)
 
-	// Parse result
-	if r, ok := result.(map[string]any); ok {
-		ids, _ := r["insertedIds"].([]any)
-		return &InsertManyResult{
-			InsertedIDs: ids,
-		}, nil
+	fields := make(map[string]any, len(doc)-1)
+	for k, v := range doc {
+		if k != "_id" {
+			fields[k] = v
+		}
+	}
+	// The filter never matches a stored document, so the upsert always
+	// inserts, taking _id from the equality clause, and an existing _id
+	// fails with a duplicate key error just as it would for InsertOne.
+	filter := map[string]any{"$and": []any{
+		map[string]any{"_id": id},
+		map[string]any{"_id": map[string]any{"$exists": false}},
+	}}
+	update := map[string]any{"$setOnInsert": fields}
+	options := map[string]any{"upsert": true, "returnDocument": string(ReturnDocumentAfter)}
+
+	result, err := c.execute(ctx, "mongo.findOneAndUpdate", c.database.name, c.name, filter, update, c.writeOptions(ctx, options))
+	if err != nil {
+		return newSingleResultError(err)
+	}
+	if result == nil {
+		return newSingleResultError(ErrNoDocuments)
 	}
 
-	return &InsertManyResult{}, nil
+	return c.singleResult(result)
 }
 
-// FindOne finds a single document matching the filter.
-func (c *Collection) FindOne(ctx context.Context, filter any) *SingleResult {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return newSingleResultError(ErrClientDisconnected)
+// insertThenGet inserts document and reads it back by its inserted _id.
+func (c *Collection) insertThenGet(ctx context.Context, document any) *SingleResult {
+	inserted, err := c.InsertOne(ctx, document)
+	if err != nil {
+		return newSingleResultError(err)
+	}
+	if inserted.InsertedID == nil {
+		return newSingleResultError(newProtocolError("mongo.insertOne", "an inserted id", nil))
 	}
+	return c.FindOne(ctx, map[string]any{"_id": inserted.InsertedID})
+}
+
+// FindOneOptions configures a FindOne operation.
+type FindOneOptions struct {
+	Sort       any
+	Projection any
+	// AutoProjection derives the projection from the destination struct
+	// when using FindOneAs.
+	AutoProjection *bool
+	// ReadRepairField enables read repair: the document is read from both
+	// the client's endpoint and its ReadRepairEndpoint, and if both return
+	// the same document, the copy with the greater value at this version
+	// field, such as "updatedAt", is returned. A document only the
+	// ReadRepairEndpoint returns is not: the main endpoint may have deleted
+	// it or updated it out of the filter.
+	ReadRepairField *string
+}
+
+// SetSort sets the sort order used to pick the document.
+func (o *FindOneOptions) SetSort(sort any) *FindOneOptions {
+	o.Sort = sort
+	return o
+}
+
+// SetProjection sets the projection.
+func (o *FindOneOptions) SetProjection(projection any) *FindOneOptions {
+	o.Projection = projection
+	return o
+}
+
+// SetAutoProjection sets whether FindOneAs derives the projection from the
+// destination struct.
+func (o *FindOneOptions) SetAutoProjection(auto bool) *FindOneOptions {
+	o.AutoProjection = &auto
+	return o
+}
+
+// SetReadRepair enables read repair using the given version field.
+func (o *FindOneOptions) SetReadRepair(field string) *FindOneOptions {
+	o.ReadRepairField = &field
+	return o
+}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return newSingleResultError(ctx.Err())
-	default:
+// FindOne finds a single document matching the filter.
+func (c *Collection) FindOne(ctx context.Context, filter any, opts ...*FindOneOptions) *SingleResult {
+	// Build options map
+	options := make(map[string]any)
+	var repairField string
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.Sort != nil {
+				options["sort"] = opt.Sort
+			}
+			if opt.Projection != nil {
+				options["projection"] = opt.Projection
+			}
+			if opt.ReadRepairField != nil {
+				repairField = *opt.ReadRepairField
+			}
+		}
 	}
 
-	promise := rpcClient.Call("mongo.findOne", c.database.name, c.name, filter)
-	result, err := promise.Await()
+	args := withOptions([]any{c.database.name, c.name, filter}, c.readOptions(ctx, options))
+	var result any
+	var err error
+	if repairField != "" {
+		var replica any
+		result, replica, err = c.executeRepaired(ctx, "mongo.findOne", args...)
+		result = repairedDocument(result, replica, repairField)
+	} else {
+		result, err = c.execute(ctx, "mongo.findOne", args...)
+	}
 	if err != nil {
 		return newSingleResultError(err)
 	}
@@ -179,7 +721,7 @@ func (c *Collection) FindOne(ctx context.Context, filter any) *SingleResult {
 		return newSingleResultError(ErrNoDocuments)
 	}
 
-	return newSingleResult(result)
+	return c.singleResult(result)
 }
 
 // FindOptions configures a Find operation.
@@ -188,6 +730,21 @@ type FindOptions struct {
 	Projection any
 	Limit      *int64
 	Skip       *int64
+	// NoCursorTimeout asks the server not to time out an idle cursor for
+	// the query. Both backends return results in full, draining the server
+	// cursor before Find returns, so it has no practical effect; cursors
+	// left open are killed when the client disconnects.
+	NoCursorTimeout *bool
+	// AutoProjection derives the projection from the destination struct
+	// when using FindAs.
+	AutoProjection *bool
+	// ReadRepairField enables read repair: the query runs against both the
+	// client's endpoint and its ReadRepairEndpoint, and each document of the
+	// main endpoint's result is replaced by the ReadRepairEndpoint's copy
+	// if that has the greater value at this field. Documents only the
+	// ReadRepairEndpoint returned are left out, and the main endpoint's
+	// order is kept.
+	ReadRepairField *string
 }
 
 // SetSort sets the sort order.
@@ -214,26 +771,30 @@ func (o *FindOptions) SetSkip(skip int64) *FindOptions {
 	return o
 }
 
-// Find finds all documents matching the filter.
-func (c *Collection) Find(ctx context.Context, filter any, opts ...*FindOptions) (*Cursor, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
+// SetNoCursorTimeout sets whether the server cursor may time out when idle.
+func (o *FindOptions) SetNoCursorTimeout(noTimeout bool) *FindOptions {
+	o.NoCursorTimeout = &noTimeout
+	return o
+}
 
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
+// SetAutoProjection sets whether FindAs derives the projection from the
+// destination struct.
+func (o *FindOptions) SetAutoProjection(auto bool) *FindOptions {
+	o.AutoProjection = &auto
+	return o
+}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
+// SetReadRepair enables read repair using the given version field.
+func (o *FindOptions) SetReadRepair(field string) *FindOptions {
+	o.ReadRepairField = &field
+	return o
+}
 
+// Find finds all documents matching the filter.
+func (c *Collection) Find(ctx context.Context, filter any, opts ...*FindOptions) (*Cursor, error) {
 	// Build options map
 	options := make(map[string]any)
+	var repairField string
 	for _, opt := range opts {
 		if opt != nil {
 			if opt.Sort != nil {
@@ -248,11 +809,33 @@ func (c *Collection) Find(ctx context.Context, filter any, opts ...*FindOptions)
 			if opt.Skip != nil {
 				options["skip"] = *opt.Skip
 			}
+			if opt.NoCursorTimeout != nil {
+				options["noCursorTimeout"] = *opt.NoCursorTimeout
+			}
+			if opt.ReadRepairField != nil {
+				repairField = *opt.ReadRepairField
+			}
 		}
 	}
 
-	promise := rpcClient.Call("mongo.find", c.database.name, c.name, filter, options)
-	result, err := promise.Await()
+	// Without an explicit limit, ask for one document more than the
+	// guardrail allows so an oversized result can be detected. A limit of
+	// zero means no limit, so it does not count as explicit.
+	maxDocs := c.database.client.limits.MaxFindDocuments
+	limit, _ := options["limit"].(int64)
+	explicitLimit := limit != 0
+	if maxDocs > 0 && !explicitLimit {
+		options["limit"] = maxDocs + 1
+	}
+
+	args := []any{c.database.name, c.name, filter, c.readOptions(ctx, options)}
+	var result, replica any
+	var err error
+	if repairField != "" {
+		result, replica, err = c.executeRepaired(ctx, "mongo.find", args...)
+	} else {
+		result, err = c.execute(ctx, "mongo.find", args...)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -260,16 +843,38 @@ func (c *Collection) Find(ctx context.Context, filter any, opts ...*FindOptions)
 	// Parse result as documents array
 	docs, ok := result.([]any)
 	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", result)
+		return nil, unexpectedResponse("mongo.find", result)
+	}
+	if replicaDocs, ok := replica.([]any); ok {
+		docs = mergeRepaired(docs, replicaDocs, repairField)
 	}
 
-	return newCursor(docs), nil
+	if maxDocs > 0 && !explicitLimit && int64(len(docs)) > maxDocs {
+		return nil, &ResultTooLargeError{
+			Namespace: c.namespace(),
+			Limit:     maxDocs,
+			Unit:      "documents",
+		}
+	}
+	if err := c.database.client.limits.checkDecodedBytes(c.namespace(), docs); err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		if docs[i], err = c.upgradeVersion(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.cursor(docs), nil
 }
 
 // UpdateOptions configures an Update operation.
 type UpdateOptions struct {
 	Upsert       *bool
 	ArrayFilters []any
+	// BypassModifyLimit lets UpdateMany exceed the collection's
+	// MaxModifiedDocuments.
+	BypassModifyLimit *bool
 }
 
 // SetUpsert sets the upsert option.
@@ -278,30 +883,23 @@ func (o *UpdateOptions) SetUpsert(upsert bool) *UpdateOptions {
 	return o
 }
 
-// SetArrayFilters sets the array filters.
+// SetArrayFilters sets the array filters, typically built with
+// NewArrayFilters. The filters are checked against the identifiers the
+// update references before the update is sent.
 func (o *UpdateOptions) SetArrayFilters(filters []any) *UpdateOptions {
 	o.ArrayFilters = filters
 	return o
 }
 
+// SetBypassModifyLimit sets whether UpdateMany may exceed the collection's
+// MaxModifiedDocuments.
+func (o *UpdateOptions) SetBypassModifyLimit(bypass bool) *UpdateOptions {
+	o.BypassModifyLimit = &bypass
+	return o
+}
+
 // UpdateOne updates a single document matching the filter.
 func (c *Collection) UpdateOne(ctx context.Context, filter any, update any, opts ...*UpdateOptions) (*UpdateResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
 	// Build options map
 	options := make(map[string]any)
 	for _, opt := range opts {
@@ -315,8 +913,20 @@ func (c *Collection) UpdateOne(ctx context.Context, filter any, update any, opts
 		}
 	}
 
-	promise := rpcClient.Call("mongo.updateOne", c.database.name, c.name, filter, update, options)
-	result, err := promise.Await()
+	update, err := c.stampUpsert(c.encode(update), options)
+	if err != nil {
+		return nil, err
+	}
+	if filters, ok := options["arrayFilters"].([]any); ok {
+		if err := validateArrayFilters(update, filters); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkUpdateSchema(update); err != nil {
+		return nil, err
+	}
+
+	result, err := c.execute(ctx, "mongo.updateOne", c.database.name, c.name, filter, update, c.writeOptions(ctx, options))
 	if err != nil {
 		return nil, err
 	}
@@ -326,24 +936,9 @@ func (c *Collection) UpdateOne(ctx context.Context, filter any, update any, opts
 
 // UpdateMany updates all documents matching the filter.
 func (c *Collection) UpdateMany(ctx context.Context, filter any, update any, opts ...*UpdateOptions) (*UpdateResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
 	// Build options map
 	options := make(map[string]any)
+	bypass := false
 	for _, opt := range opts {
 		if opt != nil {
 			if opt.Upsert != nil {
@@ -352,11 +947,31 @@ func (c *Collection) UpdateMany(ctx context.Context, filter any, update any, opt
 			if opt.ArrayFilters != nil {
 				options["arrayFilters"] = opt.ArrayFilters
 			}
+			if opt.BypassModifyLimit != nil {
+				bypass = *opt.BypassModifyLimit
+			}
+		}
+	}
+
+	update, err := c.stampUpsert(c.encode(update), options)
+	if err != nil {
+		return nil, err
+	}
+	if filters, ok := options["arrayFilters"].([]any); ok {
+		if err := validateArrayFilters(update, filters); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkUpdateSchema(update); err != nil {
+		return nil, err
+	}
+	if !bypass {
+		if err := c.checkModifyLimit(ctx, "updateMany", filter); err != nil {
+			return nil, err
 		}
 	}
 
-	promise := rpcClient.Call("mongo.updateMany", c.database.name, c.name, filter, update, options)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.updateMany", c.database.name, c.name, filter, update, c.writeOptions(ctx, options))
 	if err != nil {
 		return nil, err
 	}
@@ -366,22 +981,6 @@ func (c *Collection) UpdateMany(ctx context.Context, filter any, update any, opt
 
 // ReplaceOne replaces a single document matching the filter.
 func (c *Collection) ReplaceOne(ctx context.Context, filter any, replacement any, opts ...*UpdateOptions) (*UpdateResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
 	// Build options map
 	options := make(map[string]any)
 	for _, opt := range opts {
@@ -392,8 +991,18 @@ func (c *Collection) ReplaceOne(ctx context.Context, filter any, replacement any
 		}
 	}
 
-	promise := rpcClient.Call("mongo.replaceOne", c.database.name, c.name, filter, replacement, options)
-	result, err := promise.Await()
+	replacement, err := c.stampVersion(c.encode(replacement))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkKeys(replacement); err != nil {
+		return nil, err
+	}
+	if err := c.checkSchema(replacement); err != nil {
+		return nil, err
+	}
+
+	result, err := c.execute(ctx, "mongo.replaceOne", c.database.name, c.name, filter, replacement, c.writeOptions(ctx, options))
 	if err != nil {
 		return nil, err
 	}
@@ -401,20 +1010,44 @@ func (c *Collection) ReplaceOne(ctx context.Context, filter any, replacement any
 	return parseUpdateResult(result), nil
 }
 
+// checkModifyLimit counts the documents filter matches and fails if a
+// multi-document write would affect more than the collection allows. The
+// count is a preflight estimate: writes racing with it can change the
+// number of documents actually affected.
+func (c *Collection) checkModifyLimit(ctx context.Context, operation string, filter any) error {
+	if c.maxModified <= 0 {
+		return nil
+	}
+	matched, err := c.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if matched > c.maxModified {
+		return &ModifyLimitError{
+			Namespace: c.namespace(),
+			Operation: operation,
+			Limit:     c.maxModified,
+			Matched:   matched,
+		}
+	}
+	return nil
+}
+
 // parseUpdateResult parses an update result from the RPC response.
 func parseUpdateResult(result any) *UpdateResult {
 	r := &UpdateResult{}
 	if m, ok := result.(map[string]any); ok {
-		if v, ok := m["matchedCount"].(float64); ok {
-			r.MatchedCount = int64(v)
+		if v, ok := asInt64(m["matchedCount"]); ok {
+			r.MatchedCount = v
 		}
-		if v, ok := m["modifiedCount"].(float64); ok {
-			r.ModifiedCount = int64(v)
+		if v, ok := asInt64(m["modifiedCount"]); ok {
+			r.ModifiedCount = v
 		}
-		if v, ok := m["upsertedCount"].(float64); ok {
-			r.UpsertedCount = int64(v)
+		if v, ok := asInt64(m["upsertedCount"]); ok {
+			r.UpsertedCount = v
 		}
-		r.UpsertedID = m["upsertedId"]
+		r.UpsertedID = normalizeID(m["upsertedId"])
+		r.Token = parseWriteToken(m)
 	}
 	return r
 }
@@ -422,6 +1055,9 @@ func parseUpdateResult(result any) *UpdateResult {
 // DeleteOptions configures a Delete operation.
 type DeleteOptions struct {
 	Collation *Collation
+	// BypassModifyLimit lets DeleteMany exceed the collection's
+	// MaxModifiedDocuments.
+	BypassModifyLimit *bool
 }
 
 // Collation specifies language-specific rules for string comparison.
@@ -436,26 +1072,16 @@ func (o *DeleteOptions) SetCollation(collation *Collation) *DeleteOptions {
 	return o
 }
 
+// SetBypassModifyLimit sets whether DeleteMany may exceed the collection's
+// MaxModifiedDocuments.
+func (o *DeleteOptions) SetBypassModifyLimit(bypass bool) *DeleteOptions {
+	o.BypassModifyLimit = &bypass
+	return o
+}
+
 // DeleteOne deletes a single document matching the filter.
 func (c *Collection) DeleteOne(ctx context.Context, filter any, opts ...*DeleteOptions) (*DeleteResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.deleteOne", c.database.name, c.name, filter)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.deleteOne", withOptions([]any{c.database.name, c.name, filter}, c.writeOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return nil, err
 	}
@@ -465,24 +1091,19 @@ func (c *Collection) DeleteOne(ctx context.Context, filter any, opts ...*DeleteO
 
 // DeleteMany deletes all documents matching the filter.
 func (c *Collection) DeleteMany(ctx context.Context, filter any, opts ...*DeleteOptions) (*DeleteResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
+	bypass := false
+	for _, opt := range opts {
+		if opt != nil && opt.BypassModifyLimit != nil {
+			bypass = *opt.BypassModifyLimit
+		}
 	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if !bypass {
+		if err := c.checkModifyLimit(ctx, "deleteMany", filter); err != nil {
+			return nil, err
+		}
 	}
 
-	promise := rpcClient.Call("mongo.deleteMany", c.database.name, c.name, filter)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.deleteMany", withOptions([]any{c.database.name, c.name, filter}, c.writeOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return nil, err
 	}
@@ -494,95 +1115,45 @@ func (c *Collection) DeleteMany(ctx context.Context, filter any, opts ...*Delete
 func parseDeleteResult(result any) *DeleteResult {
 	r := &DeleteResult{}
 	if m, ok := result.(map[string]any); ok {
-		if v, ok := m["deletedCount"].(float64); ok {
-			r.DeletedCount = int64(v)
+		if v, ok := asInt64(m["deletedCount"]); ok {
+			r.DeletedCount = v
 		}
+		r.Token = parseWriteToken(m)
 	}
 	return r
 }
 
 // CountDocuments returns the number of documents matching the filter.
 func (c *Collection) CountDocuments(ctx context.Context, filter any) (int64, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return 0, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.countDocuments", c.database.name, c.name, filter)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.countDocuments", withOptions([]any{c.database.name, c.name, filter}, c.readOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return 0, err
 	}
 
-	if v, ok := result.(float64); ok {
-		return int64(v), nil
+	if v, ok := asInt64(result); ok {
+		return v, nil
 	}
 
-	return 0, fmt.Errorf("unexpected result type: %T", result)
+	return 0, unexpectedResponse("mongo.countDocuments", result)
 }
 
 // EstimatedDocumentCount returns an estimate of the number of documents in the collection.
 func (c *Collection) EstimatedDocumentCount(ctx context.Context) (int64, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return 0, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.estimatedDocumentCount", c.database.name, c.name)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.estimatedDocumentCount", withOptions([]any{c.database.name, c.name}, c.readOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return 0, err
 	}
 
-	if v, ok := result.(float64); ok {
-		return int64(v), nil
+	if v, ok := asInt64(result); ok {
+		return v, nil
 	}
 
-	return 0, fmt.Errorf("unexpected result type: %T", result)
+	return 0, unexpectedResponse("mongo.estimatedDocumentCount", result)
 }
 
 // Distinct returns distinct values for the given field.
 func (c *Collection) Distinct(ctx context.Context, fieldName string, filter any) ([]any, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.distinct", c.database.name, c.name, fieldName, filter)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.distinct", withOptions([]any{c.database.name, c.name, fieldName, filter}, c.readOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return nil, err
 	}
@@ -591,29 +1162,43 @@ func (c *Collection) Distinct(ctx context.Context, fieldName string, filter any)
 		return values, nil
 	}
 
-	return nil, fmt.Errorf("unexpected result type: %T", result)
+	return nil, unexpectedResponse("mongo.distinct", result)
 }
 
 // Aggregate runs an aggregation pipeline on the collection.
-func (c *Collection) Aggregate(ctx context.Context, pipeline any) (*Cursor, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
+// The pipeline is checked with LintPipeline first; errors reject it before
+// the RPC call, as do warnings with AggregateOptions.SetStrictPipeline(true).
+func (c *Collection) Aggregate(ctx context.Context, pipeline any, opts ...*AggregateOptions) (*Cursor, error) {
+	options := make(map[string]any)
+	maxDocs := c.database.client.limits.MaxBufferedDocuments
+	strict := false
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.AllowDiskUse != nil {
+				options["allowDiskUse"] = *opt.AllowDiskUse
+			}
+			if opt.MaxBufferedDocuments != nil {
+				maxDocs = *opt.MaxBufferedDocuments
+			}
+			if opt.StrictPipeline != nil {
+				strict = *opt.StrictPipeline
+			}
+		}
+	}
 
-	if !connected {
-		return nil, ErrClientDisconnected
+	if err := lintPipeline(pipeline, strict); err != nil {
+		return nil, err
 	}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	// Ask for one document more than the cap so the server stops producing
+	// results as soon as an oversized result can be detected.
+	if maxDocs > 0 {
+		if limited, ok := withLimit(pipeline, maxDocs+1); ok {
+			pipeline = limited
+		}
 	}
 
-	promise := rpcClient.Call("mongo.aggregate", c.database.name, c.name, pipeline)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.aggregate", withOptions([]any{c.database.name, c.name, pipeline}, c.readOptions(ctx, options))...)
 	if err != nil {
 		return nil, err
 	}
@@ -621,30 +1206,26 @@ func (c *Collection) Aggregate(ctx context.Context, pipeline any) (*Cursor, erro
 	// Parse result as documents array
 	docs, ok := result.([]any)
 	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", result)
+		return nil, unexpectedResponse("mongo.aggregate", result)
+	}
+
+	if maxDocs > 0 && int64(len(docs)) > maxDocs {
+		return nil, &ResultTooLargeError{
+			Namespace:  c.namespace(),
+			Limit:      maxDocs,
+			Unit:       "documents",
+			Suggestion: aggregateBufferSuggestion,
+		}
+	}
+	if err := c.database.client.limits.checkDecodedBytes(c.namespace(), docs); err != nil {
+		return nil, err
 	}
 
-	return newCursor(docs), nil
+	return c.cursor(docs), nil
 }
 
 // FindOneAndUpdate finds a single document and updates it.
 func (c *Collection) FindOneAndUpdate(ctx context.Context, filter any, update any, opts ...*FindOneAndUpdateOptions) *SingleResult {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return newSingleResultError(ErrClientDisconnected)
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return newSingleResultError(ctx.Err())
-	default:
-	}
-
 	// Build options map
 	options := make(map[string]any)
 	for _, opt := range opts {
@@ -653,7 +1234,10 @@ func (c *Collection) FindOneAndUpdate(ctx context.Context, filter any, update an
 				options["upsert"] = *opt.Upsert
 			}
 			if opt.ReturnDocument != nil {
-				options["returnDocument"] = *opt.ReturnDocument
+				if err := opt.ReturnDocument.validate(); err != nil {
+					return newSingleResultError(err)
+				}
+				options["returnDocument"] = string(*opt.ReturnDocument)
 			}
 			if opt.Projection != nil {
 				options["projection"] = opt.Projection
@@ -664,8 +1248,15 @@ func (c *Collection) FindOneAndUpdate(ctx context.Context, filter any, update an
 		}
 	}
 
-	promise := rpcClient.Call("mongo.findOneAndUpdate", c.database.name, c.name, filter, update, options)
-	result, err := promise.Await()
+	update, err := c.stampUpsert(c.encode(update), options)
+	if err != nil {
+		return newSingleResultError(err)
+	}
+	if err := c.checkUpdateSchema(update); err != nil {
+		return newSingleResultError(err)
+	}
+
+	result, err := c.execute(ctx, "mongo.findOneAndUpdate", c.database.name, c.name, filter, update, c.writeOptions(ctx, options))
 	if err != nil {
 		return newSingleResultError(err)
 	}
@@ -674,13 +1265,13 @@ func (c *Collection) FindOneAndUpdate(ctx context.Context, filter any, update an
 		return newSingleResultError(ErrNoDocuments)
 	}
 
-	return newSingleResult(result)
+	return c.singleResult(result)
 }
 
 // FindOneAndUpdateOptions configures a FindOneAndUpdate operation.
 type FindOneAndUpdateOptions struct {
 	Upsert         *bool
-	ReturnDocument *string
+	ReturnDocument *ReturnDocument
 	Projection     any
 	Sort           any
 }
@@ -691,8 +1282,9 @@ func (o *FindOneAndUpdateOptions) SetUpsert(upsert bool) *FindOneAndUpdateOption
 	return o
 }
 
-// SetReturnDocument sets which document to return.
-func (o *FindOneAndUpdateOptions) SetReturnDocument(rd string) *FindOneAndUpdateOptions {
+// SetReturnDocument sets which document to return: ReturnDocumentBefore or
+// ReturnDocumentAfter.
+func (o *FindOneAndUpdateOptions) SetReturnDocument(rd ReturnDocument) *FindOneAndUpdateOptions {
 	o.ReturnDocument = &rd
 	return o
 }
@@ -711,24 +1303,7 @@ func (o *FindOneAndUpdateOptions) SetSort(sort any) *FindOneAndUpdateOptions {
 
 // FindOneAndDelete finds a single document and deletes it.
 func (c *Collection) FindOneAndDelete(ctx context.Context, filter any) *SingleResult {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return newSingleResultError(ErrClientDisconnected)
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return newSingleResultError(ctx.Err())
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.findOneAndDelete", c.database.name, c.name, filter)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.findOneAndDelete", withOptions([]any{c.database.name, c.name, filter}, c.writeOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return newSingleResultError(err)
 	}
@@ -737,29 +1312,23 @@ func (c *Collection) FindOneAndDelete(ctx context.Context, filter any) *SingleRe
 		return newSingleResultError(ErrNoDocuments)
 	}
 
-	return newSingleResult(result)
+	return c.singleResult(result)
 }
 
 // FindOneAndReplace finds a single document and replaces it.
 func (c *Collection) FindOneAndReplace(ctx context.Context, filter any, replacement any) *SingleResult {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return newSingleResultError(ErrClientDisconnected)
+	replacement, err := c.stampVersion(c.encode(replacement))
+	if err != nil {
+		return newSingleResultError(err)
 	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return newSingleResultError(ctx.Err())
-	default:
+	if err := c.checkKeys(replacement); err != nil {
+		return newSingleResultError(err)
+	}
+	if err := c.checkSchema(replacement); err != nil {
+		return newSingleResultError(err)
 	}
 
-	promise := rpcClient.Call("mongo.findOneAndReplace", c.database.name, c.name, filter, replacement)
-	result, err := promise.Await()
+	result, err := c.execute(ctx, "mongo.findOneAndReplace", withOptions([]any{c.database.name, c.name, filter, replacement}, c.writeOptions(ctx, make(map[string]any)))...)
 	if err != nil {
 		return newSingleResultError(err)
 	}
@@ -768,137 +1337,76 @@ func (c *Collection) FindOneAndReplace(ctx context.Context, filter any, replacem
 		return newSingleResultError(ErrNoDocuments)
 	}
 
-	return newSingleResult(result)
+	return c.singleResult(result)
 }
 
-// Drop drops the collection.
+// Drop drops the collection. Other handles for it become stale; the
+// receiver stays usable, and writing through it creates the collection
+// anew.
 func (c *Collection) Drop(ctx context.Context) error {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
+	if _, err := c.execute(ctx, "mongo.dropCollection", c.database.name, c.name); err != nil {
+		return err
 	}
-
-	promise := rpcClient.Call("mongo.dropCollection", c.database.name, c.name)
-	_, err := promise.Await()
-	return err
+	c.generation.Store(c.database.client.namespaces.invalidate(c.namespace()))
+	return nil
 }
 
 // CreateIndex creates an index on the collection.
 func (c *Collection) CreateIndex(ctx context.Context, model IndexModel) (string, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
+	options := indexOptions(model.Options)
 
-	if !connected {
-		return "", ErrClientDisconnected
+	result, err := c.execute(ctx, "mongo.createIndex", c.database.name, c.name, model.Keys, options)
+	if err != nil {
+		return "", err
 	}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return "", ctx.Err()
-	default:
+	if name, ok := result.(string); ok {
+		return name, nil
 	}
 
-	// Build options map
+	return "", nil
+}
+
+// indexOptions builds the createIndex options map.
+func indexOptions(opts *IndexOptions) map[string]any {
 	options := make(map[string]any)
-	if model.Options != nil {
-		if model.Options.Background != nil {
-			options["background"] = *model.Options.Background
+	if opts != nil {
+		if opts.Background != nil {
+			options["background"] = *opts.Background
 		}
-		if model.Options.Unique != nil {
-			options["unique"] = *model.Options.Unique
+		if opts.Unique != nil {
+			options["unique"] = *opts.Unique
 		}
-		if model.Options.Name != nil {
-			options["name"] = *model.Options.Name
+		if opts.Name != nil {
+			options["name"] = *opts.Name
 		}
-		if model.Options.Sparse != nil {
-			options["sparse"] = *model.Options.Sparse
+		if opts.Sparse != nil {
+			options["sparse"] = *opts.Sparse
 		}
-		if model.Options.ExpireAfterSeconds != nil {
-			options["expireAfterSeconds"] = *model.Options.ExpireAfterSeconds
+		if opts.ExpireAfterSeconds != nil {
+			options["expireAfterSeconds"] = *opts.ExpireAfterSeconds
 		}
 	}
-
-	promise := rpcClient.Call("mongo.createIndex", c.database.name, c.name, model.Keys, options)
-	result, err := promise.Await()
-	if err != nil {
-		return "", err
-	}
-
-	if name, ok := result.(string); ok {
-		return name, nil
-	}
-
-	return "", nil
+	return options
 }
 
 // DropIndex drops an index from the collection.
 func (c *Collection) DropIndex(ctx context.Context, name string) error {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.dropIndex", c.database.name, c.name, name)
-	_, err := promise.Await()
+	_, err := c.execute(ctx, "mongo.dropIndex", c.database.name, c.name, name)
 	return err
 }
 
 // Watch opens a change stream on the collection.
-func (c *Collection) Watch(ctx context.Context, pipeline any) (*ChangeStream, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.watch", c.database.name, c.name, pipeline)
-	result, err := promise.Await()
+func (c *Collection) Watch(ctx context.Context, pipeline any, opts ...*ChangeStreamOptions) (*ChangeStream, error) {
+	cs, err := c.database.client.watch(ctx, c.EffectiveTimeout, c.namespace(), c.database.name, c.name, pipeline, opts...)
 	if err != nil {
 		return nil, err
 	}
-
-	// Parse stream ID from result
-	streamID, ok := result.(string)
-	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", result)
-	}
-
-	return newChangeStream(rpcClient, streamID), nil
+	cs.strict = c.strict
+	cs.naming = c.naming
+	cs.numbers = c.numbers
+	cs.decoder = c.decoder
+	return cs, nil
 }
 
 // BulkWrite performs multiple write operations.
@@ -954,62 +1462,164 @@ type ReplaceOneModel struct {
 
 func (m *ReplaceOneModel) writeModel() {}
 
-// BulkWrite performs multiple write operations.
-func (c *Collection) BulkWrite(ctx context.Context, models []WriteModel) (*BulkWriteResult, error) {
-	c.database.client.mu.RLock()
-	connected := c.database.client.connected
-	rpcClient := c.database.client.rpcClient
-	c.database.client.mu.RUnlock()
+// BulkWriteOptions configures a BulkWrite operation.
+type BulkWriteOptions struct {
+	// BatchSize caps the number of operations per request below the
+	// server's MaxWriteBatchSize.
+	BatchSize *int
+	// Progress is called after each batch.
+	Progress ProgressFunc
+}
+
+// SetBatchSize sets the maximum number of operations per request.
+func (o *BulkWriteOptions) SetBatchSize(size int) *BulkWriteOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetProgress sets the function called after each batch.
+func (o *BulkWriteOptions) SetProgress(progress ProgressFunc) *BulkWriteOptions {
+	o.Progress = progress
+	return o
+}
 
-	if !connected {
-		return nil, ErrClientDisconnected
+// writeBatchSize returns the number of operations per write request: the
+// server's MaxWriteBatchSize, or the requested size if it is smaller.
+func (c *Collection) writeBatchSize(requested *int) (int, error) {
+	size := int(c.database.client.serverLimits().MaxWriteBatchSize)
+	if requested == nil {
+		return size, nil
 	}
+	if *requested <= 0 {
+		return 0, fmt.Errorf("mongo: batch size must be positive, got %d", *requested)
+	}
+	return min(size, *requested), nil
+}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+// BulkWrite performs multiple write operations. A Progress function set in
+// the options is told about each batch, including a failed one.
+func (c *Collection) BulkWrite(ctx context.Context, models []WriteModel, opts ...*BulkWriteOptions) (*BulkWriteResult, error) {
+	var batchSize *int
+	var progress ProgressFunc
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.BatchSize != nil {
+				batchSize = opt.BatchSize
+			}
+			if opt.Progress != nil {
+				progress = opt.Progress
+			}
+		}
+	}
+	size, err := c.writeBatchSize(batchSize)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert models to wire format
 	operations := make([]map[string]any, len(models))
 	for i, model := range models {
-		switch m := model.(type) {
-		case *InsertOneModel:
-			operations[i] = map[string]any{"insertOne": map[string]any{"document": m.Document}}
-		case *UpdateOneModel:
-			op := map[string]any{"filter": m.Filter, "update": m.Update}
-			if m.Upsert != nil {
-				op["upsert"] = *m.Upsert
+		name, op := c.writeOperation(model)
+		if op == nil {
+			continue
+		}
+		for _, field := range []string{"document", "replacement"} {
+			if doc, ok := op[field]; ok {
+				stamped, err := c.stampVersion(doc)
+				if err != nil {
+					return nil, err
+				}
+				op[field] = stamped
+			}
+			if err := c.checkKeys(op[field]); err != nil {
+				return nil, err
 			}
-			operations[i] = map[string]any{"updateOne": op}
-		case *UpdateManyModel:
-			op := map[string]any{"filter": m.Filter, "update": m.Update}
-			if m.Upsert != nil {
-				op["upsert"] = *m.Upsert
+			if err := c.checkSchema(op[field]); err != nil {
+				return nil, err
 			}
-			operations[i] = map[string]any{"updateMany": op}
-		case *DeleteOneModel:
-			operations[i] = map[string]any{"deleteOne": map[string]any{"filter": m.Filter}}
-		case *DeleteManyModel:
-			operations[i] = map[string]any{"deleteMany": map[string]any{"filter": m.Filter}}
-		case *ReplaceOneModel:
-			op := map[string]any{"filter": m.Filter, "replacement": m.Replacement}
-			if m.Upsert != nil {
-				op["upsert"] = *m.Upsert
+		}
+		if update, ok := op["update"]; ok {
+			stamped, err := c.stampUpsert(update, op)
+			if err != nil {
+				return nil, err
 			}
-			operations[i] = map[string]any{"replaceOne": op}
+			op["update"] = stamped
+		}
+		if err := c.checkUpdateSchema(op["update"]); err != nil {
+			return nil, err
+		}
+		operations[i] = map[string]any{name: op}
+	}
+
+	// As in InsertMany, large writes are sent in batches the server accepts.
+	clock := c.database.client.clock
+	var merged *BulkWriteResult
+	for start := 0; start < len(operations) || merged == nil; start += size {
+		batch := operations[start:min(start+size, len(operations))]
+		began := clock.Now()
+		result, err := c.execute(ctx, "mongo.bulkWrite", withOptions([]any{c.database.name, c.name, batch}, c.writeOptions(ctx, make(map[string]any)))...)
+		if reportErr := progress.report(c.database.client, BatchProgress{
+			Batch:     start / size,
+			Processed: start + len(batch),
+			Total:     len(operations),
+			Latency:   clock.Now().Sub(began),
+			Err:       err,
+		}); err == nil {
+			err = reportErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		r := parseBulkWriteResult(result)
+		if merged == nil {
+			merged = r
+			continue
+		}
+		merged.InsertedCount += r.InsertedCount
+		merged.MatchedCount += r.MatchedCount
+		merged.ModifiedCount += r.ModifiedCount
+		merged.DeletedCount += r.DeletedCount
+		merged.UpsertedCount += r.UpsertedCount
+		for idx, id := range r.UpsertedIDs {
+			merged.UpsertedIDs[int64(start)+idx] = id
+		}
+		if r.Token != nil {
+			merged.Token = r.Token
 		}
 	}
+	return merged, nil
+}
 
-	promise := rpcClient.Call("mongo.bulkWrite", c.database.name, c.name, operations)
-	result, err := promise.Await()
-	if err != nil {
-		return nil, err
+// writeOperation converts a write model to its wire operation name and
+// body. It returns a nil body for unknown models.
+func (c *Collection) writeOperation(model WriteModel) (string, map[string]any) {
+	switch m := model.(type) {
+	case *InsertOneModel:
+		return "insertOne", map[string]any{"document": c.encode(m.Document)}
+	case *UpdateOneModel:
+		op := map[string]any{"filter": m.Filter, "update": c.encode(m.Update)}
+		if m.Upsert != nil {
+			op["upsert"] = *m.Upsert
+		}
+		return "updateOne", op
+	case *UpdateManyModel:
+		op := map[string]any{"filter": m.Filter, "update": c.encode(m.Update)}
+		if m.Upsert != nil {
+			op["upsert"] = *m.Upsert
+		}
+		return "updateMany", op
+	case *DeleteOneModel:
+		return "deleteOne", map[string]any{"filter": m.Filter}
+	case *DeleteManyModel:
+		return "deleteMany", map[string]any{"filter": m.Filter}
+	case *ReplaceOneModel:
+		op := map[string]any{"filter": m.Filter, "replacement": c.encode(m.Replacement)}
+		if m.Upsert != nil {
+			op["upsert"] = *m.Upsert
+		}
+		return "replaceOne", op
 	}
-
-	return parseBulkWriteResult(result), nil
+	return "", nil
 }
 
 // parseBulkWriteResult parses a bulk write result from the RPC response.
@@ -1018,28 +1628,139 @@ func parseBulkWriteResult(result any) *BulkWriteResult {
 		UpsertedIDs: make(map[int64]any),
 	}
 	if m, ok := result.(map[string]any); ok {
-		if v, ok := m["insertedCount"].(float64); ok {
-			r.InsertedCount = int64(v)
+		if v, ok := asInt64(m["insertedCount"]); ok {
+			r.InsertedCount = v
 		}
-		if v, ok := m["matchedCount"].(float64); ok {
-			r.MatchedCount = int64(v)
+		if v, ok := asInt64(m["matchedCount"]); ok {
+			r.MatchedCount = v
 		}
-		if v, ok := m["modifiedCount"].(float64); ok {
-			r.ModifiedCount = int64(v)
+		if v, ok := asInt64(m["modifiedCount"]); ok {
+			r.ModifiedCount = v
 		}
-		if v, ok := m["deletedCount"].(float64); ok {
-			r.DeletedCount = int64(v)
+		if v, ok := asInt64(m["deletedCount"]); ok {
+			r.DeletedCount = v
 		}
-		if v, ok := m["upsertedCount"].(float64); ok {
-			r.UpsertedCount = int64(v)
+		if v, ok := asInt64(m["upsertedCount"]); ok {
+			r.UpsertedCount = v
 		}
-		if upserted, ok := m["upsertedIds"].(map[string]any); ok {
-			for k, v := range upserted {
-				var idx int64
-				fmt.Sscanf(k, "%d", &idx)
-				r.UpsertedIDs[idx] = v
-			}
+		for idx, id := range parseIndexedIDs(m["upsertedIds"]) {
+			r.UpsertedIDs[idx] = id
 		}
+		r.Token = parseWriteToken(m)
 	}
 	return r
 }
+
+// parseInsertedIDs parses the insertedIds of an InsertMany response of n
+// documents, which is either an array or a document keyed by operation
+// index. Indexes outside the n documents are ignored.
+func parseInsertedIDs(raw any, n int) []any {
+	if ids, ok := raw.([]any); ok {
+		normalized := make([]any, len(ids))
+		for i, id := range ids {
+			normalized[i] = normalizeID(id)
+		}
+		return normalized
+	}
+
+	indexed := parseIndexedIDs(raw)
+	if len(indexed) == 0 {
+		return nil
+	}
+	maxIdx := int64(-1)
+	for idx := range indexed {
+		if idx < int64(n) && idx > maxIdx {
+			maxIdx = idx
+		}
+	}
+	if maxIdx < 0 {
+		return nil
+	}
+	ids := make([]any, maxIdx+1)
+	for idx, id := range indexed {
+		if idx <= maxIdx {
+			ids[idx] = id
+		}
+	}
+	return ids
+}
+
+// parseIndexedIDs parses IDs keyed by operation index. The backend reports
+// them either as a document keyed by the decimal index or as an array of
+// {index, _id} documents. Entries with an invalid index are skipped.
+func parseIndexedIDs(raw any) map[int64]any {
+	ids := make(map[int64]any)
+	switch r := raw.(type) {
+	case map[string]any:
+		for k, v := range r {
+			idx, err := strconv.ParseInt(k, 10, 64)
+			if err != nil || idx < 0 {
+				continue
+			}
+			ids[idx] = normalizeID(v)
+		}
+	case []any:
+		for _, entry := range r {
+			e, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+			idx, ok := asInt64(e["index"])
+			if !ok || idx < 0 {
+				continue
+			}
+			ids[idx] = normalizeID(e["_id"])
+		}
+	}
+	return ids
+}
+
+// normalizeID converts an _id value decoded from an RPC response into a
+// consistent concrete type: integral numbers, including $numberLong and
+// $numberInt wrappers, become int64, $oid wrappers become bson.ObjectID,
+// and $uuid wrappers are unwrapped.
+func normalizeID(id any) any {
+	switch v := id.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]any:
+		if len(v) != 1 {
+			return v
+		}
+		if oid, ok := v["$oid"].(string); ok {
+			if id, err := bson.ObjectIDFromHex(oid); err == nil {
+				return id
+			}
+			return oid
+		}
+		if s, ok := v["$numberLong"].(string); ok {
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i
+			}
+		}
+		if s, ok := v["$numberInt"].(string); ok {
+			if i, err := strconv.ParseInt(s, 10, 32); err == nil {
+				return i
+			}
+		}
+		if s, ok := v["$uuid"].(string); ok {
+			return s
+		}
+	}
+	return id
+}
@@ -2,10 +2,17 @@ package mongo
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
+
+	"go.mongo.do/bson"
 )
 
+// testObjectID is the ObjectID the tests use for "507f1f77bcf86cd799439011".
+var testObjectID, _ = bson.ObjectIDFromHex("507f1f77bcf86cd799439011")
+
 // TestCollectionName tests getting the collection name.
 func TestCollectionName(t *testing.T) {
 	mock := newMockRPCClient()
@@ -222,6 +229,69 @@ func TestCollectionInsertManyNonMapResult(t *testing.T) {
 	}
 }
 
+// TestCollectionInsertAndGet tests inserting and reading back in one upsert.
+func TestCollectionInsertAndGet(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOneAndUpdate", map[string]any{"_id": "abc", "name": "John", "createdAt": "2024-01-01"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	var stored struct {
+		ID        string `json:"_id"`
+		Name      string `json:"name"`
+		CreatedAt string `json:"createdAt"`
+	}
+	doc := struct {
+		ID   string `json:"_id"`
+		Name string `json:"name"`
+	}{ID: "abc", Name: "John"}
+	if err := coll.InsertAndGet(context.Background(), doc).Decode(&stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.ID != "abc" || stored.CreatedAt != "2024-01-01" {
+		t.Errorf("unexpected document: %+v", stored)
+	}
+
+	args := mock.calls[0].args
+	filter := args[2].(map[string]any)["$and"].([]any)
+	if !reflect.DeepEqual(filter[0], map[string]any{"_id": "abc"}) {
+		t.Errorf("unexpected filter: %v", args[2])
+	}
+	if !reflect.DeepEqual(args[3], map[string]any{"$setOnInsert": map[string]any{"name": "John"}}) {
+		t.Errorf("unexpected update: %v", args[3])
+	}
+	options := args[4].(map[string]any)
+	if options["upsert"] != true || options["returnDocument"] != "after" {
+		t.Errorf("unexpected options: %v", options)
+	}
+}
+
+// TestCollectionInsertAndGetWithoutID tests inserting then re-fetching when no _id is known.
+func TestCollectionInsertAndGetWithoutID(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "new-id"}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "new-id", "name": "John"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	var stored map[string]any
+	if err := coll.InsertAndGet(context.Background(), map[string]any{"name": "John"}).Decode(&stored); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored["_id"] != "new-id" {
+		t.Errorf("unexpected document: %v", stored)
+	}
+	if !reflect.DeepEqual(mock.calls[1].args[2], map[string]any{"_id": "new-id"}) {
+		t.Errorf("unexpected filter: %v", mock.calls[1].args[2])
+	}
+
+	if err := coll.InsertAndGet(context.Background(), nil).Err(); !errors.Is(err, ErrNilDocument) {
+		t.Errorf("expected ErrNilDocument, got %v", err)
+	}
+}
+
 // TestCollectionFindOne tests finding a single document.
 func TestCollectionFindOne(t *testing.T) {
 	mock := newMockRPCClient()
@@ -371,6 +441,24 @@ func TestCollectionFindWithOptions(t *testing.T) {
 	}
 }
 
+// TestCollectionFindNoCursorTimeout tests sending the noCursorTimeout option.
+func TestCollectionFindNoCursorTimeout(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Find(context.Background(), map[string]any{}, (&FindOptions{}).SetNoCursorTimeout(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["noCursorTimeout"] != true {
+		t.Errorf("expected noCursorTimeout, got %v", options)
+	}
+}
+
 // TestCollectionFindDisconnected tests finding when disconnected.
 func TestCollectionFindDisconnected(t *testing.T) {
 	mock := newMockRPCClient()
@@ -1721,3 +1809,330 @@ func TestDeleteOptions(t *testing.T) {
 		t.Errorf("expected locale en, got %s", opts.Collation.Locale)
 	}
 }
+
+// TestCollectionSendsReadOptions tests that read operations send read preference and concern.
+func TestCollectionSendsReadOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", &CollectionOptions{
+		ReadPreference: NewReadPreference(ReadPrefSecondaryPreferred),
+		ReadConcern:    &ReadConcern{Level: ReadConcernLocal},
+	})
+	ctx := context.Background()
+
+	if _, err := coll.Find(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := coll.FindOne(ctx, map[string]any{}).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, call := range mock.calls {
+		options := call.args[len(call.args)-1].(map[string]any)
+		rp, ok := options["readPreference"].(map[string]any)
+		if !ok || rp["mode"] != "secondaryPreferred" {
+			t.Errorf("%s: expected secondaryPreferred read preference, got %v", call.method, options["readPreference"])
+		}
+		rc, ok := options["readConcern"].(map[string]any)
+		if !ok || rc["level"] != "local" {
+			t.Errorf("%s: expected local read concern, got %v", call.method, options["readConcern"])
+		}
+	}
+}
+
+// TestCollectionSendsWriteConcern tests that write operations send the write concern.
+func TestCollectionSendsWriteConcern(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "1"}, nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", &CollectionOptions{
+		WriteConcern: MajorityWriteConcern(),
+	})
+	ctx := context.Background()
+
+	if _, err := coll.InsertOne(ctx, map[string]any{"name": "John"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.DeleteMany(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, call := range mock.calls {
+		options := call.args[len(call.args)-1].(map[string]any)
+		wc, ok := options["writeConcern"].(map[string]any)
+		if !ok || wc["w"] != "majority" {
+			t.Errorf("%s: expected majority write concern, got %v", call.method, options["writeConcern"])
+		}
+	}
+}
+
+// TestCollectionOmitsEmptyOptions tests that no options argument is sent without defaults.
+func TestCollectionOmitsEmptyOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.InsertOne(context.Background(), map[string]any{"name": "John"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.calls[0].args) != 3 {
+		t.Errorf("expected 3 args, got %d", len(mock.calls[0].args))
+	}
+}
+
+// TestCollectionClone tests cloning a collection with different defaults.
+func TestCollectionClone(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	coll := client.Database("testdb").Collection("users", &CollectionOptions{
+		ReadConcern:  &ReadConcern{Level: ReadConcernLocal},
+		WriteConcern: &WriteConcern{W: 1},
+	})
+
+	clone, err := coll.Clone(&CollectionOptions{WriteConcern: MajorityWriteConcern()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if clone == coll {
+		t.Error("expected a new collection handle")
+	}
+
+	if clone.Name() != "users" || clone.Database() != coll.Database() {
+		t.Error("expected clone to target the same namespace")
+	}
+
+	if clone.ReadConcern() != coll.ReadConcern() {
+		t.Error("expected clone to keep the read concern")
+	}
+
+	if clone.WriteConcern().W != "majority" {
+		t.Errorf("expected majority write concern, got %v", clone.WriteConcern().W)
+	}
+
+	if coll.WriteConcern().W != 1 {
+		t.Error("expected original handle to be unchanged")
+	}
+}
+
+// TestCollectionCloneCodec tests overriding the codec settings of a handle.
+func TestCollectionCloneCodec(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "a"}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "a", "count": float64(3)}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	clone, err := coll.Clone((&CollectionOptions{}).
+		SetNamingStrategy(NamingSnakeCase).
+		SetNumberDecoding(NumberDecodingInt64WhenExact))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if coll.naming != NamingAsIs || coll.numbers != NumberDecodingFloat64 {
+		t.Error("expected the original handle to keep the client codec settings")
+	}
+
+	type user struct {
+		ID        string `json:"_id"`
+		FirstName string
+	}
+	if _, err := clone.InsertOne(ctx, user{ID: "a", FirstName: "Ada"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc, ok := mock.calls[0].args[2].(map[string]any); !ok || doc["first_name"] != "Ada" {
+		t.Errorf("expected a snake_case document, got %v", mock.calls[0].args[2])
+	}
+
+	var doc map[string]any
+	if err := clone.FindOne(ctx, map[string]any{"_id": "a"}).Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := doc["count"].(int64); !ok {
+		t.Errorf("expected an int64 count, got %T", doc["count"])
+	}
+
+	if _, err := coll.Clone((&CollectionOptions{}).SetMaxModifiedDocuments(-1)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+	if _, err := coll.Clone((&CollectionOptions{}).SetNumberDecoding(NumberDecoding(9))); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+}
+
+// TestNormalizeID tests normalizing IDs returned by the backend.
+func TestNormalizeID(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"string", "abc", "abc"},
+		{"integral float", float64(42), int64(42)},
+		{"fractional float", 1.5, 1.5},
+		{"json number", json.Number("9007199254740993"), int64(9007199254740993)},
+		{"oid", map[string]any{"$oid": "507f1f77bcf86cd799439011"}, testObjectID},
+		{"invalid oid", map[string]any{"$oid": "xyz"}, "xyz"},
+		{"numberLong", map[string]any{"$numberLong": "12"}, int64(12)},
+		{"numberInt", map[string]any{"$numberInt": "7"}, int64(7)},
+		{"int32", int32(7), int64(7)},
+		{"nil", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeID(tt.in); got != tt.want {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.want, tt.want, got, got)
+			}
+		})
+	}
+}
+
+// TestCollectionInsertManyIndexedIDs tests InsertMany IDs keyed by index,
+// ignoring indexes beyond the documents sent.
+func TestCollectionInsertManyIndexedIDs(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{
+		"insertedIds": map[string]any{
+			"1":                   float64(2),
+			"0":                   map[string]any{"$oid": "507f1f77bcf86cd799439011"},
+			"2":                   "extra",
+			"9223372036854775807": "corrupt",
+		},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	result, err := coll.InsertMany(context.Background(), []any{map[string]any{}, map[string]any{"_id": 2}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.InsertedIDs) != 2 {
+		t.Fatalf("expected 2 IDs, got %d", len(result.InsertedIDs))
+	}
+
+	if result.InsertedIDs[0] != testObjectID || result.InsertedIDs[1] != int64(2) {
+		t.Errorf("unexpected IDs: %v", result.InsertedIDs)
+	}
+}
+
+// TestParseBulkWriteResultUpsertedIDShapes tests both upsertedIds response shapes.
+func TestParseBulkWriteResultUpsertedIDShapes(t *testing.T) {
+	result := parseBulkWriteResult(map[string]any{
+		"upsertedIds": map[string]any{"3": float64(10), "bogus": "skip"},
+	})
+	if len(result.UpsertedIDs) != 1 || result.UpsertedIDs[3] != int64(10) {
+		t.Errorf("unexpected upserted IDs: %v", result.UpsertedIDs)
+	}
+
+	result = parseBulkWriteResult(map[string]any{
+		"upsertedIds": []any{
+			map[string]any{"index": float64(1), "_id": map[string]any{"$oid": "507f1f77bcf86cd799439011"}},
+		},
+	})
+	if result.UpsertedIDs[1] != testObjectID {
+		t.Errorf("unexpected upserted IDs: %v", result.UpsertedIDs)
+	}
+}
+
+// TestParseResultsWideIntegers tests counts beyond 2^53 keeping every digit.
+func TestParseResultsWideIntegers(t *testing.T) {
+	const wide = int64(9007199254740993)
+
+	update := parseUpdateResult(map[string]any{
+		"matchedCount":  json.Number("9007199254740993"),
+		"modifiedCount": map[string]any{"$numberLong": "9007199254740993"},
+		"upsertedCount": map[string]any{"$numberInt": "1"},
+	})
+	if update.MatchedCount != wide || update.ModifiedCount != wide || update.UpsertedCount != 1 {
+		t.Errorf("unexpected update result: %+v", update)
+	}
+
+	del := parseDeleteResult(map[string]any{"deletedCount": map[string]any{"$numberLong": "9007199254740993"}})
+	if del.DeletedCount != wide {
+		t.Errorf("expected %d deleted, got %d", wide, del.DeletedCount)
+	}
+
+	bulk := parseBulkWriteResult(map[string]any{
+		"insertedCount": json.Number("9007199254740993"),
+		"deletedCount":  int64(3),
+	})
+	if bulk.InsertedCount != wide || bulk.DeletedCount != 3 {
+		t.Errorf("unexpected bulk write result: %+v", bulk)
+	}
+
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", map[string]any{"$numberLong": "9007199254740993"}, nil)
+	mock.addCall("mongo.estimatedDocumentCount", json.Number("9007199254740993"), nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("events")
+
+	count, err := coll.CountDocuments(context.Background(), map[string]any{})
+	if err != nil || count != wide {
+		t.Errorf("expected %d, got %d (%v)", wide, count, err)
+	}
+	count, err = coll.EstimatedDocumentCount(context.Background())
+	if err != nil || count != wide {
+		t.Errorf("expected %d, got %d (%v)", wide, count, err)
+	}
+}
+
+// TestCollectionMaxModifiedDocuments tests the preflight limit on multi-document writes.
+func TestCollectionMaxModifiedDocuments(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", float64(50), nil)
+	mock.addCall("mongo.countDocuments", float64(5), nil)
+	mock.addCall("mongo.deleteMany", map[string]any{"deletedCount": float64(5)}, nil)
+	mock.addCall("mongo.updateMany", map[string]any{"matchedCount": float64(50), "modifiedCount": float64(50)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetMaxModifiedDocuments(10))
+	ctx := context.Background()
+	filter := map[string]any{"status": "inactive"}
+
+	_, err := coll.UpdateMany(ctx, filter, map[string]any{"$set": map[string]any{"archived": true}})
+	var limitErr *ModifyLimitError
+	if !errors.As(err, &limitErr) || !errors.Is(err, ErrModifyLimitExceeded) {
+		t.Fatalf("expected ModifyLimitError, got %v", err)
+	}
+	if limitErr.Matched != 50 || limitErr.Limit != 10 || limitErr.Operation != "updateMany" {
+		t.Errorf("unexpected error: %+v", limitErr)
+	}
+	if !reflect.DeepEqual(mock.calls[0].args[2], filter) {
+		t.Errorf("expected count with the write filter, got %v", mock.calls[0].args[2])
+	}
+
+	result, err := coll.DeleteMany(ctx, filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.DeletedCount != 5 {
+		t.Errorf("expected 5 deleted, got %d", result.DeletedCount)
+	}
+
+	// Bypassing skips the preflight count.
+	bypass := (&UpdateOptions{}).SetBypassModifyLimit(true)
+	if _, err := coll.UpdateMany(ctx, filter, map[string]any{"$set": map[string]any{"archived": true}}, bypass); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.callIndex != 4 {
+		t.Errorf("expected 4 calls, got %d", mock.callIndex)
+	}
+
+	clone, _ := coll.Clone()
+	if clone.maxModified != 10 {
+		t.Errorf("expected clone to keep the limit, got %d", clone.maxModified)
+	}
+}
@@ -0,0 +1,237 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// This file holds what the MessagePack and CBOR payload codecs share. Both
+// are self-describing binary formats with integers, floats, strings, byte
+// strings, arrays and maps, so values are walked once and written through a
+// compactEncoder. Types with no counterpart in either format, such as
+// ObjectIDs and dates, are written in their Extended JSON form. Decoded
+// values take the shapes the bson.go codec produces.
+
+// compactEncoder writes the values of a MessagePack or CBOR document.
+type compactEncoder interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(n int64)
+	writeUint(n uint64)
+	writeFloat(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+}
+
+// errTruncated is returned for MessagePack or CBOR data that ends early.
+var errTruncated = errors.New("unexpected end of data")
+
+// encodeCompact writes v with e.
+func encodeCompact(e compactEncoder, v any) error {
+	switch v := v.(type) {
+	case nil:
+		e.writeNil()
+	case bool:
+		e.writeBool(v)
+	case string:
+		e.writeString(v)
+	case int:
+		e.writeInt(int64(v))
+	case int8:
+		e.writeInt(int64(v))
+	case int16:
+		e.writeInt(int64(v))
+	case int32:
+		e.writeInt(int64(v))
+	case int64:
+		e.writeInt(v)
+	case uint:
+		e.writeUint(uint64(v))
+	case uint8:
+		e.writeUint(uint64(v))
+	case uint16:
+		e.writeUint(uint64(v))
+	case uint32:
+		e.writeUint(uint64(v))
+	case uint64:
+		e.writeUint(v)
+	case float32:
+		e.writeFloat(float64(v))
+	case float64:
+		e.writeFloat(v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			e.writeInt(n)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		e.writeFloat(f)
+	case []byte:
+		e.writeBytes(v)
+	case time.Time:
+		return encodeCompact(e, bson.NewDateTimeFromTime(v))
+	case bsonDoc:
+		e.writeMapHeader(len(v))
+		for _, elem := range v {
+			e.writeString(elem.Key)
+			if err := encodeCompact(e, elem.Value); err != nil {
+				return err
+			}
+		}
+	case bson.D:
+		e.writeMapHeader(len(v))
+		for _, elem := range v {
+			e.writeString(elem.Key)
+			if err := encodeCompact(e, elem.Value); err != nil {
+				return err
+			}
+		}
+	case bson.M:
+		return encodeCompact(e, map[string]any(v))
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.writeMapHeader(len(keys))
+		for _, k := range keys {
+			e.writeString(k)
+			if err := encodeCompact(e, v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		e.writeArrayHeader(len(v))
+		for _, item := range v {
+			if err := encodeCompact(e, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return encodeCompactValue(e, reflect.ValueOf(v))
+	}
+	return nil
+}
+
+// encodeCompactValue writes the values encodeCompact has no case for,
+// converting those that control their own JSON encoding, and structs,
+// through toGeneric.
+func encodeCompactValue(e compactEncoder, rv reflect.Value) error {
+	if !marshalsItself(rv.Type()) {
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if rv.IsNil() {
+				e.writeNil()
+				return nil
+			}
+			return encodeCompact(e, rv.Elem().Interface())
+		case reflect.Bool:
+			e.writeBool(rv.Bool())
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			e.writeInt(rv.Int())
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			e.writeUint(rv.Uint())
+			return nil
+		case reflect.Float32, reflect.Float64:
+			e.writeFloat(rv.Float())
+			return nil
+		case reflect.String:
+			e.writeString(rv.String())
+			return nil
+		case reflect.Slice, reflect.Array:
+			if rv.Kind() == reflect.Slice && rv.IsNil() {
+				e.writeNil()
+				return nil
+			}
+			if rv.Type().Elem().Kind() == reflect.Uint8 && rv.Kind() == reflect.Slice {
+				e.writeBytes(rv.Bytes())
+				return nil
+			}
+			e.writeArrayHeader(rv.Len())
+			for i := 0; i < rv.Len(); i++ {
+				if err := encodeCompact(e, rv.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	generic, err := toGeneric(rv.Interface())
+	if err != nil {
+		return err
+	}
+	return encodeCompact(e, generic)
+}
+
+// compactInt returns a decoded integer as the float64 the JSON transport
+// would produce, or as {$numberLong: ...} when a float64 cannot hold it.
+func compactInt(n int64) any {
+	if n > -maxSafeInteger && n < maxSafeInteger {
+		return float64(n)
+	}
+	return map[string]any{"$numberLong": strconv.FormatInt(n, 10)}
+}
+
+// compactUint returns a decoded unsigned integer as compactInt does.
+// Integers beyond the int64 range become the nearest float64.
+func compactUint(n uint64) any {
+	if n > math.MaxInt64 {
+		return float64(n)
+	}
+	return compactInt(int64(n))
+}
+
+// compactBytes returns a decoded byte string as generic binary data.
+func compactBytes(b []byte) any {
+	return map[string]any{"$binary": map[string]any{
+		"base64":  base64.StdEncoding.EncodeToString(b),
+		"subType": "00",
+	}}
+}
+
+// compactDate returns a decoded timestamp as {$date: ...}.
+func compactDate(t time.Time) any {
+	return map[string]any{"$date": bson.NewDateTimeFromTime(t).String()}
+}
+
+// compactKey returns a decoded map key as a string.
+func compactKey(k any) string {
+	switch k := k.(type) {
+	case string:
+		return k
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64)
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// compactLength checks a decoded length against the remaining data, where
+// each element takes at least one byte, so corrupt lengths fail before
+// allocating.
+func compactLength(n uint64, remaining int) (int, error) {
+	if n > uint64(remaining) {
+		return 0, errTruncated
+	}
+	return int(n), nil
+}
@@ -0,0 +1,207 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// CompareOptions configures Compare and Sync operations.
+type CompareOptions struct {
+	// BatchSize is the number of documents fetched per request, and
+	// written per BulkWrite by Sync.
+	BatchSize *int64
+	// KeepExtra leaves documents that are only in the target in place, for
+	// targets that hold data of their own.
+	KeepExtra *bool
+}
+
+// SetBatchSize sets the number of documents fetched or written per request.
+func (o *CompareOptions) SetBatchSize(size int64) *CompareOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetKeepExtra sets whether documents only in the target are kept.
+func (o *CompareOptions) SetKeepExtra(keep bool) *CompareOptions {
+	o.KeepExtra = &keep
+	return o
+}
+
+// CollectionDiff lists the differences between the documents of a source
+// and a target collection.
+type CollectionDiff struct {
+	// Missing holds the source documents whose _id is not in the target.
+	Missing []map[string]any
+	// Changed holds the source documents whose target counterpart differs.
+	Changed []map[string]any
+	// Extra holds the _id of each target document not in the source. It is
+	// empty with CompareOptions.KeepExtra.
+	Extra []any
+}
+
+// Equal reports whether the collections hold the same documents.
+func (d *CollectionDiff) Equal() bool {
+	return len(d.Missing) == 0 && len(d.Changed) == 0 && len(d.Extra) == 0
+}
+
+// WriteModels returns the writes that make the target match the source:
+// upserts of missing documents, replacements of changed ones and deletes of
+// extra ones. Missing documents are upserted by _id rather than inserted,
+// as a target document with the same _id may exist outside the compared
+// filter.
+func (d *CollectionDiff) WriteModels() []WriteModel {
+	models := make([]WriteModel, 0, len(d.Missing)+len(d.Changed)+len(d.Extra))
+	upsert := true
+	for _, doc := range d.Missing {
+		models = append(models, &ReplaceOneModel{Filter: map[string]any{"_id": doc["_id"]}, Replacement: doc, Upsert: &upsert})
+	}
+	for _, doc := range d.Changed {
+		models = append(models, &ReplaceOneModel{Filter: map[string]any{"_id": doc["_id"]}, Replacement: doc})
+	}
+	for _, id := range d.Extra {
+		models = append(models, &DeleteOneModel{Filter: map[string]any{"_id": id}})
+	}
+	return models
+}
+
+// SyncResult is the result of a Sync operation.
+type SyncResult struct {
+	// Diff is the difference found before syncing.
+	Diff *CollectionDiff
+	// InsertedCount is the number of missing documents inserted. A missing
+	// document replacing a target document outside the filter counts
+	// toward ModifiedCount instead.
+	InsertedCount int64
+	ModifiedCount int64
+	DeletedCount  int64
+}
+
+// Compare matches the documents of the collection and target matching
+// filter by _id, and compares matched documents by a hash of their content
+// that ignores field order, as Checksum does. Target hashes are held in
+// memory while the collection is scanned, along with the differing source
+// documents. The collections may belong to different clients.
+func (c *Collection) Compare(ctx context.Context, target *Collection, filter any, opts ...*CompareOptions) (*CollectionDiff, error) {
+	ctx = withBatchPriority(ctx)
+	batchSize, keepExtra, err := compareOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	type targetDoc struct {
+		id   any
+		hash [sha256.Size]byte
+		seen bool
+	}
+	targets := make(map[string]*targetDoc)
+	var order []*targetDoc
+	_, err = target.scanByID(ctx, filter, nil, batchSize, func(doc map[string]any) error {
+		key, hash, err := documentHash(doc)
+		if err != nil {
+			return err
+		}
+		t := &targetDoc{id: doc["_id"], hash: hash}
+		targets[key] = t
+		order = append(order, t)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", target.namespace(), err)
+	}
+
+	diff := &CollectionDiff{}
+	_, err = c.scanByID(ctx, filter, nil, batchSize, func(doc map[string]any) error {
+		key, hash, err := documentHash(doc)
+		if err != nil {
+			return err
+		}
+		t, ok := targets[key]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, doc)
+		case t.hash != hash:
+			diff.Changed = append(diff.Changed, doc)
+		}
+		if ok {
+			t.seen = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", c.namespace(), err)
+	}
+
+	if !keepExtra {
+		for _, t := range order {
+			if !t.seen {
+				diff.Extra = append(diff.Extra, t.id)
+			}
+		}
+	}
+	return diff, nil
+}
+
+// Sync makes the documents of target matching filter equal to those of the
+// collection, for blue/green migrations and reconciling environments. It
+// runs Compare, then applies the writes of the diff in BulkWrite batches.
+// Writes made to either collection while Sync runs may be missed.
+func (c *Collection) Sync(ctx context.Context, target *Collection, filter any, opts ...*CompareOptions) (*SyncResult, error) {
+	ctx = withBatchPriority(ctx)
+	diff, err := c.Compare(ctx, target, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	batchSize, _, _ := compareOptions(opts)
+
+	result := &SyncResult{Diff: diff}
+	models := diff.WriteModels()
+	for start := 0; start < len(models); start += int(batchSize) {
+		end := min(start+int(batchSize), len(models))
+		written, err := target.BulkWrite(ctx, models[start:end])
+		if err != nil {
+			return result, fmt.Errorf("syncing %s: %w", target.namespace(), err)
+		}
+		result.InsertedCount += written.InsertedCount + written.UpsertedCount
+		result.ModifiedCount += written.ModifiedCount
+		result.DeletedCount += written.DeletedCount
+	}
+	return result, nil
+}
+
+// compareOptions merges opts, returning the batch size and whether extra
+// target documents are kept.
+func compareOptions(opts []*CompareOptions) (int64, bool, error) {
+	batchSize := int64(DefaultChecksumBatchSize)
+	keepExtra := false
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.BatchSize != nil {
+			batchSize = *opt.BatchSize
+		}
+		if opt.KeepExtra != nil {
+			keepExtra = *opt.KeepExtra
+		}
+	}
+	if batchSize <= 0 {
+		return 0, false, fmt.Errorf("mongo: batch size must be positive, got %d", batchSize)
+	}
+	return batchSize, keepExtra, nil
+}
+
+// documentHash returns a key identifying the _id of doc and a hash of its
+// content. Both re-encode maps as JSON, which sorts their keys.
+func documentHash(doc map[string]any) (string, [sha256.Size]byte, error) {
+	id, err := json.Marshal(doc["_id"])
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	return string(id), sha256.Sum256(canonical), nil
+}
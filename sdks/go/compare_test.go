@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestCollectionSync tests comparing two collections and converging them.
+func TestCollectionSync(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "a", "n": float64(1), "tag": "x"},
+		map[string]any{"_id": "b", "n": float64(2)},
+		map[string]any{"_id": "d", "n": float64(4)},
+	}, nil)
+	mock.addCall("mongo.find", []any{
+		map[string]any{"tag": "x", "_id": "a", "n": float64(1)},
+		map[string]any{"_id": "b", "n": float64(20)},
+		map[string]any{"_id": "c", "n": float64(3)},
+	}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"upsertedCount": float64(1), "upsertedIds": map[string]any{"0": "c"}, "modifiedCount": float64(1), "deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	blue := client.Database("blue").Collection("items")
+	green := client.Database("green").Collection("items")
+
+	result, err := green.Sync(context.Background(), blue, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	diff := result.Diff
+	if len(diff.Missing) != 1 || diff.Missing[0]["_id"] != "c" {
+		t.Errorf("unexpected missing documents %v", diff.Missing)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0]["n"] != float64(20) {
+		t.Errorf("unexpected changed documents %v", diff.Changed)
+	}
+	if !reflect.DeepEqual(diff.Extra, []any{"d"}) {
+		t.Errorf("unexpected extra documents %v", diff.Extra)
+	}
+	if result.InsertedCount != 1 || result.ModifiedCount != 1 || result.DeletedCount != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	if db := mock.calls[2].args[0]; db != "blue" {
+		t.Errorf("expected writes to the target, got %v", db)
+	}
+	ops := mock.calls[2].args[2].([]map[string]any)
+	if len(ops) != 3 || ops[0]["replaceOne"] == nil || ops[1]["replaceOne"] == nil || ops[2]["deleteOne"] == nil {
+		t.Fatalf("unexpected operations %v", ops)
+	}
+	if upsert := ops[0]["replaceOne"].(map[string]any)["upsert"]; upsert != true {
+		t.Errorf("expected the missing document to be upserted, got %v", ops[0])
+	}
+	if upsert, ok := ops[1]["replaceOne"].(map[string]any)["upsert"]; ok {
+		t.Errorf("expected the changed document to be replaced without upsert, got %v", upsert)
+	}
+}
+
+// TestCollectionCompareKeepExtra tests comparing without deleting extra
+// target documents.
+func TestCollectionCompareKeepExtra(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "a"}, map[string]any{"_id": "b"}}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "a"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+
+	diff, err := db.Collection("source").Compare(context.Background(), db.Collection("target"), nil, (&CompareOptions{}).SetKeepExtra(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.Equal() || len(diff.WriteModels()) != 0 {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+
+	if _, err := db.Collection("source").Compare(context.Background(), db.Collection("target"), nil, (&CompareOptions{}).SetBatchSize(0)); err == nil {
+		t.Error("expected an error for a zero batch size")
+	}
+}
@@ -0,0 +1,94 @@
+package mongo
+
+import "time"
+
+// ReadPrefMode identifies which replica set members a read may be routed to.
+type ReadPrefMode string
+
+// Read preference modes.
+const (
+	ReadPrefPrimary            ReadPrefMode = "primary"
+	ReadPrefPrimaryPreferred   ReadPrefMode = "primaryPreferred"
+	ReadPrefSecondary          ReadPrefMode = "secondary"
+	ReadPrefSecondaryPreferred ReadPrefMode = "secondaryPreferred"
+	ReadPrefNearest            ReadPrefMode = "nearest"
+)
+
+// ReadPreference describes how reads are routed to the server.
+type ReadPreference struct {
+	Mode         ReadPrefMode
+	TagSets      []map[string]string
+	MaxStaleness time.Duration
+}
+
+// NewReadPreference returns a read preference with the given mode.
+func NewReadPreference(mode ReadPrefMode) *ReadPreference {
+	return &ReadPreference{Mode: mode}
+}
+
+// document returns the wire representation of the read preference.
+func (rp *ReadPreference) document() map[string]any {
+	doc := map[string]any{"mode": string(rp.Mode)}
+	if len(rp.TagSets) > 0 {
+		doc["tags"] = rp.TagSets
+	}
+	if rp.MaxStaleness > 0 {
+		doc["maxStalenessSeconds"] = int64(rp.MaxStaleness / time.Second)
+	}
+	return doc
+}
+
+// Read concern levels.
+const (
+	ReadConcernLocal        = "local"
+	ReadConcernAvailable    = "available"
+	ReadConcernMajority     = "majority"
+	ReadConcernLinearizable = "linearizable"
+	ReadConcernSnapshot     = "snapshot"
+)
+
+// ReadConcern describes the consistency and isolation of read operations.
+type ReadConcern struct {
+	Level string
+}
+
+// document returns the wire representation of the read concern.
+func (rc *ReadConcern) document() map[string]any {
+	return map[string]any{"level": rc.Level}
+}
+
+// WriteConcern describes the acknowledgment requested for write operations.
+type WriteConcern struct {
+	// W is the number of members that must acknowledge the write, or "majority".
+	W        any
+	Journal  *bool
+	WTimeout time.Duration
+}
+
+// MajorityWriteConcern returns a write concern requiring acknowledgment from a majority.
+func MajorityWriteConcern() *WriteConcern {
+	return &WriteConcern{W: "majority"}
+}
+
+// document returns the wire representation of the write concern.
+func (wc *WriteConcern) document() map[string]any {
+	doc := make(map[string]any)
+	if wc.W != nil {
+		doc["w"] = wc.W
+	}
+	if wc.Journal != nil {
+		doc["j"] = *wc.Journal
+	}
+	if wc.WTimeout > 0 {
+		doc["wtimeout"] = wc.WTimeout.Milliseconds()
+	}
+	return doc
+}
+
+// withOptions appends the options map to args if it has any entries.
+func withOptions(args []any, options map[string]any) []any {
+	if len(options) == 0 {
+		return args
+	}
+	return append(args, options)
+}
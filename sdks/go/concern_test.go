@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReadPreferenceDocument tests the read preference wire format.
+func TestReadPreferenceDocument(t *testing.T) {
+	rp := &ReadPreference{
+		Mode:         ReadPrefSecondary,
+		TagSets:      []map[string]string{{"region": "us-east"}},
+		MaxStaleness: 90 * time.Second,
+	}
+
+	doc := rp.document()
+
+	if doc["mode"] != "secondary" {
+		t.Errorf("expected mode secondary, got %v", doc["mode"])
+	}
+
+	if doc["maxStalenessSeconds"] != int64(90) {
+		t.Errorf("expected maxStalenessSeconds 90, got %v", doc["maxStalenessSeconds"])
+	}
+
+	if _, ok := doc["tags"]; !ok {
+		t.Error("expected tags to be set")
+	}
+}
+
+// TestWriteConcernDocument tests the write concern wire format.
+func TestWriteConcernDocument(t *testing.T) {
+	journal := true
+	wc := &WriteConcern{W: 2, Journal: &journal, WTimeout: 5 * time.Second}
+
+	doc := wc.document()
+
+	if doc["w"] != 2 {
+		t.Errorf("expected w 2, got %v", doc["w"])
+	}
+
+	if doc["j"] != true {
+		t.Errorf("expected j true, got %v", doc["j"])
+	}
+
+	if doc["wtimeout"] != int64(5000) {
+		t.Errorf("expected wtimeout 5000, got %v", doc["wtimeout"])
+	}
+}
+
+// TestWithOptions tests appending options to RPC arguments.
+func TestWithOptions(t *testing.T) {
+	args := withOptions([]any{"db", "coll"}, map[string]any{})
+	if len(args) != 2 {
+		t.Errorf("expected 2 args, got %d", len(args))
+	}
+
+	args = withOptions([]any{"db", "coll"}, map[string]any{"a": 1})
+	if len(args) != 3 {
+		t.Errorf("expected 3 args, got %d", len(args))
+	}
+}
@@ -1,9 +1,14 @@
 package mongo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"reflect"
 	"sync"
+	"sync/atomic"
+
+	"go.mongo.do/bson"
 )
 
 // Cursor provides iteration over a result set.
@@ -13,7 +18,21 @@ type Cursor struct {
 	index     int
 	closed    bool
 	err       error
-	current   []byte
+	// current is the encoding of the current document, made when Current
+	// first asks for it.
+	current []byte
+	// naming maps strategy-named keys back to untagged struct fields.
+	naming NamingStrategy
+	// numbers controls how untyped numbers are decoded.
+	numbers NumberDecoding
+	// decoder tunes how values are assigned.
+	decoder DecoderConfig
+	// strict rejects fields the target struct does not have.
+	strict bool
+	// leaks tracks the cursor under leakID while it is open, with leak
+	// detection on.
+	leaks  *leakTracker
+	leakID uint64
 }
 
 // newCursor creates a new cursor with the given documents.
@@ -24,6 +43,13 @@ func newCursor(docs []any) *Cursor {
 	}
 }
 
+// NewCursorFromDocuments returns a cursor over documents, each encoded to
+// JSON as it is reached. It lets tests and fakes stand in for a query
+// without a server.
+func NewCursorFromDocuments(documents []any) *Cursor {
+	return newCursor(append([]any{}, documents...))
+}
+
 // newEmptyCursor creates a cursor with no documents.
 func newEmptyCursor() *Cursor {
 	return &Cursor{
@@ -65,19 +91,13 @@ func (c *Cursor) Next(ctx context.Context) bool {
 	}
 
 	c.index++
+	c.current = nil
 	if c.index >= len(c.documents) {
+		c.untrack()
 		return false
 	}
 
-	// Marshal current document to bytes for Decode
-	doc := c.documents[c.index]
-	data, err := json.Marshal(doc)
-	if err != nil {
-		c.err = err
-		return false
-	}
-	c.current = data
-
+	// The document is encoded only if Decode or Current need it.
 	return true
 }
 
@@ -87,8 +107,9 @@ func (c *Cursor) TryNext(ctx context.Context) bool {
 	return c.Next(ctx)
 }
 
-// Decode decodes the current document into the provided value.
-func (c *Cursor) Decode(val any) error {
+// Decode decodes the current document into the provided value. opts
+// override the strict decoding defaults of the client and collection.
+func (c *Cursor) Decode(val any, opts ...*DecodeOptions) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -104,22 +125,179 @@ func (c *Cursor) Decode(val any) error {
 		return ErrInvalidCursor
 	}
 
-	if c.current == nil {
-		return ErrInvalidCursor
+	doc := c.documents[c.index]
+	if c.numbers == NumberDecodingFloat64 && !c.decoder.custom() && decodeGeneric(doc, val) {
+		return nil
 	}
-
-	return json.Unmarshal(c.current, val)
+	if c.current != nil {
+		return unmarshalDocument(c.current, val, c.naming, c.numbers, c.decoder, strictDecoding(c.strict, opts...))
+	}
+	buf, err := encodePooled(doc)
+	if err != nil {
+		return err
+	}
+	defer encodeBuffers.Put(buf)
+	return unmarshalDocument(encodedBytes(buf), val, c.naming, c.numbers, c.decoder, strictDecoding(c.strict, opts...))
 }
 
-// Current returns the current document as raw bytes.
-func (c *Cursor) Current() []byte {
+// Current returns the current document as raw bytes, which can be
+// inspected with bson.Raw's Lookup without decoding. It is nil when the
+// cursor is not positioned on a document or the document cannot be
+// encoded.
+func (c *Cursor) Current() bson.Raw {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.current == nil && c.index >= 0 && c.index < len(c.documents) && !c.closed {
+		c.current, _ = json.Marshal(c.documents[c.index])
+	}
 	return c.current
 }
 
-// All decodes all remaining documents into the provided slice.
-func (c *Cursor) All(ctx context.Context, results any) error {
+// encodeBuffers pools the buffers documents are encoded into for decoding,
+// so scans do not allocate one per document.
+var encodeBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// encodePooled encodes v into a buffer from encodeBuffers, which the caller
+// puts back once it is done with the bytes.
+func encodePooled(v any) (*bytes.Buffer, error) {
+	buf := encodeBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		encodeBuffers.Put(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// encodedBytes returns the encoding in buf, without the newline the
+// encoder ends it with.
+func encodedBytes(buf *bytes.Buffer) []byte {
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// decodeGeneric decodes doc into val without encoding it, when val is a
+// *map[string]any, *bson.M or *any and doc holds only the values
+// encoding/json would decode it to. It copies doc, so changes to the result
+// do not reach the cursor, and reports false, leaving val alone, otherwise.
+func decodeGeneric(doc, val any) bool {
+	switch val.(type) {
+	case *map[string]any, *bson.M, *any:
+	default:
+		return false
+	}
+	copied, ok := copyGeneric(doc)
+	if !ok {
+		return false
+	}
+	if v, ok := val.(*any); ok {
+		if v == nil {
+			return false
+		}
+		*v = copied
+		return true
+	}
+
+	fields, ok := copied.(map[string]any)
+	if !ok {
+		return false
+	}
+	var m map[string]any
+	switch v := val.(type) {
+	case *map[string]any:
+		if v == nil {
+			return false
+		}
+		if *v == nil {
+			*v = make(map[string]any, len(fields))
+		}
+		m = *v
+	case *bson.M:
+		if v == nil {
+			return false
+		}
+		if *v == nil {
+			*v = make(bson.M, len(fields))
+		}
+		m = *v
+	}
+	// Like encoding/json, keep the keys already in the map.
+	for k, e := range fields {
+		m[k] = e
+	}
+	return true
+}
+
+// copyGeneric returns a deep copy of v, reporting false if it holds values
+// other than those encoding/json decodes into an any.
+func copyGeneric(v any) (any, bool) {
+	switch v := v.(type) {
+	case nil, bool, float64, string:
+		return v, true
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for k, e := range v {
+			var ok bool
+			if copied[k], ok = copyGeneric(e); !ok {
+				return nil, false
+			}
+		}
+		return copied, true
+	case []any:
+		copied := make([]any, len(v))
+		for i, e := range v {
+			var ok bool
+			if copied[i], ok = copyGeneric(e); !ok {
+				return nil, false
+			}
+		}
+		return copied, true
+	}
+	return nil, false
+}
+
+// allChunkSize is the number of documents All decodes between context
+// checks.
+const allChunkSize = 256
+
+// AllOptions configures Cursor.All.
+type AllOptions struct {
+	// MaxDocuments caps the number of documents All decodes. The cursor is
+	// left positioned after them, so the rest can be read by further calls.
+	// Zero means no cap.
+	MaxDocuments *int64
+	// Concurrency is the number of goroutines decoding documents into a
+	// slice in parallel. Results keep the cursor's order. It pays off for
+	// large result sets decoded into structs; values below 2 decode
+	// sequentially.
+	Concurrency *int
+	// DisallowUnknownFields overrides the cursor's strict decoding; see
+	// DecodeOptions.
+	DisallowUnknownFields *bool
+}
+
+// SetMaxDocuments sets the maximum number of documents to decode.
+func (o *AllOptions) SetMaxDocuments(n int64) *AllOptions {
+	o.MaxDocuments = &n
+	return o
+}
+
+// SetConcurrency sets the number of goroutines decoding in parallel.
+func (o *AllOptions) SetConcurrency(n int) *AllOptions {
+	o.Concurrency = &n
+	return o
+}
+
+// SetDisallowUnknownFields sets whether decoding fails on fields the target
+// struct does not have.
+func (o *AllOptions) SetDisallowUnknownFields(disallow bool) *AllOptions {
+	o.DisallowUnknownFields = &disallow
+	return o
+}
+
+// All decodes all remaining documents into the provided slice. Documents
+// are decoded in chunks, checking ctx between them; if ctx is done, All
+// returns its error, leaving results and the cursor position unchanged.
+func (c *Cursor) All(ctx context.Context, results any, opts ...*AllOptions) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -139,25 +317,140 @@ func (c *Cursor) All(ctx context.Context, results any) error {
 	}
 
 	// Get remaining documents
-	remaining := c.documents
+	start := 0
 	if c.index >= 0 {
-		remaining = c.documents[c.index+1:]
+		start = c.index + 1
+	}
+	remaining := c.documents[min(start, len(c.documents)):]
+	concurrency := 1
+	strict := c.strict
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.DisallowUnknownFields != nil {
+			strict = *opt.DisallowUnknownFields
+		}
+		if opt.MaxDocuments != nil && *opt.MaxDocuments > 0 && int64(len(remaining)) > *opt.MaxDocuments {
+			remaining = remaining[:*opt.MaxDocuments]
+		}
+		if opt.Concurrency != nil {
+			concurrency = *opt.Concurrency
+		}
 	}
 
-	// Marshal all remaining documents
-	data, err := json.Marshal(remaining)
-	if err != nil {
+	if err := c.decodeAll(ctx, remaining, results, concurrency, strict); err != nil {
 		return err
 	}
 
-	// Unmarshal into the results slice
-	if err := json.Unmarshal(data, results); err != nil {
-		return err
+	// Advance past the decoded documents, marking the cursor exhausted
+	// when none are left
+	c.current = nil
+	c.index = start + len(remaining) - 1
+	if c.index+1 >= len(c.documents) {
+		c.index = len(c.documents)
+		c.untrack()
+	}
+
+	return nil
+}
+
+// decodeAll decodes docs into results, a pointer to a slice, a chunk at a
+// time, spreading the chunks over up to concurrency goroutines. Other
+// targets are decoded in one step.
+func (c *Cursor) decodeAll(ctx context.Context, docs []any, results any, concurrency int, strict bool) error {
+	rv := reflect.ValueOf(results)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		data, err := json.Marshal(docs)
+		if err != nil {
+			return err
+		}
+		return unmarshalDocument(data, results, c.naming, c.numbers, c.decoder, strict)
+	}
+
+	sliceType := rv.Elem().Type()
+	chunks := make([]reflect.Value, (len(docs)+allChunkSize-1)/allChunkSize)
+	decodeChunk := func(i int) error {
+		start := i * allChunkSize
+		end := min(start+allChunkSize, len(docs))
+		buf, err := encodePooled(docs[start:end])
+		if err != nil {
+			return err
+		}
+		defer encodeBuffers.Put(buf)
+		chunk := reflect.New(sliceType)
+		if err := unmarshalDocument(encodedBytes(buf), chunk.Interface(), c.naming, c.numbers, c.decoder, strict); err != nil {
+			return err
+		}
+		chunks[i] = chunk.Elem()
+		return nil
 	}
 
-	// Mark cursor as exhausted
-	c.index = len(c.documents)
+	if concurrency > 1 && len(chunks) > 1 {
+		if err := runParallel(ctx, len(chunks), concurrency, decodeChunk); err != nil {
+			return err
+		}
+	} else {
+		for i := range chunks {
+			if i > 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			if err := decodeChunk(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	out := reflect.MakeSlice(sliceType, 0, len(docs))
+	for _, chunk := range chunks {
+		out = reflect.AppendSlice(out, chunk)
+	}
+	rv.Elem().Set(out)
+	return nil
+}
+
+// runParallel runs task for 0 to n-1 on up to workers goroutines. Workers
+// stop taking tasks once ctx is done or a task fails; the error of the
+// earliest failed task is returned.
+func runParallel(ctx context.Context, n, workers int, task func(i int) error) error {
+	errs := make([]error, n)
+	next := make(chan int, n)
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if failed.Load() {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+				} else {
+					errs[i] = task(i)
+				}
+				if errs[i] != nil {
+					failed.Store(true)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
 
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -174,7 +467,10 @@ func (c *Cursor) RemainingBatchLength() int {
 	if c.index < 0 {
 		return len(c.documents)
 	}
-	return len(c.documents) - c.index - 1
+	if remaining := len(c.documents) - c.index - 1; remaining > 0 {
+		return remaining
+	}
+	return 0
 }
 
 // Err returns any error that occurred during iteration.
@@ -196,6 +492,7 @@ func (c *Cursor) Close(ctx context.Context) error {
 	c.closed = true
 	c.documents = nil
 	c.current = nil
+	c.untrack()
 
 	return nil
 }
@@ -204,6 +501,11 @@ func (c *Cursor) Close(ctx context.Context) error {
 type SingleResult struct {
 	err  error
 	data []byte
+
+	naming  NamingStrategy
+	numbers NumberDecoding
+	decoder DecoderConfig
+	strict  bool
 }
 
 // newSingleResult creates a new SingleResult from a document.
@@ -220,13 +522,25 @@ func newSingleResult(doc any) *SingleResult {
 	return &SingleResult{data: data}
 }
 
+// NewSingleResultFromDocument returns a SingleResult holding document, or
+// failing with err if it is not nil. A nil document fails with
+// ErrNoDocuments, as a query matching nothing does. Like
+// NewCursorFromDocuments, it lets fakes stand in for a query.
+func NewSingleResultFromDocument(document any, err error) *SingleResult {
+	if err != nil {
+		return newSingleResultError(err)
+	}
+	return newSingleResult(document)
+}
+
 // newSingleResultError creates a SingleResult with an error.
 func newSingleResultError(err error) *SingleResult {
 	return &SingleResult{err: err}
 }
 
-// Decode decodes the document into the provided value.
-func (sr *SingleResult) Decode(val any) error {
+// Decode decodes the document into the provided value. opts override the
+// strict decoding defaults of the client and collection.
+func (sr *SingleResult) Decode(val any, opts ...*DecodeOptions) error {
 	if sr.err != nil {
 		return sr.err
 	}
@@ -235,17 +549,51 @@ func (sr *SingleResult) Decode(val any) error {
 		return ErrNoDocuments
 	}
 
-	return json.Unmarshal(sr.data, val)
+	return unmarshalDocument(sr.data, val, sr.naming, sr.numbers, sr.decoder, strictDecoding(sr.strict, opts...))
+}
+
+// Raw returns the raw document bytes, which can be inspected with
+// bson.Raw's Lookup and typed accessors without decoding.
+func (sr *SingleResult) Raw() (bson.Raw, error) {
+	if sr.err != nil {
+		return nil, sr.err
+	}
+	return sr.data, nil
 }
 
-// Raw returns the raw document bytes.
-func (sr *SingleResult) Raw() ([]byte, error) {
+// DecodeBytes returns the document as a bson.Raw, to navigate with Lookup,
+// Elements and the typed accessors of bson.RawValue instead of decoding it.
+// Unlike Raw, it fails with ErrNoDocuments when there is no document. The
+// bytes are shared with the result and must not be modified.
+func (sr *SingleResult) DecodeBytes() (bson.Raw, error) {
 	if sr.err != nil {
 		return nil, sr.err
 	}
+	if sr.data == nil {
+		return nil, ErrNoDocuments
+	}
 	return sr.data, nil
 }
 
+// Get returns the value at path in the document, a dotted path of keys and
+// array indexes such as "items.0.sku", without decoding the rest of it:
+//
+//	value, err := coll.FindOne(ctx, filter).Get("address.city")
+//	if err != nil {
+//	    return err
+//	}
+//	city, ok := value.StringValueOK()
+//
+// It fails with the operation's error, or with an error wrapping
+// bson.ErrElementNotFound if the path does not exist.
+func (sr *SingleResult) Get(path string) (bson.RawValue, error) {
+	raw, err := sr.DecodeBytes()
+	if err != nil {
+		return bson.RawValue{}, err
+	}
+	return raw.LookupErr(path)
+}
+
 // Err returns any error from the operation.
 func (sr *SingleResult) Err() error {
 	return sr.err
@@ -3,7 +3,11 @@ package mongo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
+
+	"go.mongo.do/bson"
 )
 
 // TestCursorNext tests advancing the cursor.
@@ -49,6 +53,30 @@ func TestCursorNext(t *testing.T) {
 	}
 }
 
+// TestNewCursorFromDocuments tests a cursor built from caller documents.
+func TestNewCursorFromDocuments(t *testing.T) {
+	docs := []any{map[string]any{"name": "John"}, struct {
+		Name string `json:"name"`
+	}{"Jane"}}
+	cursor := NewCursorFromDocuments(docs)
+	docs[0] = nil
+
+	var names []string
+	for cursor.Next(context.Background()) {
+		name, ok := cursor.Current().Lookup("name").StringValueOK()
+		if !ok {
+			t.Fatalf("expected a name in %s", cursor.Current())
+		}
+		names = append(names, name)
+	}
+	if err := cursor.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(names) != "[John Jane]" {
+		t.Errorf("expected [John Jane], got %v", names)
+	}
+}
+
 // TestCursorTryNext tests TryNext method.
 func TestCursorTryNext(t *testing.T) {
 	docs := []any{
@@ -307,6 +335,73 @@ func TestCursorAllContextCanceled(t *testing.T) {
 	}
 }
 
+// cancelOnMarshal cancels a context when it is encoded.
+type cancelOnMarshal struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelOnMarshal) MarshalJSON() ([]byte, error) {
+	c.cancel()
+	return []byte(`{}`), nil
+}
+
+// TestCursorAllCanceledWhileDecoding tests that All stops between chunks.
+func TestCursorAllCanceledWhileDecoding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	docs := make([]any, 2*allChunkSize)
+	for i := range docs {
+		docs[i] = map[string]any{"_id": i}
+	}
+	docs[0] = cancelOnMarshal{cancel: cancel}
+	cursor := newCursor(docs)
+
+	results := []map[string]any{{"kept": true}}
+	if err := cursor.All(ctx, &results); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(results) != 1 || results[0]["kept"] != true {
+		t.Errorf("expected results to be left unchanged, got %d documents", len(results))
+	}
+	if cursor.RemainingBatchLength() != len(docs) {
+		t.Errorf("expected the cursor position to be unchanged, %d remaining", cursor.RemainingBatchLength())
+	}
+}
+
+// TestCursorAllMaxDocuments tests decoding a capped number of documents.
+func TestCursorAllMaxDocuments(t *testing.T) {
+	docs := []any{
+		map[string]any{"_id": "1"},
+		map[string]any{"_id": "2"},
+		map[string]any{"_id": "3"},
+	}
+	cursor := newCursor(docs)
+	ctx := context.Background()
+
+	var results []map[string]any
+	if err := cursor.All(ctx, &results, (&AllOptions{}).SetMaxDocuments(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[1]["_id"] != "2" {
+		t.Errorf("expected the first 2 documents, got %v", results)
+	}
+	if cursor.RemainingBatchLength() != 1 {
+		t.Errorf("expected 1 remaining document, got %d", cursor.RemainingBatchLength())
+	}
+
+	if !cursor.Next(ctx) {
+		t.Fatal("expected the third document")
+	}
+	var doc map[string]any
+	if err := cursor.Decode(&doc); err != nil || doc["_id"] != "3" {
+		t.Errorf("expected document 3, got %v (%v)", doc, err)
+	}
+	if err := cursor.All(ctx, &results, (&AllOptions{}).SetMaxDocuments(2)); err != nil || len(results) != 0 {
+		t.Errorf("expected no more documents, got %v (%v)", results, err)
+	}
+}
+
 // TestCursorAllWithError tests All with pre-existing error.
 func TestCursorAllWithError(t *testing.T) {
 	cursor := newErrorCursor(errors.New("test error"))
@@ -443,6 +538,27 @@ func TestSingleResultError(t *testing.T) {
 	}
 }
 
+// TestNewSingleResultFromDocument tests a SingleResult built from a caller
+// document or error.
+func TestNewSingleResultFromDocument(t *testing.T) {
+	var decoded map[string]any
+	if err := NewSingleResultFromDocument(map[string]any{"name": "John"}, nil).Decode(&decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded["name"] != "John" {
+		t.Errorf("expected John, got %v", decoded["name"])
+	}
+
+	if err := NewSingleResultFromDocument(nil, nil).Err(); !errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNoDocuments, got %v", err)
+	}
+
+	testErr := errors.New("test error")
+	if err := NewSingleResultFromDocument(map[string]any{"name": "John"}, testErr).Err(); err != testErr {
+		t.Errorf("expected test error, got %v", err)
+	}
+}
+
 // TestSingleResultRaw tests getting raw bytes.
 func TestSingleResultRaw(t *testing.T) {
 	doc := map[string]any{"_id": "1", "name": "John"}
@@ -459,6 +575,64 @@ func TestSingleResultRaw(t *testing.T) {
 	}
 }
 
+// TestSingleResultRawLookup tests inspecting a result without decoding it.
+func TestSingleResultRawLookup(t *testing.T) {
+	result := newSingleResult(map[string]any{"_id": "1", "address": map[string]any{"city": "Paris"}, "tags": []any{"a", "b"}})
+
+	raw, err := result.Raw()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if city, ok := raw.Lookup("address.city").StringValueOK(); !ok || city != "Paris" {
+		t.Errorf("unexpected city %q", city)
+	}
+	if tag, _ := raw.Lookup("tags.1").StringValueOK(); tag != "b" {
+		t.Errorf("unexpected tag %q", tag)
+	}
+}
+
+// TestSingleResultGet tests extracting fields without decoding.
+func TestSingleResultGet(t *testing.T) {
+	result := newSingleResult(map[string]any{
+		"_id":   map[string]any{"$oid": "507f1f77bcf86cd799439011"},
+		"total": 42,
+		"items": []any{map[string]any{"sku": "a1"}},
+	})
+
+	raw, err := result.DecodeBytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elements, _ := raw.Elements(); len(elements) != 3 {
+		t.Errorf("expected 3 elements, got %d", len(elements))
+	}
+
+	sku, err := result.Get("items.0.sku")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s, ok := sku.StringValueOK(); !ok || s != "a1" {
+		t.Errorf("unexpected sku %v", sku)
+	}
+	if total, _ := result.Get("total"); total.Type() != "int" {
+		t.Errorf("expected an int total, got %s", total.Type())
+	}
+	if id, _ := result.Get("_id"); id.Type() != "objectId" {
+		t.Errorf("expected an objectId, got %s", id.Type())
+	}
+	if _, err := result.Get("items.1.sku"); !errors.Is(err, bson.ErrElementNotFound) {
+		t.Errorf("expected ErrElementNotFound, got %v", err)
+	}
+
+	if _, err := (&SingleResult{}).DecodeBytes(); !errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNoDocuments, got %v", err)
+	}
+	testErr := errors.New("test error")
+	if _, err := newSingleResultError(testErr).Get("total"); err != testErr {
+		t.Errorf("expected test error, got %v", err)
+	}
+}
+
 // TestSingleResultRawError tests getting raw bytes with error.
 func TestSingleResultRawError(t *testing.T) {
 	testErr := errors.New("test error")
@@ -491,7 +665,7 @@ func TestSingleResultDecodeNilData(t *testing.T) {
 func TestCursorDecodeNilCurrent(t *testing.T) {
 	cursor := &Cursor{
 		documents: []any{map[string]any{"_id": "1"}},
-		index:     0,
+		index:     -1,
 		current:   nil,
 	}
 
@@ -502,3 +676,197 @@ func TestCursorDecodeNilCurrent(t *testing.T) {
 		t.Errorf("expected ErrInvalidCursor, got %v", err)
 	}
 }
+
+// TestCursorDecodeUnencoded tests decoding a document that has not been
+// encoded, and encoding it only when Current asks for it.
+func TestCursorDecodeUnencoded(t *testing.T) {
+	cursor := &Cursor{
+		documents: []any{map[string]any{"_id": "1", "n": 2}},
+		index:     0,
+		current:   nil,
+	}
+
+	var doc struct {
+		ID string `json:"_id"`
+		N  int    `json:"n"`
+	}
+	if err := cursor.Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.ID != "1" || doc.N != 2 {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	if cursor.current != nil {
+		t.Error("expected decoding to leave the document unencoded")
+	}
+	if id := cursor.Current().Lookup("_id").String(); id != `"1"` {
+		t.Errorf("expected Current to encode the document, got %s", id)
+	}
+}
+
+// TestCursorDecodeGeneric tests decoding into maps without encoding.
+func TestCursorDecodeGeneric(t *testing.T) {
+	docs := []any{
+		map[string]any{"_id": "1", "tags": []any{"a"}, "address": map[string]any{"city": "Paris"}, "n": float64(1)},
+		map[string]any{"_id": "2", "n": 2},
+	}
+	cursor := newCursor(docs)
+	ctx := context.Background()
+
+	cursor.Next(ctx)
+	doc := map[string]any{"kept": true}
+	if err := cursor.Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"kept": true, "_id": "1", "tags": []any{"a"}, "address": map[string]any{"city": "Paris"}, "n": float64(1)}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("expected %v, got %v", want, doc)
+	}
+	doc["address"].(map[string]any)["city"] = "Lyon"
+	var again bson.M
+	if err := cursor.Decode(&again); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again["address"].(map[string]any)["city"] != "Paris" {
+		t.Error("expected decoded documents not to share values with the cursor")
+	}
+
+	// Values encoding/json would not produce are decoded through it.
+	cursor.Next(ctx)
+	var v any
+	if err := cursor.Decode(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := v.(map[string]any)["n"]; n != float64(2) {
+		t.Errorf("expected a float64, got %T", n)
+	}
+}
+
+// benchOrder is a document with enough fields to make decoding costly.
+type benchOrder struct {
+	ID       string            `json:"_id"`
+	Customer string            `json:"customer"`
+	Total    float64           `json:"total"`
+	Items    []benchOrderItem  `json:"items"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type benchOrderItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// benchOrderDocs returns n order documents.
+func benchOrderDocs(n int) []any {
+	docs := make([]any, n)
+	for i := range docs {
+		items := make([]any, 8)
+		for j := range items {
+			items[j] = map[string]any{"sku": fmt.Sprintf("sku-%d", j), "quantity": float64(j + 1), "price": 9.99}
+		}
+		docs[i] = map[string]any{
+			"_id":      fmt.Sprintf("order-%d", i),
+			"customer": "customer",
+			"total":    float64(i),
+			"items":    items,
+			"labels":   map[string]any{"region": "eu", "channel": "web"},
+		}
+	}
+	return docs
+}
+
+// TestCursorAllConcurrency tests that parallel decoding keeps the cursor
+// order.
+func TestCursorAllConcurrency(t *testing.T) {
+	docs := benchOrderDocs(5*allChunkSize + 17)
+	results, err := AllAs[benchOrder](context.Background(), newCursor(docs), (&AllOptions{}).SetConcurrency(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(docs) {
+		t.Fatalf("expected %d documents, got %d", len(docs), len(results))
+	}
+	for i, order := range results {
+		if order.ID != fmt.Sprintf("order-%d", i) || len(order.Items) != 8 {
+			t.Fatalf("unexpected document %d: %+v", i, order)
+		}
+	}
+}
+
+// TestCursorAllConcurrencyError tests that a failed chunk fails All and
+// leaves results unchanged.
+func TestCursorAllConcurrencyError(t *testing.T) {
+	docs := benchOrderDocs(4 * allChunkSize)
+	docs[3*allChunkSize] = map[string]any{"_id": "bad", "total": "not a number"}
+	cursor := newCursor(docs)
+
+	results := []benchOrder{{ID: "kept"}}
+	if err := cursor.All(context.Background(), &results, (&AllOptions{}).SetConcurrency(3)); err == nil {
+		t.Fatal("expected a decoding error")
+	}
+	if len(results) != 1 || results[0].ID != "kept" {
+		t.Errorf("expected results to be left unchanged, got %d documents", len(results))
+	}
+	if cursor.RemainingBatchLength() != len(docs) {
+		t.Errorf("expected the cursor position to be unchanged, %d remaining", cursor.RemainingBatchLength())
+	}
+}
+
+// TestCursorAllConcurrencyCanceled tests that parallel decoding stops when
+// the context is canceled.
+func TestCursorAllConcurrencyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	docs := benchOrderDocs(4 * allChunkSize)
+	docs[0] = cancelOnMarshal{cancel: cancel}
+	var results []map[string]any
+	if err := newCursor(docs).All(ctx, &results, (&AllOptions{}).SetConcurrency(2)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+// BenchmarkCursorAll compares sequential and parallel decoding of a large
+// result set into structs.
+func BenchmarkCursorAll(b *testing.B) {
+	docs := benchOrderDocs(20000)
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			opts := (&AllOptions{}).SetConcurrency(concurrency)
+			for i := 0; i < b.N; i++ {
+				if _, err := AllAs[benchOrder](context.Background(), newCursor(docs), opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCursorNext compares decoding each document of a large result set
+// into a map and into a struct.
+func BenchmarkCursorNext(b *testing.B) {
+	docs := benchOrderDocs(2000)
+	b.Run("map", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cursor := newCursor(docs)
+			for cursor.Next(context.Background()) {
+				var doc map[string]any
+				if err := cursor.Decode(&doc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("struct", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cursor := newCursor(docs)
+			for cursor.Next(context.Background()) {
+				var order benchOrder
+				if err := cursor.Decode(&order); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -2,8 +2,9 @@ package mongo
 
 import (
 	"context"
-	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Database represents a MongoDB database.
@@ -12,6 +13,75 @@ type Database struct {
 	name        string
 	mu          sync.RWMutex
 	collections map[string]*Collection
+
+	generation     atomic.Uint64
+	readPreference *ReadPreference
+	readConcern    *ReadConcern
+	writeConcern   *WriteConcern
+	timeout        *time.Duration
+}
+
+// DatabaseOptions configures a Database handle.
+// Unset read preference, read concern and write concern fields leave the
+// server defaults in effect; an unset Timeout inherits the client Timeout.
+type DatabaseOptions struct {
+	ReadPreference *ReadPreference
+	ReadConcern    *ReadConcern
+	WriteConcern   *WriteConcern
+	// Timeout is the default operation timeout for the database, overriding
+	// the client Timeout. Zero disables the client default.
+	Timeout *time.Duration
+}
+
+// SetReadPreference sets the read preference.
+func (o *DatabaseOptions) SetReadPreference(rp *ReadPreference) *DatabaseOptions {
+	o.ReadPreference = rp
+	return o
+}
+
+// SetReadConcern sets the read concern.
+func (o *DatabaseOptions) SetReadConcern(rc *ReadConcern) *DatabaseOptions {
+	o.ReadConcern = rc
+	return o
+}
+
+// SetWriteConcern sets the write concern.
+func (o *DatabaseOptions) SetWriteConcern(wc *WriteConcern) *DatabaseOptions {
+	o.WriteConcern = wc
+	return o
+}
+
+// SetTimeout sets the default operation timeout for the database.
+func (o *DatabaseOptions) SetTimeout(d time.Duration) *DatabaseOptions {
+	o.Timeout = &d
+	return o
+}
+
+// newDatabase creates a database handle with the given options applied.
+func newDatabase(client *Client, name string, opts ...*DatabaseOptions) *Database {
+	db := &Database{
+		client:      client,
+		name:        name,
+		collections: make(map[string]*Collection),
+	}
+	db.generation.Store(client.namespaces.current(name))
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.ReadPreference != nil {
+				db.readPreference = opt.ReadPreference
+			}
+			if opt.ReadConcern != nil {
+				db.readConcern = opt.ReadConcern
+			}
+			if opt.WriteConcern != nil {
+				db.writeConcern = opt.WriteConcern
+			}
+			if opt.Timeout != nil {
+				db.timeout = opt.Timeout
+			}
+		}
+	}
+	return db
 }
 
 // Name returns the name of the database.
@@ -24,19 +94,39 @@ func (d *Database) Client() *Client {
 	return d.client
 }
 
+// ReadPreference returns the read preference used by this database handle.
+func (d *Database) ReadPreference() *ReadPreference {
+	return d.readPreference
+}
+
+// ReadConcern returns the read concern used by this database handle.
+func (d *Database) ReadConcern() *ReadConcern {
+	return d.readConcern
+}
+
+// WriteConcern returns the write concern used by this database handle.
+func (d *Database) WriteConcern() *WriteConcern {
+	return d.writeConcern
+}
+
 // Collection returns a handle for the specified collection.
-func (d *Database) Collection(name string) *Collection {
+// Handles requested without options are cached by name; handles requested
+// with options are always new, since their defaults may differ.
+func (d *Database) Collection(name string, opts ...*CollectionOptions) *Collection {
+	for _, opt := range opts {
+		if opt != nil {
+			return newCollection(d, name, opts...)
+		}
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if coll, ok := d.collections[name]; ok {
+	if coll, ok := d.collections[name]; ok && !coll.Stale() {
 		return coll
 	}
 
-	coll := &Collection{
-		database: d,
-		name:     name,
-	}
+	coll := newCollection(d, name)
 	d.collections[name] = coll
 
 	return coll
@@ -44,24 +134,7 @@ func (d *Database) Collection(name string) *Collection {
 
 // ListCollectionNames returns the names of all collections in the database.
 func (d *Database) ListCollectionNames(ctx context.Context) ([]string, error) {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.listCollections", d.name)
-	result, err := promise.Await()
+	result, err := d.execute(ctx, "mongo.listCollections", d.name)
 	if err != nil {
 		return nil, err
 	}
@@ -77,277 +150,179 @@ func (d *Database) ListCollectionNames(ctx context.Context) ([]string, error) {
 		return result, nil
 	}
 
-	return nil, fmt.Errorf("unexpected result type: %T", result)
+	return nil, unexpectedResponse("mongo.listCollections", result)
 }
 
-// Drop drops the database.
-func (d *Database) Drop(ctx context.Context) error {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.dropDatabase", d.name)
-	_, err := promise.Await()
-	return err
+// CollectionSpecification describes a collection, as listed by
+// ListCollectionSpecifications.
+type CollectionSpecification struct {
+	Name string
+	// Type is "collection", "view" or "timeseries".
+	Type string
+	// Options holds the options the collection was created or last modified
+	// with, such as "validator", "capped" or "viewOn".
+	Options map[string]any
+	// Info holds server-assigned details, such as "uuid" and "readOnly".
+	Info map[string]any
 }
 
-// CreateCollection creates a new collection in the database.
-func (d *Database) CreateCollection(ctx context.Context, name string) error {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.createCollection", d.name, name)
-	_, err := promise.Await()
-	return err
+// Validator returns the validator of the collection, or nil if it has
+// none.
+func (s CollectionSpecification) Validator() map[string]any {
+	v, _ := s.Options["validator"].(map[string]any)
+	return v
 }
 
-// RunCommand runs a database command.
-func (d *Database) RunCommand(ctx context.Context, command any) *SingleResult {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return newSingleResultError(ErrClientDisconnected)
+// ListCollectionSpecifications returns the specifications of the
+// collections in the database matching filter, a listCollections filter
+// such as {"name": "orders"}. A nil filter lists every collection.
+func (d *Database) ListCollectionSpecifications(ctx context.Context, filter any) ([]CollectionSpecification, error) {
+	options := map[string]any{"nameOnly": false}
+	if filter != nil {
+		options["filter"] = filter
 	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return newSingleResultError(ctx.Err())
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.runCommand", d.name, command)
-	result, err := promise.Await()
-	if err != nil {
-		return newSingleResultError(err)
-	}
-
-	return newSingleResult(result)
-}
-
-// Aggregate runs an aggregation pipeline on the database.
-func (d *Database) Aggregate(ctx context.Context, pipeline any) (*Cursor, error) {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.aggregate", d.name, "", pipeline)
-	result, err := promise.Await()
+	result, err := d.execute(ctx, "mongo.listCollections", d.name, options)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse result as documents array
-	docs, ok := result.([]any)
+	entries, ok := result.([]any)
 	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", result)
+		return nil, unexpectedResponse("mongo.listCollections", result)
 	}
-
-	return newCursor(docs), nil
-}
-
-// Watch opens a change stream on the database.
-func (d *Database) Watch(ctx context.Context, pipeline any) (*ChangeStream, error) {
-	d.client.mu.RLock()
-	connected := d.client.connected
-	rpcClient := d.client.rpcClient
-	d.client.mu.RUnlock()
-
-	if !connected {
-		return nil, ErrClientDisconnected
-	}
-
-	// Check context
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-	}
-
-	promise := rpcClient.Call("mongo.watch", d.name, "", pipeline)
-	result, err := promise.Await()
-	if err != nil {
-		return nil, err
+	specs := make([]CollectionSpecification, 0, len(entries))
+	for _, entry := range entries {
+		doc, ok := entry.(map[string]any)
+		if !ok {
+			return nil, newProtocolError("mongo.listCollections", "collection specifications", result)
+		}
+		spec := CollectionSpecification{Type: "collection"}
+		spec.Name, _ = doc["name"].(string)
+		if t, ok := doc["type"].(string); ok {
+			spec.Type = t
+		}
+		spec.Options, _ = doc["options"].(map[string]any)
+		spec.Info, _ = doc["info"].(map[string]any)
+		specs = append(specs, spec)
 	}
+	return specs, nil
+}
 
-	// Parse stream ID from result
-	streamID, ok := result.(string)
-	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", result)
+// Drop drops the database. Other handles for it, and collection handles
+// created before the drop, become stale; the receiver stays usable.
+func (d *Database) Drop(ctx context.Context) error {
+	if _, err := d.execute(ctx, "mongo.dropDatabase", d.name); err != nil {
+		return err
 	}
-
-	return newChangeStream(rpcClient, streamID), nil
+	d.generation.Store(d.client.namespaces.invalidate(d.name))
+	return nil
 }
 
-// ChangeStream represents a change stream for watching database changes.
-type ChangeStream struct {
-	rpcClient RPCClient
-	streamID  string
-	closed    bool
-	mu        sync.Mutex
-	current   *ChangeEvent
-	err       error
+// CreateCollection creates a new collection in the database.
+func (d *Database) CreateCollection(ctx context.Context, name string, opts ...*CreateCollectionOptions) error {
+	_, err := d.execute(ctx, "mongo.createCollection", withOptions([]any{d.name, name}, createCollectionOptions(opts...))...)
+	return err
 }
 
-// ChangeEvent represents a change event from a change stream.
-type ChangeEvent struct {
-	ID                any    `json:"_id"`
-	OperationType     string `json:"operationType"`
-	FullDocument      any    `json:"fullDocument"`
-	Ns                struct {
-		DB   string `json:"db"`
-		Coll string `json:"coll"`
-	} `json:"ns"`
-	DocumentKey       any `json:"documentKey"`
-	UpdateDescription struct {
-		UpdatedFields map[string]any `json:"updatedFields"`
-		RemovedFields []string       `json:"removedFields"`
-	} `json:"updateDescription"`
+// RunCmdOptions configures a RunCommand operation.
+type RunCmdOptions struct {
+	// ReadPreference routes the command, such as an administrative read
+	// like dbStats or collStats, to matching members. Without it the
+	// command runs on the primary, whatever the database's read preference
+	// or namespace defaults.
+	ReadPreference *ReadPreference
+	// Retryable marks the command as safe to run more than once, so
+	// transient failures are retried under the client's RetryOptions like
+	// regular reads. Leave it unset for commands that write.
+	Retryable *bool
 }
 
-// newChangeStream creates a new change stream.
-func newChangeStream(rpcClient RPCClient, streamID string) *ChangeStream {
-	return &ChangeStream{
-		rpcClient: rpcClient,
-		streamID:  streamID,
-	}
+// SetReadPreference sets the read preference the command is routed by.
+func (o *RunCmdOptions) SetReadPreference(rp *ReadPreference) *RunCmdOptions {
+	o.ReadPreference = rp
+	return o
 }
 
-// Next advances to the next change event.
-func (cs *ChangeStream) Next(ctx context.Context) bool {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-
-	if cs.closed {
-		cs.err = ErrCursorClosed
-		return false
-	}
+// SetRetryable sets whether the command may be retried.
+func (o *RunCmdOptions) SetRetryable(retryable bool) *RunCmdOptions {
+	o.Retryable = &retryable
+	return o
+}
 
-	// Check context
-	select {
-	case <-ctx.Done():
-		cs.err = ctx.Err()
-		return false
-	default:
+// RunCommand runs a database command. The MaxTimeMS and Comment namespace
+// defaults of the database apply to it.
+func (d *Database) RunCommand(ctx context.Context, command any, opts ...*RunCmdOptions) *SingleResult {
+	options := make(map[string]any)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.ReadPreference != nil {
+			options["readPreference"] = opt.ReadPreference.document()
+		}
+		if opt.Retryable != nil {
+			ctx = withRetryable(ctx, *opt.Retryable)
+		}
 	}
+	d.client.namespaceDefaults(d.name, "").apply(options)
 
-	promise := cs.rpcClient.Call("mongo.changeStreamNext", cs.streamID)
-	result, err := promise.Await()
+	result, err := d.execute(ctx, "mongo.runCommand", withOptions([]any{d.name, command}, options)...)
 	if err != nil {
-		cs.err = err
-		return false
-	}
-
-	if result == nil {
-		return false
-	}
-
-	// Parse result as ChangeEvent
-	if event, ok := result.(map[string]any); ok {
-		cs.current = &ChangeEvent{
-			ID:            event["_id"],
-			OperationType: event["operationType"].(string),
-			FullDocument:  event["fullDocument"],
-		}
-		if ns, ok := event["ns"].(map[string]any); ok {
-			if db, ok := ns["db"].(string); ok {
-				cs.current.Ns.DB = db
-			}
-			if coll, ok := ns["coll"].(string); ok {
-				cs.current.Ns.Coll = coll
-			}
-		}
-		return true
+		return newSingleResultError(err)
 	}
 
-	return false
+	sr := newSingleResult(d.client.resultDocument(result))
+	sr.numbers = d.client.numbers
+	sr.decoder = d.client.decoder
+	sr.strict = d.client.strict
+	return sr
 }
 
-// Decode decodes the current change event.
-func (cs *ChangeStream) Decode(val any) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-
-	if cs.current == nil {
-		return ErrNoDocuments
+// Aggregate runs an aggregation pipeline on the database, under the
+// database's namespace defaults.
+// Pipelines with LintPipeline errors are rejected before the RPC call.
+func (d *Database) Aggregate(ctx context.Context, pipeline any) (*Cursor, error) {
+	if err := lintPipeline(pipeline, false); err != nil {
+		return nil, err
 	}
 
-	// Type assert to *ChangeEvent
-	if ce, ok := val.(*ChangeEvent); ok {
-		*ce = *cs.current
-		return nil
+	options := make(map[string]any)
+	defaults := d.client.namespaceDefaults(d.name, "")
+	rp := d.readPreference
+	if rp == nil {
+		rp = defaults.ReadPreference
 	}
+	if rp != nil {
+		options["readPreference"] = rp.document()
+	}
+	if d.readConcern != nil {
+		options["readConcern"] = d.readConcern.document()
+	}
+	defaults.apply(options)
+	applyWriteToken(ctx, options)
+	d.client.tagOperation(ctx, options)
 
-	return fmt.Errorf("cannot decode into %T", val)
-}
-
-// Current returns the current change event.
-func (cs *ChangeStream) Current() *ChangeEvent {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	return cs.current
-}
-
-// Err returns any error from the change stream.
-func (cs *ChangeStream) Err() error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	return cs.err
-}
-
-// Close closes the change stream.
-func (cs *ChangeStream) Close(ctx context.Context) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	result, err := d.execute(ctx, "mongo.aggregate", withOptions([]any{d.name, "", pipeline}, options)...)
+	if err != nil {
+		return nil, err
+	}
 
-	if cs.closed {
-		return nil
+	// Parse result as documents array
+	docs, ok := result.([]any)
+	if !ok {
+		return nil, unexpectedResponse("mongo.aggregate", result)
 	}
 
-	cs.closed = true
+	cursor := newCursor(d.client.resultDocuments(docs))
+	cursor.numbers = d.client.numbers
+	cursor.decoder = d.client.decoder
+	cursor.strict = d.client.strict
+	d.client.trackCursor(cursor, d.name)
+	return cursor, nil
+}
 
-	// Notify server to close the stream
-	promise := cs.rpcClient.Call("mongo.changeStreamClose", cs.streamID)
-	_, err := promise.Await()
-	return err
+// Watch opens a change stream on the database, under the database's
+// namespace defaults.
+func (d *Database) Watch(ctx context.Context, pipeline any, opts ...*ChangeStreamOptions) (*ChangeStream, error) {
+	return d.client.watch(ctx, d.EffectiveTimeout, d.name, d.name, "", pipeline, opts...)
 }
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -73,6 +74,37 @@ func TestDatabaseListCollectionNames(t *testing.T) {
 	}
 }
 
+// TestDatabaseListCollectionSpecifications tests listing collection
+// specifications with their validators.
+func TestDatabaseListCollectionSpecifications(t *testing.T) {
+	validator := map[string]any{"$jsonSchema": map[string]any{"required": []any{"name"}}}
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listCollections", []any{
+		map[string]any{"name": "users", "type": "collection", "options": map[string]any{"validator": validator}},
+		map[string]any{"name": "active", "type": "view", "options": map[string]any{"viewOn": "users"}},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	specs, err := client.Database("testdb").ListCollectionSpecifications(context.Background(), map[string]any{"name": "users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[1].(map[string]any)
+	if options["nameOnly"] != false || !reflect.DeepEqual(options["filter"], map[string]any{"name": "users"}) {
+		t.Errorf("unexpected options: %v", options)
+	}
+	if len(specs) != 2 || specs[0].Name != "users" || specs[1].Type != "view" {
+		t.Fatalf("unexpected specifications: %+v", specs)
+	}
+	if !reflect.DeepEqual(specs[0].Validator(), validator) {
+		t.Errorf("expected validator %v, got %v", validator, specs[0].Validator())
+	}
+	if specs[1].Validator() != nil {
+		t.Errorf("expected no validator, got %v", specs[1].Validator())
+	}
+}
+
 // TestDatabaseListCollectionNamesDisconnected tests listing when disconnected.
 func TestDatabaseListCollectionNamesDisconnected(t *testing.T) {
 	mock := newMockRPCClient()
@@ -240,6 +272,41 @@ func TestDatabaseRunCommand(t *testing.T) {
 	}
 }
 
+// TestDatabaseRunCommandOptions tests routing a command by read preference
+// and retrying it when marked retryable.
+func TestDatabaseRunCommandOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1), "collections": float64(4)}, nil)
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 2})
+	ctx := context.Background()
+
+	opts := (&RunCmdOptions{}).
+		SetReadPreference(NewReadPreference(ReadPrefSecondaryPreferred)).
+		SetRetryable(true)
+	var stats struct{ Collections int }
+	if err := client.Database("testdb").RunCommand(ctx, map[string]any{"dbStats": 1}, opts).Decode(&stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Collections != 4 || mock.callIndex != 2 {
+		t.Errorf("expected a retried command, got %+v after %d calls", stats, mock.callIndex)
+	}
+	options, ok := mock.calls[1].args[2].(map[string]any)
+	if !ok || options["readPreference"].(map[string]any)["mode"] != "secondaryPreferred" {
+		t.Errorf("expected a secondaryPreferred read preference, got %v", mock.calls[1].args)
+	}
+
+	mock = newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, &ConnectionError{Address: "localhost"})
+	client = newRetryTestClient(mock, &RetryOptions{MaxAttempts: 2})
+	if err := client.Database("testdb").RunCommand(ctx, map[string]any{"compact": "users"}).Err(); !IsNetworkError(err) {
+		t.Errorf("expected a network error, got %v", err)
+	}
+	if mock.callIndex != 1 || len(mock.calls[0].args) != 2 {
+		t.Errorf("expected one call without options, got %d calls with %v", mock.callIndex, mock.calls[0].args)
+	}
+}
+
 // TestDatabaseRunCommandDisconnected tests running command when disconnected.
 func TestDatabaseRunCommandDisconnected(t *testing.T) {
 	mock := newMockRPCClient()
@@ -618,3 +685,83 @@ func TestChangeStreamErr(t *testing.T) {
 		t.Errorf("expected nil error, got %v", stream.Err())
 	}
 }
+
+// TestDatabaseWithOptions tests that database handles with options are not cached.
+func TestDatabaseWithOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	cached := client.Database("testdb")
+	rp := NewReadPreference(ReadPrefSecondary)
+	db := client.Database("testdb", (&DatabaseOptions{}).SetReadPreference(rp))
+
+	if db == cached {
+		t.Error("expected a new handle for database with options")
+	}
+
+	if db.ReadPreference() != rp {
+		t.Error("expected read preference to be set")
+	}
+
+	if cached.ReadPreference() != nil {
+		t.Error("expected cached handle to be unaffected")
+	}
+
+	if client.Database("testdb") != cached {
+		t.Error("expected cached handle without options")
+	}
+}
+
+// TestDatabaseCollectionInheritsOptions tests that collections inherit database defaults.
+func TestDatabaseCollectionInheritsOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	db := client.Database("testdb", &DatabaseOptions{
+		ReadConcern:  &ReadConcern{Level: ReadConcernMajority},
+		WriteConcern: MajorityWriteConcern(),
+	})
+
+	coll := db.Collection("users", &CollectionOptions{
+		WriteConcern: &WriteConcern{W: 1},
+	})
+
+	if coll.ReadConcern() == nil || coll.ReadConcern().Level != ReadConcernMajority {
+		t.Error("expected read concern inherited from database")
+	}
+
+	if coll.WriteConcern() == nil || coll.WriteConcern().W != 1 {
+		t.Error("expected collection write concern to override database")
+	}
+
+	if db.Collection("users") == coll {
+		t.Error("expected a new handle for collection with options")
+	}
+}
+
+// TestDatabaseAggregateSendsReadOptions tests that Aggregate sends read options.
+func TestDatabaseAggregateSendsReadOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb", &DatabaseOptions{
+		ReadPreference: NewReadPreference(ReadPrefNearest),
+	})
+
+	_, err := db.Aggregate(context.Background(), []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	args := mock.calls[0].args
+	if len(args) != 4 {
+		t.Fatalf("expected 4 args, got %d", len(args))
+	}
+
+	options := args[3].(map[string]any)
+	rp := options["readPreference"].(map[string]any)
+	if rp["mode"] != "nearest" {
+		t.Errorf("expected mode nearest, got %v", rp["mode"])
+	}
+}
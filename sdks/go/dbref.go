@@ -0,0 +1,28 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongo.do/bson"
+)
+
+// ResolveRef fetches the document ref points to. The referenced collection
+// is looked up in the database ref names, or in the collection's database
+// when it names none, so c need not be the referenced collection itself; a
+// collection other than c is read with its database's defaults. The result
+// fails with ErrNoDocuments when the document no longer exists, and with
+// bson.ErrInvalidDBRef when ref has no collection or _id.
+func (c *Collection) ResolveRef(ctx context.Context, ref bson.DBRef, opts ...*FindOneOptions) *SingleResult {
+	if ref.Ref == "" || ref.ID == nil {
+		return newSingleResultError(fmt.Errorf("%w: %v", bson.ErrInvalidDBRef, ref))
+	}
+	coll := c
+	switch {
+	case ref.DB != "" && ref.DB != c.database.name:
+		coll = c.database.client.Database(ref.DB).Collection(ref.Ref)
+	case ref.Ref != c.name:
+		coll = c.database.Collection(ref.Ref)
+	}
+	return coll.FindOne(ctx, bson.M{"_id": ref.ID}, opts...)
+}
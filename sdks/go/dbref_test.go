@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestResolveRef tests fetching referenced documents from the collection
+// and database a DBRef names.
+func TestResolveRef(t *testing.T) {
+	id := bson.NewObjectID()
+	tests := []struct {
+		name       string
+		ref        bson.DBRef
+		db, coll   string
+		wantResult bool
+	}{
+		{"same collection", bson.DBRef{Ref: "users", ID: id}, "app", "users", true},
+		{"other collection", bson.DBRef{Ref: "accounts", ID: id}, "app", "accounts", true},
+		{"other database", bson.DBRef{Ref: "users", ID: id, DB: "legacy"}, "legacy", "users", true},
+		{"same database", bson.DBRef{Ref: "users", ID: id, DB: "app"}, "app", "users", true},
+		{"missing", bson.DBRef{Ref: "users", ID: id}, "app", "users", false},
+	}
+
+	for _, tt := range tests {
+		mock := newMockRPCClient()
+		var doc any
+		if tt.wantResult {
+			doc = map[string]any{"_id": map[string]any{"$oid": id.Hex()}, "name": "Ada"}
+		}
+		mock.addCall("mongo.findOne", doc, nil)
+		client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+		result := client.Database("app").Collection("users").ResolveRef(context.Background(), tt.ref)
+		args := mock.calls[0].args
+		if args[0] != tt.db || args[1] != tt.coll {
+			t.Errorf("%s: expected %s.%s, got %v.%v", tt.name, tt.db, tt.coll, args[0], args[1])
+		}
+		if !reflect.DeepEqual(args[2], bson.M{"_id": id}) {
+			t.Errorf("%s: unexpected filter %v", tt.name, args[2])
+		}
+		var got struct{ Name string }
+		err := result.Decode(&got)
+		if tt.wantResult && (err != nil || got.Name != "Ada") {
+			t.Errorf("%s: expected Ada, got %v %v", tt.name, got, err)
+		}
+		if !tt.wantResult && !errors.Is(err, ErrNoDocuments) {
+			t.Errorf("%s: expected ErrNoDocuments, got %v", tt.name, err)
+		}
+	}
+}
+
+// TestResolveRefInvalid tests a DBRef without a collection or _id.
+func TestResolveRefInvalid(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("app").Collection("users")
+
+	for _, ref := range []bson.DBRef{{ID: 1}, {Ref: "users"}} {
+		if err := coll.ResolveRef(context.Background(), ref).Err(); !errors.Is(err, bson.ErrInvalidDBRef) {
+			t.Errorf("%v: expected ErrInvalidDBRef, got %v", ref, err)
+		}
+	}
+	if len(mock.calls) != 0 {
+		t.Errorf("expected no calls, got %d", len(mock.calls))
+	}
+}
+
+// TestBSONDBRef tests that DBRef keys are encoded in the order the server
+// requires, as a DBRef value and as a decoded map.
+func TestBSONDBRef(t *testing.T) {
+	for _, value := range []any{
+		bson.DBRef{Ref: "users", ID: int32(7), DB: "app"},
+		map[string]any{"$db": "app", "$id": int32(7), "$ref": "users"},
+	} {
+		data, err := marshalBSON(bson.D{{Key: "owner", Value: value}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Skip the outer document's size, the element type and its key
+		var keys []string
+		r := &bsonReader{data: data[4+1+len("owner")+1:]}
+		if err := r.elements(func(key string, value any) { keys = append(keys, key) }); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(keys, []string{"$ref", "$id", "$db"}) {
+			t.Errorf("%T: expected $ref, $id, $db, got %v", value, keys)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package mongo
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongo.do/bson"
+)
+
+// This file holds the decoder for types that use `bson` struct tags or may
+// hold dates. encoding/json knows neither the tag nor {"$date": ...}
+// documents, so documents are decoded generically and then assigned field
+// by field following structFields. Dates decode to time.Time, in typed
+// fields and in interfaces alike. Binary data decodes to []byte fields and
+// UUIDs to any [16]byte type; in interfaces they become bson.UUID for
+// subtype 4 and bson.Binary otherwise. Types implementing bson.Unmarshaler
+// receive their document whole.
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeTagged decodes data into val, a non-nil pointer, honoring `bson`
+// tags and config.
+func decodeTagged(data []byte, val any, naming NamingStrategy, numbers NumberDecoding, config DecoderConfig) error {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &json.InvalidUnmarshalError{Type: reflect.TypeOf(val)}
+	}
+	doc, err := decodeUseNumber(data)
+	if err != nil {
+		return err
+	}
+	d := &taggedDecoder{naming: naming, numbers: numbers, config: config}
+	return d.decode(doc, rv.Elem(), "")
+}
+
+// taggedDecoder assigns generic values, as decoded with json.Number
+// numbers, to Go values.
+type taggedDecoder struct {
+	naming  NamingStrategy
+	numbers NumberDecoding
+	config  DecoderConfig
+}
+
+// decode assigns src to dst. field is the dotted path of dst, for errors.
+func (d *taggedDecoder) decode(src any, dst reflect.Value, field string) error {
+	if kind := nullableKind(dst.Type()); kind != "" {
+		return d.decodeNullable(src, dst, kind, field)
+	}
+	if src == nil {
+		d.config.null(dst)
+		return nil
+	}
+
+	if dst.Type() == timeType {
+		if m, ok := src.(map[string]any); ok {
+			t, err := parseDateTime(m)
+			if err != nil {
+				return &json.UnmarshalTypeError{Value: "object", Type: dst.Type(), Field: field}
+			}
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+
+	if dst.Kind() != reflect.Pointer && dst.CanAddr() {
+		if pt := dst.Addr().Type(); pt.Implements(bsonUnmarshalerType) {
+			return unmarshalBSONValue(src, dst.Addr().Interface().(bson.Unmarshaler))
+		} else if pt.Implements(jsonUnmarshalerType) {
+			data, err := json.Marshal(src)
+			if err != nil {
+				return err
+			}
+			return dst.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data)
+		} else if s, ok := src.(string); ok && pt.Implements(textUnmarshalerType) {
+			return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return d.decode(src, dst.Elem(), field)
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return typeError(src, dst.Type(), field)
+		}
+		dst.Set(reflect.ValueOf(d.generic(src)))
+		return nil
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		if d.config.ZeroStructs {
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return d.decodeStruct(m, dst, field)
+	case reflect.Map:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		if dst.IsNil() || d.config.ZeroMaps {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(m)))
+		}
+		for k, v := range m {
+			if err := d.decodeMapEntry(dst, k, v, joinPath(field, k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if s, ok := src.(string); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			dst.SetBytes(b)
+			return nil
+		}
+		if b, ok := binaryValue(src); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(b.Data)
+			return nil
+		}
+		arr, ok := src.([]any)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		dst.Set(reflect.MakeSlice(dst.Type(), len(arr), len(arr)))
+		for i, v := range arr {
+			if err := d.decode(v, dst.Index(i), joinPath(field, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Array:
+		if isUUIDArray(dst.Type()) {
+			if u, ok := uuidValue(src); ok {
+				reflect.Copy(dst, reflect.ValueOf(u[:]))
+				return nil
+			}
+		}
+		arr, ok := src.([]any)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		for i := 0; i < dst.Len(); i++ {
+			if i >= len(arr) {
+				dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+				continue
+			}
+			if err := d.decode(arr[i], dst.Index(i), joinPath(field, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		s, ok := src.(string)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		dst.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := src.(bool)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		dst.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := src.(json.Number)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		i, err := n.Int64()
+		if err != nil || dst.OverflowInt(i) {
+			return &json.UnmarshalTypeError{Value: "number " + n.String(), Type: dst.Type(), Field: field}
+		}
+		dst.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := src.(json.Number)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		u, err := strconv.ParseUint(n.String(), 10, 64)
+		if err != nil || dst.OverflowUint(u) {
+			return &json.UnmarshalTypeError{Value: "number " + n.String(), Type: dst.Type(), Field: field}
+		}
+		dst.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := src.(json.Number)
+		if !ok {
+			return typeError(src, dst.Type(), field)
+		}
+		f, err := n.Float64()
+		if err != nil || dst.OverflowFloat(f) {
+			return &json.UnmarshalTypeError{Value: "number " + n.String(), Type: dst.Type(), Field: field}
+		}
+		dst.SetFloat(f)
+		return nil
+	}
+	return typeError(src, dst.Type(), field)
+}
+
+// decodeStruct assigns the fields of m to the struct dst. Fields no struct
+// field claims go to the inline map, if the struct has one, and are
+// otherwise ignored, as encoding/json does.
+func (d *taggedDecoder) decodeStruct(m map[string]any, dst reflect.Value, field string) error {
+	var inline *structField
+	claimed := make(map[string]bool, len(m))
+	fields := structFields(dst.Type())
+	for i := range fields {
+		f := &fields[i]
+		if f.inlineMap {
+			inline = f
+			continue
+		}
+		key := f.documentName(d.naming)
+		v, ok := m[key]
+		if !ok {
+			// Like encoding/json, fall back to a case-insensitive match.
+			for k, kv := range m {
+				if !claimed[k] && strings.EqualFold(k, key) {
+					key, v, ok = k, kv, true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		claimed[key] = true
+		fv, err := fieldByIndexAlloc(dst, f.index)
+		if err != nil {
+			return err
+		}
+		if err := d.decode(v, fv, joinPath(field, key)); err != nil {
+			return err
+		}
+	}
+
+	if inline == nil || len(claimed) == len(m) {
+		return nil
+	}
+	mv, err := fieldByIndexAlloc(dst, inline.index)
+	if err != nil {
+		return err
+	}
+	if mv.IsNil() {
+		mv.Set(reflect.MakeMap(mv.Type()))
+	}
+	for k, v := range m {
+		if claimed[k] {
+			continue
+		}
+		if err := d.decodeMapEntry(mv, k, v, joinPath(field, k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeMapEntry decodes v and stores it under key k in the map m.
+func (d *taggedDecoder) decodeMapEntry(m reflect.Value, k string, v any, field string) error {
+	kt := m.Type().Key()
+	var key reflect.Value
+	switch kt.Kind() {
+	case reflect.String:
+		key = reflect.ValueOf(k).Convert(kt)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			return &json.UnmarshalTypeError{Value: "number " + k, Type: kt, Field: field}
+		}
+		key = reflect.New(kt).Elem()
+		key.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return &json.UnmarshalTypeError{Value: "number " + k, Type: kt, Field: field}
+		}
+		key = reflect.New(kt).Elem()
+		key.SetUint(u)
+	default:
+		return &json.UnmarshalTypeError{Value: "object", Type: m.Type(), Field: field}
+	}
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := d.decode(v, elem, field); err != nil {
+		return err
+	}
+	m.SetMapIndex(key, elem)
+	return nil
+}
+
+// generic returns src with its numbers in the decoder's mode, for values
+// assigned to interfaces.
+func (d *taggedDecoder) generic(src any) any {
+	switch v := src.(type) {
+	case json.Number:
+		switch d.numbers {
+		case NumberDecodingJSONNumber:
+			return v
+		case NumberDecodingInt64WhenExact:
+			return exactNumber(v)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		if _, ok := v["$date"]; ok && len(v) == 1 {
+			if t, err := parseDateTime(v); err == nil {
+				return t
+			}
+		}
+		if b, ok := binaryValue(v); ok {
+			if u, err := b.UUID(); err == nil && b.Subtype == bson.BinaryUUID {
+				return u
+			}
+			return b
+		}
+		for k, elem := range v {
+			v[k] = d.generic(elem)
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = d.generic(elem)
+		}
+	}
+	return src
+}
+
+// binaryValue returns src as binary data when it is a $binary or $uuid
+// document.
+func binaryValue(src any) (bson.Binary, bool) {
+	m, ok := src.(map[string]any)
+	if !ok {
+		return bson.Binary{}, false
+	}
+	_, isBinary := m["$binary"]
+	_, isUUID := m["$uuid"]
+	if !isBinary && !isUUID {
+		return bson.Binary{}, false
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return bson.Binary{}, false
+	}
+	var b bson.Binary
+	if err := b.UnmarshalJSON(data); err != nil {
+		return bson.Binary{}, false
+	}
+	return b, true
+}
+
+// uuidValue returns src as a UUID when it is a $uuid document, a $binary
+// document with a UUID subtype or a UUID string.
+func uuidValue(src any) (bson.UUID, bool) {
+	if s, ok := src.(string); ok {
+		u, err := bson.ParseUUID(s)
+		return u, err == nil
+	}
+	b, ok := binaryValue(src)
+	if !ok {
+		return bson.UUID{}, false
+	}
+	u, err := b.UUID()
+	return u, err == nil
+}
+
+// fieldByIndexAlloc returns the field of v at index, allocating nil
+// embedded struct pointers along the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, &json.UnmarshalTypeError{Value: "object", Type: v.Type()}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// typeError describes src not fitting t, in the form encoding/json uses.
+func typeError(src any, t reflect.Type, field string) error {
+	value := "object"
+	switch src.(type) {
+	case string:
+		value = "string"
+	case json.Number:
+		value = "number"
+	case bool:
+		value = "bool"
+	case []any:
+		value = "array"
+	}
+	return &json.UnmarshalTypeError{Value: value, Type: t, Field: field}
+}
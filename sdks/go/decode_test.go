@@ -0,0 +1,317 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+type taggedAddress struct {
+	City string `bson:"city"`
+	Zip  string `bson:"zip,omitempty"`
+}
+
+type taggedMeta struct {
+	Version int `bson:"v"`
+}
+
+type taggedUser struct {
+	ID        bson.ObjectID  `bson:"_id"`
+	Name      string         `bson:"full_name"`
+	Email     string         `json:"email"`
+	Age       int            `bson:"age,omitempty"`
+	Address   *taggedAddress `bson:"address,omitempty"`
+	Tags      []string       `bson:"tags"`
+	CreatedAt time.Time      `bson:"created_at"`
+	Secret    string         `bson:"-" json:"-"`
+	Meta      taggedMeta     `bson:",inline"`
+	Extra     map[string]any `bson:",inline"`
+}
+
+// TestDecodeTagged tests decoding with bson tags, the json fallback,
+// ignored fields and inline structs and maps.
+func TestDecodeTagged(t *testing.T) {
+	id := bson.NewObjectID()
+	data := []byte(`{"_id":{"$oid":"` + id.Hex() + `"},"full_name":"Ada","email":"ada@example.com","age":36,` +
+		`"address":{"city":"London"},"tags":["a","b"],"created_at":"2024-01-02T03:04:05Z",` +
+		`"v":2,"nickname":"countess","score":1.5}`)
+
+	var got taggedUser
+	if err := unmarshalDocument(data, &got, NamingAsIs, NumberDecodingInt64WhenExact, DecoderConfig{}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := taggedUser{
+		ID:        id,
+		Name:      "Ada",
+		Email:     "ada@example.com",
+		Age:       36,
+		Address:   &taggedAddress{City: "London"},
+		Tags:      []string{"a", "b"},
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Meta:      taggedMeta{Version: 2},
+		Extra:     map[string]any{"nickname": "countess", "score": 1.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if err := unmarshalDocument([]byte(`{"age":"old"}`), &got, NamingAsIs, NumberDecodingFloat64, DecoderConfig{}, false); err == nil {
+		t.Error("expected a type error")
+	}
+}
+
+// TestEncodeTagged tests that encoding honors bson tags and omitempty.
+func TestEncodeTagged(t *testing.T) {
+	user := taggedUser{
+		Name:   "Ada",
+		Secret: "x",
+		Meta:   taggedMeta{Version: 1},
+		Extra:  map[string]any{"nickname": "countess", "v": 9},
+	}
+	got, ok := encodeNamed(user, NamingAsIs).(map[string]any)
+	if !ok {
+		t.Fatalf("expected a document, got %T", encodeNamed(user, NamingAsIs))
+	}
+	if got["full_name"] != "Ada" || got["nickname"] != "countess" || got["v"] != 1 {
+		t.Errorf("unexpected document: %v", got)
+	}
+	for _, key := range []string{"age", "address", "Secret", "Name", "Meta", "Extra"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("unexpected key %q in %v", key, got)
+		}
+	}
+	if _, ok := got["email"]; !ok {
+		t.Errorf("expected the json-tagged email field, got %v", got)
+	}
+}
+
+// TestDecodeTaggedResults tests bson tags across cursors, single results
+// and change streams.
+func TestDecodeTaggedResults(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "u1", "full_name": "Ada"},
+		map[string]any{"_id": "u2", "full_name": "Grace"},
+	}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u3", "full_name": "Edsger", "tags": []any{"x"}}, nil)
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           "change-1",
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"_id": "u4", "full_name": "Barbara", "city": "Boston"},
+	}, nil)
+
+	type person struct {
+		ID   string   `bson:"_id"`
+		Name string   `bson:"full_name"`
+		Tags []string `bson:"tags,omitempty"`
+	}
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	cursor, err := coll.Find(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var people []person
+	if err := cursor.All(ctx, &people); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(people) != 2 || people[0].Name != "Ada" || people[1].ID != "u2" {
+		t.Errorf("unexpected documents: %+v", people)
+	}
+
+	var one person
+	if err := coll.FindOne(ctx, map[string]any{}).Decode(&one); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if one.Name != "Edsger" || !reflect.DeepEqual(one.Tags, []string{"x"}) {
+		t.Errorf("unexpected document: %+v", one)
+	}
+
+	stream, err := coll.Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stream.Next(ctx) {
+		t.Fatalf("expected event, got error %v", stream.Err())
+	}
+	var changed person
+	if err := stream.Current().DecodeDocument(&changed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changed.ID != "u4" || changed.Name != "Barbara" {
+		t.Errorf("unexpected document: %+v", changed)
+	}
+
+	var event struct {
+		OperationType string `bson:"operationType"`
+		FullDocument  struct {
+			Extra map[string]string `bson:",inline"`
+		} `bson:"fullDocument"`
+	}
+	if err := stream.Decode(&event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event.OperationType != "insert" || event.FullDocument.Extra["city"] != "Boston" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+// TestProjectionForInlineMap tests that a struct with an inline map is not
+// projected.
+func TestProjectionForInlineMap(t *testing.T) {
+	projection, err := ProjectionFor(taggedUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if projection != nil {
+		t.Errorf("expected no projection, got %v", projection)
+	}
+	projection, _ = ProjectionFor(taggedAddress{})
+	if !reflect.DeepEqual(projection, map[string]any{"city": 1, "zip": 1}) {
+		t.Errorf("unexpected projection: %v", projection)
+	}
+}
+
+// TestTimeRoundTrip tests that times are sent as dates and decoded back
+// into time.Time, in struct fields and untyped documents alike.
+func TestTimeRoundTrip(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	type event struct {
+		Name string    `json:"name"`
+		At   time.Time `json:"at"`
+		Meta any       `json:"meta"`
+	}
+
+	encoded := encodeDates(event{Name: "login", At: when, Meta: map[string]any{"seen": when}})
+	want := map[string]any{
+		"name": "login",
+		"at":   bson.NewDateTimeFromTime(when),
+		"meta": map[string]any{"seen": bson.NewDateTimeFromTime(when)},
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("expected %v, got %v", want, encoded)
+	}
+	if filter := encodeDates(map[string]any{"at": map[string]any{"$gte": when}}); !reflect.DeepEqual(filter,
+		map[string]any{"at": map[string]any{"$gte": bson.NewDateTimeFromTime(when)}}) {
+		t.Errorf("unexpected filter %v", filter)
+	}
+	if v := encodeDates("plain"); v != "plain" {
+		t.Errorf("unexpected value %v", v)
+	}
+
+	// The wire protocol sends a BSON date and reads it back as $date.
+	data, err := marshalBSON(event{Name: "login", At: when})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw, _ := json.Marshal(doc)
+
+	var got event
+	if err := unmarshalDocument(raw, &got, NamingAsIs, NumberDecodingFloat64, DecoderConfig{}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.At.Equal(when) || got.Name != "login" {
+		t.Errorf("unexpected event %+v", got)
+	}
+	var m map[string]any
+	if err := unmarshalDocument(raw, &m, NamingAsIs, NumberDecodingFloat64, DecoderConfig{}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if at, ok := m["at"].(time.Time); !ok || !at.Equal(when) {
+		t.Errorf("expected a time, got %T %v", m["at"], m["at"])
+	}
+}
+
+// TestFindDecodesDates tests decoding $date results from the service.
+func TestFindDecodesDates(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{
+		"_id":     "e1",
+		"created": map[string]any{"$date": "2024-01-02T03:04:05.006Z"},
+		"log":     []any{map[string]any{"at": map[string]any{"$date": map[string]any{"$numberLong": "1704164645006"}}}},
+	}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	var got struct {
+		ID      string    `bson:"_id"`
+		Created time.Time `bson:"created"`
+		Log     []bson.M  `bson:"log"`
+	}
+	if err := client.Database("testdb").Collection("events").FindOne(context.Background(), map[string]any{}).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	if !got.Created.Equal(when) || len(got.Log) != 1 || got.Log[0]["at"] != when {
+		t.Errorf("unexpected document %+v", got)
+	}
+}
+
+// textUUID is a UUID type that, like github.com/google/uuid.UUID, only
+// knows its text form.
+type textUUID [16]byte
+
+func (u *textUUID) UnmarshalText(text []byte) error {
+	parsed, err := bson.ParseUUID(string(text))
+	*u = textUUID(parsed)
+	return err
+}
+
+// TestDecodeBinary tests decoding binary data and UUIDs into typed fields
+// and untyped documents.
+func TestDecodeBinary(t *testing.T) {
+	const id = "01234567-89ab-cdef-0123-456789abcdef"
+	mock := newMockRPCClient()
+	doc := map[string]any{
+		"_id":   map[string]any{"$uuid": id},
+		"other": map[string]any{"$binary": map[string]any{"base64": "ASNFZ4mrze8BI0VniavN7w==", "subType": "04"}},
+		"hash":  map[string]any{"$binary": map[string]any{"base64": "AQI=", "subType": "05"}},
+		"raw":   map[string]any{"$binary": map[string]any{"base64": "Aw==", "subType": "00"}},
+		"name":  id,
+	}
+	mock.addCall("mongo.findOne", doc, nil)
+	mock.addCall("mongo.findOne", doc, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("files")
+	ctx := context.Background()
+
+	var got struct {
+		ID    bson.UUID   `json:"_id"`
+		Other textUUID    `json:"other"`
+		Hash  bson.Binary `json:"hash"`
+		Raw   []byte      `json:"raw"`
+		Name  textUUID    `json:"name"`
+	}
+	if err := coll.FindOne(ctx, map[string]any{}).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := bson.ParseUUID(id)
+	if got.ID != want || got.Other != textUUID(want) || got.Name != textUUID(want) {
+		t.Errorf("unexpected UUIDs %+v", got)
+	}
+	if !reflect.DeepEqual(got.Hash, bson.Binary{Subtype: bson.BinaryMD5, Data: []byte{1, 2}}) || !reflect.DeepEqual(got.Raw, []byte{3}) {
+		t.Errorf("unexpected binary %+v", got)
+	}
+
+	var m map[string]any
+	if err := coll.FindOne(ctx, map[string]any{}).Decode(&m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m["_id"] != want || m["other"] != want {
+		t.Errorf("expected UUIDs, got %v", m)
+	}
+	if !reflect.DeepEqual(m["hash"], bson.Binary{Subtype: bson.BinaryMD5, Data: []byte{1, 2}}) {
+		t.Errorf("expected binary, got %v", m["hash"])
+	}
+}
@@ -0,0 +1,89 @@
+package mongo
+
+import "reflect"
+
+// NullDecoding controls what a null document field does to the Go value it
+// is decoded into.
+type NullDecoding int
+
+// Null decoding modes.
+const (
+	// NullDecodingNil sets pointers, interfaces, maps and slices to nil and
+	// leaves other values unchanged, as encoding/json does.
+	NullDecodingNil NullDecoding = iota
+	// NullDecodingZero sets any value to its zero value, as the official
+	// driver does.
+	NullDecodingZero
+	// NullDecodingEmpty is like NullDecodingZero, but makes maps and
+	// slices empty rather than nil, so they encode back as {} and [].
+	NullDecodingEmpty
+)
+
+// DecoderConfig tunes how results are decoded into Go values, so code
+// migrating from the official driver can keep its semantics. The zero
+// value decodes as encoding/json does. Any other value decodes structs with
+// the SDK's own decoder, the one used for `bson` tags.
+type DecoderConfig struct {
+	// UseNumber decodes untyped numbers as json.Number, as
+	// json.Decoder.UseNumber does. It is shorthand for
+	// ClientOptions.NumberDecoding set to NumberDecodingJSONNumber, which
+	// takes precedence when set.
+	UseNumber bool
+	// ZeroStructs zeroes a struct before decoding a document into it, so
+	// fields missing from the document do not keep earlier values. Without
+	// it, decoding into a reused struct merges, as encoding/json does.
+	ZeroStructs bool
+	// ZeroMaps clears a non-nil map before decoding a document into it,
+	// rather than adding to its entries.
+	ZeroMaps bool
+	// Nulls controls how null fields are decoded. The default,
+	// NullDecodingNil, follows encoding/json.
+	Nulls NullDecoding
+}
+
+// SetUseNumber sets whether untyped numbers decode as json.Number.
+func (c *DecoderConfig) SetUseNumber(useNumber bool) *DecoderConfig {
+	c.UseNumber = useNumber
+	return c
+}
+
+// SetZeroStructs sets whether structs are zeroed before decoding.
+func (c *DecoderConfig) SetZeroStructs(zero bool) *DecoderConfig {
+	c.ZeroStructs = zero
+	return c
+}
+
+// SetZeroMaps sets whether maps are cleared before decoding.
+func (c *DecoderConfig) SetZeroMaps(zero bool) *DecoderConfig {
+	c.ZeroMaps = zero
+	return c
+}
+
+// SetNulls sets how null fields are decoded.
+func (c *DecoderConfig) SetNulls(nulls NullDecoding) *DecoderConfig {
+	c.Nulls = nulls
+	return c
+}
+
+// custom reports whether c departs from encoding/json in how struct values
+// are assigned. UseNumber only affects untyped values.
+func (c DecoderConfig) custom() bool {
+	return c.ZeroStructs || c.ZeroMaps || c.Nulls != NullDecodingNil
+}
+
+// null assigns a null document field to dst.
+func (c DecoderConfig) null(dst reflect.Value) {
+	switch {
+	case c.Nulls == NullDecodingEmpty && dst.Kind() == reflect.Map:
+		dst.Set(reflect.MakeMap(dst.Type()))
+	case c.Nulls == NullDecodingEmpty && dst.Kind() == reflect.Slice:
+		dst.Set(reflect.MakeSlice(dst.Type(), 0, 0))
+	case c.Nulls != NullDecodingNil:
+		dst.Set(reflect.Zero(dst.Type()))
+	default:
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+	}
+}
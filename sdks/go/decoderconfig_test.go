@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type configProfile struct {
+	Name  string            `json:"name"`
+	Age   int               `json:"age"`
+	Nick  *string           `json:"nick"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+// TestDecoderConfig tests zeroing and null handling.
+func TestDecoderConfig(t *testing.T) {
+	nick := "ada"
+	existing := func() configProfile {
+		return configProfile{Name: "old", Age: 40, Nick: &nick, Tags: []string{"x"}, Attrs: map[string]string{"a": "1"}}
+	}
+	tests := []struct {
+		name   string
+		data   string
+		config DecoderConfig
+		want   configProfile
+	}{
+		{
+			"merge", `{"name":"new","attrs":{"b":"2"}}`, DecoderConfig{},
+			configProfile{Name: "new", Age: 40, Nick: &nick, Tags: []string{"x"}, Attrs: map[string]string{"a": "1", "b": "2"}},
+		},
+		{
+			"zero structs", `{"name":"new"}`, DecoderConfig{ZeroStructs: true},
+			configProfile{Name: "new"},
+		},
+		{
+			"zero maps", `{"attrs":{"b":"2"}}`, DecoderConfig{ZeroMaps: true},
+			configProfile{Name: "old", Age: 40, Nick: &nick, Tags: []string{"x"}, Attrs: map[string]string{"b": "2"}},
+		},
+		{
+			"nulls as nil", `{"name":null,"age":null,"nick":null,"tags":null,"attrs":null}`, DecoderConfig{},
+			configProfile{Name: "old", Age: 40},
+		},
+		{
+			"nulls as zero", `{"name":null,"age":null,"nick":null,"tags":null,"attrs":null}`, DecoderConfig{Nulls: NullDecodingZero},
+			configProfile{},
+		},
+		{
+			"nulls as empty", `{"name":null,"nick":null,"tags":null,"attrs":null}`, DecoderConfig{Nulls: NullDecodingEmpty},
+			configProfile{Age: 40, Tags: []string{}, Attrs: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := existing()
+			if err := unmarshalDocument([]byte(tt.data), &got, NamingAsIs, NumberDecodingFloat64, tt.config, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestClientDecoderConfig tests decoder configuration set on the client.
+func TestClientDecoderConfig(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{"name": "new", "count": 7}, nil)
+
+	config := (&DecoderConfig{}).SetUseNumber(true).SetZeroStructs(true)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetDecoderConfig(config))
+	coll := client.Database("testdb").Collection("profiles")
+
+	var got struct {
+		Name  string `json:"name"`
+		Age   int    `json:"age"`
+		Count any    `json:"count"`
+	}
+	got.Age = 40
+	if err := coll.FindOne(context.Background(), map[string]any{}).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "new" || got.Age != 0 {
+		t.Errorf("expected a zeroed struct, got %+v", got)
+	}
+	if got.Count != json.Number("7") {
+		t.Errorf("expected json.Number, got %T %v", got.Count, got.Count)
+	}
+}
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamespaceDefaults are options applied to every operation on a namespace
+// unless the collection handle or the call sets them explicitly.
+type NamespaceDefaults struct {
+	ReadPreference *ReadPreference
+	// MaxTimeMS is the server-side time limit for operations, in milliseconds.
+	MaxTimeMS int64
+	// Comment is attached to operations for attribution in server logs and
+	// currentOp output.
+	Comment string
+}
+
+// SetNamespaceDefaults registers defaults for a namespace. The namespace is
+// either "db.collection" or "db" to cover every collection in a database;
+// collection defaults take precedence over database defaults.
+func (c *Client) SetNamespaceDefaults(namespace string, defaults NamespaceDefaults) error {
+	if namespace == "" || strings.HasPrefix(namespace, ".") || strings.HasSuffix(namespace, ".") {
+		return fmt.Errorf("mongo: invalid namespace %q", namespace)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.nsDefaults == nil {
+		c.nsDefaults = make(map[string]NamespaceDefaults)
+	}
+	c.nsDefaults[namespace] = defaults
+	return nil
+}
+
+// ClearNamespaceDefaults removes the defaults registered for a namespace.
+func (c *Client) ClearNamespaceDefaults(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nsDefaults, namespace)
+}
+
+// namespaceDefaults returns the defaults for a collection, merging the
+// database-level entry with the collection-level entry. An empty coll
+// returns the database-level entry alone.
+func (c *Client) namespaceDefaults(db, coll string) NamespaceDefaults {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	merged := c.nsDefaults[db]
+	if coll == "" {
+		return merged
+	}
+	if d, ok := c.nsDefaults[db+"."+coll]; ok {
+		if d.ReadPreference != nil {
+			merged.ReadPreference = d.ReadPreference
+		}
+		if d.MaxTimeMS > 0 {
+			merged.MaxTimeMS = d.MaxTimeMS
+		}
+		if d.Comment != "" {
+			merged.Comment = d.Comment
+		}
+	}
+	return merged
+}
+
+// apply adds the operation-level defaults to options unless already set.
+func (d NamespaceDefaults) apply(options map[string]any) {
+	if _, ok := options["maxTimeMS"]; !ok && d.MaxTimeMS > 0 {
+		options["maxTimeMS"] = d.MaxTimeMS
+	}
+	if _, ok := options["comment"]; !ok && d.Comment != "" {
+		options["comment"] = d.Comment
+	}
+}
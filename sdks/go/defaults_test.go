@@ -0,0 +1,163 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+)
+
+// TestSetNamespaceDefaults tests that namespace defaults are sent with operations.
+func TestSetNamespaceDefaults(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	mock.addCall("mongo.updateMany", map[string]any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	err := client.SetNamespaceDefaults("testdb.users", NamespaceDefaults{
+		ReadPreference: NewReadPreference(ReadPrefSecondary),
+		MaxTimeMS:      500,
+		Comment:        "team-a",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	if _, err := coll.Find(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.UpdateMany(ctx, map[string]any{}, map[string]any{"$set": map[string]any{"a": 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	find := mock.calls[0].args[3].(map[string]any)
+	if find["readPreference"].(map[string]any)["mode"] != "secondary" {
+		t.Errorf("expected secondary read preference, got %v", find["readPreference"])
+	}
+	if find["maxTimeMS"] != int64(500) || find["comment"] != "team-a" {
+		t.Errorf("unexpected find options: %v", find)
+	}
+
+	update := mock.calls[1].args[4].(map[string]any)
+	if _, ok := update["readPreference"]; ok {
+		t.Error("unexpected read preference on write")
+	}
+	if update["maxTimeMS"] != int64(500) || update["comment"] != "team-a" {
+		t.Errorf("unexpected update options: %v", update)
+	}
+}
+
+// TestNamespaceDefaultsPrecedence tests database-level fallback and handle precedence.
+func TestNamespaceDefaultsPrecedence(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", float64(0), nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.SetNamespaceDefaults("testdb", NamespaceDefaults{
+		ReadPreference: NewReadPreference(ReadPrefSecondary),
+		MaxTimeMS:      1000,
+		Comment:        "db-wide",
+	})
+	client.SetNamespaceDefaults("testdb.orders", NamespaceDefaults{MaxTimeMS: 200})
+
+	coll := client.Database("testdb").Collection("orders", &CollectionOptions{
+		ReadPreference: NewReadPreference(ReadPrefPrimary),
+	})
+
+	if _, err := coll.CountDocuments(context.Background(), map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["readPreference"].(map[string]any)["mode"] != "primary" {
+		t.Errorf("expected handle read preference to win, got %v", options["readPreference"])
+	}
+	if options["maxTimeMS"] != int64(200) {
+		t.Errorf("expected collection maxTimeMS, got %v", options["maxTimeMS"])
+	}
+	if options["comment"] != "db-wide" {
+		t.Errorf("expected database comment, got %v", options["comment"])
+	}
+}
+
+// TestClearNamespaceDefaults tests removing namespace defaults.
+func TestClearNamespaceDefaults(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.deleteOne", map[string]any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.SetNamespaceDefaults("testdb.users", NamespaceDefaults{Comment: "x"})
+	client.ClearNamespaceDefaults("testdb.users")
+
+	coll := client.Database("testdb").Collection("users")
+	if _, err := coll.DeleteOne(context.Background(), map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mock.calls[0].args) != 3 {
+		t.Errorf("expected no options, got %v", mock.calls[0].args)
+	}
+}
+
+// TestSetNamespaceDefaultsInvalid tests rejecting malformed namespaces.
+func TestSetNamespaceDefaultsInvalid(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+
+	for _, ns := range []string{"", ".users", "testdb."} {
+		if err := client.SetNamespaceDefaults(ns, NamespaceDefaults{}); err == nil {
+			t.Errorf("expected error for %q", ns)
+		}
+	}
+}
+
+// TestNamespaceDefaultsDatabaseOperations tests that database-level defaults
+// apply to RunCommand, Database.Aggregate and Database.Watch.
+func TestNamespaceDefaultsDatabaseOperations(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.watch", "stream-1", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.SetNamespaceDefaults("testdb", NamespaceDefaults{
+		ReadPreference: NewReadPreference(ReadPrefSecondary),
+		MaxTimeMS:      750,
+		Comment:        "db-wide",
+	})
+	client.SetNamespaceDefaults("testdb.users", NamespaceDefaults{Comment: "users-only"})
+
+	db := client.Database("testdb")
+	ctx := context.Background()
+
+	if err := db.RunCommand(ctx, map[string]any{"dbStats": 1}).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.Aggregate(ctx, []any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.Watch(ctx, []any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	run := mock.calls[0].args[2].(map[string]any)
+	if run["maxTimeMS"] != int64(750) || run["comment"] != "db-wide" {
+		t.Errorf("unexpected runCommand options: %v", run)
+	}
+	if _, ok := run["readPreference"]; ok {
+		t.Error("expected runCommand to stay on the primary")
+	}
+
+	agg := mock.calls[1].args[3].(map[string]any)
+	if agg["readPreference"].(map[string]any)["mode"] != "secondary" {
+		t.Errorf("expected secondary read preference, got %v", agg["readPreference"])
+	}
+	if agg["maxTimeMS"] != int64(750) || agg["comment"] != "db-wide" {
+		t.Errorf("unexpected aggregate options: %v", agg)
+	}
+
+	watch := mock.calls[2].args[3].(map[string]any)
+	if watch["maxTimeMS"] != int64(750) || watch["comment"] != "db-wide" {
+		t.Errorf("unexpected watch options: %v", watch)
+	}
+}
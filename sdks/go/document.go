@@ -0,0 +1,41 @@
+package mongo
+
+import "go.mongo.do/bson"
+
+// plainDocument returns a copy of v with the bson package's document types
+// converted to map[string]any and []any, for code that inspects documents
+// rather than sending them: key order within bson.D is lost. Other values
+// are returned as they are.
+func plainDocument(v any) any {
+	switch d := v.(type) {
+	case bson.D:
+		m := make(map[string]any, len(d))
+		for _, e := range d {
+			m[e.Key] = plainDocument(e.Value)
+		}
+		return m
+	case bson.M:
+		return plainDocument(map[string]any(d))
+	case bson.A:
+		return plainDocument([]any(d))
+	case []bson.D:
+		a := make([]any, len(d))
+		for i, value := range d {
+			a[i] = plainDocument(value)
+		}
+		return a
+	case map[string]any:
+		m := make(map[string]any, len(d))
+		for k, value := range d {
+			m[k] = plainDocument(value)
+		}
+		return m
+	case []any:
+		a := make([]any, len(d))
+		for i, value := range d {
+			a[i] = plainDocument(value)
+		}
+		return a
+	}
+	return v
+}
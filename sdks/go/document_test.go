@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestPlainDocument tests converting bson documents for inspection.
+func TestPlainDocument(t *testing.T) {
+	doc := bson.D{
+		{Key: "a", Value: bson.M{"b": bson.A{1, bson.D{{Key: "c", Value: 2}}}}},
+		{Key: "d", Value: []any{bson.D{{Key: "e", Value: 3}}}},
+	}
+	want := map[string]any{
+		"a": map[string]any{"b": []any{1, map[string]any{"c": 2}}},
+		"d": []any{map[string]any{"e": 3}},
+	}
+	if got := plainDocument(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := plainDocument("x"); got != "x" {
+		t.Errorf("expected scalar unchanged, got %v", got)
+	}
+}
+
+// TestOrderedDocuments tests passing bson documents to collection methods.
+func TestOrderedDocuments(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "id-1"}, nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.idGenerator = IDGeneratorFunc(func() any { return "id-1" })
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	if _, err := coll.InsertOne(ctx, bson.D{{Key: "z", Value: 1}, {Key: "a", Value: 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(mock.calls[0].args[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"_id":"id-1","z":1,"a":2}` {
+		t.Errorf("unexpected document %s", data)
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{"status": "A"}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "b", Value: -1}, {Key: "a", Value: 1}}}},
+	}
+	if _, err := coll.Aggregate(ctx, pipeline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ = json.Marshal(mock.calls[1].args[2]); !strings.Contains(string(data), `{"$sort":{"b":-1,"a":1}}`) {
+		t.Errorf("unexpected pipeline %s", data)
+	}
+}
+
+// TestOrderedDocumentsInspected tests that linting, array filter validation
+// and $limit handling see bson documents.
+func TestOrderedDocumentsInspected(t *testing.T) {
+	issues := LintPipeline([]bson.D{{{Key: "match", Value: bson.M{}}}})
+	if len(issues) != 1 || issues[0].Severity != LintError {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "grades.$[g].ok", Value: true}}}}
+	if err := validateArrayFilters(update, []any{bson.D{{Key: "g.score", Value: bson.M{"$gt": 80}}}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateArrayFilters(update, nil); err == nil {
+		t.Error("expected an error for an unfiltered identifier")
+	}
+
+	limited, ok := withLimit([]bson.D{{{Key: "$match", Value: bson.M{}}}}, 5)
+	want := []bson.D{{{Key: "$match", Value: bson.M{}}}, {{Key: "$limit", Value: int64(5)}}}
+	if !ok || !reflect.DeepEqual(limited, want) {
+		t.Errorf("expected %v, got %v", want, limited)
+	}
+	if name := stageName(bson.D{{Key: "$group", Value: bson.M{}}}); name != "$group" {
+		t.Errorf("expected $group, got %q", name)
+	}
+}
@@ -0,0 +1,92 @@
+package mongo
+
+import "fmt"
+
+// ReturnDocument selects which version of a document a find-and-modify
+// operation returns.
+type ReturnDocument string
+
+// Return document values.
+const (
+	ReturnDocumentBefore ReturnDocument = "before"
+	ReturnDocumentAfter  ReturnDocument = "after"
+)
+
+// validate reports an error for unknown values.
+func (rd ReturnDocument) validate() error {
+	switch rd {
+	case ReturnDocumentBefore, ReturnDocumentAfter:
+		return nil
+	}
+	return fmt.Errorf("%w: returnDocument %q (want %q or %q)", ErrInvalidOption, string(rd), ReturnDocumentBefore, ReturnDocumentAfter)
+}
+
+// FullDocumentMode controls whether change events include the full document
+// (FullDocument) or its pre-image (FullDocumentBeforeChange).
+type FullDocumentMode string
+
+// Full document modes. FullDocumentDefault and FullDocumentUpdateLookup apply
+// only to FullDocument; FullDocumentOff applies only to FullDocumentBeforeChange.
+const (
+	FullDocumentDefault       FullDocumentMode = "default"
+	FullDocumentUpdateLookup  FullDocumentMode = "updateLookup"
+	FullDocumentWhenAvailable FullDocumentMode = "whenAvailable"
+	FullDocumentRequired      FullDocumentMode = "required"
+	FullDocumentOff           FullDocumentMode = "off"
+)
+
+// validateFullDocument reports an error for modes not valid for fullDocument.
+func (m FullDocumentMode) validateFullDocument() error {
+	switch m {
+	case FullDocumentDefault, FullDocumentUpdateLookup, FullDocumentWhenAvailable, FullDocumentRequired:
+		return nil
+	}
+	return fmt.Errorf("%w: fullDocument %q", ErrInvalidOption, string(m))
+}
+
+// validateBeforeChange reports an error for modes not valid for
+// fullDocumentBeforeChange.
+func (m FullDocumentMode) validateBeforeChange() error {
+	switch m {
+	case FullDocumentOff, FullDocumentWhenAvailable, FullDocumentRequired:
+		return nil
+	}
+	return fmt.Errorf("%w: fullDocumentBeforeChange %q", ErrInvalidOption, string(m))
+}
+
+// ValidationLevel controls which documents a collection validator checks.
+type ValidationLevel string
+
+// Validation levels.
+const (
+	ValidationLevelOff      ValidationLevel = "off"
+	ValidationLevelStrict   ValidationLevel = "strict"
+	ValidationLevelModerate ValidationLevel = "moderate"
+)
+
+// validate reports an error for unknown values.
+func (l ValidationLevel) validate() error {
+	switch l {
+	case ValidationLevelOff, ValidationLevelStrict, ValidationLevelModerate:
+		return nil
+	}
+	return fmt.Errorf("%w: validationLevel %q", ErrInvalidOption, string(l))
+}
+
+// ValidationAction controls what happens when a document fails validation.
+type ValidationAction string
+
+// Validation actions.
+const (
+	ValidationActionError ValidationAction = "error"
+	ValidationActionWarn  ValidationAction = "warn"
+)
+
+// validate reports an error for unknown values.
+func (a ValidationAction) validate() error {
+	switch a {
+	case ValidationActionError, ValidationActionWarn:
+		return nil
+	}
+	return fmt.Errorf("%w: validationAction %q", ErrInvalidOption, string(a))
+}
@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestFindOneAndUpdateReturnDocument tests typed and string return document values.
+func TestFindOneAndUpdateReturnDocument(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOneAndUpdate", map[string]any{"_id": "1"}, nil)
+	mock.addCall("mongo.findOneAndUpdate", map[string]any{"_id": "1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+	update := map[string]any{"$set": map[string]any{"a": 1}}
+
+	if err := coll.FindOneAndUpdate(ctx, map[string]any{}, update, (&FindOneAndUpdateOptions{}).SetReturnDocument(ReturnDocumentAfter)).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mock.calls[0].args[4].(map[string]any)["returnDocument"]; got != "after" {
+		t.Errorf("expected after, got %v", got)
+	}
+
+	mode := "before"
+	if err := coll.FindOneAndUpdate(ctx, map[string]any{}, update, (&FindOneAndUpdateOptions{}).SetReturnDocument(ReturnDocument(mode))).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mock.calls[1].args[4].(map[string]any)["returnDocument"]; got != "before" {
+		t.Errorf("expected before, got %v", got)
+	}
+}
+
+// TestFindOneAndUpdateInvalidReturnDocument tests rejecting unknown values client-side.
+func TestFindOneAndUpdateInvalidReturnDocument(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	opts := (&FindOneAndUpdateOptions{}).SetReturnDocument("After")
+	err := coll.FindOneAndUpdate(context.Background(), map[string]any{}, map[string]any{}, opts).Err()
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+	if mock.callIndex != 0 {
+		t.Error("expected no RPC call")
+	}
+}
+
+// TestWatchInvalidFullDocument tests rejecting modes that do not apply.
+func TestWatchInvalidFullDocument(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	tests := []*ChangeStreamOptions{
+		(&ChangeStreamOptions{}).SetFullDocument(FullDocumentOff),
+		(&ChangeStreamOptions{}).SetFullDocumentBeforeChange(FullDocumentUpdateLookup),
+		(&ChangeStreamOptions{}).SetFullDocument("lookup"),
+	}
+	for _, opts := range tests {
+		if _, err := coll.Watch(ctx, []any{}, opts); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("expected ErrInvalidOption, got %v", err)
+		}
+		if _, err := client.Database("testdb").Watch(ctx, []any{}, opts); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("expected ErrInvalidOption, got %v", err)
+		}
+	}
+}
+
+// TestModifyCollectionInvalidValidation tests rejecting unknown validation settings.
+func TestModifyCollectionInvalidValidation(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	db := client.Database("testdb")
+	ctx := context.Background()
+
+	if err := db.ModifyCollection(ctx, "users", *(&CollModOptions{}).SetValidationLevel("lenient")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+	if err := db.ModifyCollection(ctx, "users", *(&CollModOptions{}).SetValidationAction("ignore")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+}
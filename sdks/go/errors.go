@@ -8,6 +8,8 @@ package mongo
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
 // Standard errors that can be checked with errors.Is.
@@ -35,6 +37,70 @@ var (
 
 	// ErrContextCanceled is returned when the context is canceled.
 	ErrContextCanceled = errors.New("mongo: context canceled")
+
+	// ErrInvalidOption is returned when an option has an unknown value.
+	ErrInvalidOption = errors.New("mongo: invalid option")
+
+	// ErrReconnectQueueFull is returned when too many operations are waiting for a reconnection.
+	ErrReconnectQueueFull = errors.New("mongo: reconnect queue is full")
+
+	// ErrInvalidArrayFilter is returned when arrayFilters do not match the identifiers used by an update.
+	ErrInvalidArrayFilter = errors.New("mongo: invalid array filter")
+
+	// ErrInvalidPipeline is returned when an aggregation pipeline fails client-side linting.
+	ErrInvalidPipeline = errors.New("mongo: invalid pipeline")
+
+	// ErrModifyLimitExceeded is returned when a multi-document write would affect more documents than allowed.
+	ErrModifyLimitExceeded = errors.New("mongo: modify limit exceeded")
+
+	// ErrOperationNotFound is returned when no running operation matches a cancellation request.
+	ErrOperationNotFound = errors.New("mongo: operation not found")
+
+	// ErrResultTooLarge is returned when a result exceeds a client-side size guardrail.
+	ErrResultTooLarge = errors.New("mongo: result too large")
+
+	// ErrThrottled is returned when a request is rejected client-side because the backend is overloaded.
+	ErrThrottled = errors.New("mongo: request throttled while backend is overloaded")
+
+	// ErrUnsupportedFeature is returned when the server did not advertise a feature in the handshake.
+	ErrUnsupportedFeature = errors.New("mongo: feature not supported by server")
+
+	// ErrNotSupportedByBackend is matched by NotSupportedError, returned when the backend lacks a feature.
+	ErrNotSupportedByBackend = errors.New("mongo: not supported by backend")
+
+	// ErrPayloadTooLarge is returned when a request exceeds the server's maximum payload size.
+	ErrPayloadTooLarge = errors.New("mongo: request payload too large")
+
+	// ErrNamespaceNotFound is matched by server errors reporting that the database or collection does not exist.
+	ErrNamespaceNotFound = errors.New("mongo: namespace not found")
+
+	// ErrUnsafeKey is returned when a collection that rejects unsafe keys is given a document with one.
+	ErrUnsafeKey = errors.New("mongo: unsafe document key")
+
+	// ErrInvalidSchema is returned when a JSON Schema cannot be compiled.
+	ErrInvalidSchema = errors.New("mongo: invalid schema")
+
+	// ErrSchemaViolation is returned when a document fails a collection's client-side JSON Schema.
+	ErrSchemaViolation = errors.New("mongo: document fails schema validation")
+
+	// ErrCallbackPanic is matched by PanicError, returned when a callback passed to the SDK panics.
+	ErrCallbackPanic = errors.New("mongo: callback panicked")
+
+	// ErrResourceLeak is matched by LeakError, returned when cursors or change streams were never closed.
+	ErrResourceLeak = errors.New("mongo: cursors or change streams left open")
+
+	// ErrUnknownField is returned by strict decoding for a document field
+	// the target struct does not have.
+	ErrUnknownField = errors.New("mongo: unknown field")
+
+	// ErrStaleHandle is returned by operations through a Database or Collection handle whose namespace was dropped or renamed since.
+	ErrStaleHandle = errors.New("mongo: handle refers to a dropped or renamed namespace")
+
+	// ErrArchiveMismatch is returned when documents copied by Archive are missing from the archive or differ there, so they are not deleted.
+	ErrArchiveMismatch = errors.New("mongo: archived documents missing or different in archive")
+
+	// ErrMinPoolSizeUnmet is returned by WarmUp when the client cannot establish MinPoolSize connections.
+	ErrMinPoolSizeUnmet = errors.New("mongo: cannot establish MinPoolSize connections")
 )
 
 // QueryError represents an error returned from a query operation.
@@ -104,11 +170,202 @@ func (e *BulkWriteError) Error() string {
 	return e.WriteErrors.Error()
 }
 
+// ResultTooLargeError is returned when a result exceeds a client-side guardrail.
+type ResultTooLargeError struct {
+	Namespace string
+	Limit     int64
+	// Unit is "documents" or "bytes".
+	Unit string
+	// Suggestion overrides the default remediation hint in the message.
+	Suggestion string
+}
+
+// Error implements the error interface.
+func (e *ResultTooLargeError) Error() string {
+	suggestion := e.Suggestion
+	if suggestion == "" {
+		suggestion = "add a filter or an explicit limit, or iterate in pages"
+	}
+	return fmt.Sprintf("mongo: result for %s exceeds the limit of %d %s (suggestion: %s)", e.Namespace, e.Limit, e.Unit, suggestion)
+}
+
+// Unwrap returns ErrResultTooLarge so the error can be checked with errors.Is.
+func (e *ResultTooLargeError) Unwrap() error {
+	return ErrResultTooLarge
+}
+
+// ModifyLimitError is returned when UpdateMany or DeleteMany matches more
+// documents than the collection's MaxModifiedDocuments.
+type ModifyLimitError struct {
+	Namespace string
+	Operation string
+	Limit     int64
+	Matched   int64
+}
+
+// Error implements the error interface.
+func (e *ModifyLimitError) Error() string {
+	return fmt.Sprintf("mongo: %s on %s matches %d documents, more than the limit of %d (narrow the filter or set BypassModifyLimit)", e.Operation, e.Namespace, e.Matched, e.Limit)
+}
+
+// Unwrap returns ErrModifyLimitExceeded so the error can be checked with errors.Is.
+func (e *ModifyLimitError) Unwrap() error {
+	return ErrModifyLimitExceeded
+}
+
+// UnsafeKeyError is returned when a collection with RejectUnsafeKeys is
+// asked to store a document with a key that starts with "$" or contains ".".
+type UnsafeKeyError struct {
+	Namespace string
+	// Path is the dotted path of the document holding the key, empty for
+	// the top level.
+	Path string
+	Key  string
+}
+
+// Error implements the error interface.
+func (e *UnsafeKeyError) Error() string {
+	at := "top level"
+	if e.Path != "" {
+		at = fmt.Sprintf("%q", e.Path)
+	}
+	return fmt.Sprintf("mongo: document for %s has unsafe key %q at %s (keys may not start with '$' or contain '.')", e.Namespace, e.Key, at)
+}
+
+// Unwrap returns ErrUnsafeKey so the error can be checked with errors.Is.
+func (e *UnsafeKeyError) Unwrap() error {
+	return ErrUnsafeKey
+}
+
+// SchemaError is returned when a collection with a Schema is asked to write
+// a document, or an update, that fails it.
+type SchemaError struct {
+	Namespace string
+	// Violations are every failed constraint, in document order.
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	if e.Namespace == "" {
+		return "mongo: document fails schema validation: " + strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("mongo: document for %s fails schema validation: %s", e.Namespace, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns ErrSchemaViolation so the error can be checked with errors.Is.
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// UnknownFieldError is returned when decoding with DisallowUnknownFields
+// meets a document field that no field of the target struct claims.
+type UnknownFieldError struct {
+	// Field is the dotted path of the field, with array indexes.
+	Field string
+	// Type is the type decoded into.
+	Type reflect.Type
+}
+
+// Error implements the error interface.
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("mongo: unknown field %q decoding into %v", e.Field, e.Type)
+}
+
+// Unwrap returns ErrUnknownField so the error can be checked with errors.Is.
+func (e *UnknownFieldError) Unwrap() error {
+	return ErrUnknownField
+}
+
+// PipelineError is returned when linting rejects an aggregation pipeline.
+type PipelineError struct {
+	// Issues are the problems that caused the rejection.
+	Issues []PipelineIssue
+}
+
+// Error implements the error interface.
+func (e *PipelineError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return "mongo: invalid pipeline: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns ErrInvalidPipeline so the error can be checked with errors.Is.
+func (e *PipelineError) Unwrap() error {
+	return ErrInvalidPipeline
+}
+
+// PanicError is returned when a callback run by the SDK, such as a
+// WithTransaction function or a Subscribe handler, panics. The panic is
+// recovered so it does not kill the goroutine running the callback.
+type PanicError struct {
+	// Callback names the callback that panicked, such as "Subscribe handler".
+	Callback string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack string
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("mongo: %s panicked: %v", e.Callback, e.Value)
+}
+
+// Unwrap returns ErrCallbackPanic, and Value if it is an error, so the
+// error can be checked with errors.Is.
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrCallbackPanic, err}
+	}
+	return []error{ErrCallbackPanic}
+}
+
+// LeakError is returned by Disconnect, with leak detection on, when
+// cursors or change streams opened by the client were never closed.
+type LeakError struct {
+	// Leaks are the resources left open, in the order they were opened.
+	Leaks []Leak
+}
+
+// Error implements the error interface.
+func (e *LeakError) Error() string {
+	msgs := make([]string, len(e.Leaks))
+	for i, l := range e.Leaks {
+		msgs[i] = fmt.Sprintf("%s on %s opened by %s", l.Kind, l.Namespace, l.Caller)
+	}
+	return fmt.Sprintf("mongo: %d cursors or change streams left open: %s", len(e.Leaks), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns ErrResourceLeak so the error can be checked with errors.Is.
+func (e *LeakError) Unwrap() error {
+	return ErrResourceLeak
+}
+
 // CommandError represents an error from a database command.
 type CommandError struct {
 	Code    int
 	Name    string
 	Message string
+	// Labels are the server's errorLabels, such as
+	// "ResumableChangeStreamError".
+	Labels []string
+}
+
+// HasErrorLabel reports whether the server labeled the error with label.
+func (e *CommandError) HasErrorLabel(label string) bool {
+	for _, l := range e.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
 }
 
 // Error implements the error interface.
@@ -119,6 +376,84 @@ func (e *CommandError) Error() string {
 	return fmt.Sprintf("mongo command error (code %d): %s", e.Code, e.Message)
 }
 
+// Server error codes.
+const (
+	// codeNamespaceNotFound is the server error code for a missing namespace.
+	codeNamespaceNotFound = 26
+	// codeCommandNotFound and codeCommandNotSupported report commands or
+	// RPC methods the backend does not implement.
+	codeCommandNotFound     = 59
+	codeCommandNotSupported = 115
+	// codeChangeStreamNotSupported reports a change stream on a deployment
+	// without an oplog, such as a standalone server.
+	codeChangeStreamNotSupported = 40573
+)
+
+// Is reports whether a NamespaceNotFound error matches ErrNamespaceNotFound.
+func (e *CommandError) Is(target error) bool {
+	return target == ErrNamespaceNotFound && (e.Code == codeNamespaceNotFound || e.Name == "NamespaceNotFound")
+}
+
+// serverError returns the CommandError described by an error response
+// document, {ok: 0, code, codeName, errmsg}, or nil for any other result.
+func serverError(result any) error {
+	doc, ok := result.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if okValue, ok := asInt64(doc["ok"]); !ok || okValue != 0 {
+		return nil
+	}
+	code, ok := asInt64(doc["code"])
+	if !ok {
+		return nil
+	}
+	err := &CommandError{Code: int(code)}
+	err.Name, _ = doc["codeName"].(string)
+	if msg, ok := doc["errmsg"].(string); ok {
+		err.Message = msg
+	} else {
+		err.Message, _ = doc["error"].(string)
+	}
+	if labels, ok := doc["errorLabels"].([]any); ok {
+		for _, label := range labels {
+			if l, ok := label.(string); ok {
+				err.Labels = append(err.Labels, l)
+			}
+		}
+	}
+	return err
+}
+
+// NotSupportedError is returned when the backend does not support a
+// feature, either because it was not advertised in the handshake or because
+// the server rejected the operation as unsupported. It matches both
+// ErrNotSupportedByBackend and ErrUnsupportedFeature.
+type NotSupportedError struct {
+	Feature string
+	// Wrapped is the server error, if the server rejected the operation.
+	Wrapped error
+}
+
+// Error implements the error interface.
+func (e *NotSupportedError) Error() string {
+	if e.Wrapped != nil {
+		return fmt.Sprintf("mongo: %s is not supported by the backend: %v", e.Feature, e.Wrapped)
+	}
+	return fmt.Sprintf("mongo: %s is not supported by the backend", e.Feature)
+}
+
+// Is reports whether target is ErrNotSupportedByBackend or
+// ErrUnsupportedFeature.
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrNotSupportedByBackend || target == ErrUnsupportedFeature
+}
+
+// Unwrap implements the errors unwrap interface.
+func (e *NotSupportedError) Unwrap() error {
+	return e.Wrapped
+}
+
 // IsNetworkError returns true if the error is a network-related error.
 func IsNetworkError(err error) bool {
 	var connErr *ConnectionError
@@ -142,3 +477,84 @@ func IsDuplicateKeyError(err error) bool {
 	}
 	return false
 }
+
+// retryableCodes are server error codes for transient conditions.
+var retryableCodes = map[int]bool{
+	6:     true, // HostUnreachable
+	7:     true, // HostNotFound
+	89:    true, // NetworkTimeout
+	91:    true, // ShutdownInProgress
+	189:   true, // PrimarySteppedDown
+	262:   true, // ExceededTimeLimit
+	462:   true, // IngressRequestRateLimitExceeded
+	9001:  true, // SocketException
+	10107: true, // NotWritablePrimary
+	11600: true, // InterruptedAtShutdown
+	11602: true, // InterruptedDueToReplStateChange
+	13435: true, // NotPrimaryNoSecondaryOk
+	13436: true, // NotPrimaryOrSecondary
+}
+
+// overloadCodes are server error codes indicating the backend is shedding load.
+var overloadCodes = map[int]bool{
+	462: true, // IngressRequestRateLimitExceeded
+}
+
+// IsRetryable returns true if the error is transient and the operation may be retried.
+func IsRetryable(err error) bool {
+	if IsNetworkError(err) {
+		return true
+	}
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return retryableCodes[cmdErr.Code]
+	}
+	return false
+}
+
+// IsOverloaded returns true if the error indicates the backend is overloaded.
+func IsOverloaded(err error) bool {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return overloadCodes[cmdErr.Code]
+	}
+	return false
+}
+
+// Change stream error labels.
+const (
+	labelResumableChangeStream    = "ResumableChangeStreamError"
+	labelNonResumableChangeStream = "NonResumableChangeStreamError"
+)
+
+// resumableChangeStreamCodes are the server error codes after which a change
+// stream can be resumed, for servers that do not label their errors.
+var resumableChangeStreamCodes = map[int]bool{
+	43:    true, // CursorNotFound
+	63:    true, // StaleShardVersion
+	133:   true, // FailedToSatisfyReadPreference
+	150:   true, // StaleEpoch
+	234:   true, // RetryChangeStream
+	13388: true, // StaleConfig
+}
+
+// IsResumableChangeStreamError returns true if a change stream that failed
+// with err can be resumed from its last resume token: network errors,
+// errors the server labels ResumableChangeStreamError, and, without a
+// label, transient and stale routing error codes.
+func IsResumableChangeStreamError(err error) bool {
+	if IsNetworkError(err) {
+		return true
+	}
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	switch {
+	case cmdErr.HasErrorLabel(labelResumableChangeStream):
+		return true
+	case cmdErr.HasErrorLabel(labelNonResumableChangeStream):
+		return false
+	}
+	return retryableCodes[cmdErr.Code] || resumableChangeStreamCodes[cmdErr.Code]
+}
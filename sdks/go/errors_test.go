@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"context"
 	"errors"
 	"testing"
 )
@@ -243,3 +244,115 @@ func TestErrorsIs(t *testing.T) {
 		t.Error("expected errors.Is to return false for different errors")
 	}
 }
+
+// TestIsRetryable tests classification of transient errors.
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(&ConnectionError{Address: "localhost"}) {
+		t.Error("expected connection error to be retryable")
+	}
+
+	if !IsRetryable(&CommandError{Code: 189}) {
+		t.Error("expected PrimarySteppedDown to be retryable")
+	}
+
+	if IsRetryable(&CommandError{Code: 11000}) {
+		t.Error("expected duplicate key not to be retryable")
+	}
+
+	if IsRetryable(errors.New("boom")) {
+		t.Error("expected generic error not to be retryable")
+	}
+}
+
+// TestIsOverloaded tests classification of overload errors.
+func TestIsOverloaded(t *testing.T) {
+	if !IsOverloaded(&CommandError{Code: 462}) {
+		t.Error("expected rate limit error to be an overload")
+	}
+
+	if IsOverloaded(&ConnectionError{Address: "localhost"}) {
+		t.Error("expected connection error not to be an overload")
+	}
+}
+
+// TestServerError tests parsing error response documents.
+func TestServerError(t *testing.T) {
+	err := serverError(map[string]any{"ok": float64(0), "code": float64(26), "codeName": "NamespaceNotFound", "errmsg": "ns not found: db.users"})
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != 26 || cmdErr.Name != "NamespaceNotFound" || cmdErr.Message != "ns not found: db.users" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	err = serverError(map[string]any{"ok": float64(0), "code": float64(2), "error": "bad value"})
+	if !errors.As(err, &cmdErr) || cmdErr.Message != "bad value" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	for _, result := range []any{
+		nil,
+		[]any{},
+		map[string]any{"ok": float64(1)},
+		map[string]any{"ok": float64(0)},
+		map[string]any{"n": float64(0), "code": float64(26)},
+	} {
+		if err := serverError(result); err != nil {
+			t.Errorf("%v: unexpected error: %v", result, err)
+		}
+	}
+}
+
+// TestIsResumableChangeStreamError tests classifying change stream errors
+// by label and code.
+func TestIsResumableChangeStreamError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&ConnectionError{Address: "localhost"}, true},
+		{&CommandError{Code: 280, Labels: []string{"ResumableChangeStreamError"}}, true},
+		{&CommandError{Code: 91, Labels: []string{"NonResumableChangeStreamError"}}, false},
+		{&CommandError{Code: 43}, true},
+		{&CommandError{Code: 10107}, true},
+		{&CommandError{Code: 280}, false},
+		{errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsResumableChangeStreamError(tt.err); got != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+
+	err := serverError(map[string]any{"ok": float64(0), "code": float64(280), "errorLabels": []any{"ResumableChangeStreamError"}})
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || !cmdErr.HasErrorLabel("ResumableChangeStreamError") || cmdErr.HasErrorLabel("other") {
+		t.Errorf("unexpected labels: %#v", err)
+	}
+}
+
+// TestErrNamespaceNotFound tests telling missing namespaces from empty results.
+func TestErrNamespaceNotFound(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", map[string]any{"ok": float64(0), "code": float64(26), "codeName": "NamespaceNotFound", "errmsg": "ns not found"}, nil)
+	mock.addCall("mongo.findOne", nil, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("missing")
+	ctx := context.Background()
+
+	_, err := coll.CountDocuments(ctx, map[string]any{})
+	if !errors.Is(err, ErrNamespaceNotFound) || errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNamespaceNotFound, got %v", err)
+	}
+
+	err = coll.FindOne(ctx, map[string]any{}).Err()
+	if !errors.Is(err, ErrNoDocuments) || errors.Is(err, ErrNamespaceNotFound) {
+		t.Errorf("expected ErrNoDocuments, got %v", err)
+	}
+
+	if errors.Is(&CommandError{Code: 2}, ErrNamespaceNotFound) {
+		t.Error("expected other codes not to match ErrNamespaceNotFound")
+	}
+	if !errors.Is(&CommandError{Name: "NamespaceNotFound"}, ErrNamespaceNotFound) {
+		t.Error("expected the code name to match ErrNamespaceNotFound")
+	}
+}
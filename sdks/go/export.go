@@ -0,0 +1,188 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultExportBatchSize is the number of documents fetched per request by
+// Export.
+const DefaultExportBatchSize = 1000
+
+// FieldTransformer rewrites a field of an exported document, for example to
+// anonymize production data before it is loaded into staging. It returns the
+// new value, or false to drop the field.
+type FieldTransformer interface {
+	Transform(value any) (any, bool)
+}
+
+// FieldTransformerFunc adapts a function to the FieldTransformer interface.
+type FieldTransformerFunc func(value any) (any, bool)
+
+// Transform calls f.
+func (f FieldTransformerFunc) Transform(value any) (any, bool) {
+	return f(value)
+}
+
+// DropField returns a transformer that removes the field.
+func DropField() FieldTransformer {
+	return FieldTransformerFunc(func(any) (any, bool) {
+		return nil, false
+	})
+}
+
+// HashField returns a transformer that replaces the value with the
+// hex-encoded SHA-256 of salt and the value's JSON encoding. Equal values
+// hash equally, so hashed fields still work as join keys.
+func HashField(salt string) FieldTransformer {
+	return FieldTransformerFunc(func(value any) (any, bool) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		sum := sha256.Sum256(append([]byte(salt), data...))
+		return hex.EncodeToString(sum[:]), true
+	})
+}
+
+// MaskField returns a transformer that replaces all but the last keep
+// characters of a string with '*'. Values that are not strings are replaced
+// with "***".
+func MaskField(keep int) FieldTransformer {
+	return FieldTransformerFunc(func(value any) (any, bool) {
+		s, ok := value.(string)
+		if !ok {
+			return "***", true
+		}
+		runes := []rune(s)
+		masked := len(runes) - keep
+		if masked < 0 {
+			masked = 0
+		}
+		for i := 0; i < masked; i++ {
+			runes[i] = '*'
+		}
+		return string(runes), true
+	})
+}
+
+// ExportOptions configures an Export operation.
+type ExportOptions struct {
+	Projection any
+	BatchSize  *int64
+	// Transforms maps dotted field paths to the transformer applied to
+	// them. Paths through arrays apply to every element.
+	Transforms map[string]FieldTransformer
+}
+
+// SetProjection sets the exported fields.
+func (o *ExportOptions) SetProjection(projection any) *ExportOptions {
+	o.Projection = projection
+	return o
+}
+
+// SetBatchSize sets the number of documents fetched per request.
+func (o *ExportOptions) SetBatchSize(size int64) *ExportOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetTransform sets the transformer applied to the field at path.
+func (o *ExportOptions) SetTransform(path string, t FieldTransformer) *ExportOptions {
+	if o.Transforms == nil {
+		o.Transforms = make(map[string]FieldTransformer)
+	}
+	o.Transforms[path] = t
+	return o
+}
+
+// Export streams the documents matching filter to w as newline-delimited
+// JSON, in _id order, applying the configured field transformers to each
+// document before it is written. Documents are fetched in pages keyed on
+// _id, so memory use is bounded by the batch size. It returns the number of
+// documents written.
+func (c *Collection) Export(ctx context.Context, w io.Writer, filter any, opts ...*ExportOptions) (int64, error) {
+	ctx = withBatchPriority(ctx)
+	var projection any
+	batchSize := int64(DefaultExportBatchSize)
+	transforms := make(map[string]FieldTransformer)
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.Projection != nil {
+				projection = opt.Projection
+			}
+			if opt.BatchSize != nil {
+				batchSize = *opt.BatchSize
+			}
+			for path, t := range opt.Transforms {
+				transforms[path] = t
+			}
+		}
+	}
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("mongo: batch size must be positive, got %d", batchSize)
+	}
+	for path, t := range transforms {
+		if path == "" || t == nil {
+			return 0, fmt.Errorf("mongo: invalid transform for field %q", path)
+		}
+	}
+
+	// Apply transforms in a fixed order so nested paths behave the same on
+	// every run.
+	paths := make([]string, 0, len(transforms))
+	for path := range transforms {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	enc := json.NewEncoder(w)
+	client := c.database.client
+	return c.scanByID(ctx, filter, projection, batchSize, func(doc map[string]any) error {
+		err := client.runCallback("FieldTransformer", func() error {
+			for _, path := range paths {
+				transformField(doc, strings.Split(path, "."), transforms[path])
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return enc.Encode(doc)
+	})
+}
+
+// transformField applies t to the field at path within doc, descending into
+// every element of arrays along the way.
+func transformField(doc map[string]any, path []string, t FieldTransformer) {
+	value, ok := doc[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		if v, keep := t.Transform(value); keep {
+			doc[path[0]] = v
+		} else {
+			delete(doc, path[0])
+		}
+		return
+	}
+	transformNested(value, path[1:], t)
+}
+
+func transformNested(value any, path []string, t FieldTransformer) {
+	switch v := value.(type) {
+	case map[string]any:
+		transformField(v, path, t)
+	case []any:
+		for _, elem := range v {
+			transformNested(elem, path, t)
+		}
+	}
+}
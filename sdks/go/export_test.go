@@ -0,0 +1,97 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestFieldTransformers tests the built-in field transformers.
+func TestFieldTransformers(t *testing.T) {
+	if v, keep := MaskField(4).Transform("4111111111111111"); !keep || v != "************1111" {
+		t.Errorf("unexpected mask: %v", v)
+	}
+	if v, _ := MaskField(10).Transform("abc"); v != "abc" {
+		t.Errorf("expected short value unchanged, got %v", v)
+	}
+	if v, _ := MaskField(2).Transform(42); v != "***" {
+		t.Errorf("expected non-string mask, got %v", v)
+	}
+
+	a, _ := HashField("salt").Transform("ada@example.com")
+	b, _ := HashField("salt").Transform("ada@example.com")
+	c, _ := HashField("other").Transform("ada@example.com")
+	if a != b || a == c || len(a.(string)) != 64 {
+		t.Errorf("unexpected hashes: %v %v %v", a, b, c)
+	}
+
+	if _, keep := DropField().Transform("x"); keep {
+		t.Error("expected DropField to drop")
+	}
+}
+
+// TestCollectionExport tests streaming an export with transformers.
+func TestCollectionExport(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{
+			"_id":      "u1",
+			"email":    "ada@example.com",
+			"password": "secret",
+			"card":     "4111111111111111",
+			"contacts": []any{map[string]any{"phone": "5551234"}},
+		},
+		map[string]any{"_id": "u2", "email": "bob@example.com"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	opts := (&ExportOptions{}).
+		SetTransform("email", HashField("s")).
+		SetTransform("password", DropField()).
+		SetTransform("card", MaskField(4)).
+		SetTransform("contacts.phone", MaskField(2))
+
+	var buf bytes.Buffer
+	n, err := coll.Export(context.Background(), &buf, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 documents, got %d", n)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &doc); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if _, ok := doc["password"]; ok {
+		t.Error("expected password to be dropped")
+	}
+	if doc["card"] != "************1111" || doc["email"] == "ada@example.com" || doc["_id"] != "u1" {
+		t.Errorf("unexpected document: %v", doc)
+	}
+	if phone := doc["contacts"].([]any)[0].(map[string]any)["phone"]; phone != "*****34" {
+		t.Errorf("unexpected nested phone: %v", phone)
+	}
+}
+
+// TestCollectionExportInvalidOptions tests option validation.
+func TestCollectionExportInvalidOptions(t *testing.T) {
+	coll := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017").Database("testdb").Collection("users")
+
+	var buf bytes.Buffer
+	if _, err := coll.Export(context.Background(), &buf, nil, (&ExportOptions{}).SetBatchSize(0)); err == nil {
+		t.Error("expected error for non-positive batch size")
+	}
+	if _, err := coll.Export(context.Background(), &buf, nil, (&ExportOptions{}).SetTransform("email", nil)); err == nil {
+		t.Error("expected error for nil transformer")
+	}
+}
@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// resultDocument returns doc ready for decoding: when the client decodes
+// Extended JSON, its wrappers are converted to the forms the SDK decodes.
+func (c *Client) resultDocument(doc any) any {
+	if !c.extJSON {
+		return doc
+	}
+	return fromExtendedJSON(doc)
+}
+
+// resultDocuments applies resultDocument to each of docs.
+func (c *Client) resultDocuments(docs []any) []any {
+	if !c.extJSON {
+		return docs
+	}
+	converted := make([]any, len(docs))
+	for i, doc := range docs {
+		converted[i] = fromExtendedJSON(doc)
+	}
+	return converted
+}
+
+// fromExtendedJSON converts the canonical and relaxed Extended JSON v2
+// wrappers within v. Numbers become json.Number, keeping their digits,
+// $date a time.Time, and $binary a []byte, or a $uuid for subtype 4.
+// $oid, $numberDecimal and $binary with other subtypes are kept, as
+// bson.ObjectID, bson.Decimal128 and bson.Binary decode them, and so are
+// non-finite doubles, which JSON cannot express.
+func fromExtendedJSON(v any) any {
+	switch v := v.(type) {
+	case []any:
+		converted := make([]any, len(v))
+		for i, elem := range v {
+			converted[i] = fromExtendedJSON(elem)
+		}
+		return converted
+	case map[string]any:
+		if len(v) == 1 {
+			if converted, ok := fromExtendedJSONWrapper(v); ok {
+				return converted
+			}
+		}
+		converted := make(map[string]any, len(v))
+		for k, elem := range v {
+			converted[k] = fromExtendedJSON(elem)
+		}
+		return converted
+	}
+	return v
+}
+
+// fromExtendedJSONWrapper converts a single-key wrapper document. It reports
+// false for documents it leaves alone.
+func fromExtendedJSONWrapper(doc map[string]any) (any, bool) {
+	for key, value := range doc {
+		s, _ := value.(string)
+		switch key {
+		case "$numberInt", "$numberLong":
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return json.Number(s), true
+			}
+		case "$numberDouble":
+			if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), true
+			}
+		case "$date":
+			if inner, ok := value.(map[string]any); ok {
+				ms, _ := inner["$numberLong"].(string)
+				if n, err := strconv.ParseInt(ms, 10, 64); err == nil {
+					return time.UnixMilli(n).UTC(), true
+				}
+				return nil, false
+			}
+			if t, err := parseDateTime(value); err == nil {
+				return t.UTC(), true
+			}
+		case "$binary":
+			inner, _ := value.(map[string]any)
+			encoded, _ := inner["base64"].(string)
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, false
+			}
+			switch subtype, _ := inner["subType"].(string); {
+			case subtype == "04" && len(data) == 16:
+				return map[string]any{"$uuid": formatUUID(data)}, true
+			case subtype == "00" || subtype == "0":
+				return data, true
+			}
+			return nil, false
+		}
+	}
+	return nil, false
+}
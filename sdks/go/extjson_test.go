@@ -0,0 +1,101 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// TestFromExtendedJSON tests converting canonical and relaxed wrappers.
+func TestFromExtendedJSON(t *testing.T) {
+	in := map[string]any{
+		"int":    map[string]any{"$numberInt": "7"},
+		"long":   map[string]any{"$numberLong": "9007199254740993"},
+		"double": map[string]any{"$numberDouble": "2.5"},
+		"nan":    map[string]any{"$numberDouble": "NaN"},
+		"dates": []any{
+			map[string]any{"$date": map[string]any{"$numberLong": "1704164645006"}},
+			map[string]any{"$date": "2024-01-02T03:04:05.006Z"},
+		},
+		"bin":  map[string]any{"$binary": map[string]any{"base64": "AQI=", "subType": "00"}},
+		"uuid": map[string]any{"$binary": map[string]any{"base64": "ASNFZ4mrze8BI0VniavN7w==", "subType": "04"}},
+		"oid":  map[string]any{"$oid": "507f1f77bcf86cd799439011"},
+		"ops":  map[string]any{"$gt": map[string]any{"$numberInt": "1"}},
+	}
+	when := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	want := map[string]any{
+		"int":    json.Number("7"),
+		"long":   json.Number("9007199254740993"),
+		"double": json.Number("2.5"),
+		"nan":    map[string]any{"$numberDouble": "NaN"},
+		"dates":  []any{when, when},
+		"bin":    []byte{1, 2},
+		"uuid":   map[string]any{"$uuid": "01234567-89ab-cdef-0123-456789abcdef"},
+		"oid":    map[string]any{"$oid": "507f1f77bcf86cd799439011"},
+		"ops":    map[string]any{"$gt": json.Number("1")},
+	}
+	if got := fromExtendedJSON(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestClientExtendedJSON tests decoding Extended JSON results with the
+// client option, and leaving them alone without it.
+func TestClientExtendedJSON(t *testing.T) {
+	doc := map[string]any{
+		"_id":    map[string]any{"$oid": "507f1f77bcf86cd799439011"},
+		"visits": map[string]any{"$numberLong": "9007199254740993"},
+		"score":  map[string]any{"$numberDouble": "1.5"},
+		"joined": map[string]any{"$date": map[string]any{"$numberLong": "1704164645006"}},
+	}
+	type user struct {
+		ID     bson.ObjectID `json:"_id"`
+		Visits int64         `json:"visits"`
+		Score  float64       `json:"score"`
+		Joined time.Time     `json:"joined"`
+	}
+
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{doc}, nil)
+	mock.addCall("mongo.findOne", doc, nil)
+	mock.addCall("mongo.findOne", doc, nil)
+
+	options := DefaultClientOptions().SetExtendedJSON(true).SetNumberDecoding(NumberDecodingInt64WhenExact)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", options)
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	users, err := FindAs[user](ctx, coll, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := user{
+		ID:     testObjectID,
+		Visits: 9007199254740993,
+		Score:  1.5,
+		Joined: time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC),
+	}
+	if len(users) != 1 || users[0] != want {
+		t.Errorf("expected %+v, got %+v", want, users)
+	}
+
+	var m map[string]any
+	if err := coll.FindOne(ctx, map[string]any{}).Decode(&m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m["visits"] != int64(9007199254740993) || m["joined"] != "2024-01-02T03:04:05.006Z" {
+		t.Errorf("unexpected document: %v", m)
+	}
+
+	plain := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions())
+	if err := plain.Database("testdb").Collection("users").FindOne(ctx, map[string]any{}).Decode(&m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := m["visits"].(map[string]any); !ok {
+		t.Errorf("expected wrappers to be kept without the option, got %v", m)
+	}
+}
@@ -0,0 +1,332 @@
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// ErrRejected is returned when untrusted input does not fit a Sanitizer's
+// allowlist.
+var ErrRejected = errors.New("filter: input rejected")
+
+// FieldType is the type a Sanitizer coerces a field's values to.
+type FieldType int
+
+// Field types.
+const (
+	// String accepts strings, and formats numbers and booleans as strings.
+	String FieldType = iota
+	// Int accepts integers and strings holding them.
+	Int
+	// Float accepts numbers and strings holding them.
+	Float
+	// Bool accepts booleans and the strings strconv.ParseBool does.
+	Bool
+	// Time accepts time.Time and RFC 3339 strings.
+	Time
+	// ObjectID accepts bson.ObjectID and hex strings.
+	ObjectID
+)
+
+// Operator is a query operator a Sanitizer can allow.
+type Operator string
+
+// Operators a Field may allow. Equality is always allowed.
+const (
+	Eq     Operator = "$eq"
+	Ne     Operator = "$ne"
+	Gt     Operator = "$gt"
+	Gte    Operator = "$gte"
+	Lt     Operator = "$lt"
+	Lte    Operator = "$lte"
+	In     Operator = "$in"
+	Nin    Operator = "$nin"
+	Exists Operator = "$exists"
+)
+
+// Field allows untrusted input to filter on one field.
+type Field struct {
+	// Name is the name of the field in the input.
+	Name string
+	// Path is the document path the field filters on. It defaults to Name.
+	Path string
+	// Type is the type values are coerced to.
+	Type FieldType
+	// Operators are the operators allowed besides equality.
+	Operators []Operator
+}
+
+// Sanitizer builds filters from untrusted input, such as the parameters of
+// a search endpoint, keeping only what an allowlist permits:
+//
+//	s := filter.NewSanitizer(
+//		filter.Field{Name: "status", Type: filter.String, Operators: []filter.Operator{filter.In}},
+//		filter.Field{Name: "age", Type: filter.Int, Operators: []filter.Operator{filter.Gte, filter.Lte}},
+//	)
+//	f, err := s.SanitizeValues(r.URL.Query()) // ?status=A&age[gte]=21
+//
+// Unknown fields and operators, documents in value positions and values
+// that do not coerce to the field's type are rejected with ErrRejected, so
+// the input can only ever produce the filters the allowlist describes. A
+// Sanitizer is safe for concurrent use.
+type Sanitizer struct {
+	fields map[string]Field
+}
+
+// NewSanitizer returns a Sanitizer allowing the given fields.
+func NewSanitizer(fields ...Field) *Sanitizer {
+	s := &Sanitizer{fields: make(map[string]Field, len(fields))}
+	for _, f := range fields {
+		if f.Path == "" {
+			f.Path = f.Name
+		}
+		s.fields[f.Name] = f
+	}
+	return s
+}
+
+// Sanitize builds a filter from decoded input, typically a JSON request
+// body. Each value is either a scalar, matched for equality, or a document
+// of allowed operators:
+//
+//	{"status": "A", "age": {"$gte": 21}}
+func (s *Sanitizer) Sanitize(input map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(input))
+	for _, name := range sortedNames(input) {
+		field, ok := s.fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q is not allowed", ErrRejected, name)
+		}
+		ops, isDoc := input[name].(map[string]any)
+		if !isDoc {
+			v, err := field.coerce(Eq, input[name])
+			if err != nil {
+				return nil, err
+			}
+			out[field.Path] = v
+			continue
+		}
+		cond := make(map[string]any, len(ops))
+		for _, key := range sortedNames(ops) {
+			op, err := field.operator(key)
+			if err != nil {
+				return nil, err
+			}
+			if cond[string(op)], err = field.coerce(op, ops[key]); err != nil {
+				return nil, err
+			}
+		}
+		out[field.Path] = cond
+	}
+	return out, nil
+}
+
+// SanitizeValues builds a filter from URL query values. A plain name
+// matches for equality and name[op] applies an operator, written with or
+// without its "$":
+//
+//	?status=A&age[gte]=21&tag[in]=a&tag[in]=b
+//
+// Repeated values are only accepted for $in and $nin.
+func (s *Sanitizer) SanitizeValues(values url.Values) (map[string]any, error) {
+	input := make(map[string]any, len(values))
+	for _, key := range sortedNames(values) {
+		name, op := key, ""
+		if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], key[i+1:len(key)-1]
+		}
+		vals := values[key]
+		var value any = vals[0]
+		if op == "in" || op == "nin" || op == "$in" || op == "$nin" {
+			list := make([]any, len(vals))
+			for i, v := range vals {
+				list[i] = v
+			}
+			value = list
+		} else if len(vals) > 1 {
+			return nil, fmt.Errorf("%w: %q is repeated", ErrRejected, key)
+		}
+
+		if op == "" {
+			if _, dup := input[name]; dup {
+				return nil, fmt.Errorf("%w: %q combines equality and operators", ErrRejected, name)
+			}
+			input[name] = value
+			continue
+		}
+		if !strings.HasPrefix(op, "$") {
+			op = "$" + op
+		}
+		ops, ok := input[name].(map[string]any)
+		if !ok {
+			if _, dup := input[name]; dup {
+				return nil, fmt.Errorf("%w: %q combines equality and operators", ErrRejected, name)
+			}
+			ops = make(map[string]any)
+			input[name] = ops
+		}
+		ops[op] = value
+	}
+	return s.Sanitize(input)
+}
+
+// operator returns the allowed operator named key.
+func (f Field) operator(key string) (Operator, error) {
+	op := Operator(key)
+	if op == Eq {
+		return op, nil
+	}
+	for _, allowed := range f.Operators {
+		if allowed == op {
+			return op, nil
+		}
+	}
+	return "", fmt.Errorf("%w: operator %q is not allowed on %q", ErrRejected, key, f.Name)
+}
+
+// coerce converts the operand of op to the field's type.
+func (f Field) coerce(op Operator, value any) (any, error) {
+	switch op {
+	case Exists:
+		b, err := coerceBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s on %q: %v", ErrRejected, op, f.Name, err)
+		}
+		return b, nil
+	case In, Nin:
+		list, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s on %q requires a list", ErrRejected, op, f.Name)
+		}
+		out := make([]any, len(list))
+		for i, v := range list {
+			c, err := f.coerceValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s on %q: %v", ErrRejected, op, f.Name, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	}
+	v, err := f.coerceValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s on %q: %v", ErrRejected, op, f.Name, err)
+	}
+	return v, nil
+}
+
+// coerceValue converts a scalar to the field's type.
+func (f Field) coerceValue(value any) (any, error) {
+	switch value.(type) {
+	case map[string]any, []any, nil:
+		return nil, fmt.Errorf("expected a value, got %T", value)
+	}
+
+	switch f.Type {
+	case String:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	case Int:
+		switch v := value.(type) {
+		case string:
+			return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+				return int64(v), nil
+			}
+		case json.Number:
+			return v.Int64()
+		case int:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		}
+	case Float:
+		switch v := value.(type) {
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		case float64:
+			return v, nil
+		case json.Number:
+			return v.Float64()
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+	case Bool:
+		return coerceBool(value)
+	case Time:
+		switch v := value.(type) {
+		case string:
+			return time.Parse(time.RFC3339, v)
+		case time.Time:
+			return v, nil
+		}
+	case ObjectID:
+		switch v := value.(type) {
+		case string:
+			return bson.ObjectIDFromHex(v)
+		case bson.ObjectID:
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot use %T as %s", value, f.Type)
+}
+
+// coerceBool converts a boolean or a string holding one.
+func coerceBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("cannot use %T as a boolean", value)
+}
+
+// String returns the name of the type.
+func (t FieldType) String() string {
+	switch t {
+	case String:
+		return "string"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case Time:
+		return "time"
+	case ObjectID:
+		return "ObjectID"
+	}
+	return "FieldType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// sortedNames returns the keys of m in order, so errors are deterministic.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// testSanitizer allows the fields the sanitizer tests use.
+func testSanitizer() *Sanitizer {
+	return NewSanitizer(
+		Field{Name: "status", Type: String, Operators: []Operator{In}},
+		Field{Name: "age", Type: Int, Operators: []Operator{Gte, Lte}},
+		Field{Name: "score", Type: Float},
+		Field{Name: "active", Type: Bool, Operators: []Operator{Exists}},
+		Field{Name: "since", Path: "createdAt", Type: Time, Operators: []Operator{Gt}},
+		Field{Name: "owner", Type: ObjectID},
+	)
+}
+
+// TestSanitize tests building filters from decoded input.
+func TestSanitize(t *testing.T) {
+	got, err := testSanitizer().Sanitize(map[string]any{
+		"status": map[string]any{"$in": []any{"A", float64(2)}},
+		"age":    map[string]any{"$gte": float64(21), "$lte": "65"},
+		"score":  "2.5",
+		"active": map[string]any{"$exists": true},
+		"since":  map[string]any{"$gt": "2024-01-02T03:04:05Z"},
+		"owner":  "507f1f77bcf86cd799439011",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	owner, _ := bson.ObjectIDFromHex("507f1f77bcf86cd799439011")
+	want := map[string]any{
+		"status":    map[string]any{"$in": []any{"A", "2"}},
+		"age":       map[string]any{"$gte": int64(21), "$lte": int64(65)},
+		"score":     2.5,
+		"active":    map[string]any{"$exists": true},
+		"createdAt": map[string]any{"$gt": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		"owner":     owner,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestSanitizeRejects tests that input outside the allowlist is rejected.
+func TestSanitizeRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]any
+	}{
+		{"unknown field", map[string]any{"password": "x"}},
+		{"operator injection", map[string]any{"status": map[string]any{"$ne": nil}}},
+		{"where", map[string]any{"$where": "sleep(1000)"}},
+		{"disallowed operator", map[string]any{"score": map[string]any{"$gt": 1}}},
+		{"nested document", map[string]any{"status": map[string]any{"$eq": map[string]any{"$gt": ""}}}},
+		{"array value", map[string]any{"status": []any{"A"}}},
+		{"bad int", map[string]any{"age": "21; drop"}},
+		{"fractional int", map[string]any{"age": 2.5}},
+		{"in without list", map[string]any{"status": map[string]any{"$in": "A"}}},
+		{"bad object id", map[string]any{"owner": "nope"}},
+		{"null", map[string]any{"status": nil}},
+	}
+
+	for _, tt := range tests {
+		if _, err := testSanitizer().Sanitize(tt.input); !errors.Is(err, ErrRejected) {
+			t.Errorf("%s: expected ErrRejected, got %v", tt.name, err)
+		}
+	}
+}
+
+// TestSanitizeValues tests building filters from query parameters.
+func TestSanitizeValues(t *testing.T) {
+	values, _ := url.ParseQuery("status[in]=A&status[in]=B&age[$gte]=21&active=true")
+	got, err := testSanitizer().SanitizeValues(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"status": map[string]any{"$in": []any{"A", "B"}},
+		"age":    map[string]any{"$gte": int64(21)},
+		"active": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, query := range []string{"age=1&age=2", "age=1&age[gte]=2", "score[gt]=1", "name=x"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := testSanitizer().SanitizeValues(values); !errors.Is(err, ErrRejected) {
+			t.Errorf("%q: expected ErrRejected, got %v", query, err)
+		}
+	}
+}
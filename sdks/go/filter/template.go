@@ -0,0 +1,213 @@
+// Package filter provides parameterized query filter templates, and a
+// Sanitizer for building filters from untrusted input.
+//
+// A template is a JSON filter document with named parameters in value
+// positions:
+//
+//	active := filter.Tmpl(`{"status": :status, "age": {"$gt": :minAge}}`)
+//	f, err := active.Bind(map[string]any{"status": "A", "minAge": 21})
+//
+// Bound values are placed into the parsed document as values, never spliced
+// into the template text, so they cannot change the shape of the query.
+//
+// When the shape of the query itself comes from input, as with the
+// parameters of a search endpoint, a Sanitizer limits it to allowed fields
+// and operators and coerces values to the declared types.
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidTemplate is returned when a template cannot be parsed.
+var ErrInvalidTemplate = errors.New("filter: invalid template")
+
+// ErrParameter is returned when bound parameters do not match a template.
+var ErrParameter = errors.New("filter: parameter mismatch")
+
+// placeholderPrefix marks parameter placeholders in the parsed document. It
+// cannot appear in template string literals, which are rejected if they
+// contain it.
+const placeholderPrefix = "\x00param:"
+
+// Template is a parsed filter template. It is safe for concurrent use.
+type Template struct {
+	source string
+	doc    map[string]any
+	params []string
+}
+
+// Parse parses a filter template. Parameters are written as :name in value
+// positions, where name starts with a letter or underscore followed by
+// letters, digits or underscores.
+func Parse(source string) (*Template, error) {
+	rewritten, params, err := rewrite(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(rewritten), &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("%w: template must be a document", ErrInvalidTemplate)
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return &Template{source: source, doc: doc, params: names}, nil
+}
+
+// Tmpl parses a filter template and panics if it is invalid. It is intended
+// for templates defined as constants; use Parse for templates loaded at run
+// time.
+func Tmpl(source string) *Template {
+	t, err := Parse(source)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the template source.
+func (t *Template) String() string {
+	return t.source
+}
+
+// Params returns the sorted names of the template parameters.
+func (t *Template) Params() []string {
+	return append([]string(nil), t.params...)
+}
+
+// Bind returns the filter document with every parameter replaced by its
+// value. It fails if a parameter is missing from params or params contains
+// a name the template does not use.
+func (t *Template) Bind(params map[string]any) (map[string]any, error) {
+	for _, name := range t.params {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("%w: missing value for :%s", ErrParameter, name)
+		}
+	}
+	if len(params) > len(t.params) {
+		for name := range params {
+			if !t.hasParam(name) {
+				return nil, fmt.Errorf("%w: unknown parameter :%s", ErrParameter, name)
+			}
+		}
+	}
+	return bind(t.doc, params).(map[string]any), nil
+}
+
+func (t *Template) hasParam(name string) bool {
+	i := sort.SearchStrings(t.params, name)
+	return i < len(t.params) && t.params[i] == name
+}
+
+// bind copies v, replacing placeholders with their values.
+func bind(v any, params map[string]any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			out[k] = bind(elem, params)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = bind(elem, params)
+		}
+		return out
+	case string:
+		if name, ok := strings.CutPrefix(v, placeholderPrefix); ok {
+			return params[name]
+		}
+	}
+	return v
+}
+
+// rewrite replaces each :name parameter in source with a JSON string
+// placeholder and returns the parameter names.
+func rewrite(source string) (string, map[string]bool, error) {
+	var b strings.Builder
+	params := make(map[string]bool)
+	// prev is the last significant character outside string literals.
+	var prev byte
+
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case c == '"':
+			end, err := stringEnd(source, i)
+			if err != nil {
+				return "", nil, err
+			}
+			literal := source[i:end]
+			if strings.Contains(literal, `\u0000`) {
+				return "", nil, fmt.Errorf("%w: NUL characters are not allowed in string literals", ErrInvalidTemplate)
+			}
+			b.WriteString(literal)
+			i = end - 1
+			prev = '"'
+		case c == ':' && prev != '"':
+			// A colon after a string is a key separator; anywhere else it
+			// starts a parameter.
+			j := i + 1
+			for j < len(source) && isNameByte(source[j], j == i+1) {
+				j++
+			}
+			if j == i+1 {
+				return "", nil, fmt.Errorf("%w: expected parameter name at offset %d", ErrInvalidTemplate, i)
+			}
+			if prev != ':' && prev != ',' && prev != '[' {
+				return "", nil, fmt.Errorf("%w: parameter %s at offset %d is not in a value position", ErrInvalidTemplate, source[i:j], i)
+			}
+			name := source[i+1 : j]
+			params[name] = true
+			placeholder, _ := json.Marshal(placeholderPrefix + name)
+			b.Write(placeholder)
+			i = j - 1
+			prev = '"'
+		default:
+			b.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				prev = c
+			}
+		}
+	}
+	return b.String(), params, nil
+}
+
+// stringEnd returns the offset just past the string literal starting at
+// source[start].
+func stringEnd(source string, start int) (int, error) {
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated string at offset %d", ErrInvalidTemplate, start)
+}
+
+// isNameByte reports whether c may appear in a parameter name.
+func isNameByte(c byte, first bool) bool {
+	switch {
+	case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestTemplateBind tests binding parameters into a template.
+func TestTemplateBind(t *testing.T) {
+	tmpl := Tmpl(`{"status": :status, "age": {"$gt": :minAge}, "tags": {"$in": [:tag, "x:y"]}, "active":true}`)
+
+	if got := tmpl.Params(); !reflect.DeepEqual(got, []string{"minAge", "status", "tag"}) {
+		t.Errorf("unexpected params: %v", got)
+	}
+
+	got, err := tmpl.Bind(map[string]any{"status": "A", "minAge": 21, "tag": `{"$ne": null}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"status": "A",
+		"age":    map[string]any{"$gt": 21},
+		"tags":   map[string]any{"$in": []any{`{"$ne": null}`, "x:y"}},
+		"active": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Binding again starts from the parsed template, not the previous result.
+	again, err := tmpl.Bind(map[string]any{"status": "B", "minAge": 30, "tag": "t"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again["status"] != "B" || got["status"] != "A" {
+		t.Errorf("expected independent results, got %v and %v", got, again)
+	}
+}
+
+// TestTemplateBindErrors tests missing and unknown parameters.
+func TestTemplateBindErrors(t *testing.T) {
+	tmpl := Tmpl(`{"status": :status}`)
+
+	if _, err := tmpl.Bind(nil); !errors.Is(err, ErrParameter) {
+		t.Errorf("expected ErrParameter for missing value, got %v", err)
+	}
+	if _, err := tmpl.Bind(map[string]any{"status": "A", "extra": 1}); !errors.Is(err, ErrParameter) {
+		t.Errorf("expected ErrParameter for unknown parameter, got %v", err)
+	}
+}
+
+// TestParseInvalid tests rejecting malformed templates.
+func TestParseInvalid(t *testing.T) {
+	for _, src := range []string{
+		`{:field: 1}`,
+		`{"a": :}`,
+		`{"a": :1x}`,
+		`{"a": "unterminated}`,
+		`{"a": "\u0000param:x"}`,
+		`[:a]`,
+		`{"a": :b`,
+	} {
+		if _, err := Parse(src); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("%s: expected ErrInvalidTemplate, got %v", src, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Tmpl to panic on an invalid template")
+		}
+	}()
+	Tmpl(`{"a": :}`)
+}
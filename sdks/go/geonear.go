@@ -0,0 +1,129 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultGeoNearDistanceField is the field GeoNear stores distances in.
+const DefaultGeoNearDistanceField = "distance"
+
+// GeoPoint is a GeoJSON point, in degrees.
+type GeoPoint struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// geoJSON returns p as a GeoJSON document.
+func (p GeoPoint) geoJSON() map[string]any {
+	return map[string]any{"type": "Point", "coordinates": []any{p.Longitude, p.Latitude}}
+}
+
+// GeoNearOptions configures a GeoNear operation.
+type GeoNearOptions struct {
+	// MaxDistance is the greatest distance from the point, in meters, of
+	// the documents returned.
+	MaxDistance *float64
+	// Query filters the documents considered.
+	Query any
+	// DistanceField is the field of each document the distance is stored
+	// in. It defaults to DefaultGeoNearDistanceField.
+	DistanceField *string
+}
+
+// SetMaxDistance sets the greatest distance, in meters.
+func (o *GeoNearOptions) SetMaxDistance(meters float64) *GeoNearOptions {
+	o.MaxDistance = &meters
+	return o
+}
+
+// SetQuery sets the filter of the documents considered.
+func (o *GeoNearOptions) SetQuery(query any) *GeoNearOptions {
+	o.Query = query
+	return o
+}
+
+// SetDistanceField sets the field distances are stored in.
+func (o *GeoNearOptions) SetDistanceField(field string) *GeoNearOptions {
+	o.DistanceField = &field
+	return o
+}
+
+// GeoNearResult is a document returned by GeoNear.
+type GeoNearResult struct {
+	// Distance is the distance of the document from the point, in meters.
+	Distance float64
+	// Document is the document, including its distance field.
+	Document map[string]any
+
+	result *SingleResult
+}
+
+// Decode decodes the document into val, as SingleResult.Decode does.
+func (r *GeoNearResult) Decode(val any, opts ...*DecodeOptions) error {
+	return r.result.Decode(val, opts...)
+}
+
+// GeoNear returns the documents nearest to point, closest first, by running
+// a $geoNear aggregation with spherical geometry. The collection needs a
+// 2dsphere index on the location field. Results are capped by
+// ClientOptions.Limits.MaxBufferedDocuments, as Aggregate results are.
+func (c *Collection) GeoNear(ctx context.Context, point GeoPoint, opts ...*GeoNearOptions) ([]GeoNearResult, error) {
+	stage := map[string]any{
+		"near":          point.geoJSON(),
+		"distanceField": DefaultGeoNearDistanceField,
+		"spherical":     true,
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.MaxDistance != nil {
+			stage["maxDistance"] = *opt.MaxDistance
+		}
+		if opt.Query != nil {
+			stage["query"] = opt.Query
+		}
+		if opt.DistanceField != nil {
+			stage["distanceField"] = *opt.DistanceField
+		}
+	}
+	field, _ := stage["distanceField"].(string)
+	if field == "" {
+		return nil, fmt.Errorf("%w: empty GeoNear distance field", ErrInvalidOption)
+	}
+
+	cur, err := c.Aggregate(ctx, Pipeline{map[string]any{"$geoNear": stage}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	results := make([]GeoNearResult, 0, len(cur.documents))
+	for _, d := range cur.documents {
+		doc, ok := d.(map[string]any)
+		if !ok {
+			return nil, unexpectedResponse("mongo.aggregate", d)
+		}
+		distance, ok := geoDistance(doc[field])
+		if !ok {
+			return nil, newProtocolError("mongo.aggregate", "a numeric "+field+" field", doc[field])
+		}
+		results = append(results, GeoNearResult{
+			Distance: distance,
+			Document: doc,
+			result:   c.decodedResult(doc),
+		})
+	}
+	return results, nil
+}
+
+// geoDistance converts a distance field to float64.
+func geoDistance(v any) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return asFloat64(v)
+}
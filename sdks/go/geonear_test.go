@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestGeoNear tests building the $geoNear stage and decoding distances.
+func TestGeoNear(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"_id": "a", "name": "Cafe", "dist": 12.5},
+		map[string]any{"_id": "b", "name": "Bar", "dist": float64(40)},
+	}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("places")
+
+	opts := (&GeoNearOptions{}).SetMaxDistance(500).SetQuery(map[string]any{"open": true}).SetDistanceField("dist")
+	results, err := coll.GeoNear(context.Background(), GeoPoint{Longitude: -0.12, Latitude: 51.5}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pipeline := mock.calls[0].args[2].(Pipeline)
+	want := map[string]any{"$geoNear": map[string]any{
+		"near":          map[string]any{"type": "Point", "coordinates": []any{-0.12, 51.5}},
+		"distanceField": "dist",
+		"spherical":     true,
+		"maxDistance":   float64(500),
+		"query":         map[string]any{"open": true},
+	}}
+	if !reflect.DeepEqual(pipeline[0], want) {
+		t.Errorf("expected stage %v, got %v", want, pipeline[0])
+	}
+
+	if len(results) != 2 || results[0].Distance != 12.5 || results[1].Distance != 40 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	var place struct {
+		Name string `json:"name"`
+	}
+	if err := results[1].Decode(&place); err != nil || place.Name != "Bar" {
+		t.Errorf("expected Bar, got %q (%v)", place.Name, err)
+	}
+}
+
+// TestGeoNearMissingDistance tests rejecting results without a distance.
+func TestGeoNearMissingDistance(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{map[string]any{"_id": "a"}}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("places")
+
+	_, err := coll.GeoNear(context.Background(), GeoPoint{})
+	var protocolErr *ProtocolError
+	if !errors.As(err, &protocolErr) {
+		t.Errorf("expected a ProtocolError, got %v", err)
+	}
+
+	_, err = coll.GeoNear(context.Background(), GeoPoint{}, (&GeoNearOptions{}).SetDistanceField(""))
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Driver identification sent in the connection handshake.
+const (
+	DriverName    = "mongo.do-go"
+	DriverVersion = "0.1.0"
+)
+
+// Server features advertised in the handshake.
+const (
+	// FeatureClientBulkWrite is cross-namespace bulk writes through
+	// Client.BulkWrite.
+	FeatureClientBulkWrite = "clientBulkWrite"
+	// FeatureTransactions is multi-document transactions.
+	FeatureTransactions = "transactions"
+	// FeatureChangeStreams is change streams through Watch.
+	FeatureChangeStreams = "changeStreams"
+	// FeatureSearchIndexes is Atlas Search and vector search indexes.
+	FeatureSearchIndexes = "searchIndexes"
+	// FeatureBSONPayloads, FeatureMsgPackPayloads and FeatureCBORPayloads
+	// are RPC payloads in BSON, MessagePack and CBOR; see PayloadEncoding.
+	FeatureBSONPayloads    = "bsonPayloads"
+	FeatureMsgPackPayloads = "msgpackPayloads"
+	FeatureCBORPayloads    = "cborPayloads"
+)
+
+// methodFeatures maps RPC methods to the feature they belong to, for
+// methods whose feature is not named after the method.
+var methodFeatures = map[string]string{
+	"mongo.watch":                 FeatureChangeStreams,
+	"mongo.changeStreamNext":      FeatureChangeStreams,
+	"mongo.changeStreamNextBatch": FeatureChangeStreams,
+	"mongo.changeStreamClose":     FeatureChangeStreams,
+}
+
+// ClientMetadata identifies the client to the server in the handshake.
+type ClientMetadata struct {
+	AppName       string
+	DriverName    string
+	DriverVersion string
+	OS            string
+	Architecture  string
+	Platform      string
+}
+
+// newClientMetadata returns the metadata of this driver running appName.
+func newClientMetadata(appName string) ClientMetadata {
+	return ClientMetadata{
+		AppName:       appName,
+		DriverName:    DriverName,
+		DriverVersion: DriverVersion,
+		OS:            runtime.GOOS,
+		Architecture:  runtime.GOARCH,
+		Platform:      runtime.Version(),
+	}
+}
+
+// document returns the metadata in the handshake's wire format.
+func (m ClientMetadata) document() map[string]any {
+	doc := map[string]any{
+		"driver":   map[string]any{"name": m.DriverName, "version": m.DriverVersion},
+		"os":       map[string]any{"type": m.OS, "architecture": m.Architecture},
+		"platform": m.Platform,
+	}
+	if m.AppName != "" {
+		doc["application"] = map[string]any{"name": m.AppName}
+	}
+	return doc
+}
+
+// attribution returns the compact form of the metadata attached to
+// operations: the application name, "name/version" of the driver and
+// "os/architecture".
+func (m ClientMetadata) attribution() map[string]any {
+	return map[string]any{
+		"appName":  m.AppName,
+		"driver":   m.DriverName + "/" + m.DriverVersion,
+		"platform": m.OS + "/" + m.Architecture,
+	}
+}
+
+// Metadata returns the metadata the client identifies itself with.
+func (c *Client) Metadata() ClientMetadata {
+	return c.metadata
+}
+
+// tagOperation adds the request ID from ctx to options and, for clients
+// with an AppName, the client attribution under "$client" so the server can
+// attribute the operation to the application.
+func (c *Client) tagOperation(ctx context.Context, options map[string]any) {
+	tagRequest(ctx, options)
+	if c.metadata.AppName != "" {
+		options["$client"] = c.metadata.attribution()
+	}
+}
+
+// Server limits assumed when the handshake did not report them.
+const (
+	DefaultMaxWriteBatchSize = 100000
+	DefaultMaxBSONObjectSize = 16 * 1024 * 1024
+)
+
+// ServerDescription describes the server from the connection handshake:
+// its version, limits and advertised features. Limits the server did not
+// report hold their defaults, so they can be used as they are: InsertMany
+// and BulkWrite split writes into batches of MaxWriteBatchSize, and sessions
+// are refreshed well within LogicalSessionTimeout.
+type ServerDescription struct {
+	// Version is the server version, such as "7.0.4", or empty if it was
+	// not reported.
+	Version        string
+	MaxWireVersion int32
+	// MaxPayloadBytes is the largest request the server accepts. Zero means
+	// no limit was reported.
+	MaxPayloadBytes int64
+	// MaxWriteBatchSize is the largest number of operations in one write.
+	MaxWriteBatchSize int64
+	// MaxBSONObjectSize is the largest document the server stores, in
+	// bytes.
+	MaxBSONObjectSize int64
+	// LogicalSessionTimeout is how long the server keeps an idle session.
+	LogicalSessionTimeout time.Duration
+	Features              []string
+}
+
+// Supports reports whether the server advertised feature.
+func (d *ServerDescription) Supports(feature string) bool {
+	if d == nil {
+		return false
+	}
+	for _, f := range d.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// helloReply is the mongo.hello result as the server sends it.
+type helloReply struct {
+	Version                      string   `json:"version"`
+	MaxPayloadBytes              int64    `json:"maxPayloadBytes"`
+	MaxBSONObjectSize            int64    `json:"maxBsonObjectSize"`
+	MaxWriteBatchSize            int64    `json:"maxWriteBatchSize"`
+	MaxWireVersion               int32    `json:"maxWireVersion"`
+	LogicalSessionTimeoutMinutes int64    `json:"logicalSessionTimeoutMinutes"`
+	Features                     []string `json:"features"`
+}
+
+// description returns the reply as a ServerDescription, with defaults for
+// the limits that were not reported. A nil r yields the defaults alone.
+func (r *helloReply) description() *ServerDescription {
+	d := &ServerDescription{
+		MaxWriteBatchSize:     DefaultMaxWriteBatchSize,
+		MaxBSONObjectSize:     DefaultMaxBSONObjectSize,
+		LogicalSessionTimeout: DefaultSessionTimeout,
+	}
+	if r == nil {
+		return d
+	}
+	d.Version = r.Version
+	d.MaxWireVersion = r.MaxWireVersion
+	d.MaxPayloadBytes = r.MaxPayloadBytes
+	d.Features = r.Features
+	if r.MaxWriteBatchSize > 0 {
+		d.MaxWriteBatchSize = r.MaxWriteBatchSize
+	}
+	if r.MaxBSONObjectSize > 0 {
+		d.MaxBSONObjectSize = r.MaxBSONObjectSize
+	}
+	if r.LogicalSessionTimeoutMinutes > 0 {
+		d.LogicalSessionTimeout = time.Duration(r.LogicalSessionTimeoutMinutes) * time.Minute
+	}
+	return d
+}
+
+// handshake sends the client metadata over rpcClient and records the
+// server description the reply gives.
+func (c *Client) handshake(ctx context.Context, rpcClient RPCClient) error {
+	result, err := awaitContext(ctx, callContext(ctx, rpcClient, "mongo.hello", c.metadata.document()))
+	if err == nil {
+		err = validateResponse("mongo.hello", result)
+	}
+	if err != nil {
+		return fmt.Errorf("mongo: handshake: %w", err)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var reply helloReply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return newProtocolError("mongo.hello", "server description", result)
+	}
+
+	c.mu.Lock()
+	c.capabilities = reply.description()
+	c.mu.Unlock()
+	return nil
+}
+
+// ServerDescription returns the server description from the connection
+// handshake, or nil if no handshake has completed.
+func (c *Client) ServerDescription() *ServerDescription {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capabilities
+}
+
+// serverLimits returns the server description, or the default limits
+// before a handshake has completed.
+func (c *Client) serverLimits() *ServerDescription {
+	if d := c.ServerDescription(); d != nil {
+		return d
+	}
+	return (*helloReply)(nil).description()
+}
+
+// Supports reports whether the server advertised feature, such as
+// FeatureTransactions or FeatureChangeStreams, in the connection handshake.
+// It is false before a handshake has completed.
+func (c *Client) Supports(feature string) bool {
+	return c.ServerDescription().Supports(feature)
+}
+
+// requireFeature fails with a NotSupportedError unless the server
+// advertised feature in the handshake.
+func (c *Client) requireFeature(feature string) error {
+	if !c.Supports(feature) {
+		return &NotSupportedError{Feature: feature}
+	}
+	return nil
+}
+
+// notSupported turns a server rejection of method as unsupported into a
+// NotSupportedError, returning other errors unchanged.
+func notSupported(method string, err error) error {
+	var ce *CommandError
+	if !errors.As(err, &ce) {
+		return err
+	}
+	switch ce.Code {
+	case codeCommandNotFound, codeCommandNotSupported, codeChangeStreamNotSupported:
+	default:
+		return err
+	}
+	feature, ok := methodFeatures[method]
+	if !ok {
+		feature = strings.TrimPrefix(method, "mongo.")
+	}
+	return &NotSupportedError{Feature: feature, Wrapped: err}
+}
+
+// checkPayload fails with ErrPayloadTooLarge if the estimated encoded size
+// of the args of method exceeds the server's maximum payload size. The
+// size is estimated without encoding the args; see estimateSize.
+func (c *Client) checkPayload(method string, args []any) error {
+	server := c.ServerDescription()
+	if server == nil || server.MaxPayloadBytes <= 0 {
+		return nil
+	}
+	if size := estimateSize(args); size > server.MaxPayloadBytes {
+		return fmt.Errorf("%w: %s request is about %d bytes, the server accepts at most %d", ErrPayloadTooLarge, method, size, server.MaxPayloadBytes)
+	}
+	return nil
+}
+
+// ClientWriteModel is a write model targeting a namespace, for
+// Client.BulkWrite.
+type ClientWriteModel struct {
+	Database   string
+	Collection string
+	Model      WriteModel
+}
+
+// BulkWrite performs write operations across namespaces in one request. The
+// server must advertise FeatureClientBulkWrite in the handshake; otherwise
+// BulkWrite fails with ErrUnsupportedFeature.
+func (c *Client) BulkWrite(ctx context.Context, models []ClientWriteModel) (*BulkWriteResult, error) {
+	if err := c.requireFeature(FeatureClientBulkWrite); err != nil {
+		return nil, err
+	}
+
+	operations := make([]map[string]any, len(models))
+	for i, model := range models {
+		coll := c.Database(model.Database).Collection(model.Collection)
+		if name, op := coll.writeOperation(model.Model); op != nil {
+			op["namespace"] = coll.namespace()
+			operations[i] = map[string]any{name: op}
+		}
+	}
+
+	result, err := c.execute(ctx, "admin", "mongo.clientBulkWrite", operations)
+	if err != nil {
+		return nil, err
+	}
+	return parseBulkWriteResult(result), nil
+}
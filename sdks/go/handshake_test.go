@@ -0,0 +1,311 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestClientHandshake tests sending metadata and recording the server description.
+func TestClientHandshake(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{
+		"maxPayloadBytes":   float64(1024),
+		"maxWriteBatchSize": float64(1000),
+		"maxWireVersion":    float64(21),
+		"features":          []any{FeatureClientBulkWrite},
+	}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017",
+		DefaultClientOptions().SetAppName("billing"))
+	if client.ServerDescription() != nil {
+		t.Fatal("expected no description before the handshake")
+	}
+	if err := client.handshake(context.Background(), mock); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	doc := mock.calls[0].args[0].(map[string]any)
+	if doc["application"].(map[string]any)["name"] != "billing" {
+		t.Errorf("unexpected application: %v", doc["application"])
+	}
+	driver := doc["driver"].(map[string]any)
+	if driver["name"] != DriverName || driver["version"] != DriverVersion {
+		t.Errorf("unexpected driver: %v", driver)
+	}
+	if doc["os"].(map[string]any)["type"] != runtime.GOOS || doc["platform"] != runtime.Version() {
+		t.Errorf("unexpected platform metadata: %v", doc)
+	}
+
+	desc := client.ServerDescription()
+	if desc.MaxPayloadBytes != 1024 || desc.MaxWriteBatchSize != 1000 || desc.MaxWireVersion != 21 {
+		t.Errorf("unexpected description: %+v", desc)
+	}
+	if !desc.Supports(FeatureClientBulkWrite) || desc.Supports("other") {
+		t.Errorf("unexpected features: %v", desc.Features)
+	}
+}
+
+// TestClientHandshakeError tests a failed or malformed handshake.
+func TestClientHandshakeError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", nil, errors.New("unknown method"))
+	mock.addCall("mongo.hello", "ok", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.handshake(context.Background(), mock); err == nil || !strings.Contains(err.Error(), "handshake") {
+		t.Errorf("expected handshake error, got %v", err)
+	}
+	var protocolErr *ProtocolError
+	if err := client.handshake(context.Background(), mock); !errors.As(err, &protocolErr) {
+		t.Errorf("expected ProtocolError, got %v", err)
+	}
+	if client.ServerDescription() != nil {
+		t.Error("expected no description after a failed handshake")
+	}
+}
+
+// TestClientBulkWriteGated tests that Client.BulkWrite requires the advertised feature.
+func TestClientBulkWriteGated(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.clientBulkWrite", map[string]any{"insertedCount": float64(1), "deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	models := []ClientWriteModel{
+		{Database: "db1", Collection: "users", Model: &InsertOneModel{Document: map[string]any{"_id": "1"}}},
+		{Database: "db2", Collection: "logs", Model: &DeleteOneModel{Filter: map[string]any{"_id": "2"}}},
+	}
+
+	if _, err := client.BulkWrite(context.Background(), models); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("expected ErrUnsupportedFeature, got %v", err)
+	}
+	client.capabilities = (&helloReply{Features: []string{"other"}}).description()
+	if _, err := client.BulkWrite(context.Background(), models); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("expected ErrUnsupportedFeature, got %v", err)
+	}
+	if len(mock.calls[0].args) != 0 {
+		t.Fatal("expected no RPC call without the feature")
+	}
+
+	client.capabilities = (&helloReply{Features: []string{FeatureClientBulkWrite}}).description()
+	result, err := client.BulkWrite(context.Background(), models)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.InsertedCount != 1 || result.DeletedCount != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	operations := mock.calls[0].args[0].([]map[string]any)
+	if operations[0]["insertOne"].(map[string]any)["namespace"] != "db1.users" {
+		t.Errorf("unexpected operation: %v", operations[0])
+	}
+	if operations[1]["deleteOne"].(map[string]any)["namespace"] != "db2.logs" {
+		t.Errorf("unexpected operation: %v", operations[1])
+	}
+}
+
+// TestMaxPayloadBytes tests rejecting requests larger than the server accepts.
+func TestMaxPayloadBytes(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.capabilities = (&helloReply{MaxPayloadBytes: 64}).description()
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.InsertOne(context.Background(), map[string]any{"_id": "1", "bio": strings.Repeat("x", 100)})
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected ErrPayloadTooLarge, got %v", err)
+	}
+	if len(mock.calls[0].args) != 0 {
+		t.Fatal("expected no RPC call for an oversized request")
+	}
+
+	if _, err := coll.InsertOne(context.Background(), map[string]any{"_id": "1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestClientAttribution tests attaching the application name to operations.
+func TestClientAttribution(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	mock.addCall("mongo.deleteOne", map[string]any{}, nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.find", []any{}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017",
+		DefaultClientOptions().SetAppName("billing"))
+	db := client.Database("testdb")
+	coll := db.Collection("users")
+	ctx := context.Background()
+
+	if _, err := coll.Find(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.DeleteOne(ctx, map[string]any{"_id": "1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := db.Aggregate(ctx, []any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{
+		"appName":  "billing",
+		"driver":   DriverName + "/" + DriverVersion,
+		"platform": runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	for i := 0; i < 3; i++ {
+		args := mock.calls[i].args
+		options := args[len(args)-1].(map[string]any)
+		if !reflect.DeepEqual(options["$client"], want) {
+			t.Errorf("%s: unexpected attribution: %v", mock.calls[i].method, options["$client"])
+		}
+	}
+	if client.Metadata().AppName != "billing" {
+		t.Errorf("unexpected metadata: %+v", client.Metadata())
+	}
+
+	plain := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if _, err := plain.Database("testdb").Collection("users").Find(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := mock.calls[3].args[3].(map[string]any)["$client"]; ok {
+		t.Error("expected no attribution without an app name")
+	}
+}
+
+// TestClientSupports tests probing features advertised in the handshake.
+func TestClientSupports(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	if client.Supports(FeatureTransactions) {
+		t.Error("expected no features before the handshake")
+	}
+
+	client.capabilities = (&helloReply{Features: []string{FeatureTransactions, FeatureChangeStreams}}).description()
+	if !client.Supports(FeatureTransactions) || !client.Supports(FeatureChangeStreams) {
+		t.Error("expected advertised features to be supported")
+	}
+	if client.Supports(FeatureSearchIndexes) {
+		t.Error("expected FeatureSearchIndexes to be unsupported")
+	}
+
+	err := client.requireFeature(FeatureSearchIndexes)
+	var notSupported *NotSupportedError
+	if !errors.As(err, &notSupported) || notSupported.Feature != FeatureSearchIndexes {
+		t.Errorf("expected NotSupportedError for %s, got %v", FeatureSearchIndexes, err)
+	}
+	if !errors.Is(err, ErrNotSupportedByBackend) {
+		t.Errorf("expected ErrNotSupportedByBackend, got %v", err)
+	}
+}
+
+// TestNotSupportedByBackend tests mapping server rejections of unsupported operations.
+func TestNotSupportedByBackend(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", map[string]any{"ok": float64(0), "code": float64(40573), "errmsg": "The $changeStream stage is only supported on replica sets"}, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(0), "code": float64(59), "codeName": "CommandNotFound", "errmsg": "Unknown method: mongo.runCommand"}, nil)
+	mock.addCall("mongo.find", map[string]any{"ok": float64(0), "code": float64(2), "codeName": "BadValue", "errmsg": "bad filter"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	_, err := coll.Watch(ctx, []any{})
+	var notSupported *NotSupportedError
+	if !errors.As(err, &notSupported) || notSupported.Feature != FeatureChangeStreams {
+		t.Fatalf("expected NotSupportedError for change streams, got %v", err)
+	}
+	var ce *CommandError
+	if !errors.As(err, &ce) || ce.Code != 40573 {
+		t.Errorf("expected the server error to be wrapped, got %v", err)
+	}
+
+	err = client.Database("testdb").RunCommand(ctx, map[string]any{"ping": 1}).Err()
+	if !errors.As(err, &notSupported) || notSupported.Feature != "runCommand" {
+		t.Errorf("expected NotSupportedError for runCommand, got %v", err)
+	}
+
+	if _, err := coll.Find(ctx, map[string]any{}); errors.Is(err, ErrNotSupportedByBackend) {
+		t.Errorf("expected other server errors to be unchanged, got %v", err)
+	}
+}
+
+// TestServerDescription tests the typed server description and its defaults.
+func TestServerDescription(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	if client.ServerDescription() != nil {
+		t.Fatal("expected no description before the handshake")
+	}
+	if limits := client.serverLimits(); limits.MaxWriteBatchSize != DefaultMaxWriteBatchSize || limits.LogicalSessionTimeout != DefaultSessionTimeout {
+		t.Errorf("expected default limits, got %+v", limits)
+	}
+
+	client.capabilities = (&helloReply{
+		Version:                      "7.0.4",
+		MaxWriteBatchSize:            500,
+		LogicalSessionTimeoutMinutes: 10,
+		Features:                     []string{FeatureTransactions},
+	}).description()
+	want := &ServerDescription{
+		Version:               "7.0.4",
+		MaxWriteBatchSize:     500,
+		MaxBSONObjectSize:     DefaultMaxBSONObjectSize,
+		LogicalSessionTimeout: 10 * time.Minute,
+		Features:              []string{FeatureTransactions},
+	}
+	if got := client.ServerDescription(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if !client.ServerDescription().Supports(FeatureTransactions) {
+		t.Error("expected FeatureTransactions to be supported")
+	}
+	if client.sessionTimeout() != 10*time.Minute {
+		t.Errorf("expected the reported session timeout, got %v", client.sessionTimeout())
+	}
+}
+
+// TestWriteBatchSize tests splitting writes at the server's batch limit.
+func TestWriteBatchSize(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": map[string]any{"0": "a"}}, nil)
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"c"}}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"insertedCount": float64(2)}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{"upsertedCount": float64(1), "upsertedIds": map[string]any{"0": "u"}}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.capabilities = (&helloReply{MaxWriteBatchSize: 2}).description()
+	coll := client.Database("testdb").Collection("items")
+	ctx := context.Background()
+
+	inserted, err := coll.InsertMany(ctx, []any{map[string]any{"_id": "a"}, map[string]any{"x": 1}, map[string]any{"_id": "c"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.calls[0].args[2].([]any)) != 2 || len(mock.calls[1].args[2].([]any)) != 1 {
+		t.Errorf("expected batches of 2 and 1 documents, got %v and %v", mock.calls[0].args[2], mock.calls[1].args[2])
+	}
+	if want := []any{"a", nil, "c"}; !reflect.DeepEqual(inserted.InsertedIDs, want) {
+		t.Errorf("expected inserted IDs %v, got %v", want, inserted.InsertedIDs)
+	}
+
+	upsert := true
+	result, err := coll.BulkWrite(ctx, []WriteModel{
+		&InsertOneModel{Document: map[string]any{"_id": 1}},
+		&InsertOneModel{Document: map[string]any{"_id": 2}},
+		&UpdateOneModel{Filter: map[string]any{"_id": 3}, Update: map[string]any{"$set": map[string]any{"x": 1}}, Upsert: &upsert},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.calls) != 4 {
+		t.Fatalf("expected 2 bulkWrite batches, got %d calls", len(mock.calls)-2)
+	}
+	if result.InsertedCount != 2 || result.UpsertedCount != 1 || result.UpsertedIDs[2] != "u" {
+		t.Errorf("unexpected merged result %+v", result)
+	}
+}
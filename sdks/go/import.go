@@ -0,0 +1,247 @@
+package mongo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DefaultImportBatchSize is the number of documents written per request by
+// Import.
+const DefaultImportBatchSize = 1000
+
+// maxImportLineSize is the longest line Import accepts, the 16MiB document
+// limit plus room for the JSON encoding.
+const maxImportLineSize = 32 << 20
+
+// ImportOptions configures an Import operation.
+type ImportOptions struct {
+	BatchSize *int
+	// UpsertKey lists the top-level fields that identify a document. When
+	// set, each document replaces the one with the same key values, or is
+	// inserted if there is none, so importing a line twice has no further
+	// effect.
+	UpsertKey []string
+	// Checkpoint records the number of lines imported after each batch,
+	// and Import skips that many lines when it starts, so an aborted import
+	// run again resumes where it stopped. Use a new CheckpointKey to import
+	// the same input from the start.
+	Checkpoint CheckpointStore
+	// CheckpointKey is the key the progress is saved under. It defaults to
+	// "import:" followed by the collection namespace.
+	CheckpointKey *string
+	// Progress is called after each batch, with Processed counting lines.
+	Progress ProgressFunc
+}
+
+// SetBatchSize sets the number of documents written per request.
+func (o *ImportOptions) SetBatchSize(size int) *ImportOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetUpsertKey sets the fields that identify documents, making the import
+// idempotent.
+func (o *ImportOptions) SetUpsertKey(fields ...string) *ImportOptions {
+	o.UpsertKey = fields
+	return o
+}
+
+// SetCheckpoint sets the store that records progress, and the key it is
+// saved under. An empty key uses the default.
+func (o *ImportOptions) SetCheckpoint(store CheckpointStore, key string) *ImportOptions {
+	o.Checkpoint = store
+	if key != "" {
+		o.CheckpointKey = &key
+	}
+	return o
+}
+
+// SetProgress sets the function called after each batch.
+func (o *ImportOptions) SetProgress(progress ProgressFunc) *ImportOptions {
+	o.Progress = progress
+	return o
+}
+
+// ImportResult summarizes an Import operation.
+type ImportResult struct {
+	// Lines is the number of lines consumed, including skipped ones.
+	Lines int64
+	// Skipped is the number of lines skipped because a checkpoint showed
+	// they were imported by an earlier run.
+	Skipped int64
+	// Inserted is the number of documents inserted, by insert or upsert.
+	Inserted int64
+	// Replaced is the number of existing documents matched by an upsert
+	// key and replaced.
+	Replaced int64
+}
+
+// Import reads newline-delimited JSON documents from r, in the format
+// Export writes, and writes them to the collection in batches. Blank lines
+// are ignored. Numbers are kept exact rather than rounded through float64.
+//
+// If a batch fails, Import returns the result so far with the error. With a
+// Checkpoint, the lines of the batches that succeeded are recorded, and
+// running the import again skips them; with an UpsertKey as well, a batch
+// written just before a crash, but not yet recorded, is replaced rather
+// than duplicated when it is imported again.
+func (c *Collection) Import(ctx context.Context, r io.Reader, opts ...*ImportOptions) (*ImportResult, error) {
+	ctx = withBatchPriority(ctx)
+	batchSize := DefaultImportBatchSize
+	var upsertKey []string
+	var store CheckpointStore
+	checkpointKey := "import:" + c.namespace()
+	var progress ProgressFunc
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.BatchSize != nil {
+				batchSize = *opt.BatchSize
+			}
+			if opt.UpsertKey != nil {
+				upsertKey = opt.UpsertKey
+			}
+			if opt.Checkpoint != nil {
+				store = opt.Checkpoint
+			}
+			if opt.CheckpointKey != nil {
+				checkpointKey = *opt.CheckpointKey
+			}
+			if opt.Progress != nil {
+				progress = opt.Progress
+			}
+		}
+	}
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("mongo: batch size must be positive, got %d", batchSize)
+	}
+
+	result := &ImportResult{}
+	if store != nil {
+		token, err := store.Load(ctx, checkpointKey)
+		if err != nil {
+			return nil, fmt.Errorf("mongo: load import checkpoint: %w", err)
+		}
+		if token != nil {
+			skip, ok := asInt64(normalizeID(token))
+			if !ok || skip < 0 {
+				return nil, fmt.Errorf("mongo: invalid import checkpoint %v", token)
+			}
+			result.Skipped = skip
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	clock := c.database.client.clock
+	batch := make([]any, 0, batchSize)
+	batches := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		began := clock.Now()
+		err := c.importBatch(ctx, batch, upsertKey, result)
+		if err == nil && store != nil {
+			if err = store.Save(ctx, checkpointKey, result.Lines); err != nil {
+				err = fmt.Errorf("mongo: save import checkpoint: %w", err)
+			}
+		}
+		if reportErr := progress.report(c.database.client, BatchProgress{
+			Batch:     batches,
+			Processed: int(result.Lines),
+			Latency:   clock.Now().Sub(began),
+			Err:       err,
+		}); err == nil {
+			err = reportErr
+		}
+		batches++
+		batch = make([]any, 0, batchSize)
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if result.Lines < result.Skipped {
+			result.Lines++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			doc, err := decodeImportLine(line, upsertKey)
+			if err != nil {
+				return result, fmt.Errorf("mongo: import line %d: %w", result.Lines+1, err)
+			}
+			batch = append(batch, doc)
+		}
+		result.Lines++
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("mongo: read import: %w", err)
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// decodeImportLine decodes one document, keeping numbers as json.Number,
+// and checks that it has the upsert key fields.
+func decodeImportLine(line []byte, upsertKey []string) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("expected a document")
+	}
+	for _, field := range upsertKey {
+		if _, ok := doc[field]; !ok {
+			return nil, fmt.Errorf("missing upsert key field %q", field)
+		}
+	}
+	return doc, nil
+}
+
+// importBatch writes docs, inserting them or, with upsertKey, replacing
+// the documents with the same key values, and adds the counts to result.
+func (c *Collection) importBatch(ctx context.Context, docs []any, upsertKey []string, result *ImportResult) error {
+	if len(upsertKey) == 0 {
+		if _, err := c.InsertMany(ctx, docs); err != nil {
+			return err
+		}
+		result.Inserted += int64(len(docs))
+		return nil
+	}
+
+	models := make([]WriteModel, len(docs))
+	upsert := true
+	for i, d := range docs {
+		doc := d.(map[string]any)
+		filter := make(map[string]any, len(upsertKey))
+		for _, field := range upsertKey {
+			filter[field] = doc[field]
+		}
+		models[i] = &ReplaceOneModel{Filter: filter, Replacement: doc, Upsert: &upsert}
+	}
+	written, err := c.BulkWrite(ctx, models)
+	if err != nil {
+		return err
+	}
+	result.Inserted += written.UpsertedCount
+	result.Replaced += written.MatchedCount
+	return nil
+}
@@ -0,0 +1,105 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importInput is three documents and a blank line in Export's format.
+const importInput = `{"_id":1,"sku":"a","qty":9007199254740993}
+{"_id":2,"sku":"b"}
+
+{"_id":3,"sku":"c"}
+`
+
+// TestCollectionImport tests inserting documents in batches.
+func TestCollectionImport(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{float64(1), float64(2)}}, nil)
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{float64(3)}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("items")
+
+	var reports []BatchProgress
+	opts := (&ImportOptions{}).SetBatchSize(2).SetProgress(func(p BatchProgress) {
+		reports = append(reports, p)
+	})
+	result, err := coll.Import(context.Background(), strings.NewReader(importInput), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != (ImportResult{Lines: 4, Inserted: 3}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	first := mock.calls[0].args[2].([]any)
+	if len(first) != 2 || first[0].(map[string]any)["qty"] != json.Number("9007199254740993") {
+		t.Errorf("unexpected first batch: %v", first)
+	}
+	if len(reports) != 2 || reports[0].Processed != 2 || reports[1].Processed != 4 || reports[1].Batch != 1 {
+		t.Errorf("unexpected reports: %+v", reports)
+	}
+}
+
+// TestCollectionImportResume tests that a checkpoint skips imported lines
+// and upsert keys replace documents.
+func TestCollectionImportResume(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.bulkWrite", nil, errors.New("connection reset"))
+	mock.addCall("mongo.bulkWrite", map[string]any{"matchedCount": float64(1), "upsertedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("items")
+	store := newMemoryCheckpointStore()
+	store.tokens["import:testdb.items"] = float64(1)
+	opts := (&ImportOptions{}).SetBatchSize(1).SetUpsertKey("sku").SetCheckpoint(store, "")
+	ctx := context.Background()
+
+	result, err := coll.Import(ctx, strings.NewReader(importInput), opts)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if result.Skipped != 1 || result.Lines != 2 || store.saves != 0 {
+		t.Errorf("unexpected result %+v after %d saves", result, store.saves)
+	}
+	ops := mock.calls[0].args[2].([]map[string]any)
+	replace := ops[0]["replaceOne"].(map[string]any)
+	if !reflect.DeepEqual(replace["filter"], map[string]any{"sku": "b"}) || replace["upsert"] != true {
+		t.Errorf("unexpected operation: %v", replace)
+	}
+
+	// The failed batch is imported again, replacing the document it may
+	// have written.
+	result, err = coll.Import(ctx, strings.NewReader(importInput), (&ImportOptions{}).SetBatchSize(2).SetUpsertKey("sku").SetCheckpoint(store, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *result != (ImportResult{Lines: 4, Skipped: 1, Inserted: 1, Replaced: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if store.tokens["import:testdb.items"] != int64(4) {
+		t.Errorf("expected checkpoint at line 4, got %v", store.tokens["import:testdb.items"])
+	}
+}
+
+// TestCollectionImportInvalid tests rejecting malformed input.
+func TestCollectionImportInvalid(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("items")
+	ctx := context.Background()
+
+	if _, err := coll.Import(ctx, strings.NewReader("{\"_id\":1}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a line 2 error, got %v", err)
+	}
+	if _, err := coll.Import(ctx, strings.NewReader(`{"_id":1}`), (&ImportOptions{}).SetUpsertKey("sku")); err == nil || !strings.Contains(err.Error(), `"sku"`) {
+		t.Errorf("expected a missing key error, got %v", err)
+	}
+	if _, err := coll.Import(ctx, strings.NewReader(""), (&ImportOptions{}).SetBatchSize(0)); err == nil {
+		t.Error("expected an error for a zero batch size")
+	}
+}
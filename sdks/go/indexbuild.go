@@ -0,0 +1,178 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultIndexBuildPollInterval is how often WaitForIndexBuild checks progress.
+const DefaultIndexBuildPollInterval = time.Second
+
+// IndexBuildProgress reports the state of an index build.
+type IndexBuildProgress struct {
+	Name string
+	// InProgress is false once the build has finished and the index is ready.
+	InProgress bool
+	// Done and Total count the units of the current build phase, typically
+	// documents scanned or keys inserted. Both are zero when not reported.
+	Done    int64
+	Total   int64
+	Message string
+}
+
+// Percent returns the completion of the current phase from 0 to 100, or 0 if
+// the total is unknown.
+func (p IndexBuildProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Done) / float64(p.Total) * 100
+}
+
+// IndexBuild is a handle to an index build started with StartIndexBuild.
+type IndexBuild struct {
+	coll *Collection
+	name string
+}
+
+// Name returns the name of the index being built.
+func (b *IndexBuild) Name() string {
+	return b.name
+}
+
+// Progress returns the current state of the build.
+func (b *IndexBuild) Progress(ctx context.Context) (*IndexBuildProgress, error) {
+	return b.coll.IndexBuildProgress(ctx, b.name)
+}
+
+// Wait blocks until the build completes. See Collection.WaitForIndexBuild.
+func (b *IndexBuild) Wait(ctx context.Context, opts ...*WaitForIndexBuildOptions) error {
+	return b.coll.WaitForIndexBuild(ctx, b.name, opts...)
+}
+
+// WaitForIndexBuildOptions configures WaitForIndexBuild.
+type WaitForIndexBuildOptions struct {
+	PollInterval *time.Duration
+	// OnProgress is called with the progress observed at each poll.
+	OnProgress func(IndexBuildProgress)
+}
+
+// SetPollInterval sets how often progress is checked.
+func (o *WaitForIndexBuildOptions) SetPollInterval(d time.Duration) *WaitForIndexBuildOptions {
+	o.PollInterval = &d
+	return o
+}
+
+// SetOnProgress sets the progress callback.
+func (o *WaitForIndexBuildOptions) SetOnProgress(fn func(IndexBuildProgress)) *WaitForIndexBuildOptions {
+	o.OnProgress = fn
+	return o
+}
+
+// StartIndexBuild starts building an index and returns without waiting for
+// the build to finish, for large collections where index creation takes
+// minutes. Use the returned handle to monitor or wait for the build.
+func (c *Collection) StartIndexBuild(ctx context.Context, model IndexModel) (*IndexBuild, error) {
+	options := indexOptions(model.Options)
+	options["blocking"] = false
+
+	result, err := c.execute(ctx, "mongo.createIndex", c.database.name, c.name, model.Keys, options)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := result.(string)
+	if name == "" && model.Options != nil && model.Options.Name != nil {
+		name = *model.Options.Name
+	}
+	if name == "" {
+		return nil, fmt.Errorf("mongo: createIndex returned no index name")
+	}
+
+	return &IndexBuild{coll: c, name: name}, nil
+}
+
+// IndexBuildProgress returns the state of the named index build. The build
+// is looked up in currentOp; if it is not running, the index must exist.
+func (c *Collection) IndexBuildProgress(ctx context.Context, name string) (*IndexBuildProgress, error) {
+	pipeline := []any{
+		map[string]any{"$currentOp": map[string]any{"allUsers": true}},
+		map[string]any{"$match": map[string]any{
+			"ns":                    c.namespace(),
+			"command.createIndexes": c.name,
+			"command.indexes.name":  name,
+		}},
+	}
+
+	cursor, err := c.database.client.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []map[string]any
+	if err := cursor.All(ctx, &ops); err != nil {
+		return nil, err
+	}
+	if len(ops) > 0 {
+		return parseIndexBuildOp(name, ops[0]), nil
+	}
+
+	docs, err := c.listIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		if doc, ok := d.(map[string]any); ok && doc["name"] == name {
+			return &IndexBuildProgress{Name: name}, nil
+		}
+	}
+	return nil, fmt.Errorf("index %s not found on %s", name, c.namespace())
+}
+
+// parseIndexBuildOp converts a currentOp entry into build progress.
+func parseIndexBuildOp(name string, op map[string]any) *IndexBuildProgress {
+	progress := &IndexBuildProgress{Name: name, InProgress: true}
+	progress.Message, _ = op["msg"].(string)
+	if p, ok := op["progress"].(map[string]any); ok {
+		progress.Done, _ = asInt64(normalizeID(p["done"]))
+		progress.Total, _ = asInt64(normalizeID(p["total"]))
+	}
+	return progress
+}
+
+// WaitForIndexBuild polls until the named index build completes.
+func (c *Collection) WaitForIndexBuild(ctx context.Context, name string, opts ...*WaitForIndexBuildOptions) error {
+	interval := DefaultIndexBuildPollInterval
+	var onProgress func(IndexBuildProgress)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.PollInterval != nil {
+			interval = *opt.PollInterval
+		}
+		if opt.OnProgress != nil {
+			onProgress = opt.OnProgress
+		}
+	}
+
+	for {
+		progress, err := c.IndexBuildProgress(ctx, name)
+		if err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(*progress)
+		}
+		if !progress.InProgress {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.database.client.clock.After(interval):
+		}
+	}
+}
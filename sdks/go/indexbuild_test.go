@@ -0,0 +1,139 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestCollectionStartIndexBuild tests starting a non-blocking index build.
+func TestCollectionStartIndexBuild(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.createIndex", "email_1", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	unique := true
+	build, err := coll.StartIndexBuild(context.Background(), IndexModel{
+		Keys:    map[string]any{"email": 1},
+		Options: &IndexOptions{Unique: &unique},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if build.Name() != "email_1" {
+		t.Errorf("expected email_1, got %s", build.Name())
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["blocking"] != false || options["unique"] != true {
+		t.Errorf("unexpected options: %v", options)
+	}
+}
+
+// TestCollectionStartIndexBuildNamed tests falling back to the requested name.
+func TestCollectionStartIndexBuildNamed(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.createIndex", map[string]any{"ok": float64(1)}, nil)
+	mock.addCall("mongo.createIndex", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	name := "by_email"
+	build, err := coll.StartIndexBuild(ctx, IndexModel{Keys: map[string]any{"email": 1}, Options: &IndexOptions{Name: &name}})
+	if err != nil || build.Name() != "by_email" {
+		t.Errorf("unexpected build: %v, %v", build, err)
+	}
+
+	if _, err := coll.StartIndexBuild(ctx, IndexModel{Keys: map[string]any{"email": 1}}); err == nil {
+		t.Error("expected error without index name")
+	}
+}
+
+// TestIndexBuildWait tests polling until the build completes.
+func TestIndexBuildWait(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.createIndex", "email_1", nil)
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"msg": "Index Build: scanning collection", "progress": map[string]any{"done": float64(250), "total": float64(1000)}},
+	}, nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.listIndexes", []any{
+		map[string]any{"key": map[string]any{"_id": float64(1)}, "name": "_id_"},
+		map[string]any{"key": map[string]any{"email": float64(1)}, "name": "email_1"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	build, err := coll.StartIndexBuild(ctx, IndexModel{Keys: map[string]any{"email": 1}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var seen []IndexBuildProgress
+	opts := (&WaitForIndexBuildOptions{}).SetPollInterval(0).SetOnProgress(func(p IndexBuildProgress) {
+		seen = append(seen, p)
+	})
+	if err := build.Wait(ctx, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("expected 2 progress reports, got %d", len(seen))
+	}
+	if !seen[0].InProgress || seen[0].Percent() != 25 || seen[0].Message != "Index Build: scanning collection" {
+		t.Errorf("unexpected progress: %+v", seen[0])
+	}
+	if seen[1].InProgress {
+		t.Errorf("expected finished build, got %+v", seen[1])
+	}
+
+	match := mock.calls[1].args[2].([]any)[1].(map[string]any)["$match"].(map[string]any)
+	if match["ns"] != "testdb.users" || match["command.indexes.name"] != "email_1" {
+		t.Errorf("unexpected currentOp filter: %v", match)
+	}
+}
+
+// TestWaitForIndexBuildMissing tests waiting for an index that does not exist.
+func TestWaitForIndexBuildMissing(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.listIndexes", []any{map[string]any{"key": map[string]any{"_id": float64(1)}, "name": "_id_"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if err := coll.WaitForIndexBuild(context.Background(), "email_1"); err == nil {
+		t.Error("expected error for missing index")
+	}
+}
+
+// TestWaitForIndexBuildContextCanceled tests canceling the wait.
+func TestWaitForIndexBuildContextCanceled(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{map[string]any{"msg": "building"}}, nil)
+
+	clock := newFakeClock(time.Now())
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	coll := client.Database("testdb").Collection("users")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := (&WaitForIndexBuildOptions{}).SetOnProgress(func(IndexBuildProgress) { cancel() })
+
+	if err := coll.WaitForIndexBuild(ctx, "email_1", opts); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestIndexBuildProgressPercent tests the percentage of unknown totals.
+func TestIndexBuildProgressPercent(t *testing.T) {
+	if (IndexBuildProgress{Done: 5}).Percent() != 0 {
+		t.Error("expected 0 for unknown total")
+	}
+}
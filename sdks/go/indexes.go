@@ -0,0 +1,258 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"go.mongo.do/bson"
+)
+
+// IndexKey is a single field of an index key pattern.
+type IndexKey struct {
+	Field string
+	// Value is the index direction (1 or -1) or type ("text", "2dsphere", "hashed").
+	Value any
+}
+
+// IndexSpecification describes an existing index as reported by listIndexes.
+type IndexSpecification struct {
+	Name      string
+	Namespace string
+	// Keys is the key pattern in index order, unless KeysUnordered is set.
+	Keys                    []IndexKey
+	Unique                  bool
+	Sparse                  bool
+	ExpireAfterSeconds      *int32
+	PartialFilterExpression map[string]any
+	Version                 int32
+	// KeysUnordered is set when the backend reported a compound key pattern
+	// as a plain object, as JSON transports do, losing the index order.
+	// Keys then holds the fields sorted by name, which need not be the
+	// order of the index.
+	KeysUnordered bool
+}
+
+// KeysDocument returns the key pattern as an ordered document, suitable for
+// IndexModel.Keys. With KeysUnordered set, its order is not the index's, so
+// it does not recreate the same index.
+func (s *IndexSpecification) KeysDocument() bson.D {
+	doc := make(bson.D, len(s.Keys))
+	for i, k := range s.Keys {
+		doc[i] = bson.E{Key: k.Field, Value: k.Value}
+	}
+	return doc
+}
+
+// ListIndexes returns a cursor over the raw index documents of the collection.
+func (c *Collection) ListIndexes(ctx context.Context) (*Cursor, error) {
+	docs, err := c.listIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cursor := newCursor(docs)
+	c.database.client.trackCursor(cursor, c.namespace())
+	return cursor, nil
+}
+
+// ListIndexSpecifications returns the specifications of all indexes on the collection.
+// Compound indexes whose key pattern the backend reported without its order
+// have KeysUnordered set.
+func (c *Collection) ListIndexSpecifications(ctx context.Context) ([]*IndexSpecification, error) {
+	docs, err := c.listIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseIndexSpecifications(docs)
+}
+
+// listIndexes calls listIndexes and returns the index documents.
+func (c *Collection) listIndexes(ctx context.Context) ([]any, error) {
+	result, err := c.execute(ctx, "mongo.listIndexes", c.database.name, c.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexDocuments(result)
+}
+
+// indexDocuments extracts the index documents from a listIndexes response.
+// The backend returns either a plain array, a command-style cursor
+// ({cursor: {firstBatch: [...]}}), or an {indexes: [...]} document.
+func indexDocuments(result any) ([]any, error) {
+	switch r := result.(type) {
+	case []any:
+		return r, nil
+	case map[string]any:
+		if cursor, ok := r["cursor"].(map[string]any); ok {
+			if batch, ok := cursor["firstBatch"].([]any); ok {
+				return batch, nil
+			}
+		}
+		if indexes, ok := r["indexes"].([]any); ok {
+			return indexes, nil
+		}
+	case nil:
+		return []any{}, nil
+	}
+	return nil, unexpectedResponse("mongo.listIndexes", result)
+}
+
+// parseIndexSpecifications converts index documents into specifications.
+func parseIndexSpecifications(docs []any) ([]*IndexSpecification, error) {
+	specs := make([]*IndexSpecification, 0, len(docs))
+	for i, d := range docs {
+		doc, ok := d.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unexpected index document type at %d: %T", i, d)
+		}
+		spec, err := parseIndexSpecification(doc)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// parseIndexSpecification converts a single index document into a specification.
+func parseIndexSpecification(doc map[string]any) (*IndexSpecification, error) {
+	spec := &IndexSpecification{}
+
+	name, ok := doc["name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("index document missing name")
+	}
+	spec.Name = name
+	spec.Namespace, _ = doc["ns"].(string)
+
+	keys, ordered, err := parseIndexKeys(doc["key"])
+	if err != nil {
+		return nil, fmt.Errorf("index %s: %w", name, err)
+	}
+	spec.Keys = keys
+	spec.KeysUnordered = !ordered
+
+	spec.Unique = asBool(doc["unique"])
+	spec.Sparse = asBool(doc["sparse"])
+
+	if v, ok := asInt64(doc["expireAfterSeconds"]); ok {
+		ttl := int32(v)
+		spec.ExpireAfterSeconds = &ttl
+	}
+	if v, ok := asInt64(doc["v"]); ok {
+		spec.Version = int32(v)
+	}
+	if pfe, ok := doc["partialFilterExpression"].(map[string]any); ok {
+		spec.PartialFilterExpression = pfe
+	}
+
+	return spec, nil
+}
+
+// parseIndexKeys parses a key pattern given as a bson.D, an array of
+// [field, value] pairs, an array of single-field objects, or an object. It
+// reports whether the keys are in index order: an object of several fields
+// has lost the order of the compound index, so its keys are sorted by field
+// name instead.
+func parseIndexKeys(raw any) ([]IndexKey, bool, error) {
+	switch k := raw.(type) {
+	case map[string]any:
+		keys := make([]IndexKey, 0, len(k))
+		for field, value := range k {
+			keys = append(keys, IndexKey{Field: field, Value: normalizeIndexValue(value)})
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Field < keys[j].Field })
+		return keys, len(keys) <= 1, nil
+	case bson.D:
+		keys := make([]IndexKey, 0, len(k))
+		for _, e := range k {
+			keys = append(keys, IndexKey{Field: e.Key, Value: normalizeIndexValue(e.Value)})
+		}
+		return keys, true, nil
+	case []any:
+		keys := make([]IndexKey, 0, len(k))
+		for _, entry := range k {
+			switch e := entry.(type) {
+			case []any:
+				if len(e) != 2 {
+					return nil, false, fmt.Errorf("invalid key pair of length %d", len(e))
+				}
+				field, ok := e[0].(string)
+				if !ok {
+					return nil, false, fmt.Errorf("invalid key field type: %T", e[0])
+				}
+				keys = append(keys, IndexKey{Field: field, Value: normalizeIndexValue(e[1])})
+			case map[string]any:
+				if len(e) != 1 {
+					return nil, false, fmt.Errorf("invalid key entry with %d fields", len(e))
+				}
+				for field, value := range e {
+					keys = append(keys, IndexKey{Field: field, Value: normalizeIndexValue(value)})
+				}
+			default:
+				return nil, false, fmt.Errorf("invalid key entry type: %T", entry)
+			}
+		}
+		return keys, true, nil
+	}
+	return nil, false, fmt.Errorf("invalid key pattern type: %T", raw)
+}
+
+// normalizeIndexValue converts numeric index directions to int32.
+func normalizeIndexValue(v any) any {
+	if n, ok := asInt64(v); ok {
+		return int32(n)
+	}
+	return v
+}
+
+// asInt64 converts a numeric RPC value to int64. json.Number values and
+// $numberLong and $numberInt wrappers are parsed from their text, so
+// integers beyond 2^53 keep every digit.
+func asInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			f, ferr := n.Float64()
+			if ferr != nil {
+				return 0, false
+			}
+			return int64(f), true
+		}
+		return i, true
+	case map[string]any:
+		if len(n) != 1 {
+			return 0, false
+		}
+		for _, key := range []string{"$numberLong", "$numberInt"} {
+			if s, ok := n[key].(string); ok {
+				i, err := strconv.ParseInt(s, 10, 64)
+				return i, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// asBool reports whether v is a true boolean or a non-zero number.
+func asBool(v any) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	if n, ok := asInt64(v); ok {
+		return n != 0
+	}
+	return false
+}
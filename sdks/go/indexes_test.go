@@ -0,0 +1,210 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestCollectionListIndexes tests listing raw index documents.
+func TestCollectionListIndexes(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", []any{
+		map[string]any{"v": float64(2), "key": map[string]any{"_id": float64(1)}, "name": "_id_"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	cursor, err := coll.ListIndexes(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var indexes []map[string]any
+	if err := cursor.All(context.Background(), &indexes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(indexes) != 1 || indexes[0]["name"] != "_id_" {
+		t.Errorf("unexpected indexes: %v", indexes)
+	}
+}
+
+// TestCollectionListIndexSpecifications tests parsing a full index document.
+func TestCollectionListIndexSpecifications(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", []any{
+		map[string]any{
+			"v":                       float64(2),
+			"key":                     []any{[]any{"email", float64(1)}, []any{"createdAt", float64(-1)}},
+			"name":                    "email_1_createdAt_-1",
+			"ns":                      "testdb.users",
+			"unique":                  true,
+			"sparse":                  true,
+			"expireAfterSeconds":      float64(3600),
+			"partialFilterExpression": map[string]any{"active": true},
+		},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	specs, err := coll.ListIndexSpecifications(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(specs) != 1 {
+		t.Fatalf("expected 1 spec, got %d", len(specs))
+	}
+
+	spec := specs[0]
+	if spec.Name != "email_1_createdAt_-1" || spec.Namespace != "testdb.users" {
+		t.Errorf("unexpected name/namespace: %s %s", spec.Name, spec.Namespace)
+	}
+
+	if len(spec.Keys) != 2 || spec.Keys[0].Field != "email" || spec.Keys[1].Value != int32(-1) {
+		t.Errorf("unexpected keys: %v", spec.Keys)
+	}
+
+	if !spec.Unique || !spec.Sparse {
+		t.Error("expected unique and sparse")
+	}
+
+	if spec.ExpireAfterSeconds == nil || *spec.ExpireAfterSeconds != 3600 {
+		t.Errorf("expected TTL 3600, got %v", spec.ExpireAfterSeconds)
+	}
+
+	if spec.Version != 2 {
+		t.Errorf("expected version 2, got %d", spec.Version)
+	}
+
+	if spec.PartialFilterExpression["active"] != true {
+		t.Errorf("unexpected partial filter: %v", spec.PartialFilterExpression)
+	}
+}
+
+// TestCollectionListIndexSpecificationsCursorShape tests the command-style cursor response.
+func TestCollectionListIndexSpecificationsCursorShape(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", map[string]any{
+		"cursor": map[string]any{
+			"firstBatch": []any{
+				map[string]any{"v": json.Number("2"), "key": bson.D{{Key: "b", Value: "text"}, {Key: "a", Value: json.Number("1")}}, "name": "ab"},
+			},
+		},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	specs, err := coll.ListIndexSpecifications(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := specs[0].Keys
+	if len(keys) != 2 || keys[0].Field != "b" || keys[0].Value != "text" || keys[1].Value != int32(1) {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	want := bson.D{{Key: "b", Value: "text"}, {Key: "a", Value: int32(1)}}
+	if doc := specs[0].KeysDocument(); !reflect.DeepEqual(doc, want) {
+		t.Errorf("expected keys document %v, got %v", want, doc)
+	}
+}
+
+// TestCollectionListIndexSpecificationsIndexesShape tests the {indexes: [...]} response.
+func TestCollectionListIndexSpecificationsIndexesShape(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", map[string]any{
+		"indexes": []any{
+			map[string]any{"key": []any{map[string]any{"loc": "2dsphere"}}, "name": "loc_2dsphere", "unique": float64(0)},
+		},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("places")
+
+	specs, err := coll.ListIndexSpecifications(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if specs[0].Keys[0].Field != "loc" || specs[0].Unique {
+		t.Errorf("unexpected spec: %+v", specs[0])
+	}
+}
+
+// TestCollectionListIndexSpecificationsUnordered tests listing a compound
+// index reported as an object next to ordered ones.
+func TestCollectionListIndexSpecificationsUnordered(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", []any{
+		map[string]any{"name": "_id_", "key": map[string]any{"_id": float64(1)}},
+		map[string]any{"name": "b_1_a_-1", "key": map[string]any{"b": float64(1), "a": float64(-1)}},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	specs, err := coll.ListIndexSpecifications(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specs) != 2 || specs[0].KeysUnordered {
+		t.Fatalf("expected an ordered single-field index, got %+v", specs)
+	}
+	want := []IndexKey{{Field: "a", Value: int32(-1)}, {Field: "b", Value: int32(1)}}
+	if !specs[1].KeysUnordered || !reflect.DeepEqual(specs[1].Keys, want) {
+		t.Errorf("expected unordered keys %v, got %+v", want, specs[1])
+	}
+}
+
+// TestCollectionListIndexSpecificationsInvalid tests malformed index documents.
+func TestCollectionListIndexSpecificationsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		result any
+	}{
+		{"unexpected type", "not indexes"},
+		{"missing name", []any{map[string]any{"key": map[string]any{"a": float64(1)}}}},
+		{"bad key", []any{map[string]any{"name": "a", "key": "a"}}},
+		{"bad pair", []any{map[string]any{"name": "a", "key": []any{[]any{"a"}}}}},
+		{"non-document", []any{"a_1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := newMockRPCClient()
+			mock.addCall("mongo.listIndexes", tt.result, nil)
+
+			client := newClientWithRPC(mock, "mongodb://localhost:27017")
+			coll := client.Database("testdb").Collection("users")
+
+			_, err := coll.ListIndexSpecifications(context.Background())
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestCollectionListIndexesDisconnected tests listing indexes when disconnected.
+func TestCollectionListIndexesDisconnected(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	ctx := context.Background()
+	client.Disconnect(ctx)
+
+	_, err := client.Database("testdb").Collection("users").ListIndexes(ctx)
+	if !errors.Is(err, ErrClientDisconnected) {
+		t.Errorf("expected ErrClientDisconnected, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package mongo
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLeakStackDepth is the number of frames kept in a Leak's stack trace.
+const maxLeakStackDepth = 32
+
+// sdkFuncPrefix is the prefix of the SDK's function names as the runtime
+// reports them, which escapes the dots of the import path.
+var sdkFuncPrefix = strings.TrimSuffix(runtime.FuncForPC(reflect.ValueOf(logLeak).Pointer()).Name(), "logLeak")
+
+// Leak describes a cursor or change stream found open by leak detection;
+// see ClientOptions.LeakDetection.
+type Leak struct {
+	// Kind is "cursor" or "change stream".
+	Kind      string
+	Namespace string
+	// Created is when it was opened, by the client clock.
+	Created time.Time
+	// Caller is the function, file and line outside the SDK that opened it.
+	Caller string
+	// Stack is the stack trace of the goroutine that opened it, starting
+	// at Caller.
+	Stack string
+}
+
+// String returns l with its stack trace.
+func (l Leak) String() string {
+	return fmt.Sprintf("%s on %s opened at %s by %s\n%s", l.Kind, l.Namespace, l.Created.Format(time.RFC3339), l.Caller, l.Stack)
+}
+
+// logLeak is the default ClientOptions.OnLeak, logging a warning.
+func logLeak(l Leak) {
+	log.Printf("mongo: %s on %s was garbage collected without being closed; opened by %s\n%s", l.Kind, l.Namespace, l.Caller, l.Stack)
+}
+
+// leakTracker records the open cursors and change streams of a client.
+// Resources refer to it by id rather than the other way round, so tracking
+// does not keep them from being garbage collected.
+type leakTracker struct {
+	mu     sync.Mutex
+	clock  Clock
+	onLeak func(Leak)
+	open   map[uint64]Leak
+	next   uint64
+}
+
+// newLeakTracker returns a tracker reporting collected resources to onLeak,
+// or logging them when it is nil.
+func newLeakTracker(clock Clock, onLeak func(Leak)) *leakTracker {
+	if onLeak == nil {
+		onLeak = logLeak
+	}
+	return &leakTracker{clock: clock, onLeak: onLeak, open: make(map[uint64]Leak)}
+}
+
+// track records a resource opened by the caller and returns its id.
+func (t *leakTracker) track(kind, ns string) uint64 {
+	caller, stack := leakStack()
+	leak := Leak{Kind: kind, Namespace: ns, Created: t.clock.Now(), Caller: caller, Stack: stack}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next++
+	t.open[t.next] = leak
+	return t.next
+}
+
+// release forgets a resource that was closed or exhausted.
+func (t *leakTracker) release(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.open, id)
+}
+
+// collect reports a resource garbage collected while still open.
+func (t *leakTracker) collect(id uint64) {
+	t.mu.Lock()
+	leak, ok := t.open[id]
+	delete(t.open, id)
+	t.mu.Unlock()
+	if ok {
+		t.onLeak(leak)
+	}
+}
+
+// drain returns the open resources in the order they were opened and
+// forgets them, so they are not reported again when collected.
+func (t *leakTracker) drain() []Leak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ids := make([]uint64, 0, len(t.open))
+	for id := range t.open {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	leaks := make([]Leak, len(ids))
+	for i, id := range ids {
+		leaks[i] = t.open[id]
+	}
+	t.open = make(map[uint64]Leak)
+	return leaks
+}
+
+// leakStack returns the first frame outside the SDK and the stack trace
+// from it. Tests of the SDK count as outside it.
+func leakStack() (caller, stack string) {
+	pcs := make([]uintptr, maxLeakStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		internal := strings.HasPrefix(frame.Function, sdkFuncPrefix) && !strings.HasSuffix(frame.File, "_test.go")
+		if b.Len() > 0 || !internal || !more {
+			if caller == "" {
+				caller = fmt.Sprintf("%s (%s:%d)", frame.Function, frame.File, frame.Line)
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return caller, b.String()
+}
+
+// trackCursor registers cur with leak detection, if it is on.
+func (c *Client) trackCursor(cur *Cursor, ns string) {
+	if c.leaks == nil {
+		return
+	}
+	cur.leaks, cur.leakID = c.leaks, c.leaks.track("cursor", ns)
+	runtime.SetFinalizer(cur, (*Cursor).finalize)
+}
+
+// trackChangeStream registers cs with leak detection, if it is on.
+func (c *Client) trackChangeStream(cs *ChangeStream, ns string) {
+	if c.leaks == nil {
+		return
+	}
+	cs.leaks, cs.leakID = c.leaks, c.leaks.track("change stream", ns)
+	runtime.SetFinalizer(cs, (*ChangeStream).finalize)
+}
+
+// untrack removes c from leak detection once it is closed or exhausted.
+// The caller holds c.mu.
+func (c *Cursor) untrack() {
+	if c.leaks != nil {
+		c.leaks.release(c.leakID)
+		c.leaks = nil
+		runtime.SetFinalizer(c, nil)
+	}
+}
+
+// finalize reports c if it is collected while tracked.
+func (c *Cursor) finalize() {
+	if c.leaks != nil {
+		c.leaks.collect(c.leakID)
+	}
+}
+
+// untrack removes cs from leak detection once it is closed. The caller
+// holds cs.mu.
+func (cs *ChangeStream) untrack() {
+	if cs.leaks != nil {
+		cs.leaks.release(cs.leakID)
+		cs.leaks = nil
+		runtime.SetFinalizer(cs, nil)
+	}
+}
+
+// finalize reports cs if it is collected while tracked.
+func (cs *ChangeStream) finalize() {
+	if cs.leaks != nil {
+		cs.leaks.collect(cs.leakID)
+	}
+}
@@ -0,0 +1,117 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLeakDetectionDisconnect tests that Disconnect reports the cursors
+// and change streams left open, and only those.
+func TestLeakDetectionDisconnect(t *testing.T) {
+	mock := newMockRPCClient()
+	docs := []any{map[string]any{"_id": 1}}
+	mock.addCall("mongo.find", docs, nil)
+	mock.addCall("mongo.find", docs, nil)
+	mock.addCall("mongo.find", docs, nil)
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.watch", "stream-2", nil)
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetLeakDetection(true))
+	ctx := context.Background()
+	coll := client.Database("app").Collection("users")
+
+	closed, err := coll.Find(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closed.Close(ctx)
+	exhausted, _ := coll.Find(ctx, map[string]any{})
+	for exhausted.Next(ctx) {
+	}
+	leaked, _ := coll.Find(ctx, map[string]any{})
+	leakedStream, err := coll.Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closedStream, _ := coll.Watch(ctx, []any{})
+	closedStream.Close(ctx)
+
+	err = client.Disconnect(ctx)
+	var leakErr *LeakError
+	if !errors.As(err, &leakErr) || !errors.Is(err, ErrResourceLeak) {
+		t.Fatalf("expected a LeakError, got %v", err)
+	}
+	if len(leakErr.Leaks) != 2 {
+		t.Fatalf("expected 2 leaks, got %v", leakErr.Leaks)
+	}
+	cursor, stream := leakErr.Leaks[0], leakErr.Leaks[1]
+	if cursor.Kind != "cursor" || stream.Kind != "change stream" || cursor.Namespace != "app.users" || stream.Namespace != "app.users" {
+		t.Errorf("unexpected leaks %+v", leakErr.Leaks)
+	}
+	if !strings.Contains(cursor.Caller, "TestLeakDetectionDisconnect") || !strings.Contains(cursor.Stack, "leak_test.go") {
+		t.Errorf("expected the test as the caller, got %q\n%s", cursor.Caller, cursor.Stack)
+	}
+	if strings.Contains(cursor.Stack, "(*Collection).Find") {
+		t.Errorf("expected SDK frames to be skipped:\n%s", cursor.Stack)
+	}
+	if !strings.Contains(err.Error(), "2 cursors or change streams left open") {
+		t.Errorf("unexpected message %q", err)
+	}
+	_, _ = leaked, leakedStream
+}
+
+// TestLeakDetectionFinalizer tests that a cursor garbage collected without
+// being closed is reported to OnLeak.
+func TestLeakDetectionFinalizer(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": 1}}, nil)
+	leaks := make(chan Leak, 1)
+	opts := DefaultClientOptions().SetLeakDetection(true).SetOnLeak(func(l Leak) { leaks <- l })
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", opts)
+
+	func() {
+		if _, err := client.Database("app").Collection("users").Find(context.Background(), map[string]any{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case l := <-leaks:
+			if l.Kind != "cursor" || l.Namespace != "app.users" {
+				t.Errorf("unexpected leak %+v", l)
+			}
+			if err := client.Disconnect(context.Background()); err != nil {
+				t.Errorf("expected a reported leak not to be reported again, got %v", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("expected the leak to be reported")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// TestLeakDetectionDisabled tests that nothing is tracked by default.
+func TestLeakDetectionDisabled(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	cursor, err := client.Database("app").Collection("users").Find(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cursor.leaks != nil {
+		t.Error("expected the cursor not to be tracked")
+	}
+	if err := client.Disconnect(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
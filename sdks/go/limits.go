@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// resultLimits are the client-side guardrails on result sizes.
+type resultLimits struct {
+	MaxFindDocuments     int64
+	MaxResponseBytes     int64
+	MaxBufferedDocuments int64
+}
+
+// checkDecodedBytes fails if the estimated encoded size of docs exceeds
+// MaxResponseBytes. It is a post-hoc check: docs have already been received
+// and decoded, so it keeps an oversized result from the caller but does not
+// bound the memory used to read it.
+func (l resultLimits) checkDecodedBytes(ns string, docs []any) error {
+	if l.MaxResponseBytes <= 0 {
+		return nil
+	}
+	var size int64
+	for _, doc := range docs {
+		size += estimateSize(doc)
+		if size > l.MaxResponseBytes {
+			return &ResultTooLargeError{Namespace: ns, Limit: l.MaxResponseBytes, Unit: "bytes"}
+		}
+	}
+	return nil
+}
+
+// estimateSize approximates the JSON-encoded size of a decoded value
+// without encoding it. Values of types a decoded result does not normally
+// hold are measured by encoding them.
+func estimateSize(v any) int64 {
+	switch val := v.(type) {
+	case nil:
+		return 4
+	case bool:
+		return 5
+	case string:
+		return int64(len(val)) + 2
+	case json.Number:
+		return int64(len(val))
+	case float64, float32, int, int32, int64, uint32, uint64:
+		return 8
+	case time.Time:
+		return 26
+	case map[string]any:
+		size := int64(2)
+		for k, elem := range val {
+			size += int64(len(k)) + 4 + estimateSize(elem)
+		}
+		return size
+	case []any:
+		size := int64(2)
+		for _, elem := range val {
+			size += estimateSize(elem) + 1
+		}
+		return size
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return 0
+		}
+		return int64(len(data))
+	}
+}
@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFindMaxDocumentsGuardrail tests failing a Find without a limit that returns too many documents.
+func TestFindMaxDocumentsGuardrail(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "1"},
+		map[string]any{"_id": "2"},
+		map[string]any{"_id": "3"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.limits.MaxFindDocuments = 2
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.Find(context.Background(), map[string]any{})
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("expected ErrResultTooLarge, got %v", err)
+	}
+
+	var tooLarge *ResultTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Unit != "documents" || tooLarge.Limit != 2 {
+		t.Errorf("unexpected error details: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "testdb.users") {
+		t.Errorf("expected namespace in message, got %q", err.Error())
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["limit"] != int64(3) {
+		t.Errorf("expected limit of max+1 sent, got %v", options["limit"])
+	}
+}
+
+// TestFindMaxDocumentsWithinLimit tests a Find that stays within the guardrail.
+func TestFindMaxDocumentsWithinLimit(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "1"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.limits.MaxFindDocuments = 2
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Find(context.Background(), map[string]any{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestFindMaxDocumentsExplicitLimit tests that an explicit limit bypasses the guardrail.
+func TestFindMaxDocumentsExplicitLimit(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "1"},
+		map[string]any{"_id": "2"},
+		map[string]any{"_id": "3"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.limits.MaxFindDocuments = 2
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Find(context.Background(), map[string]any{}, (&FindOptions{}).SetLimit(10)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["limit"] != int64(10) {
+		t.Errorf("expected explicit limit sent, got %v", options["limit"])
+	}
+}
+
+// TestFindMaxDocumentsZeroLimit tests that a limit of zero, meaning no
+// limit, keeps the guardrail in place.
+func TestFindMaxDocumentsZeroLimit(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "1"},
+		map[string]any{"_id": "2"},
+		map[string]any{"_id": "3"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.limits.MaxFindDocuments = 2
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.Find(context.Background(), map[string]any{}, (&FindOptions{}).SetLimit(0))
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Fatalf("expected ErrResultTooLarge, got %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["limit"] != int64(3) {
+		t.Errorf("expected limit of max+1 sent, got %v", options["limit"])
+	}
+}
+
+// TestEstimateSize tests the estimated encoded size of decoded values.
+func TestEstimateSize(t *testing.T) {
+	doc := map[string]any{
+		"name": "alice",
+		"tags": []any{"a", "b"},
+		"age":  float64(30),
+		"ok":   true,
+		"none": nil,
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The estimate may differ from the exact size, but not by much.
+	got, want := estimateSize(doc), int64(len(data))
+	if got < want/2 || got > want*2 {
+		t.Errorf("estimate %d too far from encoded size %d", got, want)
+	}
+}
+
+// TestAggregateMaxResponseBytes tests failing an oversized aggregation result.
+func TestAggregateMaxResponseBytes(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{
+		map[string]any{"payload": strings.Repeat("x", 100)},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.limits.MaxResponseBytes = 50
+	coll := client.Database("testdb").Collection("events")
+
+	_, err := coll.Aggregate(context.Background(), []any{})
+
+	var tooLarge *ResultTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Unit != "bytes" {
+		t.Errorf("expected bytes ResultTooLargeError, got %v", err)
+	}
+}
+
+// TestClientOptionsResultLimits tests setting result guardrails.
+func TestClientOptionsResultLimits(t *testing.T) {
+	opts := DefaultClientOptions().SetMaxFindDocuments(100).SetMaxResponseBytes(1 << 20)
+
+	client := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017", opts)
+
+	if client.limits.MaxFindDocuments != 100 || client.limits.MaxResponseBytes != 1<<20 {
+		t.Errorf("unexpected limits: %+v", client.limits)
+	}
+}
@@ -0,0 +1,373 @@
+package mongo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LintSeverity classifies a pipeline lint issue.
+type LintSeverity int
+
+// Lint severities.
+const (
+	// LintWarning marks a likely mistake that the server would accept.
+	LintWarning LintSeverity = iota
+	// LintError marks a pipeline the server would reject.
+	LintError
+)
+
+// String returns "warning" or "error".
+func (s LintSeverity) String() string {
+	if s == LintError {
+		return "error"
+	}
+	return "warning"
+}
+
+// PipelineIssue describes a problem found in an aggregation pipeline.
+type PipelineIssue struct {
+	// Stage is the zero-based index of the offending stage.
+	Stage    int
+	Severity LintSeverity
+	Message  string
+}
+
+// String formats the issue with its stage index and severity.
+func (i PipelineIssue) String() string {
+	return fmt.Sprintf("stage %d: %s: %s", i.Stage, i.Severity, i.Message)
+}
+
+// knownStages are the aggregation stage names the linter recognizes.
+var knownStages = map[string]bool{
+	"$addFields": true, "$bucket": true, "$bucketAuto": true, "$changeStream": true,
+	"$changeStreamSplitLargeEvent": true, "$collStats": true, "$count": true,
+	"$currentOp": true, "$densify": true, "$documents": true, "$facet": true,
+	"$fill": true, "$geoNear": true, "$graphLookup": true, "$group": true,
+	"$indexStats": true, "$limit": true, "$listLocalSessions": true,
+	"$listSampledQueries": true, "$listSearchIndexes": true, "$listSessions": true,
+	"$lookup": true, "$match": true, "$merge": true, "$out": true,
+	"$planCacheStats": true, "$project": true, "$redact": true, "$replaceRoot": true,
+	"$replaceWith": true, "$sample": true, "$search": true, "$searchMeta": true,
+	"$set": true, "$setWindowFields": true, "$skip": true, "$sort": true,
+	"$sortByCount": true, "$unionWith": true, "$unset": true, "$unwind": true,
+	"$vectorSearch": true,
+}
+
+// LintPipeline checks an aggregation pipeline for common mistakes: stages
+// that are not single-key documents, stage names without the $ prefix,
+// unknown stage names, field paths missing their $ prefix, and $match stages
+// filtering on fields an earlier stage removed. Pipelines that are not a
+// Pipeline, []any or []map[string]any are not checked.
+func LintPipeline(pipeline any) []PipelineIssue {
+	stages, ok := pipelineStages(pipeline)
+	if !ok {
+		return nil
+	}
+
+	l := &pipelineLinter{}
+	for i, stage := range stages {
+		l.stage(i, plainDocument(stage))
+	}
+	return l.issues
+}
+
+// lintPipeline returns a PipelineError if the pipeline has errors, or, in
+// strict mode, any issue at all.
+func lintPipeline(pipeline any, strict bool) error {
+	var rejected []PipelineIssue
+	for _, issue := range LintPipeline(pipeline) {
+		if strict || issue.Severity == LintError {
+			rejected = append(rejected, issue)
+		}
+	}
+	if len(rejected) > 0 {
+		return &PipelineError{Issues: rejected}
+	}
+	return nil
+}
+
+// pipelineLinter tracks the document shape across stages.
+type pipelineLinter struct {
+	issues []PipelineIssue
+	// present, when non-nil, is the set of top-level fields known to be the
+	// only ones in the documents; shapedBy is the stage that produced it.
+	present map[string]bool
+	// removed holds top-level fields known to be removed, with the stage
+	// that removed them.
+	removed  map[string]int
+	shapedBy int
+}
+
+func (l *pipelineLinter) report(stage int, severity LintSeverity, format string, args ...any) {
+	l.issues = append(l.issues, PipelineIssue{Stage: stage, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *pipelineLinter) stage(i int, stage any) {
+	doc, ok := stage.(map[string]any)
+	if !ok {
+		l.report(i, LintError, "stage must be a document, got %T", stage)
+		return
+	}
+	if len(doc) != 1 {
+		l.report(i, LintError, "stage must have exactly one field, got %d", len(doc))
+		return
+	}
+
+	var name string
+	var spec any
+	for name, spec = range doc {
+	}
+
+	if !strings.HasPrefix(name, "$") {
+		if knownStages["$"+name] {
+			l.report(i, LintError, "stage name %q is missing the $ prefix (did you mean %q?)", name, "$"+name)
+		} else {
+			l.report(i, LintError, "stage name %q is missing the $ prefix", name)
+		}
+		l.reset()
+		return
+	}
+	if !knownStages[name] {
+		l.report(i, LintWarning, "unknown stage %q", name)
+		l.reset()
+		return
+	}
+
+	switch name {
+	case "$match":
+		l.match(i, spec)
+	case "$project":
+		l.project(i, spec)
+	case "$unset":
+		l.unset(i, spec)
+	case "$addFields", "$set":
+		l.addFields(spec)
+	case "$group":
+		l.group(i, spec)
+	case "$unwind":
+		l.unwind(i, spec)
+	case "$sortByCount":
+		l.fieldRef(i, name, spec)
+		l.shape(i, "_id", "count")
+	case "$count":
+		if field, ok := spec.(string); ok {
+			l.shape(i, field)
+		}
+	case "$limit", "$skip", "$sort", "$sample", "$lookup", "$graphLookup", "$unionWith":
+		// These keep (or only add to) the document shape.
+	default:
+		l.reset()
+	}
+}
+
+// reset forgets the tracked shape after a stage with unknown effects.
+func (l *pipelineLinter) reset() {
+	l.present = nil
+	l.removed = nil
+}
+
+// shape records that stage i outputs only fields.
+func (l *pipelineLinter) shape(i int, fields ...string) {
+	l.present = make(map[string]bool, len(fields))
+	for _, f := range fields {
+		l.present[f] = true
+	}
+	l.removed = nil
+	l.shapedBy = i
+}
+
+func (l *pipelineLinter) remove(i int, field string) {
+	if l.present != nil {
+		delete(l.present, field)
+		return
+	}
+	if l.removed == nil {
+		l.removed = make(map[string]int)
+	}
+	l.removed[field] = i
+}
+
+func (l *pipelineLinter) match(i int, spec any) {
+	filter, ok := spec.(map[string]any)
+	if !ok {
+		l.report(i, LintError, "$match requires a document, got %T", spec)
+		return
+	}
+	fields := make(map[string]bool)
+	matchFields(filter, fields)
+
+	names := make([]string, 0, len(fields))
+	for f := range fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	for _, f := range names {
+		if l.present != nil && !l.present[f] {
+			l.report(i, LintWarning, "$match on %q, which stage %d does not output", f, l.shapedBy)
+		} else if stage, ok := l.removed[f]; ok {
+			l.report(i, LintWarning, "$match on %q, which stage %d removed", f, stage)
+		}
+	}
+}
+
+// matchFields collects the top-level fields referenced by a query filter.
+func matchFields(filter map[string]any, fields map[string]bool) {
+	for key, value := range filter {
+		switch key {
+		case "$and", "$or", "$nor":
+			if clauses, ok := value.([]any); ok {
+				for _, clause := range clauses {
+					if doc, ok := clause.(map[string]any); ok {
+						matchFields(doc, fields)
+					}
+				}
+			}
+		default:
+			if !strings.HasPrefix(key, "$") {
+				root, _, _ := strings.Cut(key, ".")
+				fields[root] = true
+			}
+		}
+	}
+}
+
+func (l *pipelineLinter) project(i int, spec any) {
+	doc, ok := spec.(map[string]any)
+	if !ok {
+		l.report(i, LintError, "$project requires a document, got %T", spec)
+		return
+	}
+
+	var included, excluded []string
+	keepID := true
+	for key, value := range doc {
+		root, _, _ := strings.Cut(key, ".")
+		if isExclusion(value) {
+			if key == "_id" {
+				keepID = false
+				continue
+			}
+			excluded = append(excluded, root)
+			continue
+		}
+		if key != "_id" {
+			included = append(included, root)
+		}
+	}
+
+	if len(included) > 0 {
+		if keepID {
+			included = append(included, "_id")
+		}
+		l.shape(i, included...)
+		return
+	}
+	if !keepID {
+		excluded = append(excluded, "_id")
+	}
+	for _, f := range excluded {
+		l.remove(i, f)
+	}
+}
+
+// isExclusion reports whether a projection value excludes its field.
+func isExclusion(v any) bool {
+	switch n := v.(type) {
+	case bool:
+		return !n
+	case int:
+		return n == 0
+	case int32:
+		return n == 0
+	case int64:
+		return n == 0
+	case float64:
+		return n == 0
+	}
+	return false
+}
+
+func (l *pipelineLinter) unset(i int, spec any) {
+	switch fields := spec.(type) {
+	case string:
+		root, _, _ := strings.Cut(fields, ".")
+		l.remove(i, root)
+	case []string:
+		for _, f := range fields {
+			root, _, _ := strings.Cut(f, ".")
+			l.remove(i, root)
+		}
+	case []any:
+		for _, f := range fields {
+			if s, ok := f.(string); ok {
+				root, _, _ := strings.Cut(s, ".")
+				l.remove(i, root)
+			}
+		}
+	}
+}
+
+func (l *pipelineLinter) addFields(spec any) {
+	doc, ok := spec.(map[string]any)
+	if !ok {
+		return
+	}
+	for key := range doc {
+		root, _, _ := strings.Cut(key, ".")
+		if l.present != nil {
+			l.present[root] = true
+		}
+		delete(l.removed, root)
+	}
+}
+
+func (l *pipelineLinter) group(i int, spec any) {
+	doc, ok := spec.(map[string]any)
+	if !ok {
+		l.report(i, LintError, "$group requires a document, got %T", spec)
+		return
+	}
+	if _, ok := doc["_id"]; !ok {
+		l.report(i, LintError, "$group requires an _id")
+	}
+
+	fields := make([]string, 0, len(doc))
+	for key := range doc {
+		fields = append(fields, key)
+	}
+	sort.Strings(fields)
+	for _, key := range fields {
+		value := doc[key]
+		if key == "_id" {
+			l.fieldRef(i, "$group _id", value)
+			continue
+		}
+		if acc, ok := value.(map[string]any); ok {
+			for op, operand := range acc {
+				l.fieldRef(i, fmt.Sprintf("%s for %q", op, key), operand)
+			}
+		}
+	}
+	l.shape(i, fields...)
+}
+
+func (l *pipelineLinter) unwind(i int, spec any) {
+	path, ok := spec.(string)
+	if doc, isDoc := spec.(map[string]any); isDoc {
+		path, ok = doc["path"].(string)
+	}
+	if ok && !strings.HasPrefix(path, "$") {
+		l.report(i, LintError, "$unwind path %q is missing the $ prefix (did you mean %q?)", path, "$"+path)
+	}
+}
+
+// fieldRef warns when a string operand looks like a field name without its
+// $ prefix, which MongoDB treats as a string literal.
+func (l *pipelineLinter) fieldRef(i int, context string, operand any) {
+	s, ok := operand.(string)
+	if !ok || s == "" || strings.HasPrefix(s, "$") || strings.ContainsAny(s, " ") {
+		return
+	}
+	l.report(i, LintWarning, "%s is the literal %q (did you mean %q?)", context, s, "$"+s)
+}
@@ -0,0 +1,111 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestLintPipeline tests the issues reported for common pipeline mistakes.
+func TestLintPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline any
+		severity LintSeverity
+		contains string
+	}{
+		{"missing stage prefix", []any{map[string]any{"match": map[string]any{"a": 1}}}, LintError, `did you mean "$match"`},
+		{"unknown stage", []any{map[string]any{"$mtach": map[string]any{}}}, LintWarning, `unknown stage "$mtach"`},
+		{"multiple keys", []any{map[string]any{"$match": map[string]any{}, "$limit": 1}}, LintError, "exactly one field"},
+		{"not a document", []any{"$match"}, LintError, "must be a document"},
+		{"unwind prefix", Pipeline{map[string]any{"$unwind": "items"}}, LintError, `did you mean "$items"`},
+		{"unwind document prefix", Pipeline{map[string]any{"$unwind": map[string]any{"path": "items"}}}, LintError, "$unwind path"},
+		{"accumulator prefix", []any{map[string]any{"$group": map[string]any{"_id": "$cat", "total": map[string]any{"$sum": "amount"}}}}, LintWarning, `did you mean "$amount"`},
+		{"group without id", []any{map[string]any{"$group": map[string]any{"n": map[string]any{"$sum": 1}}}}, LintError, "requires an _id"},
+		{"match after inclusion", []any{
+			map[string]any{"$project": map[string]any{"name": 1}},
+			map[string]any{"$match": map[string]any{"age": map[string]any{"$gt": 21}}},
+		}, LintWarning, `"age", which stage 0 does not output`},
+		{"match after exclusion", []map[string]any{
+			{"$project": map[string]any{"secret": 0}},
+			{"$match": map[string]any{"$or": []any{map[string]any{"secret.level": 2}}}},
+		}, LintWarning, `"secret", which stage 0 removed`},
+		{"match after group", []any{
+			map[string]any{"$group": map[string]any{"_id": "$cat", "n": map[string]any{"$sum": 1}}},
+			map[string]any{"$match": map[string]any{"cat": "a"}},
+		}, LintWarning, `"cat", which stage 0 does not output`},
+		{"match after unset", []any{
+			map[string]any{"$unset": []any{"a", "b"}},
+			map[string]any{"$match": map[string]any{"b": 1}},
+		}, LintWarning, `"b", which stage 0 removed`},
+	}
+
+	for _, tt := range tests {
+		issues := LintPipeline(tt.pipeline)
+		if len(issues) != 1 {
+			t.Errorf("%s: expected 1 issue, got %v", tt.name, issues)
+			continue
+		}
+		if issues[0].Severity != tt.severity || !strings.Contains(issues[0].Message, tt.contains) {
+			t.Errorf("%s: unexpected issue: %v", tt.name, issues[0])
+		}
+	}
+}
+
+// TestLintPipelineClean tests pipelines that should produce no issues.
+func TestLintPipelineClean(t *testing.T) {
+	pipelines := []any{
+		[]any{
+			map[string]any{"$match": map[string]any{"status": "A"}},
+			map[string]any{"$project": map[string]any{"name": 1, "age": 1}},
+			map[string]any{"$addFields": map[string]any{"adult": true}},
+			map[string]any{"$match": map[string]any{"age": map[string]any{"$gte": 18}, "adult": true, "_id": 1}},
+			map[string]any{"$group": map[string]any{"_id": "$name", "count": map[string]any{"$sum": 1}}},
+			map[string]any{"$match": map[string]any{"count": map[string]any{"$gt": 1}}},
+			map[string]any{"$sort": map[string]any{"count": -1}},
+		},
+		Pipeline{map[string]any{"$unwind": "$items"}},
+		"not a slice",
+		nil,
+	}
+	for _, p := range pipelines {
+		if issues := LintPipeline(p); len(issues) != 0 {
+			t.Errorf("unexpected issues for %v: %v", p, issues)
+		}
+	}
+}
+
+// TestAggregateLintsPipeline tests that Aggregate rejects pipelines before the RPC call.
+func TestAggregateLintsPipeline(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	_, err := coll.Aggregate(ctx, []any{map[string]any{"match": map[string]any{}}})
+	var pipelineErr *PipelineError
+	if !errors.As(err, &pipelineErr) || !errors.Is(err, ErrInvalidPipeline) {
+		t.Fatalf("expected PipelineError, got %v", err)
+	}
+	if len(pipelineErr.Issues) != 1 || pipelineErr.Issues[0].Stage != 0 {
+		t.Errorf("unexpected issues: %v", pipelineErr.Issues)
+	}
+
+	warning := []any{map[string]any{"$mtach": map[string]any{}}}
+	if _, err := coll.Aggregate(ctx, warning, (&AggregateOptions{}).SetStrictPipeline(true)); !errors.Is(err, ErrInvalidPipeline) {
+		t.Fatalf("expected ErrInvalidPipeline in strict mode, got %v", err)
+	}
+	if mock.calls[0].args != nil {
+		t.Fatal("expected no RPC call for rejected pipelines")
+	}
+
+	if _, err := coll.Aggregate(ctx, warning); err != nil {
+		t.Fatalf("expected warnings to pass outside strict mode, got %v", err)
+	}
+	if mock.calls[0].args == nil {
+		t.Error("expected the RPC call to be made")
+	}
+}
@@ -0,0 +1,223 @@
+package mongo
+
+import (
+	"context"
+	"time"
+)
+
+// ChangeStreamResumeEvent reports that a change stream was closed and
+// resumed, either because it saw no event or heartbeat within its liveness
+// timeout or because it failed with a resumable error.
+type ChangeStreamResumeEvent struct {
+	// OldStreamID and NewStreamID identify the abandoned and the resumed
+	// server-side streams. NewStreamID is empty if resuming failed.
+	OldStreamID string
+	NewStreamID string
+	// Idle is how long the stream had been silent, for liveness resumes.
+	Idle time.Duration
+	// Cause is the resumable error that triggered the resume, or nil for a
+	// liveness resume.
+	Cause error
+	// ResumeToken is the token the stream resumed after, or nil if no event
+	// had been seen and the stream was reopened with its original options.
+	ResumeToken any
+	// Err is the error that prevented resuming, if any.
+	Err error
+}
+
+// maxChangeStreamResumes is the number of times Next resumes a stream after
+// consecutive resumable errors before returning the error.
+const maxChangeStreamResumes = 3
+
+// changeStreamLiveness tracks when a stream last showed signs of life.
+type changeStreamLiveness struct {
+	timeout  time.Duration
+	clock    Clock
+	lastSeen time.Time
+}
+
+// watch opens a change stream on db.coll, or on the whole database when coll
+// is empty, recording operation metrics under ns. Opening the stream, and
+// reopening it, runs under the timeout that timeout resolves, with the
+// namespace defaults of db.coll or db. With a liveness timeout, the stream
+// keeps what it needs to reopen itself.
+func (c *Client) watch(ctx context.Context, opTimeout func(context.Context) OperationTimeout, ns, db, coll string, pipeline any, opts ...*ChangeStreamOptions) (*ChangeStream, error) {
+	options, err := changeStreamOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defaults := c.namespaceDefaults(db, coll)
+	if _, ok := options["readPreference"]; !ok && defaults.ReadPreference != nil {
+		options["readPreference"] = defaults.ReadPreference.document()
+	}
+	defaults.apply(options)
+
+	open := func(ctx context.Context, options map[string]any) (RPCClient, string, error) {
+		result, err := c.executeWithin(ctx, opTimeout(ctx), ns, "mongo.watch", withOptions([]any{db, coll, pipeline}, options)...)
+		if err != nil {
+			return nil, "", err
+		}
+		// Parse stream ID from result
+		streamID, ok := result.(string)
+		if !ok {
+			return nil, "", unexpectedResponse("mongo.watch", result)
+		}
+		return c.transport(), streamID, nil
+	}
+
+	rpcClient, streamID, err := open(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	cs := newChangeStream(rpcClient, streamID)
+	cs.strict = c.strict
+	cs.naming = c.naming
+	cs.numbers = c.numbers
+	cs.decoder = c.decoder
+	cs.clock = c.clock
+	cs.reopen = func(ctx context.Context, token any) (RPCClient, string, error) {
+		if token == nil {
+			return open(ctx, options)
+		}
+		resumed := make(map[string]any, len(options)+1)
+		for k, v := range options {
+			if k != "resumeAfter" && k != "startAfter" && k != "startAtOperationTime" {
+				resumed[k] = v
+			}
+		}
+		resumed["resumeAfter"] = token
+		return open(ctx, resumed)
+	}
+
+	var timeout time.Duration
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.LivenessTimeout != nil {
+				timeout = *opt.LivenessTimeout
+			}
+			if opt.OnResume != nil {
+				onResume := opt.OnResume
+				cs.onResume = func(event ChangeStreamResumeEvent) error {
+					return c.runCallback("OnResume", func() error {
+						onResume(event)
+						return nil
+					})
+				}
+			}
+			if opt.BatchCompression != nil {
+				cs.compression = *opt.BatchCompression
+			}
+			if opt.PollInterval != nil {
+				cs.pollInterval = *opt.PollInterval
+			}
+		}
+	}
+	if cs.compression != "" {
+		cs.namespace = ns
+		cs.maxBatchBytes = c.limits.MaxResponseBytes
+		if cs.maxBatchBytes <= 0 {
+			cs.maxBatchBytes = DefaultMaxEventBatchBytes
+		}
+	}
+	if timeout > 0 {
+		cs.liveness = &changeStreamLiveness{
+			timeout:  timeout,
+			clock:    c.clock,
+			lastSeen: c.clock.Now(),
+		}
+	}
+	c.trackChangeStream(cs, ns)
+	return cs, nil
+}
+
+// heartbeatToken reports whether a changeStreamNext result is a heartbeat
+// rather than an event: a document with a postBatchResumeToken and no
+// operationType. It returns the heartbeat's resume token.
+func heartbeatToken(result map[string]any) (any, bool) {
+	if _, ok := result["operationType"]; ok {
+		return nil, false
+	}
+	token, ok := result["postBatchResumeToken"]
+	return token, ok
+}
+
+// nextWithLiveness fetches the next event of a stream with a liveness
+// timeout. A stream that has been silent for the whole window, or whose
+// request does not complete within the remaining window, is closed and
+// resumed. The error of a failed request is returned for Next to handle.
+func (cs *ChangeStream) nextWithLiveness(ctx context.Context) (bool, error) {
+	l := cs.liveness
+	remaining := l.timeout - l.clock.Now().Sub(l.lastSeen)
+	if remaining <= 0 {
+		cs.resume(ctx, nil)
+		return false, nil
+	}
+
+	type response struct {
+		result any
+		err    error
+	}
+	done := make(chan response, 1)
+	promise := callContext(ctx, cs.rpcClient, cs.nextMethod(), cs.streamID)
+	go func() {
+		result, err := promise.Await()
+		done <- response{result, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err == nil {
+			r.err = serverError(r.result)
+		}
+		if r.err != nil {
+			return false, r.err
+		}
+		return cs.advance(r.result), nil
+	case <-l.clock.After(remaining):
+		cs.resume(ctx, nil)
+		return false, nil
+	case <-ctx.Done():
+		cs.err = ctx.Err()
+		return false, nil
+	}
+}
+
+// resume closes the server-side stream and opens a new one after the last
+// resume token, reporting the outcome to the OnResume callback. cause is
+// the resumable error that made the stream resume, or nil when it was
+// silent for its liveness timeout. It reports whether the stream resumed.
+func (cs *ChangeStream) resume(ctx context.Context, cause error) bool {
+	event := ChangeStreamResumeEvent{
+		OldStreamID: cs.streamID,
+		Cause:       cause,
+		ResumeToken: cs.resumeToken,
+	}
+	l := cs.liveness
+	if l != nil && cause == nil {
+		event.Idle = l.clock.Now().Sub(l.lastSeen)
+	}
+
+	// The old stream is presumed dead; a failure to close it is expected.
+	cs.rpcClient.Call("mongo.changeStreamClose", cs.streamID)
+
+	rpcClient, streamID, err := cs.reopen(ctx, cs.resumeToken)
+	if err != nil {
+		cs.err = err
+		event.Err = err
+	} else {
+		cs.rpcClient = rpcClient
+		cs.streamID = streamID
+		event.NewStreamID = streamID
+	}
+	if l != nil {
+		l.lastSeen = l.clock.Now()
+	}
+
+	if cs.onResume != nil {
+		if perr := cs.onResume(event); perr != nil && err == nil {
+			cs.err = perr
+			return false
+		}
+	}
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// hangingRPCClient is a transport whose calls never complete.
+type hangingRPCClient struct {
+	release chan struct{}
+}
+
+type hangingPromise struct {
+	release chan struct{}
+}
+
+func (p *hangingPromise) Await() (any, error) {
+	<-p.release
+	return nil, nil
+}
+
+func (h *hangingRPCClient) Call(method string, args ...any) RPCPromise {
+	return &hangingPromise{release: h.release}
+}
+
+func (h *hangingRPCClient) Close() error      { return nil }
+func (h *hangingRPCClient) IsConnected() bool { return true }
+
+// waitTimers waits until the fake clock has n pending timers.
+func waitTimers(t *testing.T, clock *fakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		clock.mu.Lock()
+		got := len(clock.waiters)
+		clock.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d timers", n)
+}
+
+// TestChangeStreamLivenessResume tests resuming a stream that stayed silent for the window.
+func TestChangeStreamLivenessResume(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           map[string]any{"_data": "t1"},
+		"operationType": "insert",
+	}, nil)
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+	mock.addCall("mongo.watch", "stream-2", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{"postBatchResumeToken": map[string]any{"_data": "t2"}}, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	coll := client.Database("testdb").Collection("orders")
+	ctx := context.Background()
+
+	var events []ChangeStreamResumeEvent
+	opts := (&ChangeStreamOptions{}).
+		SetFullDocument(FullDocumentUpdateLookup).
+		SetLivenessTimeout(30 * time.Second).
+		SetOnResume(func(e ChangeStreamResumeEvent) { events = append(events, e) })
+	cs, err := coll.Watch(ctx, []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !cs.Next(ctx) {
+		t.Fatalf("expected an event, got error %v", cs.Err())
+	}
+
+	clock.Advance(31 * time.Second)
+	if cs.Next(ctx) {
+		t.Fatal("expected no event while resuming")
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected one resume event, got %d", len(events))
+	}
+	e := events[0]
+	if e.OldStreamID != "stream-1" || e.NewStreamID != "stream-2" || e.Idle != 31*time.Second || e.Err != nil {
+		t.Errorf("unexpected resume event: %+v", e)
+	}
+
+	options := mock.calls[3].args[3].(map[string]any)
+	if !reflect.DeepEqual(options["resumeAfter"], map[string]any{"_data": "t1"}) || options["fullDocument"] != "updateLookup" {
+		t.Errorf("unexpected resume options: %v", options)
+	}
+
+	// A heartbeat counts as liveness and updates the resume token.
+	clock.Advance(20 * time.Second)
+	if cs.Next(ctx) {
+		t.Fatal("expected no event for a heartbeat")
+	}
+	if mock.calls[4].args[0] != "stream-2" {
+		t.Errorf("expected next on the resumed stream, got %v", mock.calls[4].args)
+	}
+	if !reflect.DeepEqual(cs.resumeToken, map[string]any{"_data": "t2"}) {
+		t.Errorf("unexpected resume token: %v", cs.resumeToken)
+	}
+	if cs.Err() != nil {
+		t.Errorf("unexpected error: %v", cs.Err())
+	}
+}
+
+// TestChangeStreamLivenessHangingRequest tests resuming when a request never completes.
+func TestChangeStreamLivenessHangingRequest(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.watch", "stream-2", nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	db := client.Database("testdb")
+
+	resumed := make(chan ChangeStreamResumeEvent, 1)
+	opts := (&ChangeStreamOptions{}).
+		SetLivenessTimeout(time.Minute).
+		SetOnResume(func(e ChangeStreamResumeEvent) { resumed <- e })
+	cs, err := db.Watch(context.Background(), []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hanging := &hangingRPCClient{release: make(chan struct{})}
+	defer close(hanging.release)
+	cs.rpcClient = hanging
+
+	done := make(chan bool)
+	go func() { done <- cs.Next(context.Background()) }()
+
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Minute)
+
+	if <-done {
+		t.Error("expected no event from a hanging stream")
+	}
+	e := <-resumed
+	if e.NewStreamID != "stream-2" || e.ResumeToken != nil {
+		t.Errorf("unexpected resume event: %+v", e)
+	}
+	if cs.rpcClient != RPCClient(mock) {
+		t.Error("expected the resumed stream to use the client transport")
+	}
+}
@@ -0,0 +1,212 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Loader defaults.
+const (
+	DefaultLoaderWindow   = 2 * time.Millisecond
+	DefaultLoaderMaxBatch = 100
+)
+
+// LoaderOptions configures a Loader.
+type LoaderOptions struct {
+	// Window is how long the first Load of a batch waits for more keys.
+	Window *time.Duration
+	// MaxBatch dispatches a batch as soon as it holds this many keys.
+	MaxBatch *int
+	// Field is the top-level field keys are matched on. Defaults to "_id".
+	Field *string
+}
+
+// SetWindow sets how long loads are collected before a batch is sent.
+func (o *LoaderOptions) SetWindow(d time.Duration) *LoaderOptions {
+	o.Window = &d
+	return o
+}
+
+// SetMaxBatch sets the maximum number of keys per batch.
+func (o *LoaderOptions) SetMaxBatch(n int) *LoaderOptions {
+	o.MaxBatch = &n
+	return o
+}
+
+// SetField sets the field keys are matched on.
+func (o *LoaderOptions) SetField(field string) *LoaderOptions {
+	o.Field = &field
+	return o
+}
+
+// Loader coalesces single-document lookups into batched $in queries, in the
+// style of a DataLoader, to avoid N+1 query patterns in API resolvers. Loads
+// for the same collection issued within the batch window share one Find,
+// and duplicate keys share one result. A Loader is safe for concurrent use.
+type Loader struct {
+	window   time.Duration
+	maxBatch int
+	field    string
+
+	mu      sync.Mutex
+	pending map[*Collection]*loaderBatch
+}
+
+// loaderBatch is a set of keys for one collection, loaded together.
+type loaderBatch struct {
+	coll  *Collection
+	keys  []any
+	index map[string]bool
+	done  chan struct{}
+	docs  map[string]map[string]any
+	err   error
+}
+
+// NewLoader returns a Loader.
+func NewLoader(opts ...*LoaderOptions) (*Loader, error) {
+	l := &Loader{
+		window:   DefaultLoaderWindow,
+		maxBatch: DefaultLoaderMaxBatch,
+		field:    "_id",
+		pending:  make(map[*Collection]*loaderBatch),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.Window != nil {
+				l.window = *opt.Window
+			}
+			if opt.MaxBatch != nil {
+				l.maxBatch = *opt.MaxBatch
+			}
+			if opt.Field != nil {
+				l.field = *opt.Field
+			}
+		}
+	}
+	if l.maxBatch <= 0 {
+		return nil, fmt.Errorf("mongo: loader batch size must be positive, got %d", l.maxBatch)
+	}
+	if l.field == "" {
+		return nil, fmt.Errorf("mongo: loader field must not be empty")
+	}
+	return l, nil
+}
+
+// Load returns the document of coll whose key field equals key, batched
+// with other loads issued within the window. The result holds
+// ErrNoDocuments if no document matches. If several documents match, one
+// of them is returned.
+func (l *Loader) Load(ctx context.Context, coll *Collection, key any) *SingleResult {
+	norm, err := loaderKey(key)
+	if err != nil {
+		return newSingleResultError(err)
+	}
+
+	b := l.enqueue(coll, norm, key)
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return newSingleResultError(ctx.Err())
+	}
+
+	if b.err != nil {
+		return newSingleResultError(b.err)
+	}
+	doc, ok := b.docs[norm]
+	if !ok {
+		return newSingleResultError(ErrNoDocuments)
+	}
+	return coll.singleResult(doc)
+}
+
+// enqueue adds key to the pending batch for coll, starting a new batch and
+// its window timer if needed.
+func (l *Loader) enqueue(coll *Collection, norm string, key any) *loaderBatch {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.pending[coll]
+	if !ok {
+		b = &loaderBatch{
+			coll:  coll,
+			index: make(map[string]bool),
+			done:  make(chan struct{}),
+		}
+		l.pending[coll] = b
+		timer := coll.database.client.clock.After(l.window)
+		go func() {
+			<-timer
+			l.flush(b)
+		}()
+	}
+
+	if !b.index[norm] {
+		b.index[norm] = true
+		b.keys = append(b.keys, key)
+	}
+	if len(b.keys) >= l.maxBatch {
+		delete(l.pending, coll)
+		go l.dispatch(b)
+	}
+	return b
+}
+
+// flush dispatches b if it is still pending once its window has elapsed.
+func (l *Loader) flush(b *loaderBatch) {
+	l.mu.Lock()
+	if l.pending[b.coll] != b {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.pending, b.coll)
+	l.mu.Unlock()
+
+	l.dispatch(b)
+}
+
+// dispatch loads every key of b with a single $in query. The query runs
+// under the client's context rather than any one caller's, since the batch
+// is shared; callers stop waiting when their own context is done.
+func (l *Loader) dispatch(b *loaderBatch) {
+	defer close(b.done)
+
+	ctx := b.coll.database.client.ctx
+	filter := map[string]any{l.field: map[string]any{"$in": b.keys}}
+	cursor, err := b.coll.Find(ctx, filter)
+	if err != nil {
+		b.err = err
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var docs []map[string]any
+	if err := cursor.All(ctx, &docs); err != nil {
+		b.err = err
+		return
+	}
+
+	b.docs = make(map[string]map[string]any, len(docs))
+	for _, doc := range docs {
+		norm, err := loaderKey(doc[l.field])
+		if err != nil {
+			continue
+		}
+		if _, ok := b.docs[norm]; !ok {
+			b.docs[norm] = doc
+		}
+	}
+}
+
+// loaderKey normalizes a key by its JSON encoding, so that keys compare
+// equal to the decoded values in results (for example 5 and 5.0) and
+// unhashable keys can be used.
+func loaderKey(key any) (string, error) {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("mongo: invalid loader key: %w", err)
+	}
+	return string(data), nil
+}
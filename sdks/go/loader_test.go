@@ -0,0 +1,128 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitPendingKeys waits until the pending batch for coll holds n keys.
+func waitPendingKeys(t *testing.T, l *Loader, coll *Collection, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		l.mu.Lock()
+		b := l.pending[coll]
+		got := 0
+		if b != nil {
+			got = len(b.keys)
+		}
+		l.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d pending keys", n)
+}
+
+// TestLoaderCoalescesLoads tests that loads within the window share one query.
+func TestLoaderCoalescesLoads(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": float64(1), "name": "Ada"},
+		map[string]any{"_id": float64(2), "name": "Bob"},
+	}, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	coll := client.Database("testdb").Collection("users")
+
+	loader, err := NewLoader((&LoaderOptions{}).SetWindow(10 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := []any{1, 2, 1, 3}
+	names := make([]string, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key any) {
+			defer wg.Done()
+			var doc struct {
+				Name string `json:"name"`
+			}
+			errs[i] = loader.Load(context.Background(), coll, key).Decode(&doc)
+			names[i] = doc.Name
+		}(i, key)
+	}
+
+	waitPendingKeys(t, loader, coll, 3)
+	clock.Advance(10 * time.Millisecond)
+	wg.Wait()
+
+	if !reflect.DeepEqual(names, []string{"Ada", "Bob", "Ada", ""}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+	if errs[0] != nil || !errors.Is(errs[3], ErrNoDocuments) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if mock.callIndex != 1 {
+		t.Fatalf("expected one query, got %d", mock.callIndex)
+	}
+	in := mock.calls[0].args[2].(map[string]any)["_id"].(map[string]any)["$in"].([]any)
+	if len(in) != 3 {
+		t.Errorf("expected 3 distinct keys, got %v", in)
+	}
+}
+
+// TestLoaderMaxBatch tests that a full batch is sent without waiting for the window.
+func TestLoaderMaxBatch(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "a", "sku": "x"}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("products")
+
+	loader, err := NewLoader((&LoaderOptions{}).SetWindow(time.Hour).SetMaxBatch(1).SetField("sku"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc map[string]any
+	if err := loader.Load(context.Background(), coll, "x").Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc["_id"] != "a" {
+		t.Errorf("unexpected document: %v", doc)
+	}
+	if _, ok := mock.calls[0].args[2].(map[string]any)["sku"]; !ok {
+		t.Errorf("expected query on sku, got %v", mock.calls[0].args[2])
+	}
+}
+
+// TestLoaderContextCanceled tests that a caller stops waiting when its context is done.
+func TestLoaderContextCanceled(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	loader, err := NewLoader((&LoaderOptions{}).SetWindow(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := loader.Load(ctx, coll, 1).Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if _, err := NewLoader((&LoaderOptions{}).SetMaxBatch(0)); err == nil {
+		t.Error("expected error for non-positive batch size")
+	}
+}
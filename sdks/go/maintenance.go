@@ -0,0 +1,152 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+)
+
+// CollModOptions describes changes to a collection's settings. Only fields
+// that are set are changed.
+type CollModOptions struct {
+	Validator        any
+	ValidationLevel  *ValidationLevel
+	ValidationAction *ValidationAction
+	// ExpireAfterSeconds changes the TTL of the index named by TTLIndex. When
+	// TTLIndex is empty it sets the collection-level expiry of a time series
+	// or clustered collection.
+	ExpireAfterSeconds *int64
+	TTLIndex           string
+	// ChangeStreamPreAndPostImages enables or disables recording of document
+	// images for change streams.
+	ChangeStreamPreAndPostImages *bool
+}
+
+// SetValidator sets the document validator.
+func (o *CollModOptions) SetValidator(validator any) *CollModOptions {
+	o.Validator = validator
+	return o
+}
+
+// SetValidationLevel sets the validation level.
+func (o *CollModOptions) SetValidationLevel(level ValidationLevel) *CollModOptions {
+	o.ValidationLevel = &level
+	return o
+}
+
+// SetValidationAction sets the validation action.
+func (o *CollModOptions) SetValidationAction(action ValidationAction) *CollModOptions {
+	o.ValidationAction = &action
+	return o
+}
+
+// SetTTLIndexExpiry sets the expiry of a TTL index.
+func (o *CollModOptions) SetTTLIndexExpiry(index string, seconds int64) *CollModOptions {
+	o.TTLIndex = index
+	o.ExpireAfterSeconds = &seconds
+	return o
+}
+
+// SetExpireAfterSeconds sets the collection-level expiry.
+func (o *CollModOptions) SetExpireAfterSeconds(seconds int64) *CollModOptions {
+	o.ExpireAfterSeconds = &seconds
+	return o
+}
+
+// SetChangeStreamPreAndPostImages enables or disables pre- and post-images.
+func (o *CollModOptions) SetChangeStreamPreAndPostImages(enabled bool) *CollModOptions {
+	o.ChangeStreamPreAndPostImages = &enabled
+	return o
+}
+
+// command builds the collMod command for the collection.
+func (o *CollModOptions) command(name string) (map[string]any, error) {
+	cmd := map[string]any{"collMod": name}
+	if o.Validator != nil {
+		cmd["validator"] = o.Validator
+	}
+	if o.ValidationLevel != nil {
+		if err := o.ValidationLevel.validate(); err != nil {
+			return nil, err
+		}
+		cmd["validationLevel"] = string(*o.ValidationLevel)
+	}
+	if o.ValidationAction != nil {
+		if err := o.ValidationAction.validate(); err != nil {
+			return nil, err
+		}
+		cmd["validationAction"] = string(*o.ValidationAction)
+	}
+	if o.ExpireAfterSeconds != nil {
+		if o.TTLIndex != "" {
+			cmd["index"] = map[string]any{
+				"name":               o.TTLIndex,
+				"expireAfterSeconds": *o.ExpireAfterSeconds,
+			}
+		} else {
+			cmd["expireAfterSeconds"] = *o.ExpireAfterSeconds
+		}
+	}
+	if o.ChangeStreamPreAndPostImages != nil {
+		cmd["changeStreamPreAndPostImages"] = map[string]any{"enabled": *o.ChangeStreamPreAndPostImages}
+	}
+	if len(cmd) == 1 {
+		return nil, fmt.Errorf("mongo: collMod on %s has no changes", name)
+	}
+	return cmd, nil
+}
+
+// ModifyCollection changes the settings of an existing collection with the
+// collMod command.
+func (d *Database) ModifyCollection(ctx context.Context, name string, opts CollModOptions) error {
+	cmd, err := opts.command(name)
+	if err != nil {
+		return err
+	}
+	return d.RunCommand(ctx, cmd).Err()
+}
+
+// ValidationResult is the outcome of a validate command.
+type ValidationResult struct {
+	Namespace             string           `json:"ns"`
+	Valid                 bool             `json:"valid"`
+	Repaired              bool             `json:"repaired"`
+	Records               int64            `json:"nrecords"`
+	InvalidDocuments      int64            `json:"nInvalidDocuments"`
+	NonCompliantDocuments int64            `json:"nNonCompliantDocuments"`
+	Indexes               int64            `json:"nIndexes"`
+	KeysPerIndex          map[string]int64 `json:"keysPerIndex"`
+	CorruptRecords        []any            `json:"corruptRecords"`
+	Warnings              []string         `json:"warnings"`
+	Errors                []string         `json:"errors"`
+}
+
+// Validate checks the collection's data and indexes for consistency. A full
+// validation is more thorough but slower and blocks writes for its duration.
+func (c *Collection) Validate(ctx context.Context, full bool) (*ValidationResult, error) {
+	cmd := map[string]any{"validate": c.name, "full": full}
+
+	var result ValidationResult
+	if err := c.database.RunCommand(ctx, cmd).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Namespace == "" {
+		result.Namespace = c.namespace()
+	}
+	return &result, nil
+}
+
+// CompactResult is the outcome of a compact command.
+type CompactResult struct {
+	// BytesFreed is the storage reclaimed by the compaction, if reported.
+	BytesFreed int64 `json:"bytesFreed"`
+}
+
+// Compact rewrites and defragments the data and indexes of a collection to
+// release unused disk space.
+func (d *Database) Compact(ctx context.Context, coll string) (*CompactResult, error) {
+	var result CompactResult
+	if err := d.RunCommand(ctx, map[string]any{"compact": coll}).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
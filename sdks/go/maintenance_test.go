@@ -0,0 +1,153 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestDatabaseModifyCollection tests building the collMod command.
+func TestDatabaseModifyCollection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+
+	opts := (&CollModOptions{}).
+		SetValidator(map[string]any{"$jsonSchema": map[string]any{"required": []any{"email"}}}).
+		SetValidationLevel(ValidationLevelModerate).
+		SetValidationAction(ValidationActionWarn).
+		SetTTLIndexExpiry("createdAt_1", 3600).
+		SetChangeStreamPreAndPostImages(true)
+
+	if err := db.ModifyCollection(context.Background(), "users", *opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := mock.calls[0].args[1].(map[string]any)
+	if cmd["collMod"] != "users" || cmd["validationLevel"] != "moderate" || cmd["validationAction"] != "warn" {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+	if cmd["validator"] == nil {
+		t.Error("expected validator")
+	}
+	index := cmd["index"].(map[string]any)
+	if index["name"] != "createdAt_1" || index["expireAfterSeconds"] != int64(3600) {
+		t.Errorf("unexpected index change: %v", index)
+	}
+	if _, ok := cmd["expireAfterSeconds"]; ok {
+		t.Error("unexpected collection-level expiry")
+	}
+	if cmd["changeStreamPreAndPostImages"].(map[string]any)["enabled"] != true {
+		t.Errorf("unexpected pre/post images: %v", cmd["changeStreamPreAndPostImages"])
+	}
+}
+
+// TestDatabaseModifyCollectionExpiry tests a collection-level expiry change.
+func TestDatabaseModifyCollectionExpiry(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	opts := (&CollModOptions{}).SetExpireAfterSeconds(60)
+
+	if err := client.Database("testdb").ModifyCollection(context.Background(), "metrics", *opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := mock.calls[0].args[1].(map[string]any)
+	if cmd["expireAfterSeconds"] != int64(60) || cmd["index"] != nil {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+}
+
+// TestDatabaseModifyCollectionErrors tests empty changes and command failures.
+func TestDatabaseModifyCollectionErrors(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, errors.New("ns not found"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("testdb")
+	ctx := context.Background()
+
+	if err := db.ModifyCollection(ctx, "users", CollModOptions{}); err == nil {
+		t.Error("expected error for empty changes")
+	}
+	if len(mock.calls) != 1 || mock.callIndex != 0 {
+		t.Error("expected no command to be sent")
+	}
+
+	if err := db.ModifyCollection(ctx, "users", *(&CollModOptions{}).SetValidationLevel(ValidationLevelOff)); err == nil {
+		t.Error("expected command error")
+	}
+}
+
+// TestCollectionValidate tests parsing a validate result.
+func TestCollectionValidate(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{
+		"ns":                "testdb.users",
+		"valid":             false,
+		"nrecords":          float64(100),
+		"nInvalidDocuments": float64(2),
+		"nIndexes":          float64(2),
+		"keysPerIndex":      map[string]any{"_id_": float64(100), "email_1": float64(98)},
+		"warnings":          []any{"index email_1 is missing keys"},
+		"errors":            []any{"2 documents fail validation"},
+		"ok":                float64(1),
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	result, err := coll.Validate(context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd := mock.calls[0].args[1].(map[string]any)
+	if cmd["validate"] != "users" || cmd["full"] != true {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+
+	if result.Valid || result.Records != 100 || result.InvalidDocuments != 2 || result.Indexes != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.KeysPerIndex["email_1"] != 98 {
+		t.Errorf("unexpected keys per index: %v", result.KeysPerIndex)
+	}
+	if len(result.Warnings) != 1 || len(result.Errors) != 1 {
+		t.Errorf("unexpected warnings/errors: %v %v", result.Warnings, result.Errors)
+	}
+}
+
+// TestCollectionValidateError tests a failing validate command.
+func TestCollectionValidateError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", nil, errors.New("ns not found"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if _, err := client.Database("testdb").Collection("users").Validate(context.Background(), false); err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestDatabaseCompact tests the compact wrapper.
+func TestDatabaseCompact(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.runCommand", map[string]any{"bytesFreed": float64(4096), "ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	result, err := client.Database("testdb").Compact(context.Background(), "users")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.BytesFreed != 4096 {
+		t.Errorf("expected 4096 bytes freed, got %d", result.BytesFreed)
+	}
+	if cmd := mock.calls[0].args[1].(map[string]any); cmd["compact"] != "users" {
+		t.Errorf("unexpected command: %v", cmd)
+	}
+}
@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go.mongo.do/bson"
+)
+
+var (
+	bsonMarshalerType   = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	bsonUnmarshalerType = reflect.TypeOf((*bson.Unmarshaler)(nil)).Elem()
+)
+
+// hasBSONCodec reports whether t, or a pointer to it, implements
+// bson.Marshaler or bson.Unmarshaler.
+func hasBSONCodec(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Implements(bsonMarshalerType) || pt.Implements(bsonMarshalerType) ||
+		t.Implements(bsonUnmarshalerType) || pt.Implements(bsonUnmarshalerType)
+}
+
+// asBSONMarshaler returns v as a bson.Marshaler when its type, or a
+// pointer to it, implements the interface.
+func asBSONMarshaler(v reflect.Value) (bson.Marshaler, bool) {
+	switch {
+	case (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil():
+		return nil, false
+	case v.Type().Implements(bsonMarshalerType):
+		return v.Interface().(bson.Marshaler), true
+	case reflect.PointerTo(v.Type()).Implements(bsonMarshalerType):
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface().(bson.Marshaler), true
+	}
+	return nil, false
+}
+
+// marshaledDocument returns the document m builds. A failure is returned as
+// a value whose JSON encoding fails, so it surfaces when the document is
+// sent.
+func marshaledDocument(m bson.Marshaler) any {
+	data, err := m.MarshalBSON()
+	if err != nil {
+		return marshalBSONError{err}
+	}
+	if isBSONDocument(data) {
+		doc, err := unmarshalBSON(data)
+		if err != nil {
+			return marshalBSONError{err}
+		}
+		return doc
+	}
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return marshalBSONError{err}
+	}
+	return doc
+}
+
+// isBSONDocument reports whether data is framed as a binary BSON document:
+// a little-endian length equal to its size, and a final NUL byte. JSON
+// text cannot be, as it has no NUL bytes.
+func isBSONDocument(data []byte) bool {
+	return len(data) >= 5 && int(binary.LittleEndian.Uint32(data)) == len(data) && data[len(data)-1] == 0
+}
+
+// marshalBSONError carries the error of a failed MarshalBSON call until the
+// document holding it is encoded.
+type marshalBSONError struct {
+	err error
+}
+
+// MarshalJSON returns the MarshalBSON error.
+func (e marshalBSONError) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("mongo: MarshalBSON: %w", e.err)
+}
+
+// unmarshalBSONValue passes src, a generically decoded document, to the
+// UnmarshalBSON method of dst as Extended JSON.
+func unmarshalBSONValue(src any, dst bson.Unmarshaler) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return dst.UnmarshalBSON(data)
+}
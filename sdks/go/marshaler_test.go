@@ -0,0 +1,104 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// geoPoint stores itself as a GeoJSON point.
+type geoPoint struct {
+	Lng, Lat float64
+}
+
+func (p geoPoint) MarshalBSON() ([]byte, error) {
+	return bson.MarshalExtJSON(bson.D{{Key: "type", Value: "Point"}, {Key: "coordinates", Value: bson.A{p.Lng, p.Lat}}}, false, false)
+}
+
+func (p *geoPoint) UnmarshalBSON(data []byte) error {
+	coords, ok := bson.Raw(data).Lookup("coordinates").ArrayOK()
+	if !ok || len(coords) != 2 {
+		return fmt.Errorf("not a point: %s", data)
+	}
+	p.Lng, _ = coords[0].DoubleOK()
+	p.Lat, _ = coords[1].DoubleOK()
+	return nil
+}
+
+type place struct {
+	ID       string   `json:"_id"`
+	Location geoPoint `json:"location"`
+}
+
+// binaryDoc marshals itself as binary BSON, as types written for the
+// official driver do.
+type binaryDoc struct{ N int }
+
+func (d binaryDoc) MarshalBSON() ([]byte, error) {
+	return marshalBSON(map[string]any{"n": d.N})
+}
+
+type failingDoc struct{}
+
+var errMarshal = errors.New("cannot marshal")
+
+func (failingDoc) MarshalBSON() ([]byte, error) { return nil, errMarshal }
+
+// TestBSONMarshaler tests encoding values that build their own documents.
+func TestBSONMarshaler(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "p1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("places")
+
+	if _, err := coll.InsertOne(context.Background(), place{ID: "p1", Location: geoPoint{Lng: 1.5, Lat: 2.5}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := mock.calls[0].args[2].(map[string]any)
+	data, err := json.Marshal(doc["location"])
+	if err != nil || string(data) != `{"type":"Point","coordinates":[1.5,2.5]}` {
+		t.Errorf("unexpected location %s (%v)", data, err)
+	}
+
+	encoded := encodeDates(map[string]any{"doc": binaryDoc{N: 7}})
+	if n := encoded.(map[string]any)["doc"].(map[string]any)["n"]; n != float64(7) {
+		t.Errorf("expected the binary BSON document, got %v", encoded)
+	}
+
+	_, err = json.Marshal(encodeDates(map[string]any{"doc": failingDoc{}}))
+	if !errors.Is(err, errMarshal) {
+		t.Errorf("expected the MarshalBSON error, got %v", err)
+	}
+}
+
+// TestBSONUnmarshaler tests decoding values that decode themselves.
+func TestBSONUnmarshaler(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{
+		"_id":      "p1",
+		"location": map[string]any{"type": "Point", "coordinates": []any{1.5, 2.5}},
+	}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"type": "Point", "coordinates": []any{3.5, 4.5}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("places")
+	ctx := context.Background()
+
+	var p place
+	if err := coll.FindOne(ctx, bson.M{}).Decode(&p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.ID != "p1" || p.Location != (geoPoint{Lng: 1.5, Lat: 2.5}) {
+		t.Errorf("unexpected place: %+v", p)
+	}
+
+	var point geoPoint
+	if err := coll.FindOne(ctx, bson.M{}).Decode(&point); err != nil || point != (geoPoint{Lng: 3.5, Lat: 4.5}) {
+		t.Errorf("unexpected point %+v (%v)", point, err)
+	}
+}
@@ -0,0 +1,344 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMaterializedViewMetadata is the collection, in the target
+// database, that records when each materialized view was last refreshed.
+const DefaultMaterializedViewMetadata = "materializedViews"
+
+// DefaultMaterializedViewPollInterval is how often Run checks the source
+// change stream for events when refreshing on change.
+const DefaultMaterializedViewPollInterval = time.Second
+
+// MaterializedViewOptions configures a MaterializedView.
+type MaterializedViewOptions struct {
+	// On lists the fields identifying output documents for $merge; the
+	// server defaults to _id.
+	On []string
+	// WhenMatched is the $merge action for output documents that already
+	// exist: "replace" (the default), "keepExisting", "merge" or "fail".
+	WhenMatched *string
+	// Interval makes Run do a full refresh this often.
+	Interval *time.Duration
+	// OnChange makes Run refresh when the source collection changes,
+	// checking its change stream every PollInterval.
+	OnChange     *bool
+	PollInterval *time.Duration
+	// Incremental returns a filter selecting the source documents affected
+	// by events, so a change-triggered refresh recomputes only their output.
+	// It runs as a $match stage ahead of the pipeline. Returning nil, or
+	// leaving Incremental unset, refreshes in full.
+	Incremental func(events []*ChangeEvent) any
+	// MetadataCollection overrides DefaultMaterializedViewMetadata.
+	MetadataCollection *string
+}
+
+// SetOn sets the fields identifying output documents.
+func (o *MaterializedViewOptions) SetOn(fields ...string) *MaterializedViewOptions {
+	o.On = fields
+	return o
+}
+
+// SetWhenMatched sets the $merge action for existing output documents.
+func (o *MaterializedViewOptions) SetWhenMatched(action string) *MaterializedViewOptions {
+	o.WhenMatched = &action
+	return o
+}
+
+// SetInterval sets the interval between full refreshes.
+func (o *MaterializedViewOptions) SetInterval(d time.Duration) *MaterializedViewOptions {
+	o.Interval = &d
+	return o
+}
+
+// SetOnChange sets whether changes to the source trigger a refresh.
+func (o *MaterializedViewOptions) SetOnChange(onChange bool) *MaterializedViewOptions {
+	o.OnChange = &onChange
+	return o
+}
+
+// SetPollInterval sets how often the source change stream is checked.
+func (o *MaterializedViewOptions) SetPollInterval(d time.Duration) *MaterializedViewOptions {
+	o.PollInterval = &d
+	return o
+}
+
+// SetIncremental sets the function selecting the source documents to
+// recompute after changes.
+func (o *MaterializedViewOptions) SetIncremental(fn func(events []*ChangeEvent) any) *MaterializedViewOptions {
+	o.Incremental = fn
+	return o
+}
+
+// SetMetadataCollection sets the collection recording refreshes.
+func (o *MaterializedViewOptions) SetMetadataCollection(name string) *MaterializedViewOptions {
+	o.MetadataCollection = &name
+	return o
+}
+
+// MaterializedViewStatus records the last refresh of a materialized view.
+type MaterializedViewStatus struct {
+	// View is the namespace of the output collection.
+	View   string `json:"_id"`
+	Source string `json:"source"`
+	// RefreshedAt is when the last successful refresh started; the output
+	// reflects the source as of then. It is zero if the view was never
+	// refreshed.
+	RefreshedAt time.Time `json:"refreshedAt"`
+	DurationMS  int64     `json:"durationMS"`
+	Incremental bool      `json:"incremental"`
+	// LastError is the error of the last refresh, if it failed.
+	LastError   string    `json:"lastError,omitempty"`
+	LastErrorAt time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Staleness returns how long ago, as of now, the last successful refresh
+// started.
+func (s *MaterializedViewStatus) Staleness(now time.Time) time.Duration {
+	return now.Sub(s.RefreshedAt)
+}
+
+// IsStale reports whether the view was never refreshed, or last refreshed
+// more than maxAge before now.
+func (s *MaterializedViewStatus) IsStale(now time.Time, maxAge time.Duration) bool {
+	return s.RefreshedAt.IsZero() || s.Staleness(now) > maxAge
+}
+
+// MaterializedView keeps a collection filled with the output of an
+// aggregation over another, written with $merge, for dashboards that need
+// precomputed aggregates. $merge only inserts and updates, so output
+// documents whose source data is gone remain until removed.
+type MaterializedView struct {
+	source   *Collection
+	target   *Collection
+	pipeline []any
+	options  MaterializedViewOptions
+	metadata *Collection
+}
+
+// NewMaterializedView creates a view filling target with the output of
+// pipeline over source. The pipeline must not write its output itself.
+func NewMaterializedView(source, target *Collection, pipeline any, opts ...*MaterializedViewOptions) (*MaterializedView, error) {
+	stages, ok := pipelineStages(pipeline)
+	if !ok {
+		return nil, fmt.Errorf("%w: materialized view pipeline must be a list of stages, got %T", ErrInvalidOption, pipeline)
+	}
+	if n := len(stages); n > 0 {
+		if name := stageName(stages[n-1]); name == "$merge" || name == "$out" {
+			return nil, fmt.Errorf("%w: materialized view pipeline must not end with %s", ErrInvalidOption, name)
+		}
+	}
+
+	options := MaterializedViewOptions{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.On != nil {
+			options.On = opt.On
+		}
+		if opt.WhenMatched != nil {
+			options.WhenMatched = opt.WhenMatched
+		}
+		if opt.Interval != nil {
+			options.Interval = opt.Interval
+		}
+		if opt.OnChange != nil {
+			options.OnChange = opt.OnChange
+		}
+		if opt.PollInterval != nil {
+			options.PollInterval = opt.PollInterval
+		}
+		if opt.Incremental != nil {
+			options.Incremental = opt.Incremental
+		}
+		if opt.MetadataCollection != nil {
+			options.MetadataCollection = opt.MetadataCollection
+		}
+	}
+
+	metadata := DefaultMaterializedViewMetadata
+	if options.MetadataCollection != nil {
+		metadata = *options.MetadataCollection
+	}
+	return &MaterializedView{
+		source:   source,
+		target:   target,
+		pipeline: stages,
+		options:  options,
+		metadata: target.database.Collection(metadata),
+	}, nil
+}
+
+// Refresh recomputes the whole view.
+func (v *MaterializedView) Refresh(ctx context.Context) error {
+	return v.refresh(ctx, nil)
+}
+
+// refresh runs the pipeline into the target, restricted to the source
+// documents matching filter if it is not nil, and records the outcome.
+func (v *MaterializedView) refresh(ctx context.Context, filter any) error {
+	clock := v.source.database.client.clock
+	started := clock.Now()
+
+	pipeline := make([]any, 0, len(v.pipeline)+2)
+	if filter != nil {
+		pipeline = append(pipeline, map[string]any{"$match": filter})
+	}
+	pipeline = append(pipeline, v.pipeline...)
+	pipeline = append(pipeline, map[string]any{"$merge": v.mergeSpec()})
+
+	// The output is written by $merge, so no results are buffered and no
+	// $limit may follow it.
+	cursor, err := v.source.Aggregate(ctx, pipeline, (&AggregateOptions{}).SetMaxBufferedDocuments(0))
+	if err == nil {
+		err = cursor.Close(ctx)
+	}
+
+	var update map[string]any
+	if err != nil {
+		update = map[string]any{
+			"$set": map[string]any{
+				"source":      v.source.namespace(),
+				"lastError":   err.Error(),
+				"lastErrorAt": clock.Now(),
+			},
+		}
+	} else {
+		update = map[string]any{
+			"$set": map[string]any{
+				"source":      v.source.namespace(),
+				"refreshedAt": started,
+				"durationMS":  clock.Now().Sub(started).Milliseconds(),
+				"incremental": filter != nil,
+			},
+			"$unset": map[string]any{"lastError": "", "lastErrorAt": ""},
+		}
+	}
+	_, recordErr := v.metadata.UpdateOne(ctx, map[string]any{"_id": v.target.namespace()}, update, (&UpdateOptions{}).SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("refreshing %s: %w", v.target.namespace(), err)
+	}
+	if recordErr != nil {
+		return fmt.Errorf("recording refresh of %s: %w", v.target.namespace(), recordErr)
+	}
+	return nil
+}
+
+// mergeSpec returns the $merge stage specification.
+func (v *MaterializedView) mergeSpec() map[string]any {
+	into := any(v.target.name)
+	if v.target.database.name != v.source.database.name {
+		into = map[string]any{"db": v.target.database.name, "coll": v.target.name}
+	}
+	whenMatched := "replace"
+	if v.options.WhenMatched != nil {
+		whenMatched = *v.options.WhenMatched
+	}
+	spec := map[string]any{
+		"into":           into,
+		"whenMatched":    whenMatched,
+		"whenNotMatched": "insert",
+	}
+	if len(v.options.On) > 0 {
+		spec["on"] = v.options.On
+	}
+	return spec
+}
+
+// Status returns the recorded state of the view's last refresh, which may
+// have been made by another process. A view never refreshed has a zero
+// RefreshedAt.
+func (v *MaterializedView) Status(ctx context.Context) (*MaterializedViewStatus, error) {
+	status := &MaterializedViewStatus{View: v.target.namespace(), Source: v.source.namespace()}
+	err := v.metadata.FindOne(ctx, map[string]any{"_id": v.target.namespace()}).Decode(status)
+	if err != nil && !errors.Is(err, ErrNoDocuments) {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Run refreshes the view in full, then keeps it up to date on the
+// configured schedule: a full refresh every Interval, and a refresh after
+// changes to the source when OnChange is set. It returns when ctx is done
+// or a refresh fails; the failure is recorded in the view's status first.
+func (v *MaterializedView) Run(ctx context.Context) error {
+	var interval time.Duration
+	if v.options.Interval != nil {
+		interval = *v.options.Interval
+	}
+	onChange := v.options.OnChange != nil && *v.options.OnChange
+	if interval <= 0 && !onChange {
+		return fmt.Errorf("%w: materialized view requires an Interval or OnChange to run", ErrInvalidOption)
+	}
+
+	var stream *ChangeStream
+	if onChange {
+		// Open the stream first, so changes made during the initial
+		// refresh trigger another.
+		var err error
+		if stream, err = v.source.Watch(ctx, []any{}); err != nil {
+			return err
+		}
+		defer stream.Close(ctx)
+	}
+
+	clock := v.source.database.client.clock
+	if err := v.Refresh(ctx); err != nil {
+		return err
+	}
+	last := clock.Now()
+
+	wait := interval
+	if onChange {
+		wait = DefaultMaterializedViewPollInterval
+		if v.options.PollInterval != nil && *v.options.PollInterval > 0 {
+			wait = *v.options.PollInterval
+		}
+	}
+	for {
+		select {
+		case <-clock.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		var events []*ChangeEvent
+		if stream != nil {
+			for stream.Next(ctx) {
+				events = append(events, stream.Current())
+			}
+			if err := stream.Err(); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch {
+		case interval > 0 && clock.Now().Sub(last) >= interval:
+			err = v.Refresh(ctx)
+		case len(events) > 0:
+			var filter any
+			if v.options.Incremental != nil {
+				err = v.source.database.client.runCallback("MaterializedView Incremental", func() error {
+					filter = v.options.Incremental(events)
+					return nil
+				})
+			}
+			if err == nil {
+				err = v.refresh(ctx, filter)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		last = clock.Now()
+	}
+}
@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestMaterializedViewRefresh tests refreshing a view and recording its
+// status.
+func TestMaterializedViewRefresh(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(0), "upsertedCount": float64(1)}, nil)
+	mock.addCall("mongo.aggregate", nil, errors.New("boom"))
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+	mock.addCall("mongo.findOne", map[string]any{
+		"_id":         "shop.daily",
+		"source":      "shop.orders",
+		"refreshedAt": "2024-01-01T00:00:00Z",
+		"lastError":   "boom",
+	}, nil)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := newFakeClock(now)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	db := client.Database("shop")
+	ctx := context.Background()
+
+	group := map[string]any{"$group": map[string]any{"_id": "$day", "total": map[string]any{"$sum": "$amount"}}}
+	view, err := NewMaterializedView(db.Collection("orders"), db.Collection("daily"), []any{group})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := view.Refresh(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []any{group, map[string]any{"$merge": map[string]any{"into": "daily", "whenMatched": "replace", "whenNotMatched": "insert"}}}
+	if got := mock.calls[0].args[2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected pipeline %v", got)
+	}
+	if coll := mock.calls[1].args[1]; coll != DefaultMaterializedViewMetadata {
+		t.Errorf("expected the status in %s, got %v", DefaultMaterializedViewMetadata, coll)
+	}
+	set := mock.calls[1].args[3].(map[string]any)["$set"].(map[string]any)
+	if set["refreshedAt"] != now || set["incremental"] != false {
+		t.Errorf("unexpected status update %v", set)
+	}
+
+	if err := view.Refresh(ctx); err == nil || err.Error() != "refreshing shop.daily: boom" {
+		t.Errorf("expected the refresh error, got %v", err)
+	}
+	if set := mock.calls[3].args[3].(map[string]any)["$set"].(map[string]any); set["lastError"] != "boom" {
+		t.Errorf("expected the error to be recorded, got %v", set)
+	}
+
+	status, err := view.Status(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !status.RefreshedAt.Equal(now) || status.LastError != "boom" {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.IsStale(now.Add(time.Minute), time.Hour) || !status.IsStale(now.Add(2*time.Hour), time.Hour) {
+		t.Errorf("unexpected staleness %v", status.Staleness(now.Add(time.Minute)))
+	}
+
+	if _, err := NewMaterializedView(db.Collection("orders"), db.Collection("daily"), []any{map[string]any{"$out": "daily"}}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption for an $out pipeline, got %v", err)
+	}
+}
+
+// TestMaterializedViewRunOnChange tests incremental refreshes triggered by
+// source changes.
+func TestMaterializedViewRunOnChange(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           "change-1",
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"_id": "o1", "day": "2024-01-01"},
+	}, nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.aggregate", []any{}, nil)
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	db := client.Database("shop")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := (&MaterializedViewOptions{}).SetOnChange(true).SetIncremental(func(events []*ChangeEvent) any {
+		days := make([]any, len(events))
+		for i, e := range events {
+			days[i] = e.Document()["day"]
+		}
+		return map[string]any{"day": map[string]any{"$in": days}}
+	})
+	view, err := NewMaterializedView(db.Collection("orders"), db.Collection("daily"), []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- view.Run(ctx) }()
+	for polls := 0; polls < 2; polls++ {
+		waitTimers(t, clock, 1)
+		clock.Advance(DefaultMaterializedViewPollInterval)
+	}
+	waitTimers(t, clock, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Run to stop with the context, got %v", err)
+	}
+
+	match := mock.calls[5].args[2].([]any)[0]
+	want := map[string]any{"$match": map[string]any{"day": map[string]any{"$in": []any{"2024-01-01"}}}}
+	if !reflect.DeepEqual(match, want) {
+		t.Errorf("expected an incremental refresh, got %v", match)
+	}
+	if set := mock.calls[6].args[3].(map[string]any)["$set"].(map[string]any); set["incremental"] != true {
+		t.Errorf("expected the refresh to be recorded as incremental, got %v", set)
+	}
+
+	unscheduled, err := NewMaterializedView(db.Collection("orders"), db.Collection("daily"), []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := unscheduled.Run(context.Background()); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption without a schedule, got %v", err)
+	}
+	if _, err := NewMaterializedView(db.Collection("orders"), db.Collection("daily"), "not a pipeline"); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultSlackBuckets are the upper bounds of the deadline slack histogram.
+var DefaultSlackBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// DurationHistogram counts observed durations into buckets.
+// Counts[i] is the number of observations <= Bounds[i] and greater than the
+// previous bound; the final element of Counts holds observations above the
+// largest bound.
+type DurationHistogram struct {
+	Bounds []time.Duration
+	Counts []int64
+	Count  int64
+	Sum    time.Duration
+}
+
+// newDurationHistogram creates a histogram with the given bucket bounds.
+func newDurationHistogram(bounds []time.Duration) *DurationHistogram {
+	return &DurationHistogram{
+		Bounds: bounds,
+		Counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// observe records a single duration.
+func (h *DurationHistogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && d > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// Mean returns the average observed duration.
+func (h DurationHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// clone returns a deep copy of the histogram.
+func (h *DurationHistogram) clone() DurationHistogram {
+	c := *h
+	c.Bounds = append([]time.Duration(nil), h.Bounds...)
+	c.Counts = append([]int64(nil), h.Counts...)
+	return c
+}
+
+// NamespaceStats holds operation metrics for a single namespace.
+type NamespaceStats struct {
+	// Operations is the number of operations issued on the namespace.
+	Operations int64
+	// DeadlineExceeded counts operations that finished at or after their
+	// deadline, set on the context or by a timeout.
+	DeadlineExceeded int64
+	// DeadlineSlack records how much time was left before the deadline
+	// when operations with a deadline completed.
+	DeadlineSlack DurationHistogram
+}
+
+// ClientStats is a snapshot of client operation metrics.
+type ClientStats struct {
+	// Namespaces maps "db.collection" (or "db" for database-level and
+	// "admin" for client-level operations) to its metrics.
+	Namespaces map[string]NamespaceStats
+}
+
+// clientMetrics accumulates operation metrics for a client.
+type clientMetrics struct {
+	mu         sync.Mutex
+	namespaces map[string]*namespaceMetrics
+}
+
+type namespaceMetrics struct {
+	operations       int64
+	deadlineExceeded int64
+	slack            *DurationHistogram
+}
+
+// newClientMetrics creates an empty metrics accumulator.
+func newClientMetrics() *clientMetrics {
+	return &clientMetrics{namespaces: make(map[string]*namespaceMetrics)}
+}
+
+// record records the completion of an operation on ns at time now. The
+// deadline, zero for operations without one, must be on the same clock as
+// now.
+func (m *clientMetrics) record(ns string, deadline time.Time, now time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nm, ok := m.namespaces[ns]
+	if !ok {
+		nm = &namespaceMetrics{slack: newDurationHistogram(DefaultSlackBuckets)}
+		m.namespaces[ns] = nm
+	}
+	nm.operations++
+
+	if deadline.IsZero() {
+		return
+	}
+	slack := deadline.Sub(now)
+	if slack <= 0 || errors.Is(err, context.DeadlineExceeded) {
+		nm.deadlineExceeded++
+		return
+	}
+	nm.slack.observe(slack)
+}
+
+// snapshot returns a copy of the current metrics.
+func (m *clientMetrics) snapshot() ClientStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := ClientStats{Namespaces: make(map[string]NamespaceStats, len(m.namespaces))}
+	for ns, nm := range m.namespaces {
+		stats.Namespaces[ns] = NamespaceStats{
+			Operations:       nm.operations,
+			DeadlineExceeded: nm.deadlineExceeded,
+			DeadlineSlack:    nm.slack.clone(),
+		}
+	}
+	return stats
+}
+
+// Stats returns a snapshot of the client's operation metrics.
+func (c *Client) Stats() ClientStats {
+	return c.metrics.snapshot()
+}
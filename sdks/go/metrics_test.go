@@ -0,0 +1,160 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDurationHistogram tests bucketing observed durations.
+func TestDurationHistogram(t *testing.T) {
+	h := newDurationHistogram([]time.Duration{time.Second, 5 * time.Second})
+
+	h.observe(500 * time.Millisecond)
+	h.observe(time.Second)
+	h.observe(3 * time.Second)
+	h.observe(time.Minute)
+
+	want := []int64{2, 1, 1}
+	for i, n := range want {
+		if h.Counts[i] != n {
+			t.Errorf("bucket %d: expected %d, got %d", i, n, h.Counts[i])
+		}
+	}
+
+	if h.Count != 4 {
+		t.Errorf("expected count 4, got %d", h.Count)
+	}
+
+	if h.Mean() != (500*time.Millisecond+time.Second+3*time.Second+time.Minute)/4 {
+		t.Errorf("unexpected mean %v", h.Mean())
+	}
+}
+
+// slowRPCClient advances a fake clock by delay on every call.
+type slowRPCClient struct {
+	RPCClient
+	clock *fakeClock
+	delay time.Duration
+}
+
+func (s *slowRPCClient) Call(method string, args ...any) RPCPromise {
+	s.clock.Advance(s.delay)
+	return s.RPCClient.Call(method, args...)
+}
+
+// TestClientStatsDeadlineSlack tests recording deadline slack per namespace,
+// measured on the client's clock.
+func TestClientStatsDeadlineSlack(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", float64(1), nil)
+	mock.addCall("mongo.countDocuments", float64(1), nil)
+
+	clock := newFakeClock(time.Now().Add(-24 * time.Hour))
+	slow := &slowRPCClient{RPCClient: mock, clock: clock}
+
+	client := newClientWithRPC(slow, "mongodb://localhost:27017")
+	client.clock = clock
+	coll := client.Database("testdb").Collection("users")
+	ctx := WithOperationTimeout(context.Background(), time.Hour)
+
+	if _, err := coll.CountDocuments(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Complete the second operation after its deadline.
+	slow.delay = 2 * time.Hour
+	if _, err := coll.CountDocuments(ctx, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats := client.Stats().Namespaces["testdb.users"]
+	if stats.Operations != 2 {
+		t.Errorf("expected 2 operations, got %d", stats.Operations)
+	}
+
+	if stats.DeadlineExceeded != 1 {
+		t.Errorf("expected 1 deadline exceeded, got %d", stats.DeadlineExceeded)
+	}
+
+	if stats.DeadlineSlack.Count != 1 || stats.DeadlineSlack.Sum != time.Hour {
+		t.Errorf("unexpected slack histogram: %+v", stats.DeadlineSlack)
+	}
+}
+
+// TestClientStatsConnectionFailure tests that operations failing to get a
+// connection are counted.
+func TestClientStatsConnectionFailure(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	client.Disconnect(context.Background())
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("expected error for a disconnected client")
+	}
+
+	stats := client.Stats().Namespaces["admin"]
+	if stats.Operations != 1 {
+		t.Errorf("expected 1 operation, got %d", stats.Operations)
+	}
+}
+
+// TestClientStatsCanceledBeforeCall tests that expired contexts are counted.
+func TestClientStatsCanceledBeforeCall(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	if err := client.Database("testdb").Drop(ctx); err == nil {
+		t.Fatal("expected error for expired context")
+	}
+
+	stats := client.Stats().Namespaces["testdb"]
+	if stats.DeadlineExceeded != 1 {
+		t.Errorf("expected 1 deadline exceeded, got %d", stats.DeadlineExceeded)
+	}
+}
+
+// TestClientStatsWithoutDeadline tests operations without a deadline.
+func TestClientStatsWithoutDeadline(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.ping", "pong", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.Ping(WithOperationTimeout(context.Background(), 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats := client.Stats().Namespaces["admin"]
+	if stats.Operations != 1 || stats.DeadlineSlack.Count != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestClientStatsTimeouts tests that deadlines set by timeouts are recorded.
+func TestClientStatsTimeouts(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.ping", "pong", nil)
+	mock.addCall("mongo.ping", "pong", nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017",
+		DefaultClientOptions().SetTimeout(time.Hour))
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.Ping(WithOperationTimeout(context.Background(), time.Nanosecond))
+
+	stats := client.Stats().Namespaces["admin"]
+	if stats.Operations != 2 {
+		t.Errorf("expected 2 operations, got %d", stats.Operations)
+	}
+	if stats.DeadlineSlack.Count != 1 {
+		t.Errorf("expected the client timeout in the slack histogram, got %+v", stats.DeadlineSlack)
+	}
+	if stats.DeadlineExceeded != 1 {
+		t.Errorf("expected the operation timeout to be exceeded, got %d", stats.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,252 @@
+package mongotest
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	mongo "go.mongo.do"
+)
+
+// ErrChaosDropped is wrapped in the ConnectionError of calls failed by a
+// dropped ChaosTransport.
+var ErrChaosDropped = errors.New("mongotest: connection dropped by chaos transport")
+
+// DefaultReorderWindow is the longest a held response waits for a later
+// one when ChaosOptions.ReorderWindow is zero.
+const DefaultReorderWindow = 50 * time.Millisecond
+
+// ChaosOptions configures the faults a ChaosTransport injects. Each
+// probability is the chance, from 0 to 1, that a call suffers the fault.
+type ChaosOptions struct {
+	// LatencyProbability is the chance that a response is delayed by
+	// Latency, as in a latency spike.
+	LatencyProbability float64
+	Latency            time.Duration
+	// DropProbability is the chance that the connection drops on a call.
+	// The call fails with a ConnectionError, which is retryable, without
+	// reaching the server, and the transport reports itself disconnected
+	// and fails every later call, until the client dials a new connection
+	// or Heal is called.
+	DropProbability float64
+	// ReorderProbability is the chance that a response is held back until
+	// the response to a later call is delivered, or until ReorderWindow
+	// passes. Responses are only reordered among concurrent calls.
+	ReorderProbability float64
+	ReorderWindow      time.Duration
+	// Methods limits faults to the named RPC methods, such as
+	// "mongo.find". Empty means every method, including the handshake.
+	Methods []string
+	// Seed seeds the fault decisions, so a failing run can be repeated.
+	// Zero picks a random seed.
+	Seed int64
+}
+
+// SetLatency sets the chance and length of latency spikes.
+func (o *ChaosOptions) SetLatency(probability float64, latency time.Duration) *ChaosOptions {
+	o.LatencyProbability = probability
+	o.Latency = latency
+	return o
+}
+
+// SetDropProbability sets the chance that the connection drops on a call.
+func (o *ChaosOptions) SetDropProbability(probability float64) *ChaosOptions {
+	o.DropProbability = probability
+	return o
+}
+
+// SetReorder sets the chance that a response is held back, and how long
+// it waits for a later one.
+func (o *ChaosOptions) SetReorder(probability float64, window time.Duration) *ChaosOptions {
+	o.ReorderProbability = probability
+	o.ReorderWindow = window
+	return o
+}
+
+// SetMethods limits faults to the named RPC methods.
+func (o *ChaosOptions) SetMethods(methods ...string) *ChaosOptions {
+	o.Methods = methods
+	return o
+}
+
+// SetSeed sets the seed of the fault decisions.
+func (o *ChaosOptions) SetSeed(seed int64) *ChaosOptions {
+	o.Seed = seed
+	return o
+}
+
+// ChaosStats counts the faults a ChaosTransport injected.
+type ChaosStats struct {
+	Calls     int
+	Delayed   int
+	Dropped   int
+	Reordered int
+}
+
+// ChaosTransport is an RPCClient that wraps another and injects latency
+// spikes, dropped connections and reordered responses, to check timeout,
+// retry and reconnection settings under faults. Install it on a client
+// with ClientOptions.WrapTransport and Chaos:
+//
+//	opts := mongo.DefaultClientOptions().
+//		SetWrapTransport(mongotest.Chaos(&mongotest.ChaosOptions{
+//			LatencyProbability: 0.1, Latency: 2 * time.Second,
+//			DropProbability:    0.01,
+//		}))
+type ChaosTransport struct {
+	next    mongo.RPCClient
+	opts    ChaosOptions
+	methods map[string]bool
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	dropped bool
+	// held is released when the response to the next call is ready.
+	held  chan struct{}
+	stats ChaosStats
+}
+
+// NewChaosTransport returns a ChaosTransport injecting the faults of opts
+// into the calls of next.
+func NewChaosTransport(next mongo.RPCClient, opts *ChaosOptions) *ChaosTransport {
+	t := &ChaosTransport{next: next}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if len(t.opts.Methods) > 0 {
+		t.methods = make(map[string]bool, len(t.opts.Methods))
+		for _, m := range t.opts.Methods {
+			t.methods[m] = true
+		}
+	}
+	if t.opts.ReorderWindow <= 0 {
+		t.opts.ReorderWindow = DefaultReorderWindow
+	}
+	seed := t.opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.rand = rand.New(rand.NewSource(seed))
+	return t
+}
+
+// Chaos returns a function for ClientOptions.WrapTransport that wraps each
+// connection the client dials in a ChaosTransport. With a non-zero Seed,
+// each later connection uses the next seed, so a run stays repeatable
+// across reconnections.
+func Chaos(opts *ChaosOptions) func(mongo.RPCClient) mongo.RPCClient {
+	var mu sync.Mutex
+	var n int64
+	return func(next mongo.RPCClient) mongo.RPCClient {
+		mu.Lock()
+		defer mu.Unlock()
+		o := ChaosOptions{}
+		if opts != nil {
+			o = *opts
+		}
+		if o.Seed != 0 {
+			o.Seed += n
+		}
+		n++
+		return NewChaosTransport(next, &o)
+	}
+}
+
+// Call implements mongo.RPCClient, deciding the faults of the call.
+func (t *ChaosTransport) Call(method string, args ...any) mongo.RPCPromise {
+	t.mu.Lock()
+	if t.dropped {
+		t.mu.Unlock()
+		return chaosPromise(func() (any, error) { return nil, dropError() })
+	}
+	t.stats.Calls++
+	faulty := t.methods == nil || t.methods[method]
+	if faulty && t.roll(t.opts.DropProbability) {
+		t.dropped = true
+		t.stats.Dropped++
+		t.mu.Unlock()
+		return chaosPromise(func() (any, error) { return nil, dropError() })
+	}
+	var delay time.Duration
+	if faulty && t.roll(t.opts.LatencyProbability) {
+		delay = t.opts.Latency
+		t.stats.Delayed++
+	}
+	earlier := t.held
+	t.held = nil
+	var release chan struct{}
+	if faulty && t.roll(t.opts.ReorderProbability) {
+		release = make(chan struct{})
+		t.held = release
+		t.stats.Reordered++
+	}
+	window := t.opts.ReorderWindow
+	t.mu.Unlock()
+
+	promise := t.next.Call(method, args...)
+	return chaosPromise(func() (any, error) {
+		result, err := promise.Await()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		if earlier != nil {
+			close(earlier)
+		}
+		if release != nil {
+			select {
+			case <-release:
+			case <-time.After(window):
+			}
+		}
+		return result, err
+	})
+}
+
+// Close implements mongo.RPCClient.
+func (t *ChaosTransport) Close() error {
+	return t.next.Close()
+}
+
+// IsConnected implements mongo.RPCClient. It reports false once the
+// connection has dropped.
+func (t *ChaosTransport) IsConnected() bool {
+	t.mu.Lock()
+	dropped := t.dropped
+	t.mu.Unlock()
+	return !dropped && t.next.IsConnected()
+}
+
+// Heal restores a dropped connection, so later calls reach the server
+// again.
+func (t *ChaosTransport) Heal() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropped = false
+}
+
+// Stats returns the counts of calls and injected faults so far.
+func (t *ChaosTransport) Stats() ChaosStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+// roll reports whether a fault of the given probability occurs. The caller
+// holds t.mu.
+func (t *ChaosTransport) roll(probability float64) bool {
+	return probability > 0 && t.rand.Float64() < probability
+}
+
+// dropError returns the error of a call on a dropped connection.
+func dropError() error {
+	return &mongo.ConnectionError{Address: "chaos transport", Wrapped: ErrChaosDropped}
+}
+
+// chaosPromise is an RPCPromise computed by a function.
+type chaosPromise func() (any, error)
+
+// Await implements mongo.RPCPromise.
+func (p chaosPromise) Await() (any, error) {
+	return p()
+}
@@ -0,0 +1,154 @@
+package mongotest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	mongo "go.mongo.do"
+)
+
+// echoTransport answers each call with its method name.
+type echoTransport struct {
+	mu     sync.Mutex
+	calls  []string
+	closed bool
+}
+
+func (e *echoTransport) Call(method string, args ...any) mongo.RPCPromise {
+	e.mu.Lock()
+	e.calls = append(e.calls, method)
+	e.mu.Unlock()
+	return chaosPromise(func() (any, error) { return method, nil })
+}
+
+func (e *echoTransport) Close() error {
+	e.closed = true
+	return nil
+}
+
+func (e *echoTransport) IsConnected() bool { return !e.closed }
+
+// TestChaosTransportPassThrough tests that calls pass through without
+// faults.
+func TestChaosTransportPassThrough(t *testing.T) {
+	next := &echoTransport{}
+	chaos := NewChaosTransport(next, nil)
+	result, err := chaos.Call("mongo.find").Await()
+	if err != nil || result != "mongo.find" {
+		t.Fatalf("unexpected result %v (%v)", result, err)
+	}
+	if stats := chaos.Stats(); stats != (ChaosStats{Calls: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if err := chaos.Close(); err != nil || !next.closed || chaos.IsConnected() {
+		t.Error("expected Close to close the wrapped transport")
+	}
+}
+
+// TestChaosTransportDrop tests dropping the connection.
+func TestChaosTransportDrop(t *testing.T) {
+	next := &echoTransport{}
+	chaos := NewChaosTransport(next, (&ChaosOptions{}).SetDropProbability(1).SetMethods("mongo.insertOne"))
+
+	if _, err := chaos.Call("mongo.find").Await(); err != nil {
+		t.Fatalf("expected other methods to pass, got %v", err)
+	}
+	_, err := chaos.Call("mongo.insertOne").Await()
+	if !errors.Is(err, ErrChaosDropped) || !mongo.IsRetryable(err) {
+		t.Fatalf("expected a retryable drop error, got %v", err)
+	}
+	if chaos.IsConnected() {
+		t.Error("expected the transport to report the drop")
+	}
+	if _, err := chaos.Call("mongo.find").Await(); !errors.Is(err, ErrChaosDropped) {
+		t.Errorf("expected calls after a drop to fail, got %v", err)
+	}
+	if len(next.calls) != 1 {
+		t.Errorf("expected only the first call to reach the transport, got %v", next.calls)
+	}
+
+	chaos.Heal()
+	if !chaos.IsConnected() {
+		t.Error("expected Heal to restore the connection")
+	}
+	if stats := chaos.Stats(); stats.Dropped != 1 || stats.Calls != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// TestChaosTransportLatency tests delaying responses.
+func TestChaosTransportLatency(t *testing.T) {
+	chaos := NewChaosTransport(&echoTransport{}, (&ChaosOptions{}).SetLatency(1, 20*time.Millisecond))
+	start := time.Now()
+	if _, err := chaos.Call("mongo.find").Await(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected a delay of 20ms, got %v", elapsed)
+	}
+	if chaos.Stats().Delayed != 1 {
+		t.Errorf("unexpected stats %+v", chaos.Stats())
+	}
+}
+
+// TestChaosTransportReorder tests holding a response back until a later
+// one is ready.
+func TestChaosTransportReorder(t *testing.T) {
+	chaos := NewChaosTransport(&echoTransport{}, (&ChaosOptions{}).SetReorder(1, time.Second).SetMethods("first"))
+	first := chaos.Call("first")
+	second := chaos.Call("second")
+
+	done := make(chan any, 1)
+	go func() {
+		result, _ := first.Await()
+		done <- result
+	}()
+	select {
+	case result := <-done:
+		t.Fatalf("expected the first response to be held, got %v", result)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if result, err := second.Await(); err != nil || result != "second" {
+		t.Fatalf("unexpected second result %v (%v)", result, err)
+	}
+	select {
+	case result := <-done:
+		if result != "first" {
+			t.Errorf("unexpected first result %v", result)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected the first response after the second")
+	}
+	if chaos.Stats().Reordered != 1 {
+		t.Errorf("unexpected stats %+v", chaos.Stats())
+	}
+}
+
+// TestChaosSeed tests that a seed repeats the fault decisions.
+func TestChaosSeed(t *testing.T) {
+	decisions := func() []bool {
+		wrap := Chaos((&ChaosOptions{}).SetDropProbability(0.5).SetSeed(42))
+		var dropped []bool
+		for i := 0; i < 20; i++ {
+			_, err := wrap(&echoTransport{}).Call("mongo.find").Await()
+			dropped = append(dropped, err != nil)
+		}
+		return dropped
+	}
+	a, b := decisions(), decisions()
+	drops := 0
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("expected the same decisions, got %v and %v", a, b)
+		}
+		if a[i] {
+			drops++
+		}
+	}
+	if drops == 0 || drops == len(a) {
+		t.Errorf("expected connections to vary, got %v", a)
+	}
+}
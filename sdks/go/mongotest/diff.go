@@ -0,0 +1,360 @@
+package mongotest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongo.do/bson"
+)
+
+// anyDate stands in for every date when dates are ignored.
+const anyDate = "<any>"
+
+// config is the merged form of AssertOptions.
+type config struct {
+	ignore      [][]string
+	ignoreDates bool
+	unordered   bool
+	filter      any
+	sort        any
+}
+
+// mergeOptions merges opts, later options taking precedence and ignored
+// fields accumulating.
+func mergeOptions(opts []*AssertOptions) config {
+	var cfg config
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		for _, path := range opt.IgnoreFields {
+			cfg.ignore = append(cfg.ignore, strings.Split(path, "."))
+		}
+		if opt.IgnoreDates != nil {
+			cfg.ignoreDates = *opt.IgnoreDates
+		}
+		if opt.Unordered != nil {
+			cfg.unordered = *opt.Unordered
+		}
+		if opt.Filter != nil {
+			cfg.filter = opt.Filter
+		}
+		if opt.Sort != nil {
+			cfg.sort = opt.Sort
+		}
+	}
+	return cfg
+}
+
+// Diff compares got and want, both slices of documents, and describes how
+// they differ, one line per difference. It returns "" if they are equal
+// under opts. Differences are reported by document index and JSON Pointer,
+// such as:
+//
+//	document 1 (_id 2): /name: got "Ada", want "Grace"
+//	document 2: missing, want {"name":"Alan"}
+func Diff(got, want any, opts ...*AssertOptions) (string, error) {
+	cfg := mergeOptions(opts)
+	gotDocs, err := cfg.documents(got)
+	if err != nil {
+		return "", fmt.Errorf("got: %w", err)
+	}
+	wantDocs, err := cfg.documents(want)
+	if err != nil {
+		return "", fmt.Errorf("want: %w", err)
+	}
+
+	var lines []string
+	if cfg.unordered {
+		lines = diffUnordered(gotDocs, wantDocs)
+	} else {
+		lines = diffOrdered(gotDocs, wantDocs)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if len(gotDocs) != len(wantDocs) {
+		lines = append([]string{fmt.Sprintf("got %d documents, want %d", len(gotDocs), len(wantDocs))}, lines...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// diffOrdered compares documents pairwise by position.
+func diffOrdered(got, want []any) []string {
+	var lines []string
+	for i := 0; i < len(got) || i < len(want); i++ {
+		switch {
+		case i >= len(got):
+			lines = append(lines, fmt.Sprintf("document %d: missing, want %s", i, compact(want[i])))
+		case i >= len(want):
+			lines = append(lines, fmt.Sprintf("document %d: unexpected %s", i, compact(got[i])))
+		default:
+			var diffs []string
+			diffValue(&diffs, "", got[i], want[i])
+			label := documentLabel(i, got[i])
+			for _, d := range diffs {
+				lines = append(lines, label+": "+d)
+			}
+		}
+	}
+	return lines
+}
+
+// diffUnordered pairs each wanted document with an equal one found, and
+// reports the documents left over on either side.
+func diffUnordered(got, want []any) []string {
+	used := make([]bool, len(got))
+	var lines []string
+	for i, w := range want {
+		matched := false
+		for j, g := range got {
+			if !used[j] && reflect.DeepEqual(g, w) {
+				used[j], matched = true, true
+				break
+			}
+		}
+		if !matched {
+			lines = append(lines, fmt.Sprintf("want document %d: missing %s", i, compact(w)))
+		}
+	}
+	for j, g := range got {
+		if !used[j] {
+			lines = append(lines, fmt.Sprintf("got document %d: unexpected %s", j, compact(g)))
+		}
+	}
+	return lines
+}
+
+// documentLabel names document i, with its _id when it has one.
+func documentLabel(i int, doc any) string {
+	if m, ok := doc.(map[string]any); ok {
+		if id, ok := m["_id"]; ok {
+			return fmt.Sprintf("document %d (_id %s)", i, compact(id))
+		}
+	}
+	return fmt.Sprintf("document %d", i)
+}
+
+// diffValue appends a line to diffs for each difference between got and
+// want at or below path.
+func diffValue(diffs *[]string, path string, got, want any) {
+	gotMap, gotIsMap := got.(map[string]any)
+	wantMap, wantIsMap := want.(map[string]any)
+	if gotIsMap && wantIsMap {
+		keys := make([]string, 0, len(gotMap)+len(wantMap))
+		for k := range gotMap {
+			keys = append(keys, k)
+		}
+		for k := range wantMap {
+			if _, ok := gotMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escapePointer(k)
+			g, inGot := gotMap[k]
+			w, inWant := wantMap[k]
+			switch {
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", child, compact(w)))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", child, compact(g)))
+			default:
+				diffValue(diffs, child, g, w)
+			}
+		}
+		return
+	}
+
+	gotArr, gotIsArr := got.([]any)
+	wantArr, wantIsArr := want.([]any)
+	if gotIsArr && wantIsArr {
+		for i := 0; i < len(gotArr) || i < len(wantArr); i++ {
+			child := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(gotArr):
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, want %s", child, compact(wantArr[i])))
+			case i >= len(wantArr):
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", child, compact(gotArr[i])))
+			default:
+				diffValue(diffs, child, gotArr[i], wantArr[i])
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		if path == "" {
+			path = "/"
+		}
+		*diffs = append(*diffs, fmt.Sprintf("%s: got %s, want %s", path, compact(got), compact(want)))
+	}
+}
+
+// documents encodes v, a slice of documents, to relaxed Extended JSON and
+// returns its documents normalized for comparison.
+func (cfg config) documents(v any) ([]any, error) {
+	data, err := bson.MarshalExtJSON(v, false, false)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	docs, ok := decoded.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a slice of documents, got %T", v)
+	}
+	for i, doc := range docs {
+		if _, ok := doc.(map[string]any); !ok {
+			return nil, fmt.Errorf("element %d is not a document: %s", i, compact(doc))
+		}
+		docs[i] = cfg.normalize(doc, nil)
+	}
+	return docs, nil
+}
+
+// normalize returns v, found at path, in a canonical form, so values that
+// are equal in the database compare equal with reflect.DeepEqual: numbers
+// are written the same way whatever their type, dates in UTC with
+// milliseconds, and UUIDs as $uuid. Ignored fields are dropped.
+func (cfg config) normalize(v any, path []string) any {
+	switch v := v.(type) {
+	case json.Number:
+		return canonicalNumber(v)
+	case []any:
+		out := make([]any, 0, len(v))
+		for i, elem := range v {
+			child := append(append([]string(nil), path...), strconv.Itoa(i))
+			out = append(out, cfg.normalize(elem, child))
+		}
+		return out
+	case map[string]any:
+		if len(v) == 1 {
+			if out, ok := cfg.normalizeWrapper(v); ok {
+				return out
+			}
+		}
+		out := make(map[string]any, len(v))
+		for k, elem := range v {
+			child := append(append([]string(nil), path...), k)
+			if cfg.ignored(child) {
+				continue
+			}
+			out[k] = cfg.normalize(elem, child)
+		}
+		return out
+	}
+	return v
+}
+
+// normalizeWrapper normalizes an Extended JSON wrapper, reporting false
+// if m is not one it rewrites.
+func (cfg config) normalizeWrapper(m map[string]any) (any, bool) {
+	if date, ok := m["$date"]; ok {
+		if cfg.ignoreDates {
+			return map[string]any{"$date": anyDate}, true
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, false
+		}
+		var d bson.DateTime
+		if err := d.UnmarshalJSON(data); err != nil {
+			return map[string]any{"$date": date}, true
+		}
+		return map[string]any{"$date": d.Time().UTC().Format("2006-01-02T15:04:05.000Z")}, true
+	}
+	for _, key := range []string{"$numberInt", "$numberLong", "$numberDouble"} {
+		if s, ok := m[key].(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+				return m, true
+			}
+			return canonicalNumber(json.Number(s)), true
+		}
+	}
+	if _, ok := m["$binary"]; ok {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, false
+		}
+		var b bson.Binary
+		if err := b.UnmarshalJSON(data); err != nil {
+			return nil, false
+		}
+		if u, err := b.UUID(); err == nil && b.Subtype == bson.BinaryUUID {
+			return map[string]any{"$uuid": u.String()}, true
+		}
+		return map[string]any{"$binary": map[string]any{
+			"base64":  base64.StdEncoding.EncodeToString(b.Data),
+			"subType": fmt.Sprintf("%02x", b.Subtype),
+		}}, true
+	}
+	if s, ok := m["$uuid"].(string); ok {
+		if u, err := bson.ParseUUID(s); err == nil {
+			return map[string]any{"$uuid": u.String()}, true
+		}
+	}
+	return nil, false
+}
+
+// ignored reports whether path matches an ignored field.
+func (cfg config) ignored(path []string) bool {
+	for _, pattern := range cfg.ignore {
+		if len(pattern) != len(path) {
+			continue
+		}
+		match := true
+		for i, part := range pattern {
+			if part != "*" && part != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalNumber writes n so numbers of equal value are equal: whole
+// values that a double holds exactly as integers, others in shortest form.
+func canonicalNumber(n json.Number) json.Number {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return json.Number(strconv.FormatInt(i, 10))
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return n
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// compact returns v as compact JSON for messages.
+func compact(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// escapePointer escapes a key for use in a JSON Pointer.
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
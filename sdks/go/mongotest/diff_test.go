@@ -0,0 +1,124 @@
+package mongotest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// TestDiffEqual tests values that are stored alike comparing equal.
+func TestDiffEqual(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	u, err := bson.ParseUUID("6f1c2a9e-4b7d-4e0a-9d5c-3a2b1c0d9e8f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []any{map[string]any{
+		"count": map[string]any{"$numberLong": "3"},
+		"ratio": 2.0,
+		"at":    map[string]any{"$date": map[string]any{"$numberLong": "1714557600000"}},
+		"key":   map[string]any{"$binary": map[string]any{"base64": "bxwqnkt9TgqdXDorHA2ejw==", "subType": "04"}},
+	}}
+	want := []bson.M{{"count": int32(3), "ratio": 2, "at": when, "key": u}}
+
+	diff, err := Diff(got, want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected no difference, got:\n%s", diff)
+	}
+}
+
+// TestDiffReportsPaths tests the lines describing differences.
+func TestDiffReportsPaths(t *testing.T) {
+	got := []any{
+		map[string]any{"_id": 1, "name": "Ada", "tags": []any{"a", "b"}, "extra": true},
+		map[string]any{"_id": 2, "name": "Grace"},
+	}
+	want := []any{
+		map[string]any{"_id": 1, "name": "Bob", "tags": []any{"a"}, "age": 36},
+		map[string]any{"_id": 2, "name": "Grace"},
+		map[string]any{"_id": 3, "name": "Alan"},
+	}
+
+	diff, err := Diff(got, want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := strings.Join([]string{
+		"got 2 documents, want 3",
+		`document 0 (_id 1): /age: missing, want 36`,
+		`document 0 (_id 1): /extra: unexpected true`,
+		`document 0 (_id 1): /name: got "Ada", want "Bob"`,
+		`document 0 (_id 1): /tags/1: unexpected "b"`,
+		`document 2: missing, want {"_id":3,"name":"Alan"}`,
+	}, "\n")
+	if diff != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, diff)
+	}
+}
+
+// TestDiffIgnore tests ignored fields and dates.
+func TestDiffIgnore(t *testing.T) {
+	got := []any{map[string]any{
+		"_id":   bson.NewObjectID(),
+		"name":  "Ada",
+		"audit": map[string]any{"by": "root", "at": time.Now()},
+		"items": []any{map[string]any{"sku": "x", "addedAt": 5}},
+		"seen":  time.Now(),
+	}}
+	want := []any{map[string]any{
+		"name":  "Ada",
+		"audit": map[string]any{"by": "root"},
+		"items": []any{map[string]any{"sku": "x"}},
+		"seen":  time.Unix(0, 0),
+	}}
+	opts := (&AssertOptions{}).
+		SetIgnoreFields("_id", "audit.at", "items.*.addedAt").
+		SetIgnoreDates(true)
+
+	diff, err := Diff(got, want, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected no difference, got:\n%s", diff)
+	}
+
+	delete(got[0].(map[string]any), "seen")
+	diff, err = Diff(got, want, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != `document 0: /seen: missing, want {"$date":"<any>"}` {
+		t.Errorf("expected a missing date, got:\n%s", diff)
+	}
+}
+
+// TestDiffUnordered tests matching documents regardless of order.
+func TestDiffUnordered(t *testing.T) {
+	got := []any{map[string]any{"n": 2}, map[string]any{"n": 1}, map[string]any{"n": 4}}
+	want := []any{map[string]any{"n": 1}, map[string]any{"n": 2}, map[string]any{"n": 3}}
+
+	diff, err := Diff(got, want, (&AssertOptions{}).SetUnordered(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "want document 2: missing {\"n\":3}\ngot document 2: unexpected {\"n\":4}"
+	if diff != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, diff)
+	}
+}
+
+// TestDiffInvalid tests inputs that are not slices of documents.
+func TestDiffInvalid(t *testing.T) {
+	if _, err := Diff(map[string]any{"a": 1}, []any{}); err == nil {
+		t.Error("expected an error for a document")
+	}
+	if _, err := Diff([]any{1}, []any{}); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
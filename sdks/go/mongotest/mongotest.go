@@ -0,0 +1,250 @@
+// Package mongotest checks collection contents in tests against expected
+// documents or golden fixture files, reporting differences field by field.
+//
+//	mongotest.AssertCollection(t, ctx, db.Collection("users"), []bson.M{
+//		{"name": "Ada", "role": "admin"},
+//		{"name": "Grace", "role": "user"},
+//	}, (&mongotest.AssertOptions{}).SetIgnoreFields("_id", "createdAt"))
+//
+// Documents are compared by value after both sides are encoded as relaxed
+// Extended JSON, so an int32 matches the same int64 or whole double, and a
+// time matches the date it was stored as. Fields named in IgnoreFields are
+// left out on both sides, which keeps generated _id values and timestamps
+// out of fixtures.
+//
+// Golden files hold a JSON array of documents. Run the tests with
+// -mongotest.update to rewrite them from the documents found.
+//
+// ChaosTransport injects latency spikes, dropped connections and reordered
+// responses into a client's RPC calls, to check timeout and retry settings
+// under faults.
+package mongotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+var update = flag.Bool("mongotest.update", false, "rewrite golden files with the documents found")
+
+// TB is the part of testing.TB the assertions use.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// Finder reads documents for the assertions. *mongo.Collection satisfies
+// it.
+type Finder interface {
+	Find(ctx context.Context, filter any, opts ...*mongo.FindOptions) (*mongo.Cursor, error)
+}
+
+// AssertOptions configures how documents are compared.
+type AssertOptions struct {
+	// IgnoreFields lists dotted paths left out of the comparison, such as
+	// "_id" or "audit.updatedAt". A "*" segment matches any key or array
+	// index, as in "items.*.addedAt".
+	IgnoreFields []string
+	// IgnoreDates compares every date as equal to any other date, while
+	// still requiring it to be present.
+	IgnoreDates *bool
+	// Unordered matches documents regardless of their order.
+	Unordered *bool
+	// Filter selects the documents of the collection to check. The default
+	// is every document.
+	Filter any
+	// Sort orders the documents of the collection. The default is by _id,
+	// which is insertion order for generated ObjectIDs.
+	Sort any
+}
+
+// SetIgnoreFields adds paths to leave out of the comparison.
+func (o *AssertOptions) SetIgnoreFields(paths ...string) *AssertOptions {
+	o.IgnoreFields = append(o.IgnoreFields, paths...)
+	return o
+}
+
+// SetIgnoreDates sets whether dates compare equal to any other date.
+func (o *AssertOptions) SetIgnoreDates(ignore bool) *AssertOptions {
+	o.IgnoreDates = &ignore
+	return o
+}
+
+// SetUnordered sets whether document order is ignored.
+func (o *AssertOptions) SetUnordered(unordered bool) *AssertOptions {
+	o.Unordered = &unordered
+	return o
+}
+
+// SetFilter sets the filter selecting the documents to check.
+func (o *AssertOptions) SetFilter(filter any) *AssertOptions {
+	o.Filter = filter
+	return o
+}
+
+// SetSort sets the order the documents are read in.
+func (o *AssertOptions) SetSort(sort any) *AssertOptions {
+	o.Sort = sort
+	return o
+}
+
+// AssertCollection fails t unless the documents of coll equal want, a
+// slice of documents of any type that encodes to Extended JSON.
+func AssertCollection(t TB, ctx context.Context, coll Finder, want any, opts ...*AssertOptions) {
+	t.Helper()
+	got, err := FindDocuments(ctx, coll, opts...)
+	if err != nil {
+		t.Fatalf("mongotest: %v", err)
+		return
+	}
+	AssertDocuments(t, got, want, opts...)
+}
+
+// AssertDocuments fails t unless got equals want, both slices of
+// documents.
+func AssertDocuments(t TB, got, want any, opts ...*AssertOptions) {
+	t.Helper()
+	diff, err := Diff(got, want, opts...)
+	if err != nil {
+		t.Fatalf("mongotest: %v", err)
+		return
+	}
+	if diff != "" {
+		t.Errorf("mongotest: documents differ:\n%s", diff)
+	}
+}
+
+// AssertGolden fails t unless the documents of coll equal those in the
+// golden file at path. With -mongotest.update it writes the documents
+// found to path instead, without the ignored fields.
+func AssertGolden(t TB, ctx context.Context, coll Finder, path string, opts ...*AssertOptions) {
+	t.Helper()
+	got, err := FindDocuments(ctx, coll, opts...)
+	if err != nil {
+		t.Fatalf("mongotest: %v", err)
+		return
+	}
+	if *update {
+		if err := writeGolden(path, got, opts); err != nil {
+			t.Fatalf("mongotest: %v", err)
+		}
+		return
+	}
+	want, err := readDocuments(path)
+	if err != nil {
+		t.Fatalf("mongotest: %v (run with -mongotest.update to create it)", err)
+		return
+	}
+	diff, err := Diff(got, want, opts...)
+	if err != nil {
+		t.Fatalf("mongotest: %v", err)
+		return
+	}
+	if diff != "" {
+		t.Errorf("mongotest: documents differ from %s:\n%s", path, diff)
+	}
+}
+
+// ReadFixture returns the documents of the JSON array in the file at path,
+// ready to insert with InsertMany. Extended JSON wrappers, such as
+// {"$oid": ...} and {"$date": ...}, are kept as they are written.
+func ReadFixture(t TB, path string) []any {
+	t.Helper()
+	docs, err := readDocuments(path)
+	if err != nil {
+		t.Fatalf("mongotest: %v", err)
+		return nil
+	}
+	return docs
+}
+
+// FindDocuments returns the documents of coll selected and ordered by
+// opts, as AssertCollection reads them.
+func FindDocuments(ctx context.Context, coll Finder, opts ...*AssertOptions) ([]any, error) {
+	cfg := mergeOptions(opts)
+	filter := cfg.filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+	findOpts := &mongo.FindOptions{}
+	if cfg.sort != nil {
+		findOpts.SetSort(cfg.sort)
+	} else if !cfg.unordered {
+		findOpts.SetSort(bson.D{{Key: "_id", Value: 1}})
+	}
+	cursor, err := coll.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, fmt.Errorf("find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	docs := []any{}
+	for cursor.Next(ctx) {
+		doc, err := decodeJSON(cursor.Current())
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("find: %w", err)
+	}
+	return docs, nil
+}
+
+// readDocuments reads a JSON array of documents from the file at path.
+func readDocuments(path string) ([]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	docs, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a JSON array of documents", path)
+	}
+	return docs, nil
+}
+
+// writeGolden writes docs to path as an indented JSON array, without the
+// fields opts ignores.
+func writeGolden(path string, docs []any, opts []*AssertOptions) error {
+	normalized, err := mergeOptions(opts).documents(docs)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(normalized); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// decodeJSON decodes data generically, keeping numbers as json.Number.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,159 @@
+package mongotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+// fakeFinder returns fixed documents and records the query it receives.
+type fakeFinder struct {
+	docs   []any
+	err    error
+	filter any
+	opts   []*mongo.FindOptions
+}
+
+func (f *fakeFinder) Find(ctx context.Context, filter any, opts ...*mongo.FindOptions) (*mongo.Cursor, error) {
+	f.filter, f.opts = filter, opts
+	if f.err != nil {
+		return nil, f.err
+	}
+	return mongo.NewCursorFromDocuments(f.docs), nil
+}
+
+// recorder is a TB that records failures.
+type recorder struct {
+	errors []string
+	fatal  string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+}
+
+// TestAssertCollection tests comparing a collection with documents.
+func TestAssertCollection(t *testing.T) {
+	coll := &fakeFinder{docs: []any{
+		map[string]any{"_id": map[string]any{"$oid": "65f1a2b3c4d5e6f708192a3b"}, "name": "Ada"},
+		map[string]any{"_id": map[string]any{"$oid": "65f1a2b3c4d5e6f708192a3c"}, "name": "Grace"},
+	}}
+	ignoreID := (&AssertOptions{}).SetIgnoreFields("_id")
+
+	r := &recorder{}
+	AssertCollection(r, context.Background(), coll, []bson.M{{"name": "Ada"}, {"name": "Grace"}}, ignoreID)
+	if len(r.errors) != 0 || r.fatal != "" {
+		t.Errorf("expected no failure, got %v %q", r.errors, r.fatal)
+	}
+	if !reflect.DeepEqual(coll.filter, bson.M{}) {
+		t.Errorf("expected an empty filter, got %v", coll.filter)
+	}
+	if len(coll.opts) != 1 || !reflect.DeepEqual(coll.opts[0].Sort, bson.D{{Key: "_id", Value: 1}}) {
+		t.Errorf("expected a sort by _id, got %+v", coll.opts)
+	}
+
+	r = &recorder{}
+	AssertCollection(r, context.Background(), coll, []bson.M{{"name": "Ada"}, {"name": "Alan"}}, ignoreID)
+	if len(r.errors) != 1 || !strings.HasSuffix(r.errors[0], `document 1: /name: got "Grace", want "Alan"`) {
+		t.Errorf("expected a difference in document 1, got %q", r.errors)
+	}
+
+	r = &recorder{}
+	AssertCollection(r, context.Background(), coll, nil, (&AssertOptions{}).SetFilter(bson.M{"name": "Ada"}).SetUnordered(true))
+	if !reflect.DeepEqual(coll.filter, bson.M{"name": "Ada"}) {
+		t.Errorf("expected the filter to be passed, got %v", coll.filter)
+	}
+	if coll.opts[0].Sort != nil {
+		t.Errorf("expected no sort when unordered, got %v", coll.opts[0].Sort)
+	}
+}
+
+// TestAssertCollectionFindError tests a failing query.
+func TestAssertCollectionFindError(t *testing.T) {
+	r := &recorder{}
+	AssertCollection(r, context.Background(), &fakeFinder{err: errors.New("boom")}, []any{})
+	if r.fatal != "mongotest: find: boom" {
+		t.Errorf("expected a fatal find error, got %q", r.fatal)
+	}
+}
+
+// TestAssertGolden tests writing and comparing a golden file.
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "users.golden.json")
+	coll := &fakeFinder{docs: []any{
+		map[string]any{"_id": 1, "name": "Ada", "createdAt": map[string]any{"$date": "2024-05-01T10:00:00Z"}},
+	}}
+	opts := (&AssertOptions{}).SetIgnoreFields("createdAt")
+
+	r := &recorder{}
+	AssertGolden(r, context.Background(), coll, path, opts)
+	if !strings.Contains(r.fatal, "-mongotest.update") {
+		t.Errorf("expected a hint to create the file, got %q", r.fatal)
+	}
+
+	*update = true
+	r = &recorder{}
+	AssertGolden(r, context.Background(), coll, path, opts)
+	*update = false
+	if r.fatal != "" {
+		t.Fatalf("unexpected failure: %s", r.fatal)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[\n  {\n    \"_id\": 1,\n    \"name\": \"Ada\"\n  }\n]\n" {
+		t.Errorf("unexpected golden file:\n%s", data)
+	}
+
+	r = &recorder{}
+	AssertGolden(r, context.Background(), coll, path, opts)
+	if len(r.errors) != 0 || r.fatal != "" {
+		t.Errorf("expected no failure, got %v %q", r.errors, r.fatal)
+	}
+
+	coll.docs[0].(map[string]any)["name"] = "Grace"
+	r = &recorder{}
+	AssertGolden(r, context.Background(), coll, path, opts)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], `/name: got "Grace", want "Ada"`) {
+		t.Errorf("expected a name difference, got %q", r.errors)
+	}
+}
+
+// TestReadFixture tests reading documents to seed a collection.
+func TestReadFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, []byte(`[{"_id": {"$oid": "65f1a2b3c4d5e6f708192a3b"}, "n": 1}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{}
+	docs := ReadFixture(r, path)
+	if r.fatal != "" {
+		t.Fatalf("unexpected failure: %s", r.fatal)
+	}
+	if len(docs) != 1 || fmt.Sprint(docs[0]) != "map[_id:map[$oid:65f1a2b3c4d5e6f708192a3b] n:1]" {
+		t.Errorf("unexpected documents: %v", docs)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"n": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = &recorder{}
+	if docs := ReadFixture(r, path); docs != nil || !strings.Contains(r.fatal, "not a JSON array") {
+		t.Errorf("expected a fatal error, got %v %q", docs, r.fatal)
+	}
+}
@@ -0,0 +1,332 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// This file holds the minimal MessagePack codec used for MessagePack
+// payloads over the RPC transport (see payload.go).
+
+// msgpackTimestamp is the extension type of MessagePack timestamps.
+const msgpackTimestamp = -1
+
+// marshalMsgPack encodes a document as MessagePack.
+func marshalMsgPack(doc any) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if err := encodeCompact(e, doc); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// msgpackEncoder writes MessagePack values, each in its smallest form.
+type msgpackEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *msgpackEncoder) writeNil() {
+	e.buf.WriteByte(0xc0)
+}
+
+func (e *msgpackEncoder) writeBool(b bool) {
+	if b {
+		e.buf.WriteByte(0xc3)
+	} else {
+		e.buf.WriteByte(0xc2)
+	}
+}
+
+func (e *msgpackEncoder) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.writeUint(uint64(n))
+	case n >= -32:
+		e.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		e.buf.Write([]byte{0xd0, byte(n)})
+	case n >= math.MinInt16:
+		e.buf.WriteByte(0xd1)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n >= math.MinInt32:
+		e.buf.WriteByte(0xd2)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(0xd3)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	}
+}
+
+func (e *msgpackEncoder) writeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xcc, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(0xcd)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(0xce)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		e.buf.WriteByte(0xcf)
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
+
+func (e *msgpackEncoder) writeFloat(f float64) {
+	e.buf.WriteByte(0xcb)
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func (e *msgpackEncoder) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(0xda)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		e.buf.WriteByte(0xdb)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	e.buf.WriteString(s)
+}
+
+func (e *msgpackEncoder) writeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf.Write([]byte{0xc4, byte(n)})
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(0xc5)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		e.buf.WriteByte(0xc6)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	e.buf.Write(b)
+}
+
+func (e *msgpackEncoder) writeArrayHeader(n int) {
+	e.writeHeader(n, 0x90, 0xdc, 0xdd)
+}
+
+func (e *msgpackEncoder) writeMapHeader(n int) {
+	e.writeHeader(n, 0x80, 0xde, 0xdf)
+}
+
+// writeHeader writes the length of an array or map in its fix, 16-bit or
+// 32-bit form.
+func (e *msgpackEncoder) writeHeader(n int, fix, b16, b32 byte) {
+	switch {
+	case n < 16:
+		e.buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(b16)
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		e.buf.WriteByte(b32)
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// unmarshalMsgPack decodes a MessagePack document.
+func unmarshalMsgPack(data []byte) (map[string]any, error) {
+	r := &msgpackReader{data: data}
+	v, err := r.value()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(data) {
+		return nil, fmt.Errorf("%d bytes after document", len(data)-r.pos)
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a map, got %T", v)
+	}
+	return doc, nil
+}
+
+// msgpackReader decodes MessagePack values.
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, errTruncated
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (r *msgpackReader) uint(size int) (uint64, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (r *msgpackReader) value() (any, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	t := b[0]
+	switch {
+	case t <= 0x7f:
+		return float64(t), nil
+	case t >= 0xe0:
+		return float64(int8(t)), nil
+	case t&0xf0 == 0x80:
+		return r.mapOf(uint64(t & 0x0f))
+	case t&0xf0 == 0x90:
+		return r.arrayOf(uint64(t & 0x0f))
+	case t&0xe0 == 0xa0:
+		return r.str(uint64(t & 0x1f))
+	}
+
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (t - 0xcc))
+		return compactUint(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (t - 0xd0)
+		n, err := r.uint(size)
+		// Sign-extend from the integer's size.
+		shift := 64 - 8*size
+		return compactInt(int64(n<<shift) >> shift), err
+	case 0xca:
+		n, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := r.uint(8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.str(n)
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		size, err := compactLength(n, len(r.data)-r.pos)
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(size)
+		return compactBytes(b), err
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayOf(n)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapOf(n)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.ext(1 << (t - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.uint(1 << (t - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.ext(int(n))
+	}
+	return nil, fmt.Errorf("unsupported MessagePack type 0x%02x", t)
+}
+
+func (r *msgpackReader) str(n uint64) (any, error) {
+	size, err := compactLength(n, len(r.data)-r.pos)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.next(size)
+	return string(b), err
+}
+
+func (r *msgpackReader) arrayOf(n uint64) (any, error) {
+	size, err := compactLength(n, len(r.data)-r.pos)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]any, size)
+	for i := range items {
+		if items[i], err = r.value(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (r *msgpackReader) mapOf(n uint64) (any, error) {
+	size, err := compactLength(n, len(r.data)-r.pos)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, size)
+	for i := 0; i < size; i++ {
+		k, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		doc[compactKey(k)] = v
+	}
+	return doc, nil
+}
+
+// ext decodes an extension value of size bytes. Only timestamps are
+// supported.
+func (r *msgpackReader) ext(size int) (any, error) {
+	b, err := r.next(size + 1)
+	if err != nil {
+		return nil, err
+	}
+	if int8(b[0]) != msgpackTimestamp {
+		return nil, fmt.Errorf("unsupported MessagePack extension type %d", int8(b[0]))
+	}
+	b = b[1:]
+	switch size {
+	case 4:
+		return compactDate(time.Unix(int64(binary.BigEndian.Uint32(b)), 0)), nil
+	case 8:
+		n := binary.BigEndian.Uint64(b)
+		return compactDate(time.Unix(int64(n&(1<<34-1)), int64(n>>34))), nil
+	case 12:
+		return compactDate(time.Unix(int64(binary.BigEndian.Uint64(b[4:])), int64(binary.BigEndian.Uint32(b)))), nil
+	}
+	return nil, fmt.Errorf("invalid MessagePack timestamp of %d bytes", size)
+}
@@ -0,0 +1,86 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// TestMsgPackEncoding tests encoding values in their smallest MessagePack
+// forms.
+func TestMsgPackEncoding(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{bsonDoc{{"a", 1}}, "81a16101"},
+		{bsonDoc{{"n", -33}}, "81a16ed0df"},
+		{bsonDoc{{"n", uint16(300)}}, "81a16ecd012c"},
+		{bsonDoc{{"n", int64(1) << 40}}, "81a16ecf0000010000000000"},
+		{bsonDoc{{"b", []byte{1, 2}}}, "81a162c4020102"},
+		{bsonDoc{{"x", []any{true, nil, 1.5}}}, "81a17893c3c0cb3ff8000000000000"},
+	}
+	for _, tt := range tests {
+		data, err := marshalMsgPack(tt.value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := hex.EncodeToString(data); got != tt.want {
+			t.Errorf("encoding %v: got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestMsgPackRoundTrip tests that decoded values take the shapes of the
+// JSON transport.
+func TestMsgPackRoundTrip(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := bson.NewObjectID()
+	data, err := marshalMsgPack(map[string]any{
+		"small": int32(-7),
+		"big":   int64(1) << 60,
+		"name":  string(bytes.Repeat([]byte("x"), 40)),
+		"data":  []byte{0xff},
+		"when":  when,
+		"id":    id,
+		"tags":  []string{"a", "b"},
+		"doc":   bson.D{{Key: "z", Value: 1}, {Key: "a", Value: 2}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := unmarshalMsgPack(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"small": float64(-7),
+		"big":   map[string]any{"$numberLong": "1152921504606846976"},
+		"name":  string(bytes.Repeat([]byte("x"), 40)),
+		"data":  map[string]any{"$binary": map[string]any{"base64": "/w==", "subType": "00"}},
+		"when":  map[string]any{"$date": "2024-01-02T03:04:05.000Z"},
+		"id":    map[string]any{"$oid": id.Hex()},
+		"tags":  []any{"a", "b"},
+		"doc":   map[string]any{"z": float64(1), "a": float64(2)},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("unexpected document\n got: %v\nwant: %v", doc, want)
+	}
+
+	// A timestamp extension decodes as a date.
+	doc, err = unmarshalMsgPack([]byte{0x81, 0xa1, 't', 0xd6, 0xff, 0x65, 0x93, 0x7d, 0x25})
+	if err != nil || !reflect.DeepEqual(doc["t"], map[string]any{"$date": "2024-01-02T03:04:05.000Z"}) {
+		t.Errorf("unexpected timestamp %v (%v)", doc, err)
+	}
+
+	for _, bad := range []string{"81a1", "dc0010", "c1", "8101"} {
+		data, _ := hex.DecodeString(bad)
+		if _, err := unmarshalMsgPack(data); err == nil {
+			t.Errorf("expected an error decoding %s", bad)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// namespaceGenerations counts the drops and renames of each database and
+// collection, so handles created before one can tell they are stale. It
+// also remembers the collection UUIDs Client.Refresh saw, to notice
+// collections dropped and re-created by other clients.
+type namespaceGenerations struct {
+	mu          sync.Mutex
+	generations map[string]uint64
+	uuids       map[string]any
+}
+
+// current returns the generation of ns, a database name or a
+// "database.collection" namespace.
+func (n *namespaceGenerations) current(ns string) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.generations[ns]
+}
+
+// invalidate starts a new generation of each of namespaces, making the
+// handles created for them so far stale, and returns the generation of the
+// first.
+func (n *namespaceGenerations) invalidate(namespaces ...string) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.generations == nil {
+		n.generations = make(map[string]uint64)
+	}
+	for _, ns := range namespaces {
+		n.generations[ns]++
+		delete(n.uuids, ns)
+	}
+	return n.generations[namespaces[0]]
+}
+
+// Stale reports whether the database was dropped since the handle was
+// created, other than through the handle itself, or found missing by
+// Client.Refresh. Operations through a stale handle fail with
+// ErrStaleHandle; get a new one from Client.Database.
+func (d *Database) Stale() bool {
+	return d.generation.Load() != d.client.namespaces.current(d.name)
+}
+
+// Stale reports whether the collection or its database was dropped or
+// renamed since the handle was created, other than by dropping through the
+// handle itself, or found re-created by Client.Refresh. Operations through
+// a stale handle fail with ErrStaleHandle rather than acting on whatever
+// collection now has the name; get a new one from Database.Collection.
+func (c *Collection) Stale() bool {
+	namespaces := &c.database.client.namespaces
+	return c.dbGeneration != namespaces.current(c.database.name) ||
+		c.generation.Load() != namespaces.current(c.namespace())
+}
+
+// checkStale fails with ErrStaleHandle if the database handle is stale.
+func (d *Database) checkStale() error {
+	if d.Stale() {
+		return fmt.Errorf("%w: database %s", ErrStaleHandle, d.name)
+	}
+	return nil
+}
+
+// checkStale fails with ErrStaleHandle if the collection handle is stale.
+func (c *Collection) checkStale() error {
+	if c.Stale() {
+		return fmt.Errorf("%w: collection %s", ErrStaleHandle, c.namespace())
+	}
+	return nil
+}
+
+// RenameCollectionOptions configures a Rename operation.
+type RenameCollectionOptions struct {
+	// DropTarget drops an existing collection with the new name instead of
+	// failing.
+	DropTarget *bool
+}
+
+// SetDropTarget sets whether an existing collection with the new name is
+// dropped.
+func (o *RenameCollectionOptions) SetDropTarget(drop bool) *RenameCollectionOptions {
+	o.DropTarget = &drop
+	return o
+}
+
+// Rename renames the collection to name within its database and returns a
+// handle for it with the same options. The receiver and handles for name
+// created before the rename become stale.
+func (c *Collection) Rename(ctx context.Context, name string, opts ...*RenameCollectionOptions) (*Collection, error) {
+	options := make(map[string]any)
+	for _, opt := range opts {
+		if opt != nil && opt.DropTarget != nil {
+			options["dropTarget"] = *opt.DropTarget
+		}
+	}
+	if _, err := c.execute(ctx, "mongo.renameCollection", c.database.name, c.name, name, options); err != nil {
+		return nil, err
+	}
+
+	target := c.database.name + "." + name
+	c.database.client.namespaces.invalidate(c.namespace(), target)
+	renamed, err := c.Clone()
+	if err != nil {
+		return nil, err
+	}
+	renamed.name = name
+	renamed.dbGeneration = c.database.client.namespaces.current(c.database.name)
+	renamed.generation.Store(c.database.client.namespaces.current(target))
+	return renamed, nil
+}
+
+// Refresh re-lists the databases and collections the client has handles
+// for and marks the handles stale whose namespace was dropped, renamed or
+// re-created since the last Refresh, including by other clients.
+// Collections are told apart by UUID, so a collection dropped and created
+// again under the same name is noticed. Handles for namespaces that do not
+// exist yet are left alone, as inserting creates them. The first Refresh
+// records the namespaces that exist; it cannot tell what happened before.
+func (c *Client) Refresh(ctx context.Context) error {
+	existing, err := c.ListDatabaseNames(ctx)
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	c.mu.RLock()
+	names := make([]string, 0, len(c.databases))
+	for name := range c.databases {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+
+	n := &c.namespaces
+	for _, name := range names {
+		if !exists[name] {
+			// Handles of a database that is gone are stale if it had
+			// collections at the last Refresh.
+			if n.seen(name) {
+				n.reconcile(name, nil)
+				n.invalidate(name)
+			}
+			continue
+		}
+		// Database replaces a cached handle that is already stale.
+		specs, err := c.Database(name).ListCollectionSpecifications(ctx, nil)
+		if err != nil {
+			return err
+		}
+		listed := make(map[string]any, len(specs))
+		for _, spec := range specs {
+			listed[name+"."+spec.Name] = spec.Info["uuid"]
+		}
+		n.reconcile(name, listed)
+	}
+	return nil
+}
+
+// seen reports whether Refresh has recorded collections of database db.
+func (n *namespaceGenerations) seen(db string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ns := range n.uuids {
+		if strings.HasPrefix(ns, db+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcile compares the collections listed in database db, namespaces
+// mapped to UUIDs, with those recorded by the last Refresh, starting a new
+// generation of each collection that is gone or has a new UUID.
+func (n *namespaceGenerations) reconcile(db string, listed map[string]any) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.generations == nil {
+		n.generations = make(map[string]uint64)
+	}
+	if n.uuids == nil {
+		n.uuids = make(map[string]any)
+	}
+	for ns, uuid := range n.uuids {
+		if !strings.HasPrefix(ns, db+".") {
+			continue
+		}
+		if current, ok := listed[ns]; !ok || !reflect.DeepEqual(current, uuid) {
+			n.generations[ns]++
+			delete(n.uuids, ns)
+		}
+	}
+	for ns, uuid := range listed {
+		if uuid != nil {
+			n.uuids[ns] = uuid
+		}
+	}
+}
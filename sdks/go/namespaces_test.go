@@ -0,0 +1,135 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestDropInvalidatesHandles tests that dropping makes other handles stale.
+func TestDropInvalidatesHandles(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.dropCollection", nil, nil)
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "a"}, nil)
+	mock.addCall("mongo.dropDatabase", nil, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("shop")
+	ctx := context.Background()
+
+	cached := db.Collection("orders")
+	strict := db.Collection("orders", (&CollectionOptions{}).SetDisallowUnknownFields(true))
+	if err := strict.Drop(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strict.Stale() {
+		t.Error("expected the dropping handle to stay usable")
+	}
+	if _, err := strict.InsertOne(ctx, map[string]any{"_id": "a"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !cached.Stale() {
+		t.Error("expected the other handle to be stale")
+	}
+	if _, err := cached.InsertOne(ctx, map[string]any{"_id": "b"}); !errors.Is(err, ErrStaleHandle) {
+		t.Errorf("expected ErrStaleHandle, got %v", err)
+	}
+	fresh := db.Collection("orders")
+	if fresh == cached || fresh.Stale() {
+		t.Error("expected a new cached handle replacing the stale one")
+	}
+
+	other := client.Database("shop", (&DatabaseOptions{}).SetWriteConcern(MajorityWriteConcern()))
+	if err := other.Drop(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !db.Stale() || !fresh.Stale() || other.Stale() {
+		t.Errorf("expected the other database handles to be stale: db %v, collection %v, dropping %v", db.Stale(), fresh.Stale(), other.Stale())
+	}
+	if err := db.Drop(ctx); !errors.Is(err, ErrStaleHandle) {
+		t.Errorf("expected ErrStaleHandle, got %v", err)
+	}
+	if client.Database("shop") == db {
+		t.Error("expected a new cached database handle")
+	}
+	if len(mock.calls) != 3 {
+		t.Errorf("expected no calls through stale handles, got %d calls", len(mock.calls))
+	}
+}
+
+// TestCollectionRename tests renaming a collection and invalidating handles.
+func TestCollectionRename(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.renameCollection", nil, nil)
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "a"}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("shop")
+	ctx := context.Background()
+
+	coll := db.Collection("orders", (&CollectionOptions{}).SetRejectUnsafeKeys(true))
+	target := db.Collection("archive")
+	renamed, err := coll.Rename(ctx, "archive", (&RenameCollectionOptions{}).SetDropTarget(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []any{"shop", "orders", "archive", map[string]any{"dropTarget": true}}; !reflect.DeepEqual(mock.calls[0].args, want) {
+		t.Errorf("expected args %v, got %v", want, mock.calls[0].args)
+	}
+	if renamed.Name() != "archive" || !renamed.rejectUnsafeKeys || renamed.Stale() {
+		t.Errorf("unexpected renamed handle %s, stale %v", renamed.Name(), renamed.Stale())
+	}
+	if !coll.Stale() || !target.Stale() {
+		t.Error("expected the old handles to be stale")
+	}
+	if _, err := renamed.InsertOne(ctx, map[string]any{"_id": "a"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := coll.InsertOne(ctx, map[string]any{"_id": "b"}); !errors.Is(err, ErrStaleHandle) {
+		t.Errorf("expected ErrStaleHandle, got %v", err)
+	}
+}
+
+// TestClientRefresh tests noticing collections re-created by other clients.
+func TestClientRefresh(t *testing.T) {
+	listing := func(uuids ...string) []any {
+		docs := make([]any, len(uuids))
+		for i, uuid := range uuids {
+			name := []string{"orders", "users"}[i]
+			docs[i] = map[string]any{"name": name, "type": "collection", "info": map[string]any{"uuid": uuid}}
+		}
+		return docs
+	}
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listDatabases", []any{"shop"}, nil)
+	mock.addCall("mongo.listCollections", listing("u1", "u2"), nil)
+	mock.addCall("mongo.listDatabases", []any{"shop"}, nil)
+	mock.addCall("mongo.listCollections", listing("u3", "u2"), nil)
+	mock.addCall("mongo.listDatabases", []any{}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	db := client.Database("shop")
+	ctx := context.Background()
+
+	orders, users, pending := db.Collection("orders"), db.Collection("users"), db.Collection("pending")
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if orders.Stale() || users.Stale() || pending.Stale() {
+		t.Error("expected no stale handles after the first refresh")
+	}
+
+	// orders was dropped and created again by another client.
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !orders.Stale() || users.Stale() || pending.Stale() {
+		t.Errorf("expected only orders to be stale: orders %v, users %v, pending %v", orders.Stale(), users.Stale(), pending.Stale())
+	}
+
+	// The database was dropped.
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !db.Stale() || !users.Stale() {
+		t.Error("expected the database handles to be stale")
+	}
+}
@@ -0,0 +1,283 @@
+package mongo
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongo.do/bson"
+)
+
+// NamingStrategy controls how struct fields without an explicit name tag
+// are named in documents.
+type NamingStrategy int
+
+// Naming strategies.
+const (
+	// NamingAsIs uses the Go field name unchanged, as encoding/json does.
+	NamingAsIs NamingStrategy = iota
+	// NamingCamelCase lower-cases the leading word: UserID becomes userID.
+	NamingCamelCase
+	// NamingSnakeCase splits words with underscores: UserID becomes user_id.
+	NamingSnakeCase
+)
+
+// fieldName applies the strategy to a Go field name.
+func (n NamingStrategy) fieldName(name string) string {
+	switch n {
+	case NamingCamelCase:
+		return camelCase(name)
+	case NamingSnakeCase:
+		return snakeCase(name)
+	}
+	return name
+}
+
+// documentName returns the document field name of f under naming strategy n.
+func (f structField) documentName(n NamingStrategy) string {
+	if f.named {
+		return f.name
+	}
+	return n.fieldName(f.name)
+}
+
+// camelCase lower-cases the leading run of upper-case letters, keeping the
+// last one of a run upper-case when it starts the next word (HTTPServer
+// becomes httpServer).
+func camelCase(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// snakeCase splits name into lower-case words joined by underscores,
+// treating runs of upper-case letters as one word (HTTPServer becomes
+// http_server).
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	bsonDType         = reflect.TypeOf(bson.D(nil))
+	timeType          = reflect.TypeOf(time.Time{})
+	dateTimeType      = reflect.TypeOf(bson.DateTime(0))
+)
+
+// marshalsItself reports whether t controls its own JSON encoding.
+func marshalsItself(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+// encodeNamed converts structs within v to documents whose untagged fields
+// are named by the strategy, and time.Time values to bson.DateTime. With
+// NamingAsIs, v is returned unchanged unless its type uses `bson` tags;
+// the transports convert its times with encodeDates.
+func encodeNamed(v any, naming NamingStrategy) any {
+	if p, ok := v.(PatchUpdate); ok {
+		return p.document(naming)
+	}
+	if v == nil || (naming == NamingAsIs && !usesBSONTags(reflect.TypeOf(v))) {
+		return v
+	}
+	return encodeNamedValue(reflect.ValueOf(v), naming)
+}
+
+// encodeDates converts the time.Time values within v to bson.DateTime, so
+// they are sent as dates rather than the RFC 3339 strings encoding/json
+// produces, and bson.Marshaler values to their documents. Structs holding
+// either become documents.
+func encodeDates(v any) any {
+	if v == nil || (!holdsDates(reflect.TypeOf(v)) && !usesBSONTags(reflect.TypeOf(v))) {
+		return v
+	}
+	return encodeNamedValue(reflect.ValueOf(v), NamingAsIs)
+}
+
+func encodeNamedValue(v reflect.Value, naming NamingStrategy) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type() == bsonDType && !v.IsNil() {
+		d := v.Interface().(bson.D)
+		doc := make(bson.D, len(d))
+		for i, e := range d {
+			doc[i] = bson.E{Key: e.Key, Value: encodeNamedValue(reflect.ValueOf(e.Value), naming)}
+		}
+		return doc
+	}
+	if v.Type() == timeType {
+		return bson.NewDateTimeFromTime(v.Interface().(time.Time))
+	}
+	if nullableKind(v.Type()) != "" {
+		return encodeNullable(v, naming)
+	}
+	if m, ok := asBSONMarshaler(v); ok {
+		return marshaledDocument(m)
+	}
+	if marshalsItself(v.Type()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeNamedValue(v.Elem(), naming)
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		doc := make(map[string]any, len(fields))
+		var inline reflect.Value
+		for _, f := range fields {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			if f.inlineMap {
+				inline = fv
+				continue
+			}
+			if (f.omitEmpty && fv.IsZero()) || isAbsent(fv) {
+				continue
+			}
+			doc[f.documentName(naming)] = encodeNamedValue(fv, naming)
+		}
+		if inline.IsValid() && !inline.IsNil() {
+			iter := inline.MapRange()
+			for iter.Next() {
+				if _, taken := doc[iter.Key().String()]; !taken && !isAbsent(iter.Value()) {
+					doc[iter.Key().String()] = encodeNamedValue(iter.Value(), naming)
+				}
+			}
+		}
+		return doc
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		arr := make([]any, v.Len())
+		for i := range arr {
+			arr[i] = encodeNamedValue(v.Index(i), naming)
+		}
+		return arr
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		doc := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if isAbsent(iter.Value()) {
+				continue
+			}
+			doc[iter.Key().String()] = encodeNamedValue(iter.Value(), naming)
+		}
+		return doc
+	}
+	return v.Interface()
+}
+
+// decodeNamed rewrites document keys produced by the strategy back to the Go
+// field names of t, so encoding/json can decode them. With NamingAsIs, doc is
+// returned unchanged.
+func decodeNamed(doc any, t reflect.Type, naming NamingStrategy) any {
+	if naming == NamingAsIs {
+		return doc
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if marshalsItself(t) {
+		return doc
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return doc
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = v
+		}
+		for _, f := range structFields(t) {
+			key := f.documentName(naming)
+			v, ok := m[key]
+			if !ok {
+				continue
+			}
+			delete(out, key)
+			out[f.name] = decodeNamed(v, t.FieldByIndex(f.index).Type, naming)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		arr, ok := doc.([]any)
+		if !ok {
+			return doc
+		}
+		out := make([]any, len(arr))
+		for i, v := range arr {
+			out[i] = decodeNamed(v, t.Elem(), naming)
+		}
+		return out
+	case reflect.Map:
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return doc
+		}
+		out := make(map[string]any, len(m))
+		for k, v := range m {
+			out[k] = decodeNamed(v, t.Elem(), naming)
+		}
+		return out
+	}
+	return doc
+}
+
+// unmarshalNamed decodes data into val, mapping strategy-named keys to the
+// fields of val's type.
+func unmarshalNamed(data []byte, val any, naming NamingStrategy) error {
+	t := reflect.TypeOf(val)
+	if naming == NamingAsIs || t == nil || t.Kind() != reflect.Pointer {
+		return json.Unmarshal(data, val)
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	renamed, err := json.Marshal(decodeNamed(doc, t.Elem(), naming))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(renamed, val)
+}
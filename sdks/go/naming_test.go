@@ -0,0 +1,153 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+type namingProfile struct {
+	HomeCity string
+}
+
+type namingUser struct {
+	ID        string `json:"_id"`
+	FirstName string
+	UserID    int
+	Nickname  string `json:",omitempty"`
+	Profile   *namingProfile
+	Tags      []string
+	CreatedAt time.Time
+}
+
+// TestNamingStrategyFieldName tests converting Go field names.
+func TestNamingStrategyFieldName(t *testing.T) {
+	tests := []struct {
+		name  string
+		camel string
+		snake string
+	}{
+		{"Name", "name", "name"},
+		{"ID", "id", "id"},
+		{"UserID", "userID", "user_id"},
+		{"HTTPServer", "httpServer", "http_server"},
+		{"CreatedAt", "createdAt", "created_at"},
+		{"Address2Line", "address2Line", "address2_line"},
+		{"already_snake", "already_snake", "already_snake"},
+	}
+
+	for _, tt := range tests {
+		if got := NamingCamelCase.fieldName(tt.name); got != tt.camel {
+			t.Errorf("camelCase(%q): expected %q, got %q", tt.name, tt.camel, got)
+		}
+		if got := NamingSnakeCase.fieldName(tt.name); got != tt.snake {
+			t.Errorf("snakeCase(%q): expected %q, got %q", tt.name, tt.snake, got)
+		}
+		if got := NamingAsIs.fieldName(tt.name); got != tt.name {
+			t.Errorf("asIs(%q): expected %q, got %q", tt.name, tt.name, got)
+		}
+	}
+}
+
+// TestEncodeNamed tests encoding structs with a naming strategy.
+func TestEncodeNamed(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := &namingUser{
+		ID:        "u1",
+		FirstName: "Ada",
+		UserID:    7,
+		Profile:   &namingProfile{HomeCity: "London"},
+		Tags:      []string{"a"},
+		CreatedAt: created,
+	}
+
+	got := encodeNamed(user, NamingSnakeCase)
+	want := map[string]any{
+		"_id":        "u1",
+		"first_name": "Ada",
+		"user_id":    7,
+		"profile":    map[string]any{"home_city": "London"},
+		"tags":       []any{"a"},
+		"created_at": bson.NewDateTimeFromTime(created),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := encodeNamed(user, NamingAsIs); got != any(user) {
+		t.Errorf("expected NamingAsIs to leave the value unchanged, got %v", got)
+	}
+
+	update := encodeNamed(map[string]any{"$set": namingProfile{HomeCity: "Paris"}}, NamingCamelCase)
+	if !reflect.DeepEqual(update, map[string]any{"$set": map[string]any{"homeCity": "Paris"}}) {
+		t.Errorf("unexpected update: %v", update)
+	}
+}
+
+// TestUnmarshalNamed tests decoding strategy-named documents into structs.
+func TestUnmarshalNamed(t *testing.T) {
+	data := []byte(`{"_id":"u1","first_name":"Ada","user_id":7,"nickname":"ada","profile":{"home_city":"London"},"extra":true}`)
+
+	var user namingUser
+	if err := unmarshalNamed(data, &user, NamingSnakeCase); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != "u1" || user.FirstName != "Ada" || user.UserID != 7 || user.Nickname != "ada" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if user.Profile == nil || user.Profile.HomeCity != "London" {
+		t.Errorf("unexpected profile: %+v", user.Profile)
+	}
+
+	var users []namingUser
+	if err := unmarshalNamed([]byte(`[{"first_name":"Bob"}]`), &users, NamingSnakeCase); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].FirstName != "Bob" {
+		t.Errorf("unexpected users: %+v", users)
+	}
+}
+
+// TestClientNamingStrategy tests that the client strategy applies to writes and reads.
+func TestClientNamingStrategy(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "u1"}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u1", "first_name": "Ada", "user_id": float64(7)}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "u2", "first_name": "Bob"}}, nil)
+
+	options := DefaultClientOptions().SetNamingStrategy(NamingSnakeCase)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", options)
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	if _, err := coll.InsertOne(ctx, namingUser{ID: "u1", FirstName: "Ada", UserID: 7}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, ok := mock.calls[0].args[2].(map[string]any)
+	if !ok || doc["first_name"] != "Ada" || doc["user_id"] != 7 {
+		t.Errorf("unexpected inserted document: %v", mock.calls[0].args[2])
+	}
+
+	var user namingUser
+	if err := coll.FindOne(ctx, map[string]any{"_id": "u1"}).Decode(&user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.FirstName != "Ada" || user.UserID != 7 {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	users, err := FindAs[namingUser](ctx, coll, map[string]any{}, (&FindOptions{}).SetAutoProjection(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].FirstName != "Bob" {
+		t.Errorf("unexpected users: %+v", users)
+	}
+	projection := mock.calls[2].args[3].(map[string]any)["projection"].(map[string]any)
+	if _, ok := projection["first_name"]; !ok {
+		t.Errorf("expected snake_case projection, got %v", projection)
+	}
+}
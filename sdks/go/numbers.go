@@ -0,0 +1,137 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// NumberDecoding controls how numbers surface in untyped results: values
+// decoded into any, map[string]any or []any. Typed struct fields are
+// unaffected.
+type NumberDecoding int
+
+// Number decoding modes.
+const (
+	// NumberDecodingFloat64 decodes numbers as float64, as encoding/json
+	// does.
+	NumberDecodingFloat64 NumberDecoding = iota
+	// NumberDecodingJSONNumber decodes numbers as json.Number, keeping
+	// their exact text.
+	NumberDecodingJSONNumber
+	// NumberDecodingInt64WhenExact decodes integers as int64 and other
+	// numbers as float64.
+	NumberDecodingInt64WhenExact
+)
+
+// jsonNumberType is the type of json.Number.
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// unmarshalDocument decodes data into val like unmarshalNamed, surfacing
+// untyped numbers as numbers selects and assigning values as config says.
+// With strict, fields val has no counterpart for fail with an
+// UnknownFieldError.
+func unmarshalDocument(data []byte, val any, naming NamingStrategy, numbers NumberDecoding, config DecoderConfig, strict bool) error {
+	if strict {
+		if err := checkUnknownFields(data, val, naming); err != nil {
+			return err
+		}
+	}
+	if t := reflect.TypeOf(val); t != nil && t.Kind() == reflect.Pointer && (config.custom() || usesBSONTags(t.Elem()) || holdsDates(t.Elem()) || holdsBinary(t.Elem())) {
+		return decodeTagged(data, val, naming, numbers, config)
+	}
+	if numbers == NumberDecodingFloat64 {
+		return unmarshalNamed(data, val, naming)
+	}
+
+	if t := reflect.TypeOf(val); naming != NamingAsIs && t != nil && t.Kind() == reflect.Pointer {
+		doc, err := decodeUseNumber(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(decodeNamed(doc, t.Elem(), naming)); err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(val); err != nil {
+		return err
+	}
+	if numbers == NumberDecodingInt64WhenExact {
+		convertNumbers(reflect.ValueOf(val))
+	}
+	return nil
+}
+
+// decodeUseNumber decodes data into a generic value, keeping numbers as
+// json.Number.
+func decodeUseNumber(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	err := dec.Decode(&doc)
+	return doc, err
+}
+
+// exactNumber returns n as an int64 when it is an integer that fits, and as
+// a float64 otherwise.
+func exactNumber(n json.Number) any {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// convertNumbers replaces the json.Number values held in interfaces within
+// v with exactNumber values.
+func convertNumbers(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			convertNumbers(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		elem := v.Elem()
+		if elem.Type() == jsonNumberType {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(exactNumber(elem.Interface().(json.Number))))
+			}
+			return
+		}
+		convertNumbers(elem)
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Interface {
+			for _, key := range v.MapKeys() {
+				convertNumbers(v.MapIndex(key))
+			}
+			return
+		}
+		for _, key := range v.MapKeys() {
+			value := v.MapIndex(key)
+			if value.IsNil() {
+				continue
+			}
+			if n, ok := value.Interface().(json.Number); ok {
+				v.SetMapIndex(key, reflect.ValueOf(exactNumber(n)))
+				continue
+			}
+			convertNumbers(value.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			convertNumbers(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				convertNumbers(v.Field(i))
+			}
+		}
+	}
+}
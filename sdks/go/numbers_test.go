@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestUnmarshalDocumentNumbers tests each number decoding mode.
+func TestUnmarshalDocumentNumbers(t *testing.T) {
+	data := []byte(`{"count":3,"ratio":2.5,"big":9007199254740993,"nested":{"n":[1,1.5]}}`)
+
+	tests := []struct {
+		numbers NumberDecoding
+		want    map[string]any
+	}{
+		{NumberDecodingFloat64, map[string]any{
+			"count": float64(3), "ratio": 2.5, "big": float64(9007199254740993),
+			"nested": map[string]any{"n": []any{float64(1), 1.5}},
+		}},
+		{NumberDecodingJSONNumber, map[string]any{
+			"count": json.Number("3"), "ratio": json.Number("2.5"), "big": json.Number("9007199254740993"),
+			"nested": map[string]any{"n": []any{json.Number("1"), json.Number("1.5")}},
+		}},
+		{NumberDecodingInt64WhenExact, map[string]any{
+			"count": int64(3), "ratio": 2.5, "big": int64(9007199254740993),
+			"nested": map[string]any{"n": []any{int64(1), 1.5}},
+		}},
+	}
+
+	for _, tt := range tests {
+		var got map[string]any
+		if err := unmarshalDocument(data, &got, NamingAsIs, tt.numbers, DecoderConfig{}, false); err != nil {
+			t.Fatalf("mode %d: unexpected error: %v", tt.numbers, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mode %d: expected %v, got %v", tt.numbers, tt.want, got)
+		}
+	}
+}
+
+// TestUnmarshalDocumentNumbersStruct tests that typed fields are unaffected
+// and untyped fields within structs are converted.
+func TestUnmarshalDocumentNumbersStruct(t *testing.T) {
+	type stats struct {
+		TotalCount int
+		Ratio      float64
+		Extra      any
+		Values     []any
+	}
+
+	var got stats
+	data := []byte(`{"total_count":3,"ratio":2,"extra":7,"values":[1,2.5]}`)
+	if err := unmarshalDocument(data, &got, NamingSnakeCase, NumberDecodingInt64WhenExact, DecoderConfig{}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := stats{TotalCount: 3, Ratio: 2, Extra: int64(7), Values: []any{int64(1), 2.5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+// TestClientNumberDecoding tests that the client mode applies to results.
+func TestClientNumberDecoding(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": float64(1), "total": float64(42), "avg": 1.25}}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": float64(2), "total": float64(7)}, nil)
+
+	options := DefaultClientOptions().SetNumberDecoding(NumberDecodingInt64WhenExact)
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", options)
+	coll := client.Database("testdb").Collection("stats")
+	ctx := context.Background()
+
+	cursor, err := coll.Find(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs []map[string]any
+	if err := cursor.All(ctx, &docs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 1 || docs[0]["total"] != int64(42) || docs[0]["avg"] != 1.25 {
+		t.Errorf("unexpected documents: %v", docs)
+	}
+
+	var doc map[string]any
+	if err := coll.FindOne(ctx, map[string]any{}).Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc["_id"] != int64(2) || doc["total"] != int64(7) {
+		t.Errorf("unexpected document: %v", doc)
+	}
+}
+
+// TestDecodeDecimal128 tests that decimals decode exactly into typed
+// fields, whatever the number decoding mode.
+func TestDecodeDecimal128(t *testing.T) {
+	type order struct {
+		ID    string          `json:"_id"`
+		Total bson.Decimal128 `json:"total"`
+	}
+
+	for _, numbers := range []NumberDecoding{NumberDecodingFloat64, NumberDecodingJSONNumber, NumberDecodingInt64WhenExact} {
+		mock := newMockRPCClient()
+		mock.addCall("mongo.findOne", map[string]any{"_id": "o1", "total": map[string]any{"$numberDecimal": "1234567890.123456789012345678"}}, nil)
+
+		client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetNumberDecoding(numbers))
+		var got order
+		if err := client.Database("testdb").Collection("orders").FindOne(context.Background(), map[string]any{}).Decode(&got); err != nil {
+			t.Fatalf("mode %d: unexpected error: %v", numbers, err)
+		}
+		if got.Total.String() != "1234567890.123456789012345678" {
+			t.Errorf("mode %d: unexpected total %v", numbers, got.Total)
+		}
+	}
+}
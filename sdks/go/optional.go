@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongo.do/bson"
+)
+
+// bsonPkgPath is the import path of the bson package.
+var bsonPkgPath = reflect.TypeOf(bson.D(nil)).PkgPath()
+
+// nullableKind returns "Optional" or "Null" for instances of bson.Optional
+// and bson.Null, and "" for other types. Both hold the value in their first
+// field and whether it is present or valid in their second.
+func nullableKind(t reflect.Type) string {
+	if t.Kind() != reflect.Struct || t.PkgPath() != bsonPkgPath {
+		return ""
+	}
+	switch name, _, _ := strings.Cut(t.Name(), "["); name {
+	case "Optional", "Null":
+		return name
+	}
+	return ""
+}
+
+// isAbsent reports whether v is an absent bson.Optional, which is left out
+// of documents.
+func isAbsent(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return nullableKind(v.Type()) == "Optional" && !v.Field(1).Bool()
+}
+
+// encodeNullable encodes a bson.Optional or bson.Null as its value, or as
+// nil when it is absent or null.
+func encodeNullable(v reflect.Value, naming NamingStrategy) any {
+	if !v.Field(1).Bool() {
+		return nil
+	}
+	return encodeNamedValue(v.Field(0), naming)
+}
+
+// decodeNullable assigns src to dst, a bson.Optional or bson.Null of the
+// given kind. An Optional becomes present even when src is null.
+func (d *taggedDecoder) decodeNullable(src any, dst reflect.Value, kind, field string) error {
+	if src == nil && kind == "Null" {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if err := d.decode(src, dst.Field(0), field); err != nil {
+		return err
+	}
+	dst.Field(1).SetBool(true)
+	return nil
+}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+type account struct {
+	ID       string                           `json:"_id"`
+	Nickname bson.Optional[bson.Null[string]] `json:"nickname"`
+	Visits   bson.Optional[int]               `json:"visits"`
+	Seen     bson.Null[time.Time]             `json:"seen"`
+}
+
+// TestOptionalEncode tests that absent Optional fields are left out of
+// inserted documents.
+func TestOptionalEncode(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "a1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("accounts")
+
+	seen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := coll.InsertOne(context.Background(), account{
+		ID:       "a1",
+		Nickname: bson.NewOptional(bson.Null[string]{}),
+		Seen:     bson.NewNull(seen),
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := mock.calls[0].args[2].(map[string]any)
+	if _, ok := doc["visits"]; ok {
+		t.Errorf("expected visits to be left out, got %v", doc)
+	}
+	if v, ok := doc["nickname"]; !ok || v != nil {
+		t.Errorf("expected a null nickname, got %v", doc)
+	}
+	if doc["seen"] != bson.NewDateTimeFromTime(seen) {
+		t.Errorf("expected seen as a date, got %v", doc["seen"])
+	}
+}
+
+// TestOptionalDecode tests telling absent, null and set fields apart.
+func TestOptionalDecode(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{"_id": "a1", "nickname": nil, "seen": nil}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "a2", "nickname": "ada", "visits": 3}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("accounts")
+	ctx := context.Background()
+
+	var a account
+	if err := coll.FindOne(ctx, bson.M{}).Decode(&a, (&DecodeOptions{}).SetDisallowUnknownFields(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nick, ok := a.Nickname.Get(); !ok || nick.Valid {
+		t.Errorf("expected a present null nickname, got %+v", a.Nickname)
+	}
+	if a.Visits.Present || a.Seen.Valid {
+		t.Errorf("expected absent visits and null seen, got %+v", a)
+	}
+
+	a = account{}
+	if err := coll.FindOne(ctx, bson.M{}).Decode(&a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nick, _ := a.Nickname.Get(); nick != bson.NewNull("ada") {
+		t.Errorf("unexpected nickname %+v", a.Nickname)
+	}
+	if visits, ok := a.Visits.Get(); !ok || visits != 3 {
+		t.Errorf("unexpected visits %+v", a.Visits)
+	}
+}
@@ -0,0 +1,88 @@
+package mongo
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// PatchUpdate is an update that changes only some fields of a document, as
+// for an HTTP PATCH request. Create one with Patch.
+type PatchUpdate struct {
+	doc any
+}
+
+// Patch returns an update that $sets the fields of doc, a struct or a map
+// with string keys. bson.Optional fields say what changes: absent ones are
+// left as they are, present ones holding null, such as a null bson.Null or
+// a nil pointer, are removed with $unset, and the rest are set. Fields of
+// other types are always set. Pass it to UpdateOne, UpdateMany,
+// FindOneAndUpdate or an update model; fields are named with the client's
+// NamingStrategy.
+func Patch(doc any) PatchUpdate {
+	return PatchUpdate{doc: doc}
+}
+
+// MarshalJSON encodes the update with Go field names, for use outside a
+// collection.
+func (p PatchUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.document(NamingAsIs))
+}
+
+// document builds the $set and $unset update, naming fields with naming.
+func (p PatchUpdate) document(naming NamingStrategy) map[string]any {
+	set, unset := make(map[string]any), make(map[string]any)
+	put := func(key string, v reflect.Value) {
+		for v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		if !v.IsValid() || nullableKind(v.Type()) != "Optional" {
+			set[key] = encodeNamedValue(v, naming)
+			return
+		}
+		if !v.Field(1).Bool() {
+			return
+		}
+		if value := encodeNamedValue(v.Field(0), naming); value != nil {
+			set[key] = value
+		} else {
+			unset[key] = ""
+		}
+	}
+
+	rv := reflect.ValueOf(p.doc)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Kind() == reflect.Struct:
+		for _, f := range structFields(rv.Type()) {
+			fv, err := rv.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			if f.inlineMap {
+				for iter := fv.MapRange(); iter.Next(); {
+					put(iter.Key().String(), iter.Value())
+				}
+				continue
+			}
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			put(f.documentName(naming), fv)
+		}
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		for iter := rv.MapRange(); iter.Next(); {
+			put(iter.Key().String(), iter.Value())
+		}
+	}
+
+	update := make(map[string]any, 2)
+	if len(set) > 0 || len(unset) == 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
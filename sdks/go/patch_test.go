@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+type accountPatch struct {
+	DisplayName bson.Optional[string]            `json:"displayName"`
+	Nickname    bson.Optional[bson.Null[string]] `json:"nickname"`
+	Email       bson.Optional[*string]           `json:"email"`
+}
+
+// TestPatch tests building $set and $unset updates from Optional fields.
+func TestPatch(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("accounts")
+
+	patch := accountPatch{
+		DisplayName: bson.NewOptional("Ada"),
+		Nickname:    bson.NewOptional(bson.Null[string]{}),
+	}
+	if _, err := coll.UpdateOne(context.Background(), bson.M{"_id": "a1"}, Patch(patch)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"$set":   map[string]any{"displayName": "Ada"},
+		"$unset": map[string]any{"nickname": ""},
+	}
+	if got := mock.calls[0].args[3]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected update %v", got)
+	}
+
+	data, err := json.Marshal(Patch(map[string]any{"email": bson.NewOptional[*string](nil), "visits": 3}))
+	if err != nil || string(data) != `{"$set":{"visits":3},"$unset":{"email":""}}` {
+		t.Errorf("unexpected update %s (%v)", data, err)
+	}
+	data, err = json.Marshal(Patch(accountPatch{}))
+	if err != nil || string(data) != `{"$set":{}}` {
+		t.Errorf("unexpected empty update %s (%v)", data, err)
+	}
+}
@@ -0,0 +1,201 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync/atomic"
+)
+
+// PayloadEncoding is how RPC arguments and results are encoded.
+type PayloadEncoding string
+
+// Payload encodings. JSON loses the distinction between int32, int64 and
+// doubles and carries dates and binary data as Extended JSON; the binary
+// encodings keep integers and bytes and are more compact. BSON also keeps
+// dates and the other BSON types; MessagePack and CBOR, for deployments
+// that cannot use BSON framing, carry those as Extended JSON.
+const (
+	// PayloadEncodingAuto uses BSON when the server advertises
+	// FeatureBSONPayloads in the handshake, and JSON otherwise.
+	PayloadEncodingAuto PayloadEncoding = "auto"
+	PayloadEncodingJSON PayloadEncoding = "json"
+	// PayloadEncodingBSON, PayloadEncodingMsgPack and PayloadEncodingCBOR
+	// require their encoding; connecting to a server that does not
+	// advertise it fails with a NotSupportedError.
+	PayloadEncodingBSON    PayloadEncoding = "bson"
+	PayloadEncodingMsgPack PayloadEncoding = "msgpack"
+	PayloadEncodingCBOR    PayloadEncoding = "cbor"
+)
+
+// payloadCodec encodes the payloads of a binary encoding.
+type payloadCodec struct {
+	// feature is the handshake feature advertising the encoding.
+	feature   string
+	marshal   func(doc any) ([]byte, error)
+	unmarshal func(data []byte) (map[string]any, error)
+}
+
+// payloadCodecs holds the codec of each binary encoding.
+var payloadCodecs = map[PayloadEncoding]payloadCodec{
+	PayloadEncodingBSON:    {FeatureBSONPayloads, marshalBSON, unmarshalBSON},
+	PayloadEncodingMsgPack: {FeatureMsgPackPayloads, marshalMsgPack, unmarshalMsgPack},
+	PayloadEncodingCBOR:    {FeatureCBORPayloads, marshalCBOR, unmarshalCBOR},
+}
+
+// validate reports an error for unknown encodings.
+func (e PayloadEncoding) validate() error {
+	if _, ok := payloadCodecs[e]; ok || e == "" || e == PayloadEncodingAuto || e == PayloadEncodingJSON {
+		return nil
+	}
+	return fmt.Errorf("%w: unknown payload encoding %q", ErrInvalidOption, string(e))
+}
+
+// key returns the field of the document that wraps a payload, in base64,
+// in place of the JSON arguments or result.
+func (e PayloadEncoding) key() string {
+	return "$" + string(e)
+}
+
+// payloadTransport sends calls in a binary encoding once the server has
+// agreed to it in the mongo.hello handshake, and sends them unchanged
+// before then or, for PayloadEncodingAuto, with servers that do not
+// support it.
+type payloadTransport struct {
+	RPCClient
+	encoding PayloadEncoding
+	required bool
+	codec    payloadCodec
+	enabled  atomic.Bool
+}
+
+// withPayloadEncoding returns next, sending payloads with encoding.
+func withPayloadEncoding(next RPCClient, encoding PayloadEncoding) RPCClient {
+	required := true
+	switch encoding {
+	case PayloadEncodingJSON:
+		return next
+	case "", PayloadEncodingAuto:
+		encoding, required = PayloadEncodingBSON, false
+	}
+	return &payloadTransport{RPCClient: next, encoding: encoding, required: required, codec: payloadCodecs[encoding]}
+}
+
+// Call sends the call. The handshake offers the encoding to the server;
+// later calls wrap their arguments, as a document holding them under
+// "args", in a single {$<encoding>: ...} argument.
+func (t *payloadTransport) Call(method string, args ...any) RPCPromise {
+	if method == "mongo.hello" {
+		return &helloPromise{t: t, promise: t.RPCClient.Call(method, t.offer(args)...)}
+	}
+	if !t.enabled.Load() {
+		return t.RPCClient.Call(method, args...)
+	}
+
+	if args == nil {
+		args = []any{}
+	}
+	data, err := t.codec.marshal(bsonDoc{{"args", args}})
+	if err != nil {
+		return &payloadPromise{err: fmt.Errorf("mongo: encoding %s arguments as %s: %w", method, t.encoding, err)}
+	}
+	payload := map[string]any{t.encoding.key(): base64.StdEncoding.EncodeToString(data)}
+	return &payloadPromise{t: t, method: method, promise: t.RPCClient.Call(method, payload)}
+}
+
+// offer returns the mongo.hello arguments with the payload encodings the
+// client accepts added to its metadata document.
+func (t *payloadTransport) offer(args []any) []any {
+	if len(args) == 0 {
+		return args
+	}
+	metadata, ok := args[0].(map[string]any)
+	if !ok {
+		return args
+	}
+	offered := make(map[string]any, len(metadata)+1)
+	for k, v := range metadata {
+		offered[k] = v
+	}
+	encodings := []any{string(t.encoding)}
+	if !t.required {
+		encodings = append(encodings, string(PayloadEncodingJSON))
+	}
+	offered["payloadEncodings"] = encodings
+	return append([]any{offered}, args[1:]...)
+}
+
+// helloPromise enables the payload encoding when the handshake reply
+// advertises it.
+type helloPromise struct {
+	t       *payloadTransport
+	promise RPCPromise
+}
+
+func (p *helloPromise) Await() (any, error) {
+	result, err := p.promise.Await()
+	if err != nil {
+		return result, err
+	}
+	reply, _ := result.(map[string]any)
+	var features []string
+	switch fs := reply["features"].(type) {
+	case []string:
+		features = fs
+	case []any:
+		for _, f := range fs {
+			if name, ok := f.(string); ok {
+				features = append(features, name)
+			}
+		}
+	}
+	if (&ServerDescription{Features: features}).Supports(p.t.codec.feature) {
+		p.t.enabled.Store(true)
+		return result, nil
+	}
+	if p.t.required {
+		return nil, &NotSupportedError{Feature: p.t.codec.feature}
+	}
+	return result, nil
+}
+
+// payloadPromise decodes an encoded result. Results that are not wrapped,
+// such as errors, pass through unchanged.
+type payloadPromise struct {
+	t       *payloadTransport
+	method  string
+	promise RPCPromise
+	err     error
+}
+
+func (p *payloadPromise) Await() (any, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	result, err := p.promise.Await()
+	if err != nil {
+		return result, err
+	}
+	return p.t.decode(p.method, result)
+}
+
+// decode returns the "result" field of the document wrapped in result, or
+// result itself if it is not wrapped.
+func (t *payloadTransport) decode(method string, result any) (any, error) {
+	wrapped, ok := result.(map[string]any)
+	if !ok || len(wrapped) != 1 {
+		return result, nil
+	}
+	encoded, ok := wrapped[t.encoding.key()].(string)
+	if !ok {
+		return result, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, newProtocolError(method, "a base64 "+string(t.encoding)+" payload", result)
+	}
+	doc, err := t.codec.unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: decoding %s result: %w", method, err)
+	}
+	return doc["result"], nil
+}
@@ -0,0 +1,147 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// bsonPayload wraps doc as a BSON payload.
+func bsonPayload(t *testing.T, doc bsonDoc) map[string]any {
+	t.Helper()
+	data, err := marshalBSON(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return map[string]any{PayloadEncodingBSON.key(): base64.StdEncoding.EncodeToString(data)}
+}
+
+// TestBSONPayloads tests sending and receiving BSON payloads once the
+// server agrees to them.
+func TestBSONPayloads(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{"features": []any{FeatureBSONPayloads}}, nil)
+	mock.addCall("mongo.findOne", bsonPayload(t, bsonDoc{{"result", map[string]any{"n": int64(1) << 60}}}), nil)
+	mock.addCall("mongo.findOne", map[string]any{"ok": float64(0), "code": float64(26)}, nil)
+
+	transport := withPayloadEncoding(mock, PayloadEncodingAuto)
+	if _, err := transport.Call("mongo.hello", newClientMetadata("app").document()).Await(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if encodings := mock.calls[0].args[0].(map[string]any)["payloadEncodings"]; !reflect.DeepEqual(encodings, []any{"bson", "json"}) {
+		t.Errorf("expected BSON to be offered, got %v", encodings)
+	}
+
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	result, err := transport.Call("mongo.findOne", "db", "coll", map[string]any{"when": when, "data": []byte{1, 2}}).Await()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]any{"n": map[string]any{"$numberLong": "1152921504606846976"}}; !reflect.DeepEqual(result, want) {
+		t.Errorf("unexpected result %v", result)
+	}
+
+	args := mock.calls[1].args
+	if len(args) != 1 {
+		t.Fatalf("expected a single payload argument, got %v", args)
+	}
+	data, err := base64.StdEncoding.DecodeString(args[0].(map[string]any)[PayloadEncodingBSON.key()].(string))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc, err := unmarshalBSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter := doc["args"].([]any)[2].(map[string]any)
+	if filter["when"].(map[string]any)["$date"] != "2024-01-02T03:04:05.000Z" {
+		t.Errorf("expected a BSON date, got %v", filter["when"])
+	}
+	if _, ok := filter["data"].(map[string]any)["$binary"]; !ok {
+		t.Errorf("expected BSON binary data, got %v", filter["data"])
+	}
+
+	// Unwrapped results, such as server errors, pass through.
+	result, err = transport.Call("mongo.findOne", "db", "coll", nil).Await()
+	if err != nil || result.(map[string]any)["code"] != float64(26) {
+		t.Errorf("unexpected result %v (%v)", result, err)
+	}
+}
+
+// TestBSONPayloadsFallback tests falling back to JSON for servers without
+// BSON payloads.
+func TestBSONPayloadsFallback(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{"features": []any{FeatureTransactions}}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"n": float64(1)}, nil)
+	mock.addCall("mongo.hello", map[string]any{"features": []any{}}, nil)
+
+	transport := withPayloadEncoding(mock, PayloadEncodingAuto)
+	if _, err := transport.Call("mongo.hello", map[string]any{}).Await(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := transport.Call("mongo.findOne", "db", "coll").Await(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args := mock.calls[1].args; !reflect.DeepEqual(args, []any{"db", "coll"}) {
+		t.Errorf("expected JSON arguments, got %v", args)
+	}
+
+	required := withPayloadEncoding(mock, PayloadEncodingBSON)
+	if _, err := required.Call("mongo.hello", map[string]any{}).Await(); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("expected a NotSupportedError, got %v", err)
+	}
+	if transport := withPayloadEncoding(mock, PayloadEncodingJSON); transport != RPCClient(mock) {
+		t.Errorf("expected JSON to leave the transport unwrapped")
+	}
+}
+
+// TestCompactPayloads tests the MessagePack and CBOR payload encodings.
+func TestCompactPayloads(t *testing.T) {
+	for _, encoding := range []PayloadEncoding{PayloadEncodingMsgPack, PayloadEncodingCBOR} {
+		codec := payloadCodecs[encoding]
+		reply, err := codec.marshal(bsonDoc{{"result", map[string]any{"n": int64(1) << 60}}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		mock := newMockRPCClient()
+		mock.addCall("mongo.hello", map[string]any{"features": []any{codec.feature}}, nil)
+		mock.addCall("mongo.findOne", map[string]any{encoding.key(): base64.StdEncoding.EncodeToString(reply)}, nil)
+
+		transport := withPayloadEncoding(mock, encoding)
+		if _, err := transport.Call("mongo.hello", map[string]any{}).Await(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", encoding, err)
+		}
+		if encodings := mock.calls[0].args[0].(map[string]any)["payloadEncodings"]; !reflect.DeepEqual(encodings, []any{string(encoding)}) {
+			t.Errorf("%s: unexpected offer %v", encoding, encodings)
+		}
+		result, err := transport.Call("mongo.findOne", "db", "coll", map[string]any{"data": []byte{1}}).Await()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", encoding, err)
+		}
+		if want := map[string]any{"n": map[string]any{"$numberLong": "1152921504606846976"}}; !reflect.DeepEqual(result, want) {
+			t.Errorf("%s: unexpected result %v", encoding, result)
+		}
+
+		data, err := base64.StdEncoding.DecodeString(mock.calls[1].args[0].(map[string]any)[encoding.key()].(string))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		doc, err := codec.unmarshal(data)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", encoding, err)
+		}
+		filter := doc["args"].([]any)[2].(map[string]any)
+		if _, ok := filter["data"].(map[string]any)["$binary"]; !ok {
+			t.Errorf("%s: expected binary data, got %v", encoding, filter["data"])
+		}
+	}
+
+	if _, err := NewClient(context.Background(), "mongodb://localhost:27017", DefaultClientOptions().SetPayloadEncoding("xml")); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption for an unknown encoding, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority is the scheduling class of an operation. It only matters for a
+// client with ClientOptions.MaxOperations set: when the client has that many
+// operations in flight, waiting interactive operations are admitted before
+// waiting batch operations, and batch operations never take more than
+// MaxBatchOperations of the slots, so background scans cannot starve the
+// queries of users sharing the client. Operations of a class are admitted
+// in the order they arrived.
+type Priority int
+
+const (
+	// PriorityInteractive is latency-sensitive work, such as serving a
+	// user request. It is the default.
+	PriorityInteractive Priority = iota
+	// PriorityBatch is background work, such as scans and bulk loads.
+	// Export, Import, Checksum, Compare, Sync and Archive run at this
+	// priority unless their context sets another.
+	PriorityBatch
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+// priorityKey is the context key for operation priorities.
+type priorityKey struct{}
+
+// WithPriority returns a context that runs every operation issued with it
+// at priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority attached to ctx, or
+// PriorityInteractive if there is none.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// withBatchPriority returns ctx at PriorityBatch, unless ctx sets a
+// priority already.
+func withBatchPriority(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return WithPriority(ctx, PriorityBatch)
+}
+
+// operationLimiter bounds the operations in flight, admitting waiting
+// operations by priority and then in arrival order.
+type operationLimiter struct {
+	mu          sync.Mutex
+	max         int
+	maxBatch    int
+	active      int
+	activeBatch int
+	// waiting holds the waiting operations of each priority, oldest first.
+	waiting [2][]chan struct{}
+}
+
+// newOperationLimiter returns a limiter admitting max operations, of which
+// at most maxBatch at PriorityBatch.
+func newOperationLimiter(max, maxBatch int) *operationLimiter {
+	return &operationLimiter{max: max, maxBatch: min(max, maxBatch)}
+}
+
+// acquire waits for a slot for an operation at priority p, failing if ctx
+// is done first. The caller must release the slot once the operation is
+// over.
+func (l *operationLimiter) acquire(ctx context.Context, p Priority) error {
+	if p != PriorityBatch {
+		p = PriorityInteractive
+	}
+	l.mu.Lock()
+	// Operations only jump the queue if nothing of their priority or
+	// higher is waiting.
+	queued := len(l.waiting[PriorityInteractive]) > 0 || len(l.waiting[p]) > 0
+	if !queued && l.admits(p) {
+		l.admit(p)
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	l.waiting[p] = append(l.waiting[p], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-ready:
+		// Admitted as ctx ended; hand the slot on.
+		l.leave(p)
+	default:
+		for i, w := range l.waiting[p] {
+			if w == ready {
+				l.waiting[p] = append(l.waiting[p][:i:i], l.waiting[p][i+1:]...)
+				break
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// release frees the slot of an operation at priority p.
+func (l *operationLimiter) release(p Priority) {
+	if p != PriorityBatch {
+		p = PriorityInteractive
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leave(p)
+}
+
+// admits reports whether an operation at priority p fits. l.mu is held.
+func (l *operationLimiter) admits(p Priority) bool {
+	if l.active >= l.max {
+		return false
+	}
+	return p != PriorityBatch || l.activeBatch < l.maxBatch
+}
+
+// admit takes a slot for an operation at priority p. l.mu is held.
+func (l *operationLimiter) admit(p Priority) {
+	l.active++
+	if p == PriorityBatch {
+		l.activeBatch++
+	}
+}
+
+// leave frees a slot of priority p and admits the waiting operations that
+// fit, interactive ones first. l.mu is held.
+func (l *operationLimiter) leave(p Priority) {
+	l.active--
+	if p == PriorityBatch {
+		l.activeBatch--
+	}
+	for _, q := range []Priority{PriorityInteractive, PriorityBatch} {
+		for len(l.waiting[q]) > 0 && l.admits(q) {
+			l.admit(q)
+			close(l.waiting[q][0])
+			l.waiting[q] = l.waiting[q][1:]
+		}
+	}
+}
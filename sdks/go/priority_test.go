@@ -0,0 +1,107 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitLimiterQueue waits until n operations of priority p wait in l.
+func waitLimiterQueue(t *testing.T, l *operationLimiter, p Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		queued := len(l.waiting[p])
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d waiting %s operations, got %d", n, p, queued)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestOperationLimiterPriority tests admitting interactive operations first.
+func TestOperationLimiterPriority(t *testing.T) {
+	l := newOperationLimiter(2, 2)
+	ctx := context.Background()
+	if err := l.acquire(ctx, PriorityBatch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.acquire(ctx, PriorityBatch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	admitted := make(chan Priority, 3)
+	wait := func(p Priority) {
+		if err := l.acquire(ctx, p); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		admitted <- p
+	}
+	go wait(PriorityBatch)
+	waitLimiterQueue(t, l, PriorityBatch, 1)
+	go wait(PriorityInteractive)
+	waitLimiterQueue(t, l, PriorityInteractive, 1)
+
+	// The interactive operation arrived last but is admitted first.
+	l.release(PriorityBatch)
+	if p := <-admitted; p != PriorityInteractive {
+		t.Errorf("expected the interactive operation first, got %s", p)
+	}
+	l.release(PriorityBatch)
+	if p := <-admitted; p != PriorityBatch {
+		t.Errorf("expected the batch operation next, got %s", p)
+	}
+}
+
+// TestOperationLimiterBatchShare tests keeping slots for interactive
+// operations and giving up on a done context.
+func TestOperationLimiterBatchShare(t *testing.T) {
+	l := newOperationLimiter(3, 1)
+	ctx := context.Background()
+	if err := l.acquire(ctx, PriorityBatch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(timeoutCtx, PriorityBatch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second batch operation to wait out its deadline, got %v", err)
+	}
+	if err := l.acquire(ctx, PriorityInteractive); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(l.waiting[PriorityBatch]) != 0 || l.active != 2 || l.activeBatch != 1 {
+		t.Errorf("unexpected limiter state: %d waiting, %d active, %d batch", len(l.waiting[PriorityBatch]), l.active, l.activeBatch)
+	}
+}
+
+// TestWithPriority tests attaching priorities to contexts.
+func TestWithPriority(t *testing.T) {
+	ctx := context.Background()
+	if p := PriorityFromContext(ctx); p != PriorityInteractive {
+		t.Errorf("expected interactive by default, got %s", p)
+	}
+	if p := PriorityFromContext(withBatchPriority(ctx)); p != PriorityBatch {
+		t.Errorf("expected batch, got %s", p)
+	}
+	interactive := WithPriority(ctx, PriorityInteractive)
+	if p := PriorityFromContext(withBatchPriority(interactive)); p != PriorityInteractive {
+		t.Errorf("expected an explicit priority to be kept, got %s", p)
+	}
+
+	client := newClient(ctx, newMockRPCClient(), "mongodb://localhost:27017", DefaultClientOptions())
+	if client.limiter != nil {
+		t.Error("expected no admission limit by default")
+	}
+	client = newClient(ctx, newMockRPCClient(), "mongodb://localhost:27017",
+		DefaultClientOptions().SetMaxOperations(8).SetMaxBatchOperations(2))
+	if client.limiter.max != 8 || client.limiter.maxBatch != 2 {
+		t.Errorf("unexpected limits %d and %d", client.limiter.max, client.limiter.maxBatch)
+	}
+}
@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProtocolError is returned when an RPC response does not have the shape
+// expected for its method.
+type ProtocolError struct {
+	Method   string
+	Expected string
+	Got      string
+	// Payload is a truncated JSON rendering of the response.
+	Payload string
+}
+
+// Error implements the error interface.
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("mongo: unexpected %s response: expected %s, got %s (payload: %s)", e.Method, e.Expected, e.Got, e.Payload)
+}
+
+// maxPayloadSnippet is the maximum length of ProtocolError.Payload.
+const maxPayloadSnippet = 128
+
+// responseKind is the JSON kind of an RPC response value.
+type responseKind int
+
+const (
+	kindNull responseKind = 1 << iota
+	kindDocument
+	kindArray
+	kindString
+	kindNumber
+	kindBool
+)
+
+// responseKinds are the response shapes accepted for each known RPC method.
+// Methods not listed are not validated.
+var responseKinds = map[string]responseKind{
+	"mongo.find":                   kindArray,
+	"mongo.aggregate":              kindArray,
+	"mongo.distinct":               kindArray,
+	"mongo.listCollections":        kindArray,
+	"mongo.listDatabases":          kindArray,
+	"mongo.countDocuments":         kindNumber,
+	"mongo.estimatedDocumentCount": kindNumber,
+	"mongo.findOne":                kindDocument | kindNull,
+	"mongo.findOneAndUpdate":       kindDocument | kindNull,
+	"mongo.findOneAndDelete":       kindDocument | kindNull,
+	"mongo.findOneAndReplace":      kindDocument | kindNull,
+	"mongo.changeStreamNext":       kindDocument | kindNull,
+	"mongo.listIndexes":            kindArray | kindDocument | kindNull,
+	"mongo.watch":                  kindString,
+	"mongo.hello":                  kindDocument,
+	"mongo.clientBulkWrite":        kindDocument,
+}
+
+// kindOf returns the kind of an RPC response value. Integer wrappers, such
+// as {"$numberLong": ...}, are numbers.
+func kindOf(v any) responseKind {
+	switch v.(type) {
+	case nil:
+		return kindNull
+	case map[string]any:
+		if _, ok := asInt64(v); ok {
+			return kindNumber
+		}
+		return kindDocument
+	case []any:
+		return kindArray
+	case string:
+		return kindString
+	case bool:
+		return kindBool
+	}
+	if _, ok := asInt64(v); ok {
+		return kindNumber
+	}
+	return 0
+}
+
+// String returns the kinds as a human-readable list.
+func (k responseKind) String() string {
+	names := []struct {
+		kind responseKind
+		name string
+	}{
+		{kindDocument, "document"},
+		{kindArray, "array"},
+		{kindString, "string"},
+		{kindNumber, "number"},
+		{kindBool, "bool"},
+		{kindNull, "null"},
+	}
+	var parts []string
+	for _, n := range names {
+		if k&n.kind != 0 {
+			parts = append(parts, n.name)
+		}
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " or ")
+}
+
+// validateResponse checks the shape of a response to a known method.
+func validateResponse(method string, result any) error {
+	expected, ok := responseKinds[method]
+	if !ok || kindOf(result)&expected != 0 {
+		return nil
+	}
+	return newProtocolError(method, expected.String(), result)
+}
+
+// newProtocolError describes a response that does not have the expected shape.
+func newProtocolError(method, expected string, result any) *ProtocolError {
+	got := kindOf(result).String()
+	if got == "unknown" {
+		got = fmt.Sprintf("%T", result)
+	}
+
+	payload := fmt.Sprintf("%v", result)
+	if data, err := json.Marshal(result); err == nil {
+		payload = string(data)
+	}
+	if len(payload) > maxPayloadSnippet {
+		payload = payload[:maxPayloadSnippet] + "..."
+	}
+
+	return &ProtocolError{Method: method, Expected: expected, Got: got, Payload: payload}
+}
+
+// unexpectedResponse describes a response to method that its caller could
+// not interpret.
+func unexpectedResponse(method string, result any) error {
+	return newProtocolError(method, responseKinds[method].String(), result)
+}
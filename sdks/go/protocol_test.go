@@ -0,0 +1,84 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestValidateResponse tests the response shapes of known methods.
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		method string
+		result any
+		valid  bool
+	}{
+		{"mongo.find", []any{}, true},
+		{"mongo.find", map[string]any{}, false},
+		{"mongo.findOne", nil, true},
+		{"mongo.findOne", "doc", false},
+		{"mongo.countDocuments", float64(3), true},
+		{"mongo.countDocuments", json.Number("3"), true},
+		{"mongo.countDocuments", "3", false},
+		{"mongo.listIndexes", map[string]any{}, true},
+		{"mongo.watch", float64(1), false},
+		{"mongo.unknownMethod", 42, true},
+	}
+
+	for _, tt := range tests {
+		err := validateResponse(tt.method, tt.result)
+		if tt.valid && err != nil {
+			t.Errorf("%s(%v): unexpected error %v", tt.method, tt.result, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s(%v): expected error", tt.method, tt.result)
+		}
+	}
+}
+
+// TestProtocolErrorFromOperation tests the error returned for a malformed response.
+func TestProtocolErrorFromOperation(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", map[string]any{"cursor": map[string]any{"id": float64(0)}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	_, err := client.Database("testdb").Collection("users").Find(context.Background(), map[string]any{})
+
+	var protoErr *ProtocolError
+	if !errors.As(err, &protoErr) {
+		t.Fatalf("expected ProtocolError, got %v", err)
+	}
+	if protoErr.Method != "mongo.find" || protoErr.Expected != "array" || protoErr.Got != "document" {
+		t.Errorf("unexpected error fields: %+v", protoErr)
+	}
+	if !strings.Contains(protoErr.Payload, `"cursor"`) {
+		t.Errorf("expected payload snippet, got %q", protoErr.Payload)
+	}
+}
+
+// TestProtocolErrorPayloadTruncated tests truncating long payloads.
+func TestProtocolErrorPayloadTruncated(t *testing.T) {
+	err := newProtocolError("mongo.find", "array", strings.Repeat("x", 500))
+
+	if len(err.Payload) != maxPayloadSnippet+3 || !strings.HasSuffix(err.Payload, "...") {
+		t.Errorf("unexpected payload length %d", len(err.Payload))
+	}
+	if err.Got != "string" {
+		t.Errorf("expected string, got %s", err.Got)
+	}
+	if !strings.Contains(err.Error(), "expected array, got string") {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+}
+
+// TestProtocolErrorKinds tests describing combined and unknown kinds.
+func TestProtocolErrorKinds(t *testing.T) {
+	if got := (kindDocument | kindNull).String(); got != "document or null" {
+		t.Errorf("unexpected kinds: %s", got)
+	}
+	if got := newProtocolError("mongo.find", "array", struct{}{}).Got; got != "struct {}" {
+		t.Errorf("expected Go type for unknown kind, got %s", got)
+	}
+}
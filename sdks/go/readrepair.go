@@ -0,0 +1,165 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// errNoReadRepairEndpoint is returned for read-repair reads on a client
+// without ClientOptions.ReadRepairEndpoint.
+var errNoReadRepairEndpoint = fmt.Errorf("%w: read repair requires ClientOptions.ReadRepairEndpoint", ErrInvalidOption)
+
+// executeRepaired issues a read on both the client's endpoint and its read
+// repair endpoint at the same time. It returns both results; an endpoint
+// that failed has a nil result. The error is the primary endpoint's, unless
+// only the repair endpoint answered.
+func (c *Collection) executeRepaired(ctx context.Context, method string, args ...any) (any, any, error) {
+	client := c.database.client
+	replica := client.repairRPC
+	if replica == nil {
+		return nil, nil, errNoReadRepairEndpoint
+	}
+
+	type response struct {
+		result any
+		err    error
+	}
+	done := make(chan response, 1)
+	go func() {
+		opCtx, cancel := withTimeout(ctx, c.EffectiveTimeout(ctx))
+		defer cancel()
+		result, err := client.call(opCtx, replica, method, args...)
+		if err == nil {
+			err = validateResponse(method, result)
+		}
+		done <- response{result, err}
+	}()
+
+	primary, err := c.execute(ctx, method, args...)
+	r := <-done
+	if r.err != nil {
+		return primary, nil, err
+	}
+	if err != nil {
+		return r.result, nil, nil
+	}
+	return primary, r.result, nil
+}
+
+// newerDocument returns whichever of a and b has the greater value at the
+// dotted version field. A missing document or version loses; ties go to a.
+func newerDocument(a, b any, field string) any {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+	if compareVersions(documentVersion(a, field), documentVersion(b, field)) < 0 {
+		return b
+	}
+	return a
+}
+
+// documentVersion returns the value at the dotted path field of doc.
+func documentVersion(doc any, field string) any {
+	value := doc
+	for _, part := range strings.Split(field, ".") {
+		value = getPath(value, part)
+	}
+	return value
+}
+
+// compareVersions orders version field values: numbers numerically, dates
+// (RFC 3339 strings, $date documents and time values) chronologically, and
+// anything else by its string form. A nil version orders first.
+func compareVersions(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if x, ok := asFloat64(normalizeID(a)); ok {
+		if y, ok := asFloat64(normalizeID(b)); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, err := parseDateTime(a); err == nil {
+		if y, err := parseDateTime(b); err == nil {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// asFloat64 converts a numeric value to float64.
+func asFloat64(v any) (float64, bool) {
+	if f, ok := v.(float64); ok {
+		return f, true
+	}
+	n, ok := asInt64(v)
+	return float64(n), ok
+}
+
+// repairedDocument returns primary, or the replica's copy of the same
+// document if it is newer. A document only the replica returned is not
+// returned, as the primary may have deleted it or updated it so that it no
+// longer matches.
+func repairedDocument(primary, replica any, field string) any {
+	if primary == nil || replica == nil {
+		return primary
+	}
+	key, ok := documentKey(primary)
+	if !ok {
+		return primary
+	}
+	if replicaKey, ok := documentKey(replica); !ok || replicaKey != key {
+		return primary
+	}
+	return newerDocument(primary, replica, field)
+}
+
+// mergeRepaired returns the primary's documents of a find result, each
+// replaced by the replica's copy of it when that is newer. Documents only
+// the replica returned are left out, and the primary's order is kept.
+func mergeRepaired(primary, replica []any, field string) []any {
+	copies := make(map[string]any, len(replica))
+	for _, doc := range replica {
+		if key, ok := documentKey(doc); ok {
+			copies[key] = doc
+		}
+	}
+	merged := make([]any, len(primary))
+	for i, doc := range primary {
+		merged[i] = doc
+		if key, ok := documentKey(doc); ok {
+			merged[i] = newerDocument(doc, copies[key], field)
+		}
+	}
+	return merged
+}
+
+// documentKey returns a comparable key for the _id of doc.
+func documentKey(doc any) (string, bool) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	id, ok := m["_id"]
+	if !ok {
+		return "", false
+	}
+	key, err := loaderKey(normalizeID(id))
+	return key, err == nil
+}
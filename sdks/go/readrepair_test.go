@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestFindOneReadRepair tests returning the newer document of two endpoints,
+// and not returning one only the repair endpoint found.
+func TestFindOneReadRepair(t *testing.T) {
+	primary := newMockRPCClient()
+	primary.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "old", "updatedAt": "2024-01-01T00:00:00Z"}, nil)
+	primary.addCall("mongo.findOne", nil, nil)
+	replica := newMockRPCClient()
+	replica.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "new", "updatedAt": "2024-01-02T00:00:00Z"}, nil)
+	replica.addCall("mongo.findOne", map[string]any{"_id": "2", "name": "only"}, nil)
+
+	client := newClientWithRPC(primary, "mongodb://localhost:27017")
+	client.repairRPC = replica
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+	opts := (&FindOneOptions{}).SetReadRepair("updatedAt")
+
+	var doc map[string]any
+	if err := coll.FindOne(ctx, map[string]any{"_id": "1"}, opts).Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc["name"] != "new" {
+		t.Errorf("expected the newer document, got %v", doc)
+	}
+	if !reflect.DeepEqual(replica.calls[0].args, primary.calls[0].args) {
+		t.Errorf("expected the same query on both endpoints, got %v and %v", primary.calls[0].args, replica.calls[0].args)
+	}
+
+	if err := coll.FindOne(ctx, map[string]any{"_id": "2"}, opts).Err(); !errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNoDocuments for a document only the repair endpoint found, got %v", err)
+	}
+}
+
+// TestFindReadRepair tests replacing documents of a find result by newer
+// copies, leaving out documents only the repair endpoint returned.
+func TestFindReadRepair(t *testing.T) {
+	primary := newMockRPCClient()
+	primary.addCall("mongo.find", []any{
+		map[string]any{"_id": "a", "v": float64(2)},
+		map[string]any{"_id": "b", "v": float64(1)},
+	}, nil)
+	replica := newMockRPCClient()
+	replica.addCall("mongo.find", []any{
+		map[string]any{"_id": "b", "v": float64(3)},
+		map[string]any{"_id": "a", "v": float64(1)},
+		map[string]any{"_id": "c", "v": float64(1)},
+	}, nil)
+
+	client := newClientWithRPC(primary, "mongodb://localhost:27017")
+	client.repairRPC = replica
+	coll := client.Database("testdb").Collection("users")
+
+	cursor, err := coll.Find(context.Background(), map[string]any{}, (&FindOptions{}).SetReadRepair("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs []map[string]any
+	if err := cursor.All(context.Background(), &docs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []map[string]any{{"_id": "a", "v": float64(2)}, {"_id": "b", "v": float64(3)}}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("expected %v, got %v", want, docs)
+	}
+}
+
+// TestReadRepairEndpointFailure tests falling back to the endpoint that answered.
+func TestReadRepairEndpointFailure(t *testing.T) {
+	primary := newMockRPCClient()
+	primary.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "primary"}, nil)
+	primary.addCall("mongo.findOne", nil, errors.New("primary down"))
+	replica := newMockRPCClient()
+	replica.addCall("mongo.findOne", nil, errors.New("replica down"))
+	replica.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "replica"}, nil)
+
+	client := newClientWithRPC(primary, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	opts := (&FindOneOptions{}).SetReadRepair("updatedAt")
+
+	if err := coll.FindOne(context.Background(), map[string]any{}, opts).Err(); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("expected ErrInvalidOption without a repair endpoint, got %v", err)
+	}
+
+	client.repairRPC = replica
+	for _, want := range []string{"primary", "replica"} {
+		var doc map[string]any
+		if err := coll.FindOne(context.Background(), map[string]any{}, opts).Decode(&doc); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if doc["name"] != want {
+			t.Errorf("expected the %s document, got %v", want, doc)
+		}
+	}
+}
+
+// TestCompareVersions tests ordering version field values.
+func TestCompareVersions(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		a, b any
+		want int
+	}{
+		{float64(1), int64(2), -1},
+		{int32(5), float64(5), 0},
+		{"2024-01-02T00:00:00Z", map[string]any{"$date": "2024-01-01T00:00:00Z"}, 1},
+		{day, day.Add(time.Hour), -1},
+		{nil, float64(0), -1},
+		{"b", "a", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%v, %v): expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package mongo
+
+import (
+	"context"
+	"time"
+)
+
+// ReconnectQueueOptions configures queuing of operations while the client
+// re-establishes a lost transport connection. Fields left at zero, or set
+// negative, take their values from DefaultReconnectQueueOptions.
+type ReconnectQueueOptions struct {
+	// MaxQueued is the maximum number of operations waiting for the
+	// connection. Further operations fail with ErrReconnectQueueFull.
+	MaxQueued int
+	// Timeout is how long an operation waits for the connection before
+	// failing with ErrClientDisconnected.
+	Timeout time.Duration
+	// Backoff is the delay between reconnection attempts; it doubles after
+	// each failed attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultReconnectQueueOptions returns options queuing up to 1000 operations
+// for at most five seconds.
+func DefaultReconnectQueueOptions() *ReconnectQueueOptions {
+	return &ReconnectQueueOptions{
+		MaxQueued:  1000,
+		Timeout:    5 * time.Second,
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// withDefaults returns a copy of o with unset fields taken from
+// DefaultReconnectQueueOptions, so that a partly filled struct neither
+// rejects every operation nor redials without a pause.
+func (o *ReconnectQueueOptions) withDefaults() *ReconnectQueueOptions {
+	merged := *o
+	defaults := DefaultReconnectQueueOptions()
+	if merged.MaxQueued <= 0 {
+		merged.MaxQueued = defaults.MaxQueued
+	}
+	if merged.Timeout <= 0 {
+		merged.Timeout = defaults.Timeout
+	}
+	if merged.Backoff <= 0 {
+		merged.Backoff = defaults.Backoff
+	}
+	if merged.MaxBackoff <= 0 {
+		merged.MaxBackoff = defaults.MaxBackoff
+	}
+	return &merged
+}
+
+// dialFunc opens a new transport connection.
+type dialFunc func(ctx context.Context) (RPCClient, error)
+
+// reconnectState tracks an in-progress reconnection. Fields are guarded by
+// the client mutex.
+type reconnectState struct {
+	options *ReconnectQueueOptions
+	dial    dialFunc
+	active  bool
+	// done is closed when the current reconnection attempt finishes.
+	done   chan struct{}
+	queued int
+}
+
+// connection returns the transport to use for an operation. While a
+// reconnection is in progress and queuing is enabled, it waits for the
+// connection to be restored.
+func (c *Client) connection(ctx context.Context) (RPCClient, error) {
+	c.mu.RLock()
+	if c.connected {
+		rpcClient := c.rpcClient
+		c.mu.RUnlock()
+		return rpcClient, nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	if c.connected {
+		rpcClient := c.rpcClient
+		c.mu.Unlock()
+		return rpcClient, nil
+	}
+	r := c.reconnect
+	if r == nil || !r.active {
+		c.mu.Unlock()
+		return nil, ErrClientDisconnected
+	}
+	if r.queued >= r.options.MaxQueued {
+		c.mu.Unlock()
+		return nil, ErrReconnectQueueFull
+	}
+	r.queued++
+	done := r.done
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		r.queued--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.clock.After(r.options.Timeout):
+		return nil, ErrClientDisconnected
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.connected {
+		return nil, ErrClientDisconnected
+	}
+	return c.rpcClient, nil
+}
+
+// connectionLost starts reconnecting in the background if failed is still
+// the current transport and it reports being disconnected.
+func (c *Client) connectionLost(failed RPCClient) {
+	if failed.IsConnected() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := c.reconnect
+	if r == nil || r.dial == nil || r.active || !c.connected || c.rpcClient != failed {
+		return
+	}
+	c.connected = false
+	r.active = true
+	r.done = make(chan struct{})
+	go c.redial(r)
+}
+
+// redial dials until a connection is established or the client is
+// disconnected, then releases the queued operations.
+func (c *Client) redial(r *reconnectState) {
+	backoff := r.options.Backoff
+	for {
+		rpcClient, err := r.dial(c.ctx)
+		if err == nil {
+			c.mu.Lock()
+			if c.ctx.Err() == nil {
+				c.rpcClient = rpcClient
+				c.connected = true
+			} else {
+				rpcClient.Close()
+			}
+			r.active = false
+			close(r.done)
+			c.mu.Unlock()
+			return
+		}
+
+		select {
+		case <-c.ctx.Done():
+			c.mu.Lock()
+			r.active = false
+			close(r.done)
+			c.mu.Unlock()
+			return
+		case <-c.clock.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > r.options.MaxBackoff {
+			backoff = r.options.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newReconnectTestClient returns a client whose transport has just been lost
+// and whose dialer returns next once release is closed.
+func newReconnectTestClient(t *testing.T, opts *ReconnectQueueOptions, next RPCClient) (*Client, *fakeClock, chan struct{}) {
+	t.Helper()
+
+	failed := newMockRPCClient()
+	failed.addCall("mongo.ping", nil, &ConnectionError{Address: "localhost", Wrapped: errors.New("reset")})
+	failed.connected = false
+
+	clock := newFakeClock(time.Now())
+	client := newClient(context.Background(), failed, "mongodb://localhost:27017",
+		DefaultClientOptions().SetClock(clock).SetReconnectQueue(opts))
+
+	release := make(chan struct{})
+	client.reconnect.dial = func(ctx context.Context) (RPCClient, error) {
+		select {
+		case <-release:
+			return next, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if err := client.Ping(context.Background()); !IsNetworkError(err) {
+		t.Fatalf("expected network error, got %v", err)
+	}
+	return client, clock, release
+}
+
+// waitQueued waits until n operations are queued for the reconnection.
+func waitQueued(t *testing.T, client *Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		client.mu.RLock()
+		queued := client.reconnect.queued
+		client.mu.RUnlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued operations", n)
+}
+
+// TestReconnectQueuesOperations tests that operations wait for the new connection.
+func TestReconnectQueuesOperations(t *testing.T) {
+	next := newMockRPCClient()
+	next.addCall("mongo.ping", true, nil)
+
+	opts := &ReconnectQueueOptions{MaxQueued: 10, Timeout: time.Hour}
+	client, _, release := newReconnectTestClient(t, opts, next)
+
+	result := make(chan error, 1)
+	go func() { result <- client.Ping(context.Background()) }()
+
+	waitQueued(t, client, 1)
+	close(release)
+
+	if err := <-result; err != nil {
+		t.Fatalf("expected queued operation to succeed, got %v", err)
+	}
+	if client.transport() != next {
+		t.Error("expected client to use the new connection")
+	}
+}
+
+// TestReconnectQueueFull tests the bound on queued operations.
+func TestReconnectQueueFull(t *testing.T) {
+	opts := &ReconnectQueueOptions{MaxQueued: 1, Timeout: time.Hour}
+	client, _, _ := newReconnectTestClient(t, opts, newMockRPCClient())
+
+	result := make(chan error, 1)
+	go func() { result <- client.Ping(context.Background()) }()
+	waitQueued(t, client, 1)
+
+	if err := client.Ping(context.Background()); !errors.Is(err, ErrReconnectQueueFull) {
+		t.Errorf("expected ErrReconnectQueueFull, got %v", err)
+	}
+
+	client.Disconnect(context.Background())
+	if err := <-result; !errors.Is(err, ErrClientDisconnected) {
+		t.Errorf("expected ErrClientDisconnected after Disconnect, got %v", err)
+	}
+}
+
+// TestReconnectQueueTimeout tests that queued operations give up after the timeout.
+func TestReconnectQueueTimeout(t *testing.T) {
+	opts := &ReconnectQueueOptions{MaxQueued: 10, Timeout: time.Second}
+	client, clock, _ := newReconnectTestClient(t, opts, newMockRPCClient())
+	defer client.Disconnect(context.Background())
+
+	result := make(chan error, 1)
+	go func() { result <- client.Ping(context.Background()) }()
+	waitQueued(t, client, 1)
+
+	clock.Advance(time.Second)
+	if err := <-result; !errors.Is(err, ErrClientDisconnected) {
+		t.Errorf("expected ErrClientDisconnected, got %v", err)
+	}
+}
+
+// TestReconnectQueueContextCanceled tests canceling a queued operation.
+func TestReconnectQueueContextCanceled(t *testing.T) {
+	opts := &ReconnectQueueOptions{MaxQueued: 10, Timeout: time.Hour}
+	client, _, _ := newReconnectTestClient(t, opts, newMockRPCClient())
+	defer client.Disconnect(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- client.Ping(ctx) }()
+	waitQueued(t, client, 1)
+
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestReconnectDisabled tests that operations fail fast without queuing.
+func TestReconnectDisabled(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.ping", nil, &ConnectionError{Address: "localhost", Wrapped: errors.New("reset")})
+	mock.connected = false
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	if err := client.Ping(context.Background()); !IsNetworkError(err) {
+		t.Fatalf("expected network error, got %v", err)
+	}
+
+	client.Disconnect(context.Background())
+	if err := client.Ping(context.Background()); !errors.Is(err, ErrClientDisconnected) {
+		t.Errorf("expected ErrClientDisconnected, got %v", err)
+	}
+}
+
+// TestReconnectQueueOptionsDefaults tests that unset options take the defaults.
+func TestReconnectQueueOptionsDefaults(t *testing.T) {
+	defaults := DefaultReconnectQueueOptions()
+	opts := &ReconnectQueueOptions{MaxQueued: 10, Backoff: -time.Second}
+	client := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017",
+		DefaultClientOptions().SetReconnectQueue(opts))
+	defer client.Disconnect(context.Background())
+
+	got := client.reconnect.options
+	want := &ReconnectQueueOptions{MaxQueued: 10, Timeout: defaults.Timeout, Backoff: defaults.Backoff, MaxBackoff: defaults.MaxBackoff}
+	if *got != *want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if opts.Backoff != -time.Second {
+		t.Error("expected the caller's options to be left unmodified")
+	}
+
+	if got := (&ReconnectQueueOptions{}).withDefaults(); *got != *defaults {
+		t.Errorf("expected %+v, got %+v", defaults, got)
+	}
+}
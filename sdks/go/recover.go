@@ -0,0 +1,19 @@
+package mongo
+
+import "runtime/debug"
+
+// runCallback runs fn, a callback passed to the SDK, converting a panic
+// into a PanicError naming callback, which is reported to the client's
+// OnPanic hook and returned.
+func (c *Client) runCallback(callback string, fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			perr := &PanicError{Callback: callback, Value: v, Stack: string(debug.Stack())}
+			if c.onPanic != nil {
+				c.onPanic(perr)
+			}
+			err = perr
+		}
+	}()
+	return fn()
+}
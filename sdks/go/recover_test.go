@@ -0,0 +1,163 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunCallback tests converting callback panics into errors.
+func TestRunCallback(t *testing.T) {
+	var reported []*PanicError
+	opts := DefaultClientOptions().SetOnPanic(func(e *PanicError) { reported = append(reported, e) })
+	client := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017", opts)
+
+	err := client.runCallback("test callback", func() error { panic("boom") })
+	var perr *PanicError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrCallbackPanic) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if perr.Value != "boom" || err.Error() != "mongo: test callback panicked: boom" {
+		t.Errorf("unexpected error %q", err)
+	}
+	if !strings.Contains(perr.Stack, "recover_test.go") {
+		t.Errorf("expected the panicking frame in the stack:\n%s", perr.Stack)
+	}
+	if len(reported) != 1 || reported[0] != perr {
+		t.Errorf("expected the panic to be reported, got %v", reported)
+	}
+
+	err = client.runCallback("test callback", func() error { panic(io.ErrUnexpectedEOF) })
+	if !errors.Is(err, ErrCallbackPanic) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected the panicked error to be wrapped, got %v", err)
+	}
+
+	if err := client.runCallback("test callback", func() error { return io.EOF }); err != io.EOF {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+	if len(reported) != 2 {
+		t.Errorf("expected 2 reported panics, got %d", len(reported))
+	}
+}
+
+// TestWithTransactionPanic tests that a panicking transaction function
+// returns an error.
+func TestWithTransactionPanic(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	session, _ := client.StartSession()
+
+	result, err := session.WithTransaction(context.Background(), func(ctx context.Context) (any, error) {
+		var m map[string]int
+		m["x"] = 1
+		return "unreachable", nil
+	})
+	if result != nil || !errors.Is(err, ErrCallbackPanic) {
+		t.Errorf("expected a PanicError, got %v %v", result, err)
+	}
+	if !strings.Contains(err.Error(), "WithTransaction function panicked") {
+		t.Errorf("unexpected message %q", err)
+	}
+}
+
+// TestSubscribePanic tests that a panicking handler stops the subscription
+// with an error, without checkpointing its batch.
+func TestSubscribePanic(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t1"), nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	store := newMemoryCheckpointStore()
+
+	err := client.Database("testdb").Collection("orders").Subscribe(context.Background(), func(ctx context.Context, events []*ChangeEvent) error {
+		panic("bad handler")
+	}, (&SubscribeOptions{}).SetCheckpoint(store, ""))
+	if !errors.Is(err, ErrCallbackPanic) || !strings.Contains(err.Error(), "Subscribe handler panicked: bad handler") {
+		t.Errorf("expected a PanicError, got %v", err)
+	}
+	if store.saves != 0 {
+		t.Errorf("expected no checkpoint, got %d saves", store.saves)
+	}
+	if mock.callIndex != 4 {
+		t.Errorf("expected the stream to be closed, got %d calls", mock.callIndex)
+	}
+}
+
+// TestProgressAndTransformerPanics tests that panicking progress functions
+// and field transformers stop their operation with an error.
+func TestProgressAndTransformerPanics(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"a"}}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "a", "email": "a@example.com"}}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+	ctx := context.Background()
+
+	progress := (&InsertManyOptions{}).SetProgress(func(BatchProgress) { panic("bad progress") })
+	if _, err := coll.InsertMany(ctx, []any{map[string]any{"_id": "a"}}, progress); !errors.Is(err, ErrCallbackPanic) ||
+		!strings.Contains(err.Error(), "ProgressFunc panicked") {
+		t.Errorf("expected a PanicError, got %v", err)
+	}
+
+	var out strings.Builder
+	transform := FieldTransformerFunc(func(any) (any, bool) { panic("bad transformer") })
+	if _, err := coll.Export(ctx, &out, nil, (&ExportOptions{}).SetTransform("email", transform)); !errors.Is(err, ErrCallbackPanic) ||
+		!strings.Contains(err.Error(), "FieldTransformer panicked") {
+		t.Errorf("expected a PanicError, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no exported document, got %q", out.String())
+	}
+}
+
+// TestOnResumePanic tests that a panicking OnResume fails the stream.
+func TestOnResumePanic(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t1"), nil)
+	mock.addCall("mongo.changeStreamClose", nil, nil)
+	mock.addCall("mongo.watch", "stream-2", nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetClock(clock))
+	ctx := context.Background()
+
+	opts := (&ChangeStreamOptions{}).
+		SetLivenessTimeout(30 * time.Second).
+		SetOnResume(func(ChangeStreamResumeEvent) { panic("bad callback") })
+	cs, err := client.Database("testdb").Collection("orders").Watch(ctx, []any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cs.Next(ctx) {
+		t.Fatalf("expected an event, got error %v", cs.Err())
+	}
+
+	clock.Advance(31 * time.Second)
+	if cs.Next(ctx) {
+		t.Fatal("expected no event")
+	}
+	if err := cs.Err(); !errors.Is(err, ErrCallbackPanic) || !strings.Contains(err.Error(), "OnResume panicked") {
+		t.Errorf("expected a PanicError, got %v", err)
+	}
+}
+
+// TestOnLeakPanic tests that a panicking OnLeak is reported to OnPanic
+// rather than crashing the finalizer goroutine.
+func TestOnLeakPanic(t *testing.T) {
+	var reported []*PanicError
+	opts := DefaultClientOptions().
+		SetLeakDetection(true).
+		SetOnLeak(func(Leak) { panic("bad callback") }).
+		SetOnPanic(func(e *PanicError) { reported = append(reported, e) })
+	client := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017", opts)
+
+	client.leaks.collect(client.leaks.track("cursor", "app.users"))
+	if len(reported) != 1 || reported[0].Callback != "OnLeak" {
+		t.Errorf("expected the panic to be reported, got %v", reported)
+	}
+}
@@ -0,0 +1,241 @@
+package mongo
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryOptions configures automatic retries and client-side throttling.
+type RetryOptions struct {
+	// MaxAttempts is the total number of attempts for a retryable read,
+	// including the first. Values <= 1 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles on each
+	// subsequent retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BudgetRatio is the maximum fraction of requests that may be retries,
+	// e.g. 0.1 allows one retry per ten requests across the client. A small
+	// reserve lets low-traffic clients retry occasionally. Zero leaves
+	// retries unbudgeted.
+	BudgetRatio float64
+	// ThrottleMultiplier enables adaptive throttling when > 0. Once the
+	// backend reports overload, requests are rejected locally with
+	// probability (requests - K*accepts) / (requests + 1), where K is the
+	// multiplier. Typical values are 1.5 to 2.
+	ThrottleMultiplier float64
+}
+
+// DefaultRetryOptions returns retry options with one retry, a 10% retry
+// budget, and adaptive throttling enabled.
+func DefaultRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:        2,
+		Backoff:            50 * time.Millisecond,
+		MaxBackoff:         2 * time.Second,
+		BudgetRatio:        0.1,
+		ThrottleMultiplier: 2,
+	}
+}
+
+// retryableMethods are the RPC methods that are safe to retry. An aggregate
+// is retried only if its pipeline does not write; see isRetryableCall.
+var retryableMethods = map[string]bool{
+	"mongo.find":                   true,
+	"mongo.findOne":                true,
+	"mongo.countDocuments":         true,
+	"mongo.estimatedDocumentCount": true,
+	"mongo.distinct":               true,
+	"mongo.aggregate":              true,
+	"mongo.listIndexes":            true,
+	"mongo.listCollections":        true,
+	"mongo.listDatabases":          true,
+	"mongo.ping":                   true,
+}
+
+// retryableKey is the context key overriding whether a call is retryable.
+type retryableKey struct{}
+
+// withRetryable returns a context whose calls are retried, or not, as
+// retryable says, whatever their method.
+func withRetryable(ctx context.Context, retryable bool) context.Context {
+	return context.WithValue(ctx, retryableKey{}, retryable)
+}
+
+// isRetryableCall reports whether a call of method with args under ctx may
+// be retried. An aggregate whose pipeline ends in $out or $merge writes, so
+// it is not retried after an error that leaves its outcome unknown; neither
+// is one whose pipeline cannot be inspected.
+func isRetryableCall(ctx context.Context, method string, args []any) bool {
+	if retryable, ok := ctx.Value(retryableKey{}).(bool); ok {
+		return retryable
+	}
+	if method == "mongo.aggregate" {
+		return len(args) > 2 && !writesOutput(args[2])
+	}
+	return retryableMethods[method]
+}
+
+// writesOutput reports whether pipeline ends in a $out or $merge stage, or
+// is of a type whose stages cannot be told.
+func writesOutput(pipeline any) bool {
+	stages, ok := pipelineStages(pipeline)
+	if !ok {
+		return true
+	}
+	if len(stages) == 0 {
+		return false
+	}
+	name := stageName(stages[len(stages)-1])
+	return name == "$out" || name == "$merge"
+}
+
+// retryBudgetReserve is the number of retries the budget can bank.
+const retryBudgetReserve = 10
+
+// retryBudget is a token bucket limiting retries to a fraction of requests.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+	max    float64
+}
+
+// newRetryBudget creates a budget allowing ratio retries per request.
+func newRetryBudget(ratio float64) *retryBudget {
+	return &retryBudget{
+		ratio:  ratio,
+		tokens: retryBudgetReserve,
+		max:    retryBudgetReserve,
+	}
+}
+
+// deposit credits the budget for a new request.
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.max, b.tokens+b.ratio)
+}
+
+// withdraw takes a token for a retry, reporting whether one was available.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// throttleWindow is the half-life of the adaptive throttle counters.
+const throttleWindow = time.Minute
+
+// adaptiveThrottle rejects requests locally while the backend is overloaded.
+type adaptiveThrottle struct {
+	mu        sync.Mutex
+	k         float64
+	clock     Clock
+	random    func() float64
+	requests  float64
+	accepts   float64
+	lastDecay time.Time
+}
+
+// newAdaptiveThrottle creates a throttle with multiplier k.
+func newAdaptiveThrottle(k float64, clock Clock) *adaptiveThrottle {
+	return &adaptiveThrottle{
+		k:         k,
+		clock:     clock,
+		random:    rand.Float64,
+		lastDecay: clock.Now(),
+	}
+}
+
+// decay halves the counters for every elapsed window. Callers hold mu.
+func (t *adaptiveThrottle) decay() {
+	now := t.clock.Now()
+	elapsed := now.Sub(t.lastDecay)
+	if elapsed < throttleWindow {
+		return
+	}
+	factor := math.Pow(0.5, float64(elapsed)/float64(throttleWindow))
+	t.requests *= factor
+	t.accepts *= factor
+	t.lastDecay = now
+}
+
+// reject reports whether a request should be rejected locally. Every call
+// counts as a request.
+func (t *adaptiveThrottle) reject() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decay()
+	p := (t.requests - t.k*t.accepts) / (t.requests + 1)
+	t.requests++
+	return p > 0 && t.random() < p
+}
+
+// observe records the outcome of a request sent to the backend. Overload
+// and network errors count as rejected, as an overloaded backend may stop
+// answering at all; any other response counts as accepted.
+func (t *adaptiveThrottle) observe(err error) {
+	if IsOverloaded(err) || IsNetworkError(err) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.accepts++
+}
+
+// call sends an RPC, retrying transient failures of retryable methods within
+// the retry budget and applying adaptive throttling.
+func (c *Client) call(ctx context.Context, rpcClient RPCClient, method string, args ...any) (any, error) {
+	attempts := 1
+	if c.retry != nil && isRetryableCall(ctx, method, args) && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+	if c.retryBudget != nil {
+		c.retryBudget.deposit()
+	}
+
+	var backoff time.Duration
+	if c.retry != nil {
+		backoff = c.retry.Backoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		if c.throttle != nil && c.throttle.reject() {
+			return nil, ErrThrottled
+		}
+
+		result, err := awaitContext(ctx, callContext(ctx, rpcClient, method, args...))
+		if err == nil {
+			err = serverError(result)
+		}
+		if c.throttle != nil {
+			c.throttle.observe(err)
+		}
+
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return result, err
+		}
+		if c.retryBudget != nil && !c.retryBudget.withdraw() {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.clock.After(backoff):
+		}
+
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// newRetryTestClient creates a client with the given retry options.
+func newRetryTestClient(mock *mockRPCClient, retry *RetryOptions) *Client {
+	opts := DefaultClientOptions().SetRetry(retry)
+	return newClient(context.Background(), mock, "mongodb://localhost:27017", opts)
+}
+
+// TestRetryReadOnNetworkError tests that retryable reads are retried.
+func TestRetryReadOnNetworkError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.countDocuments", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.countDocuments", float64(3), nil)
+
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 3, BudgetRatio: 0.1})
+	coll := client.Database("testdb").Collection("users")
+
+	count, err := coll.CountDocuments(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3, got %d", count)
+	}
+
+	if mock.callIndex != 2 {
+		t.Errorf("expected 2 calls, got %d", mock.callIndex)
+	}
+}
+
+// TestRetryNotAppliedToWrites tests that writes are not retried.
+func TestRetryNotAppliedToWrites(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", nil, &ConnectionError{Address: "localhost"})
+
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 3})
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := coll.InsertOne(context.Background(), map[string]any{"a": 1})
+	if !IsNetworkError(err) {
+		t.Errorf("expected network error, got %v", err)
+	}
+
+	if mock.callIndex != 1 {
+		t.Errorf("expected 1 call, got %d", mock.callIndex)
+	}
+}
+
+// TestRetryAggregateOutput tests that aggregates writing with $out or $merge
+// are not retried, while read-only ones are.
+func TestRetryAggregateOutput(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.aggregate", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.aggregate", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.aggregate", nil, &ConnectionError{Address: "localhost"})
+	mock.addCall("mongo.aggregate", []any{}, nil)
+
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 3})
+	coll := client.Database("testdb").Collection("orders")
+	ctx := context.Background()
+
+	for _, pipeline := range []any{
+		[]any{map[string]any{"$match": map[string]any{}}, map[string]any{"$out": "totals"}},
+		bson.A{bson.D{{Key: "$merge", Value: bson.D{{Key: "into", Value: "totals"}}}}},
+	} {
+		calls := mock.callIndex
+		if _, err := coll.Aggregate(ctx, pipeline); !IsNetworkError(err) {
+			t.Errorf("expected network error, got %v", err)
+		}
+		if mock.callIndex-calls != 1 {
+			t.Errorf("expected no retry of a writing pipeline, got %d calls", mock.callIndex-calls)
+		}
+	}
+
+	if _, err := coll.Aggregate(ctx, []any{map[string]any{"$match": map[string]any{}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mock.callIndex != 4 {
+		t.Errorf("expected the read-only pipeline to be retried, got %d calls", mock.callIndex)
+	}
+}
+
+// TestRetryNonRetryableError tests that permanent errors are returned immediately.
+func TestRetryNonRetryableError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", nil, &CommandError{Code: 2, Message: "bad value"})
+
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 3})
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := coll.Find(context.Background(), map[string]any{}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if mock.callIndex != 1 {
+		t.Errorf("expected 1 call, got %d", mock.callIndex)
+	}
+}
+
+// TestRetryBackoffUsesClock tests that retry backoff waits on the client clock.
+func TestRetryBackoffUsesClock(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.ping", nil, &CommandError{Code: 91})
+	mock.addCall("mongo.ping", "pong", nil)
+
+	clock := newFakeClock(time.Now())
+	opts := DefaultClientOptions().SetClock(clock).SetRetry(&RetryOptions{MaxAttempts: 2, Backoff: time.Second})
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", opts)
+
+	done := make(chan error, 1)
+	go func() { done <- client.Ping(context.Background()) }()
+
+	// Wait for the retry to block on the clock, then release it.
+	for {
+		clock.mu.Lock()
+		waiting := len(clock.waiters)
+		clock.mu.Unlock()
+		if waiting > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestRetryBudget tests that the budget limits retries to a fraction of requests.
+func TestRetryBudget(t *testing.T) {
+	budget := newRetryBudget(0.5)
+
+	for i := 0; i < retryBudgetReserve; i++ {
+		if !budget.withdraw() {
+			t.Fatalf("expected reserve token %d", i)
+		}
+	}
+
+	if budget.withdraw() {
+		t.Error("expected budget to be exhausted")
+	}
+
+	budget.deposit()
+	budget.deposit()
+
+	if !budget.withdraw() {
+		t.Error("expected token after two deposits")
+	}
+
+	if budget.withdraw() {
+		t.Error("expected budget to be exhausted again")
+	}
+}
+
+// TestRetryBudgetExhausted tests that operations stop retrying without budget.
+func TestRetryBudgetExhausted(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.ping", nil, &ConnectionError{Address: "localhost"})
+
+	client := newRetryTestClient(mock, &RetryOptions{MaxAttempts: 3, BudgetRatio: 0.1})
+	client.retryBudget.tokens = 0
+
+	if err := client.Ping(context.Background()); !IsNetworkError(err) {
+		t.Errorf("expected network error, got %v", err)
+	}
+
+	if mock.callIndex != 1 {
+		t.Errorf("expected 1 call, got %d", mock.callIndex)
+	}
+}
+
+// TestAdaptiveThrottle tests rejection probability under overload.
+func TestAdaptiveThrottle(t *testing.T) {
+	clock := newFakeClock(time.Now())
+	throttle := newAdaptiveThrottle(2, clock)
+	throttle.random = func() float64 { return 0 }
+
+	// Healthy traffic is never rejected.
+	for i := 0; i < 10; i++ {
+		if throttle.reject() {
+			t.Fatal("unexpected rejection while healthy")
+		}
+		throttle.observe(nil)
+	}
+
+	// Sustained overload eventually rejects locally.
+	overloaded := &CommandError{Code: 462}
+	rejected := false
+	for i := 0; i < 30; i++ {
+		if throttle.reject() {
+			rejected = true
+			break
+		}
+		throttle.observe(overloaded)
+	}
+	if !rejected {
+		t.Fatal("expected rejection under overload")
+	}
+
+	// Counters decay over time, letting traffic through again.
+	clock.Advance(10 * throttleWindow)
+	throttle.observe(nil)
+	if throttle.reject() {
+		t.Error("expected throttle to recover after decay")
+	}
+}
+
+// TestAdaptiveThrottleNetworkErrors tests that network errors count as
+// rejections.
+func TestAdaptiveThrottleNetworkErrors(t *testing.T) {
+	throttle := newAdaptiveThrottle(2, newFakeClock(time.Now()))
+	throttle.random = func() float64 { return 0 }
+
+	unreachable := &ConnectionError{Address: "localhost"}
+	rejected := false
+	for i := 0; i < 30; i++ {
+		if throttle.reject() {
+			rejected = true
+			break
+		}
+		throttle.observe(unreachable)
+	}
+	if !rejected {
+		t.Error("expected rejection while the backend is unreachable")
+	}
+	if throttle.accepts != 0 {
+		t.Errorf("expected no accepts, got %v", throttle.accepts)
+	}
+}
+
+// TestClientThrottled tests that throttled requests fail with ErrThrottled.
+func TestClientThrottled(t *testing.T) {
+	mock := newMockRPCClient()
+
+	client := newRetryTestClient(mock, &RetryOptions{ThrottleMultiplier: 2})
+	client.throttle.random = func() float64 { return 0 }
+	client.throttle.requests = 100
+
+	err := client.Ping(context.Background())
+	if !errors.Is(err, ErrThrottled) {
+		t.Errorf("expected ErrThrottled, got %v", err)
+	}
+
+	if mock.callIndex != 0 {
+		t.Errorf("expected no calls, got %d", mock.callIndex)
+	}
+}
@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongo.do/bson"
+)
+
+// extendedJSONKeys are the "$" keys of the extended JSON forms the SDK uses
+// for special values. A document holding exactly one of them is a value,
+// not an operator, and passes the unsafe key check.
+var extendedJSONKeys = map[string]bool{
+	"$oid": true, "$date": true, "$numberLong": true, "$numberInt": true,
+	"$numberDouble": true, "$numberDecimal": true, "$binary": true, "$uuid": true,
+	"$timestamp": true, "$regularExpression": true, "$minKey": true, "$maxKey": true,
+}
+
+// dbRefKeys are the "$" keys of a DBRef, which the server accepts in a
+// document holding both $ref and $id.
+var dbRefKeys = map[string]bool{"$ref": true, "$id": true, "$db": true}
+
+// checkKeys returns an UnsafeKeyError for the first key within document
+// that starts with "$" or contains ".", when the collection rejects them.
+func (c *Collection) checkKeys(document any) error {
+	if !c.rejectUnsafeKeys {
+		return nil
+	}
+	path, key, ok := findUnsafeKey(reflect.ValueOf(document), "")
+	if !ok {
+		return nil
+	}
+	return &UnsafeKeyError{Namespace: c.namespace(), Path: path, Key: key}
+}
+
+// findUnsafeKey walks v and returns the first unsafe key with the path of
+// the document holding it. Struct field names come from the program, not
+// from input, so only the values of struct fields are checked.
+func findUnsafeKey(v reflect.Value, path string) (string, string, bool) {
+	if !v.IsValid() {
+		return "", "", false
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "", "", false
+		}
+		return findUnsafeKey(v.Elem(), path)
+	case reflect.Struct:
+		for _, f := range structFields(v.Type()) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			fieldPath := path
+			if !f.inlineMap {
+				fieldPath = joinPath(path, f.name)
+			}
+			if p, k, ok := findUnsafeKey(fv, fieldPath); ok {
+				return p, k, true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return "", "", false
+		}
+		if v.Len() == 1 {
+			if key := v.MapKeys()[0].String(); extendedJSONKeys[key] {
+				return "", "", false
+			}
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = key.String()
+		}
+		dbRef := isDBRef(names)
+		for _, key := range keys {
+			if unsafeKey(key.String()) && !(dbRef && dbRefKeys[key.String()]) {
+				return path, key.String(), true
+			}
+			if p, k, ok := findUnsafeKey(v.MapIndex(key), joinPath(path, key.String())); ok {
+				return p, k, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type() == bsonDType {
+			d := v.Interface().(bson.D)
+			if len(d) == 1 && extendedJSONKeys[d[0].Key] {
+				return "", "", false
+			}
+			names := make([]string, len(d))
+			for i, e := range d {
+				names[i] = e.Key
+			}
+			dbRef := isDBRef(names)
+			for _, e := range d {
+				if unsafeKey(e.Key) && !(dbRef && dbRefKeys[e.Key]) {
+					return path, e.Key, true
+				}
+				if p, k, ok := findUnsafeKey(reflect.ValueOf(e.Value), joinPath(path, e.Key)); ok {
+					return p, k, true
+				}
+			}
+			return "", "", false
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return "", "", false
+		}
+		for i := 0; i < v.Len(); i++ {
+			if p, k, ok := findUnsafeKey(v.Index(i), joinPath(path, strconv.Itoa(i))); ok {
+				return p, k, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// isDBRef reports whether keys, those of one document, include both $ref
+// and $id.
+func isDBRef(keys []string) bool {
+	var ref, id bool
+	for _, k := range keys {
+		ref = ref || k == "$ref"
+		id = id || k == "$id"
+	}
+	return ref && id
+}
+
+// unsafeKey reports whether key could be read as an operator or a dotted
+// path by the server.
+func unsafeKey(key string) bool {
+	return strings.HasPrefix(key, "$") || strings.Contains(key, ".")
+}
+
+// joinPath appends key to a dotted document path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,95 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// TestFindUnsafeKey tests detecting operator and dotted keys at any depth.
+func TestFindUnsafeKey(t *testing.T) {
+	type profile struct {
+		Name  string
+		Attrs map[string]any
+	}
+
+	tests := []struct {
+		name string
+		doc  any
+		path string
+		key  string
+	}{
+		{"safe", map[string]any{"name": "Ada", "tags": []any{"a", "b"}}, "", ""},
+		{"operator", map[string]any{"$where": "1"}, "", "$where"},
+		{"dotted", map[string]any{"a.b": 1}, "", "a.b"},
+		{"nested", map[string]any{"profile": map[string]any{"age": map[string]any{"$gt": 0}}}, "profile.age", "$gt"},
+		{"in array", map[string]any{"items": []any{map[string]any{"ok": 1}, map[string]any{"$ne": 1}}}, "items.1", "$ne"},
+		{"ordered", bson.D{{Key: "a", Value: bson.D{{Key: "$set", Value: 1}}}}, "a", "$set"},
+		{"struct map", profile{Name: "Ada", Attrs: map[string]any{"x.y": 1}}, "Attrs", "x.y"},
+		{"typed map", map[string]string{"$inc": "1"}, "", "$inc"},
+		{"extended json", map[string]any{"_id": map[string]any{"$oid": "507f1f77bcf86cd799439011"}}, "", ""},
+		{"extended json with extra key", map[string]any{"_id": map[string]any{"$oid": "x", "y": 1}}, "_id", "$oid"},
+		{"dbref", map[string]any{"owner": map[string]any{"$ref": "users", "$id": 1, "$db": "app"}}, "", ""},
+		{"ordered dbref", bson.D{{Key: "owner", Value: bson.D{{Key: "$ref", Value: "users"}, {Key: "$id", Value: 1}}}}, "", ""},
+		{"dbref without id", map[string]any{"owner": map[string]any{"$ref": "users"}}, "owner", "$ref"},
+		{"dbref with operator", map[string]any{"owner": map[string]any{"$ref": "users", "$id": 1, "$where": "1"}}, "owner", "$where"},
+	}
+
+	for _, tt := range tests {
+		path, key, ok := findUnsafeKey(reflect.ValueOf(tt.doc), "")
+		if ok != (tt.key != "") || path != tt.path || key != tt.key {
+			t.Errorf("%s: expected %q at %q, got %q at %q (found %v)", tt.name, tt.key, tt.path, key, path, ok)
+		}
+	}
+}
+
+// TestRejectUnsafeKeys tests that a collection with the option refuses to
+// store unsafe documents, and that a clone without it is the escape hatch.
+func TestRejectUnsafeKeys(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "1"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetRejectUnsafeKeys(true))
+	ctx := context.Background()
+	input := map[string]any{"name": "Ada", "role": map[string]any{"$ne": "admin"}}
+
+	_, err := coll.InsertOne(ctx, input)
+	var keyErr *UnsafeKeyError
+	if !errors.As(err, &keyErr) || !errors.Is(err, ErrUnsafeKey) {
+		t.Fatalf("expected UnsafeKeyError, got %v", err)
+	}
+	if keyErr.Namespace != "testdb.users" || keyErr.Path != "role" || keyErr.Key != "$ne" {
+		t.Errorf("unexpected error: %+v", keyErr)
+	}
+
+	if _, err := coll.InsertMany(ctx, []any{map[string]any{"ok": 1}, input}); !errors.Is(err, ErrUnsafeKey) {
+		t.Errorf("InsertMany: expected ErrUnsafeKey, got %v", err)
+	}
+	if _, err := coll.ReplaceOne(ctx, map[string]any{"_id": "1"}, input); !errors.Is(err, ErrUnsafeKey) {
+		t.Errorf("ReplaceOne: expected ErrUnsafeKey, got %v", err)
+	}
+	if err := coll.FindOneAndReplace(ctx, map[string]any{"_id": "1"}, input).Err(); !errors.Is(err, ErrUnsafeKey) {
+		t.Errorf("FindOneAndReplace: expected ErrUnsafeKey, got %v", err)
+	}
+	if err := coll.InsertAndGet(ctx, input).Err(); !errors.Is(err, ErrUnsafeKey) {
+		t.Errorf("InsertAndGet: expected ErrUnsafeKey, got %v", err)
+	}
+	if _, err := coll.BulkWrite(ctx, []WriteModel{&InsertOneModel{Document: input}}); !errors.Is(err, ErrUnsafeKey) {
+		t.Errorf("BulkWrite: expected ErrUnsafeKey, got %v", err)
+	}
+	if mock.callIndex != 0 {
+		t.Fatalf("expected no calls, got %d", mock.callIndex)
+	}
+
+	trusted, err := coll.Clone((&CollectionOptions{}).SetRejectUnsafeKeys(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := trusted.InsertOne(ctx, input); err != nil {
+		t.Errorf("expected the trusted handle to insert, got %v", err)
+	}
+}
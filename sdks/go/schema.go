@@ -0,0 +1,994 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongo.do/bson"
+)
+
+// Schema is a compiled JSON Schema in the $jsonSchema dialect of MongoDB
+// validators. Attached to a collection handle with
+// CollectionOptions.SetSchema, it checks documents client-side before they
+// are sent, so invalid writes fail with a SchemaError listing every
+// violation instead of a single server error.
+//
+// Both the bsonType and the type keyword are supported. As documents travel
+// as JSON, which does not tell integers from doubles, "double" accepts any
+// number and "long" any integer within range.
+type Schema struct {
+	doc  map[string]any
+	root *schemaNode
+}
+
+// SchemaViolation is one failed constraint.
+type SchemaViolation struct {
+	// Pointer is the JSON Pointer (RFC 6901) of the offending value, such as
+	// "/address/zip" or "/tags/0", empty for the document itself.
+	Pointer string
+	// Keyword is the schema keyword that failed, such as "required".
+	Keyword string
+	Message string
+}
+
+// String formats the violation with its pointer and keyword.
+func (v SchemaViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s: %s", pointer, v.Keyword, v.Message)
+}
+
+// NewSchema compiles schema, a JSON Schema document given as a map, a
+// bson.D, a bson.M or JSON text. A validator of the form
+// {"$jsonSchema": {...}} is unwrapped. Unknown keywords are rejected, so
+// typos do not silently disable a constraint.
+func NewSchema(schema any) (*Schema, error) {
+	var generic any
+	var err error
+	switch s := schema.(type) {
+	case string:
+		generic, err = decodeUseNumber([]byte(s))
+	case []byte:
+		generic, err = decodeUseNumber(s)
+	default:
+		generic, err = toGeneric(schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	doc, ok := generic.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected a document, got %T", ErrInvalidSchema, schema)
+	}
+	if inner, ok := doc["$jsonSchema"].(map[string]any); ok && len(doc) == 1 {
+		doc = inner
+	}
+	root, err := compileSchema(doc, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{doc: doc, root: root}, nil
+}
+
+// SchemaFor returns a schema generated from the fields of v, which must be a
+// struct or a pointer to one. Fields follow the same `bson` and `json` tags
+// as encoding; untagged fields keep their Go names, as with ProjectionFor.
+// Fields without omitempty are required, pointers, slices and maps may be
+// null, and an inline map allows fields no other field claims. Types that
+// encode themselves, other than the bson package's, are unconstrained.
+func SchemaFor(v any) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mongo: cannot derive schema from %T", v)
+	}
+	return NewSchema(schemaForType(t, make(map[reflect.Type]bool)))
+}
+
+// Document returns the schema document.
+func (s *Schema) Document() map[string]any {
+	return s.doc
+}
+
+// Validator returns the schema as a server-side validator,
+// {"$jsonSchema": ...}, for CollModOptions.SetValidator.
+func (s *Schema) Validator() map[string]any {
+	return map[string]any{"$jsonSchema": s.doc}
+}
+
+// Validate checks document against the schema and returns a SchemaError
+// listing every violation, or nil.
+func (s *Schema) Validate(document any) error {
+	return s.validate("", document, NamingAsIs)
+}
+
+// ValidateUpdate checks an update document against the schema. The values
+// of $set and $setOnInsert are checked against the schema of their path,
+// and $unset and the sources of $rename may not remove required fields.
+// Other operators are not checked, as their result depends on the stored
+// document. A replacement document is validated as a whole and an update
+// pipeline is not checked.
+func (s *Schema) ValidateUpdate(update any) error {
+	return s.validateUpdate("", update, NamingAsIs)
+}
+
+// validate checks document, encoded with naming, for namespace ns.
+func (s *Schema) validate(ns string, document any, naming NamingStrategy) error {
+	doc, err := toGeneric(encodeNamed(document, naming))
+	if err != nil {
+		return err
+	}
+	var violations []SchemaViolation
+	s.root.validate(doc, "", &violations)
+	if len(violations) > 0 {
+		return &SchemaError{Namespace: ns, Violations: violations}
+	}
+	return nil
+}
+
+// validateUpdate checks update, encoded with naming, for namespace ns.
+func (s *Schema) validateUpdate(ns string, update any, naming NamingStrategy) error {
+	generic, err := toGeneric(encodeNamed(update, naming))
+	if err != nil {
+		return err
+	}
+	doc, ok := generic.(map[string]any)
+	if !ok {
+		return nil
+	}
+	operators := false
+	for key := range doc {
+		operators = operators || strings.HasPrefix(key, "$")
+	}
+	if !operators {
+		return s.validate(ns, doc, NamingAsIs)
+	}
+
+	var violations []SchemaViolation
+	for _, op := range []string{"$set", "$setOnInsert"} {
+		fields, _ := doc[op].(map[string]any)
+		for _, path := range sortedKeys(keySet(fields)) {
+			node, pointer, ok := s.root.resolve(path, &violations)
+			if ok && node != nil {
+				node.validate(fields[path], pointer, &violations)
+			}
+		}
+	}
+	var removed []string
+	if fields, ok := doc["$unset"].(map[string]any); ok {
+		removed = append(removed, sortedKeys(keySet(fields))...)
+	}
+	if fields, ok := doc["$rename"].(map[string]any); ok {
+		removed = append(removed, sortedKeys(keySet(fields))...)
+	}
+	for _, path := range removed {
+		parent, name := "", path
+		if i := strings.LastIndexByte(path, '.'); i >= 0 {
+			parent, name = path[:i], path[i+1:]
+		}
+		node, pointer := s.root, ""
+		if parent != "" {
+			var ok bool
+			if node, pointer, ok = s.root.resolve(parent, nil); !ok || node == nil {
+				continue
+			}
+		}
+		for _, required := range node.required {
+			if required == name {
+				violations = append(violations, SchemaViolation{
+					Pointer: pointer + "/" + escapePointer(name),
+					Keyword: "required",
+					Message: fmt.Sprintf("required property %q cannot be removed", name),
+				})
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Namespace: ns, Violations: violations}
+	}
+	return nil
+}
+
+// keySet returns the keys of m.
+func keySet(m map[string]any) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}
+
+// checkSchema validates document against the collection's schema, if any.
+func (c *Collection) checkSchema(document any) error {
+	if c.schema == nil || document == nil {
+		return nil
+	}
+	return c.schema.validate(c.namespace(), document, c.naming)
+}
+
+// checkUpdateSchema validates update against the collection's schema, if
+// any.
+func (c *Collection) checkUpdateSchema(update any) error {
+	if c.schema == nil || update == nil {
+		return nil
+	}
+	return c.schema.validateUpdate(c.namespace(), update, c.naming)
+}
+
+// schemaNode is one compiled (sub)schema. Nil constraints are unset.
+type schemaNode struct {
+	bsonTypes []string
+	jsonTypes []string
+	enum      []any
+
+	properties           map[string]*schemaNode
+	required             []string
+	additionalProperties *schemaNode
+	noAdditional         bool
+	patternProperties    []patternProperty
+	minProperties        *int
+	maxProperties        *int
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum bool
+	exclusiveMaximum bool
+	multipleOf       *float64
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	items           *schemaNode
+	tupleItems      []*schemaNode
+	additionalItems *schemaNode
+	noAdditionalTup bool
+	minItems        *int
+	maxItems        *int
+	uniqueItems     bool
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+}
+
+// patternProperty is a compiled patternProperties entry.
+type patternProperty struct {
+	pattern *regexp.Regexp
+	node    *schemaNode
+}
+
+// bsonTypeNames are the values bsonType accepts.
+var bsonTypeNames = map[string]bool{
+	"double": true, "string": true, "object": true, "array": true, "binData": true,
+	"objectId": true, "bool": true, "date": true, "null": true, "regex": true,
+	"int": true, "timestamp": true, "long": true, "decimal": true, "minKey": true,
+	"maxKey": true, "number": true, "undefined": true, "javascript": true, "symbol": true,
+}
+
+// jsonTypeNames are the values type accepts.
+var jsonTypeNames = map[string]bool{
+	"object": true, "array": true, "number": true, "integer": true,
+	"boolean": true, "string": true, "null": true,
+}
+
+// annotationKeywords describe a schema without constraining documents.
+var annotationKeywords = map[string]bool{
+	"title": true, "description": true, "$comment": true, "default": true, "examples": true,
+}
+
+// compileSchema compiles doc; pointer locates it in the schema, for errors.
+func compileSchema(doc map[string]any, pointer string) (*schemaNode, error) {
+	n := &schemaNode{}
+	invalid := func(keyword, format string, args ...any) error {
+		return fmt.Errorf("%w: %s at %q: %s", ErrInvalidSchema, keyword, pointer, fmt.Sprintf(format, args...))
+	}
+	sub := func(keyword string, v any, path string) (*schemaNode, error) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, invalid(keyword, "expected a schema, got %v", v)
+		}
+		return compileSchema(m, pointer+"/"+path)
+	}
+	subs := func(keyword string, v any) ([]*schemaNode, error) {
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			return nil, invalid(keyword, "expected a non-empty array of schemas")
+		}
+		nodes := make([]*schemaNode, len(list))
+		for i, elem := range list {
+			node, err := sub(keyword, elem, keyword+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			nodes[i] = node
+		}
+		return nodes, nil
+	}
+	count := func(keyword string, v any) (*int, error) {
+		f, ok := schemaNumber(v)
+		if !ok || f < 0 || f != math.Trunc(f) {
+			return nil, invalid(keyword, "expected a non-negative integer, got %v", v)
+		}
+		i := int(f)
+		return &i, nil
+	}
+	number := func(keyword string, v any) (*float64, error) {
+		f, ok := schemaNumber(v)
+		if !ok {
+			return nil, invalid(keyword, "expected a number, got %v", v)
+		}
+		return &f, nil
+	}
+	types := func(keyword string, v any, known map[string]bool) ([]string, error) {
+		var names []string
+		switch v := v.(type) {
+		case string:
+			names = []string{v}
+		case []any:
+			for _, elem := range v {
+				s, _ := elem.(string)
+				names = append(names, s)
+			}
+		}
+		if len(names) == 0 {
+			return nil, invalid(keyword, "expected a type name or a non-empty array of them")
+		}
+		for _, name := range names {
+			if !known[name] {
+				return nil, invalid(keyword, "unknown type %q", name)
+			}
+		}
+		return names, nil
+	}
+
+	var err error
+	for _, keyword := range sortedKeys(keySet(doc)) {
+		v := doc[keyword]
+		switch keyword {
+		case "bsonType":
+			n.bsonTypes, err = types(keyword, v, bsonTypeNames)
+		case "type":
+			n.jsonTypes, err = types(keyword, v, jsonTypeNames)
+		case "enum":
+			list, ok := v.([]any)
+			if !ok || len(list) == 0 {
+				return nil, invalid(keyword, "expected a non-empty array")
+			}
+			n.enum = list
+		case "properties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return nil, invalid(keyword, "expected a document of schemas")
+			}
+			n.properties = make(map[string]*schemaNode, len(props))
+			for name, prop := range props {
+				if n.properties[name], err = sub(keyword, prop, "properties/"+escapePointer(name)); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			list, ok := v.([]any)
+			if !ok {
+				return nil, invalid(keyword, "expected an array of names")
+			}
+			for _, elem := range list {
+				name, ok := elem.(string)
+				if !ok {
+					return nil, invalid(keyword, "expected an array of names")
+				}
+				n.required = append(n.required, name)
+			}
+		case "additionalProperties":
+			if b, ok := v.(bool); ok {
+				n.noAdditional = !b
+			} else {
+				n.additionalProperties, err = sub(keyword, v, keyword)
+			}
+		case "patternProperties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return nil, invalid(keyword, "expected a document of schemas")
+			}
+			for _, expr := range sortedKeys(keySet(props)) {
+				re, rerr := regexp.Compile(expr)
+				if rerr != nil {
+					return nil, invalid(keyword, "%v", rerr)
+				}
+				node, serr := sub(keyword, props[expr], "patternProperties/"+escapePointer(expr))
+				if serr != nil {
+					return nil, serr
+				}
+				n.patternProperties = append(n.patternProperties, patternProperty{re, node})
+			}
+		case "minProperties":
+			n.minProperties, err = count(keyword, v)
+		case "maxProperties":
+			n.maxProperties, err = count(keyword, v)
+		case "minimum":
+			n.minimum, err = number(keyword, v)
+		case "maximum":
+			n.maximum, err = number(keyword, v)
+		case "exclusiveMinimum", "exclusiveMaximum":
+			// MongoDB's draft 4 form is a boolean modifying minimum or
+			// maximum; later drafts give the bound itself.
+			exclusive, isBool := v.(bool)
+			var bound *float64
+			if !isBool {
+				if bound, err = number(keyword, v); err != nil {
+					return nil, err
+				}
+				exclusive = true
+			}
+			if keyword == "exclusiveMinimum" {
+				n.exclusiveMinimum = exclusive
+				if bound != nil {
+					n.minimum = bound
+				}
+			} else {
+				n.exclusiveMaximum = exclusive
+				if bound != nil {
+					n.maximum = bound
+				}
+			}
+		case "multipleOf":
+			if n.multipleOf, err = number(keyword, v); err == nil && *n.multipleOf <= 0 {
+				return nil, invalid(keyword, "must be greater than 0")
+			}
+		case "minLength":
+			n.minLength, err = count(keyword, v)
+		case "maxLength":
+			n.maxLength, err = count(keyword, v)
+		case "pattern":
+			s, ok := v.(string)
+			if !ok {
+				return nil, invalid(keyword, "expected a string")
+			}
+			if n.pattern, err = regexp.Compile(s); err != nil {
+				return nil, invalid(keyword, "%v", err)
+			}
+		case "items":
+			if _, ok := v.([]any); ok {
+				n.tupleItems, err = subs(keyword, v)
+			} else {
+				n.items, err = sub(keyword, v, keyword)
+			}
+		case "additionalItems":
+			if b, ok := v.(bool); ok {
+				n.noAdditionalTup = !b
+			} else {
+				n.additionalItems, err = sub(keyword, v, keyword)
+			}
+		case "minItems":
+			n.minItems, err = count(keyword, v)
+		case "maxItems":
+			n.maxItems, err = count(keyword, v)
+		case "uniqueItems":
+			b, ok := v.(bool)
+			if !ok {
+				return nil, invalid(keyword, "expected a boolean")
+			}
+			n.uniqueItems = b
+		case "allOf":
+			n.allOf, err = subs(keyword, v)
+		case "anyOf":
+			n.anyOf, err = subs(keyword, v)
+		case "oneOf":
+			n.oneOf, err = subs(keyword, v)
+		case "not":
+			n.not, err = sub(keyword, v, keyword)
+		default:
+			if !annotationKeywords[keyword] {
+				return nil, fmt.Errorf("%w: unknown keyword %q at %q", ErrInvalidSchema, keyword, pointer)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// resolve returns the schema of the dotted path within documents n
+// describes and the JSON Pointer of the path. It reports ok false, adding
+// a violation when violations is non-nil, for a path the schema forbids,
+// and returns a nil node for a path it does not constrain.
+func (n *schemaNode) resolve(path string, violations *[]SchemaViolation) (*schemaNode, string, bool) {
+	pointer := ""
+	for _, part := range strings.Split(path, ".") {
+		pointer += "/" + escapePointer(part)
+		if n == nil {
+			continue
+		}
+		if i, err := strconv.Atoi(part); err == nil && i >= 0 && (n.items != nil || n.tupleItems != nil) {
+			switch {
+			case i < len(n.tupleItems):
+				n = n.tupleItems[i]
+			case n.tupleItems != nil:
+				n = n.additionalItems
+			default:
+				n = n.items
+			}
+			continue
+		}
+		if prop, ok := n.properties[part]; ok {
+			n = prop
+			continue
+		}
+		if next, matched := n.patternProperty(part); matched {
+			n = next
+			continue
+		}
+		if n.noAdditional {
+			if violations != nil {
+				*violations = append(*violations, SchemaViolation{
+					Pointer: pointer, Keyword: "additionalProperties", Message: "property is not allowed",
+				})
+			}
+			return nil, pointer, false
+		}
+		n = n.additionalProperties
+	}
+	return n, pointer, true
+}
+
+// patternProperty returns the schema of the first patternProperties entry
+// matching name.
+func (n *schemaNode) patternProperty(name string) (*schemaNode, bool) {
+	for _, pp := range n.patternProperties {
+		if pp.pattern.MatchString(name) {
+			return pp.node, true
+		}
+	}
+	return nil, false
+}
+
+// validate checks v, a value decoded with json.Number numbers, at pointer
+// and appends the violations it finds.
+func (n *schemaNode) validate(v any, pointer string, violations *[]SchemaViolation) {
+	fail := func(keyword, format string, args ...any) {
+		*violations = append(*violations, SchemaViolation{Pointer: pointer, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	bsonType := bsonTypeOf(v)
+	if len(n.bsonTypes) > 0 && !matchesType(bsonType, n.bsonTypes, bsonTypeMatches) {
+		fail("bsonType", "expected %s, got %s", strings.Join(n.bsonTypes, " or "), bsonType)
+		return
+	}
+	if len(n.jsonTypes) > 0 && !matchesType(bsonType, n.jsonTypes, jsonTypeMatches) {
+		fail("type", "expected %s, got %s", strings.Join(n.jsonTypes, " or "), bsonType)
+		return
+	}
+	if n.enum != nil {
+		found := false
+		for _, allowed := range n.enum {
+			found = found || schemaEqual(v, allowed)
+		}
+		if !found {
+			fail("enum", "value is not one of the allowed values")
+		}
+	}
+
+	if f, ok := schemaNumber(v); ok {
+		if n.minimum != nil && n.exclusiveMinimum && f <= *n.minimum {
+			fail("minimum", "%s must be greater than %s", formatSchemaNumber(f), formatSchemaNumber(*n.minimum))
+		} else if n.minimum != nil && f < *n.minimum {
+			fail("minimum", "%s must be at least %s", formatSchemaNumber(f), formatSchemaNumber(*n.minimum))
+		}
+		if n.maximum != nil && n.exclusiveMaximum && f >= *n.maximum {
+			fail("maximum", "%s must be less than %s", formatSchemaNumber(f), formatSchemaNumber(*n.maximum))
+		} else if n.maximum != nil && f > *n.maximum {
+			fail("maximum", "%s must be at most %s", formatSchemaNumber(f), formatSchemaNumber(*n.maximum))
+		}
+		if n.multipleOf != nil {
+			if q := f / *n.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", "%s is not a multiple of %s", formatSchemaNumber(f), formatSchemaNumber(*n.multipleOf))
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			fail("minLength", "length %d is less than %d", length, *n.minLength)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			fail("maxLength", "length %d is greater than %d", length, *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			fail("pattern", "does not match %q", n.pattern.String())
+		}
+	case []any:
+		if n.minItems != nil && len(v) < *n.minItems {
+			fail("minItems", "%d items, want at least %d", len(v), *n.minItems)
+		}
+		if n.maxItems != nil && len(v) > *n.maxItems {
+			fail("maxItems", "%d items, want at most %d", len(v), *n.maxItems)
+		}
+		if n.uniqueItems {
+		unique:
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if schemaEqual(v[i], v[j]) {
+						fail("uniqueItems", "items %d and %d are equal", j, i)
+						break unique
+					}
+				}
+			}
+		}
+		for i, elem := range v {
+			itemPointer := pointer + "/" + strconv.Itoa(i)
+			switch {
+			case i < len(n.tupleItems):
+				n.tupleItems[i].validate(elem, itemPointer, violations)
+			case n.tupleItems != nil && n.noAdditionalTup:
+				*violations = append(*violations, SchemaViolation{Pointer: itemPointer, Keyword: "additionalItems", Message: "item is not allowed"})
+			case n.tupleItems != nil && n.additionalItems != nil:
+				n.additionalItems.validate(elem, itemPointer, violations)
+			case n.items != nil:
+				n.items.validate(elem, itemPointer, violations)
+			}
+		}
+	case map[string]any:
+		if bsonType == "object" {
+			n.validateObject(v, pointer, violations)
+		}
+	}
+
+	for _, s := range n.allOf {
+		s.validate(v, pointer, violations)
+	}
+	if n.anyOf != nil {
+		matched := false
+		for _, s := range n.anyOf {
+			matched = matched || s.matches(v)
+		}
+		if !matched {
+			fail("anyOf", "does not match any of the schemas")
+		}
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, s := range n.oneOf {
+			if s.matches(v) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("oneOf", "matches %d of the schemas, want exactly 1", matched)
+		}
+	}
+	if n.not != nil && n.not.matches(v) {
+		fail("not", "matches a schema it must not")
+	}
+}
+
+// validateObject checks the properties of doc.
+func (n *schemaNode) validateObject(doc map[string]any, pointer string, violations *[]SchemaViolation) {
+	for _, name := range n.required {
+		if _, ok := doc[name]; !ok {
+			*violations = append(*violations, SchemaViolation{
+				Pointer: pointer, Keyword: "required", Message: fmt.Sprintf("missing required property %q", name),
+			})
+		}
+	}
+	if n.minProperties != nil && len(doc) < *n.minProperties {
+		*violations = append(*violations, SchemaViolation{
+			Pointer: pointer, Keyword: "minProperties", Message: fmt.Sprintf("%d properties, want at least %d", len(doc), *n.minProperties),
+		})
+	}
+	if n.maxProperties != nil && len(doc) > *n.maxProperties {
+		*violations = append(*violations, SchemaViolation{
+			Pointer: pointer, Keyword: "maxProperties", Message: fmt.Sprintf("%d properties, want at most %d", len(doc), *n.maxProperties),
+		})
+	}
+	for _, name := range sortedKeys(keySet(doc)) {
+		propPointer := pointer + "/" + escapePointer(name)
+		claimed := false
+		if prop, ok := n.properties[name]; ok {
+			prop.validate(doc[name], propPointer, violations)
+			claimed = true
+		}
+		for _, pp := range n.patternProperties {
+			if pp.pattern.MatchString(name) {
+				pp.node.validate(doc[name], propPointer, violations)
+				claimed = true
+			}
+		}
+		switch {
+		case claimed:
+		case n.noAdditional:
+			*violations = append(*violations, SchemaViolation{
+				Pointer: propPointer, Keyword: "additionalProperties", Message: "property is not allowed",
+			})
+		case n.additionalProperties != nil:
+			n.additionalProperties.validate(doc[name], propPointer, violations)
+		}
+	}
+}
+
+// matches reports whether v satisfies n.
+func (n *schemaNode) matches(v any) bool {
+	var violations []SchemaViolation
+	n.validate(v, "", &violations)
+	return len(violations) == 0
+}
+
+// bsonTypeOf returns the BSON type name of a generically decoded value,
+// recognizing the extended JSON forms of special values.
+func bsonTypeOf(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case json.Number:
+		if n, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return "int"
+			}
+			return "long"
+		}
+		return "double"
+	case map[string]any:
+		if len(v) == 1 {
+			for key := range v {
+				switch key {
+				case "$oid":
+					return "objectId"
+				case "$date":
+					return "date"
+				case "$numberDecimal":
+					return "decimal"
+				case "$numberLong":
+					return "long"
+				case "$numberInt":
+					return "int"
+				case "$numberDouble":
+					return "double"
+				case "$binary", "$uuid":
+					return "binData"
+				case "$timestamp":
+					return "timestamp"
+				case "$regularExpression":
+					return "regex"
+				case "$minKey":
+					return "minKey"
+				case "$maxKey":
+					return "maxKey"
+				}
+			}
+		}
+		return "object"
+	}
+	return "object"
+}
+
+// matchesType reports whether a value of type actual satisfies one of the
+// type names, as decided by match.
+func matchesType(actual string, names []string, match func(actual, name string) bool) bool {
+	for _, name := range names {
+		if match(actual, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// bsonTypeMatches reports whether actual satisfies the bsonType name. JSON
+// does not keep integer widths or tell whole doubles from integers, so
+// "long" accepts int values and "double" accepts both integer types.
+func bsonTypeMatches(actual, name string) bool {
+	switch name {
+	case "number":
+		return actual == "int" || actual == "long" || actual == "double" || actual == "decimal"
+	case "long":
+		return actual == "int" || actual == "long"
+	case "double":
+		return actual == "int" || actual == "long" || actual == "double"
+	}
+	return actual == name
+}
+
+// jsonTypeMatches reports whether actual satisfies the type name.
+func jsonTypeMatches(actual, name string) bool {
+	switch name {
+	case "number":
+		return bsonTypeMatches(actual, "number")
+	case "integer":
+		return actual == "int" || actual == "long"
+	case "boolean":
+		return actual == "bool"
+	}
+	return actual == name
+}
+
+// schemaNumber returns v as a float64 when it is a number, plain or in
+// extended JSON form.
+func schemaNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case map[string]any:
+		if len(v) != 1 {
+			return 0, false
+		}
+		for _, key := range []string{"$numberInt", "$numberLong", "$numberDouble", "$numberDecimal"} {
+			if s, ok := v[key].(string); ok {
+				f, err := strconv.ParseFloat(s, 64)
+				return f, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// formatSchemaNumber formats f without an exponent for common values.
+func formatSchemaNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// schemaEqual reports whether two generically decoded values are equal,
+// comparing numbers by value.
+func schemaEqual(a, b any) bool {
+	if fa, ok := schemaNumber(a); ok {
+		fb, ok := schemaNumber(b)
+		return ok && fa == fb
+	}
+	switch a := a.(type) {
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !schemaEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !schemaEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// escapePointer escapes a JSON Pointer reference token.
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// Types with a fixed BSON type in generated schemas.
+var (
+	objectIDType = reflect.TypeOf(bson.ObjectID{})
+	decimalType  = reflect.TypeOf(bson.Decimal128{})
+	uuidType     = reflect.TypeOf(bson.UUID{})
+	binaryType   = reflect.TypeOf(bson.Binary{})
+)
+
+// schemaForType returns the schema document for values of type t. Types
+// being generated are in seen; recursive references to them are left
+// unconstrained.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	switch t {
+	case timeType, dateTimeType:
+		return map[string]any{"bsonType": "date"}
+	case objectIDType:
+		return map[string]any{"bsonType": "objectId"}
+	case decimalType:
+		return map[string]any{"bsonType": "decimal"}
+	case uuidType, binaryType:
+		return map[string]any{"bsonType": "binData"}
+	case jsonNumberType:
+		return map[string]any{"bsonType": "number"}
+	}
+	if marshalsItself(t) || seen[t] {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaForType(t.Elem(), seen))
+	case reflect.Bool:
+		return map[string]any{"bsonType": "bool"}
+	case reflect.String:
+		return map[string]any{"bsonType": "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return map[string]any{"bsonType": "int"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"bsonType": []any{"int", "long"}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"bsonType": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string.
+			return nullable(map[string]any{"bsonType": "string"})
+		}
+		return nullable(arraySchema(schemaForType(t.Elem(), seen)))
+	case reflect.Array:
+		doc := arraySchema(schemaForType(t.Elem(), seen))
+		doc["minItems"], doc["maxItems"] = t.Len(), t.Len()
+		return doc
+	case reflect.Map:
+		doc := map[string]any{"bsonType": "object"}
+		if elem := schemaForType(t.Elem(), seen); len(elem) > 0 {
+			doc["additionalProperties"] = elem
+		}
+		return nullable(doc)
+	case reflect.Struct:
+		seen[t] = true
+		defer delete(seen, t)
+		properties := make(map[string]any)
+		var required []any
+		doc := map[string]any{"bsonType": "object"}
+		for _, f := range structFields(t) {
+			ft := t.FieldByIndex(f.index).Type
+			if f.inlineMap {
+				if elem := schemaForType(ft.Elem(), seen); len(elem) > 0 {
+					doc["additionalProperties"] = elem
+				}
+				continue
+			}
+			name := f.documentName(NamingAsIs)
+			properties[name] = schemaForType(ft, seen)
+			if !f.omitEmpty {
+				required = append(required, name)
+			}
+		}
+		if len(properties) > 0 {
+			doc["properties"] = properties
+		}
+		if len(required) > 0 {
+			doc["required"] = required
+		}
+		return doc
+	}
+	return map[string]any{}
+}
+
+// arraySchema returns an array schema with items, when constrained.
+func arraySchema(items map[string]any) map[string]any {
+	doc := map[string]any{"bsonType": "array"}
+	if len(items) > 0 {
+		doc["items"] = items
+	}
+	return doc
+}
+
+// nullable allows null in addition to the types of doc.
+func nullable(doc map[string]any) map[string]any {
+	switch t := doc["bsonType"].(type) {
+	case string:
+		doc["bsonType"] = []any{t, "null"}
+	case []any:
+		doc["bsonType"] = append(t, "null")
+	}
+	return doc
+}
@@ -0,0 +1,285 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongo.do/bson"
+)
+
+// testUserSchema returns a schema exercising the common keywords.
+func testUserSchema(t *testing.T) *Schema {
+	t.Helper()
+	schema, err := NewSchema(`{"$jsonSchema": {
+		"bsonType": "object",
+		"required": ["email", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"_id": {"bsonType": "objectId"},
+			"email": {"bsonType": "string", "pattern": "^[^@]+@[^@]+$"},
+			"age": {"bsonType": "int", "minimum": 0, "maximum": 150},
+			"score": {"bsonType": "double", "minimum": 0, "exclusiveMinimum": true},
+			"role": {"enum": ["admin", "user"]},
+			"created": {"bsonType": "date"},
+			"tags": {"bsonType": "array", "maxItems": 2, "uniqueItems": true, "items": {"bsonType": "string", "minLength": 1}},
+			"address": {
+				"bsonType": "object",
+				"required": ["city"],
+				"properties": {"city": {"bsonType": "string"}, "zip/code": {"type": "string", "maxLength": 5}}
+			}
+		}
+	}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return schema
+}
+
+// TestSchemaValidate tests validating documents and the violations reported.
+func TestSchemaValidate(t *testing.T) {
+	schema := testUserSchema(t)
+
+	valid := map[string]any{
+		"_id":     bson.NewObjectID(),
+		"email":   "ada@example.com",
+		"age":     36,
+		"score":   float64(2),
+		"role":    "admin",
+		"created": time.Now(),
+		"tags":    []string{"a", "b"},
+		"address": map[string]any{"city": "London", "zip/code": "N1"},
+	}
+	if err := schema.Validate(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := map[string]any{
+		"email":   "nobody",
+		"score":   0,
+		"role":    "root",
+		"created": "yesterday",
+		"tags":    []any{"a", "a", ""},
+		"address": map[string]any{"zip/code": "123456"},
+		"extra":   true,
+	}
+	err := schema.Validate(invalid)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	var got []string
+	for _, v := range schemaErr.Violations {
+		got = append(got, v.Pointer+" "+v.Keyword)
+	}
+	want := []string{
+		" required",
+		"/address required",
+		"/address/zip~1code maxLength",
+		"/created bsonType",
+		"/email pattern",
+		"/extra additionalProperties",
+		"/role enum",
+		"/score minimum",
+		"/tags maxItems",
+		"/tags uniqueItems",
+		"/tags/2 minLength",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected violations\n%v\ngot\n%v", want, got)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `/: required: missing required property "age"`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+// TestSchemaCombinators tests allOf, anyOf, oneOf, not and tuple items.
+func TestSchemaCombinators(t *testing.T) {
+	schema, err := NewSchema(map[string]any{
+		"properties": map[string]any{
+			"id":    map[string]any{"anyOf": []any{map[string]any{"bsonType": "string"}, map[string]any{"bsonType": "long"}}},
+			"kind":  map[string]any{"oneOf": []any{map[string]any{"enum": []any{"a", "b"}}, map[string]any{"enum": []any{"b", "c"}}}},
+			"name":  map[string]any{"not": map[string]any{"enum": []any{"root"}}},
+			"point": map[string]any{"items": []any{map[string]any{"type": "number"}, map[string]any{"type": "number"}}, "additionalItems": false},
+			"count": map[string]any{"allOf": []any{map[string]any{"type": "integer"}, map[string]any{"multipleOf": 5}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := schema.Validate(map[string]any{"id": int64(1) << 40, "kind": "a", "name": "ada", "point": []any{1, 2.5}, "count": 10}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = schema.Validate(map[string]any{"id": true, "kind": "b", "name": "root", "point": []any{1, 2, 3}, "count": 7.5})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	var got []string
+	for _, v := range schemaErr.Violations {
+		got = append(got, v.Pointer+" "+v.Keyword)
+	}
+	want := []string{"/count type", "/count multipleOf", "/id anyOf", "/kind oneOf", "/name not", "/point/2 additionalItems"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected violations\n%v\ngot\n%v", want, got)
+	}
+}
+
+// TestNewSchemaInvalid tests rejecting schemas that cannot be compiled.
+func TestNewSchemaInvalid(t *testing.T) {
+	for _, schema := range []any{
+		`{"bsonType": "object", "propertise": {}}`,
+		`{"bsonType": "integer"}`,
+		`{"pattern": "("}`,
+		`{"minLength": -1}`,
+		`{"properties": {"a": 1}}`,
+		`[1]`,
+	} {
+		if _, err := NewSchema(schema); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%v: expected ErrInvalidSchema, got %v", schema, err)
+		}
+	}
+}
+
+type schemaAddress struct {
+	City string `bson:"city"`
+	Zip  string `bson:"zip,omitempty"`
+}
+
+type schemaUser struct {
+	ID      bson.ObjectID  `bson:"_id,omitempty"`
+	Email   string         `bson:"email"`
+	Age     int32          `bson:"age"`
+	Visits  int64          `bson:"visits"`
+	Score   float64        `bson:"score"`
+	Created time.Time      `bson:"created"`
+	Address *schemaAddress `bson:"address"`
+	Tags    []string       `bson:"tags"`
+	Extra   map[string]any `bson:",inline"`
+}
+
+// TestSchemaFor tests generating a schema from a struct.
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor(&schemaUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, err := NewSchema(map[string]any{
+		"bsonType": "object",
+		"required": []any{"email", "age", "visits", "score", "created", "address", "tags"},
+		"properties": map[string]any{
+			"_id":     map[string]any{"bsonType": "objectId"},
+			"email":   map[string]any{"bsonType": "string"},
+			"age":     map[string]any{"bsonType": "int"},
+			"visits":  map[string]any{"bsonType": []any{"int", "long"}},
+			"score":   map[string]any{"bsonType": "number"},
+			"created": map[string]any{"bsonType": "date"},
+			"address": map[string]any{
+				"bsonType":   []any{"object", "null"},
+				"required":   []any{"city"},
+				"properties": map[string]any{"city": map[string]any{"bsonType": "string"}, "zip": map[string]any{"bsonType": "string"}},
+			},
+			"tags": map[string]any{"bsonType": []any{"array", "null"}, "items": map[string]any{"bsonType": "string"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(schema.Document(), want.Document()) {
+		t.Errorf("expected\n%v\ngot\n%v", want.Document(), schema.Document())
+	}
+	if v := schema.Validator(); !reflect.DeepEqual(v["$jsonSchema"], schema.Document()) {
+		t.Errorf("unexpected validator %v", v)
+	}
+
+	user := schemaUser{Email: "ada@example.com", Age: 36, Visits: 1 << 40, Score: 1.5, Created: time.Now(), Extra: map[string]any{"note": "x"}}
+	if err := schema.Validate(user); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := schema.Validate(map[string]any{"email": 1}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected ErrSchemaViolation, got %v", err)
+	}
+	if _, err := SchemaFor(3); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+// TestSchemaValidateUpdate tests validating update documents.
+func TestSchemaValidateUpdate(t *testing.T) {
+	schema := testUserSchema(t)
+
+	if err := schema.ValidateUpdate(map[string]any{"$set": map[string]any{"age": 40, "address.city": "Paris"}, "$inc": map[string]any{"age": 1}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := schema.ValidateUpdate([]any{map[string]any{"$set": map[string]any{"age": "x"}}}); err != nil {
+		t.Errorf("expected pipelines to pass, got %v", err)
+	}
+
+	err := schema.ValidateUpdate(map[string]any{
+		"$set":         map[string]any{"age": -1, "tags.0": "", "nickname": "x"},
+		"$setOnInsert": map[string]any{"address.zip/code": "1234567"},
+		"$unset":       map[string]any{"email": "", "address.city": "", "role": ""},
+	})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	var got []string
+	for _, v := range schemaErr.Violations {
+		got = append(got, v.Pointer+" "+v.Keyword)
+	}
+	want := []string{
+		"/age minimum",
+		"/nickname additionalProperties",
+		"/tags/0 minLength",
+		"/address/zip~1code maxLength",
+		"/address/city required",
+		"/email required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected violations\n%v\ngot\n%v", want, got)
+	}
+
+	if err := schema.ValidateUpdate(map[string]any{"email": "ada@example.com"}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected a replacement missing age to fail, got %v", err)
+	}
+}
+
+// TestCollectionSchema tests that writes are validated before they are sent.
+func TestCollectionSchema(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "u1"}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetSchema(testUserSchema(t)))
+	ctx := context.Background()
+
+	_, err := coll.InsertOne(ctx, map[string]any{"email": "ada@example.com", "age": -3})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Namespace != "testdb.users" || schemaErr.Violations[0].Pointer != "/age" {
+		t.Fatalf("expected a SchemaError for /age, got %v", err)
+	}
+	if _, err := coll.UpdateOne(ctx, map[string]any{"_id": "u1"}, map[string]any{"$set": map[string]any{"role": "root"}}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected ErrSchemaViolation, got %v", err)
+	}
+	if _, err := coll.ReplaceOne(ctx, map[string]any{"_id": "u1"}, map[string]any{"age": 1}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected ErrSchemaViolation, got %v", err)
+	}
+	if _, err := coll.BulkWrite(ctx, []WriteModel{&InsertOneModel{Document: map[string]any{"email": "x"}}}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected ErrSchemaViolation, got %v", err)
+	}
+	if mock.callIndex != 0 {
+		t.Fatalf("expected no calls, got %d", mock.callIndex)
+	}
+
+	if _, err := coll.InsertOne(ctx, map[string]any{"email": "ada@example.com", "age": 36}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clone, err := coll.Clone()
+	if err != nil || clone.schema != coll.schema {
+		t.Errorf("expected the clone to keep the schema, got %v", err)
+	}
+}
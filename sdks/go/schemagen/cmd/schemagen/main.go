@@ -0,0 +1,83 @@
+// Command schemagen samples a collection and writes Go struct definitions
+// for its documents. It is meant to run from go:generate:
+//
+//	//go:generate go run go.mongo.do/schemagen/cmd/schemagen -uri mongodb://localhost:27017 -db shop -collection orders -type Order
+//
+// With -validator, the structs are generated from the collection's
+// $jsonSchema validator instead of sampled documents, along with a
+// constructor checking documents against it.
+//
+// The package defaults to $GOPACKAGE, which go generate sets, and the
+// output file to the lower-cased type name with a _gen.go suffix.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/schemagen"
+)
+
+func main() {
+	uri := flag.String("uri", os.Getenv("MONGO_URL"), "connection URI; defaults to $MONGO_URL")
+	db := flag.String("db", "", "database name")
+	collection := flag.String("collection", "", "collection name")
+	typeName := flag.String("type", "Document", "name of the generated struct")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file; defaults to $GOPACKAGE")
+	sample := flag.Int64("sample", 100, "number of documents sampled")
+	out := flag.String("o", "", "output file; defaults to <type>_gen.go, or - for standard output")
+	validator := flag.Bool("validator", false, "generate from the collection's $jsonSchema validator instead of sampled documents")
+	timeout := flag.Duration("timeout", 30*time.Second, "time allowed for connecting and sampling")
+	flag.Parse()
+
+	if *uri == "" || *db == "" || *collection == "" {
+		fmt.Fprintln(os.Stderr, "schemagen: -uri, -db and -collection are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	if *out == "" {
+		*out = strings.ToLower(*typeName) + "_gen.go"
+	}
+
+	if err := run(*uri, *db, *collection, *out, *validator, *timeout, schemagen.DefaultOptions().
+		SetSampleSize(*sample).
+		SetTypeName(*typeName).
+		SetPackage(*pkg)); err != nil {
+		fmt.Fprintln(os.Stderr, "schemagen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(uri, db, collection, out string, validator bool, timeout time.Duration, opts *schemagen.Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client, err := mongo.NewClient(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(ctx)
+
+	var src []byte
+	if validator {
+		src, err = schemagen.GenerateFromValidator(ctx, client.Database(db), collection, opts)
+	} else {
+		src, err = schemagen.Generate(ctx, client.Database(db).Collection(collection), opts)
+	}
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
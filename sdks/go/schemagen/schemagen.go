@@ -0,0 +1,523 @@
+// Package schemagen infers the shape of a collection's documents from a
+// sample and generates Go struct definitions for them:
+//
+//	src, err := schemagen.Generate(ctx, client.Database("shop").Collection("orders"),
+//		schemagen.DefaultOptions().SetTypeName("Order").SetPackage("shop"))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	os.WriteFile("order_gen.go", src, 0o644)
+//
+// Fields get `bson` and `json` tags with their document keys. Fields missing
+// from some sampled documents are tagged omitempty, fields holding null
+// become pointers, and nested documents become struct types of their own,
+// named after the enclosing type and the field. Fields whose values differ
+// in type across the sample become any. Each partial or mixed field carries
+// a comment saying so, as the generated code is a starting point to review
+// rather than a contract.
+//
+// For collections with a $jsonSchema validator, GenerateFromValidator
+// generates the structs from the validator instead, along with a
+// constructor checking documents against it, so application models follow
+// the server-side schema.
+//
+// The schemagen command in cmd/schemagen runs Generate from go:generate.
+package schemagen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+// ErrNoDocuments is returned by Infer and Generate when the sample is empty.
+var ErrNoDocuments = errors.New("schemagen: no documents sampled")
+
+// Source is the collection documents are sampled from. *mongo.Collection
+// implements it.
+type Source interface {
+	Find(ctx context.Context, filter any, opts ...*mongo.FindOptions) (*mongo.Cursor, error)
+}
+
+// Options configures schema inference and generation.
+type Options struct {
+	// SampleSize is the number of documents sampled.
+	SampleSize int64
+	// Filter selects the documents sampled. Nil samples any documents.
+	Filter any
+	// TypeName is the name of the generated top-level struct.
+	TypeName string
+	// Package is the package clause of the generated file.
+	Package string
+}
+
+// DefaultOptions returns the default options: 100 documents sampled into a
+// struct named Document in package main.
+func DefaultOptions() *Options {
+	return &Options{
+		SampleSize: 100,
+		TypeName:   "Document",
+		Package:    "main",
+	}
+}
+
+// SetSampleSize sets the number of documents sampled.
+func (o *Options) SetSampleSize(n int64) *Options {
+	o.SampleSize = n
+	return o
+}
+
+// SetFilter sets the filter selecting the documents sampled.
+func (o *Options) SetFilter(filter any) *Options {
+	o.Filter = filter
+	return o
+}
+
+// SetTypeName sets the name of the generated top-level struct.
+func (o *Options) SetTypeName(name string) *Options {
+	o.TypeName = name
+	return o
+}
+
+// SetPackage sets the package of the generated file.
+func (o *Options) SetPackage(pkg string) *Options {
+	o.Package = pkg
+	return o
+}
+
+// Schema is the shape inferred from sampled documents.
+type Schema struct {
+	// Samples is the number of documents sampled.
+	Samples int
+	root    *object
+}
+
+// object accumulates the fields of the documents seen at one position.
+type object struct {
+	// docs is the number of documents seen.
+	docs   int
+	fields []*field
+	index  map[string]*field
+}
+
+// field is a document key and the values seen under it.
+type field struct {
+	key   string
+	shape *shape
+}
+
+// shape accumulates the values seen at one position.
+type shape struct {
+	// present is the number of values seen, nulls included.
+	present int
+	nulls   int
+	// types counts the other values by BSON type name.
+	types map[string]int
+	// object holds the fields of the documents seen, and elem the elements
+	// of the arrays seen.
+	object *object
+	elem   *shape
+}
+
+func newObject() *object {
+	return &object{index: make(map[string]*field)}
+}
+
+func newShape() *shape {
+	return &shape{types: make(map[string]int)}
+}
+
+// add records the fields of doc.
+func (o *object) add(doc bson.Raw) error {
+	elems, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+	o.docs++
+	for _, e := range elems {
+		f, ok := o.index[e.Key]
+		if !ok {
+			f = &field{key: e.Key, shape: newShape()}
+			o.index[e.Key] = f
+			o.fields = append(o.fields, f)
+		}
+		if err := f.shape.add(e.Value); err != nil {
+			return fmt.Errorf("%s: %w", e.Key, err)
+		}
+	}
+	return nil
+}
+
+// add records v.
+func (s *shape) add(v bson.RawValue) error {
+	s.present++
+	switch t := v.Type(); t {
+	case "null":
+		s.nulls++
+	case "object":
+		s.types[t]++
+		if s.object == nil {
+			s.object = newObject()
+		}
+		doc, _ := v.DocumentOK()
+		return s.object.add(doc)
+	case "array":
+		s.types[t]++
+		if s.elem == nil {
+			s.elem = newShape()
+		}
+		items, _ := v.ArrayOK()
+		for _, item := range items {
+			if err := s.elem.add(item); err != nil {
+				return err
+			}
+		}
+	case "binData":
+		if _, ok := v.UUIDOK(); ok {
+			t = "uuid"
+		}
+		s.types[t]++
+	case "":
+		return fmt.Errorf("malformed value %s", v)
+	default:
+		s.types[t]++
+	}
+	return nil
+}
+
+// Infer samples up to opts.SampleSize documents matching opts.Filter from
+// source and returns their shape. A nil opts uses DefaultOptions.
+func Infer(ctx context.Context, source Source, opts *Options) (*Schema, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	if opts.SampleSize <= 0 {
+		return nil, fmt.Errorf("schemagen: sample size must be positive, got %d", opts.SampleSize)
+	}
+	filter := opts.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cur, err := source.Find(ctx, filter, (&mongo.FindOptions{}).SetLimit(opts.SampleSize))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	s := &Schema{root: newObject()}
+	for cur.Next(ctx) {
+		if err := s.root.add(cur.Current()); err != nil {
+			return nil, fmt.Errorf("schemagen: document %d: %w", s.Samples, err)
+		}
+		s.Samples++
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	if s.Samples == 0 {
+		return nil, ErrNoDocuments
+	}
+	return s, nil
+}
+
+// Generate samples documents from source as Infer does and returns the
+// formatted Go source of their struct definitions. A nil opts uses
+// DefaultOptions.
+func Generate(ctx context.Context, source Source, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	s, err := Infer(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.Source(opts.Package, opts.TypeName)
+}
+
+// Source returns the formatted Go source of a file in package pkg defining
+// the struct typeName and the structs of its nested documents.
+func (s *Schema) Source(pkg, typeName string) ([]byte, error) {
+	if !isIdentifier(pkg) || !isIdentifier(typeName) {
+		return nil, fmt.Errorf("schemagen: invalid package %q or type name %q", pkg, typeName)
+	}
+	g := newGenerator(typeName)
+	g.structType(typeName, s.root)
+	return g.source(fmt.Sprintf("from %d sampled documents", s.Samples), pkg)
+}
+
+// generator writes struct definitions, naming the nested ones uniquely,
+// and the declarations that follow them.
+type generator struct {
+	structs []string
+	decls   []string
+	names   map[string]bool
+	// imports maps the import paths used to their names, empty for the
+	// default.
+	imports map[string]string
+}
+
+// newGenerator returns a generator for the top-level struct typeName.
+func newGenerator(typeName string) *generator {
+	g := &generator{names: make(map[string]bool), imports: make(map[string]string)}
+	g.names[typeName] = true
+	return g
+}
+
+// source returns the formatted file in package pkg, with a generated-code
+// header saying where the definitions come from.
+func (g *generator) source(from, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by schemagen %s; DO NOT EDIT.\n\n", from)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		// Standard library imports come first, in a group of their own.
+		var std, other []string
+		for path := range g.imports {
+			if strings.Contains(path, ".") {
+				other = append(other, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+		buf.WriteString("import (\n")
+		for i, group := range [][]string{std, other} {
+			if i > 0 && len(std) > 0 && len(other) > 0 {
+				buf.WriteString("\n")
+			}
+			for _, path := range group {
+				if name := g.imports[path]; name != "" {
+					fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+				} else {
+					fmt.Fprintf(&buf, "\t%q\n", path)
+				}
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+	for _, def := range g.structs {
+		buf.WriteString(def)
+	}
+	for _, decl := range g.decls {
+		buf.WriteString(decl)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// scalarTypes maps BSON type names to Go types, and the import each needs.
+var scalarTypes = map[string]struct{ goType, importPath string }{
+	"double":    {"float64", ""},
+	"string":    {"string", ""},
+	"bool":      {"bool", ""},
+	"int":       {"int", ""},
+	"long":      {"int64", ""},
+	"binData":   {"[]byte", ""},
+	"uuid":      {"bson.UUID", "go.mongo.do/bson"},
+	"objectId":  {"bson.ObjectID", "go.mongo.do/bson"},
+	"date":      {"time.Time", "time"},
+	"decimal":   {"bson.Decimal128", "go.mongo.do/bson"},
+	"timestamp": {"bson.Timestamp", "go.mongo.do/bson"},
+	"regex":     {"bson.Regex", "go.mongo.do/bson"},
+}
+
+// structType adds the definition of struct name for the documents of o,
+// followed by those of its nested documents.
+func (g *generator) structType(name string, o *object) {
+	i := len(g.structs)
+	g.structs = append(g.structs, "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	used := make(map[string]bool)
+	for _, f := range o.fields {
+		if strings.ContainsAny(f.key, "\"`,") {
+			fmt.Fprintf(&b, "\t// Field %q is left out: its key cannot be written in a struct tag.\n", f.key)
+			continue
+		}
+		goName := uniqueName(fieldName(f.key), used)
+		goType, mixed := g.goType(name+goName, f.shape)
+
+		var notes []string
+		if f.shape.present < o.docs {
+			notes = append(notes, fmt.Sprintf("seen in %d of %d documents", f.shape.present, o.docs))
+		}
+		if len(mixed) > 0 {
+			notes = append(notes, "mixed types: "+strings.Join(mixed, ", "))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, "\t// %s is %s.\n", goName, strings.Join(notes, "; "))
+		}
+
+		tag := f.key
+		if f.shape.present < o.docs {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `bson:%q json:%q`\n", goName, goType, tag, tag)
+	}
+	b.WriteString("}\n\n")
+	g.structs[i] = b.String()
+}
+
+// goType returns the Go type of the values of s, defining a struct named
+// after name for documents, and the BSON type names seen if they have no
+// common Go type.
+func (g *generator) goType(name string, s *shape) (string, []string) {
+	types := make([]string, 0, len(s.types))
+	for t := range s.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var goType string
+	switch {
+	case len(types) == 0:
+		return "any", nil
+	case len(types) == 1 && types[0] == "object":
+		structName := uniqueName(name, g.names)
+		g.structType(structName, s.object)
+		goType = structName
+	case len(types) == 1 && types[0] == "array":
+		if s.elem == nil {
+			return "[]any", nil
+		}
+		elemType, mixed := g.goType(name, s.elem)
+		return "[]" + elemType, mixed
+	case isNumeric(types):
+		goType = numericType(s.types)
+	case len(types) == 1:
+		goType = g.scalarType(types[0])
+		if goType == "any" {
+			return goType, nil
+		}
+	default:
+		return "any", types
+	}
+
+	if s.nulls > 0 && !strings.HasPrefix(goType, "[]") {
+		goType = "*" + goType
+	}
+	return goType, nil
+}
+
+// scalarType returns the Go type of values of BSON type t, importing its
+// package, or any for types with no Go counterpart.
+func (g *generator) scalarType(t string) string {
+	scalar, ok := scalarTypes[t]
+	if !ok {
+		return "any"
+	}
+	if scalar.importPath != "" {
+		g.imports[scalar.importPath] = ""
+	}
+	return scalar.goType
+}
+
+// numericType returns the Go type holding numbers of all the BSON types
+// counted in types: int, int64 or float64.
+func numericType(types map[string]int) string {
+	switch {
+	case types["double"] > 0:
+		return "float64"
+	case types["long"] > 0:
+		return "int64"
+	}
+	return "int"
+}
+
+// isNumeric reports whether types are all numbers.
+func isNumeric(types []string) bool {
+	for _, t := range types {
+		if t != "int" && t != "long" && t != "double" {
+			return false
+		}
+	}
+	return true
+}
+
+// initialisms are the words written in upper case in Go names.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "TTL": true, "URI": true,
+	"URL": true, "UUID": true,
+}
+
+// fieldName returns the exported Go name of a document key: words split at
+// separators and lower-to-upper case changes are capitalized, and _id
+// becomes ID.
+func fieldName(key string) string {
+	var b strings.Builder
+	for _, word := range splitWords(key) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	name := b.String()
+	switch {
+	case name == "":
+		return "Field"
+	case unicode.IsDigit([]rune(name)[0]):
+		return "F" + name
+	}
+	return name
+}
+
+// splitWords splits key into its words of letters and digits.
+func splitWords(key string) []string {
+	var words []string
+	var word []rune
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		case unicode.IsUpper(r) && len(word) > 0 && unicode.IsLower(word[len(word)-1]):
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// uniqueName returns name, or name with the smallest numeric suffix not in
+// used, and adds it to used.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// isIdentifier reports whether s is a Go identifier.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
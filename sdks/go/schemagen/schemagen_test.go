@@ -0,0 +1,122 @@
+package schemagen
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mongo "go.mongo.do"
+	"go.mongo.do/bson"
+)
+
+// fakeSource returns its documents from Find and records the options.
+type fakeSource struct {
+	docs []any
+	opts []*mongo.FindOptions
+}
+
+func (f *fakeSource) Find(ctx context.Context, filter any, opts ...*mongo.FindOptions) (*mongo.Cursor, error) {
+	f.opts = opts
+	return mongo.NewCursorFromDocuments(f.docs), nil
+}
+
+// TestGenerate tests generating structs from sampled documents.
+func TestGenerate(t *testing.T) {
+	created := bson.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	source := &fakeSource{docs: []any{
+		bson.D{
+			{Key: "_id", Value: bson.NewObjectID()},
+			{Key: "customer_name", Value: "Ada"},
+			{Key: "total", Value: 12},
+			{Key: "createdAt", Value: created},
+			{Key: "address", Value: bson.D{{Key: "city", Value: "London"}}},
+			{Key: "items", Value: bson.A{bson.D{{Key: "sku", Value: "a"}, {Key: "qty", Value: 1}}}},
+			{Key: "note", Value: nil},
+			{Key: "ref", Value: "x"},
+		},
+		bson.D{
+			{Key: "_id", Value: bson.NewObjectID()},
+			{Key: "customer_name", Value: "Grace"},
+			{Key: "total", Value: 7.5},
+			{Key: "createdAt", Value: created},
+			{Key: "address", Value: bson.D{{Key: "city", Value: "Paris"}, {Key: "zip", Value: "75001"}}},
+			{Key: "items", Value: bson.A{}},
+			{Key: "note", Value: "gift"},
+			{Key: "ref", Value: 3},
+		},
+	}}
+
+	src, err := Generate(context.Background(), source, DefaultOptions().SetTypeName("Order").SetPackage("shop").SetSampleSize(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limit := source.opts[0].Limit; limit == nil || *limit != 2 {
+		t.Errorf("expected a limit of 2, got %v", limit)
+	}
+
+	// Compare with runs of spaces collapsed, as gofmt aligns fields.
+	got := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"package shop",
+		"import ( \"time\" \"go.mongo.do/bson\" )",
+		"type Order struct {",
+		"ID bson.ObjectID `bson:\"_id\" json:\"_id\"`",
+		"CustomerName string `bson:\"customer_name\" json:\"customer_name\"`",
+		"Total float64",
+		"CreatedAt time.Time",
+		"Address OrderAddress",
+		"Items []OrderItems",
+		"Note *string",
+		"// Ref is mixed types: int, string.",
+		"Ref any",
+		"type OrderAddress struct {",
+		"// Zip is seen in 1 of 2 documents.",
+		"Zip string `bson:\"zip,omitempty\" json:\"zip,omitempty\"`",
+		"type OrderItems struct {",
+		"Qty int `bson:\"qty\" json:\"qty\"`",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the source to contain %q, got:\n%s", want, src)
+		}
+	}
+	if strings.Index(got, "type Order struct") > strings.Index(got, "type OrderAddress struct") {
+		t.Errorf("expected the top-level struct first, got:\n%s", src)
+	}
+}
+
+// TestGenerateErrors tests rejecting empty samples and invalid names.
+func TestGenerateErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Generate(ctx, &fakeSource{}, nil); !errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNoDocuments, got %v", err)
+	}
+
+	source := &fakeSource{docs: []any{bson.M{"a": 1}}}
+	if _, err := Generate(ctx, source, DefaultOptions().SetTypeName("not a name")); err == nil {
+		t.Error("expected an error for an invalid type name")
+	}
+	if _, err := Generate(ctx, source, DefaultOptions().SetSampleSize(0)); err == nil {
+		t.Error("expected an error for a zero sample size")
+	}
+}
+
+// TestFieldName tests converting document keys to Go names.
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"_id":        "ID",
+		"name":       "Name",
+		"first_name": "FirstName",
+		"userId":     "UserID",
+		"homeURL":    "HomeURL",
+		"2fa":        "F2fa",
+		"$":          "Field",
+		"api-key":    "APIKey",
+	}
+	for key, want := range tests {
+		if got := fieldName(key); got != want {
+			t.Errorf("fieldName(%q): expected %q, got %q", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package schemagen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	mongo "go.mongo.do"
+)
+
+// ErrNoValidator is returned by GenerateFromValidator for a collection
+// without a $jsonSchema validator.
+var ErrNoValidator = errors.New("schemagen: collection has no $jsonSchema validator")
+
+// Catalog lists the collections of a database. *mongo.Database implements
+// it.
+type Catalog interface {
+	ListCollectionSpecifications(ctx context.Context, filter any) ([]mongo.CollectionSpecification, error)
+}
+
+// GenerateFromValidator reads the validator of collection from catalog and
+// returns the formatted Go source of its struct definitions, as
+// SourceFromValidator does. opts.TypeName and opts.Package name the struct
+// and the package; a nil opts uses DefaultOptions.
+func GenerateFromValidator(ctx context.Context, catalog Catalog, collection string, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	specs, err := catalog.ListCollectionSpecifications(ctx, map[string]any{"name": collection})
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if spec.Name == collection {
+			return SourceFromValidator(collection, spec.Validator(), opts.Package, opts.TypeName)
+		}
+	}
+	return nil, fmt.Errorf("schemagen: collection %q not found", collection)
+}
+
+// SourceFromValidator returns the formatted Go source of a file in package
+// pkg defining the struct typeName for the $jsonSchema of validator, the
+// validator of collection. Required properties become plain fields and the
+// others omitempty ones; properties that may be null become pointers, and
+// descriptions become doc comments. The file also defines
+// New<typeName>, which checks a document against the schema with
+// mongo.Schema, so invalid documents fail where they are built rather than
+// when the server rejects the write. Query operators alongside $jsonSchema
+// in the validator are not checked.
+func SourceFromValidator(collection string, validator map[string]any, pkg, typeName string) ([]byte, error) {
+	if !isIdentifier(pkg) || !isIdentifier(typeName) {
+		return nil, fmt.Errorf("schemagen: invalid package %q or type name %q", pkg, typeName)
+	}
+	schema, ok := validator["$jsonSchema"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoValidator, collection)
+	}
+	// Compile the schema now, so the generated code cannot fail to.
+	if _, err := mongo.NewSchema(schema); err != nil {
+		return nil, err
+	}
+	text, err := json.MarshalIndent(schema, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGenerator(typeName)
+	g.schemaStruct(typeName, schema)
+	g.imports["go.mongo.do"] = "mongo"
+
+	literal := strconv.Quote(string(text))
+	if !strings.Contains(string(text), "`") {
+		literal = "`" + string(text) + "`"
+	}
+	runes := []rune(typeName)
+	runes[0] = unicode.ToLower(runes[0])
+	lower := string(runes)
+	g.decls = append(g.decls, fmt.Sprintf(`// %[1]sValidator is the $jsonSchema validator of the %[3]s collection.
+const %[1]sValidator = %[4]s
+
+// %[1]sSchema is %[1]sValidator, compiled.
+var %[1]sSchema = func() *mongo.Schema {
+	schema, err := mongo.NewSchema(%[1]sValidator)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// New%[2]s returns doc after checking it against the validator of the
+// %[3]s collection, or a *mongo.SchemaError listing every violation.
+func New%[2]s(doc %[2]s) (*%[2]s, error) {
+	if err := %[1]sSchema.Validate(doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+`, lower, typeName, collection, literal))
+
+	return g.source("from the validator of the "+collection+" collection", pkg)
+}
+
+// jsonTypes maps JSON Schema type names to the BSON type names of
+// scalarTypes.
+var jsonTypes = map[string]string{
+	"integer": "long", "number": "double", "boolean": "bool",
+}
+
+// schemaStruct adds the definition of struct name for the properties of
+// schema, followed by those of its nested objects.
+func (g *generator) schemaStruct(name string, schema map[string]any) {
+	i := len(g.structs)
+	g.structs = append(g.structs, "")
+
+	properties, _ := schema["properties"].(map[string]any)
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	// _id leads, as it does in stored documents.
+	sort.Slice(keys, func(a, b int) bool {
+		if (keys[a] == "_id") != (keys[b] == "_id") {
+			return keys[a] == "_id"
+		}
+		return keys[a] < keys[b]
+	})
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, r := range list {
+			if key, ok := r.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	used := make(map[string]bool)
+	for _, key := range keys {
+		if strings.ContainsAny(key, "\"`,") {
+			fmt.Fprintf(&b, "\t// Field %q is left out: its key cannot be written in a struct tag.\n", key)
+			continue
+		}
+		property, _ := properties[key].(map[string]any)
+		goName := uniqueName(fieldName(key), used)
+		goType := g.schemaType(name+goName, property)
+
+		if description, ok := property["description"].(string); ok && description != "" {
+			for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+				fmt.Fprintf(&b, "\t// %s\n", strings.TrimSpace(line))
+			}
+		}
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `bson:%q json:%q`\n", goName, goType, tag, tag)
+	}
+	b.WriteString("}\n\n")
+	g.structs[i] = b.String()
+}
+
+// schemaType returns the Go type of values matching schema, defining a
+// struct named after name for objects with properties.
+func (g *generator) schemaType(name string, schema map[string]any) string {
+	nullable := false
+	counts := make(map[string]int)
+	var types []string
+	for _, t := range schemaTypes(schema) {
+		if t == "null" {
+			nullable = true
+			continue
+		}
+		if counts[t] == 0 {
+			types = append(types, t)
+		}
+		counts[t]++
+	}
+
+	var goType string
+	switch {
+	case len(types) == 1 && types[0] == "object":
+		properties, _ := schema["properties"].(map[string]any)
+		if len(properties) == 0 {
+			return "map[string]any"
+		}
+		goType = uniqueName(name, g.names)
+		g.schemaStruct(goType, schema)
+	case len(types) == 1 && types[0] == "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return "[]any"
+		}
+		return "[]" + g.schemaType(name, items)
+	case len(types) > 0 && isNumeric(types):
+		goType = numericType(counts)
+	case len(types) == 1:
+		goType = g.scalarType(types[0])
+	default:
+		return "any"
+	}
+	if goType == "any" {
+		return goType
+	}
+	if nullable {
+		goType = "*" + goType
+	}
+	return goType
+}
+
+// schemaTypes returns the BSON type names schema allows, from bsonType or
+// type, or inferred from properties, items or a string enum.
+func schemaTypes(schema map[string]any) []string {
+	var types []string
+	for _, keyword := range []string{"bsonType", "type"} {
+		switch v := schema[keyword].(type) {
+		case string:
+			types = append(types, v)
+		case []any:
+			for _, t := range v {
+				if s, ok := t.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+		if len(types) > 0 {
+			break
+		}
+	}
+	for i, t := range types {
+		if bsonType, ok := jsonTypes[t]; ok {
+			types[i] = bsonType
+		}
+	}
+	if len(types) > 0 {
+		return types
+	}
+
+	switch {
+	case schema["properties"] != nil:
+		return []string{"object"}
+	case schema["items"] != nil:
+		return []string{"array"}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		for _, v := range enum {
+			if _, ok := v.(string); !ok {
+				return nil
+			}
+		}
+		return []string{"string"}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package schemagen
+
+import (
+	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	mongo "go.mongo.do"
+)
+
+// fakeCatalog lists its specifications and records the filter.
+type fakeCatalog struct {
+	specs  []mongo.CollectionSpecification
+	filter any
+}
+
+func (f *fakeCatalog) ListCollectionSpecifications(ctx context.Context, filter any) ([]mongo.CollectionSpecification, error) {
+	f.filter = filter
+	return f.specs, nil
+}
+
+// TestGenerateFromValidator tests generating structs and a constructor from
+// a $jsonSchema validator.
+func TestGenerateFromValidator(t *testing.T) {
+	catalog := &fakeCatalog{specs: []mongo.CollectionSpecification{{
+		Name: "orders",
+		Options: map[string]any{"validator": map[string]any{"$jsonSchema": map[string]any{
+			"bsonType": "object",
+			"required": []any{"_id", "total", "items"},
+			"properties": map[string]any{
+				"_id":    map[string]any{"bsonType": "objectId"},
+				"total":  map[string]any{"bsonType": []any{"int", "double"}, "description": "Total in cents."},
+				"note":   map[string]any{"bsonType": []any{"string", "null"}},
+				"status": map[string]any{"enum": []any{"open", "closed"}},
+				"items": map[string]any{
+					"bsonType": "array",
+					"items": map[string]any{
+						"bsonType": "object",
+						"required": []any{"sku"},
+						"properties": map[string]any{
+							"sku": map[string]any{"bsonType": "string"},
+							"qty": map[string]any{"type": "integer"},
+						},
+					},
+				},
+				"meta": map[string]any{"bsonType": "object"},
+			},
+		}}},
+	}}}
+
+	src, err := GenerateFromValidator(context.Background(), catalog, "orders", DefaultOptions().SetTypeName("Order").SetPackage("shop"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "order_gen.go", src, 0); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+
+	// Compare with runs of spaces collapsed, as gofmt aligns fields.
+	got := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"from the validator of the orders collection",
+		"mongo \"go.mongo.do\"",
+		"type Order struct { ID bson.ObjectID `bson:\"_id\" json:\"_id\"`",
+		"Items []OrderItems `bson:\"items\" json:\"items\"`",
+		"Meta map[string]any `bson:\"meta,omitempty\" json:\"meta,omitempty\"`",
+		"Note *string `bson:\"note,omitempty\" json:\"note,omitempty\"`",
+		"Status string `bson:\"status,omitempty\"",
+		"// Total in cents. Total float64 `bson:\"total\" json:\"total\"`",
+		"type OrderItems struct {",
+		"Qty int64 `bson:\"qty,omitempty\" json:\"qty,omitempty\"`",
+		"Sku string `bson:\"sku\" json:\"sku\"`",
+		"const orderValidator = `{",
+		"func NewOrder(doc Order) (*Order, error) {",
+		"orderSchema.Validate(doc)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the source to contain %q, got:\n%s", want, src)
+		}
+	}
+	if filter, _ := catalog.filter.(map[string]any); filter["name"] != "orders" {
+		t.Errorf("expected a filter on the collection name, got %v", catalog.filter)
+	}
+}
+
+// TestGenerateFromValidatorErrors tests collections without validators.
+func TestGenerateFromValidatorErrors(t *testing.T) {
+	ctx := context.Background()
+	catalog := &fakeCatalog{specs: []mongo.CollectionSpecification{{Name: "logs"}}}
+	if _, err := GenerateFromValidator(ctx, catalog, "logs", nil); !errors.Is(err, ErrNoValidator) {
+		t.Errorf("expected ErrNoValidator, got %v", err)
+	}
+	if _, err := GenerateFromValidator(ctx, catalog, "missing", nil); err == nil {
+		t.Error("expected an error for a missing collection")
+	}
+
+	invalid := map[string]any{"$jsonSchema": map[string]any{"bsonTyp": "object"}}
+	if _, err := SourceFromValidator("logs", invalid, "main", "Log"); !errors.Is(err, mongo.ErrInvalidSchema) {
+		t.Errorf("expected ErrInvalidSchema, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package mongo
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSessionTimeout is the server's logical session timeout assumed when
+// the handshake did not report one.
+const DefaultSessionTimeout = 30 * time.Minute
+
+// maxEndSessions is the most sessions ended by one endSessions call.
+const maxEndSessions = 10000
+
+// sessionPool tracks the client's active logical sessions so they can be
+// kept alive with refreshSessions and ended on Disconnect.
+type sessionPool struct {
+	mu       sync.Mutex
+	sessions map[*Session]struct{}
+	// refreshing reports whether the refresh loop is running.
+	refreshing bool
+}
+
+// StartSession starts a new logical session. Sessions left idle for half the
+// server's session timeout are refreshed in the background until they are
+// ended, and sessions still active on Disconnect are ended then.
+func (c *Client) StartSession() (*Session, error) {
+	c.mu.RLock()
+	connected := c.connected
+	c.mu.RUnlock()
+
+	if !connected {
+		return nil, ErrClientDisconnected
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{client: c, id: id, lastUsed: c.clock.Now()}
+
+	p := &c.sessions
+	p.mu.Lock()
+	if p.sessions == nil {
+		p.sessions = make(map[*Session]struct{})
+	}
+	p.sessions[s] = struct{}{}
+	start := !p.refreshing
+	p.refreshing = true
+	p.mu.Unlock()
+
+	if start {
+		go c.refreshSessionsLoop()
+	}
+	return s, nil
+}
+
+// sessionTimeout returns the server's logical session timeout.
+func (c *Client) sessionTimeout() time.Duration {
+	return c.serverLimits().LogicalSessionTimeout
+}
+
+// refreshSessionsLoop refreshes idle sessions until the client disconnects.
+// It checks every quarter of the session timeout, so a session is refreshed
+// well before the server would expire it.
+func (c *Client) refreshSessionsLoop() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.clock.After(c.sessionTimeout() / 4):
+		}
+		c.refreshSessions(c.ctx)
+	}
+}
+
+// refreshSessions sends refreshSessions for the sessions idle for at least
+// half the session timeout.
+func (c *Client) refreshSessions(ctx context.Context) {
+	now := c.clock.Now()
+	threshold := c.sessionTimeout() / 2
+
+	p := &c.sessions
+	p.mu.Lock()
+	var idle []*Session
+	for s := range p.sessions {
+		if now.Sub(s.lastUsed) >= threshold {
+			idle = append(idle, s)
+		}
+	}
+	p.mu.Unlock()
+	if len(idle) == 0 {
+		return
+	}
+
+	ids := make([]any, len(idle))
+	for i, s := range idle {
+		ids[i] = s.ID()
+	}
+	if _, err := c.execute(ctx, "admin", "mongo.refreshSessions", ids); err != nil {
+		// Retried on the next check; the sessions stay idle until then.
+		return
+	}
+
+	p.mu.Lock()
+	for _, s := range idle {
+		s.lastUsed = now
+	}
+	p.mu.Unlock()
+}
+
+// endSessions ends all active sessions with endSessions. Errors are ignored:
+// the server expires sessions it is not told about.
+func (c *Client) endSessions(ctx context.Context) {
+	p := &c.sessions
+	p.mu.Lock()
+	ids := make([]any, 0, len(p.sessions))
+	for s := range p.sessions {
+		s.ended = true
+		ids = append(ids, s.ID())
+	}
+	p.sessions = nil
+	p.mu.Unlock()
+
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxEndSessions {
+			n = maxEndSessions
+		}
+		c.execute(ctx, "admin", "mongo.endSessions", ids[:n])
+		ids = ids[n:]
+	}
+}
+
+// newSessionID returns a random version 4 UUID.
+func newSessionID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("mongo: generate session id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return formatUUID(b[:]), nil
+}
+
+// Session represents a MongoDB logical session.
+type Session struct {
+	client *Client
+	id     string
+	// lastUsed, ended and the times are guarded by the client's session
+	// pool mutex.
+	lastUsed      time.Time
+	ended         bool
+	clusterTime   Timestamp
+	operationTime Timestamp
+}
+
+// ID returns the session's lsid document.
+func (s *Session) ID() map[string]any {
+	return map[string]any{"id": map[string]any{"$uuid": s.id}}
+}
+
+// LastUsed returns when the session was last used or refreshed.
+func (s *Session) LastUsed() time.Time {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	return s.lastUsed
+}
+
+// ClusterTime returns the latest cluster time the session has seen, or a
+// zero Timestamp if none.
+func (s *Session) ClusterTime() Timestamp {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	return s.clusterTime
+}
+
+// AdvanceClusterTime records ts as the session's cluster time if it is
+// later than the current one.
+func (s *Session) AdvanceClusterTime(ts Timestamp) {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	if ts.After(s.clusterTime) {
+		s.clusterTime = ts
+	}
+}
+
+// OperationTime returns the operation time of the latest operation the
+// session has seen, or a zero Timestamp if none.
+func (s *Session) OperationTime() Timestamp {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	return s.operationTime
+}
+
+// AdvanceOperationTime records ts as the session's operation time if it is
+// later than the current one, such as the OperationTime of a write's
+// WriteToken.
+func (s *Session) AdvanceOperationTime(ts Timestamp) {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	if ts.After(s.operationTime) {
+		s.operationTime = ts
+	}
+}
+
+// touch records a use of the session.
+func (s *Session) touch() {
+	s.client.sessions.mu.Lock()
+	defer s.client.sessions.mu.Unlock()
+	s.lastUsed = s.client.clock.Now()
+}
+
+// EndSession ends the session with endSessions. Ending a session twice is a
+// no-op.
+func (s *Session) EndSession(ctx context.Context) {
+	p := &s.client.sessions
+	p.mu.Lock()
+	if s.ended {
+		p.mu.Unlock()
+		return
+	}
+	s.ended = true
+	delete(p.sessions, s)
+	p.mu.Unlock()
+
+	s.client.execute(ctx, "admin", "mongo.endSessions", []any{s.ID()})
+}
+
+// WithTransaction runs a function within a transaction. If fn panics, the
+// panic is recovered and returned as a PanicError.
+func (s *Session) WithTransaction(ctx context.Context, fn func(ctx context.Context) (any, error)) (any, error) {
+	s.touch()
+	// For now, just execute without transaction support
+	var result any
+	err := s.client.runCallback("WithTransaction function", func() error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
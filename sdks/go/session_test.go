@@ -0,0 +1,122 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestSessionRefresh tests refreshing sessions approaching the server timeout.
+func TestSessionRefresh(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.refreshSessions", map[string]any{"ok": float64(1)}, nil)
+
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017",
+		DefaultClientOptions().SetClock(clock))
+	client.capabilities = (&helloReply{LogicalSessionTimeoutMinutes: 20}).description()
+	defer client.cancel()
+
+	session, err := client.StartSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Checks run every 5 minutes; sessions idle for 10 are refreshed.
+	waitTimers(t, clock, 1)
+	clock.Advance(5 * time.Minute)
+	waitTimers(t, clock, 1)
+	if len(mock.calls[0].args) != 0 {
+		t.Fatal("expected no refresh for a recently used session")
+	}
+
+	clock.Advance(5 * time.Minute)
+	waitTimers(t, clock, 1)
+	if !reflect.DeepEqual(mock.calls[0].args[0], []any{session.ID()}) {
+		t.Errorf("unexpected refreshSessions args: %v", mock.calls[0].args)
+	}
+	if want := clock.Now(); !session.LastUsed().Equal(want) {
+		t.Errorf("expected last use %v, got %v", want, session.LastUsed())
+	}
+}
+
+// TestSessionEnd tests ending sessions explicitly and on Disconnect.
+func TestSessionEnd(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.endSessions", map[string]any{"ok": float64(1)}, nil)
+	mock.addCall("mongo.endSessions", map[string]any{"ok": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	ctx := context.Background()
+
+	first, _ := client.StartSession()
+	second, _ := client.StartSession()
+	third, _ := client.StartSession()
+
+	first.EndSession(ctx)
+	first.EndSession(ctx)
+	if !reflect.DeepEqual(mock.calls[0].args[0], []any{first.ID()}) {
+		t.Errorf("unexpected endSessions args: %v", mock.calls[0].args)
+	}
+
+	if err := client.Disconnect(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ended := mock.calls[1].args[0].([]any)
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 sessions ended on Disconnect, got %v", ended)
+	}
+	for _, s := range []*Session{second, third} {
+		if !reflect.DeepEqual(ended[0], s.ID()) && !reflect.DeepEqual(ended[1], s.ID()) {
+			t.Errorf("expected %v to be ended", s.ID())
+		}
+	}
+
+	// Already ended by Disconnect.
+	second.EndSession(ctx)
+	if mock.callIndex != 2 {
+		t.Errorf("expected 2 endSessions calls, got %d", mock.callIndex)
+	}
+}
+
+// TestSessionTimes tests advancing the cluster and operation times.
+func TestSessionTimes(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	session, err := client.StartSession()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !session.ClusterTime().IsZero() || !session.OperationTime().IsZero() {
+		t.Fatal("expected zero times for a new session")
+	}
+
+	later := Timestamp{T: 20, I: 1}
+	session.AdvanceOperationTime(later)
+	session.AdvanceOperationTime(Timestamp{T: 10, I: 5})
+	if session.OperationTime() != later {
+		t.Errorf("expected operation time %v, got %v", later, session.OperationTime())
+	}
+	session.AdvanceClusterTime(Timestamp{T: 10, I: 5})
+	session.AdvanceClusterTime(later)
+	if session.ClusterTime() != later {
+		t.Errorf("expected cluster time %v, got %v", later, session.ClusterTime())
+	}
+}
+
+// TestNewSessionID tests generating session UUIDs.
+func TestNewSessionID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	a, err := newSessionID()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := newSessionID()
+	if !pattern.MatchString(a) {
+		t.Errorf("expected a version 4 UUID, got %q", a)
+	}
+	if a == b {
+		t.Error("expected distinct session IDs")
+	}
+}
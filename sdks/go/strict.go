@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DecodeOptions configures a single Decode call, overriding the defaults of
+// the client and collection the result came from.
+type DecodeOptions struct {
+	// DisallowUnknownFields makes decoding into a struct fail with an
+	// UnknownFieldError when the document has a field, at any depth, that
+	// no struct field claims. Structs with an inline map accept any field.
+	DisallowUnknownFields *bool
+}
+
+// SetDisallowUnknownFields sets whether decoding fails on fields the target
+// struct does not have.
+func (o *DecodeOptions) SetDisallowUnknownFields(disallow bool) *DecodeOptions {
+	o.DisallowUnknownFields = &disallow
+	return o
+}
+
+// strictDecoding merges per-call decode options into the default.
+func strictDecoding(strict bool, opts ...*DecodeOptions) bool {
+	for _, opt := range opts {
+		if opt != nil && opt.DisallowUnknownFields != nil {
+			strict = *opt.DisallowUnknownFields
+		}
+	}
+	return strict
+}
+
+// checkUnknownFields returns an UnknownFieldError for the first field of
+// data, in key order, that val's type has no field for.
+func checkUnknownFields(data []byte, val any, naming NamingStrategy) error {
+	t := reflect.TypeOf(val)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return nil
+	}
+	doc, err := decodeUseNumber(data)
+	if err != nil {
+		return err
+	}
+	if field := unknownField(doc, t.Elem(), naming, ""); field != "" {
+		return &UnknownFieldError{Field: field, Type: t.Elem()}
+	}
+	return nil
+}
+
+// unknownField returns the dotted path of the first field of src, a
+// generically decoded value, with no counterpart in t, or "" if there is
+// none. Types that decode themselves, and values whose shape does not match
+// t, are left to the decoder.
+func unknownField(src any, t reflect.Type, naming NamingStrategy, field string) string {
+	for t.Kind() == reflect.Pointer || nullableKind(t) != "" {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		} else {
+			t = t.Field(0).Type
+		}
+	}
+	if t == timeType || hasBSONCodec(t) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return ""
+		}
+		return unknownStructField(m, t, naming, field)
+	case reflect.Map:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return ""
+		}
+		for _, k := range sortedFieldNames(m) {
+			if f := unknownField(m[k], t.Elem(), naming, joinPath(field, k)); f != "" {
+				return f
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := src.([]any)
+		if !ok {
+			return ""
+		}
+		for i, v := range arr {
+			if f := unknownField(v, t.Elem(), naming, joinPath(field, strconv.Itoa(i))); f != "" {
+				return f
+			}
+		}
+	}
+	return ""
+}
+
+// unknownStructField is unknownField for a document decoded into the
+// struct type t. Keys match fields as decodeStruct matches them: by name,
+// then case-insensitively.
+func unknownStructField(m map[string]any, t reflect.Type, naming NamingStrategy, field string) string {
+	fields := structFields(t)
+	inline := false
+	for _, f := range fields {
+		inline = inline || f.inlineMap
+	}
+	for _, k := range sortedFieldNames(m) {
+		var match *structField
+		for i := range fields {
+			f := &fields[i]
+			if f.inlineMap {
+				continue
+			}
+			name := f.documentName(naming)
+			if name == k {
+				match = f
+				break
+			}
+			if match == nil && strings.EqualFold(name, k) {
+				match = f
+			}
+		}
+		if match == nil {
+			if inline {
+				continue
+			}
+			return joinPath(field, k)
+		}
+		if f := unknownField(m[k], t.FieldByIndex(match.index).Type, naming, joinPath(field, k)); f != "" {
+			return f
+		}
+	}
+	return ""
+}
+
+// sortedFieldNames returns the keys of m in order.
+func sortedFieldNames(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type strictItem struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+type strictOrder struct {
+	ID      string       `json:"_id"`
+	Items   []strictItem `json:"items"`
+	Created time.Time    `json:"created"`
+}
+
+type strictTagged struct {
+	ID    string         `bson:"_id"`
+	Extra map[string]any `bson:",inline"`
+}
+
+type strictNamed struct {
+	FirstName string
+}
+
+// TestUnknownFields tests strict decoding of nested documents.
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		val    any
+		naming NamingStrategy
+		field  string
+	}{
+		{"known", `{"_id":"o1","items":[{"sku":"a","qty":1}],"created":"2024-01-02T03:04:05Z"}`, &strictOrder{}, NamingAsIs, ""},
+		{"case-insensitive", `{"_ID":"o1","Items":[]}`, &strictOrder{}, NamingAsIs, ""},
+		{"top level", `{"_id":"o1","status":"paid"}`, &strictOrder{}, NamingAsIs, "status"},
+		{"in array", `{"items":[{"sku":"a"},{"sku":"b","price":3}]}`, &strictOrder{}, NamingAsIs, "items.1.price"},
+		{"first in key order", `{"z":1,"a":2}`, &strictOrder{}, NamingAsIs, "a"},
+		{"slice of structs", `[{"_id":"o1"},{"_id":"o2","note":"x"}]`, &[]strictOrder{}, NamingAsIs, "1.note"},
+		{"inline map", `{"_id":"t1","anything":{"deep":true}}`, &strictTagged{}, NamingAsIs, ""},
+		{"naming", `{"first_name":"Ada"}`, &strictNamed{}, NamingSnakeCase, ""},
+		{"naming unknown", `{"first_name":"Ada","last_name":"Lovelace"}`, &strictNamed{}, NamingSnakeCase, "last_name"},
+		{"map", `{"a":{"b":1}}`, &map[string]any{}, NamingAsIs, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := unmarshalDocument([]byte(tt.data), tt.val, tt.naming, NumberDecodingFloat64, DecoderConfig{}, true)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var ufe *UnknownFieldError
+			if !errors.As(err, &ufe) || !errors.Is(err, ErrUnknownField) {
+				t.Fatalf("expected UnknownFieldError, got %v", err)
+			}
+			if ufe.Field != tt.field {
+				t.Errorf("expected field %q, got %q", tt.field, ufe.Field)
+			}
+		})
+	}
+
+	if err := unmarshalDocument([]byte(`{"status":"paid"}`), &strictOrder{}, NamingAsIs, NumberDecodingFloat64, DecoderConfig{}, false); err != nil {
+		t.Errorf("expected unknown fields to be ignored without strict decoding, got %v", err)
+	}
+}
+
+// TestStrictDecoding tests the client, collection and per-call settings.
+func TestStrictDecoding(t *testing.T) {
+	doc := map[string]any{"_id": "o1", "status": "paid"}
+	mock := newMockRPCClient()
+	for i := 0; i < 4; i++ {
+		mock.addCall("mongo.findOne", doc, nil)
+	}
+	mock.addCall("mongo.find", []any{doc}, nil)
+
+	client := newClient(context.Background(), mock, "mongodb://localhost:27017", DefaultClientOptions().SetDisallowUnknownFields(true))
+	db := client.Database("testdb")
+	ctx := context.Background()
+
+	var order strictOrder
+	if err := db.Collection("orders").FindOne(ctx, map[string]any{}).Decode(&order); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected client strict decoding, got %v", err)
+	}
+	if err := db.Collection("orders").FindOne(ctx, map[string]any{}).Decode(&order, (&DecodeOptions{}).SetDisallowUnknownFields(false)); err != nil {
+		t.Errorf("expected the call to override the client, got %v", err)
+	}
+
+	lenient := db.Collection("orders", (&CollectionOptions{}).SetDisallowUnknownFields(false))
+	if err := lenient.FindOne(ctx, map[string]any{}).Decode(&order); err != nil {
+		t.Errorf("expected the collection to override the client, got %v", err)
+	}
+	strict, err := lenient.Clone((&CollectionOptions{}).SetDisallowUnknownFields(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := strict.FindOne(ctx, map[string]any{}).Decode(&order); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected the clone to decode strictly, got %v", err)
+	}
+
+	cursor, err := lenient.Find(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var orders []strictOrder
+	if err := cursor.All(ctx, &orders, (&AllOptions{}).SetDisallowUnknownFields(true)); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected All to decode strictly, got %v", err)
+	}
+	if err := cursor.All(ctx, &orders); err != nil || len(orders) != 1 {
+		t.Errorf("expected lenient All to decode, got %v, %v", orders, err)
+	}
+}
+
+// TestChangeStreamStrictDecoding tests strict decoding of change events.
+func TestChangeStreamStrictDecoding(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", map[string]any{
+		"_id":           "change-1",
+		"operationType": "insert",
+		"fullDocument":  map[string]any{"_id": "o1", "status": "paid"},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders", (&CollectionOptions{}).SetDisallowUnknownFields(true))
+	ctx := context.Background()
+
+	cs, err := coll.Watch(ctx, []any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cs.Close(ctx)
+	if !cs.Next(ctx) {
+		t.Fatalf("expected an event: %v", cs.Err())
+	}
+
+	var event struct {
+		OperationType string `json:"operationType"`
+	}
+	if err := cs.Decode(&event); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected strict event decoding, got %v", err)
+	}
+	if err := cs.Decode(&event, (&DecodeOptions{}).SetDisallowUnknownFields(false)); err != nil || event.OperationType != "insert" {
+		t.Errorf("expected lenient event decoding, got %+v, %v", event, err)
+	}
+
+	var order strictOrder
+	if err := cs.Current().DecodeDocument(&order, (&DecodeOptions{}).SetDisallowUnknownFields(true)); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected strict document decoding, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultSubscribeBatchSize is the maximum number of events delivered to a
+// ChangeHandler at once.
+const DefaultSubscribeBatchSize = 100
+
+// DefaultSubscribePollInterval is the pause between empty answers while
+// Subscribe waits for events on a stream without a PollInterval.
+const DefaultSubscribePollInterval = time.Second
+
+// DefaultSubscribeMaxBatchWait is how long Subscribe holds a partial batch
+// while events keep arriving before delivering it anyway.
+const DefaultSubscribeMaxBatchWait = time.Second
+
+// ChangeHandler processes a batch of change events. Returning nil
+// acknowledges the batch; its last resume token is then checkpointed.
+// Returning an error stops the subscription without checkpointing, so the
+// batch is delivered again after a restart. A panic in the handler does the
+// same, returning a PanicError.
+type ChangeHandler func(ctx context.Context, events []*ChangeEvent) error
+
+// SubscribeOptions configures a Subscribe operation.
+type SubscribeOptions struct {
+	Pipeline any
+	// Checkpoint stores the resume token of each acknowledged batch under
+	// CheckpointKey, which defaults to the watched namespace.
+	Checkpoint    CheckpointStore
+	CheckpointKey string
+	BatchSize     *int
+	// MaxBatchWait bounds how long a partial batch is held while events
+	// trickle in; it defaults to DefaultSubscribeMaxBatchWait. A partial
+	// batch is also delivered as soon as the stream goes idle.
+	MaxBatchWait *time.Duration
+	// ChangeStream sets the options the stream is opened with. Its
+	// PollInterval sets the pause between empty answers, which defaults to
+	// DefaultSubscribePollInterval.
+	ChangeStream *ChangeStreamOptions
+}
+
+// SetPipeline sets the change stream pipeline.
+func (o *SubscribeOptions) SetPipeline(pipeline any) *SubscribeOptions {
+	o.Pipeline = pipeline
+	return o
+}
+
+// SetCheckpoint sets the checkpoint store and the key to store tokens under.
+func (o *SubscribeOptions) SetCheckpoint(store CheckpointStore, key string) *SubscribeOptions {
+	o.Checkpoint = store
+	o.CheckpointKey = key
+	return o
+}
+
+// SetBatchSize sets the maximum number of events per handler call.
+func (o *SubscribeOptions) SetBatchSize(size int) *SubscribeOptions {
+	o.BatchSize = &size
+	return o
+}
+
+// SetMaxBatchWait sets how long a partial batch is held before delivery.
+func (o *SubscribeOptions) SetMaxBatchWait(d time.Duration) *SubscribeOptions {
+	o.MaxBatchWait = &d
+	return o
+}
+
+// SetChangeStreamOptions sets the options used to open the change stream.
+func (o *SubscribeOptions) SetChangeStreamOptions(opts *ChangeStreamOptions) *SubscribeOptions {
+	o.ChangeStream = opts
+	return o
+}
+
+// watchFunc opens a change stream.
+type watchFunc func(ctx context.Context, pipeline any, opts ...*ChangeStreamOptions) (*ChangeStream, error)
+
+// Subscribe delivers change events on the collection to handler in batches,
+// resuming from the last checkpoint when a CheckpointStore is configured. It
+// keeps waiting for events while the stream is idle, and returns when ctx is
+// done, the stream fails or is closed, or handler fails. A canceled ctx is
+// reported as its error.
+func (c *Collection) Subscribe(ctx context.Context, handler ChangeHandler, opts ...*SubscribeOptions) error {
+	return subscribe(ctx, c.database.client, c.namespace(), c.Watch, handler, opts...)
+}
+
+// Subscribe delivers change events on the database to handler in batches.
+// See Collection.Subscribe.
+func (d *Database) Subscribe(ctx context.Context, handler ChangeHandler, opts ...*SubscribeOptions) error {
+	return subscribe(ctx, d.client, d.name, d.Watch, handler, opts...)
+}
+
+// subscribe implements Subscribe for collections and databases.
+func subscribe(ctx context.Context, client *Client, ns string, watch watchFunc, handler ChangeHandler, opts ...*SubscribeOptions) error {
+	options := &SubscribeOptions{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Pipeline != nil {
+			options.Pipeline = opt.Pipeline
+		}
+		if opt.Checkpoint != nil {
+			options.Checkpoint = opt.Checkpoint
+		}
+		if opt.CheckpointKey != "" {
+			options.CheckpointKey = opt.CheckpointKey
+		}
+		if opt.BatchSize != nil {
+			options.BatchSize = opt.BatchSize
+		}
+		if opt.MaxBatchWait != nil {
+			options.MaxBatchWait = opt.MaxBatchWait
+		}
+		if opt.ChangeStream != nil {
+			options.ChangeStream = opt.ChangeStream
+		}
+	}
+
+	pipeline := options.Pipeline
+	if pipeline == nil {
+		pipeline = []any{}
+	}
+	batchSize := DefaultSubscribeBatchSize
+	if options.BatchSize != nil && *options.BatchSize > 0 {
+		batchSize = *options.BatchSize
+	}
+	maxWait := DefaultSubscribeMaxBatchWait
+	if options.MaxBatchWait != nil && *options.MaxBatchWait > 0 {
+		maxWait = *options.MaxBatchWait
+	}
+	key := options.CheckpointKey
+	if key == "" {
+		key = ns
+	}
+
+	streamOpts := []*ChangeStreamOptions{options.ChangeStream}
+	if options.Checkpoint != nil {
+		token, err := options.Checkpoint.Load(ctx, key)
+		if err != nil {
+			return fmt.Errorf("loading checkpoint %s: %w", key, err)
+		}
+		if token != nil {
+			streamOpts = append(streamOpts, &ChangeStreamOptions{ResumeAfter: token})
+		}
+	}
+
+	stream, err := watch(ctx, pipeline, streamOpts...)
+	if err != nil {
+		return err
+	}
+	defer stream.Close(ctx)
+
+	pollInterval := stream.pollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultSubscribePollInterval
+	}
+
+	// deliver hands batch to handler and checkpoints its last token.
+	deliver := func(batch []*ChangeEvent) error {
+		err := client.runCallback("Subscribe handler", func() error {
+			return handler(ctx, batch)
+		})
+		if err != nil {
+			return err
+		}
+		if options.Checkpoint != nil {
+			token := batch[len(batch)-1].ResumeToken()
+			if err := options.Checkpoint.Save(ctx, key, token); err != nil {
+				return fmt.Errorf("saving checkpoint %s: %w", key, err)
+			}
+		}
+		return nil
+	}
+
+	// A batch is delivered when it is full, when it has waited maxWait for
+	// more events, or when the stream goes idle.
+	var batch []*ChangeEvent
+	var started time.Time
+	for {
+		if stream.tryNext(ctx) {
+			if len(batch) == 0 {
+				started = client.clock.Now()
+			}
+			batch = append(batch, stream.Current())
+			if len(batch) < batchSize && client.clock.Now().Sub(started) < maxWait {
+				continue
+			}
+			if err := deliver(batch); err != nil {
+				return err
+			}
+			batch = nil
+			continue
+		}
+
+		if len(batch) > 0 && ctx.Err() == nil {
+			if err := deliver(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+		if err := stream.Err(); err != nil {
+			return err
+		}
+
+		select {
+		case <-client.clock.After(pollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// memoryCheckpointStore is an in-memory CheckpointStore for tests.
+type memoryCheckpointStore struct {
+	tokens map[string]any
+	saves  int
+}
+
+func newMemoryCheckpointStore() *memoryCheckpointStore {
+	return &memoryCheckpointStore{tokens: make(map[string]any)}
+}
+
+func (s *memoryCheckpointStore) Load(ctx context.Context, key string) (any, error) {
+	return s.tokens[key], nil
+}
+
+func (s *memoryCheckpointStore) Save(ctx context.Context, key string, token any) error {
+	s.tokens[key] = token
+	s.saves++
+	return nil
+}
+
+// changeEventDoc returns a raw insert event with the given resume token.
+func changeEventDoc(token string) map[string]any {
+	return map[string]any{
+		"_id":           token,
+		"operationType": "insert",
+		"documentKey":   map[string]any{"_id": token},
+	}
+}
+
+// TestCollectionSubscribe tests batched delivery with checkpoints.
+func TestCollectionSubscribe(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t1"), nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t2"), nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t3"), nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+	store := newMemoryCheckpointStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var batches [][]*ChangeEvent
+	handler := func(ctx context.Context, events []*ChangeEvent) error {
+		batches = append(batches, events)
+		if len(batches) == 2 {
+			cancel()
+		}
+		return nil
+	}
+
+	err := coll.Subscribe(ctx, handler, (&SubscribeOptions{}).SetCheckpoint(store, "").SetBatchSize(2))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if store.tokens["testdb.orders"] != "t3" || store.saves != 2 {
+		t.Errorf("unexpected checkpoints: %v after %d saves", store.tokens, store.saves)
+	}
+	if len(mock.calls[0].args) != 3 {
+		t.Errorf("expected no resume token, got %v", mock.calls[0].args)
+	}
+}
+
+// TestCollectionSubscribeResume tests resuming from a saved checkpoint.
+func TestCollectionSubscribeResume(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+	store := newMemoryCheckpointStore()
+	store.tokens["consumer-a"] = "t9"
+
+	opts := (&SubscribeOptions{}).
+		SetCheckpoint(store, "consumer-a").
+		SetChangeStreamOptions((&ChangeStreamOptions{}).SetFullDocument("updateLookup"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := coll.Subscribe(ctx, func(ctx context.Context, events []*ChangeEvent) error {
+		t.Error("unexpected events")
+		return nil
+	}, opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["resumeAfter"] != "t9" || options["fullDocument"] != "updateLookup" {
+		t.Errorf("unexpected watch options: %v", options)
+	}
+	if store.saves != 0 {
+		t.Errorf("expected no saves, got %d", store.saves)
+	}
+}
+
+// TestCollectionSubscribeHandlerError tests that a failed batch is not checkpointed.
+func TestCollectionSubscribeHandlerError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t1"), nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	store := newMemoryCheckpointStore()
+	handlerErr := errors.New("handler failed")
+
+	err := client.Database("testdb").Subscribe(context.Background(), func(ctx context.Context, events []*ChangeEvent) error {
+		return handlerErr
+	}, (&SubscribeOptions{}).SetCheckpoint(store, ""))
+
+	if !errors.Is(err, handlerErr) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	if store.saves != 0 {
+		t.Errorf("expected no saves, got %d", store.saves)
+	}
+}
+
+// TestCollectionSubscribeStreamError tests surfacing a stream error.
+func TestCollectionSubscribeStreamError(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", nil, errors.New("stream error"))
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	err := coll.Subscribe(context.Background(), func(ctx context.Context, events []*ChangeEvent) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+// TestCollectionSubscribeIdle tests that an idle stream delivers the partial
+// batch and keeps waiting for events.
+func TestCollectionSubscribeIdle(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.watch", "stream-1", nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t1"), nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamNext", changeEventDoc("t2"), nil)
+	mock.addCall("mongo.changeStreamNext", nil, nil)
+	mock.addCall("mongo.changeStreamClose", true, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("orders")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var batches [][]*ChangeEvent
+	handler := func(ctx context.Context, events []*ChangeEvent) error {
+		batches = append(batches, events)
+		if len(batches) == 2 {
+			cancel()
+		}
+		return nil
+	}
+
+	opts := (&SubscribeOptions{}).SetChangeStreamOptions((&ChangeStreamOptions{}).SetPollInterval(time.Millisecond))
+	err := coll.Subscribe(ctx, handler, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if len(batches) != 2 || len(batches[0]) != 1 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if batches[1][0].ResumeToken() != "t2" {
+		t.Errorf("unexpected second batch: %v", batches[1][0].ResumeToken())
+	}
+}
+
+// trickleRPC serves a change stream that never goes idle, advancing clock
+// by interval before each event.
+type trickleRPC struct {
+	clock    *fakeClock
+	interval time.Duration
+	events   int
+}
+
+func (r *trickleRPC) Call(method string, args ...any) RPCPromise {
+	switch method {
+	case "mongo.watch":
+		return &mockPromise{result: "stream-1"}
+	case "mongo.changeStreamNext":
+		r.clock.Advance(r.interval)
+		r.events++
+		return &mockPromise{result: changeEventDoc(fmt.Sprintf("t%d", r.events))}
+	}
+	return &mockPromise{result: true}
+}
+
+func (r *trickleRPC) Close() error { return nil }
+
+func (r *trickleRPC) IsConnected() bool { return true }
+
+// TestCollectionSubscribeMaxBatchWait tests that a slow trickle of events
+// delivers a partial batch once it has waited MaxBatchWait.
+func TestCollectionSubscribeMaxBatchWait(t *testing.T) {
+	clock := newFakeClock(time.Unix(0, 0))
+	rpc := &trickleRPC{clock: clock, interval: 400 * time.Millisecond}
+
+	client := newClientWithRPC(rpc, "mongodb://localhost:27017")
+	client.clock = clock
+	coll := client.Database("testdb").Collection("orders")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var batches [][]*ChangeEvent
+	handler := func(ctx context.Context, events []*ChangeEvent) error {
+		batches = append(batches, events)
+		cancel()
+		return nil
+	}
+
+	err := coll.Subscribe(ctx, handler, (&SubscribeOptions{}).SetMaxBatchWait(time.Second))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// The first event starts the batch; the fourth arrives 1.2s later.
+	if len(batches) != 1 || len(batches[0]) != 4 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+}
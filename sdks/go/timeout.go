@@ -0,0 +1,184 @@
+package mongo
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutSource identifies the level of the timeout hierarchy that decided
+// an operation's timeout. Levels are listed from lowest to highest
+// precedence: a context deadline beats a per-call timeout, which beats the
+// collection default, then the database default, then the client Timeout.
+type TimeoutSource int
+
+// Timeout sources, in increasing precedence.
+const (
+	// TimeoutSourceNone means no level set a timeout.
+	TimeoutSourceNone TimeoutSource = iota
+	// TimeoutSourceClient is ClientOptions.Timeout.
+	TimeoutSourceClient
+	// TimeoutSourceDatabase is DatabaseOptions.Timeout.
+	TimeoutSourceDatabase
+	// TimeoutSourceCollection is CollectionOptions.Timeout.
+	TimeoutSourceCollection
+	// TimeoutSourceOperation is a per-call WithOperationTimeout.
+	TimeoutSourceOperation
+	// TimeoutSourceContext is the deadline of the operation's context.
+	TimeoutSourceContext
+)
+
+// String returns the name of the level.
+func (s TimeoutSource) String() string {
+	switch s {
+	case TimeoutSourceClient:
+		return "client"
+	case TimeoutSourceDatabase:
+		return "database"
+	case TimeoutSourceCollection:
+		return "collection"
+	case TimeoutSourceOperation:
+		return "operation"
+	case TimeoutSourceContext:
+		return "context"
+	}
+	return "none"
+}
+
+// OperationTimeout is the timeout resolved for an operation.
+type OperationTimeout struct {
+	// Duration is the time allowed for the operation. Zero means no
+	// timeout. For TimeoutSourceContext it is the time left until the
+	// context deadline.
+	Duration time.Duration
+	Source   TimeoutSource
+}
+
+// operationTimeoutKey is the context key for per-call timeouts.
+type operationTimeoutKey struct{}
+
+// WithOperationTimeout returns a context that gives every operation issued
+// with it a timeout of d, overriding the collection, database and client
+// defaults. A deadline already set on ctx still takes precedence. A zero d
+// disables the lower-level defaults.
+func WithOperationTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, operationTimeoutKey{}, d)
+}
+
+// resolveTimeout walks the timeout hierarchy for an operation issued with
+// ctx against a collection and database with the given defaults, which are
+// nil when unset. The first level that is set decides; an explicit zero
+// means no timeout.
+func (c *Client) resolveTimeout(ctx context.Context, collection, database *time.Duration) OperationTimeout {
+	if deadline, ok := ctx.Deadline(); ok {
+		return OperationTimeout{Duration: time.Until(deadline), Source: TimeoutSourceContext}
+	}
+	if d, ok := ctx.Value(operationTimeoutKey{}).(time.Duration); ok {
+		return OperationTimeout{Duration: d, Source: TimeoutSourceOperation}
+	}
+	if collection != nil {
+		return OperationTimeout{Duration: *collection, Source: TimeoutSourceCollection}
+	}
+	if database != nil {
+		return OperationTimeout{Duration: *database, Source: TimeoutSourceDatabase}
+	}
+	if c.timeout > 0 {
+		return OperationTimeout{Duration: c.timeout, Source: TimeoutSourceClient}
+	}
+	return OperationTimeout{}
+}
+
+// withTimeout returns ctx bounded by timeout. Context deadlines are already
+// in place, so only lower levels add one.
+func withTimeout(ctx context.Context, timeout OperationTimeout) (context.Context, context.CancelFunc) {
+	if timeout.Source == TimeoutSourceContext || timeout.Duration <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout.Duration)
+}
+
+// deadline returns the deadline of an operation starting now under timeout,
+// on the client's clock, or the zero time without one. A context deadline
+// is taken as the time left on it, so the clock need not be the wall clock
+// the context measures.
+func (c *Client) deadline(timeout OperationTimeout) time.Time {
+	if timeout.Source != TimeoutSourceContext && timeout.Duration <= 0 {
+		return time.Time{}
+	}
+	return c.clock.Now().Add(timeout.Duration)
+}
+
+// EffectiveTimeout returns the timeout client-level operations issued with
+// ctx run under.
+func (c *Client) EffectiveTimeout(ctx context.Context) OperationTimeout {
+	return c.resolveTimeout(ctx, nil, nil)
+}
+
+// EffectiveTimeout returns the timeout operations on the database issued
+// with ctx run under.
+func (d *Database) EffectiveTimeout(ctx context.Context) OperationTimeout {
+	return d.client.resolveTimeout(ctx, nil, d.timeout)
+}
+
+// EffectiveTimeout returns the timeout operations on the collection issued
+// with ctx run under.
+func (c *Collection) EffectiveTimeout(ctx context.Context) OperationTimeout {
+	return c.database.client.resolveTimeout(ctx, c.timeout, c.database.timeout)
+}
+
+// execute issues an RPC call for a database-level operation under the
+// database's timeout.
+func (d *Database) execute(ctx context.Context, method string, args ...any) (any, error) {
+	if err := d.checkStale(); err != nil {
+		return nil, err
+	}
+	return d.client.executeWithin(ctx, d.EffectiveTimeout(ctx), d.name, method, args...)
+}
+
+// execute issues an RPC call for an operation on the collection under the
+// collection's timeout.
+func (c *Collection) execute(ctx context.Context, method string, args ...any) (any, error) {
+	if err := c.checkStale(); err != nil {
+		return nil, err
+	}
+	return c.database.client.executeWithin(ctx, c.EffectiveTimeout(ctx), c.namespace(), method, args...)
+}
+
+// contextCaller is an RPCClient that bounds calls by a context itself, such
+// as the wire protocol backend, which takes its connection deadline from
+// the context and abandons a round trip when the context is done.
+type contextCaller interface {
+	CallContext(ctx context.Context, method string, args ...any) RPCPromise
+}
+
+// callContext calls method on rpcClient, handing ctx to clients that can
+// use it.
+func callContext(ctx context.Context, rpcClient RPCClient, method string, args ...any) RPCPromise {
+	if cc, ok := rpcClient.(contextCaller); ok {
+		return cc.CallContext(ctx, method, args...)
+	}
+	return rpcClient.Call(method, args...)
+}
+
+// awaitContext waits for promise, giving up when ctx is done.
+func awaitContext(ctx context.Context, promise RPCPromise) (any, error) {
+	if ctx.Done() == nil {
+		return promise.Await()
+	}
+
+	type response struct {
+		result any
+		err    error
+	}
+	done := make(chan response, 1)
+	go func() {
+		result, err := promise.Await()
+		done <- response{result, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.result, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestEffectiveTimeoutPrecedence tests which level decides an operation's timeout.
+func TestEffectiveTimeoutPrecedence(t *testing.T) {
+	client := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017",
+		DefaultClientOptions().SetTimeout(10*time.Second))
+	plainDB := client.Database("testdb")
+	db := client.Database("testdb", (&DatabaseOptions{}).SetTimeout(5*time.Second))
+	plain := db.Collection("users")
+	coll := db.Collection("users", (&CollectionOptions{}).SetTimeout(2*time.Second))
+
+	clone, err := coll.Clone()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	background := context.Background()
+	operation := WithOperationTimeout(background, time.Second)
+
+	tests := []struct {
+		name   string
+		got    OperationTimeout
+		want   time.Duration
+		source TimeoutSource
+	}{
+		{"client", client.EffectiveTimeout(background), 10 * time.Second, TimeoutSourceClient},
+		{"database without default", plainDB.Collection("users").EffectiveTimeout(background), 10 * time.Second, TimeoutSourceClient},
+		{"database", plain.EffectiveTimeout(background), 5 * time.Second, TimeoutSourceDatabase},
+		{"collection", coll.EffectiveTimeout(background), 2 * time.Second, TimeoutSourceCollection},
+		{"clone", clone.EffectiveTimeout(background), 2 * time.Second, TimeoutSourceCollection},
+		{"operation", coll.EffectiveTimeout(operation), time.Second, TimeoutSourceOperation},
+		{"operation on database", db.EffectiveTimeout(operation), time.Second, TimeoutSourceOperation},
+	}
+
+	for _, tt := range tests {
+		if tt.got.Duration != tt.want || tt.got.Source != tt.source {
+			t.Errorf("%s: expected %v from %v, got %v from %v", tt.name, tt.want, tt.source, tt.got.Duration, tt.got.Source)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(operation, time.Minute)
+	defer cancel()
+	got := coll.EffectiveTimeout(ctx)
+	if got.Source != TimeoutSourceContext || got.Duration <= 0 || got.Duration > time.Minute {
+		t.Errorf("expected the context deadline to win, got %v from %v", got.Duration, got.Source)
+	}
+}
+
+// TestEffectiveTimeoutExplicitZero tests that a zero timeout disables lower-level defaults.
+func TestEffectiveTimeoutExplicitZero(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	db := client.Database("testdb", (&DatabaseOptions{}).SetTimeout(5*time.Second))
+	coll := db.Collection("users", (&CollectionOptions{}).SetTimeout(0))
+
+	got := coll.EffectiveTimeout(context.Background())
+	if got.Duration != 0 || got.Source != TimeoutSourceCollection {
+		t.Errorf("expected no timeout from the collection, got %v from %v", got.Duration, got.Source)
+	}
+
+	got = db.EffectiveTimeout(WithOperationTimeout(context.Background(), 0))
+	if got.Duration != 0 || got.Source != TimeoutSourceOperation {
+		t.Errorf("expected no timeout from the operation, got %v from %v", got.Duration, got.Source)
+	}
+
+	noDefault := newClient(context.Background(), newMockRPCClient(), "mongodb://localhost:27017",
+		DefaultClientOptions())
+	noDefault.timeout = 0
+	if got := noDefault.EffectiveTimeout(context.Background()); got != (OperationTimeout{}) {
+		t.Errorf("expected no timeout, got %v from %v", got.Duration, got.Source)
+	}
+}
+
+// TestOperationTimeoutEnforced tests that a resolved timeout bounds a hanging call.
+func TestOperationTimeoutEnforced(t *testing.T) {
+	hanging := &hangingRPCClient{release: make(chan struct{})}
+	defer close(hanging.release)
+
+	client := newClientWithRPC(hanging, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users",
+		(&CollectionOptions{}).SetTimeout(10*time.Millisecond))
+
+	_, err := coll.CountDocuments(context.Background(), map[string]any{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected collection timeout, got %v", err)
+	}
+
+	ctx := WithOperationTimeout(context.Background(), 10*time.Millisecond)
+	_, err = client.Database("testdb").Collection("users").CountDocuments(ctx, map[string]any{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected operation timeout, got %v", err)
+	}
+}
+
+// TestTimeoutSourceString tests naming timeout levels.
+func TestTimeoutSourceString(t *testing.T) {
+	sources := map[TimeoutSource]string{
+		TimeoutSourceNone:       "none",
+		TimeoutSourceClient:     "client",
+		TimeoutSourceDatabase:   "database",
+		TimeoutSourceCollection: "collection",
+		TimeoutSourceOperation:  "operation",
+		TimeoutSourceContext:    "context",
+	}
+	for source, want := range sources {
+		if got := source.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
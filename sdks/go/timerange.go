@@ -0,0 +1,179 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeRange is a half-open time interval [Start, End) on a document field,
+// scanned in windows of Window each.
+type TimeRange struct {
+	// Field is the time field, such as the timeField of a time series
+	// collection.
+	Field string
+	Start time.Time
+	End   time.Time
+	// Window is the width of each sub-query. The last window is cut short
+	// at End.
+	Window time.Duration
+}
+
+// windows returns the bounds of the windows covering r, in time order.
+func (r TimeRange) windows() [][2]time.Time {
+	var windows [][2]time.Time
+	for start := r.Start; start.Before(r.End); start = start.Add(r.Window) {
+		end := start.Add(r.Window)
+		if end.After(r.End) {
+			end = r.End
+		}
+		windows = append(windows, [2]time.Time{start, end})
+	}
+	return windows
+}
+
+// FindTimeRangeOptions configures FindTimeRange.
+type FindTimeRangeOptions struct {
+	// Concurrency is the number of windows queried at once. Results keep
+	// window order whatever the concurrency. The default, 1, queries the
+	// windows one after another.
+	Concurrency *int
+	// Descending returns the newest documents first, scanning the windows
+	// from End back to Start.
+	Descending *bool
+	Projection any
+	// Limit caps the number of documents returned. Sequential scans stop
+	// at the first window that reaches it.
+	Limit *int64
+}
+
+// SetConcurrency sets the number of windows queried at once.
+func (o *FindTimeRangeOptions) SetConcurrency(n int) *FindTimeRangeOptions {
+	o.Concurrency = &n
+	return o
+}
+
+// SetDescending sets whether the newest documents come first.
+func (o *FindTimeRangeOptions) SetDescending(descending bool) *FindTimeRangeOptions {
+	o.Descending = &descending
+	return o
+}
+
+// SetProjection sets the projection applied to each window.
+func (o *FindTimeRangeOptions) SetProjection(projection any) *FindTimeRangeOptions {
+	o.Projection = projection
+	return o
+}
+
+// SetLimit sets the maximum number of documents to return.
+func (o *FindTimeRangeOptions) SetLimit(limit int64) *FindTimeRangeOptions {
+	o.Limit = &limit
+	return o
+}
+
+// FindTimeRange finds the documents matching filter whose r.Field falls
+// within r, issuing one Find per window instead of a single query over the
+// whole range. Each window is sorted by the time field, so the merged
+// cursor is in time order. This keeps every query small when scanning long
+// ranges of a time series collection.
+//
+// FindTimeRange returns ErrInvalidOption when r has no field, a
+// non-positive window or an end not after its start. If any window fails,
+// the error is returned and no cursor.
+func (c *Collection) FindTimeRange(ctx context.Context, filter any, r TimeRange, opts ...*FindTimeRangeOptions) (*Cursor, error) {
+	if r.Field == "" || r.Window <= 0 || !r.End.After(r.Start) {
+		return nil, fmt.Errorf("%w: time range needs a field, a positive window and an end after its start", ErrInvalidOption)
+	}
+
+	merged := &FindTimeRangeOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.Concurrency != nil {
+				merged.Concurrency = opt.Concurrency
+			}
+			if opt.Descending != nil {
+				merged.Descending = opt.Descending
+			}
+			if opt.Projection != nil {
+				merged.Projection = opt.Projection
+			}
+			if opt.Limit != nil {
+				merged.Limit = opt.Limit
+			}
+		}
+	}
+
+	windows := r.windows()
+	order := 1
+	if merged.Descending != nil && *merged.Descending {
+		order = -1
+		for i, j := 0, len(windows)-1; i < j; i, j = i+1, j-1 {
+			windows[i], windows[j] = windows[j], windows[i]
+		}
+	}
+
+	results := make([][]any, len(windows))
+	findWindow := func(i int) error {
+		bounds := map[string]any{r.Field: map[string]any{"$gte": windows[i][0], "$lt": windows[i][1]}}
+		windowFilter := any(bounds)
+		if filter != nil {
+			windowFilter = map[string]any{"$and": []any{filter, bounds}}
+		}
+		findOpts := (&FindOptions{}).SetSort(map[string]any{r.Field: order})
+		if merged.Projection != nil {
+			findOpts.SetProjection(merged.Projection)
+		}
+		if merged.Limit != nil {
+			findOpts.SetLimit(*merged.Limit)
+		}
+		cursor, err := c.Find(ctx, windowFilter, findOpts)
+		if err != nil {
+			return err
+		}
+		results[i] = cursor.documents
+		return nil
+	}
+
+	concurrency := 1
+	if merged.Concurrency != nil {
+		concurrency = *merged.Concurrency
+	}
+	if concurrency > 1 {
+		if err := runParallel(ctx, len(windows), concurrency, findWindow); err != nil {
+			return nil, err
+		}
+	} else {
+		found := int64(0)
+		for i := range windows {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := findWindow(i); err != nil {
+				return nil, err
+			}
+			found += int64(len(results[i]))
+			if merged.Limit != nil && *merged.Limit > 0 && found >= *merged.Limit {
+				break
+			}
+		}
+	}
+
+	var docs []any
+	for _, windowDocs := range results {
+		docs = append(docs, windowDocs...)
+	}
+	if merged.Limit != nil && *merged.Limit > 0 && int64(len(docs)) > *merged.Limit {
+		docs = docs[:*merged.Limit]
+	}
+	if docs == nil {
+		docs = []any{}
+	}
+
+	cursor := newCursor(docs)
+	cursor.naming = c.naming
+	cursor.numbers = c.numbers
+	cursor.decoder = c.decoder
+	cursor.strict = c.strict
+	c.database.client.trackCursor(cursor, c.namespace())
+	return cursor, nil
+}
@@ -0,0 +1,139 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// windowRPCClient answers each find with one document stamped with the
+// start of the queried window. It is safe for concurrent use.
+type windowRPCClient struct {
+	mu      sync.Mutex
+	filters []any
+}
+
+func (w *windowRPCClient) Call(method string, args ...any) RPCPromise {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filters = append(w.filters, args[2])
+	bounds := args[2].(map[string]any)["ts"].(map[string]any)
+	return &mockPromise{result: []any{map[string]any{"ts": bounds["$gte"].(time.Time).Format(time.RFC3339)}}}
+}
+
+func (w *windowRPCClient) Close() error      { return nil }
+func (w *windowRPCClient) IsConnected() bool { return true }
+
+// TestTimeRangeWindows tests splitting a range into windows.
+func TestTimeRangeWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := TimeRange{Field: "ts", Start: start, End: start.Add(150 * time.Minute), Window: time.Hour}
+	want := [][2]time.Time{
+		{start, start.Add(time.Hour)},
+		{start.Add(time.Hour), start.Add(2 * time.Hour)},
+		{start.Add(2 * time.Hour), start.Add(150 * time.Minute)},
+	}
+	if got := r.windows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestFindTimeRange tests sequential window queries and the limit.
+func TestFindTimeRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"n": float64(3)}, map[string]any{"n": float64(4)}}, nil)
+	mock.addCall("mongo.find", []any{map[string]any{"n": float64(5)}}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("metrics")
+	ctx := context.Background()
+
+	r := TimeRange{Field: "ts", Start: start, End: start.Add(3 * time.Hour), Window: time.Hour}
+	cursor, err := coll.FindTimeRange(ctx, map[string]any{"sensor": "a"}, r, (&FindTimeRangeOptions{}).SetLimit(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs []map[string]any
+	if err := cursor.All(ctx, &docs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 3 || docs[2]["n"] != float64(3) {
+		t.Errorf("expected the first 3 documents, got %v", docs)
+	}
+	if mock.callIndex != 2 {
+		t.Errorf("expected the scan to stop after 2 windows, made %d calls", mock.callIndex)
+	}
+
+	wantFilter := map[string]any{"$and": []any{
+		map[string]any{"sensor": "a"},
+		map[string]any{"ts": map[string]any{"$gte": start.Add(time.Hour), "$lt": start.Add(2 * time.Hour)}},
+	}}
+	if !reflect.DeepEqual(mock.calls[1].args[2], wantFilter) {
+		t.Errorf("expected filter %v, got %v", wantFilter, mock.calls[1].args[2])
+	}
+	options := mock.calls[1].args[3].(map[string]any)
+	if !reflect.DeepEqual(options["sort"], map[string]any{"ts": 1}) || options["limit"] != int64(3) {
+		t.Errorf("unexpected options: %v", options)
+	}
+}
+
+// TestFindTimeRangeParallel tests that parallel windows keep time order.
+func TestFindTimeRangeParallel(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rpc := &windowRPCClient{}
+	client := newClientWithRPC(rpc, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("metrics")
+	ctx := context.Background()
+
+	r := TimeRange{Field: "ts", Start: start, End: start.Add(24 * time.Hour), Window: time.Hour}
+	opts := (&FindTimeRangeOptions{}).SetConcurrency(4).SetDescending(true)
+	cursor, err := coll.FindTimeRange(ctx, nil, r, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs []map[string]any
+	if err := cursor.All(ctx, &docs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 24 || len(rpc.filters) != 24 {
+		t.Fatalf("expected 24 windows, got %d documents from %d queries", len(docs), len(rpc.filters))
+	}
+	for i, doc := range docs {
+		want := start.Add(time.Duration(23-i) * time.Hour).Format(time.RFC3339)
+		if doc["ts"] != want {
+			t.Fatalf("document %d: expected window %s, got %v", i, want, doc["ts"])
+		}
+	}
+}
+
+// TestFindTimeRangeErrors tests invalid ranges and failing windows.
+func TestFindTimeRangeErrors(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+	mock.addCall("mongo.find", nil, errors.New("boom"))
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("metrics")
+	ctx := context.Background()
+
+	for _, r := range []TimeRange{
+		{Start: start, End: start.Add(time.Hour), Window: time.Minute},
+		{Field: "ts", Start: start, End: start.Add(time.Hour)},
+		{Field: "ts", Start: start, End: start, Window: time.Minute},
+	} {
+		if _, err := coll.FindTimeRange(ctx, nil, r); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%+v: expected ErrInvalidOption, got %v", r, err)
+		}
+	}
+
+	r := TimeRange{Field: "ts", Start: start, End: start.Add(3 * time.Hour), Window: time.Hour}
+	if _, err := coll.FindTimeRange(ctx, nil, r); err == nil {
+		t.Error("expected the failing window's error")
+	}
+}
@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// EnableTTL makes the server delete documents once the date in field is
+// older than expireAfter, and returns the name of the TTL index doing so.
+// It is safe to run at every startup: it creates the index when there is
+// none on field, changes the expiry of an existing one with collMod when
+// it differs, and does nothing otherwise. An existing index on field alone
+// that has no expiry is turned into a TTL index, which needs MongoDB 5.1 or
+// later. expireAfter is truncated to whole seconds.
+func (c *Collection) EnableTTL(ctx context.Context, field string, expireAfter time.Duration) (string, error) {
+	if field == "" {
+		return "", fmt.Errorf("%w: TTL field is empty", ErrInvalidOption)
+	}
+	seconds := int64(expireAfter / time.Second)
+	if expireAfter < 0 || seconds > math.MaxInt32 {
+		return "", fmt.Errorf("%w: TTL of %v (want 0 to %d seconds)", ErrInvalidOption, expireAfter, math.MaxInt32)
+	}
+
+	docs, err := c.listIndexes(ctx)
+	if err != nil && !errors.Is(err, ErrNamespaceNotFound) {
+		return "", err
+	}
+	for _, d := range docs {
+		doc, ok := d.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("unexpected index document type: %T", d)
+		}
+		spec, err := parseIndexSpecification(doc)
+		if err != nil {
+			return "", err
+		}
+		if !indexesField(spec, field) {
+			continue
+		}
+		if spec.ExpireAfterSeconds != nil && int64(*spec.ExpireAfterSeconds) == seconds {
+			return spec.Name, nil
+		}
+		opts := (&CollModOptions{}).SetTTLIndexExpiry(spec.Name, seconds)
+		if err := c.database.ModifyCollection(ctx, c.name, *opts); err != nil {
+			return "", err
+		}
+		return spec.Name, nil
+	}
+
+	ttl := int32(seconds)
+	name, err := c.CreateIndex(ctx, IndexModel{
+		Keys:    map[string]any{field: 1},
+		Options: &IndexOptions{ExpireAfterSeconds: &ttl},
+	})
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = field + "_1"
+	}
+	return name, nil
+}
+
+// indexesField reports whether spec is an ascending or descending index on
+// field alone, the only kind that can be a TTL index.
+func indexesField(spec *IndexSpecification, field string) bool {
+	if len(spec.Keys) != 1 || spec.Keys[0].Field != field {
+		return false
+	}
+	dir, ok := asInt64(spec.Keys[0].Value)
+	return ok && (dir == 1 || dir == -1)
+}
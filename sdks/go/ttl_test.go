@@ -0,0 +1,86 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestEnableTTLCreate tests creating a TTL index, including on a collection
+// that does not exist yet.
+func TestEnableTTLCreate(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", map[string]any{"ok": float64(0), "code": float64(26), "codeName": "NamespaceNotFound", "errmsg": "ns not found"}, nil)
+	mock.addCall("mongo.createIndex", "expiresAt_1", nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("sessions")
+
+	name, err := coll.EnableTTL(context.Background(), "expiresAt", 90*time.Minute+500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "expiresAt_1" {
+		t.Errorf("unexpected index name %q", name)
+	}
+	keys := mock.calls[1].args[2].(map[string]any)
+	options := mock.calls[1].args[3].(map[string]any)
+	if keys["expiresAt"] != 1 || options["expireAfterSeconds"] != int32(5400) {
+		t.Errorf("unexpected index %v with options %v", keys, options)
+	}
+}
+
+// TestEnableTTLExisting tests updating and keeping an existing index.
+func TestEnableTTLExisting(t *testing.T) {
+	indexes := []any{
+		map[string]any{"key": map[string]any{"_id": float64(1)}, "name": "_id_"},
+		map[string]any{"key": map[string]any{"createdAt": float64(1), "user": float64(1)}, "name": "createdAt_1_user_1"},
+		map[string]any{"key": map[string]any{"createdAt": float64(-1)}, "name": "createdAt_-1", "expireAfterSeconds": float64(3600)},
+	}
+	mock := newMockRPCClient()
+	mock.addCall("mongo.listIndexes", indexes, nil)
+	mock.addCall("mongo.runCommand", map[string]any{"ok": float64(1)}, nil)
+	mock.addCall("mongo.listIndexes", indexes, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("events")
+	ctx := context.Background()
+
+	name, err := coll.EnableTTL(ctx, "createdAt", 2*time.Hour)
+	if err != nil || name != "createdAt_-1" {
+		t.Fatalf("unexpected result %q, %v", name, err)
+	}
+	cmd := mock.calls[1].args[1].(map[string]any)
+	index := cmd["index"].(map[string]any)
+	if cmd["collMod"] != "events" || index["name"] != "createdAt_-1" || index["expireAfterSeconds"] != int64(7200) {
+		t.Errorf("unexpected collMod command: %v", cmd)
+	}
+
+	if name, err := coll.EnableTTL(ctx, "createdAt", time.Hour); err != nil || name != "createdAt_-1" {
+		t.Errorf("unexpected result %q, %v", name, err)
+	}
+	if mock.callIndex != 3 {
+		t.Errorf("expected no change for a matching TTL, got %d calls", mock.callIndex)
+	}
+}
+
+// TestEnableTTLInvalid tests rejecting unusable arguments.
+func TestEnableTTLInvalid(t *testing.T) {
+	client := newClientWithRPC(newMockRPCClient(), "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("events")
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		field string
+		ttl   time.Duration
+	}{
+		{"", time.Hour},
+		{"createdAt", -time.Second},
+		{"createdAt", 1 << 62},
+	} {
+		if _, err := coll.EnableTTL(ctx, tt.field, tt.ttl); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("EnableTTL(%q, %v): expected ErrInvalidOption, got %v", tt.field, tt.ttl, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ProjectionFor returns an inclusion projection selecting the top-level
+// document fields of v, which must be a struct, a pointer to a struct, or a
+// slice of either. Nested structs are projected as whole subdocuments. The
+// projection is nil for a struct with an inline map, which receives every
+// field.
+func ProjectionFor(v any) (map[string]any, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("mongo: cannot derive projection from %T", v)
+	}
+	return projectionForType(t, NamingAsIs), nil
+}
+
+// projectionForType returns an inclusion projection for struct type t,
+// naming untagged fields with the given strategy. A struct with an inline
+// map receives every field, so it gets no projection.
+func projectionForType(t reflect.Type, naming NamingStrategy) map[string]any {
+	fields := structFields(t)
+	projection := make(map[string]any, len(fields))
+	for _, f := range fields {
+		if f.inlineMap {
+			return nil
+		}
+		projection[f.documentName(naming)] = 1
+	}
+	return projection
+}
+
+// autoProjection returns a projection for T if auto projection is requested
+// and no explicit projection is set, or nil otherwise. The non-empty extra
+// fields are projected too, such as the read repair field, so read repair
+// can compare versions, and the collection's schema version field, so
+// documents are not taken to be at version 0.
+func autoProjection[T any](naming NamingStrategy, auto *bool, explicit any, extra ...string) any {
+	if explicit != nil || auto == nil || !*auto {
+		return nil
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	projection := projectionForType(t, naming)
+	if projection == nil {
+		return nil
+	}
+	for _, field := range extra {
+		if field != "" {
+			projection[field] = 1
+		}
+	}
+	return projection
+}
+
+// FindAs runs Find and decodes every matching document into a T. The options
+// are passed to Find unchanged. With FindOptions.SetAutoProjection(true) and
+// no explicit projection, the projection is derived from T's fields so only
+// those fields are fetched.
+func FindAs[T any](ctx context.Context, coll *Collection, filter any, opts ...*FindOptions) ([]T, error) {
+	var auto *bool
+	var explicit any
+	var repairField string
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.AutoProjection != nil {
+				auto = opt.AutoProjection
+			}
+			if opt.Projection != nil {
+				explicit = opt.Projection
+			}
+			if opt.ReadRepairField != nil {
+				repairField = *opt.ReadRepairField
+			}
+		}
+	}
+	if p := autoProjection[T](coll.naming, auto, explicit, repairField, coll.versionField()); p != nil {
+		opts = append(opts[:len(opts):len(opts)], &FindOptions{Projection: p})
+	}
+
+	cursor, err := coll.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	return AllAs[T](ctx, cursor)
+}
+
+// FindOneAs runs FindOne and decodes the matching document into a T. The
+// options are passed to FindOne unchanged. With
+// FindOneOptions.SetAutoProjection(true) and no explicit projection, the
+// projection is derived from T's fields.
+func FindOneAs[T any](ctx context.Context, coll *Collection, filter any, opts ...*FindOneOptions) (T, error) {
+	var auto *bool
+	var explicit any
+	var repairField string
+	for _, opt := range opts {
+		if opt != nil {
+			if opt.AutoProjection != nil {
+				auto = opt.AutoProjection
+			}
+			if opt.Projection != nil {
+				explicit = opt.Projection
+			}
+			if opt.ReadRepairField != nil {
+				repairField = *opt.ReadRepairField
+			}
+		}
+	}
+	if p := autoProjection[T](coll.naming, auto, explicit, repairField, coll.versionField()); p != nil {
+		opts = append(opts[:len(opts):len(opts)], &FindOneOptions{Projection: p})
+	}
+
+	var result T
+	err := coll.FindOne(ctx, filter, opts...).Decode(&result)
+	return result, err
+}
+
+// AllAs decodes all remaining documents of the cursor into a []T. Options
+// are applied as by Cursor.All.
+func AllAs[T any](ctx context.Context, cursor *Cursor, opts ...*AllOptions) ([]T, error) {
+	results := []T{}
+	if err := cursor.All(ctx, &results, opts...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
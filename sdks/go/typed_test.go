@@ -0,0 +1,250 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type typedUser struct {
+	ID   string `json:"_id"`
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// TestProjectionFor tests deriving a projection from a struct.
+func TestProjectionFor(t *testing.T) {
+	want := map[string]any{"_id": 1, "name": 1, "age": 1}
+
+	for _, v := range []any{typedUser{}, &typedUser{}, []typedUser{}, &[]*typedUser{}} {
+		got, err := ProjectionFor(v)
+		if err != nil {
+			t.Fatalf("%T: unexpected error: %v", v, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: expected %v, got %v", v, want, got)
+		}
+	}
+
+	if _, err := ProjectionFor(map[string]any{}); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
+
+// TestFindAsAutoProjection tests FindAs with a derived projection.
+func TestFindAsAutoProjection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "1", "name": "John", "age": float64(30)},
+		map[string]any{"_id": "2", "name": "Jane", "age": float64(25)},
+	}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	users, err := FindAs[typedUser](context.Background(), coll, map[string]any{}, (&FindOptions{}).SetAutoProjection(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(users) != 2 || users[1].Name != "Jane" || users[0].Age != 30 {
+		t.Errorf("unexpected users: %+v", users)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if !reflect.DeepEqual(options["projection"], map[string]any{"_id": 1, "name": 1, "age": 1}) {
+		t.Errorf("unexpected projection: %v", options["projection"])
+	}
+}
+
+// TestFindAsExplicitProjection tests that an explicit projection wins over auto projection.
+func TestFindAsExplicitProjection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	explicit := map[string]any{"name": 1}
+	opts := (&FindOptions{}).SetAutoProjection(true).SetProjection(explicit)
+	users, err := FindAs[typedUser](context.Background(), coll, map[string]any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if users == nil || len(users) != 0 {
+		t.Errorf("expected empty non-nil slice, got %v", users)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if !reflect.DeepEqual(options["projection"], explicit) {
+		t.Errorf("unexpected projection: %v", options["projection"])
+	}
+}
+
+// TestFindAsAutoProjectionVersioning tests projecting the schema version
+// field, so current documents are not upgraded again.
+func TestFindAsAutoProjectionVersioning(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{map[string]any{"_id": "1", "name": "John", "v": float64(1)}}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "John", "v": float64(1)}, nil)
+
+	upgrades := 0
+	versioning := NewDocumentVersioning(1).SetField("v").Upgrade(0, func(doc map[string]any) (map[string]any, error) {
+		upgrades++
+		return doc, nil
+	})
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetVersioning(versioning))
+	ctx := context.Background()
+
+	if _, err := FindAs[typedUser](ctx, coll, map[string]any{}, (&FindOptions{}).SetAutoProjection(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := FindOneAs[typedUser](ctx, coll, map[string]any{}, (&FindOneOptions{}).SetAutoProjection(true)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"_id": 1, "name": 1, "age": 1, "v": 1}
+	for i := range mock.calls {
+		if projection := mock.calls[i].args[3].(map[string]any)["projection"]; !reflect.DeepEqual(projection, want) {
+			t.Errorf("expected projection %v, got %v", want, projection)
+		}
+	}
+	if upgrades != 0 {
+		t.Errorf("expected no upgrades of current documents, got %d", upgrades)
+	}
+}
+
+// TestFindAsWithoutAutoProjection tests that no projection is sent by default.
+func TestFindAsWithoutAutoProjection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	if _, err := FindAs[typedUser](context.Background(), coll, map[string]any{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if _, ok := options["projection"]; ok {
+		t.Errorf("unexpected projection: %v", options["projection"])
+	}
+}
+
+// TestFindAsForwardsOptions tests that FindAs passes its options to Find.
+func TestFindAsForwardsOptions(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	opts := (&FindOptions{}).SetLimit(5).SetNoCursorTimeout(true)
+	if _, err := FindAs[typedUser](context.Background(), coll, map[string]any{}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	options := mock.calls[0].args[3].(map[string]any)
+	if options["limit"] != int64(5) || options["noCursorTimeout"] != true {
+		t.Errorf("unexpected options: %v", options)
+	}
+}
+
+// TestFindOneAsAutoProjection tests FindOneAs with a derived projection.
+func TestFindOneAsAutoProjection(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "John"}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	user, err := FindOneAs[*typedUser](context.Background(), coll, map[string]any{"_id": "1"}, (&FindOneOptions{}).SetAutoProjection(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user == nil || user.Name != "John" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+
+	args := mock.calls[0].args
+	options := args[len(args)-1].(map[string]any)
+	if _, ok := options["projection"].(map[string]any)["age"]; !ok {
+		t.Errorf("expected derived projection, got %v", options["projection"])
+	}
+}
+
+// TestFindOneAsNoDocuments tests FindOneAs when nothing matches.
+func TestFindOneAsNoDocuments(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", nil, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users")
+
+	_, err := FindOneAs[typedUser](context.Background(), coll, map[string]any{})
+	if !errors.Is(err, ErrNoDocuments) {
+		t.Errorf("expected ErrNoDocuments, got %v", err)
+	}
+}
+
+// TestFindAsReadRepair tests that FindAs keeps the read-repair option and
+// projects the version field.
+func TestFindAsReadRepair(t *testing.T) {
+	primary := newMockRPCClient()
+	primary.addCall("mongo.find", []any{
+		map[string]any{"_id": "1", "name": "old", "v": float64(1)},
+	}, nil)
+	replica := newMockRPCClient()
+	replica.addCall("mongo.find", []any{
+		map[string]any{"_id": "1", "name": "new", "v": float64(2)},
+	}, nil)
+
+	client := newClientWithRPC(primary, "mongodb://localhost:27017")
+	client.repairRPC = replica
+	coll := client.Database("testdb").Collection("users")
+
+	opts := (&FindOptions{}).SetReadRepair("v").SetAutoProjection(true)
+	users, err := FindAs[typedUser](context.Background(), coll, map[string]any{}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "new" {
+		t.Errorf("expected the repaired user, got %+v", users)
+	}
+
+	if replica.callIndex != 1 {
+		t.Fatal("expected the query on the repair endpoint")
+	}
+	options := primary.calls[0].args[3].(map[string]any)
+	want := map[string]any{"_id": 1, "name": 1, "age": 1, "v": 1}
+	if !reflect.DeepEqual(options["projection"], want) {
+		t.Errorf("expected projection %v, got %v", want, options["projection"])
+	}
+}
+
+// TestFindOneAsReadRepair tests that FindOneAs keeps the read-repair option.
+func TestFindOneAsReadRepair(t *testing.T) {
+	primary := newMockRPCClient()
+	primary.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "new", "v": float64(2)}, nil)
+	replica := newMockRPCClient()
+	replica.addCall("mongo.findOne", map[string]any{"_id": "1", "name": "old", "v": float64(1)}, nil)
+
+	client := newClientWithRPC(primary, "mongodb://localhost:27017")
+	client.repairRPC = replica
+	coll := client.Database("testdb").Collection("users")
+
+	user, err := FindOneAs[typedUser](context.Background(), coll, map[string]any{"_id": "1"}, (&FindOneOptions{}).SetReadRepair("v"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Name != "new" {
+		t.Errorf("expected the newer user, got %+v", user)
+	}
+	if replica.callIndex != 1 {
+		t.Error("expected the query on the repair endpoint")
+	}
+}
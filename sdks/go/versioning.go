@@ -0,0 +1,241 @@
+package mongo
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.mongo.do/bson"
+)
+
+// DefaultSchemaVersionField is the field DocumentVersioning stores schema
+// versions in.
+const DefaultSchemaVersionField = "schemaVersion"
+
+// UpgradeFunc upgrades a document from one schema version to the next. It
+// may modify and return doc or return a new document; the version field is
+// set by the caller.
+type UpgradeFunc func(doc map[string]any) (map[string]any, error)
+
+// DocumentVersioning lets document shapes evolve gradually instead of in a
+// single migration. Documents inserted or replaced through a collection
+// with CollectionOptions.Versioning, or inserted by an upsert, are stamped
+// with the current version, and documents read with Find, FindOne or the
+// FindOneAnd methods at an older version are upgraded one version at a
+// time before they are decoded.
+// Documents without the version field are taken to be at version 0, so
+// documents written before versioning was introduced are upgraded too.
+//
+// Upgrades happen on read only; stored documents change when they are next
+// replaced. Documents at a newer version than the current one, written by a
+// newer release of the application, are returned unchanged. Projections
+// should include the version field, or projected documents are taken to be
+// at version 0. Aggregation results are not upgraded, as they need not have
+// the shape of stored documents.
+type DocumentVersioning struct {
+	version  int
+	field    string
+	upgrades map[int]UpgradeFunc
+}
+
+// NewDocumentVersioning returns versioning at the current schema version.
+func NewDocumentVersioning(version int) *DocumentVersioning {
+	return &DocumentVersioning{
+		version:  version,
+		field:    DefaultSchemaVersionField,
+		upgrades: make(map[int]UpgradeFunc),
+	}
+}
+
+// SetField sets the field the version is stored in.
+func (v *DocumentVersioning) SetField(field string) *DocumentVersioning {
+	v.field = field
+	return v
+}
+
+// Upgrade registers fn to upgrade documents from version from to from+1.
+func (v *DocumentVersioning) Upgrade(from int, fn UpgradeFunc) *DocumentVersioning {
+	v.upgrades[from] = fn
+	return v
+}
+
+// Version returns the current schema version.
+func (v *DocumentVersioning) Version() int {
+	return v.version
+}
+
+// stamp returns doc, an encoded document, with the version field set to
+// the current version. The caller's document is not modified.
+func (v *DocumentVersioning) stamp(doc any) (any, error) {
+	switch d := doc.(type) {
+	case bson.D:
+		stamped := make(bson.D, 0, len(d)+1)
+		for _, e := range d {
+			if e.Key != v.field {
+				stamped = append(stamped, e)
+			}
+		}
+		return append(stamped, bson.E{Key: v.field, Value: v.version}), nil
+	case bson.M:
+		doc = map[string]any(d)
+	}
+
+	m, ok := doc.(map[string]any)
+	if !ok {
+		// Structs encoding/json would encode with NamingAsIs.
+		m, ok = encodeNamedValue(reflect.ValueOf(doc), NamingAsIs).(map[string]any)
+	}
+	if !ok {
+		return nil, fmt.Errorf("mongo: cannot stamp a schema version on a %T document", doc)
+	}
+	stamped := make(map[string]any, len(m)+1)
+	for k, val := range m {
+		stamped[k] = val
+	}
+	stamped[v.field] = v.version
+	return stamped, nil
+}
+
+// stampUpsert returns update, an encoded update document, with a
+// $setOnInsert of the version field, so a document the upsert inserts is
+// stamped while the documents it updates keep their version. Updates that
+// already set the version field, and pipeline updates, are returned as is.
+// The caller's update is not modified.
+func (v *DocumentVersioning) stampUpsert(update any) (any, error) {
+	switch u := update.(type) {
+	case bson.D:
+		stamped := make(bson.D, 0, len(u)+1)
+		found := false
+		for _, e := range u {
+			if hasKey(e.Value, v.field) {
+				return update, nil
+			}
+			if e.Key == "$setOnInsert" {
+				operand, err := v.stamp(e.Value)
+				if err != nil {
+					return nil, err
+				}
+				e = bson.E{Key: e.Key, Value: operand}
+				found = true
+			}
+			stamped = append(stamped, e)
+		}
+		if !found {
+			stamped = append(stamped, bson.E{Key: "$setOnInsert", Value: map[string]any{v.field: v.version}})
+		}
+		return stamped, nil
+	case bson.M:
+		update = map[string]any(u)
+	}
+
+	m, ok := update.(map[string]any)
+	if !ok {
+		return update, nil
+	}
+	stamped := make(map[string]any, len(m)+1)
+	for op, operand := range m {
+		if hasKey(operand, v.field) {
+			return update, nil
+		}
+		stamped[op] = operand
+	}
+	if operand, ok := m["$setOnInsert"]; ok {
+		onInsert, err := v.stamp(operand)
+		if err != nil {
+			return nil, err
+		}
+		stamped["$setOnInsert"] = onInsert
+	} else {
+		stamped["$setOnInsert"] = map[string]any{v.field: v.version}
+	}
+	return stamped, nil
+}
+
+// hasKey reports whether doc is a document with the top-level key.
+func hasKey(doc any, key string) bool {
+	switch d := doc.(type) {
+	case map[string]any:
+		_, ok := d[key]
+		return ok
+	case bson.M:
+		_, ok := d[key]
+		return ok
+	case bson.D:
+		for _, e := range d {
+			if e.Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgrade returns doc, a result document, upgraded to the current version.
+// Documents that are not maps, or are already current, are returned as is.
+func (v *DocumentVersioning) upgrade(client *Client, doc any) (any, error) {
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return doc, nil
+	}
+	version := 0
+	if raw, ok := m[v.field]; ok {
+		n, ok := asInt64(raw)
+		if !ok {
+			return nil, fmt.Errorf("mongo: invalid schema version %v", raw)
+		}
+		version = int(n)
+	}
+
+	for ; version < v.version; version++ {
+		fn := v.upgrades[version]
+		if fn == nil {
+			return nil, fmt.Errorf("%w: no upgrade from schema version %d", ErrInvalidOption, version)
+		}
+		err := client.runCallback("DocumentVersioning upgrade", func() error {
+			upgraded, err := fn(m)
+			m = upgraded
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("mongo: upgrading document from schema version %d: %w", version, err)
+		}
+		if m == nil {
+			return nil, fmt.Errorf("mongo: upgrade from schema version %d returned no document", version)
+		}
+		m[v.field] = version + 1
+	}
+	return m, nil
+}
+
+// stampVersion stamps doc with the collection's schema version, if any.
+func (c *Collection) stampVersion(doc any) (any, error) {
+	if c.versioning == nil || doc == nil {
+		return doc, nil
+	}
+	return c.versioning.stamp(doc)
+}
+
+// stampUpsert stamps the documents an update with options may insert with
+// the collection's schema version, if it is an upsert and there is one.
+func (c *Collection) stampUpsert(update any, options map[string]any) (any, error) {
+	if upsert, _ := options["upsert"].(bool); c.versioning == nil || !upsert || update == nil {
+		return update, nil
+	}
+	return c.versioning.stampUpsert(update)
+}
+
+// versionField returns the field the collection's schema version is stored
+// in, or "" without versioning.
+func (c *Collection) versionField() string {
+	if c.versioning == nil {
+		return ""
+	}
+	return c.versioning.field
+}
+
+// upgradeVersion upgrades doc to the collection's schema version, if any.
+func (c *Collection) upgradeVersion(doc any) (any, error) {
+	if c.versioning == nil || doc == nil {
+		return doc, nil
+	}
+	return c.versioning.upgrade(c.database.client, doc)
+}
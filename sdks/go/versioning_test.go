@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongo.do/bson"
+)
+
+// testVersioning returns versioning at version 2: version 1 split name into
+// first and last, and version 2 renamed mail to email.
+func testVersioning() *DocumentVersioning {
+	return NewDocumentVersioning(2).
+		Upgrade(0, func(doc map[string]any) (map[string]any, error) {
+			first, last, _ := strings.Cut(doc["name"].(string), " ")
+			delete(doc, "name")
+			doc["first"], doc["last"] = first, last
+			return doc, nil
+		}).
+		Upgrade(1, func(doc map[string]any) (map[string]any, error) {
+			doc["email"] = doc["mail"]
+			delete(doc, "mail")
+			return doc, nil
+		})
+}
+
+type versionedUser struct {
+	ID    string `json:"_id"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Email string `json:"email"`
+}
+
+// TestVersioningStamp tests stamping inserted and replaced documents.
+func TestVersioningStamp(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.insertOne", map[string]any{"insertedId": "u1"}, nil)
+	mock.addCall("mongo.insertMany", map[string]any{"insertedIds": []any{"u2"}}, nil)
+	mock.addCall("mongo.replaceOne", map[string]any{"matchedCount": float64(1), "modifiedCount": float64(1)}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetVersioning(testVersioning()))
+	ctx := context.Background()
+
+	if _, err := coll.InsertOne(ctx, versionedUser{ID: "u1", First: "Ada"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.InsertMany(ctx, []any{bson.D{{Key: "_id", Value: "u2"}, {Key: "schemaVersion", Value: 1}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.ReplaceOne(ctx, map[string]any{"_id": "u1"}, bson.M{"first": "Grace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := coll.BulkWrite(ctx, []WriteModel{&InsertOneModel{Document: map[string]any{"_id": "u3"}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inserted := mock.calls[0].args[2]
+	if want := map[string]any{"_id": "u1", "first": "Ada", "last": "", "email": "", "schemaVersion": 2}; !reflect.DeepEqual(inserted, want) {
+		t.Errorf("expected %v, got %v", want, inserted)
+	}
+	many := mock.calls[1].args[2].([]any)[0]
+	if want := (bson.D{{Key: "_id", Value: "u2"}, {Key: "schemaVersion", Value: 2}}); !reflect.DeepEqual(many, want) {
+		t.Errorf("expected %v, got %v", want, many)
+	}
+	replacement := mock.calls[2].args[3]
+	if want := map[string]any{"first": "Grace", "schemaVersion": 2}; !reflect.DeepEqual(replacement, want) {
+		t.Errorf("expected %v, got %v", want, replacement)
+	}
+	op := mock.calls[3].args[2].([]map[string]any)[0]["insertOne"].(map[string]any)
+	if want := map[string]any{"_id": "u3", "schemaVersion": 2}; !reflect.DeepEqual(op["document"], want) {
+		t.Errorf("expected %v, got %v", want, op["document"])
+	}
+}
+
+// TestVersioningStampUpsert tests stamping the documents upserts and
+// InsertAndGet insert, leaving plain updates and updates that set the
+// version field alone.
+func TestVersioningStampUpsert(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(0), "upsertedId": "u1"}, nil)
+	mock.addCall("mongo.updateMany", map[string]any{"matchedCount": float64(1)}, nil)
+	mock.addCall("mongo.findOneAndUpdate", map[string]any{"_id": "u2", "schemaVersion": float64(2)}, nil)
+	mock.addCall("mongo.updateOne", map[string]any{"matchedCount": float64(1)}, nil)
+	mock.addCall("mongo.bulkWrite", map[string]any{}, nil)
+	mock.addCall("mongo.findOneAndUpdate", map[string]any{"_id": "u4", "first": "Ada", "schemaVersion": float64(2)}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetVersioning(testVersioning()))
+	ctx := context.Background()
+	upsert := (&UpdateOptions{}).SetUpsert(true)
+
+	update := map[string]any{"$set": map[string]any{"first": "Ada"}, "$setOnInsert": map[string]any{"last": "Lovelace"}}
+	if _, err := coll.UpdateOne(ctx, map[string]any{"_id": "u1"}, update, upsert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"$set": map[string]any{"first": "Ada"}, "$setOnInsert": map[string]any{"last": "Lovelace", "schemaVersion": 2}}
+	if got := mock.calls[0].args[3]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if _, ok := update["$setOnInsert"].(map[string]any)["schemaVersion"]; ok {
+		t.Error("expected the caller's update to be left unmodified")
+	}
+
+	if _, err := coll.UpdateMany(ctx, map[string]any{}, map[string]any{"$set": map[string]any{"x": 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mock.calls[1].args[3]; !reflect.DeepEqual(got, map[string]any{"$set": map[string]any{"x": 1}}) {
+		t.Errorf("expected a plain update to be left alone, got %v", got)
+	}
+
+	if err := coll.FindOneAndUpdate(ctx, map[string]any{"_id": "u2"}, bson.D{{Key: "$set", Value: bson.M{"x": 1}}},
+		(&FindOneAndUpdateOptions{}).SetUpsert(true)).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantD := bson.D{{Key: "$set", Value: bson.M{"x": 1}}, {Key: "$setOnInsert", Value: map[string]any{"schemaVersion": 2}}}
+	if got := mock.calls[2].args[3]; !reflect.DeepEqual(got, wantD) {
+		t.Errorf("expected %v, got %v", wantD, got)
+	}
+
+	own := map[string]any{"$set": map[string]any{"schemaVersion": 2}}
+	if _, err := coll.UpdateOne(ctx, map[string]any{"_id": "u3"}, own, upsert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mock.calls[3].args[3]; !reflect.DeepEqual(got, own) {
+		t.Errorf("expected an update setting the version to be left alone, got %v", got)
+	}
+
+	yes := true
+	if _, err := coll.BulkWrite(ctx, []WriteModel{&UpdateOneModel{Filter: map[string]any{"_id": "u5"}, Update: map[string]any{"$inc": map[string]any{"n": 1}}, Upsert: &yes}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	op := mock.calls[4].args[2].([]map[string]any)[0]["updateOne"].(map[string]any)
+	if want := map[string]any{"$inc": map[string]any{"n": 1}, "$setOnInsert": map[string]any{"schemaVersion": 2}}; !reflect.DeepEqual(op["update"], want) {
+		t.Errorf("expected %v, got %v", want, op["update"])
+	}
+
+	if err := coll.InsertAndGet(ctx, map[string]any{"_id": "u4", "first": "Ada"}).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	onInsert := mock.calls[5].args[3].(map[string]any)["$setOnInsert"]
+	if want := map[string]any{"first": "Ada", "schemaVersion": 2}; !reflect.DeepEqual(onInsert, want) {
+		t.Errorf("expected %v, got %v", want, onInsert)
+	}
+}
+
+// TestVersioningUpgrade tests upgrading older documents as they are read.
+func TestVersioningUpgrade(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.find", []any{
+		map[string]any{"_id": "u1", "name": "Ada Lovelace", "mail": "ada@example.com"},
+		map[string]any{"_id": "u2", "first": "Grace", "last": "Hopper", "mail": "grace@example.com", "schemaVersion": float64(1)},
+		map[string]any{"_id": "u3", "first": "Alan", "last": "Turing", "email": "alan@example.com", "schemaVersion": float64(2)},
+	}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u1", "name": "Ada Lovelace", "mail": "ada@example.com"}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetVersioning(testVersioning()))
+	ctx := context.Background()
+
+	cur, err := coll.Find(ctx, map[string]any{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var users []versionedUser
+	if err := cur.All(ctx, &users); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []versionedUser{
+		{ID: "u1", First: "Ada", Last: "Lovelace", Email: "ada@example.com"},
+		{ID: "u2", First: "Grace", Last: "Hopper", Email: "grace@example.com"},
+		{ID: "u3", First: "Alan", Last: "Turing", Email: "alan@example.com"},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("expected %v, got %v", want, users)
+	}
+
+	var doc map[string]any
+	if err := coll.FindOne(ctx, map[string]any{"_id": "u1"}).Decode(&doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc["first"] != "Ada" || doc["schemaVersion"] != float64(2) {
+		t.Errorf("expected an upgraded document, got %v", doc)
+	}
+}
+
+// TestVersioningUpgradeErrors tests failing and missing upgrades.
+func TestVersioningUpgradeErrors(t *testing.T) {
+	errUpgrade := errors.New("bad document")
+	versioning := NewDocumentVersioning(2).Upgrade(0, func(doc map[string]any) (map[string]any, error) {
+		return nil, errUpgrade
+	})
+
+	mock := newMockRPCClient()
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u1"}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u1", "schemaVersion": float64(1)}, nil)
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetVersioning(versioning))
+	ctx := context.Background()
+
+	if err := coll.FindOne(ctx, map[string]any{}).Err(); !errors.Is(err, errUpgrade) {
+		t.Errorf("expected the upgrade error, got %v", err)
+	}
+	if err := coll.FindOne(ctx, map[string]any{}).Err(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("expected ErrInvalidOption for a missing upgrade, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// hostResolver resolves the hosts of the connection URI; *net.Resolver
+// implements it.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// WarmUpOptions configures a WarmUp operation.
+type WarmUpOptions struct {
+	// Ping sends a ping on each connection after the handshake, so the
+	// server has served a request before the first user request. It
+	// defaults to true.
+	Ping *bool
+}
+
+// SetPing sets whether each connection is pinged.
+func (o *WarmUpOptions) SetPing(ping bool) *WarmUpOptions {
+	o.Ping = &ping
+	return o
+}
+
+// WarmUpResult describes a WarmUp.
+type WarmUpResult struct {
+	// Addresses maps each host of the connection URI, or each target of
+	// its SRV record, to the addresses it resolved to.
+	Addresses map[string][]string
+	// Connections is the number of connections warmed: the one operations
+	// use, and the read repair endpoint's, if any.
+	Connections int
+	// Elapsed is how long the warm-up took.
+	Elapsed time.Duration
+}
+
+// WarmUp prepares the client for its first requests, so they do not pay
+// cold-start latency. It resolves the hosts of the connection URI, looking
+// up the SRV record of mongodb+srv URIs, which leaves the resolver's
+// caches primed; it makes sure the client's connection is established,
+// repeats the handshake on it to refresh the server description, and
+// pings it unless WarmUpOptions.Ping is false. The connection to the read
+// repair endpoint, if any, is pinged too.
+//
+// Operations share one transport connection, multiplexed by the RPC
+// backend and serialized by the wire protocol backend, so WarmUp warms
+// that one connection. It does not open more, and with the wire protocol
+// the handshake is a hello on the authenticated connection rather than a
+// new authentication. While the connection is being re-established,
+// WarmUp waits for it as operations do. A MinPoolSize above 1 cannot be
+// met: WarmUp still warms the connection, then returns the result with an
+// error wrapping ErrMinPoolSizeUnmet. Call WarmUp after NewClient, such as
+// before a service reports itself ready.
+func (c *Client) WarmUp(ctx context.Context, opts ...*WarmUpOptions) (*WarmUpResult, error) {
+	ping := true
+	for _, opt := range opts {
+		if opt != nil && opt.Ping != nil {
+			ping = *opt.Ping
+		}
+	}
+	start := c.clock.Now()
+
+	addresses, err := c.resolveHosts(ctx)
+	if err != nil {
+		return nil, &ConnectionError{Address: c.uri, Wrapped: err}
+	}
+	result := &WarmUpResult{Addresses: addresses}
+
+	rpcClient, err := c.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.handshake(ctx, rpcClient); err != nil {
+		return nil, &ConnectionError{Address: c.uri, Wrapped: err}
+	}
+	if ping {
+		if err := c.Ping(ctx); err != nil {
+			return nil, err
+		}
+	}
+	result.Connections++
+
+	if c.repairRPC != nil {
+		if ping {
+			if _, err := c.call(ctx, c.repairRPC, "mongo.ping"); err != nil {
+				return nil, fmt.Errorf("mongo: warming up the read repair endpoint: %w", err)
+			}
+		}
+		result.Connections++
+	}
+
+	result.Elapsed = c.clock.Now().Sub(start)
+	if c.minPoolSize > 1 {
+		return result, fmt.Errorf("%w: MinPoolSize is %d, but the client uses a single connection", ErrMinPoolSizeUnmet, c.minPoolSize)
+	}
+	return result, nil
+}
+
+// resolveHosts resolves the hosts of the connection URI, following the SRV
+// record of a mongodb+srv URI. IP addresses are returned as they are.
+func (c *Client) resolveHosts(ctx context.Context) (map[string][]string, error) {
+	hosts, srv, err := uriHosts(c.uri)
+	if err != nil {
+		return nil, err
+	}
+	if srv != "" {
+		_, records, err := c.resolver.LookupSRV(ctx, "mongodb", "tcp", srv)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			hosts = append(hosts, strings.TrimSuffix(record.Target, "."))
+		}
+	}
+
+	addresses := make(map[string][]string, len(hosts))
+	for _, host := range hosts {
+		if net.ParseIP(host) != nil {
+			addresses[host] = []string{host}
+			continue
+		}
+		addrs, err := c.resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		addresses[host] = addrs
+	}
+	return addresses, nil
+}
+
+// uriHosts returns the host names of uri, without ports, or for a
+// mongodb+srv URI the name whose SRV record lists them.
+func uriHosts(uri string) (hosts []string, srv string, err error) {
+	if strings.HasPrefix(uri, "mongodb://") {
+		cfg, err := parseWireURI(uri)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, host := range cfg.hosts {
+			name, _, _ := net.SplitHostPort(host)
+			hosts = append(hosts, name)
+		}
+		return hosts, "", nil
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidURI, err)
+	}
+	if parsed.Scheme == "mongodb+srv" {
+		return nil, parsed.Hostname(), nil
+	}
+	return []string{parsed.Hostname()}, "", nil
+}
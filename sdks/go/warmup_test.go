@@ -0,0 +1,128 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// fakeResolver answers lookups from its records and counts them.
+type fakeResolver struct {
+	hosts   map[string][]string
+	srv     map[string][]*net.SRV
+	lookups []string
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.lookups = append(r.lookups, host)
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.lookups = append(r.lookups, "_"+service+"._"+proto+"."+name)
+	return "", r.srv[name], nil
+}
+
+// TestClientWarmUp tests resolving hosts, repeating the handshake and
+// pinging.
+func TestClientWarmUp(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{"version": "7.0.4", "maxWriteBatchSize": float64(1000)}, nil)
+	mock.addCall("mongo.ping", map[string]any{"ok": float64(1)}, nil)
+	client := newClientWithRPC(mock, "mongodb://db1.example:27017,10.0.0.2/shop")
+	resolver := &fakeResolver{hosts: map[string][]string{"db1.example": {"10.0.0.1"}}}
+	client.resolver = resolver
+
+	result, err := client.WarmUp(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{"db1.example": {"10.0.0.1"}, "10.0.0.2": {"10.0.0.2"}}
+	if !reflect.DeepEqual(result.Addresses, want) {
+		t.Errorf("expected addresses %v, got %v", want, result.Addresses)
+	}
+	if !reflect.DeepEqual(resolver.lookups, []string{"db1.example"}) {
+		t.Errorf("expected one lookup, got %v", resolver.lookups)
+	}
+	if result.Connections != 1 {
+		t.Errorf("expected 1 connection, got %d", result.Connections)
+	}
+	if len(mock.calls) != 2 || mock.calls[0].method != "mongo.hello" || mock.calls[1].method != "mongo.ping" {
+		t.Errorf("expected a handshake and a ping, got %v", mock.calls)
+	}
+	if desc := client.ServerDescription(); desc == nil || desc.Version != "7.0.4" {
+		t.Errorf("expected the handshake to be recorded, got %+v", desc)
+	}
+}
+
+// TestClientWarmUpSRV tests following SRV records and skipping the ping.
+func TestClientWarmUpSRV(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{}, nil)
+	client := newClientWithRPC(mock, "mongodb+srv://cluster.example/shop")
+	client.resolver = &fakeResolver{
+		hosts: map[string][]string{"a.cluster.example": {"10.0.1.1"}, "b.cluster.example": {"10.0.1.2"}},
+		srv: map[string][]*net.SRV{"cluster.example": {
+			{Target: "a.cluster.example.", Port: 27017},
+			{Target: "b.cluster.example.", Port: 27017},
+		}},
+	}
+
+	result, err := client.WarmUp(context.Background(), (&WarmUpOptions{}).SetPing(false))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Addresses) != 2 || result.Addresses["b.cluster.example"][0] != "10.0.1.2" {
+		t.Errorf("unexpected addresses %v", result.Addresses)
+	}
+	if len(mock.calls) != 1 {
+		t.Errorf("expected only the handshake, got %d calls", len(mock.calls))
+	}
+}
+
+// TestClientWarmUpError tests failing on unresolvable hosts.
+func TestClientWarmUpError(t *testing.T) {
+	mock := newMockRPCClient()
+	client := newClientWithRPC(mock, "mongodb://missing.example")
+	client.resolver = &fakeResolver{}
+
+	_, err := client.WarmUp(context.Background())
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected a ConnectionError, got %v", err)
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || dnsErr.Name != "missing.example" {
+		t.Errorf("expected the DNS error, got %v", err)
+	}
+	if len(mock.calls) != 0 {
+		t.Errorf("expected no calls, got %d", len(mock.calls))
+	}
+}
+
+// TestClientWarmUpMinPoolSize tests reporting a MinPoolSize that a single
+// connection cannot meet, after warming that connection.
+func TestClientWarmUpMinPoolSize(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.hello", map[string]any{}, nil)
+	mock.addCall("mongo.ping", map[string]any{"ok": float64(1)}, nil)
+	client := newClient(context.Background(), mock, "mongodb://10.0.0.1/shop", DefaultClientOptions().SetMinPoolSize(3))
+	client.resolver = &fakeResolver{}
+
+	result, err := client.WarmUp(context.Background())
+	if !errors.Is(err, ErrMinPoolSizeUnmet) {
+		t.Fatalf("expected ErrMinPoolSizeUnmet, got %v", err)
+	}
+	if result == nil || result.Connections != 1 {
+		t.Errorf("expected the one connection to be warmed, got %+v", result)
+	}
+	if len(mock.calls) != 2 {
+		t.Errorf("expected a handshake and a ping, got %d calls", len(mock.calls))
+	}
+}
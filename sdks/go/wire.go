@@ -0,0 +1,658 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The wire backend implements RPCClient on top of the MongoDB wire protocol,
+// so the SDK can talk to a real mongod or mongos as well as to the RPC
+// service. Each RPC method is translated to the equivalent database command
+// (see wireops.go) and sent as an OP_MSG over a single connection.
+//
+// Limitations: the backend is not a topology-aware driver. When connecting,
+// it asks each host of the URI with hello whether it is the writable
+// primary, following the primary a secondary reports, and then stays on
+// that single host. It does not monitor the topology, so after a failover
+// commands fail with NotWritablePrimary until the client reconnects, and
+// read preferences other than primary are passed to the server but cannot
+// route reads to another member. With directConnection=true the first
+// reachable host is used whatever its role.
+
+// opMsg is the OP_MSG opcode.
+const opMsg = 2013
+
+// opMsgChecksumPresent is the OP_MSG flag bit for a trailing CRC-32C.
+const opMsgChecksumPresent = 1 << 0
+
+// defaultWirePort is the port used when the URI does not give one.
+const defaultWirePort = "27017"
+
+// wireDeadlineSlack is added to a command's maxTimeMS for the connection
+// deadline, so the server's own time limit error arrives first.
+const wireDeadlineSlack = time.Second
+
+// wireKillCursorsTimeout bounds the best-effort killCursors sent for a
+// cursor left open by a failed operation.
+const wireKillCursorsTimeout = 5 * time.Second
+
+// wireConfig is the connection configuration parsed from a mongodb:// URI.
+type wireConfig struct {
+	hosts      []string
+	username   string
+	password   string
+	authSource string
+	mechanism  string
+	tls        bool
+	// direct connects to the first reachable host without looking for the
+	// primary, as directConnection=true asks.
+	direct  bool
+	timeout time.Duration
+}
+
+// parseWireURI parses a mongodb:// URI. It is parsed by hand because
+// net/url rejects the comma-separated host lists of replica set URIs.
+func parseWireURI(uri string) (*wireConfig, error) {
+	rest, ok := strings.CutPrefix(uri, "mongodb://")
+	if !ok {
+		return nil, fmt.Errorf("%w: wire protocol needs a mongodb:// URI", ErrInvalidURI)
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	hostList, database, _ := strings.Cut(rest, "/")
+
+	cfg := &wireConfig{}
+	if at := strings.LastIndexByte(hostList, '@'); at >= 0 {
+		user, pass, _ := strings.Cut(hostList[:at], ":")
+		var err error
+		if cfg.username, err = url.PathUnescape(user); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+		}
+		if cfg.password, err = url.PathUnescape(pass); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+		}
+		hostList = hostList[at+1:]
+	}
+	for _, host := range strings.Split(hostList, ",") {
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), defaultWirePort)
+		}
+		cfg.hosts = append(cfg.hosts, host)
+	}
+	if len(cfg.hosts) == 0 {
+		return nil, fmt.Errorf("%w: no hosts", ErrInvalidURI)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+	}
+	cfg.tls = query.Get("tls") == "true" || query.Get("ssl") == "true"
+	cfg.direct = query.Get("directConnection") == "true"
+	cfg.mechanism = query.Get("authMechanism")
+	cfg.authSource = query.Get("authSource")
+	if cfg.authSource == "" {
+		cfg.authSource = database
+	}
+	if cfg.authSource == "" {
+		cfg.authSource = "admin"
+	}
+	return cfg, nil
+}
+
+// wireClient is an RPCClient speaking the MongoDB wire protocol.
+type wireClient struct {
+	cfg *wireConfig
+	// sem serializes round trips on conn. It is a channel rather than a
+	// mutex so that a caller waiting for its turn can give up when its
+	// context is done.
+	sem       chan struct{}
+	conn      net.Conn
+	requestID atomic.Int32
+	closed    atomic.Bool
+	// streams are the open change streams, by stream ID.
+	streamsMu sync.Mutex
+	streams   map[string]*wireStream
+	streamSeq int
+	// cursors are the server cursors left open, by cursor ID: those of
+	// results being drained and of change streams. Close kills them.
+	cursorsMu sync.Mutex
+	cursors   map[int64]wireNamespace
+}
+
+// wireNamespace is the database and collection of a server cursor.
+type wireNamespace struct {
+	db, coll string
+}
+
+// newWireClient returns a wireClient using conn.
+func newWireClient(cfg *wireConfig, conn net.Conn) *wireClient {
+	return &wireClient{
+		cfg:     cfg,
+		conn:    conn,
+		sem:     make(chan struct{}, 1),
+		streams: make(map[string]*wireStream),
+		cursors: make(map[int64]wireNamespace),
+	}
+}
+
+// dialWire connects to the writable primary among the hosts of uri and
+// authenticates if the URI has credentials. See the limitations above.
+func dialWire(ctx context.Context, uri string, timeout time.Duration) (*wireClient, error) {
+	cfg, err := parseWireURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	cfg.timeout = timeout
+
+	w, err := connectPrimary(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.username != "" {
+		if err := w.authenticate(ctx); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// connectPrimary connects to the host that reports itself as the writable
+// primary, trying the hosts of cfg in order and then any primary a
+// secondary names. With cfg.direct it connects to the first reachable host.
+func connectPrimary(ctx context.Context, cfg *wireConfig) (*wireClient, error) {
+	hosts := append([]string(nil), cfg.hosts...)
+	tried := make(map[string]bool, len(hosts))
+	var lastErr error
+	for i := 0; i < len(hosts); i++ {
+		host := hosts[i]
+		if tried[host] {
+			continue
+		}
+		tried[host] = true
+
+		conn, err := dialWireHost(ctx, host, cfg.tls)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		w := newWireClient(cfg, conn)
+		if cfg.direct {
+			return w, nil
+		}
+
+		reply, err := w.command(ctx, "admin", bsonDoc{{"hello", 1}})
+		if err != nil {
+			w.Close()
+			lastErr = err
+			continue
+		}
+		if asBool(reply["isWritablePrimary"]) {
+			return w, nil
+		}
+		w.Close()
+		if primary, ok := reply["primary"].(string); ok && primary != "" {
+			hosts = append(hosts, primary)
+		}
+		lastErr = fmt.Errorf("%s is not a writable primary", host)
+	}
+	return nil, fmt.Errorf("mongo: no writable primary among %s: %w", strings.Join(cfg.hosts, ","), lastErr)
+}
+
+// dialWireHost opens a connection to host, over TLS if useTLS is set.
+func dialWireHost(ctx context.Context, host string, useTLS bool) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if !useTLS {
+		return conn, nil
+	}
+	name, _, _ := net.SplitHostPort(host)
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: name})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// Call runs method in the background, bounded only by the configured
+// timeout. See CallContext.
+func (w *wireClient) Call(method string, args ...any) RPCPromise {
+	return w.CallContext(context.Background(), method, args...)
+}
+
+// CallContext runs method in the background under ctx: the connection
+// deadline comes from ctx, and a round trip still running when ctx is done
+// is abandoned.
+func (w *wireClient) CallContext(ctx context.Context, method string, args ...any) RPCPromise {
+	p := &wirePromise{done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		p.result, p.err = w.dispatch(ctx, method, args)
+	}()
+	return p
+}
+
+// Close kills the server cursors still open, best effort, so they do not
+// linger until the server's idle timeout, and closes the connection.
+func (w *wireClient) Close() error {
+	if w.closed.Load() {
+		return nil
+	}
+	w.killOpenCursors()
+	return w.drop()
+}
+
+// drop closes the connection without sending anything more.
+func (w *wireClient) drop() error {
+	if w.closed.Swap(true) {
+		return nil
+	}
+	return w.conn.Close()
+}
+
+// IsConnected reports whether the connection is usable. A network error
+// closes it, so the client can reconnect.
+func (w *wireClient) IsConnected() bool {
+	return !w.closed.Load()
+}
+
+// wirePromise is the RPCPromise of a wire call.
+type wirePromise struct {
+	done   chan struct{}
+	result any
+	err    error
+}
+
+func (p *wirePromise) Await() (any, error) {
+	<-p.done
+	return p.result, p.err
+}
+
+// command runs cmd against db and returns the reply. Replies with ok: 0
+// become a CommandError; write errors become a WriteError or BulkWriteError.
+func (w *wireClient) command(ctx context.Context, db string, cmd bsonDoc) (map[string]any, error) {
+	return w.orderedCommand(ctx, db, cmd, nil)
+}
+
+// orderedCommand runs cmd like command, decoding the embedded documents of
+// the reply that ordered selects as bson.D; see unmarshalBSONOrdered.
+func (w *wireClient) orderedCommand(ctx context.Context, db string, cmd bsonDoc, ordered func(path []string) bool) (map[string]any, error) {
+	cmd = append(cmd, bsonElem{"$db", db})
+	reply, err := w.roundTrip(ctx, cmd, ordered)
+	if err != nil {
+		return nil, err
+	}
+	if err := serverError(reply); err != nil {
+		return nil, err
+	}
+	if ok, _ := asFloat64(reply["ok"]); ok != 1 {
+		msg, _ := reply["errmsg"].(string)
+		return nil, &CommandError{Message: msg}
+	}
+	if err := wireWriteErrors(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// wireWriteErrors returns the write or write concern errors of a reply.
+func wireWriteErrors(reply map[string]any) error {
+	if raw, ok := reply["writeErrors"].([]any); ok && len(raw) > 0 {
+		errs := make(WriteErrors, 0, len(raw))
+		for _, r := range raw {
+			doc, _ := r.(map[string]any)
+			index, _ := asInt64(doc["index"])
+			code, _ := asInt64(doc["code"])
+			msg, _ := doc["errmsg"].(string)
+			errs = append(errs, WriteError{Index: int(index), Code: int(code), Message: msg})
+		}
+		if len(errs) == 1 {
+			return &errs[0]
+		}
+		return &BulkWriteError{WriteErrors: errs}
+	}
+	if wce, ok := reply["writeConcernError"].(map[string]any); ok {
+		code, _ := asInt64(wce["code"])
+		name, _ := wce["codeName"].(string)
+		msg, _ := wce["errmsg"].(string)
+		return &CommandError{Code: int(code), Name: name, Message: msg}
+	}
+	return nil
+}
+
+// roundTrip sends cmd as an OP_MSG and reads the reply document. When ctx
+// is done while the exchange runs, the connection is interrupted and
+// dropped, as its stream position is then unknown.
+func (w *wireClient) roundTrip(ctx context.Context, cmd bsonDoc, ordered func(path []string) bool) (map[string]any, error) {
+	body, err := marshalBSON(cmd)
+	if err != nil {
+		return nil, err
+	}
+	requestID := w.requestID.Add(1)
+
+	var msg bytes.Buffer
+	writeUint32(&msg, uint32(16+4+1+len(body)))
+	writeUint32(&msg, uint32(requestID))
+	writeUint32(&msg, 0)
+	writeUint32(&msg, opMsg)
+	writeUint32(&msg, 0)
+	msg.WriteByte(0)
+	msg.Write(body)
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-w.sem }()
+	if w.closed.Load() {
+		return nil, ErrClientDisconnected
+	}
+	w.conn.SetDeadline(w.deadline(ctx, cmd))
+
+	if done := ctx.Done(); done != nil {
+		finished := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			select {
+			case <-done:
+				// A deadline in the past unblocks the pending read or write.
+				w.conn.SetDeadline(time.Unix(1, 0))
+			case <-finished:
+			}
+		}()
+		defer func() {
+			close(finished)
+			<-stopped
+		}()
+	}
+
+	reply, err := w.exchange(msg.Bytes(), requestID, ordered)
+	if err != nil {
+		// The stream position is unknown after a failure; drop the
+		// connection so the client reconnects.
+		w.drop()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &ConnectionError{Address: w.conn.RemoteAddr().String(), Wrapped: err}
+	}
+	return reply, nil
+}
+
+// deadline returns the connection deadline for cmd: the deadline of ctx or,
+// without one, the command's maxTimeMS plus wireDeadlineSlack, or else the
+// configured timeout. The zero time means no deadline.
+func (w *wireClient) deadline(ctx context.Context, cmd bsonDoc) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	for _, e := range cmd {
+		if e.Key != "maxTimeMS" {
+			continue
+		}
+		if ms, ok := asInt64(e.Value); ok && ms > 0 {
+			return time.Now().Add(time.Duration(ms)*time.Millisecond + wireDeadlineSlack)
+		}
+	}
+	if w.cfg.timeout > 0 {
+		return time.Now().Add(w.cfg.timeout)
+	}
+	return time.Time{}
+}
+
+// exchange writes msg and reads the reply to requestID.
+func (w *wireClient) exchange(msg []byte, requestID int32, ordered func(path []string) bool) (map[string]any, error) {
+	if _, err := w.conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	var header [16]byte
+	if _, err := io.ReadFull(w.conn, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header[0:])
+	responseTo := int32(binary.LittleEndian.Uint32(header[8:]))
+	opCode := binary.LittleEndian.Uint32(header[12:])
+	if length < 16+5 || length > 64<<20 {
+		return nil, fmt.Errorf("invalid reply length %d", length)
+	}
+	body := make([]byte, length-16)
+	if _, err := io.ReadFull(w.conn, body); err != nil {
+		return nil, err
+	}
+	if opCode != opMsg || responseTo != requestID {
+		return nil, fmt.Errorf("unexpected reply (opcode %d, responseTo %d)", opCode, responseTo)
+	}
+
+	flags := binary.LittleEndian.Uint32(body)
+	body = body[4:]
+	if flags&opMsgChecksumPresent != 0 {
+		if len(body) < 4 {
+			return nil, fmt.Errorf("truncated reply checksum")
+		}
+		body = body[:len(body)-4]
+	}
+	for len(body) > 0 {
+		kind := body[0]
+		body = body[1:]
+		if len(body) < 4 {
+			return nil, fmt.Errorf("truncated reply section")
+		}
+		size := int(binary.LittleEndian.Uint32(body))
+		if size > len(body) {
+			return nil, fmt.Errorf("truncated reply section")
+		}
+		if kind == 0 {
+			return unmarshalBSONOrdered(body[:size], ordered)
+		}
+		body = body[size:]
+	}
+	return nil, fmt.Errorf("reply has no body section")
+}
+
+// authenticate runs the SCRAM conversation for the URI credentials.
+func (w *wireClient) authenticate(ctx context.Context) error {
+	var scram *scramClient
+	switch w.cfg.mechanism {
+	case "", "SCRAM-SHA-256":
+		scram = &scramClient{mechanism: "SCRAM-SHA-256", hash: sha256.New, password: w.cfg.password}
+	case "SCRAM-SHA-1":
+		digest := md5.Sum([]byte(w.cfg.username + ":mongo:" + w.cfg.password))
+		scram = &scramClient{mechanism: "SCRAM-SHA-1", hash: sha1.New, password: hex.EncodeToString(digest[:])}
+	default:
+		return fmt.Errorf("mongo: auth mechanism %s is not supported by the wire protocol backend", w.cfg.mechanism)
+	}
+	scram.username = w.cfg.username
+
+	first, err := scram.clientFirst()
+	if err != nil {
+		return err
+	}
+	reply, err := w.command(ctx, w.cfg.authSource, bsonDoc{
+		{"saslStart", 1},
+		{"mechanism", scram.mechanism},
+		{"payload", first},
+		{"autoAuthorize", 1},
+		{"options", bsonDoc{{"skipEmptyExchange", true}}},
+	})
+	if err != nil {
+		return fmt.Errorf("mongo: authentication failed: %w", err)
+	}
+
+	final, err := scram.clientFinal(binaryBytes(reply["payload"]))
+	if err != nil {
+		return err
+	}
+	for step := 0; step < 2 && !asBool(reply["done"]); step++ {
+		reply, err = w.command(ctx, w.cfg.authSource, bsonDoc{
+			{"saslContinue", 1},
+			{"conversationId", reply["conversationId"]},
+			{"payload", final},
+		})
+		if err != nil {
+			return fmt.Errorf("mongo: authentication failed: %w", err)
+		}
+		if final != nil {
+			if err := scram.verifyServer(binaryBytes(reply["payload"])); err != nil {
+				return err
+			}
+		}
+		final = []byte{}
+	}
+	if !asBool(reply["done"]) {
+		return fmt.Errorf("mongo: authentication failed: conversation did not complete")
+	}
+	return nil
+}
+
+// binaryBytes returns the bytes of a decoded BSON binary value.
+func binaryBytes(v any) []byte {
+	doc, _ := v.(map[string]any)
+	bin, _ := doc["$binary"].(map[string]any)
+	s, _ := bin["base64"].(string)
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return b
+}
+
+// scramClient is the client side of a SCRAM-SHA-1 or SCRAM-SHA-256
+// conversation (RFC 5802, RFC 7677).
+type scramClient struct {
+	mechanism string
+	hash      func() hash.Hash
+	username  string
+	password  string
+
+	nonce       string
+	firstBare   string
+	authMessage string
+	salted      []byte
+}
+
+// clientFirst returns the client-first message.
+func (s *scramClient) clientFirst() ([]byte, error) {
+	var raw [24]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, err
+	}
+	s.nonce = base64.StdEncoding.EncodeToString(raw[:])
+	name := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s.username)
+	s.firstBare = "n=" + name + ",r=" + s.nonce
+	return []byte("n,," + s.firstBare), nil
+}
+
+// clientFinal returns the client-final message answering serverFirst.
+func (s *scramClient) clientFinal(serverFirst []byte) ([]byte, error) {
+	attrs := scramAttributes(string(serverFirst))
+	nonce, salt64, iterations := attrs["r"], attrs["s"], attrs["i"]
+	if !strings.HasPrefix(nonce, s.nonce) {
+		return nil, errors.New("mongo: authentication failed: server nonce mismatch")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: authentication failed: invalid salt: %w", err)
+	}
+	var iter int
+	if _, err := fmt.Sscan(iterations, &iter); err != nil || iter < 1 {
+		return nil, fmt.Errorf("mongo: authentication failed: invalid iteration count %q", iterations)
+	}
+
+	s.salted = pbkdf2([]byte(s.password), salt, iter, s.hash().Size(), s.hash)
+	clientKey := s.hmac(s.salted, "Client Key")
+	h := s.hash()
+	h.Write(clientKey)
+	storedKey := h.Sum(nil)
+
+	withoutProof := "c=biws,r=" + nonce
+	s.authMessage = s.firstBare + "," + string(serverFirst) + "," + withoutProof
+	signature := s.hmac(storedKey, s.authMessage)
+	proof := make([]byte, len(clientKey))
+	for i := range proof {
+		proof[i] = clientKey[i] ^ signature[i]
+	}
+	return []byte(withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+}
+
+// verifyServer checks the server signature of the server-final message.
+func (s *scramClient) verifyServer(serverFinal []byte) error {
+	attrs := scramAttributes(string(serverFinal))
+	if e, ok := attrs["e"]; ok {
+		return fmt.Errorf("mongo: authentication failed: %s", e)
+	}
+	got, err := base64.StdEncoding.DecodeString(attrs["v"])
+	if err != nil {
+		return fmt.Errorf("mongo: authentication failed: invalid server signature")
+	}
+	want := s.hmac(s.hmac(s.salted, "Server Key"), s.authMessage)
+	if !hmac.Equal(got, want) {
+		return errors.New("mongo: authentication failed: server signature mismatch")
+	}
+	return nil
+}
+
+func (s *scramClient) hmac(key []byte, msg string) []byte {
+	m := hmac.New(s.hash, key)
+	m.Write([]byte(msg))
+	return m.Sum(nil)
+}
+
+// scramAttributes parses "k=v,k=v" SCRAM attributes.
+func scramAttributes(msg string) map[string]string {
+	attrs := make(map[string]string)
+	for _, part := range strings.Split(msg, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+// pbkdf2 derives a key with PBKDF2 (RFC 8018).
+func pbkdf2(password, salt []byte, iterations, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
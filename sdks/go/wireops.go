@@ -0,0 +1,782 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongo.do/bson"
+)
+
+// This file maps the RPC methods onto database commands for the wire
+// protocol backend, returning results in the shapes the RPC service uses.
+
+// wireArgs are the arguments of an RPC method call.
+type wireArgs []any
+
+// at returns argument i, or nil when it is missing.
+func (a wireArgs) at(i int) any {
+	if i < len(a) {
+		return a[i]
+	}
+	return nil
+}
+
+// str returns argument i as a string.
+func (a wireArgs) str(i int) string {
+	s, _ := a.at(i).(string)
+	return s
+}
+
+// options returns argument i as an options map, empty when it is missing.
+func (a wireArgs) options(i int) map[string]any {
+	if m, ok := a.at(i).(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+// errWireUnsupported returns the error for a method or option the wire
+// protocol backend does not implement.
+func errWireUnsupported(what string) error {
+	return &NotSupportedError{Feature: what}
+}
+
+// dispatch runs an RPC method as database commands.
+func (w *wireClient) dispatch(ctx context.Context, method string, args wireArgs) (any, error) {
+	db, coll := args.str(0), args.str(1)
+
+	switch method {
+	case "mongo.ping":
+		return w.command(ctx, "admin", bsonDoc{{"ping", 1}})
+	case "mongo.hello":
+		return w.hello(ctx, args.at(0))
+	case "mongo.refreshSessions":
+		return w.command(ctx, "admin", bsonDoc{{"refreshSessions", args.at(0)}})
+	case "mongo.endSessions":
+		return w.command(ctx, "admin", bsonDoc{{"endSessions", args.at(0)}})
+
+	case "mongo.find":
+		opts := args.options(3)
+		cmd := bsonDoc{{"find", coll}, {"filter", wireFilter(args.at(2))}}
+		return w.cursorAll(ctx, db, coll, commandOptions(cmd, opts))
+	case "mongo.findOne":
+		opts := args.options(3)
+		cmd := bsonDoc{{"find", coll}, {"filter", wireFilter(args.at(2))}, {"limit", 1}, {"singleBatch", true}}
+		docs, err := w.cursorAll(ctx, db, coll, commandOptions(cmd, opts, "limit"))
+		if err != nil || len(docs) == 0 {
+			return nil, err
+		}
+		return docs[0], nil
+	case "mongo.aggregate":
+		return w.cursorAll(ctx, db, coll, commandOptions(aggregateCommand(coll, args.at(2)), args.options(3)))
+	case "mongo.countDocuments":
+		return w.countDocuments(ctx, db, coll, args.at(2), args.options(3))
+	case "mongo.estimatedDocumentCount":
+		reply, err := w.command(ctx, db, commandOptions(bsonDoc{{"count", coll}}, args.options(2)))
+		if err != nil {
+			return nil, err
+		}
+		return reply["n"], nil
+	case "mongo.distinct":
+		cmd := bsonDoc{{"distinct", coll}, {"key", args.str(2)}, {"query", wireFilter(args.at(3))}}
+		reply, err := w.command(ctx, db, commandOptions(cmd, args.options(4)))
+		if err != nil {
+			return nil, err
+		}
+		return reply["values"], nil
+
+	case "mongo.insertOne":
+		doc, id, err := wireDocumentWithID(args.at(2))
+		if err != nil {
+			return nil, err
+		}
+		cmd := bsonDoc{{"insert", coll}, {"documents", []any{doc}}}
+		reply, err := w.command(ctx, db, commandOptions(cmd, args.options(3)))
+		if err != nil {
+			return nil, err
+		}
+		return withOperationTime(map[string]any{"insertedId": id}, reply), nil
+	case "mongo.insertMany":
+		return w.insertMany(ctx, db, coll, args.at(2), args.options(3))
+	case "mongo.updateOne", "mongo.updateMany", "mongo.replaceOne":
+		return w.update(ctx, db, coll, args.at(2), args.at(3), method == "mongo.updateMany", args.options(4))
+	case "mongo.deleteOne", "mongo.deleteMany":
+		return w.delete(ctx, db, coll, args.at(2), method == "mongo.deleteMany", args.options(3))
+	case "mongo.findOneAndUpdate", "mongo.findOneAndReplace":
+		return w.findAndModify(ctx, db, coll, args.at(2), args.at(3), args.options(4))
+	case "mongo.findOneAndDelete":
+		return w.findAndModify(ctx, db, coll, args.at(2), nil, args.options(3))
+	case "mongo.bulkWrite":
+		return w.bulkWrite(ctx, db, coll, args.at(2), args.options(3))
+
+	case "mongo.listIndexes":
+		docs, err := w.orderedCursorAll(ctx, db, coll, bsonDoc{{"listIndexes", coll}, {"cursor", bsonDoc{}}}, indexKeyPath)
+		if errors.Is(err, ErrNamespaceNotFound) {
+			return []any{}, nil
+		}
+		return docs, err
+	case "mongo.createIndex":
+		return w.createIndex(ctx, db, coll, args.at(2), args.options(3))
+	case "mongo.dropIndex":
+		return w.command(ctx, db, bsonDoc{{"dropIndexes", coll}, {"index", args.str(2)}})
+	case "mongo.dropCollection":
+		_, err := w.command(ctx, db, bsonDoc{{"drop", coll}})
+		if errors.Is(err, ErrNamespaceNotFound) {
+			err = nil
+		}
+		return nil, err
+	case "mongo.createCollection":
+		return w.command(ctx, db, commandOptions(bsonDoc{{"create", coll}}, args.options(2)))
+	case "mongo.renameCollection":
+		cmd := bsonDoc{{"renameCollection", db + "." + coll}, {"to", db + "." + args.str(2)}}
+		return w.command(ctx, "admin", commandOptions(cmd, args.options(3)))
+	case "mongo.dropDatabase":
+		return w.command(ctx, db, bsonDoc{{"dropDatabase", 1}})
+	case "mongo.listCollections":
+		options := args.options(1)
+		if nameOnly, ok := options["nameOnly"].(bool); ok && !nameOnly {
+			cmd := bsonDoc{{"listCollections", 1}}.appendOpt("filter", options["filter"])
+			return w.cursorAll(ctx, db, "$cmd.listCollections", append(cmd, bsonElem{"cursor", bsonDoc{}}))
+		}
+		docs, err := w.cursorAll(ctx, db, "$cmd.listCollections", bsonDoc{{"listCollections", 1}, {"nameOnly", true}, {"cursor", bsonDoc{}}})
+		return wireNames(docs), err
+	case "mongo.listDatabases":
+		reply, err := w.command(ctx, "admin", bsonDoc{{"listDatabases", 1}, {"nameOnly", true}})
+		if err != nil {
+			return nil, err
+		}
+		databases, _ := reply["databases"].([]any)
+		return wireNames(databases), nil
+	case "mongo.runCommand":
+		cmd, err := wireCommand(args.at(1))
+		if err != nil {
+			return nil, err
+		}
+		// Fields the command sets itself win over the options.
+		own := make([]string, len(cmd))
+		for i, e := range cmd {
+			own[i] = e.Key
+		}
+		return w.command(ctx, db, commandOptions(cmd, args.options(2), own...))
+
+	case "mongo.watch":
+		return w.watch(ctx, db, coll, args.at(2), args.options(3))
+	case "mongo.changeStreamNext":
+		return w.changeStreamNext(ctx, args.str(0))
+	case "mongo.changeStreamClose":
+		return nil, w.changeStreamClose(ctx, args.str(0))
+	}
+	return nil, errWireUnsupported(method)
+}
+
+// commandOptions appends the RPC options to cmd, except the skipped keys.
+// The read preference becomes $readPreference and SDK-only keys are dropped.
+func commandOptions(cmd bsonDoc, options map[string]any, skip ...string) bsonDoc {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+next:
+	for _, k := range keys {
+		for _, s := range skip {
+			if k == s {
+				continue next
+			}
+		}
+		switch k {
+		case "$client":
+		case "readPreference":
+			cmd = append(cmd, bsonElem{"$readPreference", options[k]})
+		default:
+			cmd = append(cmd, bsonElem{k, options[k]})
+		}
+	}
+	return cmd
+}
+
+// wireFilter returns filter, or an empty document for a nil filter.
+func wireFilter(filter any) any {
+	if filter == nil {
+		return bsonDoc{}
+	}
+	return filter
+}
+
+// aggregateCommand returns the aggregate command for pipeline on coll, or
+// on the database when coll is empty.
+func aggregateCommand(coll string, pipeline any) bsonDoc {
+	var target any = coll
+	if coll == "" {
+		target = 1
+	}
+	if pipeline == nil {
+		pipeline = []any{}
+	}
+	return bsonDoc{{"aggregate", target}, {"pipeline", pipeline}, {"cursor", bsonDoc{}}}
+}
+
+// cursorAll runs a cursor-returning command and drains the cursor. The
+// whole result is materialized before it is returned, as the RPC service
+// does, so options that shape a live cursor, such as noCursorTimeout and
+// batchSize, have no effect beyond the size of each getMore round trip.
+func (w *wireClient) cursorAll(ctx context.Context, db, coll string, cmd bsonDoc) ([]any, error) {
+	return w.orderedCursorAll(ctx, db, coll, cmd, nil)
+}
+
+// indexKeyPath selects the key patterns of listIndexes batches, which must
+// keep their order to describe compound indexes.
+func indexKeyPath(path []string) bool {
+	return len(path) == 4 && path[0] == "cursor" && path[3] == "key"
+}
+
+// orderedCursorAll runs a cursor-returning command like cursorAll, decoding
+// the embedded documents ordered selects as bson.D. If a getMore fails, the
+// cursor is killed so it does not linger on the server until it times out.
+func (w *wireClient) orderedCursorAll(ctx context.Context, db, coll string, cmd bsonDoc, ordered func(path []string) bool) ([]any, error) {
+	reply, err := w.orderedCommand(ctx, db, cmd, ordered)
+	if err != nil {
+		return nil, err
+	}
+	cursor, _ := reply["cursor"].(map[string]any)
+	docs, _ := cursor["firstBatch"].([]any)
+	id := wireCursorID(cursor["id"])
+	if ns, ok := cursor["ns"].(string); ok {
+		if _, c, found := strings.Cut(ns, "."); found {
+			coll = c
+		}
+	}
+
+	w.trackCursor(id, db, coll)
+	for id != 0 {
+		reply, err := w.orderedCommand(ctx, db, bsonDoc{{"getMore", id}, {"collection", coll}}, ordered)
+		if err != nil {
+			w.killCursor(db, coll, id)
+			return nil, err
+		}
+		cursor, _ := reply["cursor"].(map[string]any)
+		batch, _ := cursor["nextBatch"].([]any)
+		docs = append(docs, batch...)
+		if next := wireCursorID(cursor["id"]); next != id {
+			w.untrackCursor(id)
+			id = next
+		}
+	}
+	if docs == nil {
+		docs = []any{}
+	}
+	return docs, nil
+}
+
+// trackCursor records the open server cursor id on db.coll, so Close can
+// kill it.
+func (w *wireClient) trackCursor(id int64, db, coll string) {
+	if id == 0 {
+		return
+	}
+	w.cursorsMu.Lock()
+	defer w.cursorsMu.Unlock()
+	w.cursors[id] = wireNamespace{db: db, coll: coll}
+}
+
+// untrackCursor forgets the server cursor id, once exhausted or killed.
+func (w *wireClient) untrackCursor(id int64) {
+	w.cursorsMu.Lock()
+	defer w.cursorsMu.Unlock()
+	delete(w.cursors, id)
+}
+
+// killCursor kills cursor id on coll, best effort. It runs under its own
+// timeout, as the context of the failed operation may already be done. It
+// cannot run once the connection is lost; the server then reaps the cursor
+// after its idle timeout.
+func (w *wireClient) killCursor(db, coll string, id int64) {
+	w.untrackCursor(id)
+	if w.closed.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), wireKillCursorsTimeout)
+	defer cancel()
+	w.command(ctx, db, bsonDoc{{"killCursors", coll}, {"cursors", []any{id}}})
+}
+
+// killOpenCursors kills every tracked cursor, best effort, with one
+// killCursors command per namespace, all under wireKillCursorsTimeout.
+func (w *wireClient) killOpenCursors() {
+	w.cursorsMu.Lock()
+	byNamespace := make(map[wireNamespace][]any)
+	for id, ns := range w.cursors {
+		byNamespace[ns] = append(byNamespace[ns], id)
+	}
+	w.cursors = make(map[int64]wireNamespace)
+	w.cursorsMu.Unlock()
+	if len(byNamespace) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wireKillCursorsTimeout)
+	defer cancel()
+	for ns, ids := range byNamespace {
+		if _, err := w.command(ctx, ns.db, bsonDoc{{"killCursors", ns.coll}, {"cursors", ids}}); err != nil && w.closed.Load() {
+			return
+		}
+	}
+}
+
+// wireCursorID returns a decoded cursor ID as an int64.
+func wireCursorID(v any) int64 {
+	n, _ := asInt64(v)
+	return n
+}
+
+// wireNames returns the names of listCollections or listDatabases entries.
+func wireNames(docs []any) []any {
+	names := make([]any, 0, len(docs))
+	for _, d := range docs {
+		if doc, ok := d.(map[string]any); ok {
+			names = append(names, doc["name"])
+		}
+	}
+	return names
+}
+
+// hello runs the hello command with the client metadata and reports the
+// server limits in the mongo.hello reply shape, along with the version from buildInfo.
+// Replica sets and sharded clusters support change streams and
+// transactions; standalone servers support neither.
+func (w *wireClient) hello(ctx context.Context, metadata any) (any, error) {
+	cmd := bsonDoc{{"hello", 1}}.appendOpt("client", metadata)
+	reply, err := w.command(ctx, "admin", cmd)
+	if err != nil {
+		return nil, err
+	}
+	features := []any{}
+	if _, replicaSet := reply["setName"]; replicaSet || reply["msg"] == "isdbgrid" {
+		features = append(features, FeatureChangeStreams, FeatureTransactions)
+	}
+	// The version is informational; a failed buildInfo leaves it out.
+	var version any
+	if info, err := w.command(ctx, "admin", bsonDoc{{"buildInfo", 1}}); err == nil {
+		version = info["version"]
+	}
+	return map[string]any{
+		"version":                      version,
+		"maxPayloadBytes":              reply["maxBsonObjectSize"],
+		"maxBsonObjectSize":            reply["maxBsonObjectSize"],
+		"maxWriteBatchSize":            reply["maxWriteBatchSize"],
+		"maxWireVersion":               reply["maxWireVersion"],
+		"logicalSessionTimeoutMinutes": reply["logicalSessionTimeoutMinutes"],
+		"features":                     features,
+	}, nil
+}
+
+// countDocuments counts the documents matching filter with an aggregation,
+// as the count command does not honour skip and limit in transactions.
+func (w *wireClient) countDocuments(ctx context.Context, db, coll string, filter any, options map[string]any) (any, error) {
+	pipeline := []any{bsonDoc{{"$match", wireFilter(filter)}}}
+	if skip, ok := options["skip"]; ok {
+		pipeline = append(pipeline, bsonDoc{{"$skip", skip}})
+	}
+	if limit, ok := options["limit"]; ok {
+		pipeline = append(pipeline, bsonDoc{{"$limit", limit}})
+	}
+	pipeline = append(pipeline, bsonDoc{{"$group", bsonDoc{{"_id", 1}, {"n", bsonDoc{{"$sum", 1}}}}}})
+
+	docs, err := w.cursorAll(ctx, db, coll, commandOptions(aggregateCommand(coll, pipeline), options, "skip", "limit"))
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return float64(0), nil
+	}
+	doc, _ := docs[0].(map[string]any)
+	return doc["n"], nil
+}
+
+// wireDocumentWithID returns doc as a document with an _id, generating an
+// ObjectID when it has none, as the server would. A bson.D keeps its order,
+// with a generated _id first.
+func wireDocumentWithID(doc any) (any, any, error) {
+	if src, ok := doc.(bson.D); ok {
+		for _, e := range src {
+			if e.Key == "_id" {
+				return src, e.Value, nil
+			}
+		}
+		id := bson.NewObjectID()
+		return append(bson.D{{Key: "_id", Value: id}}, src...), id, nil
+	}
+
+	var m map[string]any
+	if src, ok := doc.(bson.M); ok {
+		doc = map[string]any(src)
+	}
+	if src, ok := doc.(map[string]any); ok {
+		m = make(map[string]any, len(src)+1)
+		for k, v := range src {
+			m[k] = v
+		}
+	} else {
+		generic, err := toGeneric(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m, ok = generic.(map[string]any); !ok {
+			return nil, nil, fmt.Errorf("cannot insert %T as a document", doc)
+		}
+	}
+	if _, ok := m["_id"]; !ok {
+		m["_id"] = bson.NewObjectID()
+	}
+	return m, m["_id"], nil
+}
+
+// insertMany inserts documents and returns their IDs in order.
+func (w *wireClient) insertMany(ctx context.Context, db, coll string, documents any, options map[string]any) (any, error) {
+	var list []any
+	if rv := reflect.ValueOf(documents); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		list = make([]any, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+	}
+
+	var err error
+	docs := make([]any, len(list))
+	ids := make([]any, len(list))
+	for i, d := range list {
+		if docs[i], ids[i], err = wireDocumentWithID(d); err != nil {
+			return nil, err
+		}
+	}
+	cmd := bsonDoc{{"insert", coll}, {"documents", docs}}
+	reply, err := w.command(ctx, db, commandOptions(cmd, options))
+	if err != nil {
+		return nil, err
+	}
+	return withOperationTime(map[string]any{"insertedIds": ids}, reply), nil
+}
+
+// withOperationTime copies the operation time of a write reply, which
+// replica sets and sharded clusters report, to result.
+func withOperationTime(result, reply map[string]any) map[string]any {
+	if t, ok := reply["operationTime"]; ok && t != nil {
+		result["operationTime"] = t
+	}
+	return result
+}
+
+// updateStatement builds an update statement, taking the statement-level
+// options out of options.
+func updateStatement(filter, update any, multi bool, options map[string]any) bsonDoc {
+	stmt := bsonDoc{{"q", wireFilter(filter)}, {"u", update}}
+	if multi {
+		stmt = append(stmt, bsonElem{"multi", true})
+	}
+	stmt = stmt.appendOpt("upsert", options["upsert"])
+	return stmt.appendOpt("arrayFilters", options["arrayFilters"])
+}
+
+// update runs an update command and returns an UpdateResult document.
+func (w *wireClient) update(ctx context.Context, db, coll string, filter, update any, multi bool, options map[string]any) (any, error) {
+	cmd := bsonDoc{{"update", coll}, {"updates", []any{updateStatement(filter, update, multi, options)}}}
+	reply, err := w.command(ctx, db, commandOptions(cmd, options, "upsert", "arrayFilters"))
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := asInt64(reply["n"])
+	modified, _ := asInt64(reply["nModified"])
+	result := map[string]any{"matchedCount": n, "modifiedCount": modified, "upsertedCount": int64(0)}
+	if upserted, ok := reply["upserted"].([]any); ok && len(upserted) > 0 {
+		first, _ := upserted[0].(map[string]any)
+		result["matchedCount"] = n - int64(len(upserted))
+		result["upsertedCount"] = int64(len(upserted))
+		result["upsertedId"] = first["_id"]
+	}
+	return withOperationTime(result, reply), nil
+}
+
+// delete runs a delete command and returns a DeleteResult document.
+func (w *wireClient) delete(ctx context.Context, db, coll string, filter any, many bool, options map[string]any) (any, error) {
+	limit := 1
+	if many {
+		limit = 0
+	}
+	cmd := bsonDoc{{"delete", coll}, {"deletes", []any{bsonDoc{{"q", wireFilter(filter)}, {"limit", limit}}}}}
+	reply, err := w.command(ctx, db, commandOptions(cmd, options))
+	if err != nil {
+		return nil, err
+	}
+	return withOperationTime(map[string]any{"deletedCount": reply["n"]}, reply), nil
+}
+
+// findAndModify runs findAndModify, removing the document when update is
+// nil, and returns the document or nil.
+func (w *wireClient) findAndModify(ctx context.Context, db, coll string, filter, update any, options map[string]any) (any, error) {
+	cmd := bsonDoc{{"findAndModify", coll}, {"query", wireFilter(filter)}}
+	if update == nil {
+		cmd = append(cmd, bsonElem{"remove", true})
+	} else {
+		cmd = append(cmd, bsonElem{"update", update})
+		if options["returnDocument"] == string(ReturnDocumentAfter) {
+			cmd = append(cmd, bsonElem{"new", true})
+		}
+	}
+	cmd = cmd.appendOpt("fields", options["projection"])
+	reply, err := w.command(ctx, db, commandOptions(cmd, options, "returnDocument", "projection"))
+	if err != nil {
+		return nil, err
+	}
+	return reply["value"], nil
+}
+
+// bulkWrite runs the operations of a bulk write in order, stopping at the
+// first error.
+func (w *wireClient) bulkWrite(ctx context.Context, db, coll string, operations any, options map[string]any) (any, error) {
+	ops, ok := operations.([]map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("mongo: invalid bulk write operations %T", operations)
+	}
+
+	var inserted, matched, modified, deleted, upsertedCount int64
+	var operationTime any
+	upserted := make(map[string]any)
+	for i, op := range ops {
+		var result any
+		var err error
+		for name, raw := range op {
+			body, _ := raw.(map[string]any)
+			switch name {
+			case "insertOne":
+				result, err = w.dispatch(ctx, "mongo.insertOne", wireArgs{db, coll, body["document"], options})
+				inserted++
+			case "updateOne", "updateMany":
+				result, err = w.update(ctx, db, coll, body["filter"], body["update"], name == "updateMany", bulkStatementOptions(body, options))
+			case "replaceOne":
+				result, err = w.update(ctx, db, coll, body["filter"], body["replacement"], false, bulkStatementOptions(body, options))
+			case "deleteOne", "deleteMany":
+				result, err = w.delete(ctx, db, coll, body["filter"], name == "deleteMany", options)
+			default:
+				err = errWireUnsupported("bulk operation " + name)
+			}
+		}
+		if err != nil {
+			if we, ok := err.(*WriteError); ok {
+				we.Index = i
+				return nil, &BulkWriteError{WriteErrors: WriteErrors{*we}}
+			}
+			return nil, err
+		}
+
+		r, _ := result.(map[string]any)
+		if n, ok := asInt64(r["matchedCount"]); ok {
+			matched += n
+		}
+		if n, ok := asInt64(r["modifiedCount"]); ok {
+			modified += n
+		}
+		if n, ok := asInt64(r["deletedCount"]); ok {
+			deleted += n
+		}
+		if id, ok := r["upsertedId"]; ok {
+			upserted[strconv.Itoa(i)] = id
+			upsertedCount++
+		}
+		if t, ok := r["operationTime"]; ok {
+			operationTime = t
+		}
+	}
+
+	return withOperationTime(map[string]any{
+		"insertedCount": inserted,
+		"matchedCount":  matched,
+		"modifiedCount": modified,
+		"deletedCount":  deleted,
+		"upsertedCount": upsertedCount,
+		"upsertedIds":   upserted,
+	}, map[string]any{"operationTime": operationTime}), nil
+}
+
+// bulkStatementOptions returns options with the upsert flag of a bulk
+// operation body.
+func bulkStatementOptions(body, options map[string]any) map[string]any {
+	merged := make(map[string]any, len(options)+1)
+	for k, v := range options {
+		merged[k] = v
+	}
+	if upsert, ok := body["upsert"]; ok {
+		merged["upsert"] = upsert
+	}
+	return merged
+}
+
+// createIndex creates an index, naming it from its keys when the options
+// give no name, and returns the name.
+func (w *wireClient) createIndex(ctx context.Context, db, coll string, keys any, options map[string]any) (any, error) {
+	elems, err := bsonElements(keys)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := options["name"].(string)
+	if name == "" {
+		parts := make([]string, 0, 2*len(elems))
+		for _, e := range elems {
+			parts = append(parts, e.Key, fmt.Sprint(e.Value))
+		}
+		name = strings.Join(parts, "_")
+	}
+
+	index := commandOptions(bsonDoc{{"key", elems}, {"name", name}}, options, "name", "writeConcern", "comment")
+	cmd := bsonDoc{{"createIndexes", coll}, {"indexes", []any{index}}}
+	cmd = cmd.appendOpt("writeConcern", options["writeConcern"]).appendOpt("comment", options["comment"])
+	if _, err := w.command(ctx, db, cmd); err != nil {
+		return nil, err
+	}
+	return name, nil
+}
+
+// wireCommandNames are commands RunCommand may be given as an unordered
+// map; the command name must be sent first.
+var wireCommandNames = map[string]bool{
+	"aggregate": true, "buildInfo": true, "collMod": true, "collStats": true,
+	"connectionStatus": true, "count": true, "create": true, "createIndexes": true,
+	"createUser": true, "currentOp": true, "dbStats": true, "delete": true,
+	"distinct": true, "drop": true, "dropDatabase": true, "dropIndexes": true,
+	"dropUser": true, "explain": true, "find": true, "findAndModify": true,
+	"getMore": true, "hello": true, "hostInfo": true, "insert": true,
+	"isMaster": true, "killCursors": true, "killOp": true, "listCollections": true,
+	"listDatabases": true, "listIndexes": true, "ping": true, "renameCollection": true,
+	"replSetGetStatus": true, "serverStatus": true, "update": true, "usersInfo": true,
+	"validate": true,
+}
+
+// wireCommand orders a RunCommand document so its command name comes first.
+func wireCommand(command any) (bsonDoc, error) {
+	elems, err := bsonElements(command)
+	if err != nil {
+		return nil, err
+	}
+	if _, ordered := command.(bsonDoc); ordered {
+		return elems, nil
+	}
+	for i, e := range elems {
+		if wireCommandNames[e.Key] {
+			cmd := append(bsonDoc{e}, elems[:i]...)
+			return append(cmd, elems[i+1:]...), nil
+		}
+	}
+	if len(elems) > 1 {
+		return nil, fmt.Errorf("%w: cannot tell the command name of a %d-field map; use a single-field command", ErrInvalidOption, len(elems))
+	}
+	return elems, nil
+}
+
+// wireStream is an open change stream cursor.
+type wireStream struct {
+	db, coll string
+	cursorID int64
+	buffer   []any
+	token    any
+}
+
+// watch opens a change stream and returns its stream ID.
+func (w *wireClient) watch(ctx context.Context, db, coll string, pipeline any, options map[string]any) (any, error) {
+	if _, ok := options["batchCompression"]; ok {
+		return nil, errWireUnsupported("batch compression")
+	}
+
+	stage := bsonDoc{}
+	for _, key := range []string{"resumeAfter", "startAfter", "startAtOperationTime", "fullDocument", "fullDocumentBeforeChange"} {
+		stage = stage.appendOpt(key, options[key])
+	}
+	stages := []any{bsonDoc{{"$changeStream", stage}}}
+	if generic, err := toGeneric(pipeline); err == nil {
+		if list, ok := generic.([]any); ok {
+			stages = append(stages, list...)
+		}
+	}
+
+	cmd := aggregateCommand(coll, stages)
+	if size, ok := options["batchSize"]; ok {
+		cmd[2].Value = bsonDoc{{"batchSize", size}}
+	}
+	skip := []string{"resumeAfter", "startAfter", "startAtOperationTime", "fullDocument", "fullDocumentBeforeChange", "batchSize"}
+	reply, err := w.command(ctx, db, commandOptions(cmd, options, skip...))
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, _ := reply["cursor"].(map[string]any)
+	stream := &wireStream{db: db, coll: coll, cursorID: wireCursorID(cursor["id"]), token: cursor["postBatchResumeToken"]}
+	stream.buffer, _ = cursor["firstBatch"].([]any)
+	if stream.coll == "" {
+		stream.coll = "$cmd.aggregate"
+	}
+
+	w.trackCursor(stream.cursorID, db, stream.coll)
+	w.streamsMu.Lock()
+	defer w.streamsMu.Unlock()
+	w.streamSeq++
+	id := "wire-" + strconv.Itoa(w.streamSeq)
+	w.streams[id] = stream
+	return id, nil
+}
+
+// changeStreamNext returns the next event of a stream. When the server has
+// no events it returns a heartbeat with the latest resume token, and nil
+// once the cursor is exhausted.
+func (w *wireClient) changeStreamNext(ctx context.Context, streamID string) (any, error) {
+	w.streamsMu.Lock()
+	stream, ok := w.streams[streamID]
+	w.streamsMu.Unlock()
+	if !ok {
+		return nil, ErrCursorClosed
+	}
+
+	if len(stream.buffer) == 0 && stream.cursorID != 0 {
+		reply, err := w.command(ctx, stream.db, bsonDoc{{"getMore", stream.cursorID}, {"collection", stream.coll}})
+		if err != nil {
+			return nil, err
+		}
+		cursor, _ := reply["cursor"].(map[string]any)
+		stream.buffer, _ = cursor["nextBatch"].([]any)
+		if next := wireCursorID(cursor["id"]); next != stream.cursorID {
+			w.untrackCursor(stream.cursorID)
+			stream.cursorID = next
+		}
+		if token, ok := cursor["postBatchResumeToken"]; ok {
+			stream.token = token
+		}
+	}
+
+	if len(stream.buffer) == 0 {
+		if stream.cursorID == 0 {
+			return nil, nil
+		}
+		return map[string]any{"postBatchResumeToken": stream.token}, nil
+	}
+	event := stream.buffer[0]
+	stream.buffer = stream.buffer[1:]
+	return event, nil
+}
+
+// changeStreamClose kills the cursor of a stream.
+func (w *wireClient) changeStreamClose(ctx context.Context, streamID string) error {
+	w.streamsMu.Lock()
+	stream, ok := w.streams[streamID]
+	delete(w.streams, streamID)
+	w.streamsMu.Unlock()
+	if !ok || stream.cursorID == 0 {
+		return nil
+	}
+	w.untrackCursor(stream.cursorID)
+	_, err := w.command(ctx, stream.db, bsonDoc{{"killCursors", stream.coll}, {"cursors", []any{stream.cursorID}}})
+	return err
+}
@@ -0,0 +1,94 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WriteToken identifies the point in the server's history at which a write
+// was applied. Reads issued with a context carrying the token wait until the
+// node serving them has caught up with the write, so a flow that reads back
+// its own write sees it even through load-balanced read endpoints.
+type WriteToken struct {
+	// OperationTime is the cluster time of the write.
+	OperationTime Timestamp
+}
+
+// String encodes the token as "t.i", for passing it between services.
+func (t *WriteToken) String() string {
+	return fmt.Sprintf("%d.%d", t.OperationTime.T, t.OperationTime.I)
+}
+
+// ParseWriteToken decodes a token encoded with WriteToken.String.
+func ParseWriteToken(s string) (*WriteToken, error) {
+	tPart, iPart, ok := strings.Cut(s, ".")
+	t, terr := strconv.ParseUint(tPart, 10, 32)
+	i, ierr := strconv.ParseUint(iPart, 10, 32)
+	if !ok || terr != nil || ierr != nil {
+		return nil, fmt.Errorf("%w: invalid write token %q", ErrInvalidOption, s)
+	}
+	return &WriteToken{OperationTime: Timestamp{T: uint32(t), I: uint32(i)}}, nil
+}
+
+// parseWriteToken returns the token of a write response, or nil when the
+// server did not report an operation time.
+func parseWriteToken(result any) *WriteToken {
+	m, ok := result.(map[string]any)
+	if !ok {
+		return nil
+	}
+	raw, ok := m["operationTime"]
+	if !ok || raw == nil {
+		return nil
+	}
+	ts, err := parseTimestamp(raw)
+	if err != nil || ts.IsZero() {
+		return nil
+	}
+	return &WriteToken{OperationTime: ts}
+}
+
+// writeTokenKey is the context key for read-after-write tokens.
+type writeTokenKey struct{}
+
+// WithWriteToken returns a context whose reads observe the write token
+// identifies. When ctx already carries a later token, that one is kept, so
+// a flow can add the token of every write it makes. A nil token leaves ctx
+// unchanged.
+func WithWriteToken(ctx context.Context, token *WriteToken) context.Context {
+	if token == nil {
+		return ctx
+	}
+	if existing, ok := WriteTokenFromContext(ctx); ok && token.OperationTime.Before(existing.OperationTime) {
+		return ctx
+	}
+	return context.WithValue(ctx, writeTokenKey{}, token)
+}
+
+// WriteTokenFromContext returns the write token carried by ctx.
+func WriteTokenFromContext(ctx context.Context) (*WriteToken, bool) {
+	token, ok := ctx.Value(writeTokenKey{}).(*WriteToken)
+	return token, ok && token != nil
+}
+
+// applyWriteToken adds the write token from ctx to the read concern in
+// options as afterClusterTime.
+func applyWriteToken(ctx context.Context, options map[string]any) {
+	token, ok := WriteTokenFromContext(ctx)
+	if !ok {
+		return
+	}
+	readConcern := map[string]any{}
+	if existing, ok := options["readConcern"].(map[string]any); ok {
+		for k, v := range existing {
+			readConcern[k] = v
+		}
+	}
+	readConcern["afterClusterTime"] = map[string]any{"$timestamp": map[string]any{
+		"t": int64(token.OperationTime.T),
+		"i": int64(token.OperationTime.I),
+	}}
+	options["readConcern"] = readConcern
+}
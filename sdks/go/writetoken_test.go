@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestWriteTokenString tests encoding and decoding tokens.
+func TestWriteTokenString(t *testing.T) {
+	token := &WriteToken{OperationTime: Timestamp{T: 1700000000, I: 3}}
+	if token.String() != "1700000000.3" {
+		t.Errorf("unexpected encoding %q", token.String())
+	}
+	parsed, err := ParseWriteToken(token.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *parsed != *token {
+		t.Errorf("expected %v, got %v", token, parsed)
+	}
+
+	for _, s := range []string{"", "1700000000", "a.b", "1.-1", "99999999999.1"} {
+		if _, err := ParseWriteToken(s); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%q: expected ErrInvalidOption, got %v", s, err)
+		}
+	}
+}
+
+// TestWithWriteToken tests that a context keeps the latest token.
+func TestWithWriteToken(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := WriteTokenFromContext(ctx); ok {
+		t.Fatal("expected no token")
+	}
+
+	later := &WriteToken{OperationTime: Timestamp{T: 10, I: 2}}
+	earlier := &WriteToken{OperationTime: Timestamp{T: 10, I: 1}}
+	ctx = WithWriteToken(ctx, later)
+	ctx = WithWriteToken(ctx, earlier)
+	ctx = WithWriteToken(ctx, nil)
+	if token, _ := WriteTokenFromContext(ctx); token != later {
+		t.Errorf("expected the later token, got %v", token)
+	}
+}
+
+// TestReadAfterWrite tests passing a write's token to a following read.
+func TestReadAfterWrite(t *testing.T) {
+	mock := newMockRPCClient()
+	mock.addCall("mongo.updateOne", map[string]any{
+		"matchedCount":  float64(1),
+		"modifiedCount": float64(1),
+		"operationTime": map[string]any{"$timestamp": map[string]any{"t": float64(1700000000), "i": float64(4)}},
+	}, nil)
+	mock.addCall("mongo.findOne", map[string]any{"_id": "u1", "name": "Ada"}, nil)
+	mock.addCall("mongo.deleteOne", map[string]any{"deletedCount": float64(1)}, nil)
+
+	client := newClientWithRPC(mock, "mongodb://localhost:27017")
+	coll := client.Database("testdb").Collection("users", (&CollectionOptions{}).SetReadConcern(&ReadConcern{Level: ReadConcernMajority}))
+	ctx := context.Background()
+
+	updated, err := coll.UpdateOne(ctx, map[string]any{"_id": "u1"}, map[string]any{"$set": map[string]any{"name": "Ada"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Token == nil || updated.Token.OperationTime != (Timestamp{T: 1700000000, I: 4}) {
+		t.Fatalf("unexpected token: %v", updated.Token)
+	}
+
+	if err := coll.FindOne(WithWriteToken(ctx, updated.Token), map[string]any{"_id": "u1"}).Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	options := mock.calls[1].args[3].(map[string]any)
+	want := map[string]any{
+		"level":            ReadConcernMajority,
+		"afterClusterTime": map[string]any{"$timestamp": map[string]any{"t": int64(1700000000), "i": int64(4)}},
+	}
+	if !reflect.DeepEqual(options["readConcern"], want) {
+		t.Errorf("expected read concern %v, got %v", want, options["readConcern"])
+	}
+
+	deleted, err := coll.DeleteOne(ctx, map[string]any{"_id": "u1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deleted.Token != nil {
+		t.Errorf("expected no token without an operation time, got %v", deleted.Token)
+	}
+}